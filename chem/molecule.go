@@ -0,0 +1,238 @@
+// Package chem draws structural formulas: atoms with element labels joined
+// by single, double, triple and stereo (wedge/hash) bonds.
+//
+// Bonds are trimmed where they meet a labelled atom, the way MetaPost users
+// write "draw (a--b) cutbefore bbox lab_a cutafter bbox lab_b", so the lines
+// stop short of the text. Unlabelled atoms (implicit carbons) are plain
+// vertices.
+//
+// Example:
+//
+//	m := chem.NewMolecule()
+//	c1 := m.Atom("", 0, 0)
+//	c2 := m.Atom("", 26, 15)
+//	o := m.Atom("O", 52, 0)
+//	m.Bond(c1, c2, chem.Single)
+//	m.Bond(c2, o, chem.Double)
+//	pic := m.Picture()
+package chem
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// BondKind selects how a bond is drawn.
+type BondKind int
+
+const (
+	Single BondKind = iota // one line
+	Double                 // two parallel lines
+	Triple                 // three parallel lines
+	Wedge                  // filled wedge, narrow end at the first atom (towards the viewer)
+	Hash                   // hashed wedge, narrow end at the first atom (away from the viewer)
+)
+
+// Standard drawing dimensions in bp.
+const (
+	DefaultBondLength = 30.0 // typical distance between bonded atoms
+	BondSpacing       = 3.0  // distance between the lines of a multiple bond
+	WedgeWidth        = 5.0  // width of the wide end of a stereo bond
+	LabelPadding      = 1.5  // space kept between an atom label and its bonds
+	hashSpacing       = 2.0  // distance between the strokes of a hashed bond
+	bondLineWidth     = 0.6
+)
+
+// Atom is a vertex of a molecule. An empty Symbol is an implicit carbon and
+// draws no label.
+type Atom struct {
+	Symbol string
+	Pos    mp.Point
+}
+
+// Bond joins two atoms.
+type Bond struct {
+	From, To *Atom
+	Kind     BondKind
+	// Side places the second line of a double bond to the left (+1) or the
+	// right (-1) of the axis, shortened, as for bonds inside a ring. The
+	// default 0 centers both lines on the axis.
+	Side int
+}
+
+// Molecule collects atoms and bonds.
+type Molecule struct {
+	Atoms []*Atom
+	Bonds []*Bond
+	Style mp.Style        // line style of the bonds
+	Font  mp.FontRenderer // optional font for exact label bounds
+	Size  float64         // label font size (default mp.DefaultFontSize)
+}
+
+// NewMolecule creates an empty molecule drawn in black.
+func NewMolecule() *Molecule {
+	return &Molecule{
+		Style: mp.Style{Stroke: mp.ColorCSS("black"), StrokeWidth: bondLineWidth},
+		Size:  mp.DefaultFontSize,
+	}
+}
+
+// Atom adds an atom with the given element symbol at (x, y).
+func (m *Molecule) Atom(symbol string, x, y float64) *Atom {
+	a := &Atom{Symbol: symbol, Pos: mp.P(x, y)}
+	m.Atoms = append(m.Atoms, a)
+	return a
+}
+
+// Bond adds a bond of the given kind between two atoms.
+func (m *Molecule) Bond(a, b *Atom, kind BondKind) *Bond {
+	bond := &Bond{From: a, To: b, Kind: kind}
+	m.Bonds = append(m.Bonds, bond)
+	return bond
+}
+
+// Picture returns the structural formula: bonds trimmed around the atom
+// labels, then the labels.
+func (m *Molecule) Picture() *draw.Picture {
+	pic := draw.NewPicture()
+	for _, b := range m.Bonds {
+		for _, path := range m.bondPaths(b) {
+			pic.AddPath(path)
+		}
+	}
+	for _, a := range m.Atoms {
+		if a.Symbol != "" {
+			pic.AddLabel(mp.NewLabel(a.Symbol, a.Pos, mp.AnchorCenter).WithFontSize(m.size()))
+		}
+	}
+	return pic
+}
+
+func (m *Molecule) size() float64 {
+	if m.Size > 0 {
+		return m.Size
+	}
+	return mp.DefaultFontSize
+}
+
+// labelBox returns the padded bounding box of the atom's label as a closed
+// path, or nil for unlabelled atoms.
+func (m *Molecule) labelBox(a *Atom) *mp.Path {
+	if a.Symbol == "" {
+		return nil
+	}
+	var w, h float64
+	if m.Font != nil {
+		w, h = m.Font.TextBounds(a.Symbol, m.size())
+	} else {
+		minX, minY, maxX, maxY := mp.NewLabel(a.Symbol, a.Pos, mp.AnchorCenter).
+			WithFontSize(m.size()).EstimateBounds()
+		w, h = maxX-minX, maxY-minY
+	}
+	w += 2 * LabelPadding
+	h += 2 * LabelPadding
+	return mp.XScaled(w).Then(mp.YScaled(h)).
+		Then(mp.Shifted(a.Pos.X-w/2, a.Pos.Y-h/2)).
+		ApplyToPath(mp.UnitSquare())
+}
+
+// trimmed returns the straight line from p to q cut before the label box of
+// a and after the label box of b, or nil if p or q is not finite.
+func (m *Molecule) trimmed(p, q mp.Point, a, b *Atom) *mp.Path {
+	path, err := mp.StraightPath([]mp.Point{p, q}, false)
+	if err != nil {
+		return nil
+	}
+	if box := m.labelBox(a); box != nil {
+		path = path.CutBefore(box)
+	}
+	if box := m.labelBox(b); box != nil {
+		path = path.CutAfter(box)
+	}
+	path.Style = m.Style
+	return path
+}
+
+// bondPaths returns the paths that draw bond b, none if an atom position
+// is not finite.
+func (m *Molecule) bondPaths(b *Bond) []*mp.Path {
+	p, q := b.From.Pos, b.To.Pos
+	dx, dy := q.X-p.X, q.Y-p.Y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return nil
+	}
+	axis := m.trimmed(p, q, b.From, b.To)
+	if axis == nil {
+		return nil
+	}
+	// Unit left normal of the bond axis.
+	nx, ny := -dy/l, dx/l
+	shifted := func(d float64) *mp.Path {
+		return m.trimmed(mp.P(p.X+nx*d, p.Y+ny*d), mp.P(q.X+nx*d, q.Y+ny*d), b.From, b.To)
+	}
+
+	switch b.Kind {
+	case Double:
+		if b.Side == 0 {
+			return []*mp.Path{shifted(BondSpacing / 2), shifted(-BondSpacing / 2)}
+		}
+		inner := shifted(float64(b.Side) * BondSpacing)
+		// The inner line is shortened at unlabelled ends so it stays
+		// inside the ring.
+		t0, t1 := 0.0, float64(inner.PathLength())
+		short := 0.15 * t1
+		if b.From.Symbol == "" {
+			t0 = short
+		}
+		if b.To.Symbol == "" {
+			t1 -= short
+		}
+		inner = inner.Subpath(t0, t1)
+		inner.Style = m.Style
+		return []*mp.Path{shifted(0), inner}
+	case Triple:
+		return []*mp.Path{shifted(BondSpacing), shifted(0), shifted(-BondSpacing)}
+	case Wedge, Hash:
+		return m.stereoPaths(axis, nx, ny, l, b.Kind)
+	}
+	return []*mp.Path{axis}
+}
+
+// stereoPaths returns a filled wedge or the strokes of a hashed wedge along
+// the trimmed axis, widening linearly from the first atom to WedgeWidth at
+// the second.
+func (m *Molecule) stereoPaths(axis *mp.Path, nx, ny, l float64, kind BondKind) []*mp.Path {
+	sx, sy := axis.PointOf(0)
+	ex, ey := axis.PointOf(float64(axis.PathLength()))
+	halfWidth := func(x, y float64) float64 {
+		// Width grows with the distance along the untrimmed bond.
+		return WedgeWidth / 2 * math.Hypot(x-sx, y-sy) / l
+	}
+	if kind == Wedge {
+		hw := halfWidth(ex, ey)
+		path, _ := draw.NewPath().
+			MoveTo(mp.P(sx, sy)).
+			LineTo(mp.P(ex+nx*hw, ey+ny*hw)).
+			LineTo(mp.P(ex-nx*hw, ey-ny*hw)).
+			Close().
+			Solve()
+		path.Style = mp.Style{Fill: m.Style.Stroke, Stroke: mp.ColorCSS("none")}
+		return []*mp.Path{path}
+	}
+	var paths []*mp.Path
+	length := math.Hypot(ex-sx, ey-sy)
+	for d := hashSpacing; d <= length+1e-9; d += hashSpacing {
+		x, y := sx+(ex-sx)*d/length, sy+(ey-sy)*d/length
+		hw := halfWidth(x, y)
+		path, _ := draw.NewPath().
+			MoveTo(mp.P(x+nx*hw, y+ny*hw)).
+			LineTo(mp.P(x-nx*hw, y-ny*hw)).
+			Solve()
+		path.Style = m.Style
+		paths = append(paths, path)
+	}
+	return paths
+}
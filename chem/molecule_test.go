@@ -0,0 +1,87 @@
+package chem
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBondTrimmedAtLabel(t *testing.T) {
+	m := NewMolecule()
+	c := m.Atom("", 0, 0)
+	o := m.Atom("O", 30, 0)
+	paths := m.bondPaths(m.Bond(c, o, Single))
+	if len(paths) != 1 {
+		t.Fatalf("expected 1 path, got %d", len(paths))
+	}
+	ex, _ := paths[0].PointOf(float64(paths[0].PathLength()))
+	// "O" is estimated 6bp wide, so the bond stops 3 + padding before it.
+	want := 30 - 3 - LabelPadding
+	if math.Abs(ex-want) > 1e-2 {
+		t.Errorf("bond ends at x=%g, want %g", ex, want)
+	}
+	sx, _ := paths[0].PointOf(0)
+	if sx != 0 {
+		t.Errorf("bond at unlabelled atom should not be trimmed, starts at %g", sx)
+	}
+}
+
+func TestMultipleBonds(t *testing.T) {
+	m := NewMolecule()
+	a := m.Atom("", 0, 0)
+	b := m.Atom("", 0, 30)
+	if n := len(m.bondPaths(m.Bond(a, b, Double))); n != 2 {
+		t.Errorf("double bond: %d lines", n)
+	}
+	triple := m.bondPaths(m.Bond(a, b, Triple))
+	if len(triple) != 3 {
+		t.Fatalf("triple bond: %d lines", len(triple))
+	}
+	x0, _ := triple[0].PointOf(0)
+	x2, _ := triple[2].PointOf(0)
+	if math.Abs(math.Abs(x0-x2)-2*BondSpacing) > 1e-9 {
+		t.Errorf("triple bond spread %g, want %g", math.Abs(x0-x2), 2*BondSpacing)
+	}
+	ring := m.Bond(a, b, Double)
+	ring.Side = 1
+	lines := m.bondPaths(ring)
+	_, y0 := lines[1].PointOf(0)
+	if y0 <= 0 {
+		t.Errorf("inner ring line not shortened: starts at y=%g", y0)
+	}
+}
+
+func TestStereoBonds(t *testing.T) {
+	m := NewMolecule()
+	a := m.Atom("", 0, 0)
+	b := m.Atom("", 30, 0)
+	wedge := m.bondPaths(m.Bond(a, b, Wedge))
+	if len(wedge) != 1 || wedge[0].Style.Fill.CSS() == "" || wedge[0].Style.Stroke.CSS() != "none" {
+		t.Fatal("wedge should be one filled path without outline")
+	}
+	hash := m.bondPaths(m.Bond(a, b, Hash))
+	if len(hash) != int(30/hashSpacing) {
+		t.Fatalf("expected %d hash strokes, got %d", int(30/hashSpacing), len(hash))
+	}
+	last := hash[len(hash)-1]
+	_, y1 := last.PointOf(0)
+	_, y2 := last.PointOf(1)
+	if math.Abs(y1-y2-WedgeWidth) > 1e-9 {
+		t.Errorf("last hash stroke %g long, want %g", y1-y2, WedgeWidth)
+	}
+	if m.Picture() == nil {
+		t.Error("nil picture")
+	}
+}
+
+func TestBondNotFinite(t *testing.T) {
+	m := NewMolecule()
+	a := m.Atom("", 0, 0)
+	b := m.Atom("O", math.NaN(), 10)
+	m.Bond(a, b, Double)
+	if paths := m.bondPaths(m.Bonds[0]); len(paths) != 0 {
+		t.Errorf("expected no paths for a NaN atom position, got %d", len(paths))
+	}
+	if n := len(m.Picture().Paths()); n != 0 {
+		t.Errorf("expected no paths in the picture, got %d", n)
+	}
+}
@@ -0,0 +1,152 @@
+package diagram
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Automaton drawing dimensions.
+const (
+	DefaultStateDiameter = 30.0
+	DefaultBend          = 20.0 // bend of parallel and opposite edges in degrees
+
+	acceptingGap    = 3.0  // distance between the two circles of an accepting state
+	initialArrowLen = 20.0 // length of the entry arrow of an initial state
+	loopHeight      = 20.0 // how far a self loop reaches beyond the outline
+)
+
+// State returns the named node as a circular automaton state, creating it if
+// needed.
+//
+// Example:
+//
+//	g := diagram.NewGraph()
+//	g.State("q0").Initial = true
+//	g.State("q1").Accepting = true
+//	g.Connect("q0", "q1").WithLabel("a", 0.5, 0)
+//	g.Connect("q1", "q1").WithLabel("b", 0.5, 0)  // self loop
+//	g.Connect("q1", "q0").WithLabel("c", 0.5, 0)  // bent automatically
+func (g *Graph) State(name string) *Node {
+	n := g.Node(name)
+	n.Shape = ShapeCircle
+	n.Width = DefaultStateDiameter
+	n.Height = DefaultStateDiameter
+	return n
+}
+
+// WithBend sets the edge's bend in degrees; see Edge.Bend.
+func (e *Edge) WithBend(deg float64) *Edge {
+	e.Bend = deg
+	return e
+}
+
+// addStateMarks adds the inner circle of accepting states and the entry
+// arrow of initial states.
+func (n *Node) addStateMarks(pic *draw.Picture) {
+	c := n.Pos.Point()
+	hw, _ := n.bounds()
+	if n.Accepting && n.Shape == ShapeCircle && n.Width > 2*acceptingGap {
+		inner := mp.Scaled(n.Width - 2*acceptingGap).Then(mp.Shifted(c.X, c.Y)).
+			ApplyToPath(mp.FullCircle())
+		inner.Style = n.Style
+		pic.AddPath(inner)
+	}
+	if n.Initial {
+		style := n.Style
+		style.Fill = mp.Color{}
+		style.Arrow = mp.ArrowStyle{End: true, Length: mp.DefaultAHLength, Angle: mp.DefaultAHAngle}
+		if arrow, err := polyline(style, mp.P(c.X-hw-initialArrowLen, c.Y), mp.P(c.X-hw, c.Y)); err == nil {
+			pic.AddPath(arrow)
+		}
+	}
+}
+
+// loopPath returns a self loop leaving and entering the node's outline
+// around the direction LoopAngle.
+func (e *Edge) loopPath() (*mp.Path, error) {
+	n := e.From
+	c := n.Pos.Point()
+	hw, hh := n.bounds()
+	angle := e.LoopAngle
+	if angle == 0 {
+		angle = 90
+	}
+	rad := angle * math.Pi / 180
+	reach := math.Hypot(hw*math.Cos(rad), hh*math.Sin(rad)) + loopHeight
+	apex := mp.P(c.X+reach*math.Cos(rad), c.Y+reach*math.Sin(rad))
+	// Traversed clockwise so that the left normal at the apex points away
+	// from the node and edge labels end up outside the loop. The apex
+	// direction is given explicitly: with a half turn on each side the
+	// solver could otherwise pick either way round.
+	path, err := draw.NewPath().
+		MoveTo(c).
+		CurveToDir(apex, angle+30, angle-90).
+		CurveToDir(c, angle-90, angle+150).
+		Solve()
+	if err != nil {
+		return nil, err
+	}
+	if o := n.Outline(); o != nil {
+		path = path.CutBefore(o)
+		path = path.Reversed().CutBefore(o).Reversed()
+	}
+	path.Style = e.Style
+	return path, nil
+}
+
+// autoBends returns the bend of every edge: the edge's own Bend if set,
+// otherwise DefaultBend steps for edges that share their pair of nodes with
+// other edges, so parallel and opposite edges do not overlap.
+func (g *Graph) autoBends() []float64 {
+	type pair struct{ a, b int }
+	groups := make(map[pair][]int)
+	for i, e := range g.Edges {
+		a, b := e.From.index, e.To.index
+		if a == b {
+			continue
+		}
+		if a > b {
+			a, b = b, a
+		}
+		groups[pair{a, b}] = append(groups[pair{a, b}], i)
+	}
+	bends := make([]float64, len(g.Edges))
+	for i, e := range g.Edges {
+		bends[i] = e.Bend
+	}
+	for _, idx := range groups {
+		if len(idx) < 2 {
+			continue
+		}
+		// Edges in each direction fan out to their own left; if all edges
+		// run the same way they are spread symmetrically.
+		forward, backward := 0, 0
+		first := g.Edges[idx[0]].From
+		for _, i := range idx {
+			if g.Edges[i].From == first {
+				forward++
+			} else {
+				backward++
+			}
+		}
+		kf, kb := 0, 0
+		for _, i := range idx {
+			if g.Edges[i].Bend != 0 {
+				continue
+			}
+			if backward == 0 {
+				bends[i] = (float64(kf) - float64(forward-1)/2) * DefaultBend
+				kf++
+			} else if g.Edges[i].From == first {
+				kf++
+				bends[i] = float64(kf) * DefaultBend
+			} else {
+				kb++
+				bends[i] = float64(kb) * DefaultBend
+			}
+		}
+	}
+	return bends
+}
@@ -0,0 +1,57 @@
+package diagram
+
+import (
+	"testing"
+)
+
+func TestAutomatonSelfLoop(t *testing.T) {
+	g := NewGraph()
+	g.State("q").At(0, 0)
+	e := g.Connect("q", "q").WithLabel("a", 0.5, 0)
+	if err := g.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	loop, err := e.Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sx, sy := loop.PointOf(0)
+	ex, ey := loop.PointOf(float64(loop.PathLength()))
+	if sx >= 0 || ex <= 0 || sy <= 0 || ey <= 0 {
+		t.Errorf("loop should leave up-left and return up-right: (%g,%g) -> (%g,%g)", sx, sy, ex, ey)
+	}
+	label := LabelAlong(loop, "a", 0.5, 0)
+	if label.Position.Y < DefaultStateDiameter/2+loopHeight-1 {
+		t.Errorf("loop label inside the loop at %v", label.Position)
+	}
+}
+
+func TestAutomatonAutoBend(t *testing.T) {
+	g := NewGraph()
+	g.State("p").At(0, 0)
+	g.State("q").At(100, 0).Accepting = true
+	g.Node("p").Initial = true
+	g.Connect("p", "q")
+	g.Connect("q", "p")
+	g.Connect("p", "q").WithBend(-30)
+	bends := g.autoBends()
+	if bends[0] != DefaultBend || bends[1] != DefaultBend || bends[2] != -30 {
+		t.Errorf("unexpected bends %v", bends)
+	}
+	pic, err := g.Picture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two states, inner circle, initial arrow, three edges.
+	if got := len(pic.Paths()); got != 7 {
+		t.Errorf("expected 7 paths, got %d", got)
+	}
+	// p->q bends to its left (up), q->p to its left (down).
+	fwd, _ := g.Edges[0].path(bends[0])
+	back, _ := g.Edges[1].path(bends[1])
+	_, fy := fwd.PointOf(float64(fwd.PathLength()) / 2)
+	_, by := back.PointOf(float64(back.PathLength()) / 2)
+	if fy <= 0 || by >= 0 {
+		t.Errorf("opposite edges not separated: %g, %g", fy, by)
+	}
+}
@@ -0,0 +1,97 @@
+package diagram
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// DefaultNodePadding is the space between the label of an auto-sized node
+// and its outline.
+const DefaultNodePadding = 4.0
+
+// FitLabel makes the node auto-sized: its outline becomes just large enough
+// for the label plus padding on every side (a circle gets the diagonal of
+// that box as its diameter), but not smaller than MinWidth × MinHeight. The
+// label is measured with the graph's Font if one is set and estimated from
+// the font size otherwise. The size is computed when the graph is laid out
+// or solved, before any position equations are added, so the label and the
+// font may still change after FitLabel.
+//
+// Example:
+//
+//	g := diagram.NewGraph()
+//	g.Font = face
+//	g.Node("parse").FitLabel(diagram.DefaultNodePadding)
+//	g.Node("type check").FitLabel(diagram.DefaultNodePadding).MinSize(60, 0)
+func (n *Node) FitLabel(padding float64) *Node {
+	n.AutoSize = true
+	n.Padding = padding
+	return n
+}
+
+// MinSize sets the smallest size of an auto-sized node.
+func (n *Node) MinSize(width, height float64) *Node {
+	n.MinWidth, n.MinHeight = width, height
+	return n
+}
+
+// FitLabels makes every node of the graph auto-sized with the given
+// padding (see Node.FitLabel). Nodes added later keep their fixed size.
+func (g *Graph) FitLabels(padding float64) *Graph {
+	for _, n := range g.Nodes {
+		n.FitLabel(padding)
+	}
+	return g
+}
+
+// fontSize returns the size of node labels.
+func (g *Graph) fontSize() float64 {
+	if g.FontSize > 0 {
+		return g.FontSize
+	}
+	return mp.DefaultFontSize
+}
+
+// labelSize returns the width and height of a node label.
+func (g *Graph) labelSize(text string) (w, h float64) {
+	if text == "" {
+		return 0, 0
+	}
+	if g.Font != nil {
+		return g.Font.TextBounds(text, g.fontSize())
+	}
+	minX, minY, maxX, maxY := mp.NewLabel(text, mp.P(0, 0), mp.AnchorCenter).
+		WithFontSize(g.fontSize()).EstimateBounds()
+	return maxX - minX, maxY - minY
+}
+
+// sizeNodes sets the size of the auto-sized nodes from their labels. Nodes
+// with the same SizeGroup all get the largest width and height of the
+// group, so rows and columns of them line up.
+func (g *Graph) sizeNodes() {
+	type size struct{ w, h float64 }
+	groups := map[string]size{}
+	for _, n := range g.Nodes {
+		if !n.AutoSize {
+			continue
+		}
+		w, h := g.labelSize(n.Label)
+		w, h = w+2*n.Padding, h+2*n.Padding
+		if n.Shape == ShapeCircle {
+			d := math.Hypot(w, h)
+			w, h = d, d
+		}
+		n.Width, n.Height = math.Max(w, n.MinWidth), math.Max(h, n.MinHeight)
+		if n.SizeGroup != "" {
+			s := groups[n.SizeGroup]
+			groups[n.SizeGroup] = size{math.Max(s.w, n.Width), math.Max(s.h, n.Height)}
+		}
+	}
+	for _, n := range g.Nodes {
+		if n.AutoSize && n.SizeGroup != "" {
+			s := groups[n.SizeGroup]
+			n.Width, n.Height = s.w, s.h
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package diagram
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// fixedFont measures every character as 5 wide and the text as 8 high.
+type fixedFont struct{}
+
+func (fixedFont) TextToPaths(string, mp.TextToPathsOptions) ([]*mp.Path, error) { return nil, nil }
+
+func (fixedFont) TextBounds(text string, _ float64) (float64, float64) {
+	return 5 * float64(len(text)), 8
+}
+
+func TestFitLabel(t *testing.T) {
+	g := NewGraph()
+	g.Font = fixedFont{}
+	g.Node("parse").FitLabel(2)
+	g.Node("x").FitLabel(2).MinSize(20, 0)
+	g.Node("emit")
+	g.Connect("parse", "x")
+	g.Connect("x", "emit")
+	g.LayoutLayered(20, 10)
+	if err := g.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	if p := g.Lookup("parse"); p.Width != 29 || p.Height != 12 {
+		t.Errorf("parse is %g × %g, want 29 × 12", p.Width, p.Height)
+	}
+	if x := g.Lookup("x"); x.Width != 20 || x.Height != 12 {
+		t.Errorf("x is %g × %g, want 20 × 12 (min width)", x.Width, x.Height)
+	}
+	if e := g.Lookup("emit"); e.Width != DefaultNodeWidth {
+		t.Errorf("emit is not auto-sized but has width %g", e.Width)
+	}
+	// The layout spaces the layers by the measured heights.
+	if dy := g.Lookup("parse").Pos.Y() - g.Lookup("x").Pos.Y(); math.Abs(dy-32) > 1e-9 {
+		t.Errorf("layer distance = %g, want 32", dy)
+	}
+}
+
+func TestFitLabelSizeGroup(t *testing.T) {
+	g := NewGraph()
+	g.Font = fixedFont{}
+	for _, name := range []string{"a", "bbbb", "cc"} {
+		g.Node(name).FitLabel(0).SizeGroup = "row"
+	}
+	g.State("q").FitLabel(1)
+	g.sizeNodes()
+	for _, name := range []string{"a", "bbbb", "cc"} {
+		if n := g.Lookup(name); n.Width != 20 || n.Height != 8 {
+			t.Errorf("%s is %g × %g, want 20 × 8", name, n.Width, n.Height)
+		}
+	}
+	if q := g.Lookup("q"); math.Abs(q.Width-math.Hypot(7, 10)) > 1e-9 || q.Width != q.Height {
+		t.Errorf("state diameter = %g, want %g", q.Width, math.Hypot(7, 10))
+	}
+}
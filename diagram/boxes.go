@@ -0,0 +1,193 @@
+package diagram
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Boxes in the style of MetaPost's boxes.mp: every node has compass point
+// variables (boxes.mp: c, n, s, e, w, ne, nw, se, sw) that can appear in
+// equations of the graph's context, BoxJoin relates consecutive nodes, and
+// DrawBoxed, DrawUnboxed and DrawBoxes draw nodes one by one instead of the
+// whole graph.
+
+// anchor is a compass point variable of a node. Its equation is added when
+// the graph is solved, after auto-sized nodes have their final size.
+type anchor struct {
+	at    mp.Anchor
+	v     *draw.Var
+	bound bool
+}
+
+// Anchor returns the point variable at a compass point of the node's
+// outline, named after the label anchors: mp.AnchorTop is the middle of the
+// top side (boxes.mp: n), mp.AnchorUpperRight the top right corner (ne),
+// mp.AnchorCenter the center (c, the same as Pos) and so on. On circles the
+// points lie on the circle. Repeated calls return the same variable. Call
+// Anchor before the graph is solved; the variable is tied to the node's
+// size when it is.
+//
+// Example:
+//
+//	// a.ne = b.nw + (-10, 0): b sits 10 units right of a, tops aligned.
+//	g.Equal(g.Node("a").Anchor(mp.AnchorUpperRight), g.Node("b").Anchor(mp.AnchorUpperLeft), -10, 0)
+func (n *Node) Anchor(at mp.Anchor) *draw.Var {
+	if at == mp.AnchorCenter {
+		return n.Pos
+	}
+	for _, a := range n.anchors {
+		if a.at == at {
+			return a.v
+		}
+	}
+	v := n.graph.ctx.Unknown()
+	n.anchors = append(n.anchors, &anchor{at: at, v: v})
+	return v
+}
+
+// anchorOffset returns the position of a compass point relative to the
+// node's center.
+func (n *Node) anchorOffset(at mp.Anchor) (dx, dy float64) {
+	hw, hh := n.bounds()
+	if n.Shape == ShapeCircle {
+		hw, hh = hw/math.Sqrt2, hh/math.Sqrt2
+		switch at {
+		case mp.AnchorLeft:
+			return -n.Width / 2, 0
+		case mp.AnchorRight:
+			return n.Width / 2, 0
+		case mp.AnchorTop:
+			return 0, n.Width / 2
+		case mp.AnchorBottom:
+			return 0, -n.Width / 2
+		}
+	}
+	switch at {
+	case mp.AnchorLeft:
+		return -hw, 0
+	case mp.AnchorRight:
+		return hw, 0
+	case mp.AnchorTop:
+		return 0, hh
+	case mp.AnchorBottom:
+		return 0, -hh
+	case mp.AnchorUpperLeft:
+		return -hw, hh
+	case mp.AnchorUpperRight:
+		return hw, hh
+	case mp.AnchorLowerLeft:
+		return -hw, -hh
+	case mp.AnchorLowerRight:
+		return hw, -hh
+	}
+	return 0, 0
+}
+
+// bindAnchors adds the equations of the node's compass points.
+func (n *Node) bindAnchors() {
+	ctx := n.graph.ctx
+	for _, a := range n.anchors {
+		if a.bound {
+			continue
+		}
+		ctx.Sum(a.v, n.Pos, ctx.Known(n.anchorOffset(a.at)))
+		a.bound = true
+	}
+}
+
+// Equal constrains a = b + (dx, dy), e.g. between the compass points of
+// two nodes.
+func (g *Graph) Equal(a, b *draw.Var, dx, dy float64) {
+	g.ctx.Diff(g.ctx.Known(dx, dy), a, b)
+}
+
+// BoxJoin sets the equations relating consecutive nodes (boxes.mp:
+// boxjoin): join(prev, n) is called for every node n created from now on,
+// with the node created before it. BoxJoin(nil) stops joining. Chains of
+// nodes built this way lay themselves out once the first one is placed,
+// so they need no layout.
+//
+// Example:
+//
+//	g.BoxJoin(diagram.JoinRight(10))
+//	g.Node("a").At(0, 0)
+//	g.Node("b") // b.w = a.e + (10, 0)
+//	g.Node("c") // c.w = b.e + (10, 0)
+func (g *Graph) BoxJoin(join func(prev, n *Node)) {
+	g.join = join
+}
+
+// JoinRight returns a BoxJoin function that places each node gap units
+// right of the previous one, with their centers level.
+func JoinRight(gap float64) func(prev, n *Node) {
+	return func(prev, n *Node) {
+		n.graph.Equal(n.Anchor(mp.AnchorLeft), prev.Anchor(mp.AnchorRight), gap, 0)
+	}
+}
+
+// JoinBelow returns a BoxJoin function that places each node gap units
+// below the previous one, centered under it.
+func JoinBelow(gap float64) func(prev, n *Node) {
+	return func(prev, n *Node) {
+		n.graph.Equal(n.Anchor(mp.AnchorTop), prev.Anchor(mp.AnchorBottom), 0, -gap)
+	}
+}
+
+// DrawBoxed adds the outlines and labels of the given nodes to pic
+// (boxes.mp: drawboxed). The graph is solved first.
+func (g *Graph) DrawBoxed(pic *draw.Picture, nodes ...*Node) error {
+	if err := g.Solve(); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		g.drawOutline(pic, n)
+		g.drawLabel(pic, n)
+	}
+	return nil
+}
+
+// DrawUnboxed adds only the labels of the given nodes to pic (boxes.mp:
+// drawunboxed). The graph is solved first.
+func (g *Graph) DrawUnboxed(pic *draw.Picture, nodes ...*Node) error {
+	if err := g.Solve(); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		g.drawLabel(pic, n)
+	}
+	return nil
+}
+
+// DrawBoxes adds only the outlines of the given nodes to pic (boxes.mp:
+// drawboxes). The graph is solved first.
+func (g *Graph) DrawBoxes(pic *draw.Picture, nodes ...*Node) error {
+	if err := g.Solve(); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		g.drawOutline(pic, n)
+	}
+	return nil
+}
+
+// drawOutline adds the node's outline and state marks to pic.
+func (g *Graph) drawOutline(pic *draw.Picture, n *Node) {
+	if o := n.Outline(); o != nil {
+		o.Style = n.Style
+		pic.AddPath(o)
+	}
+	n.addStateMarks(pic)
+}
+
+// drawLabel adds the node's label to pic.
+func (g *Graph) drawLabel(pic *draw.Picture, n *Node) {
+	if n.Label == "" {
+		return
+	}
+	label := pic.LabelWithStyle(n.Label, n.Pos.Point(), mp.AnchorCenter)
+	if g.FontSize > 0 {
+		label.WithFontSize(g.FontSize)
+	}
+}
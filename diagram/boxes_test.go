@@ -0,0 +1,73 @@
+package diagram
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func TestBoxJoinChain(t *testing.T) {
+	g := NewGraph()
+	g.Font = fixedFont{}
+	g.BoxJoin(JoinRight(10))
+	a := g.Node("a").FitLabel(2).At(0, 0)
+	b := g.Node("bbbb").FitLabel(2)
+	c := g.Node("cc").FitLabel(2)
+	g.BoxJoin(nil)
+	d := g.Node("d")
+	g.BoxJoin(JoinBelow(5))
+	g.Equal(d.Pos, c.Pos, 0, 0) // no join with c yet: d is the first node
+	e := g.Node("e")
+	ne := a.Anchor(mp.AnchorUpperRight)
+
+	pic := draw.NewPicture()
+	if err := g.DrawBoxed(pic, a, b, c, e); err != nil {
+		t.Fatal(err)
+	}
+	// Widths 9, 24 and 14: centers at 0, 4.5+10+12 and 38.5+10+7.
+	if x := b.Pos.X(); math.Abs(x-26.5) > 1e-9 {
+		t.Errorf("b at x = %g, want 26.5", x)
+	}
+	if x, y := c.Pos.XY(); math.Abs(x-55.5) > 1e-9 || math.Abs(y) > 1e-9 {
+		t.Errorf("c at (%g,%g), want (55.5,0)", x, y)
+	}
+	// e hangs 5 below d, which sits on c.
+	if x, y := e.Pos.XY(); math.Abs(x-55.5) > 1e-9 || math.Abs(y+DefaultNodeHeight+5) > 1e-9 {
+		t.Errorf("e at (%g,%g), want (55.5,%g)", x, y, -DefaultNodeHeight-5)
+	}
+	if x, y := ne.XY(); x != 4.5 || y != 6 {
+		t.Errorf("a.ne = (%g,%g), want (4.5,6)", x, y)
+	}
+	if n := len(pic.Paths()); n != 4 {
+		t.Errorf("DrawBoxed drew %d outlines, want 4", n)
+	}
+	if n := len(pic.Labels()); n != 4 {
+		t.Errorf("DrawBoxed drew %d labels, want 4", n)
+	}
+
+	unboxed := draw.NewPicture()
+	if err := g.DrawUnboxed(unboxed, d); err != nil {
+		t.Fatal(err)
+	}
+	if len(unboxed.Paths()) != 0 || len(unboxed.Labels()) != 1 {
+		t.Error("DrawUnboxed should draw only the label")
+	}
+}
+
+func TestCircleAnchors(t *testing.T) {
+	g := NewGraph()
+	q := g.State("q").At(0, 0)
+	ne, e := q.Anchor(mp.AnchorUpperRight), q.Anchor(mp.AnchorRight)
+	if err := g.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	r := DefaultStateDiameter / 2
+	if x, y := ne.XY(); math.Abs(math.Hypot(x, y)-r) > 1e-9 || math.Abs(x-y) > 1e-9 {
+		t.Errorf("ne = (%g,%g) is not on the circle", x, y)
+	}
+	if x, _ := e.XY(); x != r {
+		t.Errorf("e.x = %g, want %g", x, r)
+	}
+}
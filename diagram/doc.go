@@ -0,0 +1,68 @@
+// Package diagram builds node-and-edge diagrams on top of the draw package.
+//
+// A [Graph] collects named nodes and directed edges. Every node position is a
+// point variable of a [draw.Context], so node coordinates can be fixed by
+// hand, constrained by equations, or computed by one of the automatic
+// layouts:
+//
+//   - [Graph.LayoutLayered]: layered drawing of directed graphs (Sugiyama style)
+//   - [Graph.LayoutTree]: tidy drawing of trees and forests
+//   - [Graph.LayoutForce]: force-directed placement for general graphs
+//
+// Example:
+//
+//	g := diagram.NewGraph()
+//	g.Connect("parse", "check")
+//	g.Connect("parse", "emit")
+//	g.Connect("check", "emit")
+//	g.LayoutLayered(40, 30)
+//	pic, err := g.Picture()
+//
+// Layouts only add equations for nodes whose position is not already fixed
+// with [Node.At], so a few nodes can be pinned and the rest arranged
+// automatically.
+//
+// Edges can carry labels placed along them with [Edge.WithLabel].
+//
+// Nodes have a fixed size by default. [Node.FitLabel] sizes a node to its
+// label instead, measured with the graph's Font if set; the sizes are fixed
+// before a layout adds its equations, so nodes of different sizes are
+// spaced correctly:
+//
+//	g.Font = face
+//	g.FitLabels(diagram.DefaultNodePadding)
+//	g.LayoutLayered(40, 30)
+//
+// # Boxes
+//
+// As in MetaPost's boxes.mp, nodes can be placed by equations between their
+// compass points ([Node.Anchor]) instead of a layout. [Graph.BoxJoin] adds
+// such equations between consecutive nodes, so chains of boxes lay
+// themselves out, and [Graph.DrawBoxed] and [Graph.DrawUnboxed] draw single
+// nodes with or without their outline:
+//
+//	g.BoxJoin(diagram.JoinRight(10))
+//	a := g.Node("a").At(0, 0)
+//	b := g.Node("b")
+//	pic := draw.NewPicture()
+//	err := g.DrawBoxed(pic, a, b)
+//
+// # Automata
+//
+// [Graph.State] creates circular states; set [Node.Accepting] for a double
+// circle and [Node.Initial] for an entry arrow. An edge from a node to itself
+// is drawn as a self loop, and parallel or opposite edges are bent apart
+// automatically.
+//
+// # Sequence Diagrams
+//
+// [Sequence] builds UML-style sequence diagrams row by row:
+//
+//	s := diagram.NewSequence("Client", "Server")
+//	s.Message("Client", "Server", "request").Activate("Server")
+//	s.BeginFrame("loop", "retry")
+//	s.Message("Server", "Server", "backoff")
+//	s.EndFrame()
+//	s.Reply("Server", "Client", "response").Deactivate("Server")
+//	pic, err := s.Picture()
+package diagram
@@ -0,0 +1,93 @@
+package diagram
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// labelBackgroundPadding is the space between a label's text and its
+// background rectangle.
+const labelBackgroundPadding = 1.0
+
+// WithLabel sets the edge's label, its position as a fraction of the edge's
+// arc length and its perpendicular offset. A positive offset puts the label
+// to the left of the direction of travel ("above" a left-to-right edge), a
+// negative one to the right.
+//
+// Example:
+//
+//	g.Connect("ask", "done").WithLabel("yes", 0.5, 2)
+func (e *Edge) WithLabel(text string, frac, offset float64) *Edge {
+	e.Label = text
+	e.LabelPos = frac
+	e.LabelOffset = offset
+	return e
+}
+
+// WithLabelBackground sets a fill drawn behind the edge's label, for labels
+// placed on top of other elements.
+func (e *Edge) WithLabelBackground(c mp.Color) *Edge {
+	e.LabelBackground = c
+	return e
+}
+
+// addLabel adds the edge's label to pic, placed along the drawn path.
+func (e *Edge) addLabel(pic *draw.Picture, path *mp.Path) {
+	if e.Label == "" {
+		return
+	}
+	label := LabelAlong(path, e.Label, e.LabelPos, e.LabelOffset)
+	if label == nil {
+		return
+	}
+	if e.LabelBackground.CSS() != "" {
+		minX, minY, maxX, maxY := label.EstimateBounds()
+		bg := mp.XScaled(maxX - minX + 2*labelBackgroundPadding).
+			Then(mp.YScaled(maxY - minY + 2*labelBackgroundPadding)).
+			Then(mp.Shifted(minX-labelBackgroundPadding, minY-labelBackgroundPadding)).
+			ApplyToPath(mp.UnitSquare())
+		bg.Style = mp.Style{Fill: e.LabelBackground, Stroke: mp.ColorCSS("none")}
+		pic.AddPath(bg)
+	}
+	pic.AddLabel(label)
+}
+
+// LabelAlong returns a label for the point at fraction frac of the arc length
+// of path, pushed offset units along the left normal (right for negative
+// offsets). The anchor is chosen from the normal direction, as with
+// MetaPost's label.top, label.urt, ... suffixes, so the text sits beside the
+// path instead of on it. Returns nil for an empty path.
+func LabelAlong(path *mp.Path, text string, frac, offset float64) *mp.Label {
+	if path == nil || path.Head == nil {
+		return nil
+	}
+	frac = math.Max(0, math.Min(1, frac))
+	t := path.ArcTime(path.ArcLength() * frac)
+	x, y := path.PointOf(t)
+	dx, dy := path.DirectionOf(t)
+	nx, ny := -dy, dx
+	if offset < 0 {
+		nx, ny = dy, -dx
+	}
+	if d := math.Hypot(nx, ny); d > 0 {
+		nx, ny = nx/d, ny/d
+	} else {
+		nx, ny = 0, 1
+	}
+	pos := mp.P(x+nx*math.Abs(offset), y+ny*math.Abs(offset))
+	return mp.NewLabel(text, pos, anchorToward(nx, ny))
+}
+
+// anchorToward returns the label anchor whose offset direction is closest to
+// (dx, dy).
+func anchorToward(dx, dy float64) mp.Anchor {
+	anchors := []mp.Anchor{
+		mp.AnchorRight, mp.AnchorUpperRight, mp.AnchorTop, mp.AnchorUpperLeft,
+		mp.AnchorLeft, mp.AnchorLowerLeft, mp.AnchorBottom, mp.AnchorLowerRight,
+	}
+	a := math.Atan2(dy, dx)
+	i := int(math.Round(a/(math.Pi/4))+8) % 8
+	return anchors[i]
+}
@@ -0,0 +1,51 @@
+package diagram
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func TestLabelAlongSides(t *testing.T) {
+	path, err := draw.NewPath().MoveTo(mp.P(0, 0)).LineTo(mp.P(100, 0)).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	above := LabelAlong(path, "yes", 0.5, 2)
+	if above.Anchor != mp.AnchorTop || math.Abs(above.Position.X-50) > 1e-6 || math.Abs(above.Position.Y-2) > 1e-6 {
+		t.Errorf("above: anchor %d at %v", above.Anchor, above.Position)
+	}
+	below := LabelAlong(path, "no", 0.25, -2)
+	if below.Anchor != mp.AnchorBottom || math.Abs(below.Position.X-25) > 1e-6 || below.Position.Y >= 0 {
+		t.Errorf("below: anchor %d at %v", below.Anchor, below.Position)
+	}
+	_, minY, _, _ := above.EstimateBounds()
+	if minY < 0 {
+		t.Errorf("label above the edge overlaps it: minY = %g", minY)
+	}
+}
+
+func TestEdgeLabelBackground(t *testing.T) {
+	g := NewGraph()
+	g.Node("a").At(0, 0)
+	g.Node("b").At(100, 0)
+	g.Connect("a", "b").WithLabel("go", 0.5, 0).WithLabelBackground(mp.ColorCSS("white"))
+	pic, err := g.Picture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pic.Labels()) != 3 {
+		t.Fatalf("expected 3 labels, got %d", len(pic.Labels()))
+	}
+	// Two node outlines, the background and the edge.
+	if len(pic.Paths()) != 4 {
+		t.Fatalf("expected 4 paths, got %d", len(pic.Paths()))
+	}
+	for _, p := range pic.Paths() {
+		if p.Style.Fill.CSS() == "white" && p.Style.Stroke.CSS() != "none" {
+			t.Errorf("label background is stroked with %q", p.Style.Stroke.CSS())
+		}
+	}
+}
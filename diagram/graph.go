@@ -0,0 +1,243 @@
+package diagram
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Shape selects the outline drawn around a node.
+type Shape int
+
+const (
+	ShapeBox    Shape = iota // rectangle of Width × Height
+	ShapeCircle              // circle with diameter Width
+	ShapeNone                // no outline; edges run to the center
+)
+
+// Default node and edge dimensions.
+const (
+	DefaultNodeWidth  = 40.0
+	DefaultNodeHeight = 20.0
+)
+
+// Node is a vertex of a Graph. Its center is the point variable Pos of the
+// graph's context and is only valid after the graph has been solved.
+type Node struct {
+	Name   string   // unique key within the graph
+	Label  string   // text drawn at the center (defaults to Name)
+	Shape  Shape    // outline shape
+	Width  float64  // box width or circle diameter
+	Height float64  // box height (ignored for circles)
+	Style  mp.Style // outline style
+	Pos    *draw.Var
+
+	Accepting bool // draw an inner circle (accepting state of an automaton)
+	Initial   bool // draw an entry arrow from the left (initial state)
+
+	// AutoSize sizes the outline to the label, see FitLabel. Padding is the
+	// space around the label; MinWidth and MinHeight bound the size from
+	// below. Auto-sized nodes with the same non-empty SizeGroup share the
+	// size of the largest of them.
+	AutoSize            bool
+	Padding             float64
+	MinWidth, MinHeight float64
+	SizeGroup           string
+
+	pinned  bool
+	index   int
+	graph   *Graph
+	anchors []*anchor
+}
+
+// Edge is a directed connection between two nodes.
+type Edge struct {
+	From, To *Node
+	Style    mp.Style // stroke style; Arrow.End is set by default
+
+	// Bend is the angle in degrees by which the edge leaves to the left of
+	// the straight connection (negative bends to the right). If zero and
+	// the graph has parallel or opposite edges, a bend is chosen
+	// automatically. LoopAngle is the direction in which a self loop points
+	// (default 90, above the node).
+	Bend      float64
+	LoopAngle float64
+
+	Label           string   // optional text placed along the edge
+	LabelPos        float64  // fraction of the edge's arc length (default 0.5)
+	LabelOffset     float64  // perpendicular shift; positive is left of the direction of travel
+	LabelBackground mp.Color // optional fill behind the label
+}
+
+// Graph is a collection of nodes and directed edges whose node positions
+// live in a draw.Context.
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+
+	Font     mp.FontRenderer // optional font for measuring auto-sized nodes
+	FontSize float64         // node label size (default mp.DefaultFontSize)
+
+	ctx    *draw.Context
+	byName map[string]*Node
+	join   func(prev, n *Node) // see BoxJoin
+}
+
+// NewGraph creates an empty graph with its own equation context.
+func NewGraph() *Graph {
+	return NewGraphWithContext(draw.NewContext())
+}
+
+// NewGraphWithContext creates an empty graph whose node positions are
+// variables of ctx, so they can take part in the caller's equations.
+func NewGraphWithContext(ctx *draw.Context) *Graph {
+	return &Graph{ctx: ctx, byName: make(map[string]*Node)}
+}
+
+// Context returns the equation context holding the node positions.
+func (g *Graph) Context() *draw.Context {
+	return g.ctx
+}
+
+// Node returns the node with the given name, creating it with default
+// dimensions if it does not exist yet.
+func (g *Graph) Node(name string) *Node {
+	if n, ok := g.byName[name]; ok {
+		return n
+	}
+	n := &Node{
+		Name:   name,
+		Label:  name,
+		Width:  DefaultNodeWidth,
+		Height: DefaultNodeHeight,
+		Style:  mp.Style{Stroke: mp.ColorCSS("black"), StrokeWidth: 0.5},
+		Pos:    g.ctx.Unknown(),
+		index:  len(g.Nodes),
+		graph:  g,
+	}
+	g.byName[name] = n
+	g.Nodes = append(g.Nodes, n)
+	if g.join != nil && len(g.Nodes) > 1 {
+		g.join(g.Nodes[len(g.Nodes)-2], n)
+	}
+	return n
+}
+
+// Lookup returns the node with the given name, or nil.
+func (g *Graph) Lookup(name string) *Node {
+	return g.byName[name]
+}
+
+// Connect adds a directed edge between the named nodes, creating them as
+// needed, and returns it.
+func (g *Graph) Connect(from, to string) *Edge {
+	e := &Edge{
+		From: g.Node(from),
+		To:   g.Node(to),
+		Style: mp.Style{
+			Stroke:      mp.ColorCSS("black"),
+			StrokeWidth: 0.5,
+			Arrow:       mp.ArrowStyle{End: true, Length: 4, Angle: 45},
+		},
+		LabelPos: 0.5,
+	}
+	g.Edges = append(g.Edges, e)
+	return e
+}
+
+// At fixes the node's center. Layouts leave pinned nodes where they are.
+func (n *Node) At(x, y float64) *Node {
+	n.Pos.SetXY(x, y)
+	n.pinned = true
+	return n
+}
+
+// Outline returns the node's outline around its solved center, or nil for
+// ShapeNone.
+func (n *Node) Outline() *mp.Path {
+	c := n.Pos.Point()
+	switch n.Shape {
+	case ShapeCircle:
+		t := mp.Scaled(n.Width).Then(mp.Shifted(c.X, c.Y))
+		return t.ApplyToPath(mp.FullCircle())
+	case ShapeBox:
+		t := mp.XScaled(n.Width).Then(mp.YScaled(n.Height)).
+			Then(mp.Shifted(c.X-n.Width/2, c.Y-n.Height/2))
+		return t.ApplyToPath(mp.UnitSquare())
+	}
+	return nil
+}
+
+// bounds returns the half extents of the node used for spacing layouts.
+func (n *Node) bounds() (hw, hh float64) {
+	if n.Shape == ShapeCircle {
+		return n.Width / 2, n.Width / 2
+	}
+	return n.Width / 2, n.Height / 2
+}
+
+// Solve solves the graph's context, fixing all node positions.
+func (g *Graph) Solve() error {
+	g.sizeNodes()
+	for _, n := range g.Nodes {
+		n.bindAnchors()
+	}
+	return g.ctx.Solve()
+}
+
+// Path returns the edge as a solved path from the outline of its start node
+// to the outline of its end node: a straight line, a curve if the edge is
+// bent, or a loop if both ends are the same node. The graph must be solved.
+func (e *Edge) Path() (*mp.Path, error) {
+	return e.path(e.Bend)
+}
+
+func (e *Edge) path(bend float64) (*mp.Path, error) {
+	if e.From == e.To {
+		return e.loopPath()
+	}
+	a, b := e.From.Pos.Point(), e.To.Pos.Point()
+	pb := draw.NewPath().MoveTo(a)
+	if bend == 0 {
+		pb.LineTo(b)
+	} else {
+		angle := math.Atan2(b.Y-a.Y, b.X-a.X) * 180 / math.Pi
+		pb.CurveToDir(b, angle+bend, angle-bend)
+	}
+	path, err := pb.Solve()
+	if err != nil {
+		return nil, err
+	}
+	if o := e.From.Outline(); o != nil {
+		path = path.CutBefore(o)
+	}
+	if o := e.To.Outline(); o != nil {
+		path = path.CutAfter(o)
+	}
+	path.Style = e.Style
+	return path, nil
+}
+
+// Picture solves the graph and returns a picture with node outlines, node
+// labels and edges.
+func (g *Graph) Picture() (*draw.Picture, error) {
+	if err := g.Solve(); err != nil {
+		return nil, err
+	}
+	pic := draw.NewPicture()
+	for _, n := range g.Nodes {
+		g.drawOutline(pic, n)
+		g.drawLabel(pic, n)
+	}
+	bends := g.autoBends()
+	for i, e := range g.Edges {
+		path, err := e.path(bends[i])
+		if err != nil {
+			return nil, err
+		}
+		pic.AddPath(path)
+		e.addLabel(pic, path)
+	}
+	return pic, nil
+}
@@ -0,0 +1,344 @@
+package diagram
+
+import (
+	"math"
+	"sort"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// layeredSweeps is the number of barycenter sweeps used to reduce edge
+// crossings in LayoutLayered.
+const layeredSweeps = 8
+
+// LayoutLayered arranges the graph top-down in layers (a simplified Sugiyama
+// layout): cycles are broken by reversing back edges, each node is placed on
+// the layer after its longest incoming chain, and nodes within a layer are
+// ordered by the barycenter of their neighbours to reduce crossings.
+// layerSep is the vertical gap between layers and nodeSep the horizontal gap
+// between neighbouring nodes. Long edges get no dummy nodes and may cross
+// intermediate layers.
+//
+// The positions are added to the graph's context as equations; call only one
+// layout per graph.
+func (g *Graph) LayoutLayered(layerSep, nodeSep float64) {
+	g.sizeNodes()
+	n := len(g.Nodes)
+	if n == 0 {
+		return
+	}
+	succ, pred := g.acyclicAdjacency()
+
+	// Longest-path layering in topological order.
+	layer := make([]int, n)
+	indeg := make([]int, n)
+	for v := range pred {
+		indeg[v] = len(pred[v])
+	}
+	queue := []int{}
+	for v := 0; v < n; v++ {
+		if indeg[v] == 0 {
+			queue = append(queue, v)
+		}
+	}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, v := range succ[u] {
+			if layer[u]+1 > layer[v] {
+				layer[v] = layer[u] + 1
+			}
+			indeg[v]--
+			if indeg[v] == 0 {
+				queue = append(queue, v)
+			}
+		}
+	}
+
+	numLayers := 0
+	for _, l := range layer {
+		numLayers = max(numLayers, l+1)
+	}
+	layers := make([][]int, numLayers)
+	for v := 0; v < n; v++ {
+		layers[layer[v]] = append(layers[layer[v]], v)
+	}
+
+	// Barycenter ordering, alternating downward and upward sweeps.
+	order := make([]float64, n)
+	setOrder := func(l []int) {
+		for i, v := range l {
+			order[v] = float64(i)
+		}
+	}
+	for _, l := range layers {
+		setOrder(l)
+	}
+	for sweep := 0; sweep < layeredSweeps; sweep++ {
+		down := sweep%2 == 0
+		for k := range layers {
+			li := k
+			adj := pred
+			if !down {
+				li = numLayers - 1 - k
+				adj = succ
+			}
+			bary := make(map[int]float64, len(layers[li]))
+			for _, v := range layers[li] {
+				if len(adj[v]) == 0 {
+					bary[v] = order[v]
+					continue
+				}
+				sum := 0.0
+				for _, u := range adj[v] {
+					sum += order[u]
+				}
+				bary[v] = sum / float64(len(adj[v]))
+			}
+			sort.SliceStable(layers[li], func(i, j int) bool {
+				return bary[layers[li][i]] < bary[layers[li][j]]
+			})
+			setOrder(layers[li])
+		}
+	}
+
+	// Coordinates: layers stacked downwards, each centered on x = 0.
+	pos := make([]mp.Point, n)
+	y := 0.0
+	for li, l := range layers {
+		layerHH := 0.0
+		width := 0.0
+		for i, v := range l {
+			hw, hh := g.Nodes[v].bounds()
+			layerHH = math.Max(layerHH, hh)
+			width += 2 * hw
+			if i > 0 {
+				width += nodeSep
+			}
+		}
+		if li > 0 {
+			y -= layerHH
+		}
+		x := -width / 2
+		for _, v := range l {
+			hw, _ := g.Nodes[v].bounds()
+			pos[v] = mp.P(x+hw, y)
+			x += 2*hw + nodeSep
+		}
+		y -= layerHH + layerSep
+	}
+	g.place(pos)
+}
+
+// LayoutTree arranges the graph as a tree (or forest) growing downwards from
+// the nodes without incoming edges. Leaves are spaced siblingSep apart and
+// every parent is centered above its children; levelSep is the vertical
+// distance between the centers of successive levels. Edges that would close
+// a cycle or give a node a second parent are ignored for the layout.
+//
+// The positions are added to the graph's context as equations; call only one
+// layout per graph.
+func (g *Graph) LayoutTree(levelSep, siblingSep float64) {
+	g.sizeNodes()
+	n := len(g.Nodes)
+	if n == 0 {
+		return
+	}
+	succ := make([][]int, n)
+	hasParent := make([]bool, n)
+	for _, e := range g.Edges {
+		if e.From != e.To {
+			succ[e.From.index] = append(succ[e.From.index], e.To.index)
+			hasParent[e.To.index] = true
+		}
+	}
+
+	// Breadth-first spanning forest so each node gets a single parent.
+	children := make([][]int, n)
+	visited := make([]bool, n)
+	var roots []int
+	grow := func(root int) {
+		roots = append(roots, root)
+		visited[root] = true
+		queue := []int{root}
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, v := range succ[u] {
+				if !visited[v] {
+					visited[v] = true
+					children[u] = append(children[u], v)
+					queue = append(queue, v)
+				}
+			}
+		}
+	}
+	for v := 0; v < n; v++ {
+		if !hasParent[v] && !visited[v] {
+			grow(v)
+		}
+	}
+	for v := 0; v < n; v++ {
+		if !visited[v] {
+			grow(v)
+		}
+	}
+
+	pos := make([]mp.Point, n)
+	cursor := 0.0
+	var place func(v, depth int)
+	place = func(v, depth int) {
+		hw, _ := g.Nodes[v].bounds()
+		y := -float64(depth) * levelSep
+		if len(children[v]) == 0 {
+			pos[v] = mp.P(cursor+hw, y)
+			cursor += 2*hw + siblingSep
+			return
+		}
+		for _, c := range children[v] {
+			place(c, depth+1)
+		}
+		first, last := pos[children[v][0]], pos[children[v][len(children[v])-1]]
+		pos[v] = mp.P((first.X+last.X)/2, y)
+		cursor = math.Max(cursor, pos[v].X+hw+siblingSep)
+	}
+	for _, r := range roots {
+		place(r, 0)
+	}
+	g.place(pos)
+}
+
+// LayoutForce arranges the graph with a force-directed (Fruchterman-Reingold)
+// simulation: all nodes repel each other while edges pull their ends
+// together, settling at roughly length between adjacent nodes. The start
+// configuration is a circle, so the result is deterministic. Pinned nodes
+// take part in the forces but do not move.
+//
+// The positions are added to the graph's context as equations; call only one
+// layout per graph.
+func (g *Graph) LayoutForce(length float64, iterations int) {
+	g.sizeNodes()
+	n := len(g.Nodes)
+	if n == 0 {
+		return
+	}
+	if length <= 0 {
+		length = 50
+	}
+	pos := make([]mp.Point, n)
+	r := length * float64(n) / (2 * math.Pi)
+	for i, node := range g.Nodes {
+		if node.pinned {
+			pos[i] = node.Pos.Point()
+			continue
+		}
+		a := 2 * math.Pi * float64(i) / float64(n)
+		pos[i] = mp.P(r*math.Cos(a), r*math.Sin(a))
+	}
+
+	k := length
+	temp := length * math.Sqrt(float64(n))
+	disp := make([]mp.Point, n)
+	for it := 0; it < iterations; it++ {
+		for i := range disp {
+			disp[i] = mp.Point{}
+		}
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				dx, dy := pos[i].X-pos[j].X, pos[i].Y-pos[j].Y
+				d := math.Max(math.Hypot(dx, dy), 1e-3)
+				f := k * k / d
+				disp[i].X += dx / d * f
+				disp[i].Y += dy / d * f
+				disp[j].X -= dx / d * f
+				disp[j].Y -= dy / d * f
+			}
+		}
+		for _, e := range g.Edges {
+			i, j := e.From.index, e.To.index
+			if i == j {
+				continue
+			}
+			dx, dy := pos[i].X-pos[j].X, pos[i].Y-pos[j].Y
+			d := math.Max(math.Hypot(dx, dy), 1e-3)
+			f := d * d / k
+			disp[i].X -= dx / d * f
+			disp[i].Y -= dy / d * f
+			disp[j].X += dx / d * f
+			disp[j].Y += dy / d * f
+		}
+		for i, node := range g.Nodes {
+			if node.pinned {
+				continue
+			}
+			d := math.Hypot(disp[i].X, disp[i].Y)
+			if d > 0 {
+				step := math.Min(d, temp)
+				pos[i].X += disp[i].X / d * step
+				pos[i].Y += disp[i].Y / d * step
+			}
+		}
+		temp *= 1 - 1/float64(iterations)
+	}
+	g.place(pos)
+}
+
+// place adds equations fixing every unpinned node at its computed position.
+func (g *Graph) place(pos []mp.Point) {
+	for i, node := range g.Nodes {
+		if !node.pinned {
+			g.ctx.Eq(node.Pos, pos[i])
+		}
+	}
+}
+
+// acyclicAdjacency returns successor and predecessor lists of the graph
+// with self loops dropped, duplicate edges merged and back edges of a
+// depth-first search reversed, so the result is acyclic.
+func (g *Graph) acyclicAdjacency() (succ, pred [][]int) {
+	n := len(g.Nodes)
+	out := make([][]int, n)
+	for _, e := range g.Edges {
+		if e.From != e.To {
+			out[e.From.index] = append(out[e.From.index], e.To.index)
+		}
+	}
+	succ = make([][]int, n)
+	pred = make([][]int, n)
+	seen := make(map[[2]int]bool)
+	add := func(u, v int) {
+		if !seen[[2]int{u, v}] {
+			seen[[2]int{u, v}] = true
+			succ[u] = append(succ[u], v)
+			pred[v] = append(pred[v], u)
+		}
+	}
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make([]int, n)
+	var visit func(u int)
+	visit = func(u int) {
+		state[u] = onStack
+		for _, v := range out[u] {
+			switch state[v] {
+			case unvisited:
+				add(u, v)
+				visit(v)
+			case onStack:
+				add(v, u)
+			default:
+				add(u, v)
+			}
+		}
+		state[u] = done
+	}
+	for u := 0; u < n; u++ {
+		if state[u] == unvisited {
+			visit(u)
+		}
+	}
+	return succ, pred
+}
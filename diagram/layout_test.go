@@ -0,0 +1,81 @@
+package diagram
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/svg"
+)
+
+func TestLayoutLayered(t *testing.T) {
+	g := NewGraph()
+	g.Connect("a", "b")
+	g.Connect("a", "c")
+	g.Connect("b", "d")
+	g.Connect("c", "d")
+	g.Connect("d", "a") // back edge, must not break the layering
+	g.LayoutLayered(30, 20)
+	if err := g.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	y := func(name string) float64 { return g.Lookup(name).Pos.Y() }
+	if !(y("a") > y("b") && y("b") == y("c") && y("c") > y("d")) {
+		t.Errorf("unexpected layers: a=%g b=%g c=%g d=%g", y("a"), y("b"), y("c"), y("d"))
+	}
+	bx, cx := g.Lookup("b").Pos.X(), g.Lookup("c").Pos.X()
+	if math.Abs(bx-cx) < DefaultNodeWidth+20-1e-9 {
+		t.Errorf("nodes b and c overlap: %g, %g", bx, cx)
+	}
+}
+
+func TestLayoutTree(t *testing.T) {
+	g := NewGraph()
+	g.Connect("root", "l")
+	g.Connect("root", "r")
+	g.Connect("l", "l1")
+	g.Connect("l", "l2")
+	g.LayoutTree(40, 10)
+	if err := g.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	l1, l2 := g.Lookup("l1").Pos.Point(), g.Lookup("l2").Pos.Point()
+	l := g.Lookup("l").Pos.Point()
+	if math.Abs(l.X-(l1.X+l2.X)/2) > 1e-9 {
+		t.Errorf("parent not centered over children: %g vs %g, %g", l.X, l1.X, l2.X)
+	}
+	if l1.Y != -80 || l.Y != -40 || g.Lookup("root").Pos.Y() != 0 {
+		t.Errorf("unexpected levels: %v %v", l, l1)
+	}
+}
+
+func TestLayoutForcePinned(t *testing.T) {
+	g := NewGraph()
+	g.Node("hub").At(10, 10)
+	for _, s := range []string{"a", "b", "c", "d"} {
+		g.Connect("hub", s)
+	}
+	g.LayoutForce(50, 100)
+	pic, err := g.Picture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, y := g.Lookup("hub").Pos.XY(); x != 10 || y != 10 {
+		t.Errorf("pinned node moved to (%g, %g)", x, y)
+	}
+	for _, s := range []string{"a", "b", "c", "d"} {
+		p := g.Lookup(s).Pos.Point()
+		if d := math.Hypot(p.X-10, p.Y-10); d < 20 || d > 150 {
+			t.Errorf("node %s at unreasonable distance %g", s, d)
+		}
+	}
+	b := svg.NewBuilder()
+	b.AddPicture(pic)
+	var sb strings.Builder
+	if err := b.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "hub") {
+		t.Error("node label missing from SVG")
+	}
+}
@@ -0,0 +1,367 @@
+package diagram
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Sequence diagram dimensions.
+const (
+	seqHeadHeight  = 20.0 // height of the participant boxes
+	seqBarWidth    = 8.0  // width of an activation bar
+	seqBarNesting  = 3.0  // horizontal shift of nested activation bars
+	seqSelfWidth   = 25.0 // horizontal reach of a self message
+	seqFrameMargin = 15.0 // horizontal margin of the outermost frame
+)
+
+// Sequence builds a UML-style sequence diagram: participants with dashed
+// lifelines, horizontal message arrows, activation bars and combined
+// fragments (alt/loop/opt frames). Rows are added top to bottom in call
+// order; errors such as unknown participants are reported by Picture.
+//
+// Example:
+//
+//	s := diagram.NewSequence("Client", "Server")
+//	s.Message("Client", "Server", "request").Activate("Server")
+//	s.Reply("Server", "Client", "response").Deactivate("Server")
+//	pic, err := s.Picture()
+type Sequence struct {
+	Participants []string
+	ColumnSep    float64 // horizontal distance between lifelines (default 80)
+	RowSep       float64 // vertical distance between messages (default 20)
+
+	col     map[string]int
+	items   []seqItem
+	err     error
+	y       float64    // y of the next row
+	lastY   float64    // y of the most recent message
+	active  [][]seqBar // open activations per participant
+	bars    []seqBar
+	frames  []*seqFrame // open frames, innermost last
+	done    []*seqFrame
+	started bool
+}
+
+type seqItem struct {
+	from, to int
+	text     string
+	reply    bool
+	y        float64
+	x1, x2   float64 // endpoint offsets from the lifelines (activation bars)
+}
+
+type seqBar struct {
+	col         int
+	depth       int
+	top, bottom float64
+}
+
+type seqFrame struct {
+	kind, guard    string
+	top, bottom    float64
+	depth          int
+	minCol, maxCol int
+	elses          []seqElse
+}
+
+type seqElse struct {
+	y     float64
+	guard string
+}
+
+// NewSequence creates a sequence diagram with the given participants from
+// left to right.
+func NewSequence(participants ...string) *Sequence {
+	s := &Sequence{
+		Participants: participants,
+		ColumnSep:    80,
+		RowSep:       20,
+		col:          make(map[string]int),
+		active:       make([][]seqBar, len(participants)),
+	}
+	for i, p := range participants {
+		s.col[p] = i
+	}
+	return s
+}
+
+// advance returns the y of the next row and moves the cursor down by rows.
+func (s *Sequence) advance(rows float64) float64 {
+	if !s.started {
+		s.y = -seqHeadHeight - s.RowSep
+		s.lastY = -seqHeadHeight
+		s.started = true
+	}
+	y := s.y
+	s.y -= rows * s.RowSep
+	return y
+}
+
+// lookup returns the column of a participant, recording an error if it is
+// unknown.
+func (s *Sequence) lookup(name string) (int, bool) {
+	c, ok := s.col[name]
+	if !ok && s.err == nil {
+		s.err = fmt.Errorf("diagram: unknown participant %q", name)
+	}
+	return c, ok
+}
+
+// Message adds a solid arrow from one participant to another with the text
+// above it. A message to the same participant is drawn as a self loop.
+func (s *Sequence) Message(from, to, text string) *Sequence {
+	return s.message(from, to, text, false)
+}
+
+// Reply adds a dashed return arrow from one participant to another.
+func (s *Sequence) Reply(from, to, text string) *Sequence {
+	return s.message(from, to, text, true)
+}
+
+func (s *Sequence) message(from, to, text string, reply bool) *Sequence {
+	fc, ok1 := s.lookup(from)
+	tc, ok2 := s.lookup(to)
+	if !ok1 || !ok2 {
+		return s
+	}
+	rows := 1.0
+	if fc == tc {
+		rows = 1.6
+	}
+	item := seqItem{from: fc, to: tc, text: text, reply: reply, y: s.advance(rows)}
+	dir := 1.0
+	if tc < fc {
+		dir = -1
+	}
+	item.x1 = dir * s.barEdge(fc)
+	item.x2 = -dir * s.barEdge(tc)
+	if fc == tc {
+		item.x1, item.x2 = s.barEdge(fc), s.barEdge(fc)
+	}
+	s.items = append(s.items, item)
+	s.lastY = item.y
+	if fc == tc {
+		s.lastY = item.y - 0.6*s.RowSep
+	}
+	for _, f := range s.frames {
+		f.minCol = min(f.minCol, fc, tc)
+		f.maxCol = max(f.maxCol, fc, tc)
+	}
+	return s
+}
+
+// barEdge returns the distance from the lifeline to the outer edge of the
+// participant's innermost active bar, or 0 if it is not active.
+func (s *Sequence) barEdge(col int) float64 {
+	n := len(s.active[col])
+	if n == 0 {
+		return 0
+	}
+	return seqBarWidth/2 + float64(n-1)*seqBarNesting
+}
+
+// Activate starts an activation bar on the participant's lifeline at the
+// most recent message. Activations nest.
+func (s *Sequence) Activate(name string) *Sequence {
+	c, ok := s.lookup(name)
+	if !ok {
+		return s
+	}
+	s.advance(0)
+	s.active[c] = append(s.active[c], seqBar{col: c, depth: len(s.active[c]), top: s.lastY})
+	return s
+}
+
+// Deactivate ends the participant's innermost activation bar at the most
+// recent message.
+func (s *Sequence) Deactivate(name string) *Sequence {
+	c, ok := s.lookup(name)
+	if !ok {
+		return s
+	}
+	n := len(s.active[c])
+	if n == 0 {
+		if s.err == nil {
+			s.err = fmt.Errorf("diagram: %q is not active", name)
+		}
+		return s
+	}
+	bar := s.active[c][n-1]
+	s.active[c] = s.active[c][:n-1]
+	bar.bottom = math.Min(s.lastY, bar.top-s.RowSep/2)
+	s.bars = append(s.bars, bar)
+	return s
+}
+
+// BeginFrame opens a combined fragment such as "alt", "loop" or "opt" with
+// an optional guard condition. Frames nest and are closed with EndFrame.
+func (s *Sequence) BeginFrame(kind, guard string) *Sequence {
+	y := s.advance(1)
+	f := &seqFrame{
+		kind:   kind,
+		guard:  guard,
+		top:    y + s.RowSep/2,
+		depth:  len(s.frames),
+		minCol: len(s.Participants),
+		maxCol: -1,
+	}
+	s.frames = append(s.frames, f)
+	return s
+}
+
+// Else adds a dashed divider with a guard to the innermost open frame, as
+// used for the branches of an "alt" fragment.
+func (s *Sequence) Else(guard string) *Sequence {
+	if len(s.frames) == 0 {
+		if s.err == nil {
+			s.err = fmt.Errorf("diagram: Else outside of a frame")
+		}
+		return s
+	}
+	y := s.advance(1)
+	f := s.frames[len(s.frames)-1]
+	f.elses = append(f.elses, seqElse{y: y + s.RowSep/2, guard: guard})
+	return s
+}
+
+// EndFrame closes the innermost open frame.
+func (s *Sequence) EndFrame() *Sequence {
+	if len(s.frames) == 0 {
+		if s.err == nil {
+			s.err = fmt.Errorf("diagram: EndFrame without BeginFrame")
+		}
+		return s
+	}
+	y := s.advance(0.5)
+	f := s.frames[len(s.frames)-1]
+	s.frames = s.frames[:len(s.frames)-1]
+	f.bottom = y + s.RowSep/2
+	if len(s.frames) > 0 {
+		outer := s.frames[len(s.frames)-1]
+		outer.minCol = min(outer.minCol, f.minCol)
+		outer.maxCol = max(outer.maxCol, f.maxCol)
+	}
+	s.done = append(s.done, f)
+	return s
+}
+
+// Picture returns the diagram. Activations still open are closed at the
+// end of the lifelines; unclosed frames are an error.
+func (s *Sequence) Picture() (*draw.Picture, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(s.frames) > 0 {
+		return nil, fmt.Errorf("diagram: frame %q not closed", s.frames[len(s.frames)-1].kind)
+	}
+	bottom := s.advance(0)
+	x := func(col int) float64 { return float64(col) * s.ColumnSep }
+	black := mp.ColorCSS("black")
+	line := mp.Style{Stroke: black, StrokeWidth: 0.5}
+	pic := draw.NewPicture()
+
+	for i, name := range s.Participants {
+		w := math.Max(DefaultNodeWidth, 0.6*mp.DefaultFontSize*float64(len(name))+10)
+		box := mp.XScaled(w).Then(mp.YScaled(seqHeadHeight)).
+			Then(mp.Shifted(x(i)-w/2, -seqHeadHeight)).
+			ApplyToPath(mp.UnitSquare())
+		box.Style = line
+		pic.AddPath(box)
+		pic.Label(name, mp.P(x(i), -seqHeadHeight/2), mp.AnchorCenter)
+
+		lifeline, err := polyline(line, mp.P(x(i), -seqHeadHeight), mp.P(x(i), bottom))
+		if err != nil {
+			return nil, err
+		}
+		lifeline.Style.Dash = mp.DashEvenly()
+		pic.AddPath(lifeline)
+	}
+
+	bars := s.bars
+	for c := range s.active {
+		for _, bar := range s.active[c] {
+			bar.bottom = bottom
+			bars = append(bars, bar)
+		}
+	}
+	for _, bar := range bars {
+		cx := x(bar.col) + float64(bar.depth)*seqBarNesting
+		rect := mp.XScaled(seqBarWidth).Then(mp.YScaled(bar.top - bar.bottom)).
+			Then(mp.Shifted(cx-seqBarWidth/2, bar.bottom)).
+			ApplyToPath(mp.UnitSquare())
+		rect.Style = line
+		rect.Style.Fill = mp.ColorCSS("white")
+		pic.AddPath(rect)
+	}
+
+	for _, it := range s.items {
+		arrow := line
+		arrow.Arrow = mp.ArrowStyle{End: true, Length: mp.DefaultAHLength, Angle: mp.DefaultAHAngle}
+		var path *mp.Path
+		var err error
+		if it.from == it.to {
+			x0 := x(it.from) + it.x1
+			path, err = polyline(arrow,
+				mp.P(x0, it.y),
+				mp.P(x0+seqSelfWidth, it.y),
+				mp.P(x0+seqSelfWidth, it.y-0.6*s.RowSep),
+				mp.P(x0, it.y-0.6*s.RowSep))
+			pic.Label(it.text, mp.P(x0+seqSelfWidth, it.y-0.3*s.RowSep), mp.AnchorRight)
+		} else {
+			x1, x2 := x(it.from)+it.x1, x(it.to)+it.x2
+			path, err = polyline(arrow, mp.P(x1, it.y), mp.P(x2, it.y))
+			pic.Label(it.text, mp.P((x1+x2)/2, it.y), mp.AnchorTop)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if it.reply {
+			path.Style.Dash = mp.DashEvenly()
+		}
+		pic.AddPath(path)
+	}
+
+	for _, f := range s.done {
+		minCol, maxCol := f.minCol, f.maxCol
+		if maxCol < 0 {
+			minCol, maxCol = 0, len(s.Participants)-1
+		}
+		margin := math.Max(seqFrameMargin-4*float64(f.depth), 3)
+		left, right := x(minCol)-margin-seqSelfWidth/2, x(maxCol)+margin+seqSelfWidth
+		frame := mp.XScaled(right - left).Then(mp.YScaled(f.top - f.bottom)).
+			Then(mp.Shifted(left, f.bottom)).
+			ApplyToPath(mp.UnitSquare())
+		frame.Style = line
+		pic.AddPath(frame)
+		pic.AddLabel(mp.NewLabel(f.kind, mp.P(left, f.top), mp.AnchorLowerRight).WithOffset(2))
+		if f.guard != "" {
+			pic.Label("["+f.guard+"]", mp.P(left+30, f.top-s.RowSep/2), mp.AnchorRight)
+		}
+		for _, e := range f.elses {
+			divider, err := polyline(line, mp.P(left, e.y), mp.P(right, e.y))
+			if err != nil {
+				return nil, err
+			}
+			divider.Style.Dash = mp.DashEvenly()
+			pic.AddPath(divider)
+			if e.guard != "" {
+				pic.Label("["+e.guard+"]", mp.P(left+30, e.y-s.RowSep/2), mp.AnchorRight)
+			}
+		}
+	}
+	return pic, nil
+}
+
+// polyline returns the straight path through pts with the given style. It
+// fails like mp.StraightPath for NaN or infinite points.
+func polyline(style mp.Style, pts ...mp.Point) (*mp.Path, error) {
+	path, err := mp.StraightPath(pts, false)
+	if err != nil {
+		return nil, err
+	}
+	path.Style = style
+	return path, nil
+}
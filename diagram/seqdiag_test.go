@@ -0,0 +1,66 @@
+package diagram
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+	"github.com/boxesandglue/mpgo/svg"
+)
+
+func TestSequenceDiagram(t *testing.T) {
+	s := NewSequence("Client", "Server", "DB")
+	s.Message("Client", "Server", "request").Activate("Server")
+	s.BeginFrame("alt", "cached")
+	s.Reply("Server", "Client", "hit")
+	s.Else("miss")
+	s.Message("Server", "DB", "query")
+	s.Message("Server", "Server", "log")
+	s.EndFrame()
+	s.Reply("Server", "Client", "response").Deactivate("Server")
+
+	pic, err := s.Picture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 boxes, 3 lifelines, 1 bar, 5 messages, 1 frame, 1 divider.
+	if got := len(pic.Paths()); got != 14 {
+		t.Errorf("expected 14 paths, got %d", got)
+	}
+	b := svg.NewBuilder()
+	b.AddPicture(pic)
+	var sb strings.Builder
+	if err := b.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{">alt<", ">[cached]<", ">[miss]<", ">query<", "stroke-dasharray"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("SVG missing %q", want)
+		}
+	}
+
+	// Message arrows end at the activation bar, not at the lifeline.
+	resp := pic.Paths()[len(pic.Paths())-3]
+	if x := resp.Head.XCoord; x != 80-seqBarWidth/2 {
+		t.Errorf("reply starts at x=%g, want %g", x, 80-seqBarWidth/2)
+	}
+}
+
+func TestSequenceErrors(t *testing.T) {
+	if _, err := NewSequence("A").Message("A", "B", "x").Picture(); err == nil {
+		t.Error("expected error for unknown participant")
+	}
+	if _, err := NewSequence("A").BeginFrame("loop", "").Picture(); err == nil {
+		t.Error("expected error for unclosed frame")
+	}
+	if _, err := NewSequence("A").Deactivate("A").Picture(); err == nil {
+		t.Error("expected error for inactive participant")
+	}
+	s := NewSequence("A", "B")
+	s.ColumnSep = math.Inf(1)
+	if _, err := s.Message("A", "B", "x").Picture(); !errors.Is(err, mp.ErrInvalidKnot) {
+		t.Errorf("expected an invalid knot error for an infinite column distance, got %v", err)
+	}
+}
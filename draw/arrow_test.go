@@ -358,3 +358,92 @@ func TestArrowNoStroke(t *testing.T) {
 		t.Error("arrow head should have stroke=\"none\"")
 	}
 }
+
+func TestCustomArrowHead(t *testing.T) {
+	type call struct {
+		tip, dir mp.Point
+		style    mp.ArrowStyle
+	}
+	var calls []call
+	// A square head instead of the triangle
+	square := func(tip, dir mp.Point, s mp.ArrowStyle) *mp.Path {
+		calls = append(calls, call{tip, dir, s})
+		return mp.Shifted(tip.X-s.Length, tip.Y-s.Length/2).ApplyToPath(mp.Scaled(s.Length).ApplyToPath(mp.UnitSquare()))
+	}
+	solved, err := NewPath().
+		WithStrokeColor(mp.ColorCSS("black")).
+		WithDoubleArrow().
+		WithArrowHead(square).
+		MoveTo(P(0, 0)).
+		LineTo(P(100, 0)).
+		Solve()
+	if err != nil {
+		t.Fatalf("solve failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := svg.NewBuilder().AddPathFromPath(solved).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) < 2 {
+		t.Fatalf("head function called %d times, want once per end", len(calls))
+	}
+	end, start := calls[0], calls[1]
+	if end.tip != mp.P(100, 0) || end.dir != mp.P(1, 0) || start.tip != mp.P(0, 0) || start.dir != mp.P(-1, 0) {
+		t.Errorf("heads at %v dir %v and %v dir %v", end.tip, end.dir, start.tip, start.dir)
+	}
+	if end.style.Length != mp.DefaultAHLength || end.style.Angle != mp.DefaultAHAngle {
+		t.Errorf("head style %+v, want the default size", end.style)
+	}
+	// The square heads are 4 wide and filled: 100.25 to 104.25 after the
+	// shift of the viewBox by the start head
+	if out := buf.String(); strings.Count(out, `fill="black" stroke="none"`) != 2 || !strings.Contains(out, "L 104.250000 4.250000") {
+		t.Errorf("square heads missing:\n%s", out)
+	}
+
+	// The bounding box includes the custom head
+	x0, y0, x1, y1, _ := solved.Extent()
+	if x0 > -0.01 || x1 < 100 || y0 > -2 || y1 < 2 {
+		t.Errorf("extent (%g,%g)-(%g,%g) does not include the square heads", x0, y0, x1, y1)
+	}
+
+	// Merging a style keeps the head function
+	merged := mp.Style{}.Merge(mp.NewStyle(mp.WithArrowHead(square)))
+	if merged.Arrow.Head == nil {
+		t.Error("Merge dropped the arrowhead function")
+	}
+}
+
+func TestHarpoonArrow(t *testing.T) {
+	solved, err := NewPath().
+		WithStrokeColor(mp.ColorCSS("black")).
+		WithArrow().
+		WithArrowShape(mp.ArrowShapeHarpoonLeft).
+		MoveTo(P(0, 0)).
+		LineTo(P(100, 0)).
+		Solve()
+	if err != nil {
+		t.Fatalf("solve failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := svg.NewBuilder().AddPathFromPath(solved).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	// The shaft is not cut back: the barb meets it at the tip
+	if !strings.Contains(out, "L 100.") {
+		t.Errorf("harpoon shaft does not reach the tip:\n%s", out)
+	}
+	if strings.Count(out, `fill="black" stroke="none"`) != 1 {
+		t.Errorf("harpoon head missing:\n%s", out)
+	}
+	// Only the left barb: nothing below the shaft
+	_, y0, _, y1, _ := solved.Extent()
+	if y0 < -1 || y1 < 1 {
+		t.Errorf("extent y %g..%g, want the barb above the shaft only", y0, y1)
+	}
+	merged := mp.Style{}.Merge(mp.NewStyle(mp.WithArrowShape(mp.ArrowShapeHarpoonRight)))
+	if merged.Arrow.Shape != mp.ArrowShapeHarpoonRight {
+		t.Error("Merge dropped the arrow shape")
+	}
+}
@@ -1,6 +1,10 @@
 package draw
 
 import (
+	"context"
+	"fmt"
+	"math"
+
 	"github.com/boxesandglue/mpgo/mp"
 )
 
@@ -41,17 +45,37 @@ type PathBuilder struct {
 	closeCurlOut    float64
 	closeCurlInSet  bool
 	closeCurlOutSet bool
+	closeLine       bool // closing segment is a straight line
+	closeInT        float64
+	closeOutT       float64
+	closeInTSet     bool
+	closeOutTSet    bool
+	closeExplicit   bool
+	closeCtrl1      mp.Point
+	closeCtrl2      mp.Point
+	closeNear       bool
+	closeNearEps    float64
+	closing         *mp.Style // style of the closing segment, see CloseWith
+	warnings        []string
 	stroke          mp.Color
 	fill            mp.Color
 	strokeWidth     float64
 	pen             *mp.Pen
-	lineJoin        int
-	lineCap         int
+	lineJoin        mp.LineJoin
+	lineCap         mp.LineCap
 	arrowEnd        bool
 	arrowStart      bool
 	arrowLength     float64
 	arrowAngle      float64
+	arrowJoined     bool
+	arrowHead       mp.ArrowHeadFunc
+	arrowShape      mp.ArrowShape
 	dash            *mp.DashPattern
+	lineStyle       mp.LineStyle
+	gradient        *mp.StrokeGradient
+	hairline        bool
+	autoCloseFill   bool
+	meta            map[string]string
 	transforms      []mp.Transform // transformations to apply after solving
 	styleSet        bool
 }
@@ -157,9 +181,11 @@ func (p *PathBuilder) WithTension(t float64) *PathBuilder {
 	return p
 }
 
-// WithTensionAtLeast mirrors MetaPost's "tension atleast t"; currently treated
-// by storing a negative tension to signal "atleast" to the solver (mp.c uses
-// negative values for the flag).
+// WithTensionAtLeast mirrors MetaPost's "tension atleast t" (the "..."
+// operator is "tension atleast 1"). The tension is stored negated, as in
+// mp.c; the solver uses its absolute value and additionally shortens the
+// control vectors where necessary so that an inflection-free segment stays
+// inside the triangle formed by its chord and end tangents.
 func (p *PathBuilder) WithTensionAtLeast(t float64) *PathBuilder {
 	p.outTension = -t
 	p.inTension = -t
@@ -213,6 +239,16 @@ func (p *PathBuilder) WithFill(c mp.Color) *PathBuilder {
 	return p
 }
 
+// WithAutoCloseFill fills an open path up to the straight segment from
+// its end back to its start, leaving the stroke open (see
+// mp.Style.AutoCloseFill). Without it, filling an open path is an error
+// when the picture is written.
+func (p *PathBuilder) WithAutoCloseFill() *PathBuilder {
+	p.autoCloseFill = true
+	p.styleSet = true
+	return p
+}
+
 // WithPen attaches a pen to this path style (mirrors pen_p in mp.c:564).
 func (p *PathBuilder) WithPen(pen *mp.Pen) *PathBuilder {
 	p.pen = pen
@@ -220,23 +256,38 @@ func (p *PathBuilder) WithPen(pen *mp.Pen) *PathBuilder {
 	return p
 }
 
-// WithLineJoin sets the line join style for corners.
-// Use mp.LineJoinMiter (0), mp.LineJoinRound (1), or mp.LineJoinBevel (2).
-func (p *PathBuilder) WithLineJoin(join int) *PathBuilder {
+// WithLineJoin sets the line join style for corners: mp.LineJoinMiter,
+// mp.LineJoinRound or mp.LineJoinBevel. mp.LineJoinDefault means rounded.
+func (p *PathBuilder) WithLineJoin(join mp.LineJoin) *PathBuilder {
 	p.lineJoin = join
 	p.styleSet = true
 	return p
 }
 
-// WithLineCap sets the line cap style for endpoints.
-// Use mp.LineCapButt (1), mp.LineCapRounded (2), or mp.LineCapSquared (3).
-// 0 means default (rounded).
-func (p *PathBuilder) WithLineCap(cap int) *PathBuilder {
+// WithLineCap sets the line cap style for endpoints: mp.LineCapButt,
+// mp.LineCapRounded or mp.LineCapSquared. mp.LineCapDefault means rounded.
+func (p *PathBuilder) WithLineCap(cap mp.LineCap) *PathBuilder {
 	p.lineCap = cap
 	p.styleSet = true
 	return p
 }
 
+// WithLineJoinInt sets the line join from an untyped value in the scheme
+// of the mp.LineJoin constants (0 unset, 1 miter, 2 round, 3 bevel).
+//
+// Deprecated: Use WithLineJoin with an mp.LineJoin constant.
+func (p *PathBuilder) WithLineJoinInt(join int) *PathBuilder {
+	return p.WithLineJoin(mp.LineJoin(join))
+}
+
+// WithLineCapInt sets the line cap from an untyped value in the scheme of
+// the mp.LineCap constants (0 unset, 1 butt, 2 round, 3 square).
+//
+// Deprecated: Use WithLineCap with an mp.LineCap constant.
+func (p *PathBuilder) WithLineCapInt(cap int) *PathBuilder {
+	return p.WithLineCap(mp.LineCap(cap))
+}
+
 // WithArrow adds an arrowhead at the end of the path (like drawarrow).
 func (p *PathBuilder) WithArrow() *PathBuilder {
 	p.arrowEnd = true
@@ -261,6 +312,30 @@ func (p *PathBuilder) WithArrowStyle(length, angle float64) *PathBuilder {
 	return p
 }
 
+// WithArrowShape draws the arrowheads in a built-in shape, e.g.
+// mp.ArrowShapeHarpoonLeft for the half arrows of vector diagrams.
+func (p *PathBuilder) WithArrowShape(shape mp.ArrowShape) *PathBuilder {
+	p.arrowShape = shape
+	p.styleSet = true
+	return p
+}
+
+// WithArrowHead draws the arrowheads with head instead of the built-in
+// triangle (see mp.ArrowHeadFunc).
+func (p *PathBuilder) WithArrowHead(head mp.ArrowHeadFunc) *PathBuilder {
+	p.arrowHead = head
+	p.styleSet = true
+	return p
+}
+
+// WithJoinedArrows draws the arrowheads and the envelope of a path stroked
+// with a polygonal pen as one filled outline (see mp.JoinedArrowOutline).
+func (p *PathBuilder) WithJoinedArrows() *PathBuilder {
+	p.arrowJoined = true
+	p.styleSet = true
+	return p
+}
+
 // Dashed sets a custom dash pattern.
 // The pattern is given as alternating on/off lengths: on1, off1, on2, off2, ...
 // Example: Dashed(6, 3) creates "on 6 off 3" (long dashes with short gaps)
@@ -295,6 +370,41 @@ func (p *PathBuilder) WithDashPattern(d *mp.DashPattern) *PathBuilder {
 	return p
 }
 
+// WithLineStyle draws the path with a cartographic line style such as
+// mp.LineStyleRailway instead of a plain stroke.
+func (p *PathBuilder) WithLineStyle(ls mp.LineStyle) *PathBuilder {
+	p.lineStyle = ls
+	p.styleSet = true
+	return p
+}
+
+// WithStrokeGradient makes the stroke color vary along the path through the
+// given colors (see mp.NewStrokeGradient).
+func (p *PathBuilder) WithStrokeGradient(colors ...mp.Color) *PathBuilder {
+	p.gradient = mp.NewStrokeGradient(colors...)
+	p.styleSet = true
+	return p
+}
+
+// WithHairline draws the path as a hairline, as thin as the output device
+// allows at any scale (see mp.Style.Hairline).
+func (p *PathBuilder) WithHairline() *PathBuilder {
+	p.hairline = true
+	p.styleSet = true
+	return p
+}
+
+// WithMeta attaches the metadata entry key = value to the path, which the
+// SVG writer emits as a data- attribute (see mp.WithMeta).
+func (p *PathBuilder) WithMeta(key, value string) *PathBuilder {
+	if p.meta == nil {
+		p.meta = map[string]string{}
+	}
+	p.meta[key] = value
+	p.styleSet = true
+	return p
+}
+
 // Shifted adds a translation transformation to be applied after solving.
 // Mirrors MetaPost's "path shifted (dx, dy)".
 func (p *PathBuilder) Shifted(dx, dy float64) *PathBuilder {
@@ -324,6 +434,12 @@ func (p *PathBuilder) Slanted(s float64) *PathBuilder {
 	return p
 }
 
+// YSlanted adds a vertical shear transformation (see mp.YSlanted).
+func (p *PathBuilder) YSlanted(s float64) *PathBuilder {
+	p.transforms = append(p.transforms, mp.YSlanted(mp.Number(s)))
+	return p
+}
+
 // XScaled adds a horizontal scaling transformation.
 // Mirrors MetaPost's "path xscaled s".
 func (p *PathBuilder) XScaled(s float64) *PathBuilder {
@@ -476,6 +592,37 @@ func (p *PathBuilder) LineToVar(v *Var) *PathBuilder {
 	return p
 }
 
+// plainInfinity is plain.mp's "infinity", the tension of MetaPost's "---".
+const plainInfinity = 4095.99998
+
+// StraightTo adds a segment to pt like MetaPost's "---", which plain.mp
+// defines as ".. tension infinity ..": the segment is straight (to within
+// a tiny fraction of its length), but unlike LineTo's "--" it has no curl
+// at its ends, so the curves on either side leave and enter it smoothly
+// instead of turning a corner at the knots. Directions set for the segment
+// are kept; tensions are replaced.
+//
+// Example:
+//
+//	// z0..z1---z2..z3: a straight middle part with smooth transitions
+//	draw.NewPath().MoveTo(z0).CurveTo(z1).StraightTo(z2).CurveTo(z3)
+func (p *PathBuilder) StraightTo(pt mp.Point) *PathBuilder {
+	p.setStraightTension()
+	return p.CurveTo(pt)
+}
+
+// StraightToVar is StraightTo to a context variable.
+func (p *PathBuilder) StraightToVar(v *Var) *PathBuilder {
+	p.setStraightTension()
+	return p.CurveToVar(v)
+}
+
+// setStraightTension sets the tensions of the next segment to those of "---".
+func (p *PathBuilder) setStraightTension() {
+	p.outTension, p.inTension = plainInfinity, plainInfinity
+	p.outTSet, p.inTSet = true, true
+}
+
 func (p *PathBuilder) resetAfterSegment() {
 	p.outSet = false
 	p.inSet = false
@@ -491,15 +638,168 @@ func (p *PathBuilder) resetAfterSegment() {
 
 // Close marks the path as cyclic; use the currently set outDir/inDir as the
 // outgoing/incoming directions for the closing segment back to the start.
+// Curls and tensions set before Close apply to the closing segment as well.
+//
+// As in MetaPost, a direction or curl given on one side of a knot of the
+// cycle applies to both sides when the other side is open: the direction
+// arriving at the start ({d}cycle) is also the one leaving it unless the
+// first segment gives its own. Give both to make the start a corner, see
+// CloseAtStart.
 func (p *PathBuilder) Close() *PathBuilder {
 	p.closed = true
 	p.closeOut = p.outDir
 	p.closeIn = p.inDir
 	p.closeOutSet = p.outSet
 	p.closeInSet = p.inSet
+	p.closeCurlOut, p.closeCurlOutSet = p.outCurl, p.outCurlSet
+	p.closeCurlIn, p.closeCurlInSet = p.inCurl, p.inCurlSet
+	p.closeOutT, p.closeOutTSet = p.outTension, p.outTSet
+	p.closeInT, p.closeInTSet = p.inTension, p.inTSet
+	return p
+}
+
+// CloseDir closes the path with a segment that leaves the last point in
+// direction outDeg and arrives at the start in direction inDeg (MetaPost:
+// z2{outDeg}..{inDeg}cycle).
+func (p *PathBuilder) CloseDir(outDeg, inDeg float64) *PathBuilder {
+	p.outDir, p.inDir = outDeg, inDeg
+	p.outSet, p.inSet = true, true
+	return p.Close()
+}
+
+// CloseCurl closes the path with a segment that has curl outCurl at the
+// last point and curl inCurl at the start (MetaPost: z2{curl a}..{curl b}cycle).
+func (p *PathBuilder) CloseCurl(outCurl, inCurl float64) *PathBuilder {
+	p.outCurl, p.inCurl = outCurl, inCurl
+	p.outCurlSet, p.inCurlSet = true, true
+	return p.Close()
+}
+
+// CloseAtStart closes the path so that it arrives at the start in
+// direction inDeg and leaves it in direction outDeg, making the start a
+// corner when they differ (MetaPost: z0{outDeg}..z1..{inDeg}cycle). outDeg
+// replaces a direction given for the first segment with WithDirection; it
+// has no effect if the first segment is a line or has explicit controls.
+// Directions, curls and tensions set before CloseAtStart apply to the
+// closing segment as with Close.
+//
+// Example:
+//
+//	// A drop: round at the bottom, pointed at (0,0) where the sides meet at 90°.
+//	drop, _ := draw.NewPath().MoveTo(mp.P(0, 0)).CurveTo(mp.P(0, -60)).
+//		CloseAtStart(45, -45).Solve()
+func (p *PathBuilder) CloseAtStart(inDeg, outDeg float64) *PathBuilder {
+	p.inDir, p.inSet = inDeg, true
+	p.Close()
+	if len(p.segments) > 0 {
+		first := &p.segments[0]
+		first.outDir, first.outSet = outDeg, true
+		first.outCurlSet = false
+	}
+	return p
+}
+
+// CloseWith closes the path like Close and draws the closing segment with
+// style merged over the path's style (see mp.Style.Closing), e.g. dashed
+// or with Stroke "none" for an open outline whose fill still reaches the
+// implied closing edge.
+//
+// Example:
+//
+//	area, _ := draw.NewPath().MoveTo(mp.P(0, 0)).CurveTo(mp.P(50, 40)).LineTo(mp.P(100, 0)).
+//		CloseWith(mp.NewStyle(mp.WithDash(mp.DashEvenly()))).Solve()
+func (p *PathBuilder) CloseWith(style mp.Style) *PathBuilder {
+	p.Close()
+	p.closing = &style
 	return p
 }
 
+// nearlyClosedFraction is the gap between the ends of a path, relative to
+// the size of its bounding box, below which CloseIfNear warns about a path
+// it leaves open.
+const nearlyClosedFraction = 0.01
+
+// CloseIfNear closes the path into a cycle if its last point lies within
+// eps of its start, as is common for imported or sampled outlines that do
+// not close exactly and would otherwise render with a seam. The duplicate
+// end point is dropped and the last segment becomes the closing one,
+// keeping its type, directions and tensions; eps = 0 closes only ends that
+// coincide exactly. The check is done when the path is built, so points
+// from context variables are compared after solving.
+//
+// Ends that are further apart than eps but closer than 1% of the path's
+// extent are left open and reported by Warnings.
+//
+// Example:
+//
+//	pb := draw.NewPath().MoveTo(pts[0])
+//	for _, pt := range pts[1:] {
+//		pb.CurveTo(pt)
+//	}
+//	outline, _ := pb.CloseIfNear(0.01).Solve()  // pts[0]..pts[1]..cycle
+func (p *PathBuilder) CloseIfNear(eps float64) *PathBuilder {
+	p.closeNear = true
+	p.closeNearEps = eps
+	return p
+}
+
+// Warnings returns the warnings of the last BuildPath (or Solve) call, such
+// as a nearly closed path that CloseIfNear left open.
+func (p *PathBuilder) Warnings() []string {
+	return p.warnings
+}
+
+// closedNear returns a copy of the builder in which the last segment,
+// ending at the start point, is turned into the closing segment of a cycle.
+// ok is false if the ends are further apart than closeNearEps.
+func (p *PathBuilder) closedNear() (q *PathBuilder, ok bool) {
+	n := len(p.segments)
+	if p.closed || n < 2 {
+		return nil, false
+	}
+	start := p.resolveStart()
+	last := p.segments[n-1]
+	end := last.resolveTarget()
+	gap := math.Hypot(end.X-start.X, end.Y-start.Y)
+	if gap > p.closeNearEps {
+		minX, minY, maxX, maxY := start.X, start.Y, start.X, start.Y
+		for i := range p.segments {
+			pt := p.segments[i].resolveTarget()
+			minX, minY = math.Min(minX, pt.X), math.Min(minY, pt.Y)
+			maxX, maxY = math.Max(maxX, pt.X), math.Max(maxY, pt.Y)
+		}
+		if gap < nearlyClosedFraction*math.Hypot(maxX-minX, maxY-minY) {
+			p.warnings = append(p.warnings, fmt.Sprintf(
+				"draw: path ends %g from its start (%g,%g), not closed with tolerance %g",
+				gap, start.X, start.Y, p.closeNearEps))
+		}
+		return nil, false
+	}
+	c := *p
+	c.segments = p.segments[: n-1 : n-1]
+	c.closed = true
+	c.closeNear = false
+	c.closeLine = last.line
+	c.closeOutSet, c.closeOut = last.outSet, last.outDir
+	c.closeCurlOutSet, c.closeCurlOut = last.outCurlSet, last.outCurl
+	c.closeOutTSet, c.closeOutT = last.outTSet, last.outTension
+	c.closeInTSet, c.closeInT = last.inTSet, last.inTension
+	c.closeCurlInSet, c.closeCurlIn = last.inCurlSet, last.inCurl
+	c.closeExplicit, c.closeCtrl1, c.closeCtrl2 = last.explicit, last.ctrl1, last.ctrl2
+	// A direction given after the last point is the direction at the start.
+	switch {
+	case last.inSet:
+		c.closeInSet, c.closeIn = true, last.inDir
+	case p.inSet:
+		c.closeInSet, c.closeIn = true, p.inDir
+	case p.outSet:
+		c.closeInSet, c.closeIn = true, p.outDir
+	default:
+		c.closeInSet = false
+	}
+	return &c, true
+}
+
 // resolveStart returns the start point, resolving from Var if needed.
 func (p *PathBuilder) resolveStart() mp.Point {
 	if p.startVar != nil {
@@ -519,10 +819,18 @@ func (seg *segment) resolveTarget() mp.Point {
 // BuildPath constructs an mp.Path with knots configured for given directions.
 // Directions are converted to MetaPost's scaled degrees.
 // If the path uses context variables, the context must be solved first.
+// A builder with a start point but no segments gives a single-knot path,
+// which is drawn as a dot (MetaPost: draw z0).
 func (p *PathBuilder) BuildPath() *mp.Path {
-	if !p.startSet || len(p.segments) == 0 {
+	if !p.startSet {
 		return &mp.Path{}
 	}
+	if p.closeNear && len(p.segments) > 0 {
+		p.warnings = nil
+		if c, ok := p.closedNear(); ok {
+			return c.BuildPath()
+		}
+	}
 	path := mp.NewPath()
 	if p.styleSet {
 		path.Style.Stroke = p.stroke
@@ -533,6 +841,9 @@ func (p *PathBuilder) BuildPath() *mp.Path {
 		path.Style.LineCap = p.lineCap
 		path.Style.Arrow.Start = p.arrowStart
 		path.Style.Arrow.End = p.arrowEnd
+		path.Style.Arrow.Joined = p.arrowJoined
+		path.Style.Arrow.Shape = p.arrowShape
+		path.Style.Arrow.Head = p.arrowHead
 		if p.arrowLength > 0 {
 			path.Style.Arrow.Length = p.arrowLength
 		} else {
@@ -544,10 +855,33 @@ func (p *PathBuilder) BuildPath() *mp.Path {
 			path.Style.Arrow.Angle = mp.DefaultAHAngle
 		}
 		path.Style.Dash = p.dash
+		path.Style.LineStyle = p.lineStyle
+		path.Style.Gradient = p.gradient
+		path.Style.Hairline = p.hairline
+		path.Style.AutoCloseFill = p.autoCloseFill
+		if len(p.meta) > 0 {
+			path.Style.Meta = make(map[string]string, len(p.meta))
+			for k, v := range p.meta {
+				path.Style.Meta[k] = v
+			}
+		}
+	}
+
+	if p.closed && p.closing != nil {
+		closing := *p.closing
+		path.Style.Closing = &closing
 	}
 
 	// Resolve start point (from Var if set)
 	startPt := p.resolveStart()
+	if len(p.segments) == 0 {
+		path.Append(&mp.Knot{
+			XCoord: startPt.X, YCoord: startPt.Y,
+			LeftX: startPt.X, LeftY: startPt.Y,
+			RightX: startPt.X, RightY: startPt.Y,
+		})
+		return path
+	}
 
 	// start knot
 	start := mp.NewKnot()
@@ -559,7 +893,9 @@ func (p *PathBuilder) BuildPath() *mp.Path {
 	start.RightY = 1
 	if p.closed && len(p.segments) > 0 {
 		last := p.segments[len(p.segments)-1]
-		if last.inTSet {
+		if p.closeInTSet {
+			start.LeftY = p.closeInT
+		} else if last.inTSet {
 			start.LeftY = last.inTension
 		}
 	}
@@ -567,13 +903,17 @@ func (p *PathBuilder) BuildPath() *mp.Path {
 		start.RightY = p.segments[0].outTension
 	}
 	if p.closed {
-		if p.closeInSet {
+		if p.closeExplicit {
+			start.LType = mp.KnotExplicit
+			start.LeftX = p.closeCtrl2.X
+			start.LeftY = p.closeCtrl2.Y
+		} else if p.closeInSet {
 			start.LType = mp.KnotGiven
 			start.LeftX = degToAngle(p.closeIn)
 		} else if p.closeCurlInSet {
 			start.LType = mp.KnotCurl
 			start.LeftX = p.closeCurlIn
-		} else if p.segments[len(p.segments)-1].line {
+		} else if p.closeLine || p.segments[len(p.segments)-1].line {
 			start.LType = mp.KnotCurl
 			start.LeftX = 1 // curl 1
 		} else {
@@ -599,7 +939,7 @@ func (p *PathBuilder) BuildPath() *mp.Path {
 		// Default boundary condition for open paths: curl 1 (mp.w ~7890).
 		start.RType = mp.KnotCurl
 		start.RightX = 1
-	} else if p.segments[len(p.segments)-1].line {
+	} else if p.closeLine || p.segments[len(p.segments)-1].line {
 		// Closed path ending with a line (e.g., z0..z1--cycle): the first knot
 		// inherits curl boundary from the line closure, matching MetaPost semantics.
 		start.RType = mp.KnotCurl
@@ -607,6 +947,9 @@ func (p *PathBuilder) BuildPath() *mp.Path {
 	} else {
 		start.RType = mp.KnotOpen
 	}
+	if p.closed {
+		plugCycleKnot(start)
+	}
 	path.Append(start)
 
 	// each segment adds an end knot; if multiple segments, intermediate knots chain.
@@ -674,20 +1017,34 @@ func (p *PathBuilder) BuildPath() *mp.Path {
 				end.LType = mp.KnotCurl
 				end.LeftX = 1
 			}
+		} else if p.closeLine {
+			end.LType = mp.KnotCurl
+			end.LeftX = 1
 		} else {
 			end.LType = mp.KnotOpen
 		}
 		if i == len(p.segments)-1 {
 			if p.closed {
-				if p.closeOutSet {
+				if p.closeExplicit {
+					end.RType = mp.KnotExplicit
+					end.RightX = p.closeCtrl1.X
+					end.RightY = p.closeCtrl1.Y
+				} else if p.closeOutSet {
 					end.RType = mp.KnotGiven
 					end.RightX = degToAngle(p.closeOut)
-				} else if seg.line {
+				} else if p.closeCurlOutSet {
+					end.RType = mp.KnotCurl
+					end.RightX = p.closeCurlOut
+				} else if seg.line || p.closeLine {
 					end.RType = mp.KnotCurl
 					end.RightX = 1 // curl 1
 				} else {
 					end.RType = mp.KnotOpen
 				}
+				if p.closeOutTSet && !p.closeExplicit {
+					end.RightY = p.closeOutT
+				}
+				plugCycleKnot(end)
 			} else {
 				end.RType = mp.KnotEndpoint
 			}
@@ -720,6 +1077,18 @@ func (p *PathBuilder) BuildPath() *mp.Path {
 	return path
 }
 
+// plugCycleKnot fills an open side of a knot of a cycle from a direction
+// or curl given on its other side, as MetaPost does when it joins the
+// pieces of a path (mp.w, "Plug an opening in right_type(pp)").
+func plugCycleKnot(k *mp.Knot) {
+	switch {
+	case k.RType == mp.KnotOpen && (k.LType == mp.KnotGiven || k.LType == mp.KnotCurl):
+		k.RType, k.RightX = k.LType, k.LeftX
+	case k.LType == mp.KnotOpen && (k.RType == mp.KnotGiven || k.RType == mp.KnotCurl):
+		k.LType, k.LeftX = k.RType, k.RightX
+	}
+}
+
 // Solve builds the path, solves it with a new engine, and applies
 // any pending transformations. For better performance when solving
 // many paths, use SolveWithEngine to reuse an engine.
@@ -730,9 +1099,20 @@ func (p *PathBuilder) Solve() (*mp.Path, error) {
 // SolveWithEngine appends the built path to the engine, runs Solve,
 // and applies any pending transformations.
 func (p *PathBuilder) SolveWithEngine(e *mp.Engine) (*mp.Path, error) {
+	return p.SolveWithEngineContext(context.Background(), e)
+}
+
+// SolveContext is Solve with cancellation, see mp.Engine.SolveContext.
+func (p *PathBuilder) SolveContext(ctx context.Context) (*mp.Path, error) {
+	return p.SolveWithEngineContext(ctx, mp.NewEngine())
+}
+
+// SolveWithEngineContext is SolveWithEngine with cancellation. Progress is
+// reported through the engine, see mp.Engine.SetProgress.
+func (p *PathBuilder) SolveWithEngineContext(ctx context.Context, e *mp.Engine) (*mp.Path, error) {
 	path := p.BuildPath()
 	e.AddPath(path)
-	if err := e.Solve(); err != nil {
+	if err := e.SolveContext(ctx); err != nil {
 		return nil, err
 	}
 	// Apply any pending transformations
@@ -747,6 +1127,31 @@ func (p *PathBuilder) SolveWithEngine(e *mp.Engine) (*mp.Path, error) {
 	return result, nil
 }
 
+// SolveMultiPath solves each builder as one component of a multi-part path
+// and returns them combined into an mp.MultiPath. The style of the first
+// builder is used for the whole object.
+//
+// Example:
+//
+//	ring, _ := draw.SolveMultiPath(outer, inner)  // one <path> with two subpaths
+func SolveMultiPath(parts ...*PathBuilder) (*mp.MultiPath, error) {
+	m := mp.NewMultiPath()
+	for i, pb := range parts {
+		path, err := pb.Solve()
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			m.Style = path.Style
+		}
+		m.Append(path)
+	}
+	for _, path := range m.Parts {
+		path.Style = m.Style
+	}
+	return m, nil
+}
+
 // MetaPost angles are stored as degrees scaled by angleMultiplier.
 func degToAngle(d float64) float64 {
 	return d * mp.AngleMultiplier()
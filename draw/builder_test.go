@@ -1,6 +1,8 @@
 package draw
 
 import (
+	"context"
+	"errors"
 	"github.com/boxesandglue/mpgo/svg"
 	"math"
 	"strings"
@@ -50,3 +52,161 @@ func TestSolvePathProducesFiniteControls(t *testing.T) {
 		t.Fatalf("SVG output invalid: %q", svg)
 	}
 }
+
+// samePath reports whether a and b have the same knots and control points.
+func samePath(t *testing.T, a, b *mp.Path) {
+	t.Helper()
+	ka, kb := a.Head, b.Head
+	for {
+		for _, d := range []float64{
+			ka.XCoord - kb.XCoord, ka.YCoord - kb.YCoord,
+			ka.LeftX - kb.LeftX, ka.LeftY - kb.LeftY,
+			ka.RightX - kb.RightX, ka.RightY - kb.RightY,
+		} {
+			if math.Abs(d) > 1e-9 {
+				t.Fatalf("knot (%g,%g) differs from (%g,%g)", ka.XCoord, ka.YCoord, kb.XCoord, kb.YCoord)
+			}
+		}
+		if ka.RType != kb.RType || ka.LType != kb.LType {
+			t.Fatalf("knot (%g,%g): types %v/%v, want %v/%v", ka.XCoord, ka.YCoord, ka.LType, ka.RType, kb.LType, kb.RType)
+		}
+		ka, kb = ka.Next, kb.Next
+		if (ka == a.Head) != (kb == b.Head) {
+			t.Fatal("paths have different numbers of knots")
+		}
+		if ka == a.Head || ka == nil || kb == nil {
+			return
+		}
+	}
+}
+
+func TestCloseIfNearPolygon(t *testing.T) {
+	got, err := NewPath().MoveTo(P(0, 0)).
+		LineTo(P(10, 0)).LineTo(P(10, 10)).LineTo(P(0, 10)).LineTo(P(0.001, 0)).
+		CloseIfNear(0.01).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := NewPath().MoveTo(P(0, 0)).
+		LineTo(P(10, 0)).LineTo(P(10, 10)).LineTo(P(0, 10)).
+		Close().Solve()
+	samePath(t, got, want)
+}
+
+func TestCloseIfNearCurve(t *testing.T) {
+	pb := NewPath().MoveTo(P(10, 0)).CurveTo(P(0, 10)).CurveTo(P(-10, 0)).CurveTo(P(0, -10))
+	got, err := pb.CurveTo(P(10, 0)).CloseIfNear(0).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := NewPath().MoveTo(P(10, 0)).CurveTo(P(0, 10)).CurveTo(P(-10, 0)).CurveTo(P(0, -10)).
+		Close().Solve()
+	samePath(t, got, want)
+	if len(pb.Warnings()) != 0 {
+		t.Errorf("unexpected warnings %v", pb.Warnings())
+	}
+}
+
+func TestCloseIfNearWarnsWhenNearlyClosed(t *testing.T) {
+	pb := NewPath().MoveTo(P(0, 0)).LineTo(P(100, 0)).LineTo(P(100, 100)).LineTo(P(0.5, 0)).
+		CloseIfNear(0.1)
+	path, err := pb.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.Head.LType != mp.KnotEndpoint {
+		t.Error("path with a gap above the tolerance should stay open")
+	}
+	if len(pb.Warnings()) != 1 {
+		t.Errorf("expected one warning, got %v", pb.Warnings())
+	}
+
+	// An open arc is far from closed and not worth a warning.
+	pb = NewPath().MoveTo(P(0, 0)).CurveTo(P(50, 50)).CurveTo(P(100, 0)).CloseIfNear(0.1)
+	pb.BuildPath()
+	if len(pb.Warnings()) != 0 {
+		t.Errorf("unexpected warnings %v", pb.Warnings())
+	}
+}
+
+func TestPathBuilderSolveContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := NewPath().MoveTo(P(0, 0)).CurveTo(P(10, 10)).SolveContext(ctx); err != nil {
+		t.Fatalf("SolveContext = %v", err)
+	}
+	cancel()
+	if _, err := NewPath().MoveTo(P(0, 0)).CurveTo(P(10, 10)).SolveContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("SolveContext = %v, want context.Canceled", err)
+	}
+}
+
+func TestStraightTo(t *testing.T) {
+	path, err := NewPath().MoveTo(mp.P(0, 0)).CurveTo(mp.P(50, 50)).StraightTo(mp.P(100, 50)).CurveTo(mp.P(150, 0)).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	z1 := path.Head.Next
+	z2 := z1.Next
+	// The middle segment is straight: its controls lie on the chord, close
+	// to the knots.
+	for _, c := range []mp.Point{mp.P(z1.RightX, z1.RightY), mp.P(z2.LeftX, z2.LeftY)} {
+		if math.Abs(c.Y-50) > 1e-6 || c.X < 50 || c.X > 100 {
+			t.Errorf("control %v is off the chord", c)
+		}
+	}
+	if d := z1.RightX - 50; d <= 0 || d > 0.05 {
+		t.Errorf("control %g from z1, want a tiny positive distance", d)
+	}
+	// The curve before it arrives in the direction of the line.
+	if dy := z1.YCoord - z1.LeftY; math.Abs(dy) > 1e-6 {
+		t.Errorf("incoming direction at z1 is not horizontal: dy = %g", dy)
+	}
+}
+
+func TestCloseBoundaryConditions(t *testing.T) {
+	z := []mp.Point{mp.P(0, 0), mp.P(100, 0), mp.P(50, 80)}
+	tests := []struct {
+		name string
+		pb   *PathBuilder
+		want string
+	}{
+		{"in direction also leaves the start",
+			NewPath().MoveTo(z[0]).CurveTo(z[1]).CurveTo(z[2]).WithIncomingDirection(-90).Close(),
+			"(0,0){0,-1}\n ..(100,0)\n ..(50,80)\n ..{0,-1}cycle"},
+		{"corner at the start",
+			NewPath().MoveTo(z[0]).CurveTo(z[1]).CurveTo(z[2]).CloseAtStart(-90, 0),
+			"(0,0){1,0}\n ..(100,0)\n ..(50,80)\n ..{0,-1}cycle"},
+		{"directions of the closing segment",
+			NewPath().MoveTo(z[0]).CurveTo(z[1]).CurveTo(z[2]).CloseDir(180, -90),
+			"(0,0){0,-1}\n ..(100,0)\n ..{-1,0}(50,80){-1,0}\n ..{0,-1}cycle"},
+		{"curls of the closing segment",
+			NewPath().MoveTo(z[0]).CurveTo(z[1]).CurveTo(z[2]).CloseCurl(2, 0),
+			"(0,0){curl 0}\n ..(100,0)\n ..{curl 2}(50,80){curl 2}\n ..{curl 0}cycle"},
+		{"tension of the closing segment",
+			NewPath().MoveTo(z[0]).CurveTo(z[1]).CurveTo(z[2]).WithTension(2).Close(),
+			"(0,0)\n ..(100,0)\n ..(50,80)..tension 2\n ..cycle"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.pb.BuildPath().Show(); got != tc.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tc.want)
+			}
+			if _, err := tc.pb.Solve(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// The solved corner leaves and enters the start in the given directions.
+	path, err := NewPath().MoveTo(z[0]).CurveTo(z[1]).CurveTo(z[2]).CloseAtStart(-90, 0).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := path.Head
+	if math.Abs(h.RightY) > 1e-9 || h.RightX <= 0 {
+		t.Errorf("start leaves towards (%g,%g), want along +x", h.RightX, h.RightY)
+	}
+	if math.Abs(h.LeftX) > 1e-9 || h.LeftY <= 0 {
+		t.Errorf("start is entered from (%g,%g), want from +y", h.LeftX, h.LeftY)
+	}
+}
@@ -22,10 +22,15 @@ import (
 //	ctx.EqX(z1, 50)            // z1.x = 50
 //	ctx.Solve()
 //	fmt.Println(z1.XY())       // (50, 50)
+//
+// Equations may be added after Solve; the next Solve takes the values
+// found so far as known.
 type Context struct {
-	vars   []*Var
-	eqns   []equation
-	solved bool
+	vars     []*Var
+	scalars  []*Scalar
+	eqns     []equation
+	deferred []scalarBetween // p = t[a,b] with t and a or b unknown when added
+	solved   bool
 }
 
 // Var represents a point variable with x and y components.
@@ -38,15 +43,38 @@ type Var struct {
 	yKnown bool
 }
 
+// Scalar is a numeric unknown, such as the parameter of MetaPost's
+// whatever (see Context.Whatever).
+type Scalar struct {
+	ctx   *Context
+	index int
+	v     float64
+	known bool
+}
+
 // equation represents a linear equation: sum of (coeff * variable) = constant
 // For point equations, we have separate equations for x and y components.
 type equation struct {
 	// coeffs maps variable index -> coefficient
-	// For x-equations: index*2, for y-equations: index*2+1
+	// For x-equations: index*2, for y-equations: index*2+1; scalar i has
+	// the key -(i+1)
 	coeffs   map[int]float64
 	constant float64
 }
 
+// scalarBetween is the constraint p = t[a,b], linear once t or both a and
+// b are known.
+type scalarBetween struct {
+	p, a, b *Var
+	t       *Scalar
+}
+
+// add appends eq to the equations to solve.
+func (c *Context) add(eq equation) {
+	c.eqns = append(c.eqns, eq)
+	c.solved = false
+}
+
 // NewContext creates a new equation-solving context.
 func NewContext() *Context {
 	return &Context{
@@ -117,6 +145,7 @@ func (v *Var) Point() mp.Point {
 func (v *Var) SetX(x float64) *Var {
 	v.x = x
 	v.xKnown = true
+	v.ctx.solved = false
 	return v
 }
 
@@ -124,6 +153,7 @@ func (v *Var) SetX(x float64) *Var {
 func (v *Var) SetY(y float64) *Var {
 	v.y = y
 	v.yKnown = true
+	v.ctx.solved = false
 	return v
 }
 
@@ -133,6 +163,7 @@ func (v *Var) SetXY(x, y float64) *Var {
 	v.y = y
 	v.xKnown = true
 	v.yKnown = true
+	v.ctx.solved = false
 	return v
 }
 
@@ -150,7 +181,7 @@ func (c *Context) EqX(v *Var, x float64) {
 		coeffs:   map[int]float64{v.index * 2: 1},
 		constant: x,
 	}
-	c.eqns = append(c.eqns, eq)
+	c.add(eq)
 }
 
 // EqY constrains a variable's y-coordinate to a value.
@@ -159,7 +190,7 @@ func (c *Context) EqY(v *Var, y float64) {
 		coeffs:   map[int]float64{v.index*2 + 1: 1},
 		constant: y,
 	}
-	c.eqns = append(c.eqns, eq)
+	c.add(eq)
 }
 
 // EqVar constrains two variables to be equal.
@@ -175,7 +206,7 @@ func (c *Context) EqVarX(a, b *Var) {
 		coeffs:   map[int]float64{a.index * 2: 1, b.index * 2: -1},
 		constant: 0,
 	}
-	c.eqns = append(c.eqns, eq)
+	c.add(eq)
 }
 
 // EqVarY constrains two variables to have equal y-coordinates.
@@ -185,7 +216,7 @@ func (c *Context) EqVarY(a, b *Var) {
 		coeffs:   map[int]float64{a.index*2 + 1: 1, b.index*2 + 1: -1},
 		constant: 0,
 	}
-	c.eqns = append(c.eqns, eq)
+	c.add(eq)
 }
 
 // MidPoint constrains m to be the midpoint of a and b.
@@ -207,7 +238,7 @@ func (c *Context) Between(p, a, b *Var, t float64) {
 		},
 		constant: 0,
 	}
-	c.eqns = append(c.eqns, eqX)
+	c.add(eqX)
 
 	// p.y = (1-t)*a.y + t*b.y
 	eqY := equation{
@@ -218,43 +249,106 @@ func (c *Context) Between(p, a, b *Var, t float64) {
 		},
 		constant: 0,
 	}
-	c.eqns = append(c.eqns, eqY)
+	c.add(eqY)
 }
 
 // Collinear constrains p to lie on the line through a and b.
 // This adds the constraint that p, a, b are collinear, but doesn't
 // determine WHERE on the line p is - you need another constraint for that.
+// It is p = whatever[a, b] (see BetweenWhatever), so a and b may still be
+// unknown.
 func (c *Context) Collinear(p, a, b *Var) {
-	// Collinearity: (p - a) × (b - a) = 0
-	// (p.x - a.x)(b.y - a.y) - (p.y - a.y)(b.x - a.x) = 0
-	//
-	// This is NOT a linear equation in general. However, if a and b are known,
-	// it becomes linear in p.
-	//
-	// For the general case, we use a parametric approach:
-	// p = a + t*(b - a) for some t
-	// This requires introducing an auxiliary variable t.
-	//
-	// For now, we handle the case where a and b are known:
-	if a.xKnown && a.yKnown && b.xKnown && b.yKnown {
-		// (p.x - a.x)(b.y - a.y) = (p.y - a.y)(b.x - a.x)
-		// p.x*(b.y - a.y) - p.y*(b.x - a.x) = a.x*(b.y - a.y) - a.y*(b.x - a.x)
-		dx := b.x - a.x
-		dy := b.y - a.y
-		eq := equation{
-			coeffs: map[int]float64{
-				p.index * 2:   dy,  // coeff for p.x
-				p.index*2 + 1: -dx, // coeff for p.y
-			},
-			constant: a.x*dy - a.y*dx,
+	c.BetweenWhatever(p, a, b)
+}
+
+// BetweenWhatever constrains p to lie on the line through a and b at a
+// new unknown parameter, MetaPost's p = whatever[a,b], and returns the
+// parameter. The equation is not linear while both the parameter and one
+// of a and b are unknown; Solve then first solves the other equations
+// and uses it once a and b are known, so a and b may be determined by
+// equations added later.
+//
+// Example:
+//
+//	// z5 is where z1--z2 crosses z3--z4 (z5 = whatever[z1,z2] = whatever[z3,z4])
+//	ctx.BetweenWhatever(z5, z1, z2)
+//	ctx.BetweenWhatever(z5, z3, z4)
+func (c *Context) BetweenWhatever(p, a, b *Var) *Scalar {
+	t := c.Whatever()
+	c.BetweenScalar(p, a, b, t)
+	return t
+}
+
+// BetweenScalar constrains p to lie at parameter t on the line from a to
+// b, p = t[a,b], where t is a scalar unknown (see BetweenWhatever).
+func (c *Context) BetweenScalar(p, a, b *Var, t *Scalar) {
+	c.deferred = append(c.deferred, scalarBetween{p, a, b, t})
+	c.solved = false
+}
+
+// Whatever returns a new scalar unknown, like MetaPost's whatever: every
+// call gives a different one. Its value is set by Solve if the equations
+// determine it.
+func (c *Context) Whatever() *Scalar {
+	s := &Scalar{ctx: c, index: len(c.scalars)}
+	c.scalars = append(c.scalars, s)
+	return s
+}
+
+// Value returns the value of s and whether Solve determined it.
+func (s *Scalar) Value() (float64, bool) {
+	return s.v, s.known
+}
+
+// Term is a coefficient times a point variable, or a scalar unknown times
+// a fixed vector, in a linear combination (see Context.Linear).
+type Term struct {
+	k float64
+	v *Var
+	s *Scalar
+	d mp.Point
+}
+
+// T returns the term k*v.
+func T(k float64, v *Var) Term {
+	return Term{k: k, v: v}
+}
+
+// Times returns the term s*d, e.g. whatever*(1,2) in MetaPost.
+func (s *Scalar) Times(d mp.Point) Term {
+	return Term{k: 1, s: s, d: d}
+}
+
+// Linear constrains the sum of terms to equal the point constant, one
+// equation for x and one for y. Any linear relation between points can be
+// written this way.
+//
+// Example:
+//
+//	// z3 - z1 = 2(z2 - z1) + (0, 10)
+//	ctx.Linear(mp.P(0, 10), draw.T(1, z3), draw.T(1, z1), draw.T(-2, z2))
+//	// z4 = z1 + whatever*(1, 1)
+//	ctx.Linear(mp.P(0, 0), draw.T(1, z4), draw.T(-1, z1), ctx.Whatever().Times(mp.P(-1, -1)))
+func (c *Context) Linear(constant mp.Point, terms ...Term) {
+	eqX := equation{coeffs: map[int]float64{}, constant: constant.X}
+	eqY := equation{coeffs: map[int]float64{}, constant: constant.Y}
+	for _, t := range terms {
+		if t.s != nil {
+			key := -(t.s.index + 1)
+			eqX.coeffs[key] += t.k * t.d.X
+			eqY.coeffs[key] += t.k * t.d.Y
+			continue
 		}
-		c.eqns = append(c.eqns, eq)
+		eqX.coeffs[t.v.index*2] += t.k
+		eqY.coeffs[t.v.index*2+1] += t.k
 	}
-	// TODO: Handle case where a or b are unknown (requires nonlinear solving)
+	c.add(eqX)
+	c.add(eqY)
 }
 
 // Intersection constrains p to be the intersection of line a1-a2 and line b1-b2.
-// All of a1, a2, b1, b2 must be known.
+// All of a1, a2, b1, b2 must be known; otherwise use BetweenWhatever for
+// both lines.
 func (c *Context) Intersection(p, a1, a2, b1, b2 *Var) error {
 	if !a1.xKnown || !a1.yKnown || !a2.xKnown || !a2.yKnown ||
 		!b1.xKnown || !b1.yKnown || !b2.xKnown || !b2.yKnown {
@@ -285,7 +379,7 @@ func (c *Context) Sum(result, a, b *Var) {
 		},
 		constant: 0,
 	}
-	c.eqns = append(c.eqns, eqX)
+	c.add(eqX)
 
 	// result.y = a.y + b.y
 	eqY := equation{
@@ -296,7 +390,7 @@ func (c *Context) Sum(result, a, b *Var) {
 		},
 		constant: 0,
 	}
-	c.eqns = append(c.eqns, eqY)
+	c.add(eqY)
 }
 
 // Diff constrains: result = a - b (vector subtraction)
@@ -310,7 +404,7 @@ func (c *Context) Diff(result, a, b *Var) {
 		},
 		constant: 0,
 	}
-	c.eqns = append(c.eqns, eqX)
+	c.add(eqX)
 
 	// result.y = a.y - b.y
 	eqY := equation{
@@ -321,7 +415,7 @@ func (c *Context) Diff(result, a, b *Var) {
 		},
 		constant: 0,
 	}
-	c.eqns = append(c.eqns, eqY)
+	c.add(eqY)
 }
 
 // Scaled constrains: result = t * v (scalar multiplication)
@@ -334,7 +428,7 @@ func (c *Context) Scaled(result, v *Var, t float64) {
 		},
 		constant: 0,
 	}
-	c.eqns = append(c.eqns, eqX)
+	c.add(eqX)
 
 	// result.y = t * v.y
 	eqY := equation{
@@ -344,7 +438,7 @@ func (c *Context) Scaled(result, v *Var, t float64) {
 		},
 		constant: 0,
 	}
-	c.eqns = append(c.eqns, eqY)
+	c.add(eqY)
 }
 
 // LinearXY adds the constraint: cx*v.x + cy*v.y = constant.
@@ -358,143 +452,207 @@ func (c *Context) LinearXY(v *Var, cx, cy, constant float64) {
 		},
 		constant: constant,
 	}
-	c.eqns = append(c.eqns, eq)
+	c.add(eq)
 }
 
 // --- Solver ---
 
 // Solve solves the system of equations and updates all variables.
-// Returns an error if the system is unsolvable or underdetermined.
+// Equations that are not linear yet (BetweenScalar with unknown ends) are
+// used as soon as solving the others determines their ends. Scalars may
+// stay unknown. Returns an error if the system is inconsistent or a point
+// coordinate is left undetermined.
 func (c *Context) Solve() error {
 	if c.solved {
 		return nil
 	}
-
-	// First, apply known values as equations
+	eqns := c.eqns
+	pending := c.deferred
+	for {
+		if err := c.solveLinear(eqns); err != nil {
+			return err
+		}
+		var rest []scalarBetween
+		progress := false
+		for _, d := range pending {
+			if lin, ok := d.linearize(); ok {
+				eqns = append(eqns[:len(eqns):len(eqns)], lin...)
+				progress = true
+			} else {
+				rest = append(rest, d)
+			}
+		}
+		pending = rest
+		if !progress {
+			break
+		}
+	}
+	unknown := 0
 	for _, v := range c.vars {
-		if v.xKnown {
-			c.eqns = append(c.eqns, equation{
-				coeffs:   map[int]float64{v.index * 2: 1},
-				constant: v.x,
-			})
+		if !v.xKnown {
+			unknown++
 		}
-		if v.yKnown {
-			c.eqns = append(c.eqns, equation{
-				coeffs:   map[int]float64{v.index*2 + 1: 1},
-				constant: v.y,
-			})
+		if !v.yKnown {
+			unknown++
+		}
+	}
+	if unknown > 0 {
+		if len(pending) > 0 {
+			return fmt.Errorf("underdetermined system: %d coordinates unknown, %d whatever equations with unknown ends", unknown, len(pending))
 		}
+		return fmt.Errorf("underdetermined system: %d coordinates unknown", unknown)
 	}
+	c.solved = true
+	return nil
+}
 
-	// Number of variables (each point has x and y)
-	numVars := len(c.vars) * 2
+// linearize returns the two linear equations of p = t[a,b] if t or both
+// ends are known.
+func (d scalarBetween) linearize() ([]equation, bool) {
+	px, py := d.p.index*2, d.p.index*2+1
+	ax, ay := d.a.index*2, d.a.index*2+1
+	bx, by := d.b.index*2, d.b.index*2+1
+	switch {
+	case d.t.known:
+		t := d.t.v
+		return []equation{
+			{coeffs: map[int]float64{px: 1, ax: -(1 - t), bx: -t}},
+			{coeffs: map[int]float64{py: 1, ay: -(1 - t), by: -t}},
+		}, true
+	case d.a.xKnown && d.a.yKnown && d.b.xKnown && d.b.yKnown:
+		// p - t*(b - a) = a
+		key := -(d.t.index + 1)
+		return []equation{
+			{coeffs: map[int]float64{px: 1, key: -(d.b.x - d.a.x)}, constant: d.a.x},
+			{coeffs: map[int]float64{py: 1, key: -(d.b.y - d.a.y)}, constant: d.a.y},
+		}, true
+	}
+	return nil, false
+}
+
+// solveLinear solves eqns together with the values known so far and marks
+// every coordinate and scalar the system determines as known.
+func (c *Context) solveLinear(eqns []equation) error {
+	numPoints := len(c.vars) * 2
+	numVars := numPoints + len(c.scalars)
 	if numVars == 0 {
-		c.solved = true
 		return nil
 	}
-
-	// Build augmented matrix for Gaussian elimination
-	// Each row is an equation, columns are variable coefficients + constant
-	numEqs := len(c.eqns)
-	if numEqs < numVars {
-		return fmt.Errorf("underdetermined system: %d equations for %d variables", numEqs, numVars)
+	column := func(key int) int {
+		if key < 0 {
+			return numPoints - key - 1
+		}
+		return key
 	}
-
-	matrix := make([][]float64, numEqs)
-	for i, eq := range c.eqns {
-		row := make([]float64, numVars+1)
-		for varIdx, coeff := range eq.coeffs {
-			if varIdx < numVars {
-				row[varIdx] = coeff
-			}
+	var matrix [][]float64
+	row := func(coeffs map[int]float64, constant float64) {
+		r := make([]float64, numVars+1)
+		for key, coeff := range coeffs {
+			r[column(key)] += coeff
+		}
+		r[numVars] = constant
+		matrix = append(matrix, r)
+	}
+	for _, eq := range eqns {
+		row(eq.coeffs, eq.constant)
+	}
+	// Known values are equations too
+	for _, v := range c.vars {
+		if v.xKnown {
+			row(map[int]float64{v.index * 2: 1}, v.x)
+		}
+		if v.yKnown {
+			row(map[int]float64{v.index*2 + 1: 1}, v.y)
+		}
+	}
+	for _, s := range c.scalars {
+		if s.known {
+			row(map[int]float64{-(s.index + 1): 1}, s.v)
 		}
-		row[numVars] = eq.constant
-		matrix[i] = row
 	}
 
-	// Gaussian elimination with partial pivoting
-	solution, err := gaussianElimination(matrix, numVars)
+	solution, determined, err := gaussianElimination(matrix, numVars)
 	if err != nil {
 		return err
 	}
-
-	// Apply solution to variables
 	for i, v := range c.vars {
-		v.x = solution[i*2]
-		v.y = solution[i*2+1]
-		v.xKnown = true
-		v.yKnown = true
+		if determined[i*2] {
+			v.x, v.xKnown = solution[i*2], true
+		}
+		if determined[i*2+1] {
+			v.y, v.yKnown = solution[i*2+1], true
+		}
+	}
+	for i, s := range c.scalars {
+		if determined[numPoints+i] {
+			s.v, s.known = solution[numPoints+i], true
+		}
 	}
-
-	c.solved = true
 	return nil
 }
 
-// gaussianElimination solves Ax = b using Gaussian elimination with partial pivoting.
-func gaussianElimination(augmented [][]float64, numVars int) ([]float64, error) {
-	numRows := len(augmented)
-	if numRows == 0 {
-		return make([]float64, numVars), nil
-	}
-
+// gaussianElimination reduces the augmented matrix to reduced row echelon
+// form with partial pivoting. A variable is determined if its pivot row
+// has no other unknown left; the others may take any value.
+func gaussianElimination(augmented [][]float64, numVars int) (solution []float64, determined []bool, err error) {
 	const eps = 1e-10
 
-	// Forward elimination
-	for col := 0; col < numVars; col++ {
+	solution = make([]float64, numVars)
+	determined = make([]bool, numVars)
+	numRows := len(augmented)
+	pivotCols := make([]int, 0, numVars)
+	r := 0
+	for col := 0; col < numVars && r < numRows; col++ {
 		// Find pivot
 		maxRow := -1
 		maxVal := eps
-		for row := col; row < numRows; row++ {
+		for row := r; row < numRows; row++ {
 			if math.Abs(augmented[row][col]) > maxVal {
 				maxVal = math.Abs(augmented[row][col])
 				maxRow = row
 			}
 		}
-
 		if maxRow == -1 {
-			// No pivot found, variable might be free or determined by other equations
-			continue
+			continue // free variable
 		}
-
-		// Swap rows
-		if maxRow != col && col < numRows {
-			augmented[col], augmented[maxRow] = augmented[maxRow], augmented[col]
+		augmented[r], augmented[maxRow] = augmented[maxRow], augmented[r]
+		pivot := augmented[r][col]
+		for j := col; j <= numVars; j++ {
+			augmented[r][j] /= pivot
 		}
-
-		pivot := augmented[col][col]
-		if math.Abs(pivot) < eps {
-			continue
-		}
-
 		// Eliminate column
 		for row := 0; row < numRows; row++ {
-			if row == col {
+			if row == r || augmented[row][col] == 0 {
 				continue
 			}
-			factor := augmented[row][col] / pivot
+			factor := augmented[row][col]
 			for j := col; j <= numVars; j++ {
-				augmented[row][j] -= factor * augmented[col][j]
+				augmented[row][j] -= factor * augmented[r][j]
 			}
 		}
+		pivotCols = append(pivotCols, col)
+		r++
 	}
-
-	// Back substitution
-	solution := make([]float64, numVars)
-	for col := 0; col < numVars && col < numRows; col++ {
-		pivot := augmented[col][col]
-		if math.Abs(pivot) < eps {
-			// Check if this is inconsistent
-			if math.Abs(augmented[col][numVars]) > eps {
-				return nil, fmt.Errorf("inconsistent system at variable %d", col)
+	// Rows without pivot must read 0 = 0
+	for row := r; row < numRows; row++ {
+		if math.Abs(augmented[row][numVars]) > 1e-6 {
+			return nil, nil, errors.New("inconsistent system of equations")
+		}
+	}
+	for i, col := range pivotCols {
+		free := false
+		for j := col + 1; j < numVars; j++ {
+			if math.Abs(augmented[i][j]) > eps {
+				free = true
+				break
 			}
-			// Variable is free, assume 0
-			solution[col] = 0
-		} else {
-			solution[col] = augmented[col][numVars] / pivot
+		}
+		if !free {
+			solution[col] = augmented[i][numVars]
+			determined[col] = true
 		}
 	}
-
-	return solution, nil
+	return solution, determined, nil
 }
 
 // --- PathBuilder integration ---
@@ -2,6 +2,7 @@ package draw
 
 import (
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/boxesandglue/mpgo/mp"
@@ -300,6 +301,81 @@ func TestContext_TwoCollinearConstraints(t *testing.T) {
 	}
 }
 
+func TestContext_WhateverWithLaterEnds(t *testing.T) {
+	// z5 = whatever[z1,z2] = whatever[z3,z4], where z2 and z4 are only
+	// determined by equations added afterwards.
+	ctx := NewContext()
+	z1 := ctx.Known(0, 0)
+	z2 := ctx.Unknown()
+	z3 := ctx.Known(0, 100)
+	z4 := ctx.Unknown()
+	z5 := ctx.Unknown()
+	w := ctx.BetweenWhatever(z5, z1, z2)
+	ctx.BetweenWhatever(z5, z3, z4)
+	ctx.Linear(mp.P(100, 100), T(1, z2))           // z2 = (100,100)
+	ctx.Linear(mp.P(0, -100), T(1, z4), T(-1, z2)) // z4 = z2 + (0,-100)
+
+	if err := ctx.Solve(); err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if x, y := z5.XY(); math.Abs(x-50) > 1e-9 || math.Abs(y-50) > 1e-9 {
+		t.Errorf("z5 = (%v, %v), want (50, 50)", x, y)
+	}
+	if v, ok := w.Value(); !ok || math.Abs(v-0.5) > 1e-9 {
+		t.Errorf("whatever = %v (known %v), want 0.5", v, ok)
+	}
+}
+
+func TestContext_WhateverTimesVector(t *testing.T) {
+	// z2 = z1 + whatever*(1,2), x2 = 30
+	ctx := NewContext()
+	z1 := ctx.Known(10, 10)
+	z2 := ctx.Unknown()
+	ctx.Linear(mp.P(0, 0), T(1, z2), T(-1, z1), ctx.Whatever().Times(mp.P(-1, -2)))
+	ctx.EqX(z2, 30)
+
+	if err := ctx.Solve(); err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if x, y := z2.XY(); math.Abs(x-30) > 1e-9 || math.Abs(y-50) > 1e-9 {
+		t.Errorf("z2 = (%v, %v), want (30, 50)", x, y)
+	}
+}
+
+func TestContext_IncrementalSolve(t *testing.T) {
+	ctx := NewContext()
+	a := ctx.Known(0, 0)
+	b := ctx.Known(100, 0)
+	m := ctx.MidPointOf(a, b)
+	if err := ctx.Solve(); err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	p := ctx.Unknown()
+	ctx.Linear(mp.P(0, 20), T(1, p), T(-1, m)) // p = m + (0,20)
+	if err := ctx.Solve(); err != nil {
+		t.Fatalf("second Solve failed: %v", err)
+	}
+	if x, y := p.XY(); math.Abs(x-50) > 1e-9 || math.Abs(y-20) > 1e-9 {
+		t.Errorf("p = (%v, %v), want (50, 20)", x, y)
+	}
+	ctx.EqX(m, 10)
+	if err := ctx.Solve(); err == nil {
+		t.Error("contradicting a solved value gave no error")
+	}
+}
+
+func TestContext_Underdetermined(t *testing.T) {
+	ctx := NewContext()
+	a := ctx.Unknown()
+	b := ctx.Unknown()
+	p := ctx.Unknown()
+	ctx.Collinear(p, a, b) // ends never become known
+	ctx.EqX(p, 5)
+	if err := ctx.Solve(); err == nil || !strings.Contains(err.Error(), "whatever") {
+		t.Errorf("err = %v, want an underdetermined system with pending whatever equations", err)
+	}
+}
+
 func TestContext_PathBuilderIntegration(t *testing.T) {
 	// Create a triangle where one vertex is computed as the midpoint
 	// z0 = (0, 0), z1 = (100, 0), z2 = midpoint of z0 and z1 shifted up
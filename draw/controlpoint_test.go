@@ -450,6 +450,90 @@ func TestDirectionAtLeastControlsMatchMetaPost(t *testing.T) {
 	}
 }
 
+// Steep start, flat end: "..." must shorten the first control vector so the
+// segment stays inside the triangle of chord and tangents, including
+// MetaPost's 1+2^-12 safety factor; ".." overshoots it.
+// draw z0{dir 80}...{dir -5}z1 and z0{dir 80}..tension atleast .75..{dir -5}z1
+// with z0 = (0,0), z1 = (100,0).
+func TestAtLeastBoundingTriangle(t *testing.T) {
+	const tol = 1e-6
+	approx := func(a, b float64) bool { return math.Abs(a-b) <= tol }
+	for _, tc := range []struct {
+		tension            float64
+		c1x, c1y, c2x, c2y float64
+	}{
+		{1, 1.51885388, 8.61384842, 59.93980900, 3.50481257},
+		{0.75, 1.51885388, 8.61384842, 46.58641199, 4.67308343},
+	} {
+		path, err := NewPath().
+			MoveTo(P(0, 0)).
+			WithDirection(80).
+			WithTensionAtLeast(tc.tension).
+			CurveTo(P(100, 0)).
+			WithDirection(-5).
+			Solve()
+		if err != nil {
+			t.Fatalf("solve failed: %v", err)
+		}
+		k, q := path.Head, path.Head.Next
+		if !approx(k.RightX, tc.c1x) || !approx(k.RightY, tc.c1y) {
+			t.Errorf("atleast %g: c1 = (%.8f,%.8f), want (%.8f,%.8f)", tc.tension, k.RightX, k.RightY, tc.c1x, tc.c1y)
+		}
+		if !approx(q.LeftX, tc.c2x) || !approx(q.LeftY, tc.c2y) {
+			t.Errorf("atleast %g: c2 = (%.8f,%.8f), want (%.8f,%.8f)", tc.tension, q.LeftX, q.LeftY, tc.c2x, tc.c2y)
+		}
+	}
+
+	// The same segment with plain ".." leaves the triangle.
+	path, _ := NewPath().MoveTo(P(0, 0)).WithDirection(80).CurveTo(P(100, 0)).WithDirection(-5).Solve()
+	if k := path.Head; !approx(k.RightX, 7.66732364) || !approx(k.RightY, 43.48355319) {
+		t.Errorf("..: c1 = (%.8f,%.8f), want (7.66732364,43.48355319)", k.RightX, k.RightY)
+	}
+}
+
+// Where the triangle does not constrain the curve, or the segment has an
+// inflection, "..." must give the same controls as "..".
+func TestAtLeastMatchesPlainTension(t *testing.T) {
+	build := func(atLeast bool, pts []Point, dirs []float64) *mp.Path {
+		pb := NewPath().MoveTo(pts[0])
+		for i, pt := range pts[1:] {
+			if dirs != nil {
+				pb.WithDirection(dirs[i])
+			}
+			if atLeast {
+				pb.WithTensionAtLeast(1)
+			}
+			pb.CurveTo(pt)
+		}
+		if dirs != nil {
+			pb.WithDirection(dirs[len(dirs)-1])
+		}
+		path, err := pb.Solve()
+		if err != nil {
+			t.Fatalf("solve failed: %v", err)
+		}
+		return path
+	}
+	for name, tc := range map[string]struct {
+		pts  []Point
+		dirs []float64
+	}{
+		"arch":       {[]Point{P(0, 0), P(50, 30), P(100, 0)}, nil},
+		"symmetric":  {[]Point{P(0, 0), P(100, 0)}, []float64{60, -60}},
+		"inflection": {[]Point{P(0, 0), P(100, 0)}, []float64{45, 45}},
+	} {
+		plain, atLeast := build(false, tc.pts, tc.dirs), build(true, tc.pts, tc.dirs)
+		k, l := plain.Head, atLeast.Head
+		for i := 0; i < len(tc.pts)-1; i++ {
+			if math.Abs(k.RightX-l.RightX) > 1e-9 || math.Abs(k.RightY-l.RightY) > 1e-9 ||
+				math.Abs(k.Next.LeftX-l.Next.LeftX) > 1e-9 || math.Abs(k.Next.LeftY-l.Next.LeftY) > 1e-9 {
+				t.Errorf("%s: segment %d differs between .. and ...", name, i)
+			}
+			k, l = k.Next, l.Next
+		}
+	}
+}
+
 // direction_tension demo:
 // draw z0..z1..tension 1.5 and 1..z2..z3;
 // Controls from MetaPost 2.02 "show p".
@@ -75,6 +75,16 @@
 //	pic.Label("A", mp.P(0, 0), mp.AnchorLowerLeft)
 //	pic.DotLabel("B", mp.P(100, 0), mp.AnchorRight, mp.ColorCSS("blue"))
 //
+// A [Table] arranges pictures or texts in a grid of aligned rows and columns
+// and reports the cell positions, e.g. for truth tables and legends.
+//
+// A [Pipeline] runs geometry passes over a copy of a picture before it is
+// rendered: the built-in stages simplify polylines, remove duplicate paths,
+// cull what lies outside a rectangle, snap to a grid and expand dashes, and
+// custom passes implement [Stage]:
+//
+//	out, err := draw.NewPipeline(draw.SimplifyStage(0.1), draw.DedupeStage()).Run(pic)
+//
 // # Label Conversion
 //
 // Labels can be converted to glyph paths using the font package:
@@ -99,6 +109,13 @@
 //
 //	fmt.Println(z1.XY())       // (50, 50)
 //
+// As in MetaPost, a point can lie at an unknown parameter on a line whose
+// ends are only determined by later equations (z5 = whatever[z1,z2]), and
+// [Context.Linear] takes any linear combination of points:
+//
+//	ctx.BetweenWhatever(z5, z1, z2)
+//	ctx.BetweenWhatever(z5, z3, z4)  // z5 is where the lines cross
+//
 // Variables can be used in path building:
 //
 //	path, _ := draw.NewPath().
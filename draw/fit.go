@@ -0,0 +1,80 @@
+package draw
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// FitMode selects how FitTo maps a picture's bounding box into a target
+// rectangle.
+type FitMode int
+
+const (
+	// FitContain scales uniformly so the whole picture fits inside the
+	// rectangle and centers it (the smaller of the two scale factors).
+	FitContain FitMode = iota
+	// FitCover scales uniformly so the picture covers the whole rectangle
+	// and centers it; parts may lie outside (the larger scale factor).
+	FitCover
+	// FitStretch scales x and y independently so the bounding box matches
+	// the rectangle exactly. The aspect ratio is not preserved.
+	FitStretch
+)
+
+// FitTransform returns the transform that maps the picture's bounding box
+// into the rectangle (0,0)–(width,height) according to mode. ok is false if
+// the picture is empty or the target size is not positive. A bounding box
+// that is degenerate in one direction is centered in that direction.
+func (p *Picture) FitTransform(width, height float64, mode FitMode) (t mp.Transform, ok bool) {
+	if width <= 0 || height <= 0 {
+		return mp.Identity(), false
+	}
+	minX, minY, maxX, maxY, ok := p.BBox()
+	if !ok {
+		return mp.Identity(), false
+	}
+	w, h := maxX-minX, maxY-minY
+	var sx, sy float64
+	switch {
+	case w <= 0 && h <= 0:
+		sx, sy = 1, 1
+	case w <= 0:
+		sy = height / h
+		sx = sy
+	case h <= 0:
+		sx = width / w
+		sy = sx
+	default:
+		sx, sy = width/w, height/h
+		switch mode {
+		case FitContain:
+			sx = math.Min(sx, sy)
+			sy = sx
+		case FitCover:
+			sx = math.Max(sx, sy)
+			sy = sx
+		}
+	}
+	// Move the bbox center to the origin, scale, then center in the target.
+	t = mp.Shifted(-(minX+maxX)/2, -(minY+maxY)/2).
+		Then(mp.Transform{Txx: sx, Tyy: sy}).
+		Then(mp.Shifted(width/2, height/2))
+	return t, true
+}
+
+// FitTo transforms the picture so that its bounding box fits the rectangle
+// (0,0)–(width,height) according to mode and returns the applied transform.
+// An empty picture is left unchanged and the identity is returned.
+//
+// Example:
+//
+//	pic.FitTo(200, 100, draw.FitContain)  // fit into 200×100, keep aspect ratio
+func (p *Picture) FitTo(width, height float64, mode FitMode) mp.Transform {
+	t, ok := p.FitTransform(width, height, mode)
+	if !ok {
+		return mp.Identity()
+	}
+	p.Transform(t)
+	return t
+}
@@ -0,0 +1,66 @@
+package draw
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func fitTestPicture() *Picture {
+	// 40×20 rectangle with lower left corner at (10, 10), no stroke
+	rect := mp.XScaled(40).Then(mp.YScaled(20)).Then(mp.Shifted(10, 10)).ApplyToPath(mp.UnitSquare())
+	rect.Style.Stroke = mp.ColorCSS("none")
+	return NewPicture().AddPath(rect)
+}
+
+func TestFitTo(t *testing.T) {
+	tests := []struct {
+		mode FitMode
+		want [4]float64
+	}{
+		{FitContain, [4]float64{0, 25, 100, 75}},
+		{FitCover, [4]float64{-50, 0, 150, 100}},
+		{FitStretch, [4]float64{0, 0, 100, 100}},
+	}
+	for _, tc := range tests {
+		pic := fitTestPicture()
+		pic.FitTo(100, 100, tc.mode)
+		minX, minY, maxX, maxY, ok := pic.BBox()
+		if !ok {
+			t.Fatalf("mode %d: bbox not ok", tc.mode)
+		}
+		got := [4]float64{minX, minY, maxX, maxY}
+		for i := range got {
+			if math.Abs(got[i]-tc.want[i]) > 1e-9 {
+				t.Errorf("mode %d: got bbox %v, want %v", tc.mode, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFitToEmptyPicture(t *testing.T) {
+	pic := NewPicture()
+	if tr := pic.FitTo(100, 100, FitContain); tr != mp.Identity() {
+		t.Fatalf("expected identity for empty picture, got %+v", tr)
+	}
+}
+
+func TestExportTransform(t *testing.T) {
+	pic := fitTestPicture()
+	if tr := pic.ExportTransform(); tr != mp.Identity() {
+		t.Fatalf("expected identity before transforming, got %+v", tr)
+	}
+	pic.Transform(mp.Shifted(5, 0))
+	fit := pic.FitTo(100, 100, FitContain)
+	want := mp.Shifted(5, 0).Then(fit)
+	if got := pic.ExportTransform(); got != want {
+		t.Fatalf("ExportTransform = %+v, want %+v", got, want)
+	}
+	// The original lower left corner maps to the fitted one.
+	x, y := pic.ExportTransform().ApplyToPoint(10, 10)
+	if math.Abs(x) > 1e-9 || math.Abs(y-25) > 1e-9 {
+		t.Errorf("corner maps to (%g, %g), want (0, 25)", x, y)
+	}
+}
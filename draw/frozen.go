@@ -0,0 +1,84 @@
+package draw
+
+import "github.com/boxesandglue/mpgo/mp"
+
+// FrozenPicture is an immutable snapshot of a Picture, created by
+// Picture.Freeze. It can be rendered like a picture (it implements
+// svg.Picture and svg.MultiPathPicture) and used as a template: every
+// accessor returns fresh copies, so no caller can change the snapshot
+// through shared knots, styles or labels.
+type FrozenPicture struct {
+	pic                    *Picture
+	minX, minY, maxX, maxY float64
+	bboxOK                 bool
+}
+
+// Freeze returns an immutable snapshot of p. Later changes to p do not
+// affect the snapshot.
+//
+// Example:
+//
+//	base := pic.Freeze()
+//	for i := 0; i < 4; i++ {
+//		v := base.Picture().Transform(mp.Rotated(float64(90 * i)))
+//		b.AddPicture(v)
+//	}
+func (p *Picture) Freeze() *FrozenPicture {
+	f := &FrozenPicture{pic: p.Clone()}
+	f.minX, f.minY, f.maxX, f.maxY, f.bboxOK = f.pic.BBox()
+	return f
+}
+
+// Picture returns a new, mutable deep copy of the snapshot.
+func (f *FrozenPicture) Picture() *Picture {
+	return f.pic.Clone()
+}
+
+// Paths returns copies of the snapshot's paths.
+func (f *FrozenPicture) Paths() []*mp.Path {
+	paths := make([]*mp.Path, len(f.pic.paths))
+	for i, path := range f.pic.paths {
+		paths[i] = clonePath(path)
+	}
+	return paths
+}
+
+// MultiPaths returns copies of the snapshot's multi-part paths.
+func (f *FrozenPicture) MultiPaths() []*mp.MultiPath {
+	return f.pic.Clone().multiPaths
+}
+
+// MultiPathPositions returns the number of paths drawn before each
+// multi-path (see Picture.MultiPathPositions).
+func (f *FrozenPicture) MultiPathPositions() []int {
+	return f.pic.MultiPathPositions()
+}
+
+// Labels returns copies of the snapshot's labels.
+func (f *FrozenPicture) Labels() []*mp.Label {
+	labels := make([]*mp.Label, len(f.pic.labels))
+	for i, label := range f.pic.labels {
+		if label != nil {
+			l := *label
+			labels[i] = &l
+		}
+	}
+	return labels
+}
+
+// ClipPath returns a copy of the snapshot's clipping path, or nil.
+func (f *FrozenPicture) ClipPath() *mp.Path {
+	return clonePath(f.pic.clipPath)
+}
+
+// BBox returns the bounding box of the snapshot, computed once by Freeze
+// (see Picture.BBox).
+func (f *FrozenPicture) BBox() (minX, minY, maxX, maxY float64, ok bool) {
+	return f.minX, f.minY, f.maxX, f.maxY, f.bboxOK
+}
+
+// ExportTransform returns the export transform of the frozen picture (see
+// Picture.ExportTransform).
+func (f *FrozenPicture) ExportTransform() mp.Transform {
+	return f.pic.ExportTransform()
+}
@@ -2,6 +2,7 @@ package draw
 
 import (
 	"bytes"
+	"math"
 	"strings"
 	"testing"
 
@@ -189,3 +190,181 @@ func TestLabelXMLEscape(t *testing.T) {
 		t.Error("SVG should contain escaped &")
 	}
 }
+
+func TestTeXLabelInSVG(t *testing.T) {
+	pic := NewPicture()
+	pic.LabelWithStyle(`$\alpha_1 < x^2$`, mp.P(0, 0), mp.AnchorCenter).WithTeX()
+
+	var buf bytes.Buffer
+	if err := svg.NewBuilder().AddPicture(pic).WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, ">α₁ &lt; x²</text>") {
+		t.Errorf("SVG does not show the plain text of the TeX label:\n%s", out)
+	}
+	if got := pic.Labels()[0].Text; got != `$\alpha_1 < x^2$` {
+		t.Errorf("TeX source changed to %q", got)
+	}
+}
+
+func TestLabelInside(t *testing.T) {
+	// 100×20 rectangle: the label must be centered and limited by the height.
+	region := mp.XScaled(100).Then(mp.YScaled(20)).ApplyToPath(mp.UnitSquare())
+	pic := NewPicture()
+	label := pic.LabelInside(region, "ab", nil)
+	if label == nil {
+		t.Fatal("LabelInside returned nil")
+	}
+	if math.Abs(label.Position.X-50) > 5 || math.Abs(label.Position.Y-10) > 1 {
+		t.Errorf("label placed at %v, want near (50, 10)", label.Position)
+	}
+	if label.FontSize < 19 || label.FontSize > 20 {
+		t.Errorf("font size = %g, want just below 20", label.FontSize)
+	}
+	if len(pic.Labels()) != 1 {
+		t.Errorf("label not added to picture")
+	}
+	// A degenerate region without interior
+	line := mp.XScaled(10).ApplyToPath(mp.UnitSquare())
+	line = mp.YScaled(0).ApplyToPath(line)
+	if NewPicture().LabelInside(line, "x", nil) != nil {
+		t.Errorf("expected nil for a degenerate region")
+	}
+}
+
+func TestLabelOnPath(t *testing.T) {
+	path, _ := NewPath().MoveTo(P(0, 0)).LineTo(P(100, 0)).Solve()
+	pic := NewPicture()
+	above := pic.LabelOnPath("a", path, 0.25, SideLeft, 5)
+	if math.Abs(above.Position.X-25) > 1e-6 || above.Position.Y != 0 || above.Anchor != mp.AnchorTop || above.LabelOffset != 5 {
+		t.Errorf("left label: %+v", above)
+	}
+	if below := pic.LabelOnPath("b", path, 0.5, SideRight, 0); below.Anchor != mp.AnchorBottom ||
+		below.LabelOffset != mp.DefaultLabelOffset {
+		t.Errorf("right label: %+v", below)
+	}
+	if on := pic.LabelOnPath("c", path, 2, SideOn, 0); math.Abs(on.Position.X-100) > 1e-9 || on.Anchor != mp.AnchorCenter {
+		t.Errorf("label on path: %+v", on)
+	}
+
+	// Going up, the text turns a quarter turn and its top points left.
+	up, _ := NewPath().MoveTo(P(0, 0)).LineTo(P(0, 100)).Solve()
+	if l := pic.LabelOnPathRotated("d", up, 0.5, SideLeft, 0); l.Angle != 90 || l.Anchor != mp.AnchorTop {
+		t.Errorf("rotated label: angle %g, anchor %v", l.Angle, l.Anchor)
+	}
+	// Going left, the text is not upside down but sits below the path,
+	// which is left of the direction of travel.
+	back := path.Reversed()
+	if l := pic.LabelOnPathRotated("e", back, 0.5, SideLeft, 0); math.Abs(l.Angle) > 1e-9 || l.Anchor != mp.AnchorBottom {
+		t.Errorf("reversed label: angle %g, anchor %v", l.Angle, l.Anchor)
+	}
+
+	var b strings.Builder
+	if err := svg.NewBuilder().AddPicture(pic.AddPath(path)).WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `transform="rotate(-90.000`) {
+		t.Errorf("rotated label not written rotated:\n%s", b.String())
+	}
+}
+
+func TestRotatedLabelBounds(t *testing.T) {
+	l := mp.NewLabel("abcd", mp.P(0, 0), mp.AnchorRight).WithAngle(90)
+	minX, minY, maxX, maxY := l.EstimateBounds()
+	// 24 wide and 10 high, 3 right of the point, turned to point up.
+	if math.Abs(minX+5) > 1e-9 || math.Abs(maxX-5) > 1e-9 || math.Abs(minY-3) > 1e-9 || math.Abs(maxY-27) > 1e-9 {
+		t.Errorf("bounds (%g,%g)-(%g,%g), want (-5,3)-(5,27)", minX, minY, maxX, maxY)
+	}
+}
+
+func TestLabelAtLength(t *testing.T) {
+	path, _ := NewPath().MoveTo(P(0, 0)).CurveTo(P(50, 30)).CurveTo(P(100, 0)).Solve()
+	a := mp.NewArcLengthParam(path)
+	pic := NewPicture()
+	byFrac := pic.LabelOnPathRotated("x", path, 0.25, SideLeft, 0)
+	byLength := pic.LabelAtLength("x", a, a.Length()/4, SideLeft, 0, true)
+	if byFrac.Position != byLength.Position || byFrac.Angle != byLength.Angle || byFrac.Anchor != byLength.Anchor {
+		t.Errorf("LabelAtLength %+v differs from LabelOnPathRotated %+v", byLength, byFrac)
+	}
+}
+
+// descenderFont measures every character as 5 wide and 7 high and draws
+// the text as one rectangle reaching 2 below the baseline.
+type descenderFont struct{}
+
+func (descenderFont) TextBounds(text string, _ float64) (float64, float64) {
+	return 5 * float64(len(text)), 7
+}
+
+func (descenderFont) TextToPaths(text string, o mp.TextToPathsOptions) ([]*mp.Path, error) {
+	r := mp.XScaled(5 * float64(len(text))).Then(mp.YScaled(9)).Then(mp.Shifted(o.X, o.Y-2)).ApplyToPath(mp.UnitSquare())
+	return []*mp.Path{r}, nil
+}
+
+func TestLabelOvershoot(t *testing.T) {
+	// "ab" is estimated as 12 × 10 around the origin; the viewBox adds
+	// half the default stroke width on each side.
+	for _, c := range []struct {
+		name string
+		set  func(*svg.Builder)
+		want string
+	}{
+		{"estimate", func(*svg.Builder) {}, `viewBox="0 0 12.5 10.5"`},
+		{"overshoot", func(b *svg.Builder) { b.LabelOvershoot(0.25) }, `viewBox="0 0 17.5 15.5"`},
+		{"margins", func(b *svg.Builder) { b.LabelMargins(0, 1, 2, 0) }, `viewBox="0 0 13.5 12.5"`},
+		{"strict", func(b *svg.Builder) { b.LabelOvershoot(0.25).StrictLabelBounds(descenderFont{}) }, `viewBox="0 0 10.5 9.5"`},
+	} {
+		pic := NewPicture()
+		pic.Label("ab", mp.P(0, 0), mp.AnchorCenter)
+		b := svg.NewBuilder()
+		c.set(b)
+		var buf bytes.Buffer
+		if err := b.AddPicture(pic).WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), c.want) {
+			t.Errorf("%s: want %s in\n%s", c.name, c.want, buf.String())
+		}
+	}
+}
+
+func TestTrimEdgesAtLabels(t *testing.T) {
+	line := func(a, b mp.Point) *mp.Path {
+		p, err := NewPath().WithArrow().MoveTo(a).LineTo(b).Solve()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	pic := NewPicture().
+		AddPath(line(P(0, 0), P(100, 0))).    // through the label at 50
+		AddPath(line(P(0, 30), P(48, 2))).    // ends under it
+		AddPath(line(P(0, -30), P(100, -30))) // clear of it
+	ring := mp.Scaled(40).Then(mp.Shifted(50, 0)).ApplyToPath(mp.FullCircle())
+	ring.Style.Fill = mp.ColorCSS("yellow")
+	pic.AddPath(ring)
+	// "ab": 12 by 10 at font size 10, centered on (50, 0)
+	pic.AddLabel(&mp.Label{Text: "ab", Position: P(50, 0), Anchor: mp.AnchorCenter})
+	pic.TrimEdgesAtLabels()
+
+	paths := pic.Paths()
+	if len(paths) != 5 {
+		t.Fatalf("got %d paths, want the split line, the cut line, the clear line and the ring", len(paths))
+	}
+	left, right, cut := paths[0], paths[1], paths[2]
+	if x, _ := left.PointOf(1); math.Abs(x-44) > 0.05 {
+		t.Errorf("left piece ends at x = %g, want 44", x)
+	}
+	if x, _ := right.PointOf(0); math.Abs(x-56) > 0.05 {
+		t.Errorf("right piece starts at x = %g, want 56", x)
+	}
+	if left.Style.Arrow.End || !right.Style.Arrow.End {
+		t.Error("the end arrowhead belongs to the last piece only")
+	}
+	if x, y := cut.PointOf(mp.Number(cut.PathLength())); math.Abs(x-44) > 0.05 || y > 5 || !cut.Style.Arrow.End {
+		t.Errorf("cut line ends at (%g,%g), want on the left of the label box", x, y)
+	}
+	if paths[3].Head.YCoord != -30 || paths[4] != ring {
+		t.Error("paths clear of the label or filled were changed")
+	}
+}
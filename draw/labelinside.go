@@ -0,0 +1,135 @@
+package draw
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// labelInsideGrid is the number of candidate centers per axis searched by
+// LabelInside.
+const labelInsideGrid = 24
+
+// labelInsideEdgeSamples is the number of points per rectangle edge tested
+// against the region.
+const labelInsideEdgeSamples = 8
+
+// LabelInside adds text as a centered label inside the closed region and
+// returns it. The label is placed at the point of the region farthest from
+// its outline, at the largest font size for which the text's bounds still
+// fit inside the region (tested with point-in-path against the outline).
+// The font is only used for text metrics; with a nil font the same estimate
+// as Label.EstimateBounds is used. Returns nil if no placement is found.
+//
+// Example:
+//
+//	pic.LabelInside(countyOutline, "Kent", face)
+func (p *Picture) LabelInside(region *mp.Path, text string, f mp.FontRenderer) *mp.Label {
+	if region == nil || region.Head == nil || text == "" {
+		return nil
+	}
+	center, ok := innermostPoint(region)
+	if !ok {
+		return nil
+	}
+	// Text bounds scale linearly with the font size.
+	w1, h1 := 0.6*float64(len(text)), 1.0
+	if f != nil {
+		w1, h1 = f.TextBounds(text, 1)
+	}
+	if w1 <= 0 || h1 <= 0 {
+		return nil
+	}
+	minX, minY, maxX, maxY := mp.PathBBox(region)
+	lo, hi := 0.0, math.Min((maxX-minX)/w1, (maxY-minY)/h1)
+	for i := 0; i < 40; i++ {
+		mid := (lo + hi) / 2
+		if rectInside(region, center, mid*w1, mid*h1) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if lo <= 0 {
+		return nil
+	}
+	label := mp.NewLabel(text, center, mp.AnchorCenter).WithFontSize(lo)
+	p.labels = append(p.labels, label)
+	return label
+}
+
+// innermostPoint returns a point inside region with (approximately) maximal
+// distance to its outline, found on a regular grid over the bounding box.
+// Ties are broken towards the center of the bounding box.
+func innermostPoint(region *mp.Path) (mp.Point, bool) {
+	outline := regionOutline(region)
+	minX, minY, maxX, maxY := mp.PathBBox(region)
+	best, bestDist := mp.Point{}, -1.0
+	for i := 0; i <= labelInsideGrid; i++ {
+		for j := 0; j <= labelInsideGrid; j++ {
+			pt := mp.P(
+				minX+(maxX-minX)*float64(i)/labelInsideGrid,
+				minY+(maxY-minY)*float64(j)/labelInsideGrid,
+			)
+			if !region.Contains(pt.X, pt.Y) {
+				continue
+			}
+			d := math.Inf(1)
+			for k := range outline {
+				d = math.Min(d, segmentDistance(pt, outline[k], outline[(k+1)%len(outline)]))
+			}
+			// Among (nearly) equal candidates prefer the one nearest the bbox center.
+			mid := mp.P((minX+maxX)/2, (minY+maxY)/2)
+			if d > bestDist+1e-9 || (d > bestDist-1e-9 && mp.Distance(pt, mid) < mp.Distance(best, mid)) {
+				best, bestDist = pt, d
+			}
+		}
+	}
+	return best, bestDist >= 0
+}
+
+// segmentDistance returns the distance from pt to the segment a–b.
+func segmentDistance(pt, a, b mp.Point) float64 {
+	d := b.Sub(a)
+	l2 := d.Dot(d)
+	if l2 == 0 {
+		return mp.Distance(pt, a)
+	}
+	t := math.Max(0, math.Min(1, pt.Sub(a).Dot(d)/l2))
+	return mp.Distance(pt, a.Add(d.Mul(t)))
+}
+
+// regionOutline samples the outline of region.
+func regionOutline(region *mp.Path) []mp.Point {
+	n := region.PathLength()
+	var pts []mp.Point
+	for i := 0; i < n*labelInsideEdgeSamples; i++ {
+		x, y := region.PointOf(float64(i) / labelInsideEdgeSamples)
+		pts = append(pts, mp.P(x, y))
+	}
+	return pts
+}
+
+// rectInside reports whether the w×h rectangle centered at c lies inside
+// region: its outline must be inside and no part of the region's outline may
+// enter it.
+func rectInside(region *mp.Path, c mp.Point, w, h float64) bool {
+	x0, y0, x1, y1 := c.X-w/2, c.Y-h/2, c.X+w/2, c.Y+h/2
+	for i := 0; i <= labelInsideEdgeSamples; i++ {
+		s := float64(i) / labelInsideEdgeSamples
+		for _, pt := range []mp.Point{
+			mp.P(x0+s*w, y0), mp.P(x0+s*w, y1),
+			mp.P(x0, y0+s*h), mp.P(x1, y0+s*h),
+		} {
+			if !region.Contains(pt.X, pt.Y) {
+				return false
+			}
+		}
+	}
+	for _, o := range regionOutline(region) {
+		if o.X > x0 && o.X < x1 && o.Y > y0 && o.Y < y1 {
+			return false
+		}
+	}
+	return true
+}
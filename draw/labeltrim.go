@@ -0,0 +1,107 @@
+package draw
+
+import (
+	"sort"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// TrimEdgesAtLabels removes the parts of the picture's strokes that run
+// under a label, so that no text is overdrawn by lines. Each label covers
+// the box of Label.EstimateBounds. A stroke is cut at its intersections
+// with the boxes: an end inside a box is moved back to where the stroke
+// enters it, so an arrow stops at the label it points to, and a stroke
+// passing through a box is split in two, keeping the start arrowhead on the
+// first piece and the end arrowhead on the last.
+// Only open paths without a fill are trimmed; outlines, fills and
+// multi-paths are left alone. Call it once all paths and labels are added.
+//
+// Example:
+//
+//	pic.LabelOnPath("3", edge, 0.5, draw.SideLeft, 0)
+//	pic.TrimEdgesAtLabels()
+func (p *Picture) TrimEdgesAtLabels() *Picture {
+	var boxes [][4]float64
+	for _, label := range p.labels {
+		if label == nil || label.Text == "" {
+			continue
+		}
+		x0, y0, x1, y1 := label.EstimateBounds()
+		boxes = append(boxes, [4]float64{x0, y0, x1, y1})
+	}
+	if len(boxes) == 0 {
+		return p
+	}
+	p.mapPaths(func(path *mp.Path) []*mp.Path { return trimAtBoxes(path, boxes) })
+	return p
+}
+
+// trimAtBoxes returns the pieces of path outside all boxes, or path itself
+// if it is not an open stroke or no box covers any of it.
+func trimAtBoxes(path *mp.Path, boxes [][4]float64) []*mp.Path {
+	if path == nil || path.Head == nil || path.Head.LType != mp.KnotEndpoint {
+		return []*mp.Path{path}
+	}
+	if fill := path.Style.Fill.CSS(); fill != "" && fill != "none" {
+		return []*mp.Path{path}
+	}
+	n := mp.Number(path.PathLength())
+	if n == 0 {
+		return []*mp.Path{path}
+	}
+	times := []mp.Number{0, n}
+	for _, b := range boxes {
+		rect := mp.XScaled(b[2] - b[0]).Then(mp.YScaled(b[3] - b[1])).Then(mp.Shifted(b[0], b[1])).ApplyToPath(mp.UnitSquare())
+		for _, tt := range path.AllIntersectionTimes(rect) {
+			times = append(times, tt[0])
+		}
+	}
+	sort.Float64s(times)
+
+	// Keep the spans between consecutive cuts whose middle is outside
+	// every box, joining neighbours.
+	var spans [][2]mp.Number
+	for i := 1; i < len(times); i++ {
+		a, b := times[i-1], times[i]
+		if b-a < 1e-9 {
+			continue
+		}
+		x, y := path.PointOf((a + b) / 2)
+		if underBox(x, y, boxes) {
+			continue
+		}
+		if k := len(spans) - 1; k >= 0 && spans[k][1] == a {
+			spans[k][1] = b
+		} else {
+			spans = append(spans, [2]mp.Number{a, b})
+		}
+	}
+	if len(spans) == 1 && spans[0] == [2]mp.Number{0, n} {
+		return []*mp.Path{path}
+	}
+	out := make([]*mp.Path, 0, len(spans))
+	for i, s := range spans {
+		q := path.Subpath(s[0], s[1])
+		q.Style = path.Style
+		q.Style.Arrow.Start = path.Style.Arrow.Start && i == 0
+		q.Style.Arrow.End = path.Style.Arrow.End && i == len(spans)-1
+		if path.Envelope != nil && path.Style.Pen != nil {
+			if env := mp.MakeEnvelope(q, path.Style.Pen); env != nil {
+				env.Style = path.Envelope.Style
+				q.Envelope = env
+			}
+		}
+		out = append(out, q)
+	}
+	return out
+}
+
+// underBox reports whether (x, y) lies inside one of the boxes.
+func underBox(x, y float64, boxes [][4]float64) bool {
+	for _, b := range boxes {
+		if x > b[0] && x < b[2] && y > b[1] && y < b[3] {
+			return true
+		}
+	}
+	return false
+}
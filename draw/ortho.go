@@ -0,0 +1,158 @@
+package draw
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// OrthoStub is the minimum length of the first and last leg of an
+// OrthoPath connector that has to turn around to reach its target.
+const OrthoStub = 10.0
+
+// orthoKappa is the handle length of a cubic quarter circle of radius 1.
+const orthoKappa = 0.5522847498307936
+
+// OrthoPath returns a builder for a right-angle (Manhattan) connector from
+// from to to, as used in block diagrams. The connector leaves from in
+// exitDir and arrives at to travelling in enterDir; both are angles in
+// degrees and are rounded to the nearest multiple of 90 (0 = right, 90 =
+// up). For a box whose left side is the target, enterDir is 0.
+//
+// The route has as few corners as possible (up to four) and, among those,
+// the shortest length; legs that have to turn around are at least
+// OrthoStub long. With cornerRadius > 0 each corner is rounded by a
+// quarter circle, reduced where a leg is too short to hold it.
+//
+// Style the result like any other builder and solve it.
+//
+// Example:
+//
+//	// From the right side of one box to the left side of another.
+//	wire, err := draw.OrthoPath(mp.P(10, 0), mp.P(40, 20), 0, 0, 3).WithArrow().Solve()
+func OrthoPath(from, to mp.Point, exitDir, enterDir, cornerRadius float64) *PathBuilder {
+	pts := orthoRoute(from, to, axisDir(exitDir), axisDir(enterDir))
+	b := NewPath().MoveTo(from)
+	if cornerRadius <= 0 || len(pts) < 3 {
+		for _, pt := range pts[1:] {
+			b.LineTo(pt)
+		}
+		return b
+	}
+	// Legs at a corner are shared by two fillets, except the first and last.
+	last := len(pts) - 1
+	cur := from
+	for i := 1; i < last; i++ {
+		prev, c, next := pts[i-1], pts[i], pts[i+1]
+		in, out := mp.Distance(prev, c), mp.Distance(c, next)
+		if i > 1 {
+			in /= 2
+		}
+		if i < last-1 {
+			out /= 2
+		}
+		r := math.Min(cornerRadius, math.Min(in, out))
+		din := c.Sub(prev).Normalized()
+		dout := next.Sub(c).Normalized()
+		a := c.Sub(din.Mul(r))
+		e := c.Add(dout.Mul(r))
+		if a != cur {
+			b.LineTo(a)
+		}
+		b.CurveToWithControls(e, a.Add(din.Mul(r*orthoKappa)), e.Sub(dout.Mul(r*orthoKappa)))
+		cur = e
+	}
+	if cur != to {
+		b.LineTo(to)
+	}
+	return b
+}
+
+// axisDir returns the unit axis vector nearest to the direction deg.
+func axisDir(deg float64) mp.Point {
+	switch int(math.Round(deg/90)) % 4 {
+	case 1, -3:
+		return P(0, 1)
+	case 2, -2:
+		return P(-1, 0)
+	case 3, -1:
+		return P(0, -1)
+	}
+	return P(1, 0)
+}
+
+// orthoRoute returns from, the corners and to of the connector leaving from
+// in direction d1 and arriving at to in direction d2 (unit axis vectors).
+func orthoRoute(from, to, d1, d2 mp.Point) []mp.Point {
+	if d1.X == 0 {
+		// Route the transposed problem, which leaves horizontally.
+		tr := func(p mp.Point) mp.Point { return P(p.Y, p.X) }
+		pts := orthoRoute(tr(from), tr(to), tr(d1), tr(d2))
+		for i := range pts {
+			pts[i] = tr(pts[i])
+		}
+		return pts
+	}
+	x0, y0, x1, y1 := from.X, from.Y, to.X, to.Y
+	sx := d1.X
+	ahead := func(a, b, s float64) bool { return (b-a)*s > 0 }
+	var best []mp.Point
+	bestLen := math.Inf(1)
+	try := func(corners ...mp.Point) {
+		pts := append(append([]mp.Point{from}, corners...), to)
+		l := 0.0
+		for i := 1; i < len(pts); i++ {
+			if pts[i] == pts[i-1] {
+				return
+			}
+			l += mp.Distance(pts[i-1], pts[i])
+		}
+		if l < bestLen {
+			best, bestLen = pts, l
+		}
+	}
+	if d2.Y != 0 {
+		ty := d2.Y
+		// One corner.
+		if ahead(x0, x1, sx) && ahead(y0, y1, ty) {
+			return []mp.Point{from, P(x1, y0), to}
+		}
+		// Three corners: out to xa, across to yb, over to x1, into to.
+		for _, xa := range []float64{(x0 + x1) / 2, x0 + sx*OrthoStub} {
+			for _, yb := range []float64{(y0 + y1) / 2, y1 - ty*OrthoStub} {
+				if ahead(x0, xa, sx) && ahead(yb, y1, ty) {
+					try(P(xa, y0), P(xa, yb), P(x1, yb))
+				}
+			}
+		}
+		if best == nil {
+			best = []mp.Point{from, P(x1, y0), to}
+		}
+		return best
+	}
+	tx := d2.X
+	// Straight.
+	if y0 == y1 && sx == tx && ahead(x0, x1, sx) {
+		return []mp.Point{from, to}
+	}
+	// Two corners: out to xm, across to y1, into to.
+	if y0 != y1 {
+		for _, xm := range []float64{(x0 + x1) / 2, x0 + sx*OrthoStub, x1 - tx*OrthoStub} {
+			if ahead(x0, xm, sx) && ahead(xm, x1, tx) {
+				try(P(xm, y0), P(xm, y1))
+			}
+		}
+		if best != nil {
+			return best
+		}
+	}
+	// Four corners: out to xa, across to ym, over to xb, across to y1.
+	xa, xb := x0+sx*OrthoStub, x1-tx*OrthoStub
+	for _, ym := range []float64{(y0 + y1) / 2, math.Max(y0, y1) + OrthoStub, math.Min(y0, y1) - OrthoStub} {
+		try(P(xa, y0), P(xa, ym), P(xb, ym), P(xb, y1))
+	}
+	if best == nil {
+		best = []mp.Point{from, to}
+	}
+	return best
+}
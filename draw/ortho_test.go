@@ -0,0 +1,89 @@
+package draw
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func TestOrthoRoute(t *testing.T) {
+	tests := []struct {
+		name          string
+		from, to      mp.Point
+		exit, enter   float64
+		wantCorners   int
+		wantFirstTurn mp.Point
+	}{
+		{"straight", P(0, 0), P(30, 0), 0, 0, 0, P(30, 0)},
+		{"L", P(0, 0), P(30, 20), 0, 90, 1, P(30, 0)},
+		{"Z", P(0, 0), P(30, 20), 0, 0, 2, P(15, 0)},
+		{"U", P(0, 0), P(30, 20), 0, 180, 2, P(40, 0)},
+		{"back", P(0, 0), P(-30, 20), 0, 0, 4, P(10, 0)},
+		{"L around", P(0, 0), P(30, -20), 0, 90, 3, P(15, 0)},
+		{"vertical Z", P(0, 0), P(20, -30), -90, -90, 2, P(0, -15)},
+	}
+	for _, tc := range tests {
+		pts := orthoRoute(tc.from, tc.to, axisDir(tc.exit), axisDir(tc.enter))
+		if got := len(pts) - 2; got != tc.wantCorners {
+			t.Errorf("%s: %d corners %v, want %d", tc.name, got, pts, tc.wantCorners)
+			continue
+		}
+		if pts[0] != tc.from || pts[len(pts)-1] != tc.to || pts[1] != tc.wantFirstTurn {
+			t.Errorf("%s: route %v", tc.name, pts)
+		}
+		for i := 1; i < len(pts); i++ {
+			if pts[i].X != pts[i-1].X && pts[i].Y != pts[i-1].Y {
+				t.Errorf("%s: leg %d is not axis-parallel: %v", tc.name, i, pts)
+			}
+		}
+		d1, d2 := axisDir(tc.exit), axisDir(tc.enter)
+		if first := pts[1].Sub(pts[0]); first.Dot(d1) <= 0 {
+			t.Errorf("%s: first leg %v does not leave in %v", tc.name, first, d1)
+		}
+		if last := pts[len(pts)-1].Sub(pts[len(pts)-2]); last.Dot(d2) <= 0 {
+			t.Errorf("%s: last leg %v does not enter in %v", tc.name, last, d2)
+		}
+	}
+}
+
+func TestOrthoPathRounded(t *testing.T) {
+	path, err := OrthoPath(P(0, 0), P(30, 20), 0, 90, 5).Solve()
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	if x, y := path.PointOf(0); x != 0 || y != 0 {
+		t.Errorf("starts at (%g,%g)", x, y)
+	}
+	n := mp.Number(path.PathLength())
+	if x, y := path.PointOf(n); x != 30 || y != 20 {
+		t.Errorf("ends at (%g,%g)", x, y)
+	}
+	// line to (25,0), fillet around (25,5) to (30,5), line to (30,20)
+	if path.PathLength() != 3 {
+		t.Fatalf("got %d segments, want 3", path.PathLength())
+	}
+	for i := 0; i <= 8; i++ {
+		x, y := path.PointOf(1 + mp.Number(i)/8)
+		if r := math.Hypot(x-25, y-5); math.Abs(r-5) > 0.002 {
+			t.Errorf("fillet point %d at radius %g, want 5", i, r)
+		}
+	}
+	if dx, dy := path.DirectionOf(n); dx != 0 || dy <= 0 {
+		t.Errorf("arrives in direction (%g,%g), want up", dx, dy)
+	}
+
+	// A short middle leg limits the radius to half its length.
+	path, err = OrthoPath(P(0, 0), P(30, 4), 0, 0, 5).Solve()
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	_, _, _, maxY := mp.PathBBox(path)
+	if maxY != 4 {
+		t.Errorf("rounded Z overshoots to y=%g", maxY)
+	}
+	x, y := path.PointOf(1)
+	if math.Abs(x-13) > 1e-9 || y != 0 {
+		t.Errorf("first fillet starts at (%g,%g), want (13,0)", x, y)
+	}
+}
@@ -0,0 +1,139 @@
+package draw
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Side selects where LabelOnPath puts a label relative to the path.
+type Side int
+
+const (
+	SideLeft  Side = iota // left of the direction of travel ("above" a left-to-right path)
+	SideRight             // right of the direction of travel
+	SideOn                // centered on the path
+)
+
+// LabelOnPath adds text as a label at the fraction frac of the arc length
+// of path and returns it: beside the path on the given side, offset units
+// away along the normal (0 means mp.DefaultLabelOffset), or centered on it.
+// The anchor is chosen from the normal direction, as with MetaPost's
+// label.top, label.urt, ... suffixes, so the text never covers the curve.
+// It returns nil for an empty path. LabelOnPathRotated turns the text to
+// follow the tangent instead.
+//
+// Example:
+//
+//	pic.LabelOnPath("f(x)", graph, 0.8, draw.SideLeft, 0)
+func (p *Picture) LabelOnPath(text string, path *mp.Path, frac mp.Number, side Side, offset float64) *mp.Label {
+	a := mp.NewArcLengthParam(path)
+	return p.LabelAtLength(text, a, a.Length()*math.Max(0, math.Min(1, frac)), side, offset, false)
+}
+
+// LabelOnPathRotated is LabelOnPath with the text rotated to run along the
+// tangent of the path. Text that would be upside down is turned by a half
+// turn, keeping it on the requested side.
+//
+// Example:
+//
+//	pic.LabelOnPathRotated("Main Street", road, 0.5, draw.SideOn, 0)
+func (p *Picture) LabelOnPathRotated(text string, path *mp.Path, frac mp.Number, side Side, offset float64) *mp.Label {
+	a := mp.NewArcLengthParam(path)
+	return p.LabelAtLength(text, a, a.Length()*math.Max(0, math.Min(1, frac)), side, offset, true)
+}
+
+// LabelAtLength is LabelOnPath (LabelOnPathRotated if rotated is set) at
+// the arc length s along the path of a, for labels that must stay in step
+// with dashes, arrowheads or patterns placed with the same
+// parameterization (see mp.ArcLengthParam). s is clamped to the path for
+// open paths and wraps around cycles.
+//
+// Example:
+//
+//	a := mp.NewArcLengthParam(route)
+//	for i, km := range stops {
+//		pic.LabelAtLength(names[i], a, km*unit, draw.SideLeft, 0, true)
+//	}
+func (p *Picture) LabelAtLength(text string, a *mp.ArcLengthParam, s mp.Number, side Side, offset float64, rotated bool) *mp.Label {
+	pos, nx, ny, ok := pathLabelFrame(a, s, side)
+	if !ok {
+		return nil
+	}
+	var label *mp.Label
+	if rotated {
+		label = rotatedPathLabel(text, pos, nx, ny, side)
+	} else {
+		anchor := mp.AnchorCenter
+		if side != SideOn {
+			anchor = anchorToward(nx, ny)
+		}
+		label = mp.NewLabel(text, pos, anchor)
+	}
+	if offset > 0 {
+		label.LabelOffset = offset
+	}
+	p.labels = append(p.labels, label)
+	return label
+}
+
+// rotatedPathLabel returns the label at pos turned along the tangent of a
+// path with unit normal (nx, ny) toward side.
+func rotatedPathLabel(text string, pos mp.Point, nx, ny float64, side Side) *mp.Label {
+	tx, ty := ny, -nx // the tangent: the normal turned back a quarter turn
+	if side == SideRight {
+		tx, ty = -ny, nx
+	}
+	angle := math.Atan2(ty, tx) * 180 / math.Pi
+	up := side != SideRight // the normal is "up" in the rotated text
+	switch {
+	case angle > 90:
+		angle -= 180
+		up = !up
+	case angle <= -90:
+		angle += 180
+		up = !up
+	}
+	anchor := mp.AnchorBottom
+	switch {
+	case side == SideOn:
+		anchor = mp.AnchorCenter
+	case up:
+		anchor = mp.AnchorTop
+	}
+	return mp.NewLabel(text, pos, anchor).WithAngle(angle)
+}
+
+// pathLabelFrame returns the point at arc length s along the path of a
+// and the unit normal toward side (the left normal for SideOn).
+func pathLabelFrame(a *mp.ArcLengthParam, s mp.Number, side Side) (pos mp.Point, nx, ny float64, ok bool) {
+	path := a.Path()
+	if path == nil || path.Head == nil {
+		return mp.Point{}, 0, 0, false
+	}
+	t := a.Time(s)
+	x, y := path.PointOf(t)
+	dx, dy := path.DirectionOf(t)
+	nx, ny = -dy, dx
+	if side == SideRight {
+		nx, ny = dy, -dx
+	}
+	if d := math.Hypot(nx, ny); d > 0 {
+		nx, ny = nx/d, ny/d
+	} else {
+		nx, ny = 0, 1
+	}
+	return mp.P(x, y), nx, ny, true
+}
+
+// anchorToward returns the label anchor whose offset direction is closest
+// to (dx, dy).
+func anchorToward(dx, dy float64) mp.Anchor {
+	anchors := []mp.Anchor{
+		mp.AnchorRight, mp.AnchorUpperRight, mp.AnchorTop, mp.AnchorUpperLeft,
+		mp.AnchorLeft, mp.AnchorLowerLeft, mp.AnchorBottom, mp.AnchorLowerRight,
+	}
+	a := math.Atan2(dy, dx)
+	i := int(math.Round(a/(math.Pi/4))+8) % 8
+	return anchors[i]
+}
@@ -1,14 +1,23 @@
 package draw
 
-import "github.com/boxesandglue/mpgo/mp"
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
 
 // Picture mirrors MetaPost's picture container: it collects solved paths that can
 // be drawn together. Tracks are stored as-is (no copying) similar to how MetaPost
 // chains edge objects into a picture (mp.c around mp_make_dashes/export_dashes).
 type Picture struct {
-	paths    []*mp.Path
-	labels   []*mp.Label
-	clipPath *mp.Path // Optional clipping path
+	paths      []*mp.Path
+	multiPaths []*mp.MultiPath // Multi-part paths drawn as one object each
+	multiAt    []int           // Number of paths drawn before each multi-path
+	labels     []*mp.Label
+	clipPath   *mp.Path     // Optional clipping path
+	export     mp.Transform // Cumulative transform applied by Transform/FitTo
+	exportSet  bool         // False means export is the identity
+	defaults   mp.Style     // House style for added paths, see SetDefaults
 }
 
 // NewPicture constructs an empty picture.
@@ -16,24 +25,230 @@ func NewPicture() *Picture {
 	return &Picture{paths: make([]*mp.Path, 0)}
 }
 
+// mapPaths replaces every path by the paths f returns for it. The
+// multi-paths stay between the same paths as before.
+func (p *Picture) mapPaths(f func(*mp.Path) []*mp.Path) {
+	paths := make([]*mp.Path, 0, len(p.paths))
+	before := make([]int, len(p.paths)+1)
+	for i, path := range p.paths {
+		before[i] = len(paths)
+		paths = append(paths, f(path)...)
+	}
+	before[len(p.paths)] = len(paths)
+	for i, at := range p.multiAt {
+		p.multiAt[i] = before[at]
+	}
+	p.paths = paths
+}
+
+// mapMultiPaths replaces every multi-path by the multi-paths f returns for
+// it, drawn at the place of the original.
+func (p *Picture) mapMultiPaths(f func(*mp.MultiPath) []*mp.MultiPath) {
+	var multiPaths []*mp.MultiPath
+	var multiAt []int
+	for i, m := range p.multiPaths {
+		for _, q := range f(m) {
+			multiPaths = append(multiPaths, q)
+			multiAt = append(multiAt, p.multiAt[i])
+		}
+	}
+	p.multiPaths, p.multiAt = multiPaths, multiAt
+}
+
+// addMultiPaths appends the multi-paths of other, given with the positions
+// among the paths of other, behind the current paths.
+func (p *Picture) addMultiPaths(multiPaths []*mp.MultiPath, multiAt []int) {
+	for i, m := range multiPaths {
+		p.multiPaths = append(p.multiPaths, m)
+		p.multiAt = append(p.multiAt, len(p.paths)+multiAt[i])
+	}
+}
+
+// SetDefaults sets the style used for every property that paths added from
+// now on leave unset (see mp.Style.Merge for what counts as unset), like
+// MetaPost's interim settings of linecap, linejoin or the current pen. The
+// defaults are written into the style of each added path; paths already in
+// the picture and pictures added with AddPicture are not changed.
+//
+// Example:
+//
+//	pic.SetDefaults(mp.NewStyle(mp.WithStrokeWidth(0.8), mp.WithLineCap(mp.LineCapButt)))
+//	pic.DrawLine(a, b, mp.Style{})  // 0.8bp black line with butt caps
+func (p *Picture) SetDefaults(style mp.Style) *Picture {
+	p.defaults = style
+	return p
+}
+
+// Defaults returns the style set with SetDefaults.
+func (p *Picture) Defaults() mp.Style {
+	return p.defaults
+}
+
 // AddPath appends a solved path to the picture.
 func (p *Picture) AddPath(path *mp.Path) *Picture {
 	if path != nil {
+		path.Style = p.defaults.Merge(path.Style)
 		p.paths = append(p.paths, path)
 	}
 	return p
 }
 
-// AddPicture appends all paths from another picture (no copies; mirrors MetaPost's
-// picture addition semantics where edges are shared until output).
-func (p *Picture) AddPicture(other *Picture) *Picture {
+// AddMultiPath appends a solved multi-part path to the picture. All parts are
+// drawn as one object with the style of m.
+func (p *Picture) AddMultiPath(m *mp.MultiPath) *Picture {
+	if m != nil {
+		m.Style = p.defaults.Merge(m.Style)
+		p.multiPaths = append(p.multiPaths, m)
+		p.multiAt = append(p.multiAt, len(p.paths))
+	}
+	return p
+}
+
+// MultiPaths exposes the collected multi-part paths.
+func (p *Picture) MultiPaths() []*mp.MultiPath {
+	return p.multiPaths
+}
+
+// MultiPathPositions returns for each multi-path of MultiPaths the number
+// of paths of Paths added before it. The svg and eps writers use it to
+// stack paths and multi-paths in the order they were added, as MetaPost
+// draws a picture.
+func (p *Picture) MultiPathPositions() []int {
+	return append([]int(nil), p.multiAt...)
+}
+
+// AddPicture adds the paths, multi-paths and labels of other on top of the
+// picture, like MetaPost's "addto currentpicture also pic". Without a
+// transform the paths are shared, not copied, mirroring MetaPost's picture
+// addition where edges are shared until output; labels are copied. With
+// transforms (applied in order, as by Then) the paths are transformed
+// copies, as for "also pic transformed T", so one drawn component can be
+// placed at several positions. The clipping path of other is not carried
+// over: add clipped components to the svg or eps builder as pictures of
+// their own.
+//
+// Example:
+//
+//	pic.AddPicture(resistor, mp.Rotated(90), mp.Shifted(40, 0))
+func (p *Picture) AddPicture(other *Picture, transforms ...mp.Transform) *Picture {
 	if other == nil {
 		return p
 	}
-	p.paths = append(p.paths, other.paths...)
+	if len(transforms) == 0 {
+		p.addMultiPaths(other.multiPaths, other.multiAt)
+		p.paths = append(p.paths, other.paths...)
+		for _, label := range other.labels {
+			if label != nil {
+				l := *label
+				p.labels = append(p.labels, &l)
+			}
+		}
+		return p
+	}
+	t := transforms[0]
+	for _, next := range transforms[1:] {
+		t = t.Then(next)
+	}
+	multiPaths := make([]*mp.MultiPath, len(other.multiPaths))
+	for i, m := range other.multiPaths {
+		multiPaths[i] = transformMultiPath(m, t)
+	}
+	p.addMultiPaths(multiPaths, other.multiAt)
+	for _, path := range other.paths {
+		if path != nil {
+			path = transformPath(path, t)
+		}
+		// Nil paths are kept so that the multi-path positions stay valid.
+		p.paths = append(p.paths, path)
+	}
+	for _, label := range other.labels {
+		if label != nil {
+			p.labels = append(p.labels, transformLabel(label, t))
+		}
+	}
 	return p
 }
 
+// Clone returns a deep copy of the picture: paths, multi-paths, labels and
+// the clipping path are copied down to their knots, and styles get their own
+// pens and dash patterns. Changes to the clone never affect p, which makes
+// p usable as a template for many variations.
+//
+// Example:
+//
+//	v := base.Clone().Transform(mp.Rotated(30))  // base is unchanged
+func (p *Picture) Clone() *Picture {
+	q := &Picture{
+		paths:      make([]*mp.Path, 0, len(p.paths)),
+		multiPaths: make([]*mp.MultiPath, 0, len(p.multiPaths)),
+		multiAt:    append([]int(nil), p.multiAt...),
+		labels:     make([]*mp.Label, 0, len(p.labels)),
+		clipPath:   clonePath(p.clipPath),
+		export:     p.export,
+		exportSet:  p.exportSet,
+		defaults:   cloneStyle(p.defaults),
+	}
+	for _, path := range p.paths {
+		q.paths = append(q.paths, clonePath(path))
+	}
+	for _, m := range p.multiPaths {
+		var c *mp.MultiPath
+		if m != nil {
+			c = m.Copy()
+			for i, part := range m.Parts {
+				c.Parts[i] = clonePath(part)
+			}
+			c.Style = cloneStyle(m.Style)
+		}
+		q.multiPaths = append(q.multiPaths, c)
+	}
+	for _, label := range p.labels {
+		var l *mp.Label
+		if label != nil {
+			c := *label
+			l = &c
+		}
+		q.labels = append(q.labels, l)
+	}
+	return q
+}
+
+// clonePath returns a deep copy of path including its envelope and style,
+// or nil for nil.
+func clonePath(path *mp.Path) *mp.Path {
+	if path == nil {
+		return nil
+	}
+	q := path.Copy()
+	q.Style = cloneStyle(path.Style)
+	if path.Envelope != nil {
+		q.Envelope.Style = cloneStyle(path.Envelope.Style)
+	}
+	return q
+}
+
+// cloneStyle returns style with its own copies of the pen, dash pattern
+// and closing style.
+func cloneStyle(style mp.Style) mp.Style {
+	if style.Pen != nil {
+		pen := &mp.Pen{Elliptical: style.Pen.Elliptical}
+		if style.Pen.Head != nil {
+			pen.Head = mp.Identity().ApplyToPen(style.Pen).Head
+		}
+		style.Pen = pen
+	}
+	if style.Dash != nil {
+		d := *style.Dash
+		d.Array = append([]float64(nil), d.Array...)
+		style.Dash = &d
+	}
+	if style.Closing != nil {
+		c := cloneStyle(*style.Closing)
+		style.Closing = &c
+	}
+	return style
+}
+
 // Paths exposes the collected paths.
 func (p *Picture) Paths() []*mp.Path {
 	return p.paths
@@ -95,6 +310,187 @@ func (p *Picture) DotLabel(text string, pos mp.Point, anchor mp.Anchor, color mp
 	return p
 }
 
+// drawStyle returns style completed by the picture defaults and then by
+// those of a plain "draw": black stroke if neither stroke nor fill is set,
+// and MetaPost's 0.5bp pen width.
+func (p *Picture) drawStyle(style mp.Style) mp.Style {
+	style = p.defaults.Merge(style)
+	if style.Stroke.CSS() == "" && style.Fill.CSS() == "" {
+		style.Stroke = mp.ColorCSS("black")
+	}
+	if style.StrokeWidth == 0 {
+		style.StrokeWidth = 0.5
+	}
+	return style
+}
+
+// DrawLine adds the straight line from a to b. Properties left unset in
+// style come from the picture defaults; without those an empty style draws
+// a black 0.5bp line. A line with a NaN or infinite coordinate is not
+// added.
+//
+// Example:
+//
+//	pic.DrawLine(mp.P(0, 0), mp.P(100, 0), mp.Style{})  // draw (0,0)--(100,0)
+func (p *Picture) DrawLine(a, b mp.Point, style mp.Style) *Picture {
+	path, err := mp.StraightPath([]mp.Point{a, b}, false)
+	if err != nil {
+		return p
+	}
+	path.Style = p.drawStyle(style)
+	return p.AddPath(path)
+}
+
+// DrawCircle adds the circle of radius r around center, built from
+// MetaPost's fullcircle. Unset properties are completed as for DrawLine,
+// and like there a circle with a NaN or infinite value is not added.
+//
+// Example:
+//
+//	pic.DrawCircle(mp.P(0, 0), 20, mp.Style{})  // draw fullcircle scaled 40
+func (p *Picture) DrawCircle(center mp.Point, r float64, style mp.Style) *Picture {
+	circle := mp.Scaled(2 * r).Then(mp.Shifted(center.X, center.Y)).ApplyToPath(mp.FullCircle())
+	if circle.Validate() != nil {
+		return p
+	}
+	circle.Style = p.drawStyle(style)
+	return p.AddPath(circle)
+}
+
+// FillCircle adds the circle of radius r around center filled with color
+// and not stroked. Like DrawCircle it skips circles with a NaN or infinite
+// value.
+//
+// Example:
+//
+//	pic.FillCircle(mp.P(0, 0), 2, mp.ColorCSS("red"))  // fill fullcircle scaled 4
+func (p *Picture) FillCircle(center mp.Point, r float64, color mp.Color) *Picture {
+	circle := mp.Scaled(2 * r).Then(mp.Shifted(center.X, center.Y)).ApplyToPath(mp.FullCircle())
+	if circle.Validate() != nil {
+		return p
+	}
+	circle.Style = mp.Style{Fill: color, Stroke: mp.ColorCSS("none")}
+	return p.AddPath(circle)
+}
+
+// DrawRect adds the rectangle with lower left corner (llx, lly), width w
+// and height h, built from MetaPost's unitsquare. Unset properties are
+// completed as for DrawLine, and like there a rectangle with a NaN or
+// infinite value is not added.
+//
+// Example:
+//
+//	pic.DrawRect(0, 0, 40, 20, mp.Style{})  // draw unitsquare xscaled 40 yscaled 20
+func (p *Picture) DrawRect(llx, lly, w, h float64, style mp.Style) *Picture {
+	rect := mp.XScaled(w).Then(mp.YScaled(h)).Then(mp.Shifted(llx, lly)).ApplyToPath(mp.UnitSquare())
+	if rect.Validate() != nil {
+		return p
+	}
+	rect.Style = p.drawStyle(style)
+	return p.AddPath(rect)
+}
+
+// Shadow inserts fake cast shadows of all paths and multi-paths currently
+// in the picture beneath them (see mp.ShadowTransform). All shadows share
+// the ground line at the lowest point of the picture's paths, so a scene of
+// objects standing on a common ground casts consistent shadows. Closed
+// paths cast a filled shadow, open paths a stroke of their width; both are
+// painted with color, which may carry an opacity (mp.ColorRGBA). Labels
+// cast no shadow; convert them to paths first.
+//
+// Example:
+//
+//	pic.Shadow(30, 0.5, mp.ColorRGBA(0, 0, 0, 0.3))
+func (p *Picture) Shadow(angleDeg, lengthFactor float64, color mp.Color) *Picture {
+	baseY := math.Inf(1)
+	for _, path := range p.paths {
+		if path != nil && path.Head != nil {
+			_, y, _, _ := mp.PathBBox(path)
+			baseY = math.Min(baseY, y)
+		}
+	}
+	for _, m := range p.multiPaths {
+		for _, part := range m.Parts {
+			_, y, _, _ := mp.PathBBox(part)
+			baseY = math.Min(baseY, y)
+		}
+	}
+	if math.IsInf(baseY, 1) {
+		return p
+	}
+	t := mp.ShadowTransform(baseY, angleDeg, lengthFactor)
+	shadowStyle := func(style mp.Style, closed bool) mp.Style {
+		if closed {
+			return mp.Style{Fill: color, Stroke: mp.ColorCSS("none")}
+		}
+		return mp.Style{Stroke: color, StrokeWidth: style.StrokeWidth, LineCap: style.LineCap, LineJoin: style.LineJoin}
+	}
+	// The shadows are stacked like their objects, all beneath the picture.
+	shadow := NewPicture()
+	next := 0
+	addMultis := func(upTo int) {
+		for ; next < len(p.multiPaths) && p.multiAt[next] <= upTo; next++ {
+			m := p.multiPaths[next]
+			s := t.ApplyToMultiPath(m)
+			closed := len(m.Parts) > 0 && m.Parts[0].Head.LType != mp.KnotEndpoint
+			s.Style = shadowStyle(m.Style, closed)
+			shadow.multiPaths = append(shadow.multiPaths, s)
+			shadow.multiAt = append(shadow.multiAt, len(shadow.paths))
+		}
+	}
+	for i, path := range p.paths {
+		addMultis(i)
+		if path == nil || path.Head == nil {
+			continue
+		}
+		src := path
+		if path.Envelope != nil {
+			src = path.Envelope
+		}
+		s := t.ApplyToPath(src)
+		s.Envelope = nil
+		s.Style = shadowStyle(path.Style, src.Head.LType != mp.KnotEndpoint)
+		shadow.paths = append(shadow.paths, s)
+	}
+	addMultis(len(p.paths))
+	shadow.addMultiPaths(p.multiPaths, p.multiAt)
+	p.paths = append(shadow.paths, p.paths...)
+	p.multiPaths, p.multiAt = shadow.multiPaths, shadow.multiAt
+	return p
+}
+
+// FlowArrows adds n arrowheads evenly spaced along path, pointing in the
+// path's direction (see mp.FlowArrowHeads). The heads are filled with the
+// path's stroke color and use its arrow length and angle, falling back to
+// MetaPost's ahlength/ahangle. The path itself is not added.
+//
+// Example:
+//
+//	pic.AddPath(fieldLine).FlowArrows(fieldLine, 4)
+func (p *Picture) FlowArrows(path *mp.Path, n int) *Picture {
+	if path == nil {
+		return p
+	}
+	ahLen := path.Style.Arrow.Length
+	if ahLen <= 0 {
+		ahLen = mp.DefaultAHLength
+	}
+	ahAng := path.Style.Arrow.Angle
+	if ahAng <= 0 {
+		ahAng = mp.DefaultAHAngle
+	}
+	color := path.Style.Stroke
+	if color.CSS() == "" || color.CSS() == "none" {
+		color = mp.ColorCSS("black")
+	}
+	for _, head := range mp.FlowArrowHeads(path, n, ahLen, ahAng) {
+		head.Style.Fill = color
+		head.Style.Stroke = mp.ColorCSS("none")
+		p.paths = append(p.paths, head)
+	}
+	return p
+}
+
 // AddLabel adds a pre-configured label to the picture.
 func (p *Picture) AddLabel(label *mp.Label) *Picture {
 	if label != nil {
@@ -103,6 +499,228 @@ func (p *Picture) AddLabel(label *mp.Label) *Picture {
 	return p
 }
 
+// LabelPicture places a copy of sub at pos using the same anchor machinery as
+// text labels. Mirrors plain.mp's thelabel@#(pic, z): the sub-picture is
+// shifted so that the point (labxf, labyf) of its bounding box lands on
+// z + labeloffset*laboff@#. Paths and labels of sub are copied; a clipping
+// path on sub only contributes to its bounding box.
+//
+// Example:
+//
+//	pic.LabelPicture(icon, mp.P(50, 0), mp.AnchorTop)  // draw thelabel.top(icon, z)
+func (p *Picture) LabelPicture(sub *Picture, pos mp.Point, anchor mp.Anchor) *Picture {
+	if sub == nil {
+		return p
+	}
+	minX, minY, maxX, maxY, ok := sub.BBox()
+	if !ok {
+		return p
+	}
+	dx, dy := mp.LabelOffsetVector(anchor)
+	xf, yf := mp.LabelAnchorFactors(anchor)
+	tx := pos.X + mp.DefaultLabelOffset*dx - (minX + xf*(maxX-minX))
+	ty := pos.Y + mp.DefaultLabelOffset*dy - (minY + yf*(maxY-minY))
+	return p.addShifted(sub, tx, ty)
+}
+
+// addShifted adds copies of the paths, multi-paths and labels of sub moved
+// by (tx, ty).
+func (p *Picture) addShifted(sub *Picture, tx, ty float64) *Picture {
+	shift := mp.Shifted(tx, ty)
+	multiPaths := make([]*mp.MultiPath, len(sub.multiPaths))
+	for i, m := range sub.multiPaths {
+		multiPaths[i] = shift.ApplyToMultiPath(m)
+	}
+	p.addMultiPaths(multiPaths, sub.multiAt)
+	for _, path := range sub.paths {
+		p.paths = append(p.paths, transformPath(path, shift))
+	}
+	for _, label := range sub.labels {
+		l := *label
+		l.Position.X, l.Position.Y = shift.ApplyToPoint(l.Position.X, l.Position.Y)
+		p.labels = append(p.labels, &l)
+	}
+	return p
+}
+
+// BBox returns the bounding box of the picture's paths and labels, similar to
+// MetaPost's llcorner/urcorner of a picture. Stroked paths include half their
+// stroke width (or their envelope for polygonal pens); labels use
+// EstimateBounds. If a clipping path is set, its bounds are returned instead,
+// as MetaPost does for clipped pictures. ok is false for an empty picture.
+func (p *Picture) BBox() (minX, minY, maxX, maxY float64, ok bool) {
+	if p.clipPath != nil && p.clipPath.Head != nil {
+		minX, minY, maxX, maxY = mp.PathBBox(p.clipPath)
+		return minX, minY, maxX, maxY, true
+	}
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	merge := func(x0, y0, x1, y1 float64) {
+		minX = math.Min(minX, x0)
+		minY = math.Min(minY, y0)
+		maxX = math.Max(maxX, x1)
+		maxY = math.Max(maxY, y1)
+	}
+	paths := append([]*mp.Path{}, p.paths...)
+	for _, m := range p.multiPaths {
+		for _, part := range m.Parts {
+			q := *part
+			q.Style = m.Style
+			paths = append(paths, &q)
+		}
+	}
+	for _, path := range paths {
+		if path == nil || path.Head == nil {
+			continue
+		}
+		if path.Envelope != nil {
+			merge(mp.PathBBox(path.Envelope))
+			continue
+		}
+		x0, y0, x1, y1 := mp.PathBBox(path)
+		half := 0.0
+		if path.Style.Stroke.CSS() != "none" {
+			half = path.Style.StrokeWidth / 2
+			if pen := path.Style.Pen; pen != nil && pen.Elliptical {
+				half = mp.GetPenScale(pen) / 2
+			}
+		}
+		merge(x0-half, y0-half, x1+half, y1+half)
+	}
+	for _, label := range p.labels {
+		if label != nil {
+			merge(label.EstimateBounds())
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return 0, 0, 0, 0, false
+	}
+	return minX, minY, maxX, maxY, true
+}
+
+// Outline returns the combined outline of everything in the picture grown
+// by distance (see mp.Outline): strokes, fills and multi-paths, with labels
+// counted as the filled rectangles of their estimated bounds. The clipping
+// path is ignored. The result has no style; it is nil for an empty picture.
+//
+// Example:
+//
+//	cut := pic.Outline(4)
+//	cut.Style = mp.Style{Stroke: mp.ColorCSS("magenta"), StrokeWidth: 0.25}
+//	pic.AddMultiPath(cut)
+func (p *Picture) Outline(distance float64) *mp.MultiPath {
+	paths := append([]*mp.Path{}, p.paths...)
+	for _, m := range p.multiPaths {
+		for _, part := range m.Parts {
+			q := *part
+			q.Style = m.Style
+			paths = append(paths, &q)
+		}
+	}
+	for _, label := range p.labels {
+		if label == nil {
+			continue
+		}
+		x0, y0, x1, y1 := label.EstimateBounds()
+		box := mp.XScaled(x1 - x0).Then(mp.YScaled(y1 - y0)).Then(mp.Shifted(x0, y0)).ApplyToPath(mp.UnitSquare())
+		box.Style = mp.Style{Stroke: mp.ColorCSS("none"), Fill: mp.ColorCSS("black")}
+		paths = append(paths, box)
+	}
+	return mp.Outline(paths, distance)
+}
+
+// Transform applies t to every path, label and the clipping path of the
+// picture, like MetaPost's "pic transformed T". Paths are replaced by
+// transformed copies, so pictures sharing paths via AddPicture are not
+// affected. Label font sizes and offsets scale with sqrt|det T|.
+func (p *Picture) Transform(t mp.Transform) *Picture {
+	for i, path := range p.paths {
+		if path != nil {
+			p.paths[i] = transformPath(path, t)
+		}
+	}
+	for i, m := range p.multiPaths {
+		p.multiPaths[i] = transformMultiPath(m, t)
+	}
+	for i, label := range p.labels {
+		if label != nil {
+			p.labels[i] = transformLabel(label, t)
+		}
+	}
+	if p.clipPath != nil {
+		p.clipPath = t.ApplyToPath(p.clipPath)
+	}
+	p.export = p.ExportTransform().Then(t)
+	p.exportSet = true
+	return p
+}
+
+// ExportTransform returns the cumulative transform applied to the picture by
+// Transform and FitTo, mapping the original model coordinates to the current
+// ones. Use it to convert auxiliary data such as image hotspots or HTML
+// overlay positions into the space the picture is exported in (combine with
+// svg.Builder.ModelTransform for SVG user units).
+func (p *Picture) ExportTransform() mp.Transform {
+	if !p.exportSet {
+		return mp.Identity()
+	}
+	return p.export
+}
+
+// transformMultiPath returns a transformed copy of m with its style
+// transformed like that of a path.
+func transformMultiPath(m *mp.MultiPath, t mp.Transform) *mp.MultiPath {
+	q := t.ApplyToMultiPath(m)
+	q.Style = transformStyle(m.Style, t)
+	return q
+}
+
+// transformPath returns a transformed copy of path, including its envelope.
+// As in MetaPost, the pen is transformed along with the path (without the
+// translation part) and the stroke width scales with sqrt|det t|.
+func transformPath(path *mp.Path, t mp.Transform) *mp.Path {
+	q := t.ApplyToPath(path)
+	if path.Envelope != nil {
+		q.Envelope = t.ApplyToPath(path.Envelope)
+	}
+	q.Style = transformStyle(path.Style, t)
+	return q
+}
+
+// transformLabel returns a copy of label moved by t, with its font size and
+// offset scaled by sqrt|det t|.
+func transformLabel(label *mp.Label, t mp.Transform) *mp.Label {
+	l := *label
+	l.Position.X, l.Position.Y = t.ApplyToPoint(l.Position.X, l.Position.Y)
+	if l.FontSize == 0 {
+		l.FontSize = mp.DefaultFontSize
+	}
+	if l.LabelOffset == 0 {
+		l.LabelOffset = mp.DefaultLabelOffset
+	}
+	scale := math.Sqrt(math.Abs(t.Determinant()))
+	l.FontSize *= scale
+	l.LabelOffset *= scale
+	return &l
+}
+
+// transformStyle returns style with its pen transformed by the linear part
+// of t and its stroke width scaled by sqrt|det t|, and its closing style
+// likewise.
+func transformStyle(style mp.Style, t mp.Transform) mp.Style {
+	style.StrokeWidth *= math.Sqrt(math.Abs(t.Determinant()))
+	if pen := style.Pen; pen != nil && pen.Head != nil {
+		linear := t
+		linear.Tx, linear.Ty = 0, 0
+		style.Pen = linear.ApplyToPen(pen)
+	}
+	if style.Closing != nil {
+		closing := transformStyle(*style.Closing, t)
+		style.Closing = &closing
+	}
+	return style
+}
+
 // Labels returns all labels in the picture.
 func (p *Picture) Labels() []*mp.Label {
 	return p.labels
@@ -1,7 +1,13 @@
 package draw
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/boxesandglue/mpgo/svg"
+	"io"
+	"math"
 	"strings"
 	"testing"
 
@@ -196,3 +202,874 @@ func TestPictureNoClip(t *testing.T) {
 		t.Errorf("unexpected clip-path attribute in unclipped picture")
 	}
 }
+
+// TestLabelPicture places a unit square as a sub-picture label above a point.
+func TestLabelPicture(t *testing.T) {
+	sq := mp.UnitSquare()
+	sq.Style.Stroke = mp.ColorCSS("none")
+	sq.Style.Fill = mp.ColorCSS("black")
+	icon := NewPicture().AddPath(sq)
+
+	pic := NewPicture().LabelPicture(icon, mp.P(10, 20), mp.AnchorTop)
+	if got := len(pic.Paths()); got != 1 {
+		t.Fatalf("expected 1 path, got %d", got)
+	}
+	if pic.Paths()[0] == sq {
+		t.Fatalf("expected a copy of the sub-picture path")
+	}
+	minX, minY, maxX, maxY, ok := pic.BBox()
+	if !ok {
+		t.Fatalf("bbox not ok")
+	}
+	// label.top: bottom center of the bbox at z + labeloffset*up
+	want := [4]float64{9.5, 20 + mp.DefaultLabelOffset, 10.5, 21 + mp.DefaultLabelOffset}
+	got := [4]float64{minX, minY, maxX, maxY}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("unexpected bbox: got %v, want %v", got, want)
+		}
+	}
+	// The original picture must stay untouched.
+	if x0, y0, _, _, _ := icon.BBox(); x0 != 0 || y0 != 0 {
+		t.Fatalf("sub-picture was modified: %v %v", x0, y0)
+	}
+}
+
+// TestSVGModelBackgroundAndFrame checks that margins enlarge the viewBox and
+// that the background and frame cover the final bounding box.
+func TestSVGModelBackgroundAndFrame(t *testing.T) {
+	sq := mp.Scaled(10).ApplyToPath(mp.UnitSquare())
+	sq.Style.Stroke = mp.ColorCSS("none")
+	sq.Style.Fill = mp.ColorCSS("red")
+	pic := NewPicture().AddPath(sq)
+
+	b := svg.NewBuilder().Margin(5).
+		SetModelBackground(mp.ColorCSS("yellow")).
+		Frame(&mp.Style{Stroke: mp.ColorCSS("blue"), StrokeWidth: 1}).
+		AddPicture(pic)
+	var sb strings.Builder
+	if err := b.WriteTo(&sb); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	out := sb.String()
+	// 10 units content + 2×0.25 default half stroke + 2×5 margin
+	if !strings.Contains(out, `viewBox="0 0 20.5 20.5"`) {
+		t.Errorf("expected margins in viewBox, got %s", out)
+	}
+	bg := strings.Index(out, `fill="yellow"`)
+	content := strings.Index(out, `fill="red"`)
+	frame := strings.Index(out, `stroke="blue"`)
+	if bg < 0 || content < 0 || frame < 0 {
+		t.Fatalf("missing background, content or frame: %s", out)
+	}
+	if !(bg < content && content < frame) {
+		t.Errorf("unexpected drawing order: %s", out)
+	}
+	if !strings.Contains(out, "M 0.500000 20.000000") {
+		t.Errorf("expected frame inset by half the stroke width: %s", out)
+	}
+}
+
+// TestMultiPathSVG checks that a multi-part path becomes one path element with
+// one M command per part.
+func TestMultiPathSVG(t *testing.T) {
+	m, err := SolveMultiPath(
+		NewPath().MoveTo(P(0, 0)).LineTo(P(10, 0)).WithStrokeColor(mp.ColorCSS("red")),
+		NewPath().MoveTo(P(0, 5)).LineTo(P(10, 5)),
+	)
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	if len(m.Parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d", len(m.Parts))
+	}
+	pic := NewPicture().AddMultiPath(m)
+	if x0, y0, x1, y1, ok := pic.BBox(); !ok || x0 > 0 || y0 > 0 || x1 < 10 || y1 < 5 {
+		t.Errorf("unexpected bbox %v %v %v %v", x0, y0, x1, y1)
+	}
+
+	var b strings.Builder
+	if err := svg.NewBuilder().AddPicture(pic).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	out := b.String()
+	if cnt := strings.Count(out, "<path"); cnt != 1 {
+		t.Fatalf("expected 1 path element, got %d: %s", cnt, out)
+	}
+	if cnt := strings.Count(out, "M "); cnt != 2 {
+		t.Errorf("expected 2 move commands, got %d: %s", cnt, out)
+	}
+	if !strings.Contains(out, `stroke="red"`) {
+		t.Errorf("expected style of the first part: %s", out)
+	}
+}
+
+// TestMultiPathStacking checks that paths and multi-paths are written in the
+// order they were added, with and without a clip path.
+func TestMultiPathStacking(t *testing.T) {
+	square := func(x float64, color string) *mp.Path {
+		p := mp.Shifted(x, 0).ApplyToPath(mp.Scaled(10).ApplyToPath(mp.UnitSquare()))
+		p.Style.Fill = mp.ColorCSS(color)
+		return p
+	}
+	multi := mp.NewMultiPath(square(5, "green"))
+	multi.Style.Fill = mp.ColorCSS("green")
+	for _, clip := range []bool{false, true} {
+		pic := NewPicture().AddPath(square(0, "red")).AddMultiPath(multi).AddPath(square(10, "blue"))
+		if got := pic.MultiPathPositions(); len(got) != 1 || got[0] != 1 {
+			t.Fatalf("positions = %v, want [1]", got)
+		}
+		if clip {
+			pic.Clip(mp.Scaled(30).ApplyToPath(mp.UnitSquare()))
+		}
+		var b strings.Builder
+		if err := svg.NewBuilder().AddPicture(pic).WriteTo(&b); err != nil {
+			t.Fatalf("write svg: %v", err)
+		}
+		out := b.String()
+		red := strings.Index(out, `fill="red"`)
+		green := strings.Index(out, `fill="green"`)
+		blue := strings.Index(out, `fill="blue"`)
+		if red < 0 || !(red < green && green < blue) {
+			t.Errorf("clip %v: unexpected drawing order: %s", clip, out)
+		}
+	}
+}
+
+// TestMultiPathPositions checks that the multi-paths keep their place
+// between the paths when paths are dropped or shadows are added, and that
+// Paths still returns the stored paths.
+func TestMultiPathPositions(t *testing.T) {
+	square := func(x float64) *mp.Path {
+		return mp.Shifted(x, 0).ApplyToPath(mp.Scaled(10).ApplyToPath(mp.UnitSquare()))
+	}
+	a, b := square(0), square(20)
+	pic := NewPicture().AddPath(a).AddPath(square(0)).AddMultiPath(mp.NewMultiPath(square(10))).AddPath(b)
+	if got := pic.Paths(); len(got) != 3 || got[0] != a || got[2] != b {
+		t.Fatalf("Paths() = %v, want the added paths", got)
+	}
+	if got := pic.MultiPathPositions(); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("positions = %v, want [2]", got)
+	}
+	if _, err := DedupeStage().Process(pic); err != nil {
+		t.Fatal(err)
+	}
+	if got := pic.MultiPathPositions(); len(pic.Paths()) != 2 || len(got) != 1 || got[0] != 1 {
+		t.Fatalf("after dedupe: %d paths, positions %v, want 2 and [1]", len(pic.Paths()), got)
+	}
+	pic.Shadow(30, 0.5, mp.ColorCSS("gray"))
+	if got := pic.MultiPathPositions(); len(pic.Paths()) != 4 || len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Fatalf("after shadow: %d paths, positions %v, want 4 and [1 3]", len(pic.Paths()), got)
+	}
+	sum := NewPicture().AddPath(square(0)).AddPicture(pic, mp.Shifted(5, 0))
+	if got := sum.MultiPathPositions(); len(got) != 2 || got[0] != 2 || got[1] != 4 {
+		t.Errorf("after AddPicture: positions %v, want [2 4]", got)
+	}
+}
+
+// TestMultiPathMixedEnvelope checks that the parts of a multi-path with a
+// pen envelope are filled and the other parts stroked.
+func TestMultiPathMixedEnvelope(t *testing.T) {
+	line, err := mp.StraightPath([]mp.Point{mp.P(0, 0), mp.P(10, 0)}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stroke, err := mp.StraightPath([]mp.Point{mp.P(0, 5), mp.P(10, 5)}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stroke.Envelope = mp.Shifted(0, 4).ApplyToPath(mp.XScaled(10).Then(mp.YScaled(2)).ApplyToPath(mp.UnitSquare()))
+	m := mp.NewMultiPath(line, stroke)
+	m.Style = mp.Style{Stroke: mp.ColorCSS("red"), StrokeWidth: 1}
+	var b strings.Builder
+	if err := svg.NewBuilder().AddPicture(NewPicture().AddMultiPath(m)).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	out := b.String()
+	if cnt := strings.Count(out, "<path"); cnt != 2 {
+		t.Fatalf("expected 2 path elements, got %d: %s", cnt, out)
+	}
+	if !strings.Contains(out, `stroke="red"`) || !strings.Contains(out, `fill="red"`) {
+		t.Errorf("expected a stroked line and a filled envelope: %s", out)
+	}
+}
+
+// TestSVGSnapToPixels checks that horizontal lines are moved onto the pixel
+// grid only when snapping is enabled.
+func TestSVGSnapToPixels(t *testing.T) {
+	engine := mp.NewEngine()
+	low, err := NewPath().MoveTo(P(0, 0)).LineTo(P(10, 0)).WithStrokeWidth(1).SolveWithEngine(engine)
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	high, err := NewPath().MoveTo(P(0, 10.3)).LineTo(P(10, 10.3)).WithStrokeWidth(1).SolveWithEngine(mp.NewEngine())
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	pic := NewPicture().AddPath(low).AddPath(high)
+
+	render := func(b *svg.Builder) string {
+		var sb strings.Builder
+		if err := b.AddPicture(pic).WriteTo(&sb); err != nil {
+			t.Fatalf("write svg: %v", err)
+		}
+		return sb.String()
+	}
+	if out := render(svg.NewBuilder()); !strings.Contains(out, "M 0.500000 10.800000") {
+		t.Fatalf("unexpected unsnapped output: %s", out)
+	}
+	out := render(svg.NewBuilder().SnapToPixels(72))
+	if !strings.Contains(out, "M 0.500000 10.500000") {
+		t.Errorf("expected lower line snapped to a pixel center: %s", out)
+	}
+	if !strings.Contains(out, "M 0.500000 0.500000") {
+		t.Errorf("expected upper line unchanged: %s", out)
+	}
+}
+
+func TestPictureDrawShortcuts(t *testing.T) {
+	pic := NewPicture().
+		DrawLine(P(0, 0), P(100, 0), mp.Style{}).
+		DrawCircle(P(50, 50), 20, mp.Style{Stroke: mp.ColorCSS("red"), StrokeWidth: 2}).
+		FillCircle(P(0, 0), 2, mp.ColorCSS("blue")).
+		DrawRect(10, 20, 40, 30, mp.Style{})
+	paths := pic.Paths()
+	if len(paths) != 4 {
+		t.Fatalf("expected 4 paths, got %d", len(paths))
+	}
+	if s := paths[0].Style; s.Stroke.CSS() != "black" || s.StrokeWidth != 0.5 {
+		t.Errorf("line style = %+v, want default draw style", s)
+	}
+	if s := paths[1].Style; s.Stroke.CSS() != "red" || s.StrokeWidth != 2 {
+		t.Errorf("circle style = %+v", s)
+	}
+	minX, minY, maxX, maxY := mp.PathBBox(paths[1])
+	if math.Abs(minX-30) > 1e-9 || math.Abs(maxX-70) > 1e-9 || math.Abs(minY-30) > 1e-9 || math.Abs(maxY-70) > 1e-9 {
+		t.Errorf("circle bbox = %g %g %g %g", minX, minY, maxX, maxY)
+	}
+	if s := paths[2].Style; s.Fill.CSS() != "blue" || s.Stroke.CSS() != "none" {
+		t.Errorf("filled circle style = %+v", s)
+	}
+	minX, minY, maxX, maxY = mp.PathBBox(paths[3])
+	if minX != 10 || minY != 20 || maxX != 50 || maxY != 50 {
+		t.Errorf("rect bbox = %g %g %g %g", minX, minY, maxX, maxY)
+	}
+	if paths[3].Head.LType == mp.KnotEndpoint {
+		t.Error("rectangle should be closed")
+	}
+}
+
+// TestPictureDrawShortcutsNotFinite checks that the shortcuts skip shapes
+// with NaN or infinite values instead of panicking.
+func TestPictureDrawShortcutsNotFinite(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	pic := NewPicture().
+		DrawLine(P(nan, 0), P(10, 0), mp.Style{}).
+		DrawLine(P(0, 0), P(10, inf), mp.Style{}).
+		DrawCircle(P(0, nan), 5, mp.Style{}).
+		FillCircle(P(0, 0), inf, mp.ColorCSS("blue")).
+		DrawRect(0, 0, nan, 10, mp.Style{}).
+		DrawLine(P(0, 0), P(10, 0), mp.Style{})
+	if n := len(pic.Paths()); n != 1 {
+		t.Errorf("expected only the finite line, got %d paths", n)
+	}
+}
+
+func TestPictureSetDefaults(t *testing.T) {
+	pic := NewPicture()
+	before, _ := NewPath().MoveTo(P(0, 0)).LineTo(P(10, 0)).Solve()
+	pic.AddPath(before)
+	pic.SetDefaults(mp.NewStyle(mp.WithStroke(mp.ColorCSS("blue")), mp.WithStrokeWidth(2), mp.WithLineCap(mp.LineCapButt)))
+	pic.DrawLine(P(0, 0), P(10, 10), mp.Style{})
+	pic.DrawLine(P(0, 0), P(10, 20), mp.NewStyle(mp.WithStroke(mp.ColorCSS("red"))))
+	paths := pic.Paths()
+	if paths[0].Style.Stroke.CSS() == "blue" {
+		t.Error("defaults must not change paths added before")
+	}
+	if s := paths[1].Style; s.Stroke.CSS() != "blue" || s.StrokeWidth != 2 || s.LineCap != mp.LineCapButt {
+		t.Errorf("defaults not applied: %+v", s)
+	}
+	if s := paths[2].Style; s.Stroke.CSS() != "red" || s.StrokeWidth != 2 {
+		t.Errorf("explicit stroke should win over defaults: %+v", s)
+	}
+}
+
+func TestPictureClone(t *testing.T) {
+	base := NewPicture().
+		DrawCircle(P(0, 0), 10, mp.Style{Stroke: mp.ColorCSS("black"), Pen: mp.PenSquare(1), Dash: mp.NewDashPattern(2, 2)}).
+		Label("A", P(0, 0), mp.AnchorTop)
+	base.AddMultiPath(mp.NewMultiPath(mp.UnitSquare()))
+	base.Clip(mp.FullCircle())
+
+	c := base.Clone()
+	c.Paths()[0].Head.XCoord = 99
+	c.Paths()[0].Style.Pen.Head.XCoord = 99
+	c.Paths()[0].Style.Dash.Array[0] = 99
+	c.MultiPaths()[0].Parts[0].Head.XCoord = 99
+	c.Labels()[0].Text = "B"
+	c.ClipPath().Head.XCoord = 99
+	c.Transform(mp.Shifted(5, 5))
+
+	orig := base.Paths()[0]
+	if orig.Head.XCoord == 99 || orig.Style.Pen.Head.XCoord == 99 || orig.Style.Dash.Array[0] == 99 {
+		t.Error("changing the clone modified the path of the original")
+	}
+	if base.MultiPaths()[0].Parts[0].Head.XCoord == 99 {
+		t.Error("changing the clone modified a multi-path part of the original")
+	}
+	if base.Labels()[0].Text != "A" || base.Labels()[0].Position != P(0, 0) {
+		t.Errorf("label of the original changed: %+v", base.Labels()[0])
+	}
+	if base.ClipPath().Head.XCoord == 99 {
+		t.Error("changing the clone modified the clip path of the original")
+	}
+	if base.ExportTransform() != mp.Identity() {
+		t.Error("transforming the clone changed the export transform of the original")
+	}
+}
+
+func TestPictureFreeze(t *testing.T) {
+	pic := NewPicture().DrawLine(P(0, 0), P(10, 0), mp.Style{})
+	frozen := pic.Freeze()
+	pic.DrawLine(P(0, 0), P(0, 10), mp.Style{})
+	pic.Paths()[0].Head.XCoord = -5
+
+	if n := len(frozen.Paths()); n != 1 {
+		t.Fatalf("frozen picture has %d paths, want 1", n)
+	}
+	frozen.Paths()[0].Head.XCoord = 42
+	if x := frozen.Paths()[0].Head.XCoord; x != 0 {
+		t.Errorf("frozen path starts at x=%g, want 0", x)
+	}
+	v := frozen.Picture().Transform(mp.Shifted(100, 0))
+	if x := v.Paths()[0].Head.XCoord; x != 100 {
+		t.Errorf("variation starts at x=%g, want 100", x)
+	}
+	if minX, _, maxX, _, ok := frozen.BBox(); !ok || minX != -0.25 || maxX != 10.25 {
+		t.Errorf("frozen bbox x = %g..%g, want -0.25..10.25", minX, maxX)
+	}
+
+	var sb strings.Builder
+	b := svg.NewBuilder().AddPicture(frozen)
+	if err := b.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(sb.String(), "<path") != 1 {
+		t.Errorf("expected one path in SVG:\n%s", sb.String())
+	}
+}
+
+func TestPictureOutline(t *testing.T) {
+	if NewPicture().Outline(2) != nil {
+		t.Error("empty picture should have no outline")
+	}
+	pic := NewPicture().DrawLine(P(0, 0), P(100, 0), mp.Style{StrokeWidth: 2})
+	pic.Label("Label", P(0, 50), mp.AnchorCenter)
+	out := pic.Outline(3)
+	if out == nil || len(out.Parts) != 2 {
+		t.Fatalf("expected outlines around the line and the label, got %v", out)
+	}
+	minX, minY, maxX, maxY := mp.PathBBox(out.Parts[0])
+	for _, part := range out.Parts[1:] {
+		x0, y0, x1, y1 := mp.PathBBox(part)
+		minX, minY = math.Min(minX, x0), math.Min(minY, y0)
+		maxX, maxY = math.Max(maxX, x1), math.Max(maxY, y1)
+	}
+	if math.Abs(minY+4) > 0.3 || math.Abs(maxX-104) > 0.3 || maxY < 53 {
+		t.Errorf("outline bbox = %g %g %g %g", minX, minY, maxX, maxY)
+	}
+}
+
+func TestPictureShadow(t *testing.T) {
+	pic := NewPicture().
+		DrawRect(0, 0, 10, 20, mp.Style{}).
+		DrawLine(P(20, 0), P(20, 10), mp.Style{StrokeWidth: 2})
+	pic.Shadow(0, 1, mp.ColorRGBA(0, 0, 0, 0.3))
+	paths := pic.Paths()
+	if len(paths) != 4 {
+		t.Fatalf("expected 2 shadows and 2 paths, got %d paths", len(paths))
+	}
+	rect, line := paths[0], paths[1]
+	if rect.Style.Fill.CSS() == "" || rect.Style.Stroke.CSS() != "none" {
+		t.Errorf("closed path should cast a filled shadow: %+v", rect.Style)
+	}
+	if op, ok := rect.Style.Fill.Opacity(); !ok || op != 0.3 {
+		t.Errorf("shadow opacity = %g, %v", op, ok)
+	}
+	if line.Style.StrokeWidth != 2 || line.Style.Fill.CSS() != "" {
+		t.Errorf("open path should cast a stroked shadow: %+v", line.Style)
+	}
+	if _, _, maxX, maxY := mp.PathBBox(rect); math.Abs(maxX-30) > 1e-9 || math.Abs(maxY) > 1e-9 {
+		t.Errorf("rectangle shadow should lie flat up to x=30, got maxX=%g maxY=%g", maxX, maxY)
+	}
+	if paths[2].Style.Fill.CSS() != "" {
+		t.Error("originals should follow their shadows")
+	}
+}
+
+func TestSVGWriteProgress(t *testing.T) {
+	pic := NewPicture().
+		DrawLine(P(0, 0), P(10, 0), mp.Style{}).
+		DrawLine(P(0, 0), P(0, 10), mp.Style{}).
+		Label("A", P(5, 5), mp.AnchorCenter)
+	var reports [][2]int
+	b := svg.NewBuilder().AddPicture(pic).SetProgress(func(done, total int) {
+		reports = append(reports, [2]int{done, total})
+	})
+	var sb strings.Builder
+	if err := b.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 3 || reports[2] != [2]int{3, 3} {
+		t.Errorf("progress reports %v, want 3 ending in [3 3]", reports)
+	}
+}
+
+func TestSVGWriteToContext(t *testing.T) {
+	pic := NewPicture()
+	for i := 0; i < 10; i++ {
+		pic.DrawLine(P(0, 0), P(10, float64(i)), mp.Style{})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	written := 0
+	b := svg.NewBuilder().AddPicture(pic).SetProgress(func(done, total int) {
+		written = done
+		if done == 3 {
+			cancel()
+		}
+	})
+	var sb strings.Builder
+	if err := b.WriteToContext(ctx, &sb); !errors.Is(err, context.Canceled) {
+		t.Fatalf("WriteToContext = %v, want context.Canceled", err)
+	}
+	if written != 3 || strings.Count(sb.String(), "<path") != 3 {
+		t.Errorf("expected output to stop after 3 paths, wrote %d:\n%s", written, sb.String())
+	}
+}
+
+func TestSVGLineStyle(t *testing.T) {
+	path, err := NewPath().MoveTo(P(0, 0)).LineTo(P(20, 0)).SolveWithEngine(mp.NewEngine())
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	path.Style = mp.NewStyle(mp.WithStroke(mp.ColorCSS("black")), mp.WithStrokeWidth(1), mp.WithLineStyle(mp.LineStyleRoadCasing))
+	pic := NewPicture().AddPath(path)
+	var b strings.Builder
+	if err := svg.NewBuilder().FitViewBoxToPictures(pic).AddPicture(pic).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	out := b.String()
+	if cnt := strings.Count(out, "<path"); cnt != 2 {
+		t.Fatalf("expected 2 casing lines in svg, got %d", cnt)
+	}
+	if strings.Count(out, "stroke-dasharray") != 2 {
+		t.Fatalf("expected dashed casing lines:\n%s", out)
+	}
+}
+
+func TestSVGDot(t *testing.T) {
+	dot, err := NewPath().MoveTo(P(3, 4)).WithStrokeColor(mp.ColorCSS("red")).WithPen(mp.PenCircle(2)).Solve()
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	if !dot.IsPoint() || dot.PathLength() != 0 {
+		t.Fatalf("MoveTo without segments should give a single-knot path, got %v", dot)
+	}
+	pic := NewPicture().AddPath(dot)
+	var b strings.Builder
+	if err := svg.NewBuilder().FitViewBoxToPictures(pic).AddPicture(pic).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	out := b.String()
+	if !strings.Contains(out, `fill="red"`) || !strings.Contains(out, `stroke="none"`) {
+		t.Fatalf("dot should be a filled pen shape:\n%s", out)
+	}
+}
+
+func TestSVGEnvelopeArrow(t *testing.T) {
+	build := func(joined bool) string {
+		b := NewPath().MoveTo(P(0, 0)).LineTo(P(40, 0)).WithPen(mp.PenSquare(3)).WithStrokeColor(mp.ColorCSS("black")).WithArrow()
+		if joined {
+			b.WithJoinedArrows()
+		}
+		path, err := b.Solve()
+		if err != nil {
+			t.Fatalf("solve: %v", err)
+		}
+		if path.Envelope == nil {
+			t.Fatal("square pen should give an envelope")
+		}
+		pic := NewPicture().AddPath(path)
+		var sb strings.Builder
+		if err := svg.NewBuilder().FitViewBoxToPictures(pic).AddPicture(pic).WriteTo(&sb); err != nil {
+			t.Fatalf("write svg: %v", err)
+		}
+		return sb.String()
+	}
+	out := build(false)
+	if cnt := strings.Count(out, "<path"); cnt != 2 {
+		t.Fatalf("expected envelope and head as 2 paths, got %d:\n%s", cnt, out)
+	}
+	out = build(true)
+	if cnt := strings.Count(out, "<path"); cnt != 1 {
+		t.Fatalf("expected one joined outline, got %d paths:\n%s", cnt, out)
+	}
+	if strings.Count(out, "M") != 2 || !strings.Contains(out, `fill="black"`) {
+		t.Fatalf("joined outline should be one filled path with two parts:\n%s", out)
+	}
+}
+
+func TestSVGStrokeGradient(t *testing.T) {
+	path, err := NewPath().MoveTo(P(0, 0)).LineTo(P(10, 0)).
+		WithStrokeGradient(mp.ColorRGB(0, 0, 1), mp.ColorRGB(1, 0, 0)).Solve()
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	pic := NewPicture().AddPath(path)
+	var b strings.Builder
+	if err := svg.NewBuilder().FitViewBoxToPictures(pic).AddPicture(pic).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	out := b.String()
+	if cnt := strings.Count(out, "<path"); cnt != 5 {
+		t.Fatalf("expected 5 gradient pieces, got %d:\n%s", cnt, out)
+	}
+	if !strings.Contains(out, `stroke="rgb(26,0,230)"`) || !strings.Contains(out, `stroke="rgb(230,0,25)"`) {
+		t.Fatalf("pieces should run from blue to red:\n%s", out)
+	}
+}
+
+func TestSVGMeta(t *testing.T) {
+	path, err := NewPath().MoveTo(P(0, 0)).LineTo(P(10, 20)).
+		WithMeta("id", "inlet").WithMeta("Flow Rate", `3 "l/s"`).Solve()
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	other, err := NewPath().MoveTo(P(0, 0)).LineTo(P(10, 0)).WithMeta("kind", "axis").Solve()
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	pic := NewPicture().AddPath(path).AddPath(other)
+	b := svg.NewBuilder().FitViewBoxToPictures(pic).AddPicture(pic)
+	var out strings.Builder
+	if err := b.WriteTo(&out); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	if !strings.Contains(out.String(), ` id="inlet" data-flow-rate="3 &quot;l/s&quot;"/>`) {
+		t.Fatalf("missing metadata attributes:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), ` id="mpgo-1" data-kind="axis"/>`) {
+		t.Fatalf("missing generated id:\n%s", out.String())
+	}
+
+	var idx strings.Builder
+	if err := b.WriteIndex(&idx); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	var index map[string]svg.IndexEntry
+	if err := json.Unmarshal([]byte(idx.String()), &index); err != nil {
+		t.Fatalf("decode index: %v\n%s", err, idx.String())
+	}
+	inlet, ok := index["inlet"]
+	if !ok || len(index) != 2 {
+		t.Fatalf("index = %v", index)
+	}
+	if inlet.Meta["Flow Rate"] != `3 "l/s"` {
+		t.Errorf("meta = %v", inlet.Meta)
+	}
+	// The line runs from the bottom left to the top right of the viewBox,
+	// with the default padding and half stroke on each side.
+	bb := inlet.BBox
+	if w, h := bb[2]-bb[0], bb[3]-bb[1]; math.Abs(w-10) > 1e-9 || math.Abs(h-20) > 1e-9 {
+		t.Errorf("bbox = %v, want 10 by 20", bb)
+	}
+	if axis := index["mpgo-1"].BBox; math.Abs(axis[1]-bb[3]) > 1e-9 {
+		t.Errorf("axis at y = %g, want %g (bottom of the line)", axis[1], bb[3])
+	}
+}
+
+// extentRect is a custom ExtentContributor.
+type extentRect struct{ minX, minY, maxX, maxY float64 }
+
+func (r extentRect) Extent() (float64, float64, float64, float64, bool) {
+	return r.minX, r.minY, r.maxX, r.maxY, true
+}
+
+func TestSVGExtentContributors(t *testing.T) {
+	// A diagonal line with square caps: the cap corners reach past the
+	// half stroke padding of its end points.
+	path, err := NewPath().MoveTo(P(0, 0)).LineTo(P(10, 10)).Solve()
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	path.Style.StrokeWidth = 2
+	path.Style.LineCap = mp.LineCapSquared
+	var b strings.Builder
+	if err := svg.NewBuilder().AddPathFromPath(path).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	// 10 + 2·(1/√2) cap + 2·1 half stroke
+	want := fmt.Sprintf(`viewBox="0 0 %g %g"`, 12+math.Sqrt2, 12+math.Sqrt2)
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("expected %s:\n%s", want, b.String())
+	}
+
+	b.Reset()
+	line, _ := NewPath().MoveTo(P(0, 0)).LineTo(P(10, 0)).Solve()
+	if err := svg.NewBuilder().AddPathFromPath(line).AddExtent(extentRect{0, -20, 10, 0}).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	if !strings.Contains(b.String(), `viewBox="0 0 10.5 20.5"`) {
+		t.Errorf("custom extent not included:\n%s", b.String())
+	}
+}
+
+func TestSVGHairlineAndMinStrokeWidth(t *testing.T) {
+	hair, err := NewPath().MoveTo(P(0, 0)).LineTo(P(10, 0)).WithHairline().WithPen(mp.PenSquare(3)).Solve()
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	thin, err := NewPath().MoveTo(P(0, 5)).LineTo(P(10, 5)).WithStrokeWidth(0.05).Solve()
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	pic := NewPicture().AddPath(hair).AddPath(thin)
+	var b strings.Builder
+	if err := svg.NewBuilder().MinStrokeWidth(0.3).AddPicture(pic).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	out := b.String()
+	if strings.Count(out, `stroke-width="1" vector-effect="non-scaling-stroke"`) != 1 {
+		t.Errorf("expected one hairline (not an envelope):\n%s", out)
+	}
+	if !strings.Contains(out, `stroke-width="0.30"`) {
+		t.Errorf("thin stroke not widened to the minimum:\n%s", out)
+	}
+}
+
+func TestSVGRootAttributes(t *testing.T) {
+	path, _ := NewPath().MoveTo(P(0, 0)).LineTo(P(71.5, 0)).Solve()
+	pic := NewPicture().AddPath(path)
+	var b strings.Builder
+	err := svg.NewBuilder().XMLDeclaration(true).DocType(true).Units("mm").
+		PreserveAspectRatio("xMidYMid meet").RootAttr("class", "fig").RootAttr("class", `a "b"`).
+		AddPicture(pic).WriteTo(&b)
+	if err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	out := b.String()
+	if !strings.HasPrefix(out, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`+"\n<!DOCTYPE svg") {
+		t.Errorf("missing prolog:\n%s", out)
+	}
+	// 72 × 0.5 bp is 25.4 × 0.1764 mm.
+	if !strings.Contains(out, `width="25.4mm" height="0.176mm" viewBox="0 0 72 0.5" preserveAspectRatio="xMidYMid meet" class="a &quot;b&quot;">`) {
+		t.Errorf("unexpected root element:\n%s", out)
+	}
+	if err := svg.NewBuilder().Units("furlong").AddPicture(pic).WriteTo(io.Discard); err == nil {
+		t.Error("unknown unit should fail")
+	}
+}
+
+func TestSVGQuadraticSegments(t *testing.T) {
+	path := mp.NewPath()
+	path.Append(&mp.Knot{LType: mp.KnotEndpoint, RType: mp.KnotExplicit})
+	path.AppendQuadratic(10, 0, 10, 10)
+	path.Head.Prev.RType = mp.KnotEndpoint
+	if d := svg.PathToSVG(path); d != "M 0.000 0.000 Q 10.000 0.000 10.000 10.000" {
+		t.Errorf("PathToSVG = %q", d)
+	}
+	var b strings.Builder
+	if err := svg.NewBuilder().AddPicture(NewPicture().AddPath(path)).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	if out := b.String(); !strings.Contains(out, `Q `) || strings.Contains(out, `C `) {
+		t.Errorf("expected a quadratic segment:\n%s", out)
+	}
+}
+
+func TestSVGCloseWith(t *testing.T) {
+	path, err := NewPath().MoveTo(P(0, 0)).LineTo(P(100, 0)).LineTo(P(100, 50)).
+		CloseWith(mp.NewStyle(mp.WithStroke(mp.ColorCSS("none")))).
+		WithFill(mp.ColorCSS("yellow")).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.Style.Closing == nil || path.Head.LType == mp.KnotEndpoint {
+		t.Fatalf("CloseWith did not close the path with a closing style")
+	}
+	var b strings.Builder
+	if err := svg.NewBuilder().AddPicture(NewPicture().AddPath(path)).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	out := b.String()
+	if n := strings.Count(out, "<path"); n != 2 {
+		t.Errorf("got %d path elements, want the fill and the open outline:\n%s", n, out)
+	}
+	if !strings.Contains(out, `fill="yellow"`) {
+		t.Errorf("fill missing:\n%s", out)
+	}
+
+	moved := NewPicture().AddPath(path).Clone().Transform(mp.Scaled(2))
+	if c := moved.Paths()[0].Style.Closing; c == nil || c == path.Style.Closing {
+		t.Errorf("transformed picture shares the closing style")
+	}
+}
+
+func TestSVGAutoCloseFill(t *testing.T) {
+	build := func() *PathBuilder {
+		return NewPath().MoveTo(P(0, 0)).LineTo(P(100, 0)).LineTo(P(100, 50)).WithFill(mp.ColorCSS("yellow"))
+	}
+	open, err := build().Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := svg.NewBuilder().AddPicture(NewPicture().AddPath(open)).WriteTo(&b); !errors.Is(err, mp.ErrOpenFill) || b.Len() != 0 {
+		t.Fatalf("open fill: err = %v, wrote %q; want mp.ErrOpenFill and nothing", err, b.String())
+	}
+
+	open, err = build().WithAutoCloseFill().Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svg.NewBuilder().AddPicture(NewPicture().AddPath(open)).WriteTo(&b); err != nil {
+		t.Fatalf("write svg: %v", err)
+	}
+	out := b.String()
+	if n := strings.Count(out, "<path"); n != 2 {
+		t.Fatalf("got %d path elements, want the closed fill and the open stroke:\n%s", n, out)
+	}
+	fill := out[strings.Index(out, "<path"):]
+	stroke := fill[strings.Index(fill[1:], "<path")+1:]
+	if !strings.Contains(fill[:strings.Index(fill, "/>")], "Z") || !strings.Contains(fill, `fill="yellow" stroke="none"`) {
+		t.Errorf("fill is not a closed fill-only path:\n%s", out)
+	}
+	if strings.Contains(stroke, "Z") || !strings.Contains(stroke, `fill="none"`) {
+		t.Errorf("stroke is not the open unfilled path:\n%s", out)
+	}
+}
+
+func TestSVGDiffer(t *testing.T) {
+	frame := func(x float64, extra bool) *svg.Builder {
+		pic := NewPicture()
+		pic.DrawLine(mp.P(0, 0), mp.P(x, 0), mp.NewStyle(mp.WithMeta("id", "moving")))
+		pic.DrawCircle(mp.P(50, 50), 10, mp.Style{})
+		if extra {
+			pic.DrawLine(mp.P(0, 90), mp.P(90, 90), mp.Style{})
+		}
+		return svg.NewBuilder().SetViewBox(0, 0, 100, 100).AddPicture(pic)
+	}
+	d := svg.NewDiffer()
+	first, err := d.Diff(frame(10, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Root == "" || len(first.Added) != 2 || first.Added[0].Key != "moving" || first.Added[1].After != "moving" {
+		t.Fatalf("first patch = %+v", first)
+	}
+	circle := first.Added[1].Key
+	if !strings.Contains(first.Added[1].Markup, `data-key="`+circle+`"`) {
+		t.Errorf("element without id has no data-key: %s", first.Added[1].Markup)
+	}
+
+	same, err := d.Diff(frame(10, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !same.Empty() {
+		t.Errorf("unchanged render gave patch %+v", same)
+	}
+
+	next, err := d.Diff(frame(20, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Root != "" || len(next.Removed) != 0 {
+		t.Errorf("unexpected root change or removals: %+v", next)
+	}
+	if len(next.Updated) != 1 || next.Updated[0].Key != "moving" || !strings.Contains(next.Updated[0].Markup, `id="moving"`) {
+		t.Errorf("updated = %+v, want the moving line", next.Updated)
+	}
+	if len(next.Added) != 1 || next.Added[0].After != circle {
+		t.Errorf("added = %+v, want the new line after the circle", next.Added)
+	}
+
+	last, err := d.Diff(frame(20, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last.Removed) != 1 || last.Removed[0] != next.Added[0].Key || len(last.Added)+len(last.Updated) != 0 {
+		t.Errorf("removal patch = %+v", last)
+	}
+	if _, err := json.Marshal(last); err != nil {
+		t.Errorf("marshal patch: %v", err)
+	}
+}
+
+func TestSVGThemeColors(t *testing.T) {
+	pic := NewPicture()
+	pic.DrawLine(mp.P(0, 0), mp.P(10, 0), mp.Style{}) // default black stroke
+	box := mp.UnitSquare()
+	box.Style = mp.NewStyle(mp.WithFill(mp.ColorCSS("#1f77b4")), mp.WithStroke(mp.ColorCSS("red")))
+	pic.AddPath(box)
+
+	var sb strings.Builder
+	b := svg.NewBuilder().ThemeColors(
+		svg.Theme{"ink": mp.ColorCSS("black"), "accent": mp.ColorCSS("#1f77b4")},
+		svg.Theme{"ink": mp.ColorCSS("white"), "accent": mp.ColorCSS("#6cb4ee")},
+	)
+	if err := b.AddPicture(pic).WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		`<style>svg{--accent:#1f77b4;--ink:black;}@media (prefers-color-scheme: dark){svg{--accent:#6cb4ee;--ink:white;}}</style>`,
+		`stroke="var(--ink, black)"`,
+		`fill="var(--accent, #1f77b4)"`,
+		`stroke="red"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s:\n%s", want, out)
+		}
+	}
+
+	sb.Reset()
+	if err := b.ThemeColors(nil, nil).WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sb.String(), "var(--") || strings.Contains(sb.String(), "<style>") {
+		t.Errorf("theming not turned off:\n%s", sb.String())
+	}
+}
+
+func TestAddPictureTransformed(t *testing.T) {
+	part := NewPicture()
+	part.DrawLine(mp.P(0, 0), mp.P(10, 0), mp.Style{StrokeWidth: 1})
+	part.Label("R", mp.P(5, 0), mp.AnchorTop)
+
+	pic := NewPicture().
+		AddPicture(part).
+		AddPicture(part, mp.Shifted(0, 20)).
+		AddPicture(part, mp.Scaled(2), mp.Shifted(0, 40))
+	paths, labels := pic.Paths(), pic.Labels()
+	if len(paths) != 3 || len(labels) != 3 {
+		t.Fatalf("got %d paths and %d labels, want 3 each", len(paths), len(labels))
+	}
+	if paths[0] != part.Paths()[0] || labels[0] == part.Labels()[0] {
+		t.Error("untransformed addition should share paths and copy labels")
+	}
+	if x, y := paths[1].PointOf(1); x != 10 || y != 20 {
+		t.Errorf("shifted copy ends at (%g,%g), want (10,20)", x, y)
+	}
+	// Scaled first, then shifted; the pen scales along
+	if x, y := paths[2].PointOf(1); x != 20 || y != 40 || paths[2].Style.StrokeWidth != 2 {
+		t.Errorf("scaled copy ends at (%g,%g) width %g", x, y, paths[2].Style.StrokeWidth)
+	}
+	if l := labels[2]; l.Position != mp.P(10, 40) || l.FontSize != 2*mp.DefaultFontSize {
+		t.Errorf("scaled label at %v size %g", l.Position, l.FontSize)
+	}
+	// The component itself is unchanged
+	if x, y := part.Paths()[0].PointOf(1); x != 10 || y != 0 || part.Labels()[0].Position != mp.P(5, 0) {
+		t.Error("AddPicture changed the added picture")
+	}
+}
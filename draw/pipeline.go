@@ -0,0 +1,448 @@
+package draw
+
+import (
+	"math"
+	"reflect"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Stage is one geometry pass of a Pipeline. Process may change pic in
+// place; it returns the picture handed to the next stage.
+type Stage interface {
+	Process(pic *Picture) (*Picture, error)
+}
+
+// StageFunc adapts a function to the Stage interface.
+type StageFunc func(pic *Picture) (*Picture, error)
+
+// Process calls f(pic).
+func (f StageFunc) Process(pic *Picture) (*Picture, error) { return f(pic) }
+
+// Pipeline runs a sequence of stages over a picture before it is rendered,
+// e.g. to simplify plotted data, remove doubly drawn lines or cut away what
+// lies outside the page. The built-in stages are SimplifyStage,
+// DedupeStage, CullStage, SnapStage and DashExpandStage; custom passes
+// implement Stage or use StageFunc.
+//
+// Example:
+//
+//	pl := draw.NewPipeline(draw.DedupeStage(), draw.CullStage(0, 0, 200, 100))
+//	out, err := pl.Run(pic)
+type Pipeline struct {
+	stages []Stage
+}
+
+// NewPipeline returns a pipeline running the given stages in order.
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// Add appends stages to the pipeline.
+func (pl *Pipeline) Add(stages ...Stage) *Pipeline {
+	pl.stages = append(pl.stages, stages...)
+	return pl
+}
+
+// Stages returns the stages of the pipeline.
+func (pl *Pipeline) Stages() []Stage {
+	return pl.stages
+}
+
+// Run runs the stages on a clone of pic and returns the result; pic is not
+// changed. It stops at the first stage that fails.
+func (pl *Pipeline) Run(pic *Picture) (*Picture, error) {
+	out := pic.Clone()
+	for _, st := range pl.stages {
+		var err error
+		if out, err = st.Process(out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// SimplifyStage returns a stage that removes knots from polylines (paths
+// whose segments are all straight, such as plotted data) with the
+// Douglas-Peucker algorithm: knots that lie within tolerance of the
+// simplified line are dropped. Curved paths are not changed.
+func SimplifyStage(tolerance float64) Stage {
+	return StageFunc(func(pic *Picture) (*Picture, error) {
+		for i, path := range pic.paths {
+			pic.paths[i] = simplifyPolyline(path, tolerance)
+		}
+		for _, m := range pic.multiPaths {
+			for i, part := range m.Parts {
+				m.Parts[i] = simplifyPolyline(part, tolerance)
+			}
+		}
+		return pic, nil
+	})
+}
+
+// DedupeStage returns a stage that removes paths drawn again on top of an
+// earlier path with the same knots and style, as happens when pictures
+// with shared edges are combined.
+func DedupeStage() Stage {
+	return StageFunc(func(pic *Picture) (*Picture, error) {
+		var kept []*mp.Path
+		pic.mapPaths(func(path *mp.Path) []*mp.Path {
+			for _, k := range kept {
+				if duplicatePath(path, k) {
+					return nil
+				}
+			}
+			kept = append(kept, path)
+			return []*mp.Path{path}
+		})
+		return pic, nil
+	})
+}
+
+// CullStage returns a stage that removes paths, multi-paths and labels
+// whose extent (see mp.ExtentContributor) lies completely outside the
+// rectangle, e.g. the parts of a large map beyond the page.
+func CullStage(minX, minY, maxX, maxY float64) Stage {
+	inside := func(c mp.ExtentContributor) bool {
+		x0, y0, x1, y1, ok := c.Extent()
+		if !ok {
+			return false
+		}
+		return x1 >= minX && x0 <= maxX && y1 >= minY && y0 <= maxY
+	}
+	return StageFunc(func(pic *Picture) (*Picture, error) {
+		pic.mapPaths(func(path *mp.Path) []*mp.Path {
+			if path != nil && inside(path) {
+				return []*mp.Path{path}
+			}
+			return nil
+		})
+		pic.mapMultiPaths(func(m *mp.MultiPath) []*mp.MultiPath {
+			if m != nil && inside(m) {
+				return []*mp.MultiPath{m}
+			}
+			return nil
+		})
+		var labels []*mp.Label
+		for _, l := range pic.labels {
+			if l != nil && inside(l) {
+				labels = append(labels, l)
+			}
+		}
+		pic.labels = labels
+		return pic, nil
+	})
+}
+
+// SnapStage returns a stage that moves every knot to the nearest multiple
+// of grid, taking its control points along so curves keep their shape,
+// and moves label positions the same way. Envelopes of paths drawn with
+// polygonal pens are computed again.
+func SnapStage(grid float64) Stage {
+	snap := func(v float64) float64 { return math.Round(v/grid) * grid }
+	snapPath := func(path *mp.Path) {
+		if path == nil || path.Head == nil {
+			return
+		}
+		k := path.Head
+		for {
+			dx, dy := snap(k.XCoord)-k.XCoord, snap(k.YCoord)-k.YCoord
+			k.XCoord, k.YCoord = k.XCoord+dx, k.YCoord+dy
+			k.LeftX, k.LeftY = k.LeftX+dx, k.LeftY+dy
+			k.RightX, k.RightY = k.RightX+dx, k.RightY+dy
+			k = k.Next
+			if k == nil || k == path.Head {
+				break
+			}
+		}
+		if path.Envelope != nil && path.Style.Pen != nil {
+			if env := mp.MakeEnvelope(path, path.Style.Pen); env != nil {
+				env.Style = path.Envelope.Style
+				path.Envelope = env
+			}
+		}
+	}
+	return StageFunc(func(pic *Picture) (*Picture, error) {
+		if grid <= 0 {
+			return pic, nil
+		}
+		for _, path := range pic.paths {
+			snapPath(path)
+		}
+		for _, m := range pic.multiPaths {
+			for _, part := range m.Parts {
+				snapPath(part)
+			}
+		}
+		for _, l := range pic.labels {
+			if l != nil {
+				l.Position = mp.P(snap(l.Position.X), snap(l.Position.Y))
+			}
+		}
+		return pic, nil
+	})
+}
+
+// DashExpandStage returns a stage that replaces dashed paths by one path
+// per dash, as MetaPost does when it writes dashed pictures, for output
+// that has no dash patterns (plotters, laser cutters, some CAD formats).
+// The dashes are solid copies of the pieces of the path; arrowheads
+// become filled paths and the fill of a dashed cycle a fill-only copy.
+func DashExpandStage() Stage {
+	return StageFunc(func(pic *Picture) (*Picture, error) {
+		pic.mapPaths(func(path *mp.Path) []*mp.Path {
+			if path == nil || path.Head == nil || path.Style.Dash == nil || len(path.Style.Dash.Array) == 0 {
+				return []*mp.Path{path}
+			}
+			return expandDashes(path)
+		})
+		return pic, nil
+	})
+}
+
+// expandDashes returns the paths that draw the dashed path p.
+func expandDashes(p *mp.Path) []*mp.Path {
+	fill, body, heads := splitStroke(p)
+	var out []*mp.Path
+	if fill != nil {
+		out = append(out, fill)
+	}
+	out = append(out, dashPieces(body, p.Style.Dash)...)
+	return append(out, heads...)
+}
+
+// splitStroke splits what drawing p paints into a fill-only copy (nil
+// unless p is a filled cycle), the stroked body, shortened for arrowheads
+// as the renderers do and without fill and arrows, and the arrowheads as
+// filled paths.
+func splitStroke(p *mp.Path) (fill, body *mp.Path, heads []*mp.Path) {
+	if f := p.Style.Fill.CSS(); f != "" && f != "none" && p.Head.LType != mp.KnotEndpoint {
+		fill = p.Copy()
+		fill.Style.Stroke = mp.ColorCSS("none")
+		fill.Style.Dash = nil
+		fill.Style.Arrow = mp.ArrowStyle{}
+		fill.Envelope = nil
+	}
+
+	arrow := p.Style.Arrow
+	length, angle := arrow.Length, arrow.Angle
+	if length <= 0 {
+		length = mp.DefaultAHLength
+	}
+	if angle <= 0 {
+		angle = mp.DefaultAHAngle
+	}
+	body = p
+	if arrow.Start || arrow.End {
+		// Shorten the path by the depth of the heads, as the renderers do.
+		depth := mp.ArrowHeadDepth(arrow)
+		var s0, s1 float64
+		if arrow.Start {
+			s0 = depth
+		}
+		if arrow.End {
+			s1 = depth
+		}
+		if q := mp.ShortenPathForArrow(p, s0, s1); q != nil {
+			body = q
+		}
+	}
+	if body == p {
+		body = p.Copy()
+	}
+	body.Envelope = nil
+	body.Style.Arrow = mp.ArrowStyle{}
+	body.Style.Fill = mp.Color{}
+
+	for _, head := range []struct {
+		on   bool
+		path func(*mp.Path, mp.Number, mp.Number) *mp.Path
+	}{{arrow.End, mp.ArrowHeadEnd}, {arrow.Start, mp.ArrowHeadStart}} {
+		if !head.on {
+			continue
+		}
+		if h := head.path(p, length, angle); h != nil {
+			h.Style.Fill = p.Style.Stroke
+			h.Style.Stroke = mp.ColorCSS("none")
+			heads = append(heads, h)
+		}
+	}
+	return fill, body, heads
+}
+
+// dashPieces returns the dashes of body for the dash pattern dash, solid
+// paths with the style of body; body itself if the pattern is empty.
+func dashPieces(body *mp.Path, dash *mp.DashPattern) []*mp.Path {
+	if dash == nil {
+		return []*mp.Path{body}
+	}
+	return dash.Pieces(mp.NewArcLengthParam(body))
+}
+
+// simplifyPolyline returns path with the knots removed that the
+// Douglas-Peucker algorithm drops at tolerance, or path itself if it is
+// curved or nothing can be removed.
+func simplifyPolyline(path *mp.Path, tolerance float64) *mp.Path {
+	if path == nil || path.Head == nil || tolerance <= 0 {
+		return path
+	}
+	cycle := path.Head.LType != mp.KnotEndpoint
+	var pts []mp.Point
+	k := path.Head
+	for {
+		pts = append(pts, mp.P(k.XCoord, k.YCoord))
+		next := k.Next
+		if next == nil || (next == path.Head && !cycle) {
+			break
+		}
+		if !straightSegment(k, next) {
+			return path
+		}
+		if next == path.Head {
+			break
+		}
+		k = next
+	}
+	if cycle {
+		pts = append(pts, pts[0])
+	}
+	keep := make([]bool, len(pts))
+	keep[0], keep[len(pts)-1] = true, true
+	douglasPeucker(pts, 0, len(pts)-1, tolerance, keep)
+	var out []mp.Point
+	for i, pt := range pts {
+		if keep[i] {
+			out = append(out, pt)
+		}
+	}
+	if len(out) == len(pts) {
+		return path
+	}
+	if cycle {
+		if out = out[:len(out)-1]; len(out) < 3 {
+			return path
+		}
+	}
+	q := polyline(out, cycle)
+	q.Style = path.Style
+	return q
+}
+
+// straightSegment reports whether the control points of the segment from a
+// to b lie on the line between them.
+func straightSegment(a, b *mp.Knot) bool {
+	dx, dy := b.XCoord-a.XCoord, b.YCoord-a.YCoord
+	d := math.Hypot(dx, dy)
+	if d == 0 {
+		return a.RightX == a.XCoord && a.RightY == a.YCoord && b.LeftX == b.XCoord && b.LeftY == b.YCoord
+	}
+	off := func(x, y float64) float64 {
+		return math.Abs((x-a.XCoord)*dy-(y-a.YCoord)*dx) / d
+	}
+	const eps = 1e-9
+	return off(a.RightX, a.RightY) <= eps*math.Max(1, d) && off(b.LeftX, b.LeftY) <= eps*math.Max(1, d)
+}
+
+// douglasPeucker marks in keep the points between first and last that are
+// farther than tolerance from the simplified line.
+func douglasPeucker(pts []mp.Point, first, last int, tolerance float64, keep []bool) {
+	if last-first < 2 {
+		return
+	}
+	a, b := pts[first], pts[last]
+	dx, dy := b.X-a.X, b.Y-a.Y
+	d := math.Hypot(dx, dy)
+	worst, at := 0.0, -1
+	for i := first + 1; i < last; i++ {
+		var dist float64
+		if d == 0 {
+			dist = math.Hypot(pts[i].X-a.X, pts[i].Y-a.Y)
+		} else {
+			dist = math.Abs((pts[i].X-a.X)*dy-(pts[i].Y-a.Y)*dx) / d
+		}
+		if dist > worst {
+			worst, at = dist, i
+		}
+	}
+	if worst <= tolerance {
+		return
+	}
+	keep[at] = true
+	douglasPeucker(pts, first, at, tolerance, keep)
+	douglasPeucker(pts, at, last, tolerance, keep)
+}
+
+// polyline returns the path with straight segments through pts.
+func polyline(pts []mp.Point, cycle bool) *mp.Path {
+	p := mp.NewPath()
+	for _, pt := range pts {
+		p.Append(&mp.Knot{XCoord: pt.X, YCoord: pt.Y, LType: mp.KnotExplicit, RType: mp.KnotExplicit})
+	}
+	k := p.Head
+	for {
+		next := k.Next
+		if next == p.Head && !cycle {
+			break
+		}
+		k.RightX = k.XCoord + (next.XCoord-k.XCoord)/3
+		k.RightY = k.YCoord + (next.YCoord-k.YCoord)/3
+		next.LeftX = k.XCoord + 2*(next.XCoord-k.XCoord)/3
+		next.LeftY = k.YCoord + 2*(next.YCoord-k.YCoord)/3
+		k = next
+		if k == p.Head {
+			break
+		}
+	}
+	if !cycle {
+		first, last := p.Head, p.Head.Prev
+		first.LType = mp.KnotEndpoint
+		first.LeftX, first.LeftY = first.XCoord, first.YCoord
+		last.RType = mp.KnotEndpoint
+		last.RightX, last.RightY = last.XCoord, last.YCoord
+	}
+	return p
+}
+
+// duplicatePath reports whether a and b have the same knots and style.
+func duplicatePath(a, b *mp.Path) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil || a.Head == nil || b.Head == nil {
+		return false
+	}
+	ka, kb := a.Head, b.Head
+	for {
+		if ka.XCoord != kb.XCoord || ka.YCoord != kb.YCoord ||
+			ka.LeftX != kb.LeftX || ka.LeftY != kb.LeftY ||
+			ka.RightX != kb.RightX || ka.RightY != kb.RightY ||
+			ka.LType != kb.LType || ka.RType != kb.RType {
+			return false
+		}
+		ka, kb = ka.Next, kb.Next
+		endA := ka == nil || ka == a.Head
+		endB := kb == nil || kb == b.Head
+		if endA || endB {
+			if endA != endB {
+				return false
+			}
+			break
+		}
+	}
+	return sameStyle(a.Style, b.Style)
+}
+
+// sameStyle reports whether two styles are equal. Colors are compared by
+// their CSS value and opacity (unset opacities are NaN).
+func sameStyle(a, b mp.Style) bool {
+	sameColor := func(c, d mp.Color) bool {
+		co, cok := c.Opacity()
+		do, dok := d.Opacity()
+		return c.CSS() == d.CSS() && cok == dok && (!cok || co == do)
+	}
+	if !sameColor(a.Stroke, b.Stroke) || !sameColor(a.Fill, b.Fill) {
+		return false
+	}
+	a.Stroke, a.Fill, b.Stroke, b.Fill = mp.Color{}, mp.Color{}, mp.Color{}, mp.Color{}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,171 @@
+package draw
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func TestPipelineRunsStagesOnClone(t *testing.T) {
+	pic := NewPicture()
+	pic.DrawLine(mp.P(0, 0), mp.P(10, 0), mp.Style{})
+	var seen []int
+	count := func(pic *Picture) (*Picture, error) {
+		seen = append(seen, len(pic.Paths()))
+		pic.DrawLine(mp.P(0, 5), mp.P(10, 5), mp.Style{})
+		return pic, nil
+	}
+	out, err := NewPipeline(StageFunc(count)).Add(StageFunc(count)).Run(pic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 2 || seen[0] != 1 || seen[1] != 2 {
+		t.Errorf("stages saw %v paths, want [1 2]", seen)
+	}
+	if len(out.Paths()) != 3 || len(pic.Paths()) != 1 {
+		t.Errorf("got %d paths, input has %d; want 3 and 1", len(out.Paths()), len(pic.Paths()))
+	}
+
+	boom := errors.New("boom")
+	_, err = NewPipeline(StageFunc(func(*Picture) (*Picture, error) { return nil, boom })).Run(pic)
+	if !errors.Is(err, boom) {
+		t.Errorf("err = %v, want boom", err)
+	}
+}
+
+func TestSimplifyStage(t *testing.T) {
+	pb := NewPath().MoveTo(P(0, 0))
+	for i := 1; i <= 10; i++ {
+		pb.LineTo(P(float64(i), 0.01*float64(i%2)))
+	}
+	line, err := pb.LineTo(P(10, 10)).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	curve, err := NewPath().MoveTo(P(0, 0)).CurveTo(P(5, 0.001)).CurveTo(P(10, 0)).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic := NewPicture().AddPath(line).AddPath(curve)
+	out, err := NewPipeline(SimplifyStage(0.1)).Run(pic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.Paths()[0]
+	if n := got.PathLength(); n != 2 {
+		t.Fatalf("simplified polyline has %d segments, want 2", n)
+	}
+	if x, y := got.PointOf(1); x != 10 || math.Abs(y) > 0.01 {
+		t.Errorf("corner at (%g,%g), want (10,0)", x, y)
+	}
+	if x, y := got.PointOf(2); x != 10 || y != 10 {
+		t.Errorf("end at (%g,%g), want (10,10)", x, y)
+	}
+	if out.Paths()[1].PathLength() != 2 {
+		t.Errorf("curved path was simplified")
+	}
+}
+
+func TestDedupeStage(t *testing.T) {
+	red := mp.Style{Stroke: mp.ColorCSS("red")}
+	pic := NewPicture()
+	pic.DrawLine(mp.P(0, 0), mp.P(10, 0), red)
+	pic.DrawLine(mp.P(0, 0), mp.P(10, 0), red)
+	pic.DrawLine(mp.P(0, 0), mp.P(10, 0), mp.Style{Stroke: mp.ColorCSS("blue")})
+	pic.DrawLine(mp.P(10, 0), mp.P(0, 0), red)
+	out, err := NewPipeline(DedupeStage()).Run(pic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(out.Paths()); n != 3 {
+		t.Errorf("got %d paths after dedupe, want 3", n)
+	}
+}
+
+func TestCullStage(t *testing.T) {
+	pic := NewPicture()
+	pic.DrawLine(mp.P(0, 0), mp.P(10, 0), mp.Style{})
+	pic.DrawLine(mp.P(-50, 50), mp.P(150, 50), mp.Style{})
+	pic.DrawLine(mp.P(200, 0), mp.P(210, 0), mp.Style{})
+	pic.Label("in", mp.P(50, 50), mp.AnchorCenter)
+	pic.Label("out", mp.P(50, 500), mp.AnchorCenter)
+	out, err := NewPipeline(CullStage(0, 0, 100, 100)).Run(pic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(out.Paths()); n != 2 {
+		t.Errorf("got %d paths, want 2", n)
+	}
+	if len(out.Labels()) != 1 || out.Labels()[0].Text != "in" {
+		t.Errorf("got labels %v, want only \"in\"", out.Labels())
+	}
+}
+
+func TestSnapStage(t *testing.T) {
+	path, err := NewPath().MoveTo(P(0.4, 0.3)).CurveTo(P(9.8, 5.2)).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rx, ry := path.Head.RightX, path.Head.RightY
+	pic := NewPicture().AddPath(path)
+	pic.Label("a", mp.P(2.6, 3.4), mp.AnchorCenter)
+	out, err := NewPipeline(SnapStage(1)).Run(pic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.Paths()[0]
+	if x, y := got.PointOf(0); x != 0 || y != 0 {
+		t.Errorf("start at (%g,%g), want (0,0)", x, y)
+	}
+	if x, y := got.PointOf(1); x != 10 || y != 5 {
+		t.Errorf("end at (%g,%g), want (10,5)", x, y)
+	}
+	if dx, dy := got.Head.RightX-rx, got.Head.RightY-ry; math.Abs(dx+0.4) > 1e-9 || math.Abs(dy+0.3) > 1e-9 {
+		t.Errorf("control point moved by (%g,%g), want (-0.4,-0.3)", dx, dy)
+	}
+	if p := out.Labels()[0].Position; p.X != 3 || p.Y != 3 {
+		t.Errorf("label at %v, want (3,3)", p)
+	}
+}
+
+func TestDashExpandStage(t *testing.T) {
+	pic := NewPicture()
+	pic.DrawLine(mp.P(0, 0), mp.P(20, 0), mp.Style{Dash: mp.NewDashPattern(3, 2)})
+	pic.DrawLine(mp.P(0, 10), mp.P(20, 10), mp.Style{})
+	out, err := NewPipeline(DashExpandStage()).Run(pic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := out.Paths()
+	// 20 units of "on 3 off 2": dashes at 0, 5, 10 and 15.
+	if len(paths) != 5 {
+		t.Fatalf("got %d paths, want 4 dashes and the solid line", len(paths))
+	}
+	for i, d := range paths[:4] {
+		x0, _ := d.PointOf(0)
+		x1, _ := d.PointOf(mp.Number(d.PathLength()))
+		if math.Abs(x0-float64(5*i)) > 1e-6 || math.Abs(x1-x0-3) > 1e-6 {
+			t.Errorf("dash %d runs from %g to %g", i, x0, x1)
+		}
+		if d.Style.Dash != nil {
+			t.Errorf("dash %d is still dashed", i)
+		}
+	}
+
+	arrow := NewPicture()
+	arrow.DrawLine(mp.P(0, 0), mp.P(20, 0), mp.Style{Dash: mp.NewDashPattern(3, 2).Shifted(1), Arrow: mp.ArrowStyle{End: true}})
+	out, err = NewPipeline(DashExpandStage()).Run(arrow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths = out.Paths()
+	head := paths[len(paths)-1]
+	if head.Style.Fill.CSS() == "" || head.Style.Stroke.CSS() != "none" {
+		t.Errorf("arrowhead style = %+v, want filled", head.Style)
+	}
+	if x, _ := paths[0].PointOf(mp.Number(paths[0].PathLength())); math.Abs(x-2) > 1e-6 {
+		t.Errorf("first dash ends at %g, want 2 with offset 1", x)
+	}
+}
@@ -0,0 +1,222 @@
+package draw
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// VectorField returns the field vector (u, v) at (x, y).
+type VectorField func(x, y float64) (u, v float64)
+
+// Streamlines describes a streamline plot of a vector field. Each line starts
+// at a seed point and is integrated with fourth-order Runge-Kutta steps of
+// constant arc length, so lines are evenly sampled regardless of the field
+// magnitude. The sampled polylines are smoothed into Hobby curves and
+// decorated with flow arrows (see Picture.FlowArrows).
+//
+// Magnitude can be encoded per line: with MinWidth < MaxWidth the stroke
+// width grows with the line's mean magnitude, and with ColorMap set the
+// stroke color is ColorMap(m) for the mean magnitude normalized to [0,1]
+// over all lines.
+type Streamlines struct {
+	Field VectorField
+	Seeds []mp.Point
+	// Bounds limits the integration to the rectangle (MinX, MinY)-(MaxX,
+	// MaxY). An empty rectangle disables the check.
+	MinX, MinY, MaxX, MaxY float64
+	Step                   float64 // integration step length (default 1)
+	MaxSteps               int     // steps per direction (default 500)
+	Backward               bool    // also integrate backwards from the seed
+	Sample                 int     // every Sample-th integration point becomes a knot (default 5)
+	Arrows                 int     // arrowheads per line (default 1)
+	Style                  mp.Style
+	MinWidth, MaxWidth     float64
+	ColorMap               func(m float64) mp.Color
+}
+
+// NewStreamlines returns a streamline plot of field starting at seeds with
+// default settings.
+//
+// Example:
+//
+//	vortex := func(x, y float64) (float64, float64) { return -y, x }
+//	s := draw.NewStreamlines(vortex, draw.GridSeeds(-50, -50, 50, 50, 5, 5)...)
+//	s.MinX, s.MinY, s.MaxX, s.MaxY = -60, -60, 60, 60
+//	pic := s.Picture()
+func NewStreamlines(field VectorField, seeds ...mp.Point) *Streamlines {
+	return &Streamlines{
+		Field:    field,
+		Seeds:    seeds,
+		Step:     1,
+		MaxSteps: 500,
+		Sample:   5,
+		Arrows:   1,
+		Style:    mp.Style{Stroke: mp.ColorCSS("black"), StrokeWidth: 0.5},
+	}
+}
+
+// GridSeeds returns nx × ny seed points at the centers of a regular grid of
+// cells covering the rectangle (minX, minY)-(maxX, maxY).
+func GridSeeds(minX, minY, maxX, maxY float64, nx, ny int) []mp.Point {
+	var seeds []mp.Point
+	for j := 0; j < ny; j++ {
+		for i := 0; i < nx; i++ {
+			x := minX + (float64(i)+0.5)*(maxX-minX)/float64(nx)
+			y := minY + (float64(j)+0.5)*(maxY-minY)/float64(ny)
+			seeds = append(seeds, mp.P(x, y))
+		}
+	}
+	return seeds
+}
+
+// streamline is one integrated line and its mean field magnitude.
+type streamline struct {
+	pts       []mp.Point
+	magnitude float64
+}
+
+// Picture integrates all streamlines and returns them as a picture. Seeds
+// where the field vanishes, that lie outside the bounds or that are not
+// finite yield no line.
+func (s *Streamlines) Picture() *Picture {
+	pic := NewPicture()
+	if s.Field == nil {
+		return pic
+	}
+	var lines []streamline
+	minM, maxM := math.Inf(1), math.Inf(-1)
+	for _, seed := range s.Seeds {
+		line, ok := s.integrate(seed)
+		if !ok {
+			continue
+		}
+		lines = append(lines, line)
+		minM = math.Min(minM, line.magnitude)
+		maxM = math.Max(maxM, line.magnitude)
+	}
+	arrows := s.Arrows
+	if arrows <= 0 {
+		arrows = 1
+	}
+	for _, line := range lines {
+		path := s.smooth(line.pts)
+		if path == nil {
+			continue
+		}
+		path.Style = s.Style
+		m := 0.0
+		if maxM > minM {
+			m = (line.magnitude - minM) / (maxM - minM)
+		}
+		if s.MinWidth < s.MaxWidth {
+			path.Style.StrokeWidth = s.MinWidth + m*(s.MaxWidth-s.MinWidth)
+		}
+		if s.ColorMap != nil {
+			path.Style.Stroke = s.ColorMap(m)
+		}
+		pic.AddPath(path).FlowArrows(path, arrows)
+	}
+	return pic
+}
+
+// integrate follows the field from seed and returns the sampled points,
+// backward part first.
+func (s *Streamlines) integrate(seed mp.Point) (streamline, bool) {
+	if !s.inside(seed) {
+		return streamline{}, false
+	}
+	forward, sumF := s.trace(seed, 1)
+	var pts []mp.Point
+	sum := sumF
+	if s.Backward {
+		backward, sumB := s.trace(seed, -1)
+		for i := len(backward) - 1; i > 0; i-- {
+			pts = append(pts, backward[i])
+		}
+		sum += sumB
+	}
+	pts = append(pts, forward...)
+	if len(pts) < 2 {
+		return streamline{}, false
+	}
+	return streamline{pts: pts, magnitude: sum / float64(len(pts))}, true
+}
+
+// trace integrates from p in direction sign (1 forward, -1 backward) and
+// returns the points including p and the sum of the magnitudes there.
+func (s *Streamlines) trace(p mp.Point, sign float64) ([]mp.Point, float64) {
+	h := s.Step
+	if h <= 0 {
+		h = 1
+	}
+	h *= sign
+	maxSteps := s.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = 500
+	}
+	// The normalized field, so that each step covers the same arc length.
+	dir := func(x, y float64) (float64, float64, bool) {
+		u, v := s.Field(x, y)
+		m := math.Hypot(u, v)
+		if m < 1e-12 || math.IsNaN(m) || math.IsInf(m, 0) {
+			return 0, 0, false
+		}
+		return u / m, v / m, true
+	}
+	pts := []mp.Point{p}
+	u, v := s.Field(p.X, p.Y)
+	sum := math.Hypot(u, v)
+	for i := 0; i < maxSteps; i++ {
+		k1x, k1y, ok1 := dir(p.X, p.Y)
+		k2x, k2y, ok2 := dir(p.X+h/2*k1x, p.Y+h/2*k1y)
+		k3x, k3y, ok3 := dir(p.X+h/2*k2x, p.Y+h/2*k2y)
+		k4x, k4y, ok4 := dir(p.X+h*k3x, p.Y+h*k3y)
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			break
+		}
+		next := mp.P(p.X+h/6*(k1x+2*k2x+2*k3x+k4x), p.Y+h/6*(k1y+2*k2y+2*k3y+k4y))
+		if !s.inside(next) {
+			break
+		}
+		p = next
+		pts = append(pts, p)
+		u, v = s.Field(p.X, p.Y)
+		sum += math.Hypot(u, v)
+	}
+	return pts, sum
+}
+
+func (s *Streamlines) inside(p mp.Point) bool {
+	if s.MaxX <= s.MinX || s.MaxY <= s.MinY {
+		return true
+	}
+	return p.X >= s.MinX && p.X <= s.MaxX && p.Y >= s.MinY && p.Y <= s.MaxY
+}
+
+// smooth returns the Hobby curve through every Sample-th point of pts and
+// the last one, falling back to the polyline if the curve cannot be solved.
+// It returns nil if a point is not finite.
+func (s *Streamlines) smooth(pts []mp.Point) *mp.Path {
+	step := s.Sample
+	if step <= 0 {
+		step = 5
+	}
+	knots := []mp.Point{pts[0]}
+	for i := step; i < len(pts)-1; i += step {
+		knots = append(knots, pts[i])
+	}
+	knots = append(knots, pts[len(pts)-1])
+	pb := NewPath().MoveTo(knots[0])
+	for _, pt := range knots[1:] {
+		pb.CurveTo(pt)
+	}
+	if path, err := pb.Solve(); err == nil {
+		return path
+	}
+	path, err := mp.StraightPath(knots, false)
+	if err != nil {
+		return nil
+	}
+	return path
+}
@@ -0,0 +1,58 @@
+package draw
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func TestStreamlinesVortex(t *testing.T) {
+	vortex := func(x, y float64) (float64, float64) { return -y, x }
+	s := NewStreamlines(vortex, mp.P(10, 0), mp.P(20, 0))
+	s.MaxSteps = 40
+	s.MinWidth, s.MaxWidth = 0.5, 2
+	pic := s.Picture()
+	// Two lines, each with one arrowhead.
+	if got := len(pic.Paths()); got != 4 {
+		t.Fatalf("expected 4 paths, got %d", got)
+	}
+	for i, r := range []float64{10, 20} {
+		line := pic.Paths()[2*i]
+		n := line.PathLength()
+		for k := 0; k <= 20; k++ {
+			x, y := line.PointOf(mp.Number(n) * mp.Number(k) / 20)
+			if d := math.Hypot(x, y); math.Abs(d-r) > 0.05 {
+				t.Fatalf("line %d: point at radius %g, want %g", i, d, r)
+			}
+		}
+	}
+	if w0, w1 := pic.Paths()[0].Style.StrokeWidth, pic.Paths()[2].Style.StrokeWidth; w0 != 0.5 || w1 != 2 {
+		t.Errorf("stroke widths = %g, %g; want 0.5, 2", w0, w1)
+	}
+}
+
+func TestStreamlinesBounds(t *testing.T) {
+	uniform := func(x, y float64) (float64, float64) { return 3, 0 }
+	s := NewStreamlines(uniform, mp.P(0, 0), mp.P(200, 0))
+	s.MinX, s.MinY, s.MaxX, s.MaxY = -10, -10, 50, 10
+	s.Backward = true
+	pic := s.Picture()
+	if got := len(pic.Paths()); got != 2 {
+		t.Fatalf("expected one line with its arrow, got %d paths", got)
+	}
+	minX, _, maxX, _ := mp.PathBBox(pic.Paths()[0])
+	if minX < -10 || minX > -9 || maxX > 50 || maxX < 49 {
+		t.Errorf("line spans %g..%g, want about -10..50", minX, maxX)
+	}
+}
+
+func TestStreamlinesNotFinite(t *testing.T) {
+	uniform := func(x, y float64) (float64, float64) { return 1, 0 }
+	s := NewStreamlines(uniform, mp.P(0, 0))
+	s.Step = math.Inf(1)
+	s.MaxSteps = 3
+	if got := len(s.Picture().Paths()); got != 0 {
+		t.Errorf("expected no line through infinite points, got %d paths", got)
+	}
+}
@@ -0,0 +1,155 @@
+package draw
+
+import "github.com/boxesandglue/mpgo/mp"
+
+// defaultStrokeWidth is the width of strokes without StrokeWidth or pen,
+// MetaPost's pencircle scaled 0.5bp as in the renderers.
+const defaultStrokeWidth = 0.5
+
+// StrokesToFills replaces every stroke of the picture by the filled
+// outline of the area it paints, so the picture renders the same in
+// viewers and print workflows with poor stroke support, and strokes can
+// be combined with fills in boolean operations. Everything the renderers
+// expand is expanded the same way first: closing styles, line styles,
+// stroke gradients, dashes and arrowheads. Round pens and plain stroke
+// widths become outlines from mp.StrokeOutline with the path's caps and
+// joins; paths drawn with polygonal pens are replaced by their envelope.
+// The fill of a filled cycle, or of an open path with
+// Style.AutoCloseFill, is kept as a fill-only copy below its outline.
+// Elliptical pens that are not circles are treated as circles of their
+// scale.
+//
+// Hairlines, which are one device pixel wide at any scale, stay strokes.
+// Parts of a MultiPath are converted together, so the fill of a glyph with
+// holes and the outline of all its strokes stay single objects. Labels are
+// not changed; see ConvertLabelsToPathsWithFont. It returns p.
+//
+// Example:
+//
+//	pic.StrokesToFills()
+//	svg.NewBuilder().AddPicture(pic).WriteTo(w)  // no stroke attributes left
+func (p *Picture) StrokesToFills() *Picture {
+	p.mapPaths(strokeToFills)
+	p.mapMultiPaths(multiStrokeToFills)
+	return p
+}
+
+// strokeToFills returns the filled paths that paint what drawing path
+// paints.
+func strokeToFills(path *mp.Path) []*mp.Path {
+	if path == nil || path.Head == nil || path.Style.Hairline {
+		return []*mp.Path{path}
+	}
+	style := path.Style
+	if style.Stroke.CSS() == "none" {
+		return []*mp.Path{path}
+	}
+	if style.Stroke.CSS() == "" {
+		style.Stroke = mp.ColorCSS("black") // the renderers' default
+	}
+	expand := func(parts []*mp.Path) []*mp.Path {
+		var out []*mp.Path
+		for _, q := range parts {
+			out = append(out, strokeToFills(q)...)
+		}
+		return out
+	}
+	switch {
+	case path.Head.LType == mp.KnotEndpoint && style.Fill.CSS() != "" && style.Fill.CSS() != "none":
+		parts, err := mp.ExpandOpenFill(path)
+		if err != nil {
+			return []*mp.Path{path} // left for the renderer to refuse
+		}
+		return expand(parts)
+	case style.Closing != nil && path.Head.LType != mp.KnotEndpoint:
+		return expand(mp.ExpandClosingStyle(path))
+	case style.LineStyle != mp.LineStyleSolid:
+		return expand(mp.ExpandLineStyle(path))
+	case style.Gradient != nil && path.Envelope == nil:
+		return expand(mp.ExpandStrokeGradient(path))
+	}
+
+	var out []*mp.Path
+	if path.Envelope != nil {
+		if f := style.Fill.CSS(); f != "" && f != "none" && path.Head.LType != mp.KnotEndpoint {
+			fill := path.Copy()
+			fill.Style.Stroke = mp.ColorCSS("none")
+			fill.Style.Arrow = mp.ArrowStyle{}
+			fill.Envelope = nil
+			out = append(out, fill)
+		}
+		env := path.Envelope.Copy()
+		env.Style = filledStyle(style, style.Stroke)
+		out = append(out, env)
+		for _, head := range mp.EnvelopeArrowHeads(path) {
+			head.Style = filledStyle(style, style.Stroke)
+			out = append(out, head)
+		}
+		return out
+	}
+
+	width := style.StrokeWidth
+	if pen := style.Pen; pen != nil && pen.Elliptical {
+		if scale := mp.GetPenScale(pen); scale > 0 {
+			width = scale
+		}
+	}
+	if width <= 0 {
+		width = defaultStrokeWidth
+	}
+	fill, body, heads := splitStroke(path)
+	if fill != nil {
+		out = append(out, fill)
+	}
+	for _, piece := range dashPieces(body, style.Dash) {
+		if outline := mp.StrokeOutline(piece, width); outline != nil {
+			outline.Style = filledStyle(style, style.Stroke)
+			out = append(out, outline)
+		}
+	}
+	for _, head := range heads {
+		head.Style = filledStyle(style, style.Stroke)
+		out = append(out, head)
+	}
+	return out
+}
+
+// filledStyle returns the style of an outline filled with color that
+// replaces a stroke drawn with style: the metadata stays, everything about
+// the stroke goes.
+func filledStyle(style mp.Style, color mp.Color) mp.Style {
+	return mp.Style{Fill: color, Stroke: mp.ColorCSS("none"), Meta: style.Meta}
+}
+
+// multiStrokeToFills converts the parts of m together: a fill-only copy of
+// m if it is filled, then the converted strokes of all parts, with
+// consecutive outlines of the same color merged into one MultiPath.
+func multiStrokeToFills(m *mp.MultiPath) []*mp.MultiPath {
+	if m == nil || m.Style.Hairline || m.Style.Stroke.CSS() == "none" {
+		return []*mp.MultiPath{m}
+	}
+	var out []*mp.MultiPath
+	if f := m.Style.Fill.CSS(); f != "" && f != "none" {
+		fill := m.Copy()
+		fill.Style.Stroke = mp.ColorCSS("none")
+		fill.Style.Dash = nil
+		fill.Style.Arrow = mp.ArrowStyle{}
+		out = append(out, fill)
+	}
+	filled := len(out) // the fill copy takes no outlines
+	style := m.Style
+	style.Fill = mp.Color{}
+	for _, part := range m.Parts {
+		q := part.Copy()
+		q.Style = style
+		for _, f := range strokeToFills(q) {
+			if n := len(out); n > filled && sameStyle(out[n-1].Style, f.Style) {
+				out[n-1].Append(f)
+				continue
+			}
+			out = append(out, mp.NewMultiPath(f))
+			out[len(out)-1].Style = f.Style
+		}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package draw
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+	"github.com/boxesandglue/mpgo/svg"
+)
+
+func TestStrokesToFills(t *testing.T) {
+	pic := NewPicture()
+	pic.DrawLine(mp.P(0, 0), mp.P(20, 0), mp.Style{StrokeWidth: 2, LineCap: mp.LineCapButt})
+	pic.DrawLine(mp.P(0, 10), mp.P(20, 10), mp.Style{Dash: mp.NewDashPattern(3, 2), Arrow: mp.ArrowStyle{End: true}})
+	sq := mp.Scaled(10).ApplyToPath(mp.UnitSquare())
+	sq.Style = mp.NewStyle(mp.WithFill(mp.ColorCSS("yellow")), mp.WithStroke(mp.ColorCSS("red")))
+	pic.AddPath(sq)
+	pic.DrawLine(mp.P(0, 30), mp.P(20, 30), mp.Style{Hairline: true})
+
+	pic.StrokesToFills()
+	paths := pic.Paths()
+	for i, p := range paths[:len(paths)-1] {
+		if p.Style.Stroke.CSS() != "none" {
+			t.Errorf("path %d still stroked: %+v", i, p.Style)
+		}
+	}
+	if !paths[len(paths)-1].Style.Hairline {
+		t.Errorf("hairline was converted")
+	}
+
+	line := paths[0]
+	if minX, minY, maxX, maxY := mp.PathBBox(line); minX != 0 || minY != -1 || maxX != 20 || maxY != 1 {
+		t.Errorf("line outline bbox (%g,%g)-(%g,%g), want (0,-1)-(20,1)", minX, minY, maxX, maxY)
+	}
+	if line.Style.Fill.CSS() != "black" {
+		t.Errorf("line outline filled with %q, want black", line.Style.Fill.CSS())
+	}
+
+	// Dashes: the body is shortened for the head, so at least three dash
+	// outlines 0.5 units high and the taller arrowhead remain.
+	var dashes, heads int
+	for _, p := range paths[1:] {
+		_, minY, _, maxY := mp.PathBBox(p)
+		switch {
+		case minY < 5 || maxY > 15:
+		case maxY-minY > 1:
+			heads++
+		default:
+			dashes++
+		}
+	}
+	if heads != 1 || dashes < 3 {
+		t.Errorf("dashed arrow: %d dashes, %d heads", dashes, heads)
+	}
+
+	// The filled square keeps its fill below the outline.
+	var fill, ring *mp.Path
+	for _, p := range paths {
+		switch p.Style.Fill.CSS() {
+		case "yellow":
+			fill = p
+		case "red":
+			ring = p
+		}
+	}
+	if fill == nil || ring == nil {
+		t.Fatalf("square: fill %v, outline %v", fill, ring)
+	}
+	if minX, _, maxX, _ := mp.PathBBox(ring); math.Abs(minX+0.25) > 1e-9 || math.Abs(maxX-10.25) > 1e-9 {
+		t.Errorf("square outline from %g to %g, want the default 0.5 width", minX, maxX)
+	}
+
+	var b strings.Builder
+	if err := svg.NewBuilder().AddPicture(pic).WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), "stroke-width"); n != 1 {
+		t.Errorf("svg has %d stroke-width attributes, want only the hairline's", n)
+	}
+}
+
+func TestStrokesToFillsMultiPath(t *testing.T) {
+	outer := mp.Scaled(20).ApplyToPath(mp.UnitSquare())
+	inner := mp.Shifted(5, 5).ApplyToPath(mp.Scaled(10).ApplyToPath(mp.UnitSquare())).Reversed()
+	m := mp.NewMultiPath(outer, inner)
+	m.Style = mp.NewStyle(mp.WithFill(mp.ColorCSS("gray")), mp.WithStroke(mp.ColorCSS("blue")), mp.WithStrokeWidth(1))
+	pic := NewPicture().AddMultiPath(m)
+	pic.StrokesToFills()
+	mps := pic.MultiPaths()
+	if len(mps) != 2 {
+		t.Fatalf("got %d multipaths, want the fill and one outline", len(mps))
+	}
+	if mps[0].Style.Fill.CSS() != "gray" || len(mps[0].Parts) != 2 || mps[0].Style.Stroke.CSS() != "none" {
+		t.Errorf("fill copy = %+v", mps[0])
+	}
+	if mps[1].Style.Fill.CSS() != "blue" || len(mps[1].Parts) != 2 {
+		t.Errorf("outline = %d parts filled %q", len(mps[1].Parts), mps[1].Style.Fill.CSS())
+	}
+}
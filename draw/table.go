@@ -0,0 +1,175 @@
+package draw
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Table lays out pictures in a grid, e.g. for truth tables, matrices and
+// legend grids. Every column is as wide as its widest cell and every row
+// as high as its highest cell (by Picture.BBox); cells are aligned within
+// their column and row and separated by the gaps. The table's top left
+// corner is at the origin, rows run downwards.
+type Table struct {
+	Cells  [][]*Picture // rows from top to bottom; nil cells are empty
+	ColGap float64      // horizontal space between columns
+	RowGap float64      // vertical space between rows
+
+	// ColAlign and RowAlign place each cell within its column and row as a
+	// fraction of the free space, like the factors of mp.LabelAnchorFactors:
+	// 0 is left (bottom), 0.5 centered, 1 right (top). Columns and rows
+	// without an entry are centered.
+	ColAlign []float64
+	RowAlign []float64
+
+	// Rules draws lines between the columns and rows, in the middle of the
+	// gaps, and around the table if Frame is set; nil draws none.
+	Rules *mp.Style
+	Frame bool
+}
+
+// TableLayout is the computed geometry of a Table.
+type TableLayout struct {
+	Widths  []float64 // column widths
+	Heights []float64 // row heights
+	Left    []float64 // x coordinate of the left edge of each column
+	Top     []float64 // y coordinate of the top edge of each row
+}
+
+// NewTextTable returns a table whose cells are the given texts, each a
+// centered label.
+//
+// Example:
+//
+//	t := draw.NewTextTable([][]string{
+//		{"A", "B", "A∧B"},
+//		{"0", "0", "0"},
+//		{"0", "1", "0"},
+//	})
+//	t.ColGap, t.RowGap = 10, 4
+//	pic, layout := t.Picture()
+func NewTextTable(rows [][]string) *Table {
+	t := &Table{Cells: make([][]*Picture, len(rows))}
+	for i, row := range rows {
+		t.Cells[i] = make([]*Picture, len(row))
+		for j, text := range row {
+			if text != "" {
+				t.Cells[i][j] = NewPicture().Label(text, mp.P(0, 0), mp.AnchorCenter)
+			}
+		}
+	}
+	return t
+}
+
+// Layout computes the column widths, row heights and cell positions.
+func (t *Table) Layout() *TableLayout {
+	cols := 0
+	for _, row := range t.Cells {
+		cols = max(cols, len(row))
+	}
+	l := &TableLayout{
+		Widths:  make([]float64, cols),
+		Heights: make([]float64, len(t.Cells)),
+		Left:    make([]float64, cols),
+		Top:     make([]float64, len(t.Cells)),
+	}
+	for i, row := range t.Cells {
+		for j, cell := range row {
+			if cell == nil {
+				continue
+			}
+			minX, minY, maxX, maxY, ok := cell.BBox()
+			if !ok {
+				continue
+			}
+			l.Widths[j] = math.Max(l.Widths[j], maxX-minX)
+			l.Heights[i] = math.Max(l.Heights[i], maxY-minY)
+		}
+	}
+	x := 0.0
+	for j, w := range l.Widths {
+		l.Left[j] = x
+		x += w + t.ColGap
+	}
+	y := 0.0
+	for i, h := range l.Heights {
+		l.Top[i] = y
+		y -= h + t.RowGap
+	}
+	return l
+}
+
+// Cell returns the rectangle of a cell.
+func (l *TableLayout) Cell(row, col int) (minX, minY, maxX, maxY float64) {
+	return l.Left[col], l.Top[row] - l.Heights[row], l.Left[col] + l.Widths[col], l.Top[row]
+}
+
+// CellPoint returns the point at the fractions (xf, yf) of a cell's
+// rectangle: (0.5, 0.5) is its center, (0, 1) its top left corner. Use it
+// to attach arrows or annotations to cells.
+func (l *TableLayout) CellPoint(row, col int, xf, yf float64) mp.Point {
+	minX, minY, maxX, maxY := l.Cell(row, col)
+	return mp.P(minX+xf*(maxX-minX), minY+yf*(maxY-minY))
+}
+
+// Size returns the width and height of the table, without the frame.
+func (l *TableLayout) Size() (width, height float64) {
+	if n := len(l.Widths); n > 0 {
+		width = l.Left[n-1] + l.Widths[n-1]
+	}
+	if n := len(l.Heights); n > 0 {
+		height = l.Heights[n-1] - l.Top[n-1]
+	}
+	return width, height
+}
+
+// Picture lays out the table and returns the composed picture, with copies
+// of the cells moved into place, and the layout.
+func (t *Table) Picture() (*Picture, *TableLayout) {
+	l := t.Layout()
+	pic := NewPicture()
+	for i, row := range t.Cells {
+		for j, cell := range row {
+			if cell == nil {
+				continue
+			}
+			minX, minY, maxX, maxY, ok := cell.BBox()
+			if !ok {
+				continue
+			}
+			xf, yf := alignAt(t.ColAlign, j), alignAt(t.RowAlign, i)
+			p := l.CellPoint(i, j, xf, yf)
+			pic.addShifted(cell, p.X-(minX+xf*(maxX-minX)), p.Y-(minY+yf*(maxY-minY)))
+		}
+	}
+	if t.Rules != nil {
+		t.addRules(pic, l)
+	}
+	return pic, l
+}
+
+// alignAt returns the alignment fraction at index i, 0.5 if there is none.
+func alignAt(align []float64, i int) float64 {
+	if i < len(align) {
+		return align[i]
+	}
+	return 0.5
+}
+
+// addRules draws the lines between the cells and the frame.
+func (t *Table) addRules(pic *Picture, l *TableLayout) {
+	width, height := l.Size()
+	style := *t.Rules
+	for j := 1; j < len(l.Widths); j++ {
+		x := l.Left[j] - t.ColGap/2
+		pic.DrawLine(mp.P(x, 0), mp.P(x, -height), style)
+	}
+	for i := 1; i < len(l.Heights); i++ {
+		y := l.Top[i] + t.RowGap/2
+		pic.DrawLine(mp.P(0, y), mp.P(width, y), style)
+	}
+	if t.Frame {
+		pic.DrawRect(0, -height, width, height, style)
+	}
+}
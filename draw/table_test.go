@@ -0,0 +1,63 @@
+package draw
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// filledBox returns a picture of a w × h filled rectangle with its lower
+// left corner at (x, y).
+func filledBox(x, y, w, h float64) *Picture {
+	rect := mp.XScaled(w).Then(mp.YScaled(h)).Then(mp.Shifted(x, y)).ApplyToPath(mp.UnitSquare())
+	rect.Style = mp.Style{Fill: mp.ColorCSS("gray"), Stroke: mp.ColorCSS("none")}
+	return NewPicture().AddPath(rect)
+}
+
+func TestTableLayout(t *testing.T) {
+	tab := &Table{
+		Cells: [][]*Picture{
+			{filledBox(5, 5, 10, 4), filledBox(-3, 0, 2, 2)},
+			{nil, filledBox(0, 0, 6, 8)},
+		},
+		ColGap:   2,
+		RowGap:   1,
+		ColAlign: []float64{0},
+	}
+	pic, l := tab.Picture()
+	if l.Widths[0] != 10 || l.Widths[1] != 6 || l.Heights[0] != 4 || l.Heights[1] != 8 {
+		t.Fatalf("sizes %v × %v, want [10 6] × [4 8]", l.Widths, l.Heights)
+	}
+	if w, h := l.Size(); w != 18 || h != 13 {
+		t.Errorf("table is %g × %g, want 18 × 13", w, h)
+	}
+	if p := l.CellPoint(1, 1, 0.5, 0.5); p.X != 15 || p.Y != -9 {
+		t.Errorf("center of cell (1,1) = %v, want (15,-9)", p)
+	}
+	// The small cell is centered in its column and row.
+	minX, minY, maxX, maxY := mp.PathBBox(pic.Paths()[1])
+	if math.Abs(minX-14) > 1e-9 || math.Abs(maxX-16) > 1e-9 || math.Abs(minY+3) > 1e-9 || math.Abs(maxY+1) > 1e-9 {
+		t.Errorf("cell (0,1) at (%g,%g)-(%g,%g), want (14,-3)-(16,-1)", minX, minY, maxX, maxY)
+	}
+	if x0, y0, x1, y1, _ := pic.BBox(); x0 != 0 || y0 != -13 || x1 != 18 || y1 != 0 {
+		t.Errorf("picture bbox (%g,%g)-(%g,%g), want (0,-13)-(18,0)", x0, y0, x1, y1)
+	}
+}
+
+func TestTextTableRules(t *testing.T) {
+	tab := NewTextTable([][]string{{"A", "B"}, {"0", "1"}, {"1", ""}})
+	tab.Rules = &mp.Style{}
+	tab.Frame = true
+	pic, l := tab.Picture()
+	if n := len(pic.Labels()); n != 5 {
+		t.Errorf("got %d labels, want 5", n)
+	}
+	// One column rule, two row rules and the frame.
+	if n := len(pic.Paths()); n != 4 {
+		t.Errorf("got %d rules, want 4", n)
+	}
+	if lbl := pic.Labels()[0]; lbl.Position != l.CellPoint(0, 0, 0.5, 0.5) {
+		t.Errorf("label A at %v, want the cell center %v", lbl.Position, l.CellPoint(0, 0, 0.5, 0.5))
+	}
+}
@@ -0,0 +1,191 @@
+package draw
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Template renders many instances of a parameterized scene, e.g. one
+// figure per row of a report. It consists of a base picture that is the
+// same for all instances and a scene function that adds what depends on
+// the data. The base is solved and copied once: instances share its
+// paths, and only its labels are copied, with placeholders of the form
+// {{name}} replaced by the values the scene sets. Sub-pictures that are
+// expensive to build but repeat across instances (a legend, a symbol per
+// category) are built once through Instance.Shared.
+//
+// Instances share paths with the template and with each other, so they
+// must not be changed in place; Clone an instance first to modify its
+// paths. A Template is safe for concurrent use by several goroutines if
+// the scene function is.
+//
+// Example:
+//
+//	frame := draw.NewPicture()
+//	frame.DrawRect(0, 0, 100, 60, mp.Style{})
+//	frame.Label("{{title}}", mp.P(50, 60), mp.AnchorTop)
+//	t := draw.NewTemplate(frame, func(in *draw.Instance, r Row) error {
+//		in.Set("title", r.Name)
+//		in.Picture().DrawRect(10, 10, r.Value, 20, mp.Style{})
+//		return nil
+//	})
+//	pics, err := t.RenderAll(rows)
+type Template[D any] struct {
+	base  *Picture
+	slots []bool // slots[i]: base label i has placeholders
+	scene func(in *Instance, data D) error
+
+	mu     sync.Mutex
+	shared map[string]*sharedPart
+}
+
+// sharedPart is a sub-picture built once for all instances of a template.
+type sharedPart struct {
+	once sync.Once
+	pic  *Picture
+	err  error
+}
+
+// placeholder matches {{name}} in label texts.
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// NewTemplate returns a template with a copy of base as the shared part
+// and scene as the part that depends on the data. base may be nil, and so
+// may scene if the instances differ in nothing but the template labels,
+// which then keep their placeholders.
+func NewTemplate[D any](base *Picture, scene func(in *Instance, data D) error) *Template[D] {
+	if base == nil {
+		base = NewPicture()
+	}
+	t := &Template[D]{base: base.Clone(), scene: scene, shared: map[string]*sharedPart{}}
+	t.slots = make([]bool, len(t.base.labels))
+	for i, label := range t.base.labels {
+		t.slots[i] = label != nil && placeholder.MatchString(label.Text)
+	}
+	return t
+}
+
+// Render returns the instance of the template for data. It fails if the
+// scene fails or leaves a placeholder of the base labels without a value.
+func (t *Template[D]) Render(data D) (*Picture, error) {
+	pic := &Picture{
+		paths:      append([]*mp.Path(nil), t.base.paths...),
+		multiPaths: append([]*mp.MultiPath(nil), t.base.multiPaths...),
+		multiAt:    append([]int(nil), t.base.multiAt...),
+		labels:     make([]*mp.Label, 0, len(t.base.labels)),
+		clipPath:   t.base.clipPath,
+		export:     t.base.export,
+		exportSet:  t.base.exportSet,
+		defaults:   t.base.defaults,
+	}
+	in := &Instance{pic: pic, values: map[string]string{}, shared: t.sharedPart}
+	if t.scene != nil {
+		if err := t.scene(in, data); err != nil {
+			return nil, err
+		}
+	}
+	// Copies of the template labels go first, in their original order.
+	labels := make([]*mp.Label, 0, len(t.base.labels)+len(pic.labels))
+	for i, label := range t.base.labels {
+		if label == nil {
+			continue
+		}
+		l := *label
+		if t.slots[i] && t.scene != nil {
+			text, err := in.expand(label.Text)
+			if err != nil {
+				return nil, err
+			}
+			l.Text = text
+		}
+		labels = append(labels, &l)
+	}
+	pic.labels = append(labels, pic.labels...)
+	return pic, nil
+}
+
+// RenderAll renders one instance per element of data, in order. It stops
+// at the first error.
+func (t *Template[D]) RenderAll(data []D) ([]*Picture, error) {
+	pics := make([]*Picture, 0, len(data))
+	for i, d := range data {
+		pic, err := t.Render(d)
+		if err != nil {
+			return nil, fmt.Errorf("draw: template instance %d: %w", i, err)
+		}
+		pics = append(pics, pic)
+	}
+	return pics, nil
+}
+
+// sharedPart returns the sub-picture stored under key, building it with
+// build the first time.
+func (t *Template[D]) sharedPart(key string, build func() (*Picture, error)) (*Picture, error) {
+	t.mu.Lock()
+	part, ok := t.shared[key]
+	if !ok {
+		part = &sharedPart{}
+		t.shared[key] = part
+	}
+	t.mu.Unlock()
+	part.once.Do(func() { part.pic, part.err = build() })
+	return part.pic, part.err
+}
+
+// Instance is the state of one instance of a Template while its scene
+// function runs.
+type Instance struct {
+	pic    *Picture
+	values map[string]string
+	shared func(key string, build func() (*Picture, error)) (*Picture, error)
+}
+
+// Picture returns the picture of the instance, which already holds the
+// paths of the template's base. Add the data-dependent content to it.
+func (in *Instance) Picture() *Picture {
+	return in.pic
+}
+
+// Set sets the value of the placeholder {{name}} in the template labels.
+func (in *Instance) Set(name, value string) *Instance {
+	in.values[name] = value
+	return in
+}
+
+// Shared adds the sub-picture stored under key to the instance, building
+// it with build only for the first instance that asks for it. Its paths
+// are shared by all instances; its labels are copied. Use it for content
+// that is expensive to solve and repeats across instances, keyed by what
+// it depends on.
+//
+// Example:
+//
+//	in.Shared("marker:"+row.Kind, func() (*draw.Picture, error) { return marker(row.Kind) })
+func (in *Instance) Shared(key string, build func() (*Picture, error)) error {
+	part, err := in.shared(key, build)
+	if err != nil || part == nil {
+		return err
+	}
+	in.pic.AddPicture(part)
+	return nil
+}
+
+// expand replaces the placeholders in text by their values.
+func (in *Instance) expand(text string) (string, error) {
+	var missing string
+	out := placeholder.ReplaceAllStringFunc(text, func(m string) string {
+		name := placeholder.FindStringSubmatch(m)[1]
+		v, ok := in.values[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return v
+	})
+	if missing != "" {
+		return "", fmt.Errorf("draw: template placeholder %q has no value", missing)
+	}
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package draw
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func TestTemplate(t *testing.T) {
+	type row struct {
+		name  string
+		value float64
+		kind  string
+	}
+	base := NewPicture()
+	base.DrawRect(0, 0, 100, 60, mp.Style{})
+	base.Label("{{name}}: {{ value }}", mp.P(50, 60), mp.AnchorTop)
+	base.Label("fixed", mp.P(0, 0), mp.AnchorLeft)
+
+	builds := 0
+	tmpl := NewTemplate(base, func(in *Instance, r row) error {
+		in.Set("name", r.name).Set("value", FixedTicks(1)(r.value))
+		in.Picture().DrawRect(10, 10, r.value, 20, mp.Style{})
+		return in.Shared("marker:"+r.kind, func() (*Picture, error) {
+			builds++
+			m := NewPicture()
+			m.DrawCircle(mp.P(90, 50), 3, mp.Style{})
+			m.Label(r.kind, mp.P(90, 50), mp.AnchorRight)
+			return m, nil
+		})
+	})
+	base.Label("added later", mp.P(0, 0), mp.AnchorLeft) // not part of the template
+
+	pics, err := tmpl.RenderAll([]row{{"a", 10, "x"}, {"b", 20, "x"}, {"c", 30, "y"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if builds != 2 {
+		t.Errorf("shared part built %d times, want 2", builds)
+	}
+	if got := pics[1].Labels()[0].Text; got != "b: 20.0" {
+		t.Errorf("label = %q", got)
+	}
+	if n := len(pics[2].Labels()); n != 3 {
+		t.Errorf("got %d labels, want 3", n)
+	}
+	if pics[0].Paths()[0] != pics[1].Paths()[0] {
+		t.Error("base paths are not shared")
+	}
+	if pics[0].Paths()[2] != pics[1].Paths()[2] || pics[0].Paths()[2] == pics[2].Paths()[2] {
+		t.Error("shared parts are not keyed")
+	}
+	if pics[0].Labels()[1] == pics[1].Labels()[1] {
+		t.Error("labels are shared between instances")
+	}
+	if n := len(pics[0].Paths()); n != 3 {
+		t.Errorf("got %d paths, want 3", n)
+	}
+
+	_, err = NewTemplate(base, func(in *Instance, _ int) error {
+		in.Set("name", "x")
+		return nil
+	}).Render(0)
+	if err == nil || !strings.Contains(err.Error(), `"value"`) {
+		t.Errorf("missing value error = %v", err)
+	}
+
+	fail := errors.New("no data")
+	_, err = NewTemplate(nil, func(*Instance, int) error { return fail }).RenderAll([]int{1})
+	if !errors.Is(err, fail) {
+		t.Errorf("scene error = %v", err)
+	}
+}
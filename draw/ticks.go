@@ -0,0 +1,203 @@
+package draw
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// TickFormatter turns the value of an axis tick into the text of its
+// label. The formatters below cover the usual cases; any function with
+// this signature can be used instead.
+type TickFormatter func(v float64) string
+
+// NumberLocale describes how numbers are written: the decimal separator
+// and the separator between groups of three integer digits (empty for
+// none). Its methods return formatters that use it.
+//
+// Example:
+//
+//	german := draw.NumberLocale{Decimal: ",", Group: "."}
+//	f := german.Fixed(2) // 1234.5 -> "1.234,50"
+type NumberLocale struct {
+	Decimal string
+	Group   string
+}
+
+// DefaultNumberLocale writes numbers the way Go does: a decimal point and
+// no grouping.
+var DefaultNumberLocale = NumberLocale{Decimal: "."}
+
+// FixedTicks returns a formatter writing values with the given number of
+// decimals in the DefaultNumberLocale, e.g. "2.50" for 2.5 and 2 decimals.
+func FixedTicks(decimals int) TickFormatter {
+	return DefaultNumberLocale.Fixed(decimals)
+}
+
+// SITicks returns a formatter writing values with an SI prefix and the
+// given unit in the DefaultNumberLocale, e.g. "1.5 kHz" for 1500 with 1
+// decimal and unit "Hz". See NumberLocale.SI.
+func SITicks(decimals int, unit string) TickFormatter {
+	return DefaultNumberLocale.SI(decimals, unit)
+}
+
+// PercentTicks returns a formatter writing fractions as percentages in
+// the DefaultNumberLocale, e.g. "25%" for 0.25 and 0 decimals.
+func PercentTicks(decimals int) TickFormatter {
+	return DefaultNumberLocale.Percent(decimals)
+}
+
+// Fixed returns a formatter writing values with the given number of
+// decimals. Values that round to zero are written without a minus sign.
+func (l NumberLocale) Fixed(decimals int) TickFormatter {
+	return func(v float64) string {
+		return l.format(v, decimals)
+	}
+}
+
+// SI returns a formatter writing values scaled by the SI prefix that puts
+// them in [1, 1000), from y (1e-24) to Y (1e24), with the given number of
+// decimals and followed by unit. With a unit the prefix is separated from
+// the number by a space ("1.5 kHz"), without one it is appended directly
+// ("1.5k"). Zero has no prefix.
+func (l NumberLocale) SI(decimals int, unit string) TickFormatter {
+	return func(v float64) string {
+		exp := 0
+		if v != 0 && !math.IsInf(v, 0) && !math.IsNaN(v) {
+			exp = int(math.Floor(math.Log10(math.Abs(v)) / 3))
+			// Rounding may carry into the next prefix (999.96 -> 1.0k).
+			if r := roundTo(math.Abs(v)/math.Pow(1000, float64(exp)), decimals); r >= 1000 {
+				exp++
+			}
+			exp = max(-8, min(8, exp))
+		}
+		num := l.format(v/math.Pow(1000, float64(exp)), decimals)
+		prefix := siPrefixes[exp+8]
+		if unit == "" {
+			return num + prefix
+		}
+		return num + " " + prefix + unit
+	}
+}
+
+// siPrefixes are the SI prefixes from 1e-24 to 1e24 in steps of 1000.
+var siPrefixes = []string{"y", "z", "a", "f", "p", "n", "µ", "m", "", "k", "M", "G", "T", "P", "E", "Z", "Y"}
+
+// Percent returns a formatter writing fractions as percentages with the
+// given number of decimals: 0.25 is "25%".
+func (l NumberLocale) Percent(decimals int) TickFormatter {
+	return func(v float64) string {
+		return l.format(v*100, decimals) + "%"
+	}
+}
+
+// format writes v with the given number of decimals using the separators
+// of l.
+func (l NumberLocale) format(v float64, decimals int) string {
+	decimals = max(0, decimals)
+	if roundTo(v, decimals) == 0 {
+		v = 0 // no "-0.00"
+	}
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac, hasFrac := strings.Cut(s, ".")
+	if l.Group != "" && len(intPart) > 3 && intPart[0] >= '0' && intPart[0] <= '9' {
+		var b strings.Builder
+		for i, r := range intPart {
+			if i > 0 && (len(intPart)-i)%3 == 0 {
+				b.WriteString(l.Group)
+			}
+			b.WriteRune(r)
+		}
+		intPart = b.String()
+	}
+	if hasFrac {
+		dec := l.Decimal
+		if dec == "" {
+			dec = "."
+		}
+		return sign + intPart + dec + frac
+	}
+	return sign + intPart
+}
+
+// roundTo rounds v to the given number of decimals.
+func roundTo(v float64, decimals int) float64 {
+	f := math.Pow(10, float64(decimals))
+	return math.Round(v*f) / f
+}
+
+// TimeValue returns the axis value of t used by DateTicks: seconds since
+// the Unix epoch, with fractions.
+//
+// Example:
+//
+//	x := scale(draw.TimeValue(sample.When))
+func TimeValue(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
+
+// DateTicks returns a formatter writing axis values made with TimeValue
+// as dates and times in the layout of time.Format, e.g. "2006-01-02" or
+// "Jan 2", in the location loc (UTC if nil). Month and day names are
+// English, as time.Format writes them.
+//
+// Example:
+//
+//	pic.TickLabels(days, at, mp.AnchorBottom, draw.DateTicks("Jan 2", nil))
+func DateTicks(layout string, loc *time.Location) TickFormatter {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return func(v float64) string {
+		sec, frac := math.Modf(v)
+		return time.Unix(int64(sec), int64(math.Round(frac*1e9))).In(loc).Format(layout)
+	}
+}
+
+// Labels returns the labels of the values.
+func (f TickFormatter) Labels(values []float64) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = f(v)
+	}
+	return out
+}
+
+// TickLabels adds a label for each of the values, formatted by f, at the
+// point at(v) with the given anchor. A nil f uses FixedTicks(0) for
+// values that are all whole numbers and strconv's shortest representation
+// otherwise.
+//
+// Example:
+//
+//	// Percent labels below an x axis where 1 unit of value is 200 units.
+//	pic.TickLabels([]float64{0, 0.25, 0.5, 0.75, 1},
+//		func(v float64) mp.Point { return mp.P(200*v, -3) },
+//		mp.AnchorBottom, draw.PercentTicks(0))
+func (p *Picture) TickLabels(values []float64, at func(v float64) mp.Point, anchor mp.Anchor, f TickFormatter) *Picture {
+	if f == nil {
+		f = defaultTicks(values)
+	}
+	for _, v := range values {
+		p.Label(f(v), at(v), anchor)
+	}
+	return p
+}
+
+// defaultTicks returns the formatter TickLabels uses for values without
+// one.
+func defaultTicks(values []float64) TickFormatter {
+	for _, v := range values {
+		if v != math.Trunc(v) {
+			return func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+		}
+	}
+	return FixedTicks(0)
+}
@@ -0,0 +1,56 @@
+package draw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func TestTickFormatters(t *testing.T) {
+	german := NumberLocale{Decimal: ",", Group: "."}
+	tests := []struct {
+		name string
+		f    TickFormatter
+		v    float64
+		want string
+	}{
+		{"fixed", FixedTicks(2), 2.5, "2.50"},
+		{"fixed negative zero", FixedTicks(1), -0.01, "0.0"},
+		{"fixed grouped", german.Fixed(2), -1234567.5, "-1.234.567,50"},
+		{"fixed short", german.Fixed(0), 123, "123"},
+		{"si unit", SITicks(1, "Hz"), 1500, "1.5 kHz"},
+		{"si bare", SITicks(0, ""), 2e6, "2M"},
+		{"si small", SITicks(0, "s"), 0.000003, "3 µs"},
+		{"si carry", SITicks(1, ""), 999.96, "1.0k"},
+		{"si zero", SITicks(1, "m"), 0, "0.0 m"},
+		{"percent", PercentTicks(0), 0.25, "25%"},
+		{"percent locale", german.Percent(1), 0.125, "12,5%"},
+		{"date", DateTicks("2006-01-02", nil), TimeValue(time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)), "2024-03-05"},
+	}
+	for _, tt := range tests {
+		if got := tt.f(tt.v); got != tt.want {
+			t.Errorf("%s: f(%g) = %q, want %q", tt.name, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestTickLabels(t *testing.T) {
+	pic := NewPicture()
+	pic.TickLabels([]float64{0, 0.5, 1}, func(v float64) mp.Point { return mp.P(100*v, -3) }, mp.AnchorBottom, PercentTicks(0))
+	labels := pic.Labels()
+	if len(labels) != 3 {
+		t.Fatalf("got %d labels, want 3", len(labels))
+	}
+	if labels[1].Text != "50%" || labels[1].Position != mp.P(50, -3) {
+		t.Errorf("middle label = %q at %v", labels[1].Text, labels[1].Position)
+	}
+
+	pic = NewPicture().TickLabels([]float64{1, 2}, func(v float64) mp.Point { return mp.P(v, 0) }, mp.AnchorBottom, nil)
+	if got := pic.Labels()[1].Text; got != "2" {
+		t.Errorf("default label = %q, want 2", got)
+	}
+	if got := FixedTicks(1).Labels([]float64{1, 2}); got[0] != "1.0" || got[1] != "2.0" {
+		t.Errorf("Labels = %q", got)
+	}
+}
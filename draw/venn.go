@@ -0,0 +1,328 @@
+package draw
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// vennGrid is the number of candidate points per axis searched when placing
+// region labels.
+const vennGrid = 60
+
+// Venn describes a two- or three-set Venn diagram.
+//
+// Regions are keyed by the letters of the sets they lie inside, where "A",
+// "B" and "C" stand for Sets[0], Sets[1] and Sets[2]: "A" is the part of the
+// first set outside all others, "AB" the part shared by the first two sets
+// only, "ABC" the common part of all three.
+//
+// If Sizes is set the diagram is area-proportional: circle areas follow
+// Sizes and the pairwise overlaps follow Overlaps (keyed "AB", "AC", "BC").
+// Three-set overlaps cannot in general be matched exactly by circles; the
+// pairwise overlaps are matched and the triple region is whatever results.
+type Venn struct {
+	Sets     []string           // set names, drawn outside the circles
+	Regions  map[string]string  // region labels, keyed as described above
+	Sizes    []float64          // optional set sizes for area-proportional diagrams
+	Overlaps map[string]float64 // pairwise overlap sizes, keyed "AB", "AC", "BC"
+	Radius   float64            // radius of the largest circle (default 50)
+	Colors   []mp.Color         // circle fill colors (default translucent palette)
+	Style    mp.Style           // outline style of the circles
+	Font     mp.FontRenderer    // optional text metrics for fitting region labels
+}
+
+// ErrVennSets is returned by Venn.Picture when the diagram does not have two
+// or three sets.
+var ErrVennSets = errors.New("draw: Venn diagram needs two or three sets")
+
+// defaultVennColors are the translucent fills used when Venn.Colors is empty.
+var defaultVennColors = []mp.Color{
+	mp.ColorRGBA(0.85, 0.2, 0.2, 0.3),
+	mp.ColorRGBA(0.2, 0.4, 0.85, 0.3),
+	mp.ColorRGBA(0.2, 0.7, 0.3, 0.3),
+}
+
+// Venn2 returns a two-set Venn diagram of equal circles with the given set
+// names and region labels.
+//
+// Example:
+//
+//	pic, _ := draw.Venn2("Cats", "Dogs", map[string]string{"AB": "both"})
+func Venn2(a, b string, regions map[string]string) (*Picture, error) {
+	return (&Venn{Sets: []string{a, b}, Regions: regions}).Picture()
+}
+
+// Venn3 returns a three-set Venn diagram of equal circles with the given set
+// names and region labels.
+func Venn3(a, b, c string, regions map[string]string) (*Picture, error) {
+	return (&Venn{Sets: []string{a, b, c}, Regions: regions}).Picture()
+}
+
+// Picture lays out the diagram and returns it as a picture of filled and
+// outlined circles, set names outside the circles and region labels placed
+// at the point of each region farthest from all circle outlines. A region
+// label is only placed where its text, measured with Font or estimated as
+// by mp.Label.EstimateBounds, fits inside the region; it is left out if the
+// region is too small for it.
+func (v *Venn) Picture() (*Picture, error) {
+	n := len(v.Sets)
+	if n != 2 && n != 3 {
+		return nil, ErrVennSets
+	}
+	radius := v.Radius
+	if radius <= 0 {
+		radius = 50
+	}
+	centers, radii, err := v.layout(radius)
+	if err != nil {
+		return nil, err
+	}
+
+	pic := NewPicture()
+	colors := v.Colors
+	if len(colors) == 0 {
+		colors = defaultVennColors
+	}
+	outline := v.Style
+	if outline.StrokeWidth == 0 {
+		outline.StrokeWidth = 0.5
+	}
+	if outline.Stroke.CSS() == "" {
+		outline.Stroke = mp.ColorCSS("black")
+	}
+	for i := range centers {
+		t := mp.Scaled(2 * radii[i]).Then(mp.Shifted(centers[i].X, centers[i].Y))
+		circle := t.ApplyToPath(mp.FullCircle())
+		circle.Style = outline
+		circle.Style.Fill = colors[i%len(colors)]
+		pic.AddPath(circle)
+	}
+
+	// Set names sit outside their circle, away from the diagram's center.
+	var mid mp.Point
+	for _, c := range centers {
+		mid.X += c.X / float64(n)
+		mid.Y += c.Y / float64(n)
+	}
+	for i, name := range v.Sets {
+		if name == "" {
+			continue
+		}
+		dx, dy := centers[i].X-mid.X, centers[i].Y-mid.Y
+		d := math.Hypot(dx, dy)
+		if d == 0 {
+			dx, dy, d = 0, 1, 1
+		}
+		pos := mp.P(centers[i].X+dx/d*radii[i], centers[i].Y+dy/d*radii[i])
+		pic.Label(name, pos, vennAnchor(dx, dy))
+	}
+
+	// Sorted keys keep the label order, and so the output, deterministic.
+	keys := make([]string, 0, len(v.Regions))
+	for key := range v.Regions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		text := v.Regions[key]
+		mask, ok := vennMask(key, n)
+		if !ok || text == "" {
+			continue
+		}
+		w, h := v.textSize(text)
+		if pos, ok := vennRegionPoint(centers, radii, mask, w, h); ok {
+			pic.Label(text, pos, mp.AnchorCenter)
+		}
+	}
+	return pic, nil
+}
+
+// textSize returns the width and height of a region label with text.
+func (v *Venn) textSize(text string) (w, h float64) {
+	if v.Font != nil {
+		return v.Font.TextBounds(text, mp.DefaultFontSize)
+	}
+	minX, minY, maxX, maxY := mp.NewLabel(text, mp.P(0, 0), mp.AnchorCenter).EstimateBounds()
+	return maxX - minX, maxY - minY
+}
+
+// layout returns circle centers and radii. Equal circles are placed so that
+// every region has a comfortable size; area-proportional circles are spaced
+// so that the pairwise lens areas match the requested overlaps.
+func (v *Venn) layout(radius float64) ([]mp.Point, []float64, error) {
+	n := len(v.Sets)
+	radii := make([]float64, n)
+	if len(v.Sizes) == 0 {
+		for i := range radii {
+			radii[i] = radius
+		}
+		if n == 2 {
+			return []mp.Point{mp.P(-radius/2, 0), mp.P(radius/2, 0)}, radii, nil
+		}
+		// Centers on an equilateral triangle with side length radius.
+		h := radius / math.Sqrt(3)
+		return []mp.Point{
+			mp.P(-radius/2, h/2),
+			mp.P(radius/2, h/2),
+			mp.P(0, -h),
+		}, radii, nil
+	}
+
+	if len(v.Sizes) != n {
+		return nil, nil, errors.New("draw: Venn diagram needs one size per set")
+	}
+	maxSize := 0.0
+	for _, s := range v.Sizes {
+		if s <= 0 {
+			return nil, nil, errors.New("draw: Venn set sizes must be positive")
+		}
+		maxSize = math.Max(maxSize, s)
+	}
+	// Areas are measured in units of the largest circle's area.
+	unit := math.Pi * radius * radius / maxSize
+	for i, s := range v.Sizes {
+		radii[i] = math.Sqrt(s * unit / math.Pi)
+	}
+	dist := func(i, j int) float64 {
+		key := string(rune('A'+i)) + string(rune('A'+j))
+		return circleDistanceForOverlap(radii[i], radii[j], v.Overlaps[key]*unit)
+	}
+
+	dAB := dist(0, 1)
+	centers := []mp.Point{mp.P(-dAB/2, 0), mp.P(dAB/2, 0)}
+	if n == 2 {
+		return centers, radii, nil
+	}
+	dAC, dBC := dist(0, 2), dist(1, 2)
+	// Place C by the law of cosines, clamping distances that violate the
+	// triangle inequality.
+	dAC = math.Min(math.Max(dAC, math.Abs(dAB-dBC)), dAB+dBC)
+	x := (dAC*dAC - dBC*dBC + dAB*dAB) / (2 * dAB)
+	if dAB == 0 {
+		x = 0
+	}
+	y := math.Sqrt(math.Max(dAC*dAC-x*x, 0))
+	centers = append(centers, mp.P(x-dAB/2, -y))
+	return centers, radii, nil
+}
+
+// circleDistanceForOverlap returns the distance between the centers of two
+// circles with radii r1 and r2 whose lens has the given area. The area is
+// clamped to what two such circles can share.
+func circleDistanceForOverlap(r1, r2, area float64) float64 {
+	lo, hi := math.Abs(r1-r2), r1+r2
+	if area <= 0 {
+		return hi
+	}
+	if area >= circleOverlapArea(r1, r2, lo) {
+		return lo
+	}
+	// The lens area decreases monotonically with the distance.
+	for i := 0; i < 60; i++ {
+		mid := (lo + hi) / 2
+		if circleOverlapArea(r1, r2, mid) > area {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// circleOverlapArea returns the area of the intersection of two circles with
+// radii r1 and r2 whose centers are d apart.
+func circleOverlapArea(r1, r2, d float64) float64 {
+	if d >= r1+r2 {
+		return 0
+	}
+	if d <= math.Abs(r1-r2) {
+		r := math.Min(r1, r2)
+		return math.Pi * r * r
+	}
+	a1 := math.Acos((d*d + r1*r1 - r2*r2) / (2 * d * r1))
+	a2 := math.Acos((d*d + r2*r2 - r1*r1) / (2 * d * r2))
+	k := math.Sqrt((-d + r1 + r2) * (d + r1 - r2) * (d - r1 + r2) * (d + r1 + r2))
+	return r1*r1*a1 + r2*r2*a2 - k/2
+}
+
+// vennMask converts a region key such as "AC" into a bit mask over n sets.
+func vennMask(key string, n int) (int, bool) {
+	mask := 0
+	for _, r := range key {
+		i := int(r - 'A')
+		if i < 0 || i >= n {
+			return 0, false
+		}
+		mask |= 1 << i
+	}
+	return mask, mask != 0
+}
+
+// vennRegionPoint returns the point inside exactly the circles in mask that
+// is farthest from every circle outline and at which a w×h rectangle
+// centered there lies inside the region.
+func vennRegionPoint(centers []mp.Point, radii []float64, mask int, w, h float64) (mp.Point, bool) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i, c := range centers {
+		minX, maxX = math.Min(minX, c.X-radii[i]), math.Max(maxX, c.X+radii[i])
+		minY, maxY = math.Min(minY, c.Y-radii[i]), math.Max(maxY, c.Y+radii[i])
+	}
+	var best mp.Point
+	bestDist := 0.0
+	for i := 0; i <= vennGrid; i++ {
+		for j := 0; j <= vennGrid; j++ {
+			pt := mp.P(minX+(maxX-minX)*float64(i)/vennGrid, minY+(maxY-minY)*float64(j)/vennGrid)
+			dist := math.Inf(1)
+			in := 0
+			for k, c := range centers {
+				r := math.Hypot(pt.X-c.X, pt.Y-c.Y)
+				if r < radii[k] {
+					in |= 1 << k
+				}
+				dist = math.Min(dist, math.Abs(r-radii[k]))
+			}
+			if in == mask && dist > bestDist && vennRectInside(centers, radii, mask, pt, w, h) {
+				best, bestDist = pt, dist
+			}
+		}
+	}
+	return best, bestDist > 0
+}
+
+// vennRectInside reports whether the w×h rectangle centered at c lies
+// inside exactly the circles in mask, with no circle outline crossing it.
+func vennRectInside(centers []mp.Point, radii []float64, mask int, c mp.Point, w, h float64) bool {
+	x0, y0, x1, y1 := c.X-w/2, c.Y-h/2, c.X+w/2, c.Y+h/2
+	for k, o := range centers {
+		// Nearest point of the rectangle and farthest corner from the center.
+		near := math.Hypot(o.X-math.Max(x0, math.Min(o.X, x1)), o.Y-math.Max(y0, math.Min(o.Y, y1)))
+		far := math.Hypot(math.Max(o.X-x0, x1-o.X), math.Max(o.Y-y0, y1-o.Y))
+		if mask&(1<<k) != 0 {
+			if far >= radii[k] {
+				return false
+			}
+		} else if near <= radii[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// vennAnchor returns the label anchor for a set name placed on a circle in
+// direction (dx, dy) from the diagram's center.
+func vennAnchor(dx, dy float64) mp.Anchor {
+	if math.Abs(dx) > math.Abs(dy) {
+		if dx < 0 {
+			return mp.AnchorLeft
+		}
+		return mp.AnchorRight
+	}
+	if dy < 0 {
+		return mp.AnchorBottom
+	}
+	return mp.AnchorTop
+}
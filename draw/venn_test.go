@@ -0,0 +1,88 @@
+package draw
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func TestVenn3RegionLabels(t *testing.T) {
+	pic, err := Venn3("A", "B", "C", map[string]string{"A": "a", "AB": "ab", "ABC": "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pic.Paths()) != 3 {
+		t.Fatalf("expected 3 circles, got %d", len(pic.Paths()))
+	}
+	circles := pic.Paths()
+	inside := map[string][]bool{
+		"a":   {true, false, false},
+		"ab":  {true, true, false},
+		"abc": {true, true, true},
+	}
+	found := 0
+	for _, l := range pic.Labels() {
+		want, ok := inside[l.Text]
+		if !ok {
+			continue
+		}
+		found++
+		for i, c := range circles {
+			if got := c.Contains(l.Position.X, l.Position.Y); got != want[i] {
+				t.Errorf("label %q: inside circle %d = %v, want %v", l.Text, i, got, want[i])
+			}
+		}
+	}
+	if found != 3 {
+		t.Errorf("expected 3 region labels, got %d", found)
+	}
+}
+
+func TestVennProportionalOverlap(t *testing.T) {
+	v := &Venn{
+		Sets:     []string{"A", "B"},
+		Sizes:    []float64{100, 50},
+		Overlaps: map[string]float64{"AB": 20},
+	}
+	centers, radii, err := v.layout(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unit := math.Pi * 50 * 50 / 100
+	if got := math.Pi * radii[1] * radii[1] / unit; math.Abs(got-50) > 1e-9 {
+		t.Errorf("area of B = %g, want 50", got)
+	}
+	d := math.Hypot(centers[1].X-centers[0].X, centers[1].Y-centers[0].Y)
+	if got := circleOverlapArea(radii[0], radii[1], d) / unit; math.Abs(got-20) > 1e-6 {
+		t.Errorf("overlap = %g, want 20", got)
+	}
+	if _, err := (&Venn{Sets: []string{"A"}}).Picture(); err != ErrVennSets {
+		t.Errorf("expected ErrVennSets, got %v", err)
+	}
+}
+
+// TestVennRegionLabelFits checks that region labels are placed only where
+// their text extent fits inside the region.
+func TestVennRegionLabelFits(t *testing.T) {
+	pic, err := Venn2("A", "B", map[string]string{"A": "only A", "AB": "shared by both sets"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	circles := pic.Paths()
+	var placed []*mp.Label
+	for _, l := range pic.Labels() {
+		if l.Text == "only A" || l.Text == "shared by both sets" {
+			placed = append(placed, l)
+		}
+	}
+	if len(placed) != 1 || placed[0].Text != "only A" {
+		t.Fatalf("placed %v, want only the label that fits", placed)
+	}
+	minX, minY, maxX, maxY := placed[0].EstimateBounds()
+	for _, c := range [][2]float64{{minX, minY}, {maxX, minY}, {minX, maxY}, {maxX, maxY}} {
+		if !circles[0].Contains(c[0], c[1]) || circles[1].Contains(c[0], c[1]) {
+			t.Errorf("corner %v of %q is outside its region", c, placed[0].Text)
+		}
+	}
+}
@@ -0,0 +1,867 @@
+// Package eps writes pictures as Encapsulated PostScript in the conventions
+// of MetaPost's own output (the .mps/.eps files of mpost): a %%BoundingBox
+// and %%HiResBoundingBox header, one page, paths as moveto/curveto/lineto
+// with closepath, the graphics state (color, line width, setdash,
+// setlinecap, setlinejoin, setmiterlimit) written only where it changes,
+// and fills and strokes separated as MetaPost does ("gsave fill grestore
+// stroke" for filldraw). The files can be included by TeX workflows that
+// expect MetaPost output, such as \includegraphics with mptopdf or dvips.
+//
+// Labels are written as text in the PostScript base fonts (Helvetica for
+// sans-serif, Times-Roman for serif, Courier for monospace and the other
+// families of the 35 standard fonts under their PostScript names), placed
+// with the font's real widths through stringwidth; characters outside
+// ASCII become "?". A family without spaces, such as Optima-Bold, is taken
+// as a PostScript font name; other families are refused with
+// ErrFontFamily. TeX labels need a TeX backend and are refused with
+// ErrTeXLabel.
+//
+// Example:
+//
+//	var b bytes.Buffer
+//	w := eps.NewWriter()
+//	w.AddPicture(pic)
+//	if err := w.WriteTo(&b); err != nil {
+//		return err
+//	}
+//	os.WriteFile("figure.mps", b.Bytes(), 0o644)
+package eps
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// defaultPenWidth is the width of MetaPost's default pen (plain.mp:
+// pickup pencircle scaled .5bp).
+const defaultPenWidth = 0.5
+
+// miterLimit is MetaPost's default miterlimit.
+const miterLimit = 10
+
+// ErrTeXLabel is returned by WriteTo for a label with TeX set: PostScript
+// text cannot typeset it, and writing its PlainText would silently drop
+// the markup a TeX workflow expects.
+var ErrTeXLabel = errors.New("eps: TeX label needs a TeX backend")
+
+// ErrFontFamily is returned by WriteTo for a label whose font family has
+// no PostScript font name (see the package documentation).
+var ErrFontFamily = errors.New("eps: no PostScript font for font family")
+
+// Picture is the content the writer renders; draw.Picture implements it.
+type Picture interface {
+	Paths() []*mp.Path
+	Labels() []*mp.Label
+	ClipPath() *mp.Path
+}
+
+// MultiPathPicture is implemented by pictures that also carry multi-part
+// paths (see mp.MultiPath). AddPicture renders them after the plain paths
+// unless the picture is also a StackedPicture.
+type MultiPathPicture interface {
+	MultiPaths() []*mp.MultiPath
+}
+
+// StackedPicture is implemented by pictures whose multi-paths are drawn
+// between their paths: MultiPathPositions()[i] is the number of paths
+// drawn before multi-path i. draw.Picture implements it.
+type StackedPicture interface {
+	MultiPathPositions() []int
+}
+
+// Writer collects paths, multi-paths and labels and writes them as one
+// EPS page.
+type Writer struct {
+	items    []item
+	creator  string
+	date     time.Time
+	progress mp.ProgressFunc // Called by WriteTo after each element, nil for none
+}
+
+// item is a piece of content in drawing order. Exactly one of the fields
+// is set; a clipped group has clip and the content in group.
+type item struct {
+	path  *mp.Path
+	multi *mp.MultiPath
+	label *mp.Label
+	clip  *mp.Path
+	group []item
+}
+
+// NewWriter returns an empty writer with creator "mpgo" and no creation
+// date, so that the output is reproducible.
+func NewWriter() *Writer {
+	return &Writer{creator: "mpgo"}
+}
+
+// Creator sets the %%Creator comment.
+func (w *Writer) Creator(name string) *Writer {
+	w.creator = name
+	return w
+}
+
+// CreationDate sets the %%CreationDate comment, written in MetaPost's
+// format (2006.01.02:1504). The zero time leaves it out.
+func (w *Writer) CreationDate(t time.Time) *Writer {
+	w.date = t
+	return w
+}
+
+// SetProgress sets a function called by WriteTo after each path,
+// multi-path and label written, with the number of elements done and the
+// total. nil disables progress reports.
+func (w *Writer) SetProgress(f mp.ProgressFunc) *Writer {
+	w.progress = f
+	return w
+}
+
+// AddPath adds a solved path.
+func (w *Writer) AddPath(p *mp.Path) *Writer {
+	if p != nil && p.Head != nil {
+		w.items = append(w.items, item{path: p})
+	}
+	return w
+}
+
+// AddMultiPath adds a multi-part path, drawn as one PostScript path.
+func (w *Writer) AddMultiPath(m *mp.MultiPath) *Writer {
+	if m != nil && len(m.Parts) > 0 {
+		w.items = append(w.items, item{multi: m})
+	}
+	return w
+}
+
+// AddLabel adds a label.
+func (w *Writer) AddLabel(l *mp.Label) *Writer {
+	if l != nil {
+		w.items = append(w.items, item{label: l})
+	}
+	return w
+}
+
+// AddPicture adds the paths, multi-paths and labels of pic. The paths of a
+// picture with a clip path are clipped to it; its labels are not, as in
+// the SVG output.
+func (w *Writer) AddPicture(pic Picture) *Writer {
+	if pic == nil {
+		return w
+	}
+	var multiPaths []*mp.MultiPath
+	var positions []int
+	if mpic, ok := pic.(MultiPathPicture); ok {
+		multiPaths = mpic.MultiPaths()
+		if spic, ok := pic.(StackedPicture); ok {
+			positions = spic.MultiPathPositions()
+		}
+	}
+	var content []item
+	next := 0
+	// addMultiPaths adds the multi-paths drawn before path number upTo.
+	addMultiPaths := func(upTo int) {
+		for ; next < len(multiPaths) && next < len(positions) && positions[next] <= upTo; next++ {
+			if m := multiPaths[next]; m != nil && len(m.Parts) > 0 {
+				content = append(content, item{multi: m})
+			}
+		}
+	}
+	for i, p := range pic.Paths() {
+		addMultiPaths(i)
+		if p != nil && p.Head != nil {
+			content = append(content, item{path: p})
+		}
+	}
+	for ; next < len(multiPaths); next++ {
+		if m := multiPaths[next]; m != nil && len(m.Parts) > 0 {
+			content = append(content, item{multi: m})
+		}
+	}
+	if clip := pic.ClipPath(); clip != nil && clip.Head != nil {
+		w.items = append(w.items, item{clip: clip, group: content})
+	} else {
+		w.items = append(w.items, content...)
+	}
+	for _, l := range pic.Labels() {
+		w.AddLabel(l)
+	}
+	return w
+}
+
+// Write writes the pictures as one EPS page to out.
+func Write(out io.Writer, pics ...Picture) error {
+	w := NewWriter()
+	for _, pic := range pics {
+		w.AddPicture(pic)
+	}
+	return w.WriteTo(out)
+}
+
+// WriteTo writes the EPS document to out. It fails, writing nothing, with
+// mp.ErrOpenFill if an open path with a fill but without
+// Style.AutoCloseFill was added, and with ErrTeXLabel or ErrFontFamily for
+// a label that cannot be written as PostScript text.
+func (w *Writer) WriteTo(out io.Writer) error {
+	return w.WriteToContext(context.Background(), out)
+}
+
+// WriteToContext is WriteTo with cancellation: ctx is checked after each
+// path, multi-path and label, and ctx.Err() is returned once it is done,
+// leaving an incomplete document in out.
+func (w *Writer) WriteToContext(ctx context.Context, out io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkItems(w.items); err != nil {
+		return err
+	}
+	cw := &errWriter{w: bufio.NewWriter(out)}
+	minX, minY, maxX, maxY := w.boundingBox()
+	fmt.Fprintf(cw, "%%!PS-Adobe-3.0 EPSF-3.0\n")
+	fmt.Fprintf(cw, "%%%%BoundingBox: %d %d %d %d \n",
+		int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+	fmt.Fprintf(cw, "%%%%HiResBoundingBox: %s %s %s %s \n", num(minX), num(minY), num(maxX), num(maxY))
+	if w.creator != "" {
+		fmt.Fprintf(cw, "%%%%Creator: %s\n", w.creator)
+	}
+	if !w.date.IsZero() {
+		fmt.Fprintf(cw, "%%%%CreationDate: %s\n", w.date.Format("2006.01.02:1504"))
+	}
+	fmt.Fprintf(cw, "%%%%Pages: 1\n%%%%BeginProlog\n%%%%EndProlog\n%%%%Page: 1 1\n")
+	total, done := countItems(w.items), 0
+	st := &state{tick: func() error {
+		done++
+		if w.progress != nil {
+			w.progress(done, total)
+		}
+		return ctx.Err()
+	}}
+	for _, it := range w.items {
+		if err := st.writeItem(cw, it); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(cw, "showpage\n%%%%EOF\n")
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.err
+}
+
+// countItems returns the number of paths, multi-paths and labels in items,
+// counting those of clipped groups.
+func countItems(items []item) int {
+	n := 0
+	for _, it := range items {
+		if it.clip != nil {
+			n += countItems(it.group)
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// checkItems returns the first error of the content in items, such as a
+// fill of an open path or a label without a PostScript font.
+func checkItems(items []item) error {
+	for _, it := range items {
+		if it.path != nil {
+			if _, err := mp.ExpandOpenFill(it.path); err != nil {
+				return err
+			}
+		}
+		if l := it.label; l != nil {
+			if l.TeX {
+				return fmt.Errorf("%w: %q", ErrTeXLabel, l.Text)
+			}
+			if _, ok := fontName(l.FontFamily); !ok {
+				return fmt.Errorf("%w %q", ErrFontFamily, l.FontFamily)
+			}
+		}
+		if err := checkItems(it.group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// boundingBox returns the bounding box of the content: the extents of
+// paths, padded by half their line width unless drawn as envelopes, and
+// of labels, clipped groups counting with their clip path. It is all zero
+// for an empty writer.
+func (w *Writer) boundingBox() (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	add := func(c mp.ExtentContributor, px, py float64) {
+		x0, y0, x1, y1, ok := c.Extent()
+		if !ok {
+			return
+		}
+		minX, minY = math.Min(minX, x0-px), math.Min(minY, y0-py)
+		maxX, maxY = math.Max(maxX, x1+px), math.Max(maxY, y1+py)
+	}
+	for _, it := range w.items {
+		switch {
+		case it.path != nil:
+			px, py := halfWidth(it.path)
+			add(it.path, px, py)
+		case it.multi != nil:
+			px, py := halfWidth(&mp.Path{Style: it.multi.Style})
+			add(it.multi, px, py)
+		case it.label != nil:
+			add(it.label, 0, 0)
+		case it.clip != nil:
+			add(it.clip, 0, 0)
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return 0, 0, 0, 0
+	}
+	return minX, minY, maxX, maxY
+}
+
+// halfWidth returns how far the line p is stroked with reaches beyond
+// the path horizontally and vertically, 0 for paths drawn as envelopes or
+// not stroked.
+func halfWidth(p *mp.Path) (px, py float64) {
+	if (p.Envelope != nil && !p.Style.Hairline) || p.Style.Stroke.CSS() == "none" {
+		return 0, 0
+	}
+	if a, b, c, d, ok := penMatrix(p.Style); ok {
+		// The pen is the image of a disc of diameter 1 under the matrix;
+		// its half extents are half the lengths of the matrix rows.
+		return math.Hypot(a, c) / 2, math.Hypot(b, d) / 2
+	}
+	h := lineWidth(p.Style) / 2
+	return h, h
+}
+
+// lineWidth returns the width of the line drawn with style: the scale of
+// an elliptical pen, the style's width or the default pen's.
+func lineWidth(style mp.Style) float64 {
+	if style.Hairline {
+		return 0
+	}
+	if pen := style.Pen; pen != nil && pen.Elliptical {
+		if s := mp.GetPenScale(pen); s > 0 {
+			return s
+		}
+	}
+	if style.StrokeWidth > 0 {
+		return style.StrokeWidth
+	}
+	return defaultPenWidth
+}
+
+// state is the PostScript graphics state written so far, so that
+// operators are only written when a value changes, as mpost does.
+type state struct {
+	set    bool // the line parameters below have been written
+	color  string
+	width  float64
+	dash   string
+	cap    int
+	join   int
+	hasCol bool
+	tick   func() error // called after each element written
+}
+
+// writeItem writes one piece of content and reports it to st.tick.
+func (st *state) writeItem(w *errWriter, it item) error {
+	switch {
+	case it.path != nil:
+		st.writePath(w, it.path)
+	case it.multi != nil:
+		st.writeMultiPath(w, it.multi)
+	case it.label != nil:
+		writeLabel(w, it.label)
+	case it.clip != nil:
+		saved := *st
+		fmt.Fprintf(w, "gsave newpath ")
+		writeSegments(w, it.clip)
+		fmt.Fprintf(w, " clip\n")
+		for _, sub := range it.group {
+			if err := st.writeItem(w, sub); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(w, "grestore\n")
+		*st = saved
+		return w.err
+	}
+	if w.err != nil {
+		return w.err
+	}
+	return st.tick()
+}
+
+// writePath writes p with its fill, stroke and arrowheads, expanding the
+// styles that PostScript has no operator for into plain paths first.
+func (st *state) writePath(w *errWriter, p *mp.Path) {
+	if parts, err := mp.ExpandOpenFill(p); err == nil && parts[0] != p {
+		for _, q := range parts {
+			st.writePath(w, q)
+		}
+		return
+	}
+	if p.Style.Closing != nil && p.Head.LType != mp.KnotEndpoint {
+		for _, q := range mp.ExpandClosingStyle(p) {
+			st.writePath(w, q)
+		}
+		return
+	}
+	if p.Style.LineStyle != mp.LineStyleSolid {
+		for _, q := range mp.ExpandLineStyle(p) {
+			st.writePath(w, q)
+		}
+		return
+	}
+	if p.Style.Gradient != nil && p.Envelope == nil {
+		for _, q := range mp.ExpandStrokeGradient(p) {
+			st.writePath(w, q)
+		}
+		return
+	}
+	stroke := strokeColor(p.Style)
+	if p.Envelope != nil && !p.Style.Hairline {
+		if p.Style.Arrow.Joined {
+			if m := mp.JoinedArrowOutline(p); m != nil {
+				st.setColor(w, stroke)
+				fmt.Fprintf(w, "newpath ")
+				for _, part := range m.Parts {
+					writeSegments(w, part)
+				}
+				fmt.Fprintf(w, " fill\n")
+				return
+			}
+		}
+		st.fill(w, p.Envelope, stroke)
+		for _, head := range mp.EnvelopeArrowHeads(p) {
+			st.fill(w, head, stroke)
+		}
+		return
+	}
+	body := p
+	var heads []*mp.Path
+	if stroke.CSS() != "none" && (p.Style.Arrow.End || p.Style.Arrow.Start) {
+		body, heads = withArrowHeads(p)
+	}
+	fill := p.Style.Fill
+	filled := fill.CSS() != "" && fill.CSS() != "none" && p.Head.LType != mp.KnotEndpoint
+	stroked := stroke.CSS() != "none"
+	switch {
+	case filled && stroked:
+		if st.sameColor(fill, stroke) {
+			st.setColor(w, stroke)
+			st.setLine(w, p.Style)
+			writeNewPath(w, body)
+			fmt.Fprintf(w, " gsave fill grestore")
+		} else {
+			st.setColor(w, fill)
+			writeNewPath(w, body)
+			fmt.Fprintf(w, " gsave fill grestore")
+			st.setColor(w, stroke)
+			st.setLine(w, p.Style)
+		}
+		st.stroke(w, p.Style)
+	case filled:
+		st.fill(w, body, fill)
+	case stroked:
+		st.setColor(w, stroke)
+		st.setLine(w, p.Style)
+		writeNewPath(w, body)
+		st.stroke(w, p.Style)
+	}
+	for _, head := range heads {
+		st.fill(w, head, stroke)
+	}
+}
+
+// withArrowHeads returns p cut back for its arrowheads and the filled
+// heads, as MetaPost's drawarrow does.
+func withArrowHeads(p *mp.Path) (*mp.Path, []*mp.Path) {
+	length, angle := p.Style.Arrow.Length, p.Style.Arrow.Angle
+	if length <= 0 {
+		length = mp.DefaultAHLength
+	}
+	if angle <= 0 {
+		angle = mp.DefaultAHAngle
+	}
+	// Cut back to the base of the heads (none for harpoons).
+	cut := mp.ArrowHeadDepth(p.Style.Arrow)
+	var start, end mp.Number
+	var heads []*mp.Path
+	if p.Style.Arrow.End {
+		end = cut
+		if h := mp.ArrowHeadEnd(p, length, angle); h != nil {
+			heads = append(heads, h)
+		}
+	}
+	if p.Style.Arrow.Start {
+		start = cut
+		if h := mp.ArrowHeadStart(p, length, angle); h != nil {
+			heads = append(heads, h)
+		}
+	}
+	if q := mp.ShortenPathForArrow(p, start, end); q != nil {
+		return q, heads
+	}
+	return p, heads
+}
+
+// writeMultiPath writes the parts of m as one path with the style of m.
+// The envelopes of parts that have one are filled with the stroke color
+// as a path of their own.
+func (st *state) writeMultiPath(w *errWriter, m *mp.MultiPath) {
+	stroke := strokeColor(m.Style)
+	var envelopes, parts []*mp.Path
+	for _, part := range m.Parts {
+		if part.Envelope != nil && !m.Style.Hairline {
+			envelopes = append(envelopes, part.Envelope)
+		} else {
+			parts = append(parts, part)
+		}
+	}
+	if len(envelopes) > 0 {
+		st.setColor(w, stroke)
+		fmt.Fprintf(w, "newpath ")
+		for _, e := range envelopes {
+			writeSegments(w, e)
+		}
+		fmt.Fprintf(w, " fill\n")
+	}
+	if len(parts) == 0 {
+		return
+	}
+	if fill := m.Style.Fill; fill.CSS() != "" && fill.CSS() != "none" {
+		st.setColor(w, fill)
+		fmt.Fprintf(w, "newpath ")
+		for _, part := range parts {
+			writeSegments(w, part)
+		}
+		fmt.Fprintf(w, " fill\n")
+	}
+	if stroke.CSS() != "none" {
+		st.setColor(w, stroke)
+		st.setLine(w, m.Style)
+		fmt.Fprintf(w, "newpath ")
+		for _, part := range parts {
+			writeSegments(w, part)
+		}
+		st.stroke(w, m.Style)
+	}
+}
+
+// fill writes p filled with c.
+func (st *state) fill(w *errWriter, p *mp.Path, c mp.Color) {
+	if p == nil || p.Head == nil {
+		return
+	}
+	st.setColor(w, c)
+	writeNewPath(w, p)
+	fmt.Fprintf(w, " fill\n")
+}
+
+// stroke strokes the current path with the pen of style: non-circular
+// elliptical pens by stroking a line of width 1 in the pen's coordinates,
+// as mpost does.
+func (st *state) stroke(w *errWriter, style mp.Style) {
+	if a, b, c, d, ok := penMatrix(style); ok {
+		fmt.Fprintf(w, "\n gsave [%s %s %s %s 0 0] concat 1 setlinewidth stroke grestore\n", num(a), num(b), num(c), num(d))
+		return
+	}
+	fmt.Fprintf(w, " stroke\n")
+}
+
+// penMatrix returns the linear part of a non-circular elliptical pen of
+// style in the order of PostScript's concat; ok is false for other pens.
+func penMatrix(style mp.Style) (a, b, c, d float64, ok bool) {
+	pen := style.Pen
+	if style.Hairline || pen == nil || !pen.Elliptical || pen.Head == nil {
+		return 0, 0, 0, 0, false
+	}
+	k := pen.Head
+	a, b = k.LeftX-k.XCoord, k.LeftY-k.YCoord
+	c, d = k.RightX-k.XCoord, k.RightY-k.YCoord
+	const eps = 1e-9
+	if math.Abs(a-d) < eps && math.Abs(b+c) < eps {
+		return 0, 0, 0, 0, false // a scaled rotation: a circle
+	}
+	return a, b, c, d, true
+}
+
+// strokeColor returns the stroke color of style, black if unset.
+func strokeColor(style mp.Style) mp.Color {
+	if style.Stroke.CSS() == "" {
+		return mp.ColorCSS("black")
+	}
+	return style.Stroke
+}
+
+// setColor writes c as the current color if it differs from the last one:
+// equal components as setgray, others as setrgbcolor.
+func (st *state) setColor(w *errWriter, c mp.Color) {
+	op := colorOp(c)
+	if st.hasCol && st.color == op {
+		return
+	}
+	st.color, st.hasCol = op, true
+	fmt.Fprintf(w, " %s\n", op)
+}
+
+// sameColor reports whether a and b are written the same.
+func (st *state) sameColor(a, b mp.Color) bool {
+	return colorOp(a) == colorOp(b)
+}
+
+// colorOp returns the PostScript that sets c; colors without RGB
+// components are black.
+func colorOp(c mp.Color) string {
+	r, g, b, _ := c.RGB()
+	if r == g && g == b {
+		return num(r) + " setgray"
+	}
+	return num(r) + " " + num(g) + " " + num(b) + " setrgbcolor"
+}
+
+// setLine writes the line width, dash pattern, cap and join of style
+// where they differ from the current ones. The width is written the way
+// mpost does for circular pens, rounded to whole device pixels.
+func (st *state) setLine(w *errWriter, style mp.Style) {
+	width := lineWidth(style)
+	capV := style.LineCap.MetaPost()
+	dash := dashOp(style.Dash)
+	if _, _, _, _, ok := penMatrix(style); ok {
+		width = 1
+	}
+	var parts []string
+	if !st.set || width != st.width {
+		if width == 0 {
+			parts = append(parts, "0 setlinewidth")
+		} else {
+			parts = append(parts, "0 "+num(width)+" dtransform truncate idtransform setlinewidth pop")
+		}
+	}
+	if !st.set || dash != st.dash {
+		parts = append(parts, dash)
+	}
+	if !st.set || capV != st.cap {
+		parts = append(parts, strconv.Itoa(capV)+" setlinecap")
+	}
+	if join := style.LineJoin.MetaPost(); !st.set || join != st.join {
+		parts = append(parts, strconv.Itoa(join)+" setlinejoin")
+		st.join = join
+	}
+	if !st.set {
+		parts = append(parts, strconv.Itoa(miterLimit)+" setmiterlimit")
+	}
+	st.set, st.width, st.dash, st.cap = true, width, dash, capV
+	if len(parts) > 0 {
+		fmt.Fprintf(w, " %s\n", strings.Join(parts, " "))
+	}
+}
+
+// dashOp returns the setdash operation for d, "[] 0 setdash" for none.
+func dashOp(d *mp.DashPattern) string {
+	if d == nil || len(d.Array) == 0 {
+		return "[] 0 setdash"
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	for _, v := range d.Array {
+		b.WriteString(num(v))
+		b.WriteByte(' ')
+	}
+	b.WriteString("] ")
+	b.WriteString(num(d.Offset))
+	b.WriteString(" setdash")
+	return b.String()
+}
+
+// writeNewPath writes "newpath" and the segments of p.
+func writeNewPath(w *errWriter, p *mp.Path) {
+	fmt.Fprintf(w, "newpath ")
+	writeSegments(w, p)
+}
+
+// writeSegments writes p as moveto, lineto and curveto operations,
+// followed by closepath for a cycle. Segments whose control points lie on
+// their chord are written as lineto, as mpost does. A single point is a
+// zero-length line, which round and square caps draw as a dot.
+func writeSegments(w *errWriter, p *mp.Path) {
+	if p == nil || p.Head == nil {
+		return
+	}
+	k := p.Head
+	fmt.Fprintf(w, "%s %s moveto", num(k.XCoord), num(k.YCoord))
+	if k.Next == nil || k.RType == mp.KnotEndpoint && k.Next == k {
+		fmt.Fprintf(w, " 0 0 rlineto")
+		return
+	}
+	for k.RType != mp.KnotEndpoint {
+		q := k.Next
+		if q == nil {
+			break
+		}
+		if straight(k, q) {
+			fmt.Fprintf(w, "\n%s %s lineto", num(q.XCoord), num(q.YCoord))
+		} else {
+			fmt.Fprintf(w, "\n%s %s %s %s %s %s curveto",
+				num(k.RightX), num(k.RightY), num(q.LeftX), num(q.LeftY), num(q.XCoord), num(q.YCoord))
+		}
+		k = q
+		if k == p.Head {
+			fmt.Fprintf(w, "\n closepath")
+			return
+		}
+	}
+}
+
+// straight reports whether the segment from k to q is a straight line:
+// its control points lie on the chord, between its ends.
+func straight(k, q *mp.Knot) bool {
+	dx, dy := q.XCoord-k.XCoord, q.YCoord-k.YCoord
+	l2 := dx*dx + dy*dy
+	on := func(x, y float64) bool {
+		ex, ey := x-k.XCoord, y-k.YCoord
+		if l2 == 0 {
+			return ex == 0 && ey == 0
+		}
+		t := (ex*dx + ey*dy) / l2
+		cross := ex*dy - ey*dx
+		return t >= 0 && t <= 1 && cross*cross <= 1e-10*l2
+	}
+	return on(k.RightX, k.RightY) && on(q.LeftX, q.LeftY)
+}
+
+// writeLabel writes l as text in a base font, placed like the SVG output:
+// the baseline at the offset anchor point, moved down by the font size
+// for labels that hang below it, and the text moved left by the fraction
+// of its width the anchor asks for.
+func writeLabel(w *errWriter, l *mp.Label) {
+	size := l.FontSize
+	if size == 0 {
+		size = mp.DefaultFontSize
+	}
+	offset := l.LabelOffset
+	if offset == 0 {
+		offset = mp.DefaultLabelOffset
+	}
+	dx, dy := mp.LabelOffsetVector(l.Anchor)
+	xf, yf := mp.LabelAnchorFactors(l.Anchor)
+	color := l.Color
+	if color.CSS() == "" {
+		color = mp.ColorCSS("black")
+	}
+	fmt.Fprintf(w, "gsave %s %s translate", num(l.Position.X), num(l.Position.Y))
+	if l.Angle != 0 {
+		fmt.Fprintf(w, " %s rotate", num(l.Angle))
+	}
+	font, _ := fontName(l.FontFamily)
+	fmt.Fprintf(w, " %s\n/%s findfont %s scalefont setfont %s %s moveto %s",
+		colorOp(color), font, num(size), num(dx*offset), num(dy*offset-yf*size), psString(l.PlainText()))
+	if xf != 0 {
+		fmt.Fprintf(w, " dup stringwidth pop %s mul 0 rmoveto", num(-xf))
+	}
+	fmt.Fprintf(w, " show grestore\n")
+}
+
+// psFonts maps font family names, in lower case, to the upright fonts of
+// the 35 standard PostScript fonts.
+var psFonts = map[string]string{
+	"":                       "Helvetica",
+	"sans-serif":             "Helvetica",
+	"helvetica":              "Helvetica",
+	"arial":                  "Helvetica",
+	"helvetica narrow":       "Helvetica-Narrow",
+	"serif":                  "Times-Roman",
+	"times":                  "Times-Roman",
+	"times new roman":        "Times-Roman",
+	"monospace":              "Courier",
+	"courier":                "Courier",
+	"courier new":            "Courier",
+	"avant garde":            "AvantGarde-Book",
+	"itc avant garde gothic": "AvantGarde-Book",
+	"bookman":                "Bookman-Light",
+	"itc bookman":            "Bookman-Light",
+	"new century schoolbook": "NewCenturySchlbk-Roman",
+	"century schoolbook":     "NewCenturySchlbk-Roman",
+	"palatino":               "Palatino-Roman",
+	"palatino linotype":      "Palatino-Roman",
+	"cursive":                "ZapfChancery-MediumItalic",
+	"zapf chancery":          "ZapfChancery-MediumItalic",
+	"itc zapf chancery":      "ZapfChancery-MediumItalic",
+	"symbol":                 "Symbol",
+	"zapf dingbats":          "ZapfDingbats",
+	"itc zapf dingbats":      "ZapfDingbats",
+}
+
+// fontName returns the PostScript font for a CSS font family: the first
+// entry of a comma-separated list that names a standard font, or the
+// family itself if it is already a PostScript name (no spaces or quotes).
+// It reports false if neither applies.
+func fontName(family string) (string, bool) {
+	for _, name := range strings.Split(family, ",") {
+		name = strings.Trim(strings.TrimSpace(name), `"'`)
+		if font, ok := psFonts[strings.ToLower(name)]; ok {
+			return font, true
+		}
+	}
+	if name := strings.TrimSpace(family); name != "" && !strings.ContainsAny(name, " ,\"'()/") {
+		return name, true
+	}
+	return "", false
+}
+
+// psString returns s as a PostScript string literal. Characters outside
+// printable ASCII become "?".
+func psString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// num formats v like MetaPost's PostScript output: at most five decimals,
+// without trailing zeros.
+func num(v float64) string {
+	v = math.Round(v*1e5) / 1e5
+	if v == 0 {
+		v = 0 // no "-0"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// errWriter keeps the first error of the writes.
+type errWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (c *errWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.err = err
+	return n, err
+}
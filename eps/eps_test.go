@@ -0,0 +1,269 @@
+package eps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func render(t *testing.T, w *Writer) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := w.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	return b.String()
+}
+
+func TestHeader(t *testing.T) {
+	pic := draw.NewPicture()
+	pic.DrawRect(0, 0, 40, 20, mp.Style{})
+	w := NewWriter().CreationDate(time.Date(2024, 3, 5, 14, 7, 0, 0, time.UTC))
+	out := render(t, w.AddPicture(pic))
+	for _, want := range []string{
+		"%!PS-Adobe-3.0 EPSF-3.0\n",
+		"%%BoundingBox: -1 -1 41 21 \n",
+		"%%HiResBoundingBox: -0.25 -0.25 40.25 20.25 \n",
+		"%%Creator: mpgo\n",
+		"%%CreationDate: 2024.03.05:1407\n",
+		"%%Page: 1 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if !strings.HasPrefix(out, "%!PS-Adobe-3.0 EPSF-3.0\n%%BoundingBox:") {
+		t.Errorf("header does not start with the EPSF line and BoundingBox:\n%s", out)
+	}
+	if !strings.HasSuffix(out, "showpage\n%%EOF\n") {
+		t.Errorf("output does not end with showpage and %%%%EOF:\n%s", out)
+	}
+	if empty := render(t, NewWriter()); !strings.Contains(empty, "%%BoundingBox: 0 0 0 0 \n") {
+		t.Errorf("empty writer: %s", empty)
+	}
+}
+
+func TestPathsAndGraphicsState(t *testing.T) {
+	pic := draw.NewPicture()
+	pic.DrawLine(mp.P(0, 0), mp.P(10, 0), mp.Style{
+		Dash:     mp.NewDashPattern(3, 2),
+		LineJoin: mp.LineJoinMiter,
+		LineCap:  mp.LineCapButt,
+	})
+	pic.DrawLine(mp.P(0, 5), mp.P(10, 5), mp.Style{})
+	pic.DrawCircle(mp.P(20, 0), 5, mp.Style{Stroke: mp.ColorCSS("red")})
+	out := render(t, NewWriter().AddPicture(pic))
+	for _, want := range []string{
+		" 0 setgray\n",
+		"0 0.5 dtransform truncate idtransform setlinewidth pop [3 2 ] 0 setdash 0 setlinecap 0 setlinejoin 10 setmiterlimit\n",
+		"newpath 0 0 moveto\n10 0 lineto stroke\n",
+		" [] 0 setdash 1 setlinecap 1 setlinejoin\n",
+		" 1 0 0 setrgbcolor\n",
+		"curveto",
+		"\n closepath stroke\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	// Unchanged state is not written again.
+	if n := strings.Count(out, "setmiterlimit"); n != 1 {
+		t.Errorf("setmiterlimit written %d times", n)
+	}
+	if n := strings.Count(out, "setlinewidth"); n != 1 {
+		t.Errorf("setlinewidth written %d times", n)
+	}
+}
+
+func TestFillAndStroke(t *testing.T) {
+	pic := draw.NewPicture()
+	pic.FillCircle(mp.P(0, 0), 2, mp.ColorCSS("blue"))
+	pic.DrawRect(10, 0, 5, 5, mp.Style{Fill: mp.ColorCSS("yellow")})
+	pic.DrawRect(20, 0, 5, 5, mp.Style{Fill: mp.ColorCSS("black"), Stroke: mp.ColorCSS("black")})
+	out := render(t, NewWriter().AddPicture(pic))
+	for _, want := range []string{
+		" 0 0 1 setrgbcolor\nnewpath ",
+		"closepath fill\n",
+		" 1 1 0 setrgbcolor\nnewpath 10 0 moveto",
+		"closepath gsave fill grestore 0 setgray\n",
+		"closepath gsave fill grestore stroke\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out[:strings.Index(out, "10 0 moveto")], "stroke") {
+		t.Errorf("filled circle is stroked:\n%s", out)
+	}
+}
+
+func TestOpenFill(t *testing.T) {
+	open, err := draw.NewPath().MoveTo(mp.P(0, 0)).LineTo(mp.P(10, 0)).LineTo(mp.P(10, 10)).
+		WithFill(mp.ColorCSS("blue")).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := NewWriter().AddPath(open).WriteTo(&b); !errors.Is(err, mp.ErrOpenFill) || b.Len() != 0 {
+		t.Fatalf("WriteTo = %v and wrote %d bytes; want mp.ErrOpenFill and nothing written", err, b.Len())
+	}
+	open.Style.AutoCloseFill = true
+	out := render(t, NewWriter().AddPath(open))
+	for _, want := range []string{
+		"newpath 0 0 moveto\n10 0 lineto\n10 10 lineto\n0 0 lineto\n closepath fill\n",
+		"newpath 0 0 moveto\n10 0 lineto\n10 10 lineto stroke\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestPensAndArrows(t *testing.T) {
+	pen := mp.XScaled(3).ApplyToPen(mp.PenCircle(1))
+	p, _ := draw.NewPath().MoveTo(mp.P(0, 0)).LineTo(mp.P(10, 0)).Solve()
+	p.Style = mp.Style{Stroke: mp.ColorCSS("black"), Pen: pen}
+	a, _ := draw.NewPath().MoveTo(mp.P(0, 10)).LineTo(mp.P(20, 10)).Solve()
+	a.Style = mp.Style{Stroke: mp.ColorCSS("black"), StrokeWidth: 0.5, Arrow: mp.ArrowStyle{End: true}}
+	out := render(t, NewWriter().AddPath(p).AddPath(a))
+	if !strings.Contains(out, "%%HiResBoundingBox: -1.5 -0.5 20.25 ") {
+		t.Errorf("bounding box does not cover the pen:\n%s", out)
+	}
+	if !strings.Contains(out, "gsave [3 0 0 1 0 0] concat 1 setlinewidth stroke grestore") {
+		t.Errorf("elliptical pen not written with concat:\n%s", out)
+	}
+	// The arrow's shaft ends at the base of its head, which is filled.
+	if strings.Contains(out, "20 10 lineto stroke") {
+		t.Errorf("arrow shaft is not shortened:\n%s", out)
+	}
+	if !strings.Contains(out, "\n20 10 lineto\n") || !strings.HasSuffix(out, "fill\nshowpage\n%%EOF\n") {
+		t.Errorf("arrowhead missing:\n%s", out)
+	}
+}
+
+func TestLabelsAndClip(t *testing.T) {
+	pic := draw.NewPicture()
+	pic.DrawCircle(mp.P(0, 0), 10, mp.Style{})
+	pic.Clip(mp.Scaled(10).ApplyToPath(mp.UnitSquare()))
+	pic.Label("a(b)", mp.P(0, 0), mp.AnchorCenter)
+	out := render(t, NewWriter().AddPicture(pic))
+	for _, want := range []string{
+		"gsave newpath 0 0 moveto",
+		"closepath clip\n",
+		"grestore\ngsave 0 0 translate 0 setgray\n/Helvetica findfont 10 scalefont setfont",
+		"(a\\(b\\)) dup stringwidth pop -0.5 mul 0 rmoveto show grestore\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestMultiPathOrder(t *testing.T) {
+	gray := func(p *mp.Path, g string) *mp.Path {
+		p.Style.Fill = mp.ColorCSS(g)
+		return p
+	}
+	multi := mp.NewMultiPath(mp.Scaled(5).ApplyToPath(mp.UnitSquare()))
+	multi.Style.Fill = mp.ColorCSS("#808080")
+	pic := draw.NewPicture().
+		AddPath(gray(mp.Scaled(10).ApplyToPath(mp.UnitSquare()), "black")).
+		AddMultiPath(multi).
+		AddPath(gray(mp.Scaled(3).ApplyToPath(mp.UnitSquare()), "white"))
+	out := render(t, NewWriter().AddPicture(pic))
+	black := strings.Index(out, "10 0 lineto")
+	mid := strings.Index(out, "0.50196 setgray")
+	white := strings.Index(out, "1 setgray")
+	if black < 0 || !(black < mid && mid < white) {
+		t.Errorf("unexpected drawing order:\n%s", out)
+	}
+}
+
+func TestMultiPathMixedEnvelope(t *testing.T) {
+	line := mp.Scaled(10).ApplyToPath(mp.UnitSquare())
+	stroke := mp.Shifted(20, 0).ApplyToPath(mp.Scaled(10).ApplyToPath(mp.UnitSquare()))
+	stroke.Envelope = mp.Shifted(30, 0).ApplyToPath(mp.UnitSquare())
+	m := mp.NewMultiPath(line, stroke)
+	m.Style = mp.Style{Stroke: mp.ColorCSS("black"), StrokeWidth: 1}
+	out := render(t, NewWriter().AddMultiPath(m))
+	for _, want := range []string{"31 1 lineto", "10 10 lineto"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "30 10 lineto") {
+		t.Errorf("part with an envelope is stroked:\n%s", out)
+	}
+}
+
+func TestProgressAndCancel(t *testing.T) {
+	square := mp.Scaled(10).ApplyToPath(mp.UnitSquare())
+	clip := draw.NewPicture().AddPath(square).AddPath(square)
+	clip.Clip(square)
+	w := NewWriter().AddPicture(clip).AddPath(square).AddLabel(mp.NewLabel("x", mp.P(0, 0), mp.AnchorCenter))
+	var calls [][2]int
+	w.SetProgress(func(done, total int) { calls = append(calls, [2]int{done, total}) })
+	render(t, w)
+	if len(calls) != 4 || calls[3] != [2]int{4, 4} {
+		t.Errorf("progress calls = %v, want 4 of 4", calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.SetProgress(func(done, total int) {
+		if done == 2 {
+			cancel()
+		}
+	})
+	var b bytes.Buffer
+	if err := w.WriteToContext(ctx, &b); !errors.Is(err, context.Canceled) {
+		t.Fatalf("WriteToContext = %v, want context.Canceled", err)
+	}
+	if strings.Contains(b.String(), "showpage") {
+		t.Errorf("canceled output is complete:\n%s", b.String())
+	}
+}
+
+func TestLabelFonts(t *testing.T) {
+	for family, want := range map[string]string{
+		"":                       "/Helvetica findfont",
+		"Times New Roman, serif": "/Times-Roman findfont",
+		"'Palatino'":             "/Palatino-Roman findfont",
+		"Optima-Bold":            "/Optima-Bold findfont",
+	} {
+		l := mp.NewLabel("x", mp.P(0, 0), mp.AnchorCenter)
+		l.FontFamily = family
+		if out := render(t, NewWriter().AddLabel(l)); !strings.Contains(out, want) {
+			t.Errorf("family %q: missing %q in\n%s", family, want, out)
+		}
+	}
+	l := mp.NewLabel("x", mp.P(0, 0), mp.AnchorCenter)
+	l.FontFamily = "Minion Pro"
+	var b bytes.Buffer
+	if err := NewWriter().AddLabel(l).WriteTo(&b); !errors.Is(err, ErrFontFamily) || b.Len() != 0 {
+		t.Errorf("unknown family: WriteTo = %v, wrote %d bytes; want ErrFontFamily", err, b.Len())
+	}
+	l = mp.NewLabel(`$\alpha$`, mp.P(0, 0), mp.AnchorCenter)
+	l.TeX = true
+	if err := NewWriter().AddLabel(l).WriteTo(&b); !errors.Is(err, ErrTeXLabel) || b.Len() != 0 {
+		t.Errorf("TeX label: WriteTo = %v, wrote %d bytes; want ErrTeXLabel", err, b.Len())
+	}
+}
+
+func TestNum(t *testing.T) {
+	for _, c := range []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"}, {-0.000001, "0"}, {1.5, "1.5"}, {2.123456789, "2.12346"}, {-3, "-3"},
+	} {
+		if got := num(c.v); got != c.want {
+			t.Errorf("num(%v) = %q, want %q", c.v, got, c.want)
+		}
+	}
+}
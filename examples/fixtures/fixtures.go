@@ -0,0 +1,204 @@
+// Package fixtures provides canonical paths for regression tests: the fan,
+// circle and mpcurve figures with the control points MetaPost 2.02 reports
+// for them with "show p". Tests of the solver, of backends and of
+// downstream code can share them instead of copying knot literals.
+//
+// Example:
+//
+//	for _, f := range fixtures.All() {
+//		p, err := f.Path()
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		if err := f.Check(p, 1e-3); err != nil {
+//			t.Error(err)
+//		}
+//	}
+package fixtures
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Segment is one segment of a solved path as "show p" prints it:
+// ..controls C1 and C2..End.
+type Segment struct {
+	C1, C2, End mp.Point
+}
+
+// Fixture is a path given by MetaPost source, with what MetaPost makes of
+// it.
+type Fixture struct {
+	Name   string // e.g. "fan 3"
+	Source string // the path expression in MetaPost syntax
+	// MetaPost lists the segments MetaPost 2.02 solves Source to, rounded
+	// to five decimals; for a cycle the last one returns to the first
+	// knot. The path starts at (0,0) unless Start says otherwise.
+	MetaPost []Segment
+	Start    mp.Point
+	build    func() *draw.PathBuilder
+}
+
+// Path solves the fixture with the draw package's path builder.
+func (f Fixture) Path() (*mp.Path, error) {
+	return f.build().Solve()
+}
+
+// Check compares the knots and control points of p with MetaPost's, each
+// coordinate within tol, and describes the first difference. Use a tol of
+// about 1e-3 to allow for the rounding of "show p".
+func (f Fixture) Check(p *mp.Path, tol float64) error {
+	if p == nil || p.Head == nil {
+		return fmt.Errorf("%s: empty path", f.Name)
+	}
+	near := func(a, b mp.Point) bool { return math.Abs(a.X-b.X) <= tol && math.Abs(a.Y-b.Y) <= tol }
+	if start := mp.P(p.Head.XCoord, p.Head.YCoord); !near(start, f.Start) {
+		return fmt.Errorf("%s: starts at %v, want %v", f.Name, start, f.Start)
+	}
+	k := p.Head
+	for i, want := range f.MetaPost {
+		q := k.Next
+		if k.RType == mp.KnotEndpoint || q == nil {
+			return fmt.Errorf("%s: %d segments, want %d", f.Name, i, len(f.MetaPost))
+		}
+		got := Segment{mp.P(k.RightX, k.RightY), mp.P(q.LeftX, q.LeftY), mp.P(q.XCoord, q.YCoord)}
+		if !near(got.C1, want.C1) || !near(got.C2, want.C2) || !near(got.End, want.End) {
+			return fmt.Errorf("%s: segment %d is %v, want %v", f.Name, i, got, want)
+		}
+		k = q
+	}
+	more := k.RType != mp.KnotEndpoint
+	if p.Head.LType != mp.KnotEndpoint {
+		more = k != p.Head || len(f.MetaPost) == 0
+	}
+	if more {
+		return fmt.Errorf("%s: more than %d segments", f.Name, len(f.MetaPost))
+	}
+	return nil
+}
+
+// FanLength is the length of the curves of the fan, 6cm.
+const FanLength = 6 * 28.3464567
+
+// fanControls holds MetaPost's control points of the fan curves.
+var fanControls = [10][4]float64{
+	{44.36261, 44.36261, 110.4153, 0},
+	{43.43579, 43.43579, 109.59146, 10.6654},
+	{43.14322, 43.14322, 110.57832, 21.65662},
+	{43.78325, 43.78325, 113.80388, 32.49019},
+	{45.58353, 45.58353, 119.43259, 42.49783},
+	{48.68285, 48.68285, 127.31259, 50.96584},
+	{53.1185, 53.1185, 136.99841, 57.2969},
+	{58.8096, 58.8096, 147.82527, 61.14009},
+	{65.54742, 65.54742, 159.0586, 62.49892},
+	{72.98096, 72.98096, 170.0787, 61.77214},
+}
+
+// Fan returns curve a of the fan, a single segment leaving the origin at
+// 45 degrees and arriving at (6cm,0) at -10a degrees. MetaPost's data
+// covers a from 0 to 9; for other a MetaPost is empty.
+func Fan(a int) Fixture {
+	f := Fixture{
+		Name:   fmt.Sprintf("fan %d", a),
+		Source: fmt.Sprintf("(0,0){dir 45}..{dir %d}(6cm,0)", -10*a),
+		build: func() *draw.PathBuilder {
+			return draw.NewPath().
+				MoveTo(mp.P(0, 0)).
+				WithDirection(45).
+				WithIncomingDirection(float64(-10 * a)).
+				CurveTo(mp.P(FanLength, 0))
+		},
+	}
+	if a >= 0 && a < len(fanControls) {
+		c := fanControls[a]
+		f.MetaPost = []Segment{{mp.P(c[0], c[1]), mp.P(c[2], c[3]), mp.P(FanLength, 0)}}
+	}
+	return f
+}
+
+// Circle returns the circle of radius 80 through four knots, with the
+// first knot repeated before "cycle" as in the MetaPost source, so that
+// the last segment has length zero.
+func Circle() Fixture {
+	const r = 80.0
+	return Fixture{
+		Name:   "circle",
+		Source: "(80,0)..(0,80)..(-80,0)..(0,-80)..(80,0)..cycle",
+		Start:  mp.P(r, 0),
+		MetaPost: []Segment{
+			{mp.P(80, 44.18279), mp.P(44.18279, 80), mp.P(0, 80)},
+			{mp.P(-44.18279, 80), mp.P(-80, 44.18279), mp.P(-80, 0)},
+			{mp.P(-80, -44.18279), mp.P(-44.18279, -80), mp.P(0, -80)},
+			{mp.P(44.18279, -80), mp.P(80, -44.18279), mp.P(80, 0)},
+			{mp.P(80, 0), mp.P(80, 0), mp.P(80, 0)},
+		},
+		build: func() *draw.PathBuilder {
+			return draw.NewPath().
+				MoveTo(mp.P(r, 0)).
+				CurveTo(mp.P(0, r)).
+				CurveTo(mp.P(-r, 0)).
+				CurveTo(mp.P(0, -r)).
+				CurveTo(mp.P(r, 0)).
+				Close()
+		},
+	}
+}
+
+// mpcurveKnots are the knots z0 to z4 of the mpcurve figures.
+var mpcurveKnots = []mp.Point{{X: 0, Y: 0}, {X: 60, Y: 40}, {X: 40, Y: 90}, {X: 10, Y: 70}, {X: 30, Y: 50}}
+
+// mpcurve returns a builder for z0..z1..z2..z3..z4.
+func mpcurve() *draw.PathBuilder {
+	b := draw.NewPath().MoveTo(mpcurveKnots[0])
+	for _, z := range mpcurveKnots[1:] {
+		b.CurveTo(z)
+	}
+	return b
+}
+
+// MpCurve returns the open curve z0..z1..z2..z3..z4 through (0,0), (60,40),
+// (40,90), (10,70) and (30,50).
+func MpCurve() Fixture {
+	return Fixture{
+		Name:   "mpcurve",
+		Source: "z0..z1..z2..z3..z4",
+		MetaPost: []Segment{
+			{mp.P(26.76463, -1.84543), mp.P(51.4094, 14.58441), mp.P(60, 40)},
+			{mp.P(67.09875, 61.00188), mp.P(59.76253, 84.57518), mp.P(40, 90)},
+			{mp.P(25.35715, 94.01947), mp.P(10.48064, 84.5022), mp.P(10, 70)},
+			{mp.P(9.62895, 58.80421), mp.P(18.80421, 49.62895), mp.P(30, 50)},
+		},
+		build: mpcurve,
+	}
+}
+
+// MpCurveCycle returns the knots of MpCurve joined into the cycle
+// z0..z1..z2..z3..z4..cycle.
+func MpCurveCycle() Fixture {
+	return Fixture{
+		Name:   "mpcurve cycle",
+		Source: "z0..z1..z2..z3..z4..cycle",
+		MetaPost: []Segment{
+			{mp.P(5.18756, -26.8353), mp.P(60.36073, -18.40036), mp.P(60, 40)},
+			{mp.P(59.87714, 59.889), mp.P(57.33896, 81.64203), mp.P(40, 90)},
+			{mp.P(22.39987, 98.48387), mp.P(4.72404, 84.46368), mp.P(10, 70)},
+			{mp.P(13.38637, 60.7165), mp.P(26.35591, 59.1351), mp.P(30, 50)},
+			{mp.P(39.19409, 26.95198), mp.P(-4.10555, 21.23804), mp.P(0, 0)},
+		},
+		build: func() *draw.PathBuilder { return mpcurve().Close() },
+	}
+}
+
+// All returns every fixture: the ten curves of the fan, the circle and
+// both mpcurves.
+func All() []Fixture {
+	var all []Fixture
+	for a := range fanControls {
+		all = append(all, Fan(a))
+	}
+	return append(all, Circle(), MpCurve(), MpCurveCycle())
+}
@@ -0,0 +1,40 @@
+package fixtures
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFixturesMatchMetaPost(t *testing.T) {
+	for _, f := range All() {
+		p, err := f.Path()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		if err := f.Check(p, 1e-3); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCheckReportsDifferences(t *testing.T) {
+	f := MpCurveCycle()
+	p, err := f.Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Head.Next.RightX += 0.01
+	if err := f.Check(p, 1e-3); err == nil || !strings.Contains(err.Error(), "segment 1") {
+		t.Errorf("moved control: %v", err)
+	}
+	open, err := MpCurve().Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Check(open, 1e-3); err == nil {
+		t.Error("the open curve passed as the cycle")
+	}
+	if err := Fan(3).Check(open, 1e-3); err == nil {
+		t.Error("mpcurve passed as a fan curve")
+	}
+}
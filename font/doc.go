@@ -57,6 +57,12 @@
 //
 //	width, height := face.TextBounds("Hello", 12)
 //
+// # Glyph Pens
+//
+// Make a pen in the shape of a letter (its convex hull):
+//
+//	pen, err := face.GlyphPen('o', 4)
+//
 // # Without Font Support
 //
 // If you don't import this package, labels are rendered as SVG <text> elements
@@ -70,6 +76,7 @@
 //   - OpenType text shaping (proper glyph selection and positioning)
 //   - Glyph outline extraction
 //
-// Quadratic Bézier curves (common in TrueType) are automatically converted
-// to cubic Bézier curves to match mpgo's path representation.
+// Quadratic Bézier curves (common in TrueType) are stored as exact cubic
+// Bézier curves marked as quadratic (see mp.Knot.Quadratic), so the SVG
+// output writes them back as "Q" commands.
 package font
@@ -159,6 +159,44 @@ func (f *Face) TextBounds(text string, fontSize float64) (width, height float64)
 	return totalAdvance * scale, (ascender + descender) * scale
 }
 
+// GlyphPen returns a polygonal pen in the shape of the convex hull of the
+// glyph for r at the given font size, for Metafont-style experiments where
+// letters are drawn with letter-shaped pens. The outline is sampled along
+// its curves, so round glyphs give many-sided pens; counters and concave
+// parts are filled in, as with MakePen. The pen is centered on the middle
+// of the glyph's bounding box rather than on its origin.
+//
+// It returns an error if the font has no glyph for r or the glyph has no
+// outline (e.g. a space).
+//
+// Example:
+//
+//	pen, err := face.GlyphPen('o', 4)
+//	path.Style.Pen = pen
+func (f *Face) GlyphPen(r rune, size float64) (*mp.Pen, error) {
+	if size == 0 {
+		size = mp.DefaultFontSize
+	}
+	gid, ok := f.face.Cmap().Lookup(ot.Codepoint(r))
+	if !ok || gid == 0 {
+		return nil, fmt.Errorf("font has no glyph for %q", r)
+	}
+	outline, ok := f.face.GlyphOutline(gid)
+	if !ok {
+		return nil, fmt.Errorf("glyph for %q has no outline", r)
+	}
+	path := outlineToPath(outline, size/f.upem, 0, 0)
+	if path == nil || path.Head == nil {
+		return nil, fmt.Errorf("glyph for %q has no outline", r)
+	}
+	hull := mp.ConvexHullOfPaths(path)
+	if hull == nil {
+		return nil, fmt.Errorf("glyph for %q has no outline", r)
+	}
+	minX, minY, maxX, maxY := mp.PathBBox(hull)
+	return mp.MakePen(hull.Shifted(-(minX+maxX)/2, -(minY+maxY)/2)), nil
+}
+
 // outlineToPath converts a font glyph outline to an mp.Path.
 func outlineToPath(outline ot.GlyphOutline, scale, offsetX, offsetY float64) *mp.Path {
 	if len(outline.Segments) == 0 {
@@ -221,31 +259,17 @@ func outlineToPath(outline ot.GlyphOutline, scale, offsetX, offsetY float64) *mp
 			lastKnot = knot
 
 		case ot.SegmentQuadTo:
-			// Quadratic Bezier - convert to cubic
+			// Quadratic Bezier
 			ctrlX := float64(seg.Args[0].X)*scale + offsetX
 			ctrlY := float64(seg.Args[0].Y)*scale + offsetY
 			endX := float64(seg.Args[1].X)*scale + offsetX
 			endY := float64(seg.Args[1].Y)*scale + offsetY
 
 			if lastKnot != nil {
-				// Convert quadratic to cubic control points
-				// Cubic ctrl1 = start + 2/3 * (qctrl - start)
-				// Cubic ctrl2 = end + 2/3 * (qctrl - end)
-				lastKnot.RightX = lastKnot.XCoord + 2.0/3.0*(ctrlX-lastKnot.XCoord)
-				lastKnot.RightY = lastKnot.YCoord + 2.0/3.0*(ctrlY-lastKnot.YCoord)
-
-				knot := &mp.Knot{
-					XCoord: endX,
-					YCoord: endY,
-					LType:  mp.KnotExplicit,
-					RType:  mp.KnotExplicit,
-					LeftX:  endX + 2.0/3.0*(ctrlX-endX),
-					LeftY:  endY + 2.0/3.0*(ctrlY-endY),
-					RightX: endX,
-					RightY: endY,
-				}
-				path.Append(knot)
-				lastKnot = knot
+				// Stored exactly as a cubic, tagged as quadratic so that
+				// renderers can write it back in the quadratic form.
+				path.AppendQuadratic(ctrlX, ctrlY, endX, endY)
+				lastKnot = path.Head.Prev
 			}
 
 		case ot.SegmentCubeTo:
@@ -0,0 +1,89 @@
+package geo
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+const sample = `{
+  "type": "FeatureCollection",
+  "features": [
+    {"type": "Feature", "properties": {"name": "square"},
+     "geometry": {"type": "Polygon", "coordinates": [
+       [[0,0],[10,0],[10,10],[0,10],[0,0]],
+       [[2,2],[2,8],[8,8],[8,2],[2,2]]]}},
+    {"type": "Feature", "properties": {"name": "road"},
+     "geometry": {"type": "LineString", "coordinates": [[0,0],[5,0.01],[10,0]]}}
+  ]
+}`
+
+func TestParseAndProject(t *testing.T) {
+	features, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) != 2 || features[0].Properties["name"] != "square" {
+		t.Fatalf("unexpected features %+v", features)
+	}
+	proj := Equirectangular{Scale: 180 / math.Pi}
+	square := features[0].MultiPath(proj, 0)
+	if len(square.Parts) != 2 {
+		t.Fatalf("expected exterior and hole, got %d parts", len(square.Parts))
+	}
+	if n := square.Parts[0].PathLength(); n != 4 {
+		t.Errorf("closed ring should have 4 segments, got %d", n)
+	}
+	minX, minY, maxX, maxY := mp.PathBBox(square.Parts[0])
+	if math.Abs(minX) > 1e-9 || math.Abs(minY) > 1e-9 || math.Abs(maxX-10) > 1e-9 || math.Abs(maxY-10) > 1e-9 {
+		t.Errorf("bbox (%g,%g)-(%g,%g)", minX, minY, maxX, maxY)
+	}
+
+	road := features[1].MultiPath(proj, 0.1)
+	if n := road.Parts[0].PathLength(); n != 1 {
+		t.Errorf("simplified road should be one segment, got %d", n)
+	}
+	if _, err := Parse(strings.NewReader(`{"type":"Circle"}`)); err == nil {
+		t.Error("expected error for unknown geometry type")
+	}
+}
+
+func TestProjections(t *testing.T) {
+	m := Mercator{Scale: 1}
+	if _, y, _ := m.Project(0, 45); math.Abs(y-math.Log(math.Tan(3*math.Pi/8))) > 1e-12 {
+		t.Errorf("mercator y(45) = %g", y)
+	}
+	if _, y, _ := m.Project(0, 90); math.IsInf(y, 0) {
+		t.Error("mercator pole should be clamped")
+	}
+	a := AzimuthalEquidistant{Lat0: 90, Scale: 1}
+	// On a polar map, distance from the center is the colatitude.
+	x, y, ok := a.Project(30, 0)
+	if !ok || math.Abs(math.Hypot(x, y)-math.Pi/2) > 1e-12 {
+		t.Errorf("equator at distance %g", math.Hypot(x, y))
+	}
+	if _, _, ok := a.Project(0, -90); ok {
+		t.Error("antipode should not project")
+	}
+}
+
+// overflow is a projection that maps the poles to infinity but still
+// reports success.
+type overflow struct{}
+
+func (overflow) Project(lon, lat float64) (float64, float64, bool) {
+	if math.Abs(lat) == 90 {
+		return lon, math.Inf(1), true
+	}
+	return lon, lat, true
+}
+
+func TestNotFiniteProjection(t *testing.T) {
+	f := Feature{Lines: [][][2]float64{{{0, 0}, {10, 90}}, {{0, 0}, {10, 10}}}}
+	m := f.MultiPath(overflow{}, 0)
+	if len(m.Parts) != 1 {
+		t.Errorf("expected only the finite line, got %d parts", len(m.Parts))
+	}
+}
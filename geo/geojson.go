@@ -0,0 +1,212 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Feature is a GeoJSON feature reduced to its drawable geometry: polygons
+// (each a list of rings, the first one exterior) and lines, all as
+// [lon, lat] pairs in degrees.
+type Feature struct {
+	Properties map[string]any
+	Polygons   [][][][2]float64
+	Lines      [][][2]float64
+}
+
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Features    []geoJSON       `json:"features"`
+	Geometry    *geoJSON        `json:"geometry"`
+	Geometries  []geoJSON       `json:"geometries"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Properties  map[string]any  `json:"properties"`
+}
+
+// Parse reads a GeoJSON FeatureCollection, Feature or bare geometry. Point
+// geometries are ignored.
+func Parse(r io.Reader) ([]Feature, error) {
+	var doc geoJSON
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("geo: %w", err)
+	}
+	switch doc.Type {
+	case "FeatureCollection":
+		features := make([]Feature, 0, len(doc.Features))
+		for _, f := range doc.Features {
+			feature, err := parseFeature(f)
+			if err != nil {
+				return nil, err
+			}
+			features = append(features, feature)
+		}
+		return features, nil
+	case "Feature":
+		f, err := parseFeature(doc)
+		if err != nil {
+			return nil, err
+		}
+		return []Feature{f}, nil
+	}
+	var f Feature
+	if err := f.addGeometry(doc); err != nil {
+		return nil, err
+	}
+	return []Feature{f}, nil
+}
+
+func parseFeature(doc geoJSON) (Feature, error) {
+	f := Feature{Properties: doc.Properties}
+	if doc.Geometry == nil {
+		return f, nil
+	}
+	return f, f.addGeometry(*doc.Geometry)
+}
+
+func (f *Feature) addGeometry(g geoJSON) error {
+	var err error
+	switch g.Type {
+	case "Point", "MultiPoint":
+	case "LineString":
+		var line [][2]float64
+		if err = json.Unmarshal(g.Coordinates, &line); err == nil {
+			f.Lines = append(f.Lines, line)
+		}
+	case "MultiLineString":
+		var lines [][][2]float64
+		if err = json.Unmarshal(g.Coordinates, &lines); err == nil {
+			f.Lines = append(f.Lines, lines...)
+		}
+	case "Polygon":
+		var poly [][][2]float64
+		if err = json.Unmarshal(g.Coordinates, &poly); err == nil {
+			f.Polygons = append(f.Polygons, poly)
+		}
+	case "MultiPolygon":
+		var polys [][][][2]float64
+		if err = json.Unmarshal(g.Coordinates, &polys); err == nil {
+			f.Polygons = append(f.Polygons, polys...)
+		}
+	case "GeometryCollection":
+		for _, sub := range g.Geometries {
+			if err := f.addGeometry(sub); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("geo: unsupported geometry type %q", g.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("geo: %s coordinates: %w", g.Type, err)
+	}
+	return nil
+}
+
+// MultiPath projects the feature and returns all its rings and lines as one
+// multi-part path, simplified so that no point moves more than tolerance
+// output units (0 keeps every point). Polygon rings are closed; GeoJSON
+// orients holes against their exterior ring, so nonzero filling leaves them
+// empty. Points that cannot be projected split a part.
+func (f Feature) MultiPath(proj Projection, tolerance float64) *mp.MultiPath {
+	m := mp.NewMultiPath()
+	for _, poly := range f.Polygons {
+		for _, ring := range poly {
+			for _, pts := range project(proj, ring) {
+				m.Append(polyline(Simplify(pts, tolerance), true))
+			}
+		}
+	}
+	for _, line := range f.Lines {
+		for _, pts := range project(proj, line) {
+			m.Append(polyline(Simplify(pts, tolerance), false))
+		}
+	}
+	return m
+}
+
+// project maps coordinates to the plane, splitting the run where a point
+// cannot be projected.
+func project(proj Projection, coords [][2]float64) [][]mp.Point {
+	var runs [][]mp.Point
+	var cur []mp.Point
+	for _, c := range coords {
+		x, y, ok := proj.Project(c[0], c[1])
+		if !ok {
+			if len(cur) > 0 {
+				runs = append(runs, cur)
+			}
+			cur = nil
+			continue
+		}
+		cur = append(cur, mp.P(x, y))
+	}
+	if len(cur) > 0 {
+		runs = append(runs, cur)
+	}
+	return runs
+}
+
+// polyline returns the straight path through pts, closed if cycle is set. A
+// repeated first point at the end of a closed ring is dropped. It returns
+// nil for fewer than two points or if a point is not finite.
+func polyline(pts []mp.Point, cycle bool) *mp.Path {
+	if cycle && len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	if len(pts) < 2 {
+		return nil
+	}
+	path, err := mp.StraightPath(pts, cycle)
+	if err != nil {
+		return nil
+	}
+	return path
+}
+
+// Simplify reduces a polyline with the Douglas-Peucker algorithm: points
+// closer than tolerance to the simplified line are removed. The end points
+// are always kept. A tolerance of 0 returns pts unchanged.
+func Simplify(pts []mp.Point, tolerance float64) []mp.Point {
+	if tolerance <= 0 || len(pts) < 3 {
+		return pts
+	}
+	keep := make([]bool, len(pts))
+	keep[0], keep[len(pts)-1] = true, true
+	var simplify func(i, j int)
+	simplify = func(i, j int) {
+		maxDist, index := 0.0, -1
+		for k := i + 1; k < j; k++ {
+			if d := segmentDistance(pts[k], pts[i], pts[j]); d > maxDist {
+				maxDist, index = d, k
+			}
+		}
+		if index >= 0 && maxDist > tolerance {
+			keep[index] = true
+			simplify(i, index)
+			simplify(index, j)
+		}
+	}
+	simplify(0, len(pts)-1)
+	out := make([]mp.Point, 0, len(pts))
+	for i, pt := range pts {
+		if keep[i] {
+			out = append(out, pt)
+		}
+	}
+	return out
+}
+
+// segmentDistance returns the distance of p from the segment a--b.
+func segmentDistance(p, a, b mp.Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	l2 := dx*dx + dy*dy
+	t := 0.0
+	if l2 > 0 {
+		t = math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/l2))
+	}
+	return math.Hypot(p.X-a.X-t*dx, p.Y-a.Y-t*dy)
+}
@@ -0,0 +1,85 @@
+// Package geo imports GeoJSON geometry and projects it into mp paths for
+// thematic maps.
+//
+// Features are read with [Parse], projected with one of the map projections
+// ([Equirectangular], [Mercator], [AzimuthalEquidistant]) and optionally
+// simplified by a tolerance in output units. Polygons become closed paths
+// (holes included, as parts of one [mp.MultiPath]), lines open paths.
+//
+// Example:
+//
+//	features, err := geo.Parse(file)
+//	proj := geo.Mercator{Scale: 100}
+//	for _, f := range features {
+//		m := f.MultiPath(proj, 0.5)
+//		m.Style.Fill = mp.ColorCSS("#ddd")
+//		pic.AddMultiPath(m)
+//	}
+package geo
+
+import "math"
+
+// Projection maps geographic coordinates (degrees) to the plane.
+type Projection interface {
+	// Project returns the plane coordinates of (lon, lat) and false if the
+	// point cannot be projected.
+	Project(lon, lat float64) (x, y float64, ok bool)
+}
+
+const deg = math.Pi / 180
+
+// mercatorMaxLat is the latitude where the Mercator map becomes square, as
+// used by web maps; points beyond are clamped.
+const mercatorMaxLat = 85.05112878
+
+// Equirectangular is the plate carrée projection with optional standard
+// parallel: x = Scale·(lon-Lon0)·cos(Lat0), y = Scale·lat, in radians.
+type Equirectangular struct {
+	Lon0, Lat0 float64 // central meridian and standard parallel in degrees
+	Scale      float64 // units per radian
+}
+
+// Project implements Projection.
+func (p Equirectangular) Project(lon, lat float64) (float64, float64, bool) {
+	return p.Scale * (lon - p.Lon0) * deg * math.Cos(p.Lat0*deg), p.Scale * lat * deg, true
+}
+
+// Mercator is the conformal cylindrical projection. Latitudes are clamped to
+// ±85.05°.
+type Mercator struct {
+	Lon0  float64 // central meridian in degrees
+	Scale float64 // units per radian
+}
+
+// Project implements Projection.
+func (p Mercator) Project(lon, lat float64) (float64, float64, bool) {
+	lat = math.Max(-mercatorMaxLat, math.Min(mercatorMaxLat, lat))
+	y := math.Log(math.Tan(math.Pi/4 + lat*deg/2))
+	return p.Scale * (lon - p.Lon0) * deg, p.Scale * y, true
+}
+
+// AzimuthalEquidistant is the azimuthal projection that preserves distances
+// from its center. The antipode of the center cannot be projected.
+type AzimuthalEquidistant struct {
+	Lon0, Lat0 float64 // center in degrees
+	Scale      float64 // units per radian
+}
+
+// Project implements Projection.
+func (p AzimuthalEquidistant) Project(lon, lat float64) (float64, float64, bool) {
+	l, f := (lon-p.Lon0)*deg, lat*deg
+	f0 := p.Lat0 * deg
+	cosC := math.Sin(f0)*math.Sin(f) + math.Cos(f0)*math.Cos(f)*math.Cos(l)
+	cosC = math.Max(-1, math.Min(1, cosC))
+	c := math.Acos(cosC)
+	if c > math.Pi-1e-9 {
+		return 0, 0, false
+	}
+	k := 1.0
+	if c > 1e-12 {
+		k = c / math.Sin(c)
+	}
+	x := k * math.Cos(f) * math.Sin(l)
+	y := k * (math.Cos(f0)*math.Sin(f) - math.Sin(f0)*math.Cos(f)*math.Cos(l))
+	return p.Scale * x, p.Scale * y, true
+}
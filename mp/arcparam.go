@@ -0,0 +1,171 @@
+package mp
+
+import (
+	"math"
+	"sort"
+)
+
+// ArcLengthParam is the arc-length parameterization of a path, computed
+// once: the arc length up to every knot, from which the time at any
+// distance along the path is found by searching one segment instead of
+// integrating from the start as ArcTime does. Build one per carrier path
+// and hand it to everything placed along that path (dashes, labels,
+// arrowheads, patterns) so they all measure distances the same way and
+// stay in sync. Times agree with ArcTime.
+//
+// The path must not be changed while the parameterization is in use.
+//
+// Example:
+//
+//	a := mp.NewArcLengthParam(road)
+//	dashes := mp.NewDashPattern(6, 3).Pieces(a)
+//	ties := mp.PatternAlongParam(a, tie, 9, true) // one tie per dash period
+type ArcLengthParam struct {
+	p     *Path
+	segs  [][4]Point
+	cum   []Number // cum[i]: arc length from the start to knot i
+	cycle bool
+	exact bool // a transformed fullcircle, measured on the exact ellipse
+}
+
+// NewArcLengthParam computes the arc-length parameterization of p.
+func NewArcLengthParam(p *Path) *ArcLengthParam {
+	a := &ArcLengthParam{p: p, cum: []Number{0}}
+	if p == nil || p.Head == nil {
+		return a
+	}
+	a.cycle = p.Head.LType != KnotEndpoint
+	if p.ellipseShape() != nil {
+		a.exact = true
+		a.cum = append(a.cum, p.ArcLength())
+		return a
+	}
+	a.segs = pathSegments(p)
+	for _, c := range a.segs {
+		l := doArcTest(c[1].X-c[0].X, c[1].Y-c[0].Y, c[2].X-c[1].X, c[2].Y-c[1].Y, c[3].X-c[2].X, c[3].Y-c[2].Y)
+		a.cum = append(a.cum, a.cum[len(a.cum)-1]+l)
+	}
+	return a
+}
+
+// Path returns the parameterized path.
+func (a *ArcLengthParam) Path() *Path {
+	return a.p
+}
+
+// Length returns the arc length of the path (see Path.ArcLength).
+func (a *ArcLengthParam) Length() Number {
+	return a.cum[len(a.cum)-1]
+}
+
+// Time returns the time on the path at arc length s from its start, like
+// ArcTime: clamped to the path for open paths, wrapping around (also
+// backwards for negative s) for cycles.
+func (a *ArcLengthParam) Time(s Number) Number {
+	if a.exact {
+		return a.p.ArcTime(s)
+	}
+	n := len(a.segs)
+	total := a.Length()
+	if n == 0 || total <= 0 {
+		return 0
+	}
+	if !a.cycle {
+		switch {
+		case s <= 0:
+			return 0
+		case s >= total:
+			return Number(n)
+		}
+		return a.timeIn(s)
+	}
+	laps := math.Floor(s / total)
+	return laps*Number(n) + a.timeIn(s-laps*total)
+}
+
+// timeIn returns the time at arc length s in [0, Length].
+func (a *ArcLengthParam) timeIn(s Number) Number {
+	n := len(a.segs)
+	// The segment i with cum[i] <= s < cum[i+1].
+	i := sort.Search(n, func(i int) bool { return a.cum[i+1] > s })
+	if i == n {
+		return Number(n)
+	}
+	c := a.segs[i]
+	r := doArcTestWithGoal(c[1].X-c[0].X, c[1].Y-c[0].Y, c[2].X-c[1].X, c[2].Y-c[1].Y, c[3].X-c[2].X, c[3].Y-c[2].Y, s-a.cum[i])
+	if r < 0 {
+		return Number(i) + r + 2
+	}
+	return Number(i + 1)
+}
+
+// Point returns the point of the path at arc length s.
+func (a *ArcLengthParam) Point(s Number) Point {
+	if a.p == nil || a.p.Head == nil {
+		return Point{}
+	}
+	x, y := a.p.PointOf(a.Time(s))
+	return P(x, y)
+}
+
+// Direction returns the direction of the path at arc length s (see
+// DirectionOf); it is not normalized.
+func (a *ArcLengthParam) Direction(s Number) (dx, dy Number) {
+	if a.p == nil || a.p.Head == nil {
+		return 0, 0
+	}
+	return a.p.DirectionOf(a.Time(s))
+}
+
+// Pieces returns the pieces of the path of a that the dash pattern d
+// draws, measured along a, each a subpath with the path's style without
+// the dash. A nil pattern or one without length gives the whole path.
+//
+// Example:
+//
+//	for _, piece := range path.Style.Dash.Pieces(mp.NewArcLengthParam(path)) {
+//		pic.AddPath(piece)
+//	}
+func (d *DashPattern) Pieces(a *ArcLengthParam) []*Path {
+	body := a.p
+	if body == nil || body.Head == nil {
+		return nil
+	}
+	if d == nil {
+		return []*Path{body}
+	}
+	arr := d.Array
+	if len(arr)%2 == 1 {
+		arr = append(append([]float64(nil), arr...), arr...)
+	}
+	period := 0.0
+	for _, v := range arr {
+		period += v
+	}
+	if period <= 0 {
+		return []*Path{body}
+	}
+	style := body.Style
+	style.Dash = nil
+	total := a.Length()
+	phase := math.Mod(d.Offset, period)
+	if phase < 0 {
+		phase += period
+	}
+	i := 0
+	for phase > arr[i] {
+		phase -= arr[i]
+		i = (i + 1) % len(arr)
+	}
+	var out []*Path
+	for s := 0.0; s < total; i = (i + 1) % len(arr) {
+		end := math.Min(s+arr[i]-phase, total)
+		if i%2 == 0 {
+			piece := body.Subpath(a.Time(s), a.Time(end))
+			piece.Style = style
+			out = append(out, piece)
+		}
+		s, phase = end, 0
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestArcLengthParamMatchesArcTime(t *testing.T) {
+	open := cubicPath(P(0, 0), [3]Point{P(10, 20), P(30, 20), P(40, 0)}, [3]Point{P(45, -10), P(60, -10), P(70, 5)})
+	ring := SplitIntoMonotone(Scaled(20).ApplyToPath(FullCircle())) // cubic cycle
+	circle := Scaled(20).ApplyToPath(FullCircle())                  // exact ellipse
+	for name, p := range map[string]*Path{"open": open, "ring": ring, "circle": circle} {
+		a := NewArcLengthParam(p)
+		total := p.ArcLength()
+		if math.Abs(a.Length()-total) > 1e-9 {
+			t.Errorf("%s: Length = %g, want %g", name, a.Length(), total)
+		}
+		for _, f := range []Number{-0.3, 0, 0.1, 0.37, 0.5, 0.99, 1} {
+			s := f * total
+			if got, want := a.Time(s), p.ArcTime(s); math.Abs(got-want) > 1e-6 {
+				t.Errorf("%s: Time(%g) = %g, ArcTime = %g", name, s, got, want)
+			}
+		}
+	}
+	// Cycles wrap around by whole laps.
+	a := NewArcLengthParam(ring)
+	n := Number(ring.PathLength())
+	if got, want := a.Time(1.6*a.Length()), a.Time(0.6*a.Length())+n; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Time after one lap = %g, want %g", got, want)
+	}
+	if got := NewArcLengthParam(nil).Time(3); got != 0 {
+		t.Errorf("empty path: Time = %g", got)
+	}
+}
+
+func TestDashPatternPieces(t *testing.T) {
+	line := straightPath([]Point{P(0, 0), P(10, 0), P(10, 10)}, false)
+	line.Style.Dash = NewDashPattern(3, 2)
+	pieces := line.Style.Dash.Pieces(NewArcLengthParam(line))
+	if len(pieces) != 4 {
+		t.Fatalf("got %d dashes, want 4", len(pieces))
+	}
+	for _, d := range pieces {
+		if d.Style.Dash != nil {
+			t.Error("dash kept its pattern")
+		}
+	}
+	if x, y := pieces[2].PointOf(0); math.Abs(x-10) > 1e-6 || math.Abs(y) > 1e-6 {
+		t.Errorf("third dash starts at (%g,%g), want (10,0)", x, y)
+	}
+	if got := (*DashPattern)(nil).Pieces(NewArcLengthParam(line)); len(got) != 1 || got[0] != line {
+		t.Error("nil pattern must give the path itself")
+	}
+}
@@ -0,0 +1,93 @@
+package mp
+
+import "math"
+
+// ArrowShape selects one of the built-in arrowhead shapes (see
+// ArrowStyle.Shape).
+type ArrowShape int
+
+const (
+	ArrowShapeTriangle     ArrowShape = iota // MetaPost's arrowhead: a triangle with a barb on each side
+	ArrowShapeHarpoonLeft                    // half a triangle, with the barb left of the direction of the head
+	ArrowShapeHarpoonRight                   // half a triangle, with the barb right of the direction of the head
+)
+
+// ArrowHeadFunc builds an arrowhead whose tip is at tip, pointing in the
+// unit direction dir. style is the ArrowStyle of the path with Length and
+// Angle set to the size to draw: the defaults filled in, and scaled for a
+// broad pen (see EnvelopeArrowHeads). The head is filled with the stroke
+// color; nil draws no head. Renderers still shorten the path by
+// Length*cos(Angle/2) before stroking it, as for the built-in triangle.
+//
+// Set it as ArrowStyle.Head to replace the triangle in every renderer and
+// helper that draws arrowheads (ArrowHeadEnd, ArrowHeadStart,
+// EnvelopeArrowHeads, FlowArrowHeads and the bounding box).
+//
+// Example:
+//
+//	// the built-in head, but half as wide for this path
+//	p.Style.Arrow.Head = func(tip, dir mp.Point, s mp.ArrowStyle) *mp.Path {
+//		s.Angle /= 2
+//		return mp.TriangleArrowHead(tip, dir, s)
+//	}
+type ArrowHeadFunc func(tip, dir Point, style ArrowStyle) *Path
+
+// TriangleArrowHead is the built-in arrowhead: a triangle with its apex at
+// tip, style.Length long along dir and style.Angle degrees wide at the
+// apex, like MetaPost's arrowhead macro. It runs from the left barb over
+// the tip to the right barb.
+func TriangleArrowHead(tip, dir Point, style ArrowStyle) *Path {
+	return createArrowHead(tip.X, tip.Y, dir.X, dir.Y, style.Length, style.Angle)
+}
+
+// HarpoonArrowHead is the built-in half arrowhead of ArrowShapeHarpoonLeft
+// and ArrowShapeHarpoonRight: the half of TriangleArrowHead on one side of
+// the axis through tip, a triangle from the tip to the barb and back to the
+// axis. The side is left of dir unless style.Shape is
+// ArrowShapeHarpoonRight. The head at the start of a path points
+// backwards, so its barb lies on the other side of the path than the
+// barb of the head at the end.
+func HarpoonArrowHead(tip, dir Point, style ArrowStyle) *Path {
+	half := style.Angle * math.Pi / 360
+	side := Number(1) // left normal (-dir.Y, dir.X)
+	if style.Shape == ArrowShapeHarpoonRight {
+		side = -1
+	}
+	back := style.Length * math.Cos(half)
+	across := side * style.Length * math.Sin(half)
+	base := P(tip.X-dir.X*back, tip.Y-dir.Y*back)
+	barb := P(base.X-dir.Y*across, base.Y+dir.X*across)
+	return straightPath([]Point{tip, barb, base}, true)
+}
+
+// ArrowHeadDepth returns how far renderers cut a path back at each of its
+// arrowheads, so that the stroke ends under the head: Length*cos(Angle/2)
+// for triangles and custom heads, with MetaPost's default size for an
+// unset Length or Angle, and 0 for harpoons, whose straight side lies on
+// the path, so the stroke runs on to the tip.
+func ArrowHeadDepth(style ArrowStyle) Number {
+	if style.Head == nil && (style.Shape == ArrowShapeHarpoonLeft || style.Shape == ArrowShapeHarpoonRight) {
+		return 0
+	}
+	length, angle := style.Length, style.Angle
+	if length <= 0 {
+		length = DefaultAHLength
+	}
+	if angle <= 0 {
+		angle = DefaultAHAngle
+	}
+	return length * math.Cos(angle*math.Pi/360)
+}
+
+// makeArrowHead returns the head that style asks for at (tipX, tipY) in
+// the unit direction (dx, dy), with length ahLength and angle ahAngle.
+func makeArrowHead(style ArrowStyle, tipX, tipY, dx, dy, ahLength, ahAngle Number) *Path {
+	style.Length, style.Angle = ahLength, ahAngle
+	switch {
+	case style.Head != nil:
+		return style.Head(P(tipX, tipY), P(dx, dy), style)
+	case style.Shape == ArrowShapeHarpoonLeft || style.Shape == ArrowShapeHarpoonRight:
+		return HarpoonArrowHead(P(tipX, tipY), P(dx, dy), style)
+	}
+	return createArrowHead(tipX, tipY, dx, dy, ahLength, ahAngle)
+}
@@ -0,0 +1,48 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHarpoonArrowHead(t *testing.T) {
+	style := ArrowStyle{Length: 4, Angle: 60, Shape: ArrowShapeHarpoonLeft}
+	h := HarpoonArrowHead(P(10, 0), P(1, 0), style)
+	if h.PathLength() != 3 || h.Head.LType == KnotEndpoint {
+		t.Fatalf("harpoon is not a triangle: %s", h)
+	}
+	want := []Point{{10, 0}, {10 - 4*math.Cos(math.Pi/6), 2}, {10 - 4*math.Cos(math.Pi/6), 0}}
+	for i, w := range want {
+		if x, y := h.PointOf(Number(i)); math.Abs(x-w.X) > 1e-9 || math.Abs(y-w.Y) > 1e-9 {
+			t.Errorf("point %d = (%g, %g), want %v", i, x, y, w)
+		}
+	}
+	style.Shape = ArrowShapeHarpoonRight
+	if _, y := HarpoonArrowHead(P(10, 0), P(1, 0), style).PointOf(1); math.Abs(y+2) > 1e-9 {
+		t.Errorf("right harpoon barb at y = %g, want -2", y)
+	}
+
+	// Through the path's style, at the end of a path going up
+	p := straightPath([]Point{{0, 0}, {0, 10}}, false)
+	p.Style.Arrow = ArrowStyle{End: true, Shape: ArrowShapeHarpoonLeft}
+	head := ArrowHeadEnd(p, 4, 60)
+	if x, _ := head.PointOf(1); x >= 0 {
+		t.Errorf("left barb of a head pointing up at x = %g, want < 0", x)
+	}
+}
+
+func TestArrowHeadDepth(t *testing.T) {
+	if d := ArrowHeadDepth(ArrowStyle{}); math.Abs(d-DefaultAHLength*math.Cos(DefaultAHAngle*math.Pi/360)) > 1e-12 {
+		t.Errorf("default depth = %g", d)
+	}
+	if d := ArrowHeadDepth(ArrowStyle{Length: 8, Angle: 120}); math.Abs(d-4) > 1e-12 {
+		t.Errorf("depth of 8 by 120 degrees = %g, want 4", d)
+	}
+	if d := ArrowHeadDepth(ArrowStyle{Shape: ArrowShapeHarpoonRight}); d != 0 {
+		t.Errorf("harpoon depth = %g, want 0", d)
+	}
+	custom := ArrowStyle{Shape: ArrowShapeHarpoonLeft, Head: TriangleArrowHead}
+	if d := ArrowHeadDepth(custom); d == 0 {
+		t.Error("a custom head is cut back like the triangle")
+	}
+}
@@ -0,0 +1,124 @@
+package mp
+
+import "math"
+
+// Arrowheads for paths drawn with a pen. The plain heads of ArrowHeadEnd
+// and ArrowHeadStart have the fixed size of Style.Arrow, which is right for
+// thin strokes but makes heads thinner than the shaft of a path stroked
+// with a broad (envelope) pen. MetaPost avoids this by filldrawing the head
+// with the same pen; here the head is scaled instead, so it stays a single
+// filled triangle.
+
+// PenWidthAcross returns the width of pen measured perpendicular to the
+// direction (dx, dy): the thickness of a straight stroke in that direction.
+// It returns 0 for a nil pen or a zero direction.
+func PenWidthAcross(pen *Pen, dx, dy Number) Number {
+	nx, ny, ok := unitVector(-dy, dx)
+	if !ok {
+		return 0
+	}
+	return penReach(pen, nx, ny) + penReach(pen, -nx, -ny)
+}
+
+// penReach returns how far pen extends from its center in the unit
+// direction (ux, uy).
+func penReach(pen *Pen, ux, uy Number) Number {
+	if pen == nil || pen.Head == nil {
+		return 0
+	}
+	h := pen.Head
+	if pen.Elliptical {
+		// The pen is FullCircle (diameter 1) transformed by T, see PenDot;
+		// its support function in direction u is |Tᵀu|/2.
+		a := (h.LeftX-h.XCoord)*ux + (h.LeftY-h.YCoord)*uy
+		b := (h.RightX-h.XCoord)*ux + (h.RightY-h.YCoord)*uy
+		return math.Hypot(a, b) / 2
+	}
+	reach := math.Inf(-1)
+	for _, pt := range penPoints(pen) {
+		reach = math.Max(reach, pt[0]*ux+pt[1]*uy)
+	}
+	if math.IsInf(reach, -1) {
+		return 0
+	}
+	return reach
+}
+
+// EnvelopeArrowHeads returns the arrowheads that Style.Arrow asks for on p
+// when p is drawn with its pen, e.g. as an envelope. Each head is scaled by
+// the pen's width across the path at that end (if it is wider than 1), and
+// its tip is moved forward to where the pen reaches past the end, so the
+// head covers the end of the stroke. The heads are counterclockwise and
+// filled with the stroke color. Without a pen the result equals
+// ArrowHeadEnd and ArrowHeadStart.
+func EnvelopeArrowHeads(p *Path) []*Path {
+	if p == nil || p.Head == nil || p.Head.LType != KnotEndpoint {
+		return nil
+	}
+	length, angle := p.Style.Arrow.Length, p.Style.Arrow.Angle
+	if length <= 0 {
+		length = DefaultAHLength
+	}
+	if angle <= 0 {
+		angle = DefaultAHAngle
+	}
+	n := Number(p.PathLength())
+	head := func(x, y, dx, dy Number) *Path {
+		scale := math.Max(1, PenWidthAcross(p.Style.Pen, dx, dy))
+		reach := penReach(p.Style.Pen, dx, dy)
+		h := makeArrowHead(p.Style.Arrow, x+reach*dx, y+reach*dy, dx, dy, length*scale, angle)
+		if h == nil {
+			return nil
+		}
+		if flat, _ := flattenPath(h, 1); polygonArea(flat) < 0 {
+			h = h.Reversed()
+		}
+		h.Style.Fill = p.Style.Stroke
+		h.Style.Stroke = ColorCSS("none")
+		return h
+	}
+	var heads []*Path
+	if p.Style.Arrow.End {
+		x, y := p.PointOf(n)
+		if dx, dy := incomingDirection(p, n); dx != 0 || dy != 0 {
+			if h := head(x, y, dx, dy); h != nil {
+				heads = append(heads, h)
+			}
+		}
+	}
+	if p.Style.Arrow.Start {
+		x, y := p.PointOf(0)
+		if dx, dy := outgoingDirection(p, 0); dx != 0 || dy != 0 {
+			if h := head(x, y, -dx, -dy); h != nil {
+				heads = append(heads, h)
+			}
+		}
+	}
+	return heads
+}
+
+// JoinedArrowOutline returns the envelope of p and its arrowheads (see
+// EnvelopeArrowHeads) as one MultiPath filled with the stroke color. All
+// parts run counterclockwise, so with the nonzero fill rule the heads and
+// the stroke form a single filled outline without seams; this is what
+// renderers draw for ArrowStyle.Joined. It returns nil if p has no
+// envelope.
+func JoinedArrowOutline(p *Path) *MultiPath {
+	if p == nil || p.Envelope == nil || p.Envelope.Head == nil {
+		return nil
+	}
+	env := p.Envelope.Copy()
+	if flat, _ := flattenPath(env, 4); polygonArea(flat) < 0 {
+		env = env.Reversed()
+	}
+	m := NewMultiPath(env)
+	for _, h := range EnvelopeArrowHeads(p) {
+		m.Append(h)
+	}
+	m.Style = p.Style
+	m.Style.Arrow = ArrowStyle{}
+	m.Style.Pen = nil
+	m.Style.Fill = p.Style.Stroke
+	m.Style.Stroke = ColorCSS("none")
+	return m
+}
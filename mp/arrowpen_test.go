@@ -0,0 +1,90 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPenWidthAcross(t *testing.T) {
+	if w := PenWidthAcross(PenSquare(3), 1, 0); math.Abs(w-3) > 1e-9 {
+		t.Errorf("square pen across x = %g, want 3", w)
+	}
+	if w := PenWidthAcross(PenSquare(3), 1, 1); math.Abs(w-3*math.Sqrt2) > 1e-9 {
+		t.Errorf("square pen across the diagonal = %g, want %g", w, 3*math.Sqrt2)
+	}
+	if w := PenWidthAcross(PenCircle(2), 0, 1); math.Abs(w-2) > 1e-9 {
+		t.Errorf("circle pen = %g, want 2", w)
+	}
+	if w := PenWidthAcross(nil, 1, 0); w != 0 {
+		t.Errorf("nil pen = %g, want 0", w)
+	}
+}
+
+func TestEnvelopeArrowHeads(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(50, 0)}, false)
+	p.Style.Arrow = ArrowStyle{End: true, Start: true}
+	p.Style.Stroke = ColorCSS("blue")
+
+	// Without a pen the heads are the plain ones.
+	heads := EnvelopeArrowHeads(p)
+	if len(heads) != 2 {
+		t.Fatalf("got %d heads, want 2", len(heads))
+	}
+	_, minY, maxX, maxY := PathBBox(heads[0])
+	if maxX != 50 || math.Abs((maxY-minY)-2*DefaultAHLength*math.Sin(DefaultAHAngle*math.Pi/360)) > 1e-9 {
+		t.Errorf("plain end head bbox: maxX %g, height %g", maxX, maxY-minY)
+	}
+
+	// A square pen of width 3 triples the head and moves the tip to where
+	// the pen reaches past the end.
+	p.Style.Pen = PenSquare(3)
+	heads = EnvelopeArrowHeads(p)
+	minX, minY, maxX, maxY := PathBBox(heads[0])
+	if math.Abs(maxX-51.5) > 1e-9 {
+		t.Errorf("end tip at x = %g, want 51.5", maxX)
+	}
+	if want := 3 * DefaultAHLength * math.Cos(DefaultAHAngle*math.Pi/360); math.Abs((maxX-minX)-want) > 1e-9 {
+		t.Errorf("head length %g, want %g", maxX-minX, want)
+	}
+	if maxY-minY <= 3 {
+		t.Errorf("head (height %g) should be wider than the shaft", maxY-minY)
+	}
+	if minX, _, _, _ := PathBBox(heads[1]); math.Abs(minX+1.5) > 1e-9 {
+		t.Errorf("start tip at x = %g, want -1.5", minX)
+	}
+	for i, h := range heads {
+		if flat, _ := flattenPath(h, 1); polygonArea(flat) <= 0 {
+			t.Errorf("head %d is not counterclockwise", i)
+		}
+		if h.Style.Fill.CSS() != "blue" {
+			t.Errorf("head %d fill = %q, want blue", i, h.Style.Fill.CSS())
+		}
+	}
+}
+
+func TestJoinedArrowOutline(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(50, 0)}, false)
+	if JoinedArrowOutline(p) != nil {
+		t.Error("a path without envelope has no joined outline")
+	}
+	p.Style.Arrow = ArrowStyle{End: true}
+	p.Style.Pen = PenSquare(3)
+	p.Style.Stroke = ColorCSS("black")
+	e := NewEngine()
+	e.AddPath(p)
+	if err := e.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	m := JoinedArrowOutline(p)
+	if m == nil || len(m.Parts) != 2 {
+		t.Fatalf("want envelope and one head, got %v", m)
+	}
+	for i, part := range m.Parts {
+		if flat, _ := flattenPath(part, 4); polygonArea(flat) <= 0 {
+			t.Errorf("part %d is not counterclockwise", i)
+		}
+	}
+	if m.Style.Fill.CSS() != "black" || m.Style.Stroke.CSS() != "none" || m.Style.Arrow.End {
+		t.Errorf("unexpected style %+v", m.Style)
+	}
+}
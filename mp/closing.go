@@ -0,0 +1,50 @@
+package mp
+
+// ExpandClosingStyle returns the paths that draw the cycle p when its
+// closing segment has a style of its own (Style.Closing): a fill-only copy
+// of the whole cycle if it is filled, the open path along all other
+// segments with p's style, and the closing segment with Style.Closing
+// merged over it, left out if that has no stroke ("none"). The region is
+// thus filled as before while its last edge can be dashed, colored
+// differently or hidden. Without a closing style, or for open paths, it
+// returns p itself. The corner at the start is drawn with line caps
+// instead of a join. Renderers call it for every path they draw.
+//
+// Example:
+//
+//	// an open region that is still filled to its implied closing edge
+//	p.Style.Closing = &mp.Style{Stroke: mp.ColorCSS("none")}
+func ExpandClosingStyle(p *Path) []*Path {
+	if p == nil || p.Head == nil || p.Style.Closing == nil || p.Head.LType == KnotEndpoint {
+		return []*Path{p}
+	}
+	n := Number(p.PathLength())
+	closing := p.Style.Merge(*p.Style.Closing)
+	body := p.Style
+	body.Closing = nil
+	body.Fill = Color{}
+
+	var out []*Path
+	if fill := p.Style.Fill.CSS(); fill != "" && fill != "none" {
+		f := p.Copy()
+		f.Style.Closing = nil
+		f.Style.Stroke = ColorCSS("none")
+		f.Style.Arrow = ArrowStyle{}
+		f.Envelope = nil
+		out = append(out, f)
+	}
+	if n >= 2 {
+		q := p.Subpath(0, n-1)
+		q.Style = body
+		out = append(out, q)
+	}
+	if closing.Stroke.CSS() != "none" {
+		q := p.Subpath(n-1, n)
+		q.Style = closing
+		q.Style.Closing = nil
+		q.Style.Fill = Color{}
+		q.Style.Arrow = ArrowStyle{}
+		out = append(out, q)
+	}
+	return out
+}
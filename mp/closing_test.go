@@ -0,0 +1,43 @@
+package mp
+
+import "testing"
+
+func TestExpandClosingStyle(t *testing.T) {
+	p := makeSquareCycle()
+	p.Style = Style{Stroke: ColorCSS("black"), Fill: ColorCSS("yellow"), StrokeWidth: 1}
+	if got := ExpandClosingStyle(p); len(got) != 1 || got[0] != p {
+		t.Fatalf("without closing style got %d paths, want p itself", len(got))
+	}
+
+	p.Style.Closing = &Style{Stroke: ColorCSS("red"), Dash: DashEvenly()}
+	got := ExpandClosingStyle(p)
+	if len(got) != 3 {
+		t.Fatalf("got %d paths, want fill, body and closing segment", len(got))
+	}
+	fill, body, closing := got[0], got[1], got[2]
+	if fill.Head.LType == KnotEndpoint || fill.Style.Fill.CSS() != "yellow" || fill.Style.Stroke.CSS() != "none" {
+		t.Errorf("fill = %+v, want the filled cycle without stroke", fill.Style)
+	}
+	if body.PathLength() != 3 || body.Style.Stroke.CSS() != "black" || body.Style.Fill.CSS() != "" || body.Style.Closing != nil {
+		t.Errorf("body has %d segments and style %+v", body.PathLength(), body.Style)
+	}
+	if x, y := body.PointOf(3); x != 0 || y != 100 {
+		t.Errorf("body ends at (%g, %g), want (0, 100)", x, y)
+	}
+	if closing.PathLength() != 1 || closing.Style.Stroke.CSS() != "red" || closing.Style.Dash == nil || closing.Style.StrokeWidth != 1 {
+		t.Errorf("closing segment has %d segments and style %+v", closing.PathLength(), closing.Style)
+	}
+	if x, y := closing.PointOf(1); x != 0 || y != 0 {
+		t.Errorf("closing segment ends at (%g, %g), want (0, 0)", x, y)
+	}
+
+	p.Style.Closing = &Style{Stroke: ColorCSS("none")}
+	if got := ExpandClosingStyle(p); len(got) != 2 {
+		t.Errorf("hidden closing segment: got %d paths, want fill and body", len(got))
+	}
+	open := makeMultiSegmentPath()
+	open.Style.Closing = &Style{Stroke: ColorCSS("none")}
+	if got := ExpandClosingStyle(open); len(got) != 1 || got[0] != open {
+		t.Errorf("open path was expanded")
+	}
+}
@@ -23,6 +23,34 @@ func (c Color) Opacity() (float64, bool) {
 	return c.opacity, true
 }
 
+// RGB returns the red, green and blue components of c in [0,1], for
+// backends that need numbers rather than CSS, such as PostScript. It
+// understands the colors made with ColorRGB, ColorRGBA, ColorGray and
+// ColorCMYK, hex colors and the basic CSS color names (black, white, red,
+// green, blue, gray, …); ok is false for other colors, for "none" and for
+// the zero Color.
+func (c Color) RGB() (r, g, b float64, ok bool) {
+	if r, g, b, ok := colorComponents(c); ok {
+		return r, g, b, true
+	}
+	if v, ok := basicColors[strings.ToLower(strings.TrimSpace(c.css))]; ok {
+		return float64(v>>16) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255, true
+	}
+	return 0, 0, 0, false
+}
+
+// basicColors are the CSS basic color keywords and a few common extended
+// ones, as 0xRRGGBB.
+var basicColors = map[string]uint32{
+	"black": 0x000000, "silver": 0xc0c0c0, "gray": 0x808080, "grey": 0x808080,
+	"white": 0xffffff, "maroon": 0x800000, "red": 0xff0000, "purple": 0x800080,
+	"fuchsia": 0xff00ff, "magenta": 0xff00ff, "green": 0x008000, "lime": 0x00ff00,
+	"olive": 0x808000, "yellow": 0xffff00, "navy": 0x000080, "blue": 0x0000ff,
+	"teal": 0x008080, "aqua": 0x00ffff, "cyan": 0x00ffff, "orange": 0xffa500,
+	"darkgray": 0xa9a9a9, "darkgrey": 0xa9a9a9, "lightgray": 0xd3d3d3, "lightgrey": 0xd3d3d3,
+	"brown": 0xa52a2a, "pink": 0xffc0cb,
+}
+
 // ColorCSS uses the provided CSS color string verbatim.
 func ColorCSS(css string) Color {
 	if strings.HasPrefix(css, "#") {
@@ -0,0 +1,223 @@
+package mp
+
+import "math"
+
+// DefaultContourGrid is the number of grid cells per axis used by
+// ContourLines.
+const DefaultContourGrid = 64
+
+// Contour is one connected contour line of a scalar field at Level.
+type Contour struct {
+	Level Number
+	Path  *Path
+}
+
+// ContourLines returns the contour lines of f inside the rectangle
+// (minX, minY)-(maxX, maxY) for each of the given levels. The field is
+// sampled on a DefaultContourGrid × DefaultContourGrid grid, traced with
+// marching squares and each resulting polyline is smoothed into a Hobby
+// curve through its points (a cycle where the contour closes). Contours
+// are returned grouped by level in the order of levels.
+//
+// Example:
+//
+//	f := func(x, y Number) Number { return x*x + y*y }
+//	for _, c := range mp.ContourLines(f, -2, -2, 2, 2, []Number{1, 2, 3}) {
+//		pic.AddPath(c.Path)  // circles of radius 1, √2, √3
+//	}
+func ContourLines(f func(x, y Number) Number, minX, minY, maxX, maxY Number, levels []Number) []Contour {
+	return ContourLinesGrid(f, minX, minY, maxX, maxY, levels, DefaultContourGrid, DefaultContourGrid)
+}
+
+// ContourLinesGrid is like ContourLines with an explicit number of grid
+// cells per axis. Cells with a NaN corner value are skipped.
+func ContourLinesGrid(f func(x, y Number) Number, minX, minY, maxX, maxY Number, levels []Number, nx, ny int) []Contour {
+	if nx < 1 || ny < 1 || maxX <= minX || maxY <= minY {
+		return nil
+	}
+	dx, dy := (maxX-minX)/Number(nx), (maxY-minY)/Number(ny)
+	values := make([][]Number, ny+1)
+	for j := range values {
+		values[j] = make([]Number, nx+1)
+		for i := range values[j] {
+			values[j][i] = f(minX+Number(i)*dx, minY+Number(j)*dy)
+		}
+	}
+	var res []Contour
+	for _, level := range levels {
+		for _, pts := range marchingSquares(values, level, minX, minY, dx, dy) {
+			closed := len(pts) > 2 && pts[0] == pts[len(pts)-1]
+			if closed {
+				pts = pts[:len(pts)-1]
+			}
+			pts = dedupePoints(pts, closed)
+			if len(pts) < 2 || (closed && len(pts) < 3) {
+				continue
+			}
+			res = append(res, Contour{Level: level, Path: smoothPath(pts, closed)})
+		}
+	}
+	return res
+}
+
+// marchingSquares returns the polylines where the sampled field crosses
+// level. Closed polylines repeat their first point at the end.
+func marchingSquares(values [][]Number, level, minX, minY, dx, dy Number) [][]Point {
+	ny, nx := len(values)-1, len(values[0])-1
+	// Crossings are identified by the grid edge they lie on, so the two
+	// cells sharing an edge produce the very same point.
+	hKey := func(i, j int) int { return 2 * (j*(nx+1) + i) }
+	vKey := func(i, j int) int { return 2*(j*(nx+1)+i) + 1 }
+	points := make(map[int]Point)
+	crossing := func(key int, x0, y0, v0, x1, y1, v1 Number) int {
+		if _, ok := points[key]; !ok {
+			t := (level - v0) / (v1 - v0)
+			points[key] = P(x0+t*(x1-x0), y0+t*(y1-y0))
+		}
+		return key
+	}
+
+	var segs [][2]int
+	for j := 0; j < ny; j++ {
+		for i := 0; i < nx; i++ {
+			v := [4]Number{values[j][i], values[j][i+1], values[j+1][i+1], values[j+1][i]}
+			if math.IsNaN(v[0]) || math.IsNaN(v[1]) || math.IsNaN(v[2]) || math.IsNaN(v[3]) {
+				continue
+			}
+			x0, y0 := minX+Number(i)*dx, minY+Number(j)*dy
+			x1, y1 := x0+dx, y0+dy
+			above := [4]bool{v[0] >= level, v[1] >= level, v[2] >= level, v[3] >= level}
+			// Crossed edges: bottom, right, top, left.
+			var e [4]int
+			var crossed [4]bool
+			if above[0] != above[1] {
+				e[0], crossed[0] = crossing(hKey(i, j), x0, y0, v[0], x1, y0, v[1]), true
+			}
+			if above[1] != above[2] {
+				e[1], crossed[1] = crossing(vKey(i+1, j), x1, y0, v[1], x1, y1, v[2]), true
+			}
+			if above[3] != above[2] {
+				e[2], crossed[2] = crossing(hKey(i, j+1), x0, y1, v[3], x1, y1, v[2]), true
+			}
+			if above[0] != above[3] {
+				e[3], crossed[3] = crossing(vKey(i, j), x0, y0, v[0], x0, y1, v[3]), true
+			}
+			var ends []int
+			for k := 0; k < 4; k++ {
+				if crossed[k] {
+					ends = append(ends, e[k])
+				}
+			}
+			switch len(ends) {
+			case 2:
+				segs = append(segs, [2]int{ends[0], ends[1]})
+			case 4:
+				// Saddle: decide by the cell center which diagonal corners
+				// are connected.
+				center := (v[0] + v[1] + v[2] + v[3]) / 4
+				if (center >= level) == above[0] {
+					segs = append(segs, [2]int{e[0], e[1]}, [2]int{e[2], e[3]})
+				} else {
+					segs = append(segs, [2]int{e[0], e[3]}, [2]int{e[1], e[2]})
+				}
+			}
+		}
+	}
+
+	// Chain segments into polylines, open chains first.
+	byKey := make(map[int][]int)
+	for s, seg := range segs {
+		byKey[seg[0]] = append(byKey[seg[0]], s)
+		byKey[seg[1]] = append(byKey[seg[1]], s)
+	}
+	used := make([]bool, len(segs))
+	walk := func(start int) []Point {
+		pts := []Point{points[start]}
+		key := start
+		for {
+			next := -1
+			for _, s := range byKey[key] {
+				if !used[s] {
+					next = s
+					break
+				}
+			}
+			if next < 0 {
+				return pts
+			}
+			used[next] = true
+			if segs[next][0] == key {
+				key = segs[next][1]
+			} else {
+				key = segs[next][0]
+			}
+			pts = append(pts, points[key])
+		}
+	}
+	var lines [][]Point
+	for s, seg := range segs {
+		if used[s] {
+			continue
+		}
+		for _, key := range seg {
+			if len(byKey[key]) == 1 {
+				lines = append(lines, walk(key))
+				break
+			}
+		}
+	}
+	for s, seg := range segs {
+		if !used[s] {
+			lines = append(lines, walk(seg[0]))
+		}
+	}
+	return lines
+}
+
+// dedupePoints removes consecutive (and, for closed lines, wrap-around)
+// duplicates, which occur where a contour passes exactly through a grid
+// point.
+func dedupePoints(pts []Point, closed bool) []Point {
+	const eps = 1e-9
+	out := pts[:0:0]
+	for _, pt := range pts {
+		if n := len(out); n > 0 && math.Abs(out[n-1].X-pt.X) < eps && math.Abs(out[n-1].Y-pt.Y) < eps {
+			continue
+		}
+		out = append(out, pt)
+	}
+	if closed && len(out) > 1 {
+		first, last := out[0], out[len(out)-1]
+		if math.Abs(first.X-last.X) < eps && math.Abs(first.Y-last.Y) < eps {
+			out = out[:len(out)-1]
+		}
+	}
+	return out
+}
+
+// smoothPath returns the Hobby curve through pts (MetaPost's
+// "z0..z1..z2.." with tension 1 and curl 1 at open ends), closed into a
+// cycle if requested. If the solver fails the straight polyline is used.
+func smoothPath(pts []Point, cycle bool) *Path {
+	p := NewPath()
+	for _, pt := range pts {
+		k := NewKnot()
+		k.XCoord, k.YCoord = pt.X, pt.Y
+		k.LType, k.RType = KnotOpen, KnotOpen
+		k.LeftY, k.RightY = unity, unity
+		p.Append(k)
+	}
+	if !cycle {
+		first, last := p.Head, p.Head.Prev
+		first.LType = KnotEndpoint
+		first.RType, first.RightX = KnotCurl, unity
+		last.LType, last.LeftX = KnotCurl, unity
+		last.RType = KnotEndpoint
+	}
+	e := NewEngine()
+	e.AddPath(p)
+	if err := e.Solve(); err != nil {
+		return straightPath(pts, cycle)
+	}
+	return p
+}
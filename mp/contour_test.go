@@ -0,0 +1,46 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestContourLinesCircle(t *testing.T) {
+	f := func(x, y Number) Number { return x*x + y*y }
+	contours := ContourLines(f, -2, -2, 2, 2, []Number{1, 2})
+	if len(contours) != 2 {
+		t.Fatalf("expected 2 contours, got %d", len(contours))
+	}
+	for _, c := range contours {
+		p := c.Path
+		if p.Head.LType == KnotEndpoint {
+			t.Errorf("level %g: contour should be a cycle", c.Level)
+		}
+		r := math.Sqrt(c.Level)
+		n := Number(p.PathLength())
+		for i := 0; i < 50; i++ {
+			x, y := p.PointOf(n * Number(i) / 50)
+			if d := math.Hypot(x, y); math.Abs(d-r) > 0.01 {
+				t.Fatalf("level %g: point at distance %g, want %g", c.Level, d, r)
+			}
+		}
+	}
+}
+
+func TestContourLinesOpen(t *testing.T) {
+	f := func(x, y Number) Number { return x + 0.5*y }
+	contours := ContourLinesGrid(f, 0, 0, 4, 4, []Number{2}, 8, 8)
+	if len(contours) != 1 {
+		t.Fatalf("expected 1 contour, got %d", len(contours))
+	}
+	p := contours[0].Path
+	if p.Head.LType != KnotEndpoint {
+		t.Fatal("contour crossing the boundary should be open")
+	}
+	for _, tt := range []Number{0, 1.5, Number(p.PathLength())} {
+		x, y := p.PointOf(tt)
+		if math.Abs(x+0.5*y-2) > 1e-6 {
+			t.Errorf("point (%g,%g) off the level line", x, y)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package mp
+
+import "math"
+
+// pathSegments returns the control points of the segments of p, including
+// the closing segment of a cycle.
+func pathSegments(p *Path) [][4]Point {
+	if p == nil || p.Head == nil {
+		return nil
+	}
+	var segs [][4]Point
+	k := p.Head
+	for k.Next != nil && k.RType != KnotEndpoint {
+		n := k.Next
+		segs = append(segs, [4]Point{P(k.XCoord, k.YCoord), P(k.RightX, k.RightY), P(n.LeftX, n.LeftY), P(n.XCoord, n.YCoord)})
+		k = n
+		if k == p.Head {
+			break
+		}
+	}
+	return segs
+}
+
+// segmentsPath is the inverse of pathSegments: it returns the explicit
+// path through the consecutive segments segs, which must not be empty. For
+// a cycle the last segment ends where the first starts.
+func segmentsPath(segs [][4]Point, cycle bool) *Path {
+	q := NewPath()
+	first := segs[0][0]
+	q.Append(&Knot{XCoord: first.X, YCoord: first.Y, LeftX: first.X, LeftY: first.Y, LType: KnotEndpoint, RType: KnotExplicit})
+	for i, c := range segs {
+		last := q.Head.Prev
+		last.RightX, last.RightY = c[1].X, c[1].Y
+		if cycle && i == len(segs)-1 {
+			q.Head.LeftX, q.Head.LeftY = c[2].X, c[2].Y
+			q.Head.LType = KnotExplicit
+			break
+		}
+		q.Append(&Knot{XCoord: c[3].X, YCoord: c[3].Y, LeftX: c[2].X, LeftY: c[2].Y, RightX: c[3].X, RightY: c[3].Y, LType: KnotExplicit, RType: KnotExplicit})
+	}
+	if !cycle {
+		q.Head.Prev.RType = KnotEndpoint
+	}
+	return q
+}
+
+// segmentAt returns the segment containing time t and the time within
+// it. Times wrap on cycles and are clamped on open paths.
+func segmentAt(p *Path, segs [][4]Point, t Number) (c [4]Point, u Number) {
+	n := len(segs)
+	if p.Head.LType != KnotEndpoint {
+		t = math.Mod(t, Number(n))
+		if t < 0 {
+			t += Number(n)
+		}
+	} else {
+		t = math.Max(0, math.Min(t, Number(n)))
+	}
+	i := int(math.Floor(t))
+	if i >= n {
+		i = n - 1
+	}
+	return segs[i], t - Number(i)
+}
+
+// cubicSecondDerivative returns the second derivative of the cubic c at t.
+func cubicSecondDerivative(c [4]Point, t Number) (Number, Number) {
+	u := 1 - t
+	ddx := 6 * (u*(c[2].X-2*c[1].X+c[0].X) + t*(c[3].X-2*c[2].X+c[1].X))
+	ddy := 6 * (u*(c[2].Y-2*c[1].Y+c[0].Y) + t*(c[3].Y-2*c[2].Y+c[1].Y))
+	return ddx, ddy
+}
+
+// cubicCurvature returns the signed curvature of c at t, 0 where the
+// curve has no direction.
+func cubicCurvature(c [4]Point, t Number) Number {
+	dx, dy := cubicDerivative(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, t)
+	ddx, ddy := cubicSecondDerivative(c, t)
+	speed := math.Hypot(dx, dy)
+	if speed < 1e-12 {
+		return 0
+	}
+	return (dx*ddy - dy*ddx) / (speed * speed * speed)
+}
+
+// CurvatureOf returns the signed curvature of p at time t, the reciprocal
+// of the radius of the osculating circle: positive where the path turns
+// left (counterclockwise), negative where it turns right. It is 0 on
+// straight parts and where the path has no direction (e.g. at a cusp).
+// Times wrap on cycles and are clamped to the ends of open paths.
+func (p *Path) CurvatureOf(t Number) Number {
+	segs := pathSegments(p)
+	if len(segs) == 0 {
+		return 0
+	}
+	c, u := segmentAt(p, segs, t)
+	return cubicCurvature(c, u)
+}
+
+// CurvatureComb returns the curvature comb of p, the plot used to judge
+// the fairness of curves in CAD and type design: at samples points per
+// segment a spike leaves the path along the normal, on the convex side,
+// with a length of scale times the curvature there, and a polyline joins
+// the tips of the spikes. A fair curve has a comb without sudden jumps or
+// wiggles. The spikes come first in the result, the polyline last; the
+// result has no style. It returns nil for paths without segments.
+//
+// Example:
+//
+//	comb := mp.CurvatureComb(p, 200, 16)
+//	comb.Style = mp.NewStyle(mp.WithStroke(mp.ColorCSS("orange")), mp.WithStrokeWidth(0.2))
+//	pic.AddMultiPath(comb)
+func CurvatureComb(p *Path, scale Number, samples int) *MultiPath {
+	segs := pathSegments(p)
+	if len(segs) == 0 {
+		return nil
+	}
+	if samples < 1 {
+		samples = 1
+	}
+	cycle := p.Head.LType != KnotEndpoint
+	m := NewMultiPath()
+	var tips []Point
+	for i, c := range segs {
+		for j := 0; j <= samples; j++ {
+			if j == 0 && i > 0 || j == samples && cycle && i == len(segs)-1 {
+				continue // shared with the neighbouring segment
+			}
+			t := Number(j) / Number(samples)
+			dx, dy := cubicDerivative(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, t)
+			nx, ny, ok := unitVector(-dy, dx)
+			if !ok {
+				continue
+			}
+			x, y := evalCubic(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, t)
+			// The center of curvature lies on the left for positive
+			// curvature; the comb is drawn on the other side.
+			l := -scale * cubicCurvature(c, t)
+			tip := P(x+l*nx, y+l*ny)
+			m.Append(straightPath([]Point{P(x, y), tip}, false))
+			tips = append(tips, tip)
+		}
+	}
+	if len(tips) > 1 {
+		m.Append(straightPath(tips, cycle))
+	}
+	return m
+}
+
+// Fairness metrics, for pipelines that choose tensions or reject curves
+// automatically.
+
+// curvatureSamples is the number of intervals per segment used to
+// integrate and search the curvature numerically.
+const curvatureSamples = 64
+
+// BendingEnergy returns the bending energy of p, the integral of the
+// squared curvature over the arc length (∫κ² ds). It is the usual measure
+// of fairness: among curves through the same points, the one with the
+// smaller energy bends more evenly. It is invariant under translation and
+// rotation and scales with 1/s under scaling by s. Straight paths have
+// energy 0.
+func (p *Path) BendingEnergy() Number {
+	var e Number
+	for _, c := range pathSegments(p) {
+		f := func(t Number) Number {
+			dx, dy := cubicDerivative(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, t)
+			k := cubicCurvature(c, t)
+			return k * k * math.Hypot(dx, dy)
+		}
+		// Composite Simpson rule
+		h := 1.0 / curvatureSamples
+		sum := f(0) + f(1)
+		for i := 1; i < curvatureSamples; i++ {
+			w := 2.0
+			if i%2 == 1 {
+				w = 4
+			}
+			sum += w * f(Number(i)*h)
+		}
+		e += sum * h / 3
+	}
+	return e
+}
+
+// MaxCurvature returns the curvature of p with the largest magnitude and
+// the time where it occurs; k is signed like CurvatureOf. Both are 0 for
+// paths without segments.
+func (p *Path) MaxCurvature() (k, t Number) {
+	abs := func(c [4]Point, u Number) Number { return math.Abs(cubicCurvature(c, u)) }
+	for i, c := range pathSegments(p) {
+		best, bu := -1.0, 0.0
+		for j := 0; j <= curvatureSamples; j++ {
+			u := Number(j) / curvatureSamples
+			if a := abs(c, u); a > best {
+				best, bu = a, u
+			}
+		}
+		// Golden-section search around the best sample
+		lo := math.Max(0, bu-1.0/curvatureSamples)
+		hi := math.Min(1, bu+1.0/curvatureSamples)
+		const g = 0.6180339887498949
+		for range 40 {
+			a, b := hi-g*(hi-lo), lo+g*(hi-lo)
+			if abs(c, a) > abs(c, b) {
+				hi = b
+			} else {
+				lo = a
+			}
+		}
+		if u := (lo + hi) / 2; abs(c, u) > best {
+			bu = u
+		}
+		if ck := cubicCurvature(c, bu); math.Abs(ck) > math.Abs(k) || i == 0 {
+			k, t = ck, Number(i)+bu
+		}
+	}
+	return k, t
+}
+
+// Inflections returns the times at which the curvature of p changes sign,
+// in increasing order: inside segments, where the curve turns from left
+// to right or back, and at knots where the neighbouring segments bend to
+// different sides. Straight segments have none. The number of inflections
+// in segment i is the number of times in [i, i+1).
+func (p *Path) Inflections() []Number {
+	segs := pathSegments(p)
+	if len(segs) == 0 {
+		return nil
+	}
+	const eps = 1e-12
+	changes := func(a, b [4]Point) bool {
+		k0, k1 := cubicCurvature(a, 1), cubicCurvature(b, 0)
+		return math.Abs(k0) > eps && math.Abs(k1) > eps && (k0 < 0) != (k1 < 0)
+	}
+	var out []Number
+	n := len(segs)
+	if p.Head.LType != KnotEndpoint && changes(segs[n-1], segs[0]) {
+		out = append(out, 0)
+	}
+	for i, c := range segs {
+		for _, u := range cubicInflections(c) {
+			out = append(out, Number(i)+u)
+		}
+		if i+1 < n && changes(c, segs[i+1]) {
+			out = append(out, Number(i+1))
+		}
+	}
+	return out
+}
+
+// cubicInflections returns the times in (0,1), in increasing order, at
+// which the curvature of c changes sign: the simple roots of the cross
+// product of its first and second derivatives.
+func cubicInflections(c [4]Point) []Number {
+	// B(t) = At³ + Bt² + Ct + D, so B'×B'' = -6(A×B)t² + 6(C×A)t + 2(C×B).
+	ax := -c[0].X + 3*c[1].X - 3*c[2].X + c[3].X
+	ay := -c[0].Y + 3*c[1].Y - 3*c[2].Y + c[3].Y
+	bx := 3*c[0].X - 6*c[1].X + 3*c[2].X
+	by := 3*c[0].Y - 6*c[1].Y + 3*c[2].Y
+	cx := 3 * (c[1].X - c[0].X)
+	cy := 3 * (c[1].Y - c[0].Y)
+	size := 0.0
+	for _, q := range c[1:] {
+		size = math.Max(size, math.Hypot(q.X-c[0].X, q.Y-c[0].Y))
+	}
+	if size == 0 {
+		return nil
+	}
+	s := size * size
+	qa := -6 * (ax*by - ay*bx) / s
+	qb := 6 * (cx*ay - cy*ax) / s
+	qc := 2 * (cx*by - cy*bx) / s
+	const eps = 1e-9
+	if math.Abs(qa) < eps && math.Abs(qb) < eps {
+		return nil // straight, or curving to one side only
+	}
+	var roots []Number
+	if math.Abs(qa) < eps {
+		roots = []Number{-qc / qb}
+	} else {
+		d := qb*qb - 4*qa*qc
+		if d <= eps*eps {
+			return nil // a double root touches zero without a sign change
+		}
+		sq := math.Sqrt(d)
+		r1, r2 := (-qb-sq)/(2*qa), (-qb+sq)/(2*qa)
+		roots = []Number{math.Min(r1, r2), math.Max(r1, r2)}
+	}
+	var out []Number
+	for _, r := range roots {
+		if r > eps && r < 1-eps {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCurvatureOf(t *testing.T) {
+	circle := Scaled(20).ApplyToPath(FullCircle()) // radius 10
+	for _, tt := range []Number{0, 0.5, 3.25, 7.9, 8.5} {
+		if k := circle.CurvatureOf(tt); math.Abs(k-0.1) > 0.001 {
+			t.Errorf("curvature of circle at %g = %g, want 0.1", tt, k)
+		}
+	}
+	if k := circle.Reversed().CurvatureOf(1); math.Abs(k+0.1) > 0.001 {
+		t.Errorf("curvature of clockwise circle = %g, want -0.1", k)
+	}
+	if k := makeLinePath().CurvatureOf(0.5); k != 0 {
+		t.Errorf("curvature of line = %g, want 0", k)
+	}
+	if k := NewPath().CurvatureOf(0); k != 0 {
+		t.Errorf("curvature of empty path = %g", k)
+	}
+}
+
+func TestCurvatureComb(t *testing.T) {
+	circle := Scaled(20).ApplyToPath(FullCircle())
+	comb := CurvatureComb(circle, 50, 4)
+	// 8 segments × 4 samples, plus the closed polyline through the tips.
+	if len(comb.Parts) != 33 {
+		t.Fatalf("got %d parts, want 33", len(comb.Parts))
+	}
+	for i, spike := range comb.Parts[:32] {
+		x0, y0 := spike.PointOf(0)
+		x1, y1 := spike.PointOf(1)
+		// Spikes of length 50 × 0.1 point away from the center.
+		if r0, r1 := math.Hypot(x0, y0), math.Hypot(x1, y1); math.Abs(r1-r0-5) > 0.05 {
+			t.Errorf("spike %d runs from radius %g to %g", i, r0, r1)
+		}
+	}
+	if outline := comb.Parts[32]; outline.Head.LType == KnotEndpoint || outline.PathLength() != 32 {
+		t.Errorf("comb outline: cycle=%v, %d segments", outline.Head.LType != KnotEndpoint, outline.PathLength())
+	}
+
+	open := CurvatureComb(straightPath([]Point{P(0, 0), P(10, 0), P(10, 10)}, false), 10, 2)
+	if len(open.Parts) != 6 {
+		t.Errorf("open path comb has %d parts, want 5 spikes and the polyline", len(open.Parts))
+	}
+	if CurvatureComb(NewPath(), 1, 4) != nil {
+		t.Errorf("comb of empty path is not nil")
+	}
+}
+
+// cubicPath returns the open path through the given cubic segments, each
+// given by its two control points and end point.
+func cubicPath(start Point, segs ...[3]Point) *Path {
+	p := NewPath()
+	p.Append(&Knot{XCoord: start.X, YCoord: start.Y, LeftX: start.X, LeftY: start.Y, LType: KnotEndpoint, RType: KnotExplicit})
+	for _, s := range segs {
+		last := p.Head.Prev
+		last.RightX, last.RightY = s[0].X, s[0].Y
+		last.RType = KnotExplicit
+		p.Append(&Knot{XCoord: s[2].X, YCoord: s[2].Y, LeftX: s[1].X, LeftY: s[1].Y, RightX: s[2].X, RightY: s[2].Y, LType: KnotExplicit, RType: KnotEndpoint})
+	}
+	return p
+}
+
+func TestBendingEnergy(t *testing.T) {
+	circle := Scaled(20).ApplyToPath(FullCircle()) // radius 10
+	if e := circle.BendingEnergy(); math.Abs(e-2*math.Pi/10) > 0.001 {
+		t.Errorf("energy of circle = %g, want 2π/10", e)
+	}
+	if e := Scaled(40).ApplyToPath(FullCircle()).BendingEnergy(); math.Abs(e-2*math.Pi/20) > 0.001 {
+		t.Errorf("energy of larger circle = %g, want 2π/20", e)
+	}
+	if e := makeLinePath().BendingEnergy(); e != 0 {
+		t.Errorf("energy of line = %g, want 0", e)
+	}
+	flat := cubicPath(P(0, 0), [3]Point{P(10, 5), P(20, 5), P(30, 0)})
+	bent := cubicPath(P(0, 0), [3]Point{P(0, 30), P(30, 30), P(30, 0)})
+	if flat.BendingEnergy() >= bent.BendingEnergy() {
+		t.Errorf("flat arc has more energy (%g) than bent one (%g)", flat.BendingEnergy(), bent.BendingEnergy())
+	}
+}
+
+func TestMaxCurvature(t *testing.T) {
+	circle := Scaled(20).ApplyToPath(FullCircle())
+	if k, _ := circle.MaxCurvature(); math.Abs(k-0.1) > 0.001 {
+		t.Errorf("max curvature of circle = %g, want 0.1", k)
+	}
+	// A symmetric arch bends most at its middle.
+	arch := cubicPath(P(0, 0), [3]Point{P(0, 30), P(30, 30), P(30, 0)})
+	k, tt := arch.MaxCurvature()
+	if k >= 0 || math.Abs(tt-0.5) > 1e-6 {
+		t.Errorf("max curvature of arch = %g at %g, want negative at 0.5", k, tt)
+	}
+	if k, tt := NewPath().MaxCurvature(); k != 0 || tt != 0 {
+		t.Errorf("empty path: %g at %g", k, tt)
+	}
+}
+
+func TestInflections(t *testing.T) {
+	s := cubicPath(P(0, 0), [3]Point{P(10, 10), P(20, -10), P(30, 0)})
+	if got := s.Inflections(); len(got) != 1 || math.Abs(got[0]-0.5) > 1e-9 {
+		t.Errorf("S curve inflections = %v, want [0.5]", got)
+	}
+	// Left turn, then right turn: the sign changes at the knot.
+	knot := cubicPath(P(0, 0), [3]Point{P(5, 0), P(10, 5), P(10, 10)}, [3]Point{P(10, 15), P(15, 20), P(20, 20)})
+	if got := knot.Inflections(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("inflections at knot = %v, want [1]", got)
+	}
+	if got := Scaled(20).ApplyToPath(FullCircle()).Inflections(); len(got) != 0 {
+		t.Errorf("circle inflections = %v, want none", got)
+	}
+	if got := straightPath([]Point{P(0, 0), P(10, 0), P(10, 10)}, false).Inflections(); len(got) != 0 {
+		t.Errorf("polyline inflections = %v, want none", got)
+	}
+}
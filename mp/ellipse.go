@@ -0,0 +1,131 @@
+package mp
+
+import "math"
+
+// Closed-form arc lengths for circles and ellipses. FullCircle records that
+// its knots lie on a circle; transformations keep the record up to date, so
+// ArcLength and ArcTime of any transformed fullcircle measure the exact
+// ellipse instead of integrating its Bézier approximation. Both use the
+// same metric: the time of the ellipse point at angle θ is θ/45°, which
+// is the time of the knot there and, in between, within 2e-3 of the
+// Bézier time of the nearest point. Points stay those of the Bézier curve
+// that is drawn. The record is checked against the knots before each use
+// and ignored once the knots have been changed.
+
+// ellipseKnots is the number of knots of FullCircle, spaced at 45°.
+const ellipseKnots = 8
+
+// ellipseInfo describes the ellipse c + u cos θ + v sin θ through the knots
+// of a transformed fullcircle; knot k lies at θ = k·45°.
+type ellipseInfo struct {
+	cx, cy Number
+	ux, uy Number
+	vx, vy Number
+}
+
+// transformed returns the ellipse mapped by t.
+func (e *ellipseInfo) transformed(t Transform) *ellipseInfo {
+	cx, cy := t.ApplyToPoint(e.cx, e.cy)
+	return &ellipseInfo{
+		cx: cx, cy: cy,
+		ux: t.Txx*e.ux + t.Txy*e.uy, uy: t.Tyx*e.ux + t.Tyy*e.uy,
+		vx: t.Txx*e.vx + t.Txy*e.vy, vy: t.Tyx*e.vx + t.Tyy*e.vy,
+	}
+}
+
+func (e *ellipseInfo) point(theta Number) (Number, Number) {
+	c, s := math.Cos(theta), math.Sin(theta)
+	return e.cx + e.ux*c + e.vx*s, e.cy + e.uy*c + e.vy*s
+}
+
+// speed returns |dP/dθ| at theta.
+func (e *ellipseInfo) speed(theta Number) Number {
+	c, s := math.Cos(theta), math.Sin(theta)
+	return math.Hypot(-e.ux*s+e.vx*c, -e.uy*s+e.vy*c)
+}
+
+// gaussNodes and gaussWeights are the 10-point Gauss-Legendre rule on
+// [-1, 1] (positive half).
+var (
+	gaussNodes   = [5]Number{0.1488743389816312, 0.4333953941292472, 0.6794095682990244, 0.8650633666889845, 0.9739065285171717}
+	gaussWeights = [5]Number{0.2955242247147529, 0.2692667193099963, 0.2190863625159820, 0.1494513491505806, 0.0666713443086881}
+)
+
+// pieces returns the number of quadrature intervals per full turn, more
+// for eccentric ellipses whose speed varies quickly.
+func (e *ellipseInfo) pieces() int {
+	a := math.Hypot(e.ux, e.uy) + math.Hypot(e.vx, e.vy)
+	det := math.Abs(e.ux*e.vy - e.uy*e.vx)
+	if det == 0 {
+		return 256
+	}
+	// a²/det grows with the ratio of the axes.
+	return min(int(16*math.Ceil(math.Sqrt(a*a/det))), 256)
+}
+
+// arcLength returns the length of the ellipse between angles th0 <= th1.
+func (e *ellipseInfo) arcLength(th0, th1 Number) Number {
+	if th1 <= th0 {
+		return 0
+	}
+	step := 2 * math.Pi / Number(e.pieces())
+	total := Number(0)
+	for a := th0; a < th1; a += step {
+		b := math.Min(a+step, th1)
+		mid, half := (a+b)/2, (b-a)/2
+		sum := Number(0)
+		for i, x := range gaussNodes {
+			sum += gaussWeights[i] * (e.speed(mid-half*x) + e.speed(mid+half*x))
+		}
+		total += half * sum
+	}
+	return total
+}
+
+// arcTime returns the time at which the arc length of the ellipse from knot
+// 0 reaches arcLen, knot k being at time k. Negative lengths run backwards
+// and lengths beyond the perimeter wrap around, as ArcTime does on cycles.
+func (e *ellipseInfo) arcTime(arcLen Number) Number {
+	perimeter := e.arcLength(0, 2*math.Pi)
+	if perimeter <= 0 {
+		return 0
+	}
+	turns := math.Floor(arcLen / perimeter)
+	rest := arcLen - turns*perimeter
+	// Newton steps on the angle, starting from the circle's.
+	theta := 2 * math.Pi * rest / perimeter
+	for i := 0; i < 20; i++ {
+		diff := e.arcLength(0, theta) - rest
+		speed := e.speed(theta)
+		if math.Abs(diff) <= 1e-12*perimeter || speed <= 0 {
+			break
+		}
+		theta = math.Max(0, math.Min(2*math.Pi, theta-diff/speed))
+	}
+	return (turns + theta/(2*math.Pi)) * ellipseKnots
+}
+
+// ellipseShape returns the ellipse record of p if p is still the cycle of
+// eight knots it describes, nil otherwise.
+func (p *Path) ellipseShape() *ellipseInfo {
+	e := p.ellipse
+	if e == nil || p.Head == nil || p.Head.LType == KnotEndpoint {
+		return nil
+	}
+	tol := 1e-9 * (1 + math.Hypot(e.ux, e.uy) + math.Hypot(e.vx, e.vy) + math.Abs(e.cx) + math.Abs(e.cy))
+	k := p.Head
+	for i := 0; i < ellipseKnots; i++ {
+		if k == nil || k.RType == KnotEndpoint || (i > 0 && k == p.Head) {
+			return nil
+		}
+		x, y := e.point(Number(i) * math.Pi / 4)
+		if math.Abs(k.XCoord-x) > tol || math.Abs(k.YCoord-y) > tol {
+			return nil
+		}
+		k = k.Next
+	}
+	if k != p.Head {
+		return nil
+	}
+	return e
+}
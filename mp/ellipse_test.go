@@ -0,0 +1,90 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEllipseClosedForm(t *testing.T) {
+	circle := FullCircle().Scaled(200)
+	if got := circle.ArcLength(); math.Abs(got-200*math.Pi) > 1e-9 {
+		t.Errorf("circle arc length = %.12f, want %.12f", got, 200*math.Pi)
+	}
+
+	// Ellipse with semi-axes 100 and 50, shifted.
+	e := FullCircle().XScaled(200).YScaled(100).Shifted(10, 20)
+	const perimeter = 484.4224110273838
+	if got := e.ArcLength(); math.Abs(got-perimeter) > 1e-9 {
+		t.Errorf("ellipse arc length = %.12f, want %.12f", got, perimeter)
+	}
+	// By symmetry a quarter of the perimeter ends at knot 2.
+	for _, c := range []struct{ arc, want Number }{
+		{perimeter / 4, 2},
+		{-perimeter / 4, -2},
+		{perimeter / 2, 4},
+		{2.5 * perimeter, 20},
+	} {
+		if got := e.ArcTime(c.arc); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("arctime %g = %.12f, want %g", c.arc, got, c.want)
+		}
+	}
+	x, y := e.PointOf(1.5)
+	k := e.Head.Next
+	bx, by := evalCubic(k.XCoord, k.YCoord, k.RightX, k.RightY, k.Next.LeftX, k.Next.LeftY, k.Next.XCoord, k.Next.YCoord, 0.5)
+	if math.Hypot(x-bx, y-by) > 1e-9 {
+		t.Errorf("point 1.5 = (%g,%g), not on the Bézier curve at (%g,%g)", x, y, bx, by)
+	}
+}
+
+func TestEllipseArcTimeOfArcLength(t *testing.T) {
+	for _, p := range []*Path{
+		FullCircle().Scaled(30),
+		FullCircle().XScaled(200).YScaled(20),
+		FullCircle().Scaled(100).Rotated(30).Slanted(0.5).Shifted(-5, 7),
+	} {
+		if got, want := p.ArcTime(p.ArcLength()), Number(p.PathLength()); math.Abs(got-want) > 1e-9 {
+			t.Errorf("ArcTime(ArcLength()) = %.12f, want %g", got, want)
+		}
+		// The drawn curve up to the time has about the length asked for.
+		s := p.ArcLength() / 3
+		tt := p.ArcTime(s)
+		if got := p.Subpath(0, tt).ArcLength(); math.Abs(got-s) > 1e-3*s {
+			t.Errorf("curve length up to arctime %g is %g, want %g", tt, got, s)
+		}
+	}
+}
+
+func TestEllipseTimesOnDrawnCurve(t *testing.T) {
+	// Intersection times and points must agree with the other path.
+	circle := FullCircle().Scaled(200)
+	line := straightPath([]Point{{0, 0}, {200, 37}}, false)
+	t1, t2 := circle.IntersectionTimes(line)
+	if t1 < 0 {
+		t.Fatal("no intersection")
+	}
+	x1, y1 := circle.PointOf(t1)
+	x2, y2 := line.PointOf(t2)
+	if d := math.Hypot(x1-x2, y1-y2); d > 0.05 {
+		t.Errorf("point %g of the circle (%g,%g) is %g away from the line's (%g,%g)", t1, x1, y1, d, x2, y2)
+	}
+}
+
+func TestEllipseRecordInvalidated(t *testing.T) {
+	e := FullCircle().Scaled(100).Rotated(30).Slanted(0.5)
+	if e.ellipseShape() == nil {
+		t.Fatal("transformed fullcircle should keep its ellipse record")
+	}
+	plain := e.Copy()
+	plain.ellipse = nil
+	if got, want := e.ArcLength(), plain.ArcLength(); math.Abs(got-want) > 1e-3*want {
+		t.Errorf("closed form %g differs from integration %g", got, want)
+	}
+
+	e.Head.Next.XCoord += 1
+	if e.ellipseShape() != nil {
+		t.Error("record must be ignored after a knot moved")
+	}
+	if e.Reversed().ellipseShape() != nil {
+		t.Error("reversed path must not use the record")
+	}
+}
@@ -0,0 +1,178 @@
+package mp
+
+import "sort"
+
+// ExtendedTo returns a copy of the open path p whose arc length is length.
+// A longer path gets a straight segment at its end, continuing along the
+// end tangent like PointOf extrapolates past the last knot; a shorter one
+// is cut at the corresponding arc time. Cycles and point paths are
+// returned unchanged (as a copy). The style is copied.
+//
+// To extend the start instead, extend the reversed path:
+//
+//	axis := p.ExtendedTo(200)
+//	both := p.Reversed().ExtendedTo(250).Reversed()
+func (p *Path) ExtendedTo(length Number) *Path {
+	if p == nil || p.Head == nil {
+		return nil
+	}
+	if p.Head.LType != KnotEndpoint || p.IsPoint() {
+		return p.Copy()
+	}
+	total := p.ArcLength()
+	n := p.PathLength()
+	if length <= 0 {
+		q := p.Subpath(0, 0)
+		q.Style = p.Style
+		return q
+	}
+	if length < total {
+		q := p.Subpath(0, p.ArcTime(length))
+		q.Style = p.Style
+		return q
+	}
+	q := p.Copy()
+	excess := length - total
+	if excess <= 0 {
+		return q
+	}
+	dx, dy := incomingDirection(p, Number(n))
+	last := q.Head.Prev
+	ex, ey := last.XCoord+excess*dx, last.YCoord+excess*dy
+	last.RType = KnotExplicit
+	last.RightX, last.RightY = last.XCoord+excess*dx/3, last.YCoord+excess*dy/3
+	q.Append(&Knot{
+		XCoord: ex, YCoord: ey,
+		LeftX: ex - excess*dx/3, LeftY: ey - excess*dy/3,
+		RightX: ex, RightY: ey,
+		LType: KnotExplicit, RType: KnotEndpoint,
+	})
+	q.Head.LType = KnotEndpoint
+	return q
+}
+
+// TrimmedToRect returns the first part of the open path p that lies inside
+// the rectangle (minX, minY)–(maxX, maxY): from where p starts inside or
+// first enters the rectangle to where it next leaves it. The ends of the
+// result lie exactly on the rectangle's sides where p crosses them. It
+// returns nil if p never runs inside the rectangle. Together with
+// ExtendedTo this makes axis lines and rays that end at the plot frame.
+// The style is copied.
+//
+// Example:
+//
+//	// A ray from the origin through (1, 2), ending at the frame.
+//	ray := mp.FitLine([]mp.Point{mp.P(0, 0), mp.P(1, 2)})
+//	clipped := ray.ExtendedTo(1e4).TrimmedToRect(-50, -50, 50, 50)
+func (p *Path) TrimmedToRect(minX, minY, maxX, maxY Number) *Path {
+	if p == nil || p.Head == nil {
+		return nil
+	}
+	inside := func(t Number) bool {
+		x, y := p.PointOf(t)
+		return x >= minX-pointTolerance && x <= maxX+pointTolerance &&
+			y >= minY-pointTolerance && y <= maxY+pointTolerance
+	}
+	if p.IsPoint() {
+		if inside(0) {
+			return p.Copy()
+		}
+		return nil
+	}
+	n := Number(p.PathLength())
+	rect := straightPath([]Point{P(minX, minY), P(maxX, minY), P(maxX, maxY), P(minX, maxY)}, true)
+	times := []Number{0}
+	for _, ts := range p.AllIntersectionTimes(rect) {
+		times = append(times, ts[0])
+	}
+	times = append(times, n)
+	sort.Float64s(times)
+
+	// Find the first run of consecutive pieces that are inside; touching
+	// the boundary from inside does not end the run.
+	start, end := Number(-1), Number(-1)
+	var startMid, endMid Number
+	for i := 0; i+1 < len(times); i++ {
+		a, b := times[i], times[i+1]
+		if b-a < 1e-9 {
+			continue
+		}
+		if inside((a + b) / 2) {
+			if start < 0 {
+				start, startMid = a, (a+b)/2
+			}
+			end, endMid = b, (a+b)/2
+		} else if start >= 0 {
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+	// The intersection times are only accurate to the intersection
+	// tolerance, which on long paths is visibly off the frame; refine them
+	// by bisecting between a time inside and one just outside.
+	strictlyInside := func(t Number) bool {
+		x, y := p.PointOf(t)
+		return x >= minX && x <= maxX && y >= minY && y <= maxY
+	}
+	refine := func(in, near, dir Number) Number {
+		for h := Number(1e-6); h < 1; h *= 4 {
+			out := near + dir*h
+			if out < 0 || out > n {
+				break
+			}
+			if !strictlyInside(out) {
+				for i := 0; i < 60; i++ {
+					mid := (in + out) / 2
+					if strictlyInside(mid) {
+						in = mid
+					} else {
+						out = mid
+					}
+				}
+				return in
+			}
+		}
+		return near
+	}
+	if start > 0 {
+		start = refine(startMid, start, -1)
+	}
+	if end < n {
+		end = refine(endMid, end, 1)
+	}
+	q := p.Subpath(start, end)
+	q.Style = p.Style
+	// Snap the ends onto the sides they cross.
+	snap := func(k *Knot) {
+		x, y := snapToRange(k.XCoord, minX, maxX), snapToRange(k.YCoord, minY, maxY)
+		dx, dy := x-k.XCoord, y-k.YCoord
+		k.XCoord, k.YCoord = x, y
+		if k.LType == KnotEndpoint {
+			k.LeftX, k.LeftY = k.LeftX+dx, k.LeftY+dy
+		}
+		if k.RType == KnotEndpoint {
+			k.RightX, k.RightY = k.RightX+dx, k.RightY+dy
+		}
+	}
+	if start > 0 {
+		snap(q.Head)
+	}
+	if end < n {
+		snap(q.Head.Prev)
+	}
+	return q
+}
+
+// snapToRange clamps v to [lo, hi] and moves it onto lo or hi if it is
+// within pointTolerance of them.
+func snapToRange(v, lo, hi Number) Number {
+	switch {
+	case v <= lo+pointTolerance:
+		return lo
+	case v >= hi-pointTolerance:
+		return hi
+	}
+	return v
+}
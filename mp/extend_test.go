@@ -0,0 +1,79 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExtendedTo(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(10, 0), P(10, 10)}, false)
+	p.Style.StrokeWidth = 2
+
+	long := p.ExtendedTo(30)
+	if got := long.ArcLength(); math.Abs(got-30) > 1e-6 {
+		t.Errorf("extended length = %g, want 30", got)
+	}
+	if n := long.PathLength(); n != 3 {
+		t.Errorf("extended path has %d segments, want 3", n)
+	}
+	if x, y := long.PointOf(3); math.Abs(x-10) > 1e-9 || math.Abs(y-20) > 1e-9 {
+		t.Errorf("extended end = (%g,%g), want (10,20)", x, y)
+	}
+	if long.Head.LType != KnotEndpoint || long.Head.Prev.RType != KnotEndpoint {
+		t.Error("extended path should stay open")
+	}
+	if long.Style.StrokeWidth != 2 {
+		t.Error("style not copied")
+	}
+	// The original is untouched.
+	if n := p.PathLength(); n != 2 {
+		t.Errorf("original changed to %d segments", n)
+	}
+
+	short := p.ExtendedTo(15)
+	if got := short.ArcLength(); math.Abs(got-15) > 0.05 {
+		t.Errorf("shortened length = %g, want 15", got)
+	}
+
+	// Cycles are returned unchanged.
+	c := UnitSquare().ExtendedTo(10)
+	if n := c.PathLength(); n != 4 || c.Head.LType == KnotEndpoint {
+		t.Error("cycle should be returned unchanged")
+	}
+}
+
+func TestTrimmedToRect(t *testing.T) {
+	// A line through the rectangle from outside to outside.
+	p := straightPath([]Point{P(-20, 5), P(40, 5)}, false)
+	q := p.TrimmedToRect(0, 0, 10, 10)
+	if q == nil {
+		t.Fatal("TrimmedToRect returned nil")
+	}
+	x0, y0 := q.PointOf(0)
+	x1, y1 := q.PointOf(Number(q.PathLength()))
+	if x0 != 0 || y0 != 5 || x1 != 10 || y1 != 5 {
+		t.Errorf("trimmed to (%g,%g)--(%g,%g), want (0,5)--(10,5)", x0, y0, x1, y1)
+	}
+
+	// A ray starting inside keeps its start.
+	ray := straightPath([]Point{P(2, 3), P(4, 7)}, false).ExtendedTo(1000)
+	r := ray.TrimmedToRect(0, 0, 10, 10)
+	if x, y := r.PointOf(0); x != 2 || y != 3 {
+		t.Errorf("ray starts at (%g,%g), want (2,3)", x, y)
+	}
+	if x, y := r.PointOf(Number(r.PathLength())); math.Abs(x-5.5) > 1e-3 || y != 10 {
+		t.Errorf("ray ends at (%g,%g), want (5.5,10)", x, y)
+	}
+
+	// Only the first inside part is returned.
+	zig := straightPath([]Point{P(-5, 2), P(5, 2), P(5, 20), P(8, 20), P(8, 2), P(15, 2)}, false)
+	z := zig.TrimmedToRect(0, 0, 10, 10)
+	if x, y := z.PointOf(Number(z.PathLength())); x != 5 || y != 10 {
+		t.Errorf("first part ends at (%g,%g), want (5,10)", x, y)
+	}
+
+	// Entirely outside.
+	if out := straightPath([]Point{P(20, 20), P(30, 30)}, false).TrimmedToRect(0, 0, 10, 10); out != nil {
+		t.Error("path outside the rectangle should give nil")
+	}
+}
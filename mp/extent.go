@@ -0,0 +1,136 @@
+package mp
+
+import "math"
+
+// ExtentContributor is implemented by everything a renderer draws, so that
+// the bounding box of a drawing (e.g. an SVG viewBox) covers all of it:
+// paths with their arrowheads and caps, labels, and any feature added
+// later. Extent returns the bounding box of the element in model
+// coordinates; ok is false if it draws nothing. Renderers pad the union of
+// the extents by half the stroke width, so path extents leave out the
+// round part of the stroke.
+type ExtentContributor interface {
+	Extent() (minX, minY, maxX, maxY float64, ok bool)
+}
+
+// extentBox accumulates bounding boxes.
+type extentBox struct {
+	minX, minY, maxX, maxY float64
+	ok                     bool
+}
+
+func (b *extentBox) add(minX, minY, maxX, maxY float64) {
+	if !b.ok {
+		b.minX, b.minY, b.maxX, b.maxY, b.ok = minX, minY, maxX, maxY, true
+		return
+	}
+	b.minX, b.minY = math.Min(b.minX, minX), math.Min(b.minY, minY)
+	b.maxX, b.maxY = math.Max(b.maxX, maxX), math.Max(b.maxY, maxY)
+}
+
+func (b *extentBox) addPath(p *Path) {
+	if p != nil && p.Head != nil {
+		b.add(PathBBox(p))
+	}
+}
+
+func (b *extentBox) addPoint(x, y float64) {
+	b.add(x, y, x, y)
+}
+
+// Extent returns the bounding box of what is drawn for p: its envelope
+// (for polygonal pens) or the path itself, the pieces of a cycle with a
+// closing style, the symbols of its LineStyle, its arrowheads, and the
+// part of square caps that reaches past the open ends. Half the stroke
+// width around the path is not included (see ExtentContributor).
+func (p *Path) Extent() (minX, minY, maxX, maxY float64, ok bool) {
+	var b extentBox
+	if p == nil || p.Head == nil {
+		return 0, 0, 0, 0, false
+	}
+	if p.Style.Closing != nil && p.Head.LType != KnotEndpoint {
+		for _, q := range ExpandClosingStyle(p) {
+			x0, y0, x1, y1, qok := q.Extent()
+			if qok {
+				b.add(x0, y0, x1, y1)
+			}
+		}
+		return b.minX, b.minY, b.maxX, b.maxY, b.ok
+	}
+	if p.Style.LineStyle != LineStyleSolid {
+		for _, q := range ExpandLineStyle(p) {
+			x0, y0, x1, y1, qok := q.Extent()
+			if qok {
+				b.add(x0, y0, x1, y1)
+			}
+		}
+		return b.minX, b.minY, b.maxX, b.maxY, b.ok
+	}
+	if p.Envelope != nil && !p.Style.Hairline {
+		b.addPath(p.Envelope)
+		for _, head := range EnvelopeArrowHeads(p) {
+			b.addPath(head)
+		}
+		return b.minX, b.minY, b.maxX, b.maxY, b.ok
+	}
+	b.addPath(p)
+	length, angle := p.Style.Arrow.Length, p.Style.Arrow.Angle
+	if length <= 0 {
+		length = DefaultAHLength
+	}
+	if angle <= 0 {
+		angle = DefaultAHAngle
+	}
+	if p.Style.Arrow.End {
+		b.addPath(ArrowHeadEnd(p, length, angle))
+	}
+	if p.Style.Arrow.Start {
+		b.addPath(ArrowHeadStart(p, length, angle))
+	}
+	if p.Style.LineCap == LineCapSquared && p.Head.LType == KnotEndpoint {
+		hw := p.Style.StrokeWidth / 2
+		if pen := p.Style.Pen; pen != nil && pen.Elliptical {
+			hw = GetPenScale(pen) / 2
+		}
+		n := Number(p.PathLength())
+		if dx, dy := outgoingDirection(p, 0); dx != 0 || dy != 0 {
+			x, y := p.PointOf(0)
+			b.addPoint(x-hw*dx, y-hw*dy)
+		}
+		if dx, dy := incomingDirection(p, n); dx != 0 || dy != 0 {
+			x, y := p.PointOf(n)
+			b.addPoint(x+hw*dx, y+hw*dy)
+		}
+	}
+	return b.minX, b.minY, b.maxX, b.maxY, b.ok
+}
+
+// Extent returns the union of the extents of the parts of m, drawn with
+// the style of m.
+func (m *MultiPath) Extent() (minX, minY, maxX, maxY float64, ok bool) {
+	var b extentBox
+	if m == nil {
+		return 0, 0, 0, 0, false
+	}
+	for _, part := range m.Parts {
+		if part == nil || part.Head == nil {
+			continue
+		}
+		q := *part
+		q.Style = m.Style
+		q.Style.Arrow = ArrowStyle{}
+		if x0, y0, x1, y1, qok := q.Extent(); qok {
+			b.add(x0, y0, x1, y1)
+		}
+	}
+	return b.minX, b.minY, b.maxX, b.maxY, b.ok
+}
+
+// Extent returns the estimated bounds of the label (see EstimateBounds).
+func (l *Label) Extent() (minX, minY, maxX, maxY float64, ok bool) {
+	if l == nil {
+		return 0, 0, 0, 0, false
+	}
+	minX, minY, maxX, maxY = l.EstimateBounds()
+	return minX, minY, maxX, maxY, true
+}
@@ -0,0 +1,35 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPathExtent(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(10, 0)}, false)
+	if x0, y0, x1, y1, ok := p.Extent(); !ok || x0 != 0 || y0 != 0 || x1 != 10 || y1 != 0 {
+		t.Errorf("plain extent (%g,%g)-(%g,%g)", x0, y0, x1, y1)
+	}
+
+	// Square caps reach half the width past the ends.
+	p.Style.StrokeWidth = 4
+	p.Style.LineCap = LineCapSquared
+	if x0, _, x1, _, _ := p.Extent(); math.Abs(x0+2) > 1e-9 || math.Abs(x1-12) > 1e-9 {
+		t.Errorf("square caps: x from %g to %g, want -2 to 12", x0, x1)
+	}
+
+	// Arrowheads count.
+	q := straightPath([]Point{P(0, 0), P(0, 10)}, false)
+	q.Style.Arrow = ArrowStyle{End: true, Length: 4, Angle: 90}
+	if x0, _, x1, _, _ := q.Extent(); math.Abs(x0+4*math.Sin(math.Pi/4)) > 1e-9 || x1 <= 0 {
+		t.Errorf("arrow extent x from %g to %g", x0, x1)
+	}
+
+	var c ExtentContributor = NewLabel("ab", P(0, 0), AnchorCenter)
+	if _, _, _, _, ok := c.Extent(); !ok {
+		t.Error("labels contribute an extent")
+	}
+	if _, _, _, _, ok := (&Path{}).Extent(); ok {
+		t.Error("an empty path has no extent")
+	}
+}
@@ -0,0 +1,89 @@
+package mp
+
+import (
+	"math"
+	"sort"
+)
+
+// XExtrema returns the times at which the x coordinate of p has a local
+// extremum, in increasing order: where dx/dt is 0 inside a segment or at a
+// knot, and at knots where dx/dt changes sign (corners). The extreme
+// points of p in x, and so its bounding box, are among these points and
+// the ends of an open path. Splitting p at the times of XExtrema and
+// YExtrema yields pieces that are monotone in both coordinates, and the
+// points there are the extreme points font hinting expects on an outline.
+func (p *Path) XExtrema() []Number {
+	return pathExtrema(p, func(q Point) Number { return q.X })
+}
+
+// YExtrema returns the times at which the y coordinate of p has a local
+// extremum; see XExtrema.
+func (p *Path) YExtrema() []Number {
+	return pathExtrema(p, func(q Point) Number { return q.Y })
+}
+
+// pathExtrema returns the times of the extrema of the coordinate that
+// coord selects.
+func pathExtrema(p *Path, coord func(Point) Number) []Number {
+	segs := pathSegments(p)
+	n := len(segs)
+	if n == 0 {
+		return nil
+	}
+	const eps = 1e-9
+	cycle := p.Head.LType != KnotEndpoint
+	// deriv returns the derivative of the coordinate at the start (end
+	// false) or end of segment c, divided by 3.
+	deriv := func(c [4]Point, end bool) Number {
+		if end {
+			return coord(c[3]) - coord(c[2])
+		}
+		return coord(c[1]) - coord(c[0])
+	}
+	// atKnot reports whether the coordinate has an extremum at the knot
+	// between segments a and b: the derivative vanishes there or changes
+	// sign.
+	atKnot := func(a, b [4]Point) bool {
+		d0, d1 := deriv(a, true), deriv(b, false)
+		return math.Abs(d0) < eps || math.Abs(d1) < eps || (d0 < 0) != (d1 < 0)
+	}
+	var out []Number
+	for i, c := range segs {
+		switch {
+		case i > 0:
+			if atKnot(segs[i-1], c) {
+				out = append(out, Number(i))
+			}
+		case cycle:
+			if atKnot(segs[n-1], c) {
+				out = append(out, 0)
+			}
+		case math.Abs(deriv(c, false)) < eps:
+			out = append(out, 0)
+		}
+		for _, t := range cubicExtrema1D(coord(c[0]), coord(c[1]), coord(c[2]), coord(c[3])) {
+			out = append(out, Number(i)+t)
+		}
+	}
+	if !cycle && math.Abs(deriv(segs[n-1], true)) < eps {
+		out = append(out, Number(n))
+	}
+	sort.Float64s(out)
+	return out
+}
+
+// cubicExtrema1D returns the times in (0,1) at which the derivative of
+// the one-dimensional cubic Bézier p0..p3 is 0.
+func cubicExtrema1D(p0, p1, p2, p3 Number) []Number {
+	// Derivative: 3a t^2 + 2b t + c (common factor dropped)
+	a := -p0 + 3*p1 - 3*p2 + p3
+	b := 2 * (p0 - 2*p1 + p2)
+	c := p1 - p0
+	var out []Number
+	for _, t := range solveQuadratic(a, b, c) {
+		if t > 1e-9 && t < 1-1e-9 {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestXYExtrema(t *testing.T) {
+	near := func(got []Number, want ...Number) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if math.Abs(got[i]-want[i]) > 1e-6 {
+				return false
+			}
+		}
+		return true
+	}
+
+	circle := FullCircle() // starts at the right, 8 knots
+	if got := circle.XExtrema(); !near(got, 0, 4) {
+		t.Errorf("circle XExtrema = %v, want [0 4]", got)
+	}
+	if got := circle.YExtrema(); !near(got, 2, 6) {
+		t.Errorf("circle YExtrema = %v, want [2 6]", got)
+	}
+
+	arch := cubicPath(P(0, 0), [3]Point{P(0, 30), P(30, 30), P(30, 0)})
+	if got := arch.YExtrema(); !near(got, 0.5) {
+		t.Errorf("arch YExtrema = %v, want [0.5]", got)
+	}
+	if got := arch.XExtrema(); !near(got, 0, 1) {
+		t.Errorf("arch XExtrema = %v, want [0 1] (vertical ends)", got)
+	}
+
+	// Corners of a polyline count where the direction reverses.
+	zigzag := straightPath([]Point{P(0, 0), P(10, 10), P(20, 0), P(30, 10)}, false)
+	if got := zigzag.YExtrema(); !near(got, 1, 2) {
+		t.Errorf("zigzag YExtrema = %v, want [1 2]", got)
+	}
+	if got := zigzag.XExtrema(); len(got) != 0 {
+		t.Errorf("zigzag XExtrema = %v, want none", got)
+	}
+
+	// The points at the extrema and the ends span the bounding box.
+	p := Rotated(30).ApplyToPath(cubicPath(P(0, 0), [3]Point{P(0, 30), P(30, 30), P(30, 0)}, [3]Point{P(30, -20), P(50, -10), P(40, 10)}))
+	minX, minY, maxX, maxY := PathBBox(p)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, tt := range append(p.XExtrema(), 0, 2) {
+		x, _ := p.PointOf(tt)
+		lo, hi = math.Min(lo, x), math.Max(hi, x)
+	}
+	if math.Abs(lo-minX) > 1e-9 || math.Abs(hi-maxX) > 1e-9 {
+		t.Errorf("x range at extrema [%g,%g], bbox [%g,%g]", lo, hi, minX, maxX)
+	}
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, tt := range append(p.YExtrema(), 0, 2) {
+		_, y := p.PointOf(tt)
+		lo, hi = math.Min(lo, y), math.Max(hi, y)
+	}
+	if math.Abs(lo-minY) > 1e-9 || math.Abs(hi-maxY) > 1e-9 {
+		t.Errorf("y range at extrema [%g,%g], bbox [%g,%g]", lo, hi, minY, maxY)
+	}
+}
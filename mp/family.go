@@ -0,0 +1,111 @@
+package mp
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// SolveFamily builds and solves a family of n paths, such as the curves of
+// a parameter study or the frames of an animation: build(i) returns path i
+// for i = 0 … n-1. The paths are solved in parallel, one worker engine per
+// CPU, each reusing its working buffers for all paths it solves. The
+// solved paths are returned in order; the engine's own queue (AddPath) is
+// not touched. It is SolveFamilyContext without cancellation.
+//
+// Example:
+//
+//	// The fan of fan.mp: ten curves with incoming directions 0, -10, … -90.
+//	fan, err := mp.NewEngine().SolveFamily(func(i int) *mp.Path {
+//		a, b := mp.NewKnotAt(0, 0), mp.NewKnotAt(170, 0)
+//		p := mp.NewPath()
+//		p.Append(a)
+//		p.Append(b)
+//		a.LType, b.RType = mp.KnotEndpoint, mp.KnotEndpoint
+//		a.SetGivenDirection(45)
+//		b.SetGivenDirection(mp.Number(-10 * i))
+//		return p
+//	}, 10)
+func (e *Engine) SolveFamily(build func(i int) *Path, n int) ([]*Path, error) {
+	return e.SolveFamilyContext(context.Background(), build, n)
+}
+
+// SolveFamilyContext is SolveFamily with cancellation (see SolveContext).
+// build is called n times in order on the calling goroutine before any
+// path is solved, so it need not be safe for concurrent use. The paths are
+// checked with Path.Validate first; errors are *KnotError values whose
+// Path field is the family index. If several paths fail, the error of the
+// lowest index is returned. The progress function (SetProgress) is called
+// with the number of solved paths, from the worker goroutines but never
+// concurrently.
+func (e *Engine) SolveFamilyContext(ctx context.Context, build func(i int) *Path, n int) ([]*Path, error) {
+	if n <= 0 {
+		return nil, errors.New("no paths in family")
+	}
+	paths := make([]*Path, n)
+	for i := range paths {
+		p := build(i)
+		if err := p.Validate(); err != nil {
+			var ke *KnotError
+			if errors.As(err, &ke) {
+				ke.Path = i
+			}
+			return nil, err
+		}
+		paths[i] = p
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	errs := make([]error, n)
+	next := make(chan int)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			we := e.workerEngine()
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = we.solveQueued(ctx, paths[i], i)
+				if e.progress != nil {
+					mu.Lock()
+					done++
+					e.progress(done, n)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// workerEngine returns an empty engine with the settings of e, for solving
+// paths on another goroutine.
+func (e *Engine) workerEngine() *Engine {
+	we := NewEngine()
+	we.epsilon = e.epsilon
+	we.envelopeMaxIter = e.envelopeMaxIter
+	return we
+}
@@ -0,0 +1,114 @@
+package mp
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// fanCurve returns curve i of fan.mp: (0,0){dir 45}..{dir -10i}(6cm,0).
+func fanCurve(i int) *Path {
+	a, b := NewKnotAt(0, 0), NewKnotAt(6*28.3464567, 0)
+	p := NewPath()
+	p.Append(a)
+	p.Append(b)
+	a.LType, b.RType = KnotEndpoint, KnotEndpoint
+	a.SetGivenDirection(45)
+	b.SetGivenDirection(Number(-10 * i))
+	return p
+}
+
+func TestSolveFamily(t *testing.T) {
+	fan, err := NewEngine().SolveFamily(fanCurve, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fan) != 10 {
+		t.Fatalf("got %d paths, want 10", len(fan))
+	}
+	for i, p := range fan {
+		// Same result as solving the curve on its own.
+		q := fanCurve(i)
+		e := NewEngine()
+		e.AddPath(q)
+		if err := e.Solve(); err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(p.Head.RightX-q.Head.RightX) > 1e-12 || math.Abs(p.Head.Next.LeftY-q.Head.Next.LeftY) > 1e-12 {
+			t.Errorf("curve %d: family result differs from single solve", i)
+		}
+	}
+	// MetaPost's controls for a = 0 and a = 9 (see draw/controlpoint_test.go).
+	if got := fan[0].Head.RightX; math.Abs(got-44.36261) > 1e-3 {
+		t.Errorf("curve 0: first control x = %g, want 44.36261", got)
+	}
+	if got := fan[9].Head.Next.LeftY; math.Abs(got-61.77214) > 1e-3 {
+		t.Errorf("curve 9: second control y = %g, want 61.77214", got)
+	}
+}
+
+func TestSolveFamilyErrors(t *testing.T) {
+	_, err := NewEngine().SolveFamily(func(i int) *Path {
+		p := fanCurve(i)
+		if i == 3 {
+			p.Head.XCoord = math.NaN()
+		}
+		return p
+	}, 5)
+	var ke *KnotError
+	if !errors.As(err, &ke) || ke.Path != 3 {
+		t.Errorf("got %v, want a *KnotError for path 3", err)
+	}
+	if _, err := NewEngine().SolveFamily(fanCurve, 0); err == nil {
+		t.Error("empty family should fail")
+	}
+
+	calls := 0
+	e := NewEngine().SetProgress(func(done, total int) {
+		calls++
+		if total != 20 {
+			t.Errorf("progress total = %d, want 20", total)
+		}
+	})
+	if _, err := e.SolveFamily(fanCurve, 20); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 20 {
+		t.Errorf("progress called %d times, want 20", calls)
+	}
+}
+
+func TestEngineBuffersDoNotGrow(t *testing.T) {
+	// Solving many short paths must not grow the working buffers.
+	e := NewEngine()
+	for i := 0; i < 200; i++ {
+		e.AddPath(fanCurve(i % 10))
+	}
+	if err := e.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(e.deltaX); n > 1000 {
+		t.Errorf("buffers grew to %d entries", n)
+	}
+}
+
+func BenchmarkSolveFamily(b *testing.B) {
+	e := NewEngine()
+	for i := 0; i < b.N; i++ {
+		if _, err := e.SolveFamily(fanCurve, 100); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSolveFamilySequential(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			e := NewEngine()
+			e.AddPath(fanCurve(j))
+			if err := e.Solve(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
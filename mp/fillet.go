@@ -0,0 +1,278 @@
+package mp
+
+import "math"
+
+// cornerAngle is the smallest turn, in degrees, at a knot that
+// FilletCorners and ChamferCorners treat as a corner.
+const cornerAngle = 1.0
+
+// FilletCorners returns a copy of p with every corner rounded by a circular
+// fillet of the given radius, tangent to both segments (mechanical
+// drawing's fillet, or MetaPost's "corner rounding" done by hand). A corner
+// is a knot where the path turns by more than one degree; smooth knots,
+// the ends of open paths and cusps (turns of nearly 180°) are kept.
+//
+// The segments are cut back by radius·tan(turn/2) on either side of the
+// corner, which is exact for straight segments and a close approximation
+// for curved ones, and joined by a cubic arc that leaves and enters in the
+// directions of the cut ends. The cut-back is limited to half of a segment
+// that has corners at both ends (the whole segment otherwise), so large
+// radii shrink to what fits. The style is copied; the result is explicit.
+//
+// Example:
+//
+//	plate := mp.FilletCorners(mp.UnitSquare().Scaled(40), 5)
+func FilletCorners(p *Path, radius Number) *Path {
+	return cutCorners(p, radius, func(turn Number) Number {
+		return radius * math.Tan(turn/2)
+	}, true)
+}
+
+// ChamferCorners returns a copy of p with every corner (see FilletCorners)
+// replaced by a straight chamfer that starts length before the corner and
+// ends length after it, measured along the path. Lengths are limited like
+// the cut-back of FilletCorners. The style is copied; the result is
+// explicit.
+//
+// Example:
+//
+//	bevelled := mp.ChamferCorners(outline, 2)
+func ChamferCorners(p *Path, length Number) *Path {
+	return cutCorners(p, length, func(Number) Number { return length }, false)
+}
+
+// cutCorners implements FilletCorners and ChamferCorners: setback returns
+// the arc length cut from both segments at a corner with the given turn
+// (radians); round selects a fillet instead of a chamfer.
+func cutCorners(p *Path, size Number, setback func(turn Number) Number, round bool) *Path {
+	if p == nil || p.Head == nil {
+		return nil
+	}
+	n := p.PathLength()
+	if n == 0 || size <= 0 {
+		return p.Copy()
+	}
+	cycle := p.Head.LType != KnotEndpoint
+	knots := n + 1
+	if cycle {
+		knots = n
+	}
+	// Segments as cubics.
+	segs := make([][4]Point, n)
+	k := p.Head
+	for i := range segs {
+		segs[i] = [4]Point{P(k.XCoord, k.YCoord), P(k.RightX, k.RightY), P(k.Next.LeftX, k.Next.LeftY), P(k.Next.XCoord, k.Next.YCoord)}
+		k = k.Next
+	}
+	lengths := make([]Number, n)
+	for i := range segs {
+		lengths[i] = cubicLength(segs[i], 1)
+	}
+
+	// Wanted setback at each knot, 0 if it is not a corner.
+	want := make([]Number, knots)
+	for j := range want {
+		if !cycle && (j == 0 || j == n) {
+			continue
+		}
+		ix, iy := incomingDirection(p, Number(j))
+		ox, oy := outgoingDirection(p, Number(j))
+		turn := math.Acos(math.Max(-1, math.Min(1, ix*ox+iy*oy)))
+		if turn*180/math.Pi > cornerAngle && turn*180/math.Pi < 180-cornerAngle {
+			want[j] = setback(turn)
+		}
+	}
+	// Limit to what the adjacent segments allow.
+	cut := make([]Number, knots)
+	for j := range cut {
+		if want[j] == 0 {
+			continue
+		}
+		in, out := (j-1+n)%n, j%n // segments ending and starting at j
+		limit := func(seg, other int) Number {
+			if want[other] > 0 {
+				return lengths[seg] / 2
+			}
+			return lengths[seg]
+		}
+		cut[j] = math.Min(want[j], math.Min(limit(in, (j-1+knots)%knots), limit(out, (j+1)%knots)))
+	}
+
+	// Trim the segments and join them at the corners.
+	var pieces [][4]Point
+	trimmed := make([][4]Point, n)
+	keep := make([]bool, n)
+	for i, c := range segs {
+		start, end := cut[i], cut[(i+1)%knots]
+		keep[i] = lengths[i]-start-end > 1e-9
+		if keep[i] {
+			trimmed[i] = trimCubic(c, start, end)
+		}
+	}
+	join := func(j int) {
+		// The corner j joins the end of segment j-1 and the start of segment j.
+		in, out := (j-1+n)%n, j%n
+		pa, pb := trimmedEnd(segs[in], lengths[in]-cut[j]), trimmedEnd(segs[out], cut[j])
+		if !round {
+			pieces = append(pieces, [4]Point{pa, PointBetween(pa, pb, 1.0/3), PointBetween(pa, pb, 2.0/3), pb})
+			return
+		}
+		da := cubicDirectionAt(segs[in], lengths[in]-cut[j])
+		db := cubicDirectionAt(segs[out], cut[j])
+		pieces = append(pieces, arcCubic(pa, da, pb, db))
+	}
+	for i := 0; i < n; i++ {
+		if cut[i] > 0 && (cycle || i > 0) {
+			join(i)
+		}
+		if keep[i] {
+			pieces = append(pieces, trimmed[i])
+		}
+	}
+	if len(pieces) == 0 {
+		return p.Copy()
+	}
+	if cycle && cut[0] > 0 {
+		// Move the fillet at the head to the end so the pieces run in order.
+		pieces = append(pieces[1:], pieces[0])
+	}
+
+	q := NewPath()
+	q.Style = p.Style
+	for _, c := range pieces {
+		q.Append(&Knot{XCoord: c[0].X, YCoord: c[0].Y, RightX: c[1].X, RightY: c[1].Y, LType: KnotExplicit, RType: KnotExplicit})
+	}
+	kn := q.Head
+	for i, c := range pieces {
+		next := kn.Next
+		if !cycle && i == len(pieces)-1 {
+			last := &Knot{XCoord: c[3].X, YCoord: c[3].Y, LeftX: c[2].X, LeftY: c[2].Y, LType: KnotExplicit, RType: KnotEndpoint}
+			last.RightX, last.RightY = last.XCoord, last.YCoord
+			q.Append(last)
+			break
+		}
+		next.LeftX, next.LeftY = c[2].X, c[2].Y
+		kn = next
+	}
+	if !cycle {
+		q.Head.LType = KnotEndpoint
+		q.Head.LeftX, q.Head.LeftY = q.Head.XCoord, q.Head.YCoord
+	}
+	return q
+}
+
+// The arc length queries below integrate the speed of a single cubic
+// numerically. Unlike Path.ArcTime they stay accurate for segments with
+// zero-length handles such as those of UnitSquare.
+
+// cubicSpeed returns |c'(t)|.
+func cubicSpeed(c [4]Point, t Number) Number {
+	dx, dy := evalCubicDerivative(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, t)
+	return math.Hypot(dx, dy)
+}
+
+// cubicLength returns the arc length of c between times 0 and t.
+func cubicLength(c [4]Point, t Number) Number {
+	const pieces = 8
+	total := Number(0)
+	for i := 0; i < pieces; i++ {
+		a, b := t*Number(i)/pieces, t*Number(i+1)/pieces
+		mid, half := (a+b)/2, (b-a)/2
+		for j, x := range gaussNodes {
+			total += half * gaussWeights[j] * (cubicSpeed(c, mid-half*x) + cubicSpeed(c, mid+half*x))
+		}
+	}
+	return total
+}
+
+// cubicArcTime returns the time at which the arc length of c reaches s.
+func cubicArcTime(c [4]Point, s Number) Number {
+	if s <= 0 {
+		return 0
+	}
+	if s >= cubicLength(c, 1) {
+		return 1
+	}
+	lo, hi := Number(0), Number(1)
+	for i := 0; i < 60 && hi-lo > 1e-12; i++ {
+		mid := (lo + hi) / 2
+		if cubicLength(c, mid) < s {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// trimmedEnd returns the point at arc length s on c.
+func trimmedEnd(c [4]Point, s Number) Point {
+	switch t := cubicArcTime(c, s); t {
+	case 0:
+		return c[0]
+	case 1:
+		return c[3]
+	default:
+		x, y := evalCubic(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, t)
+		return P(x, y)
+	}
+}
+
+// trimCubic returns the part of c without the first start and the last
+// end units of arc length.
+func trimCubic(c [4]Point, start, end Number) [4]Point {
+	t0, t1 := Number(0), Number(1)
+	if start > 0 {
+		t0 = cubicArcTime(c, start)
+	}
+	if end > 0 {
+		t1 = cubicArcTime(c, cubicLength(c, 1)-end)
+	}
+	// Cut off the end first, then the start of what remains.
+	x := [8]Number{c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y}
+	if t1 < 1 {
+		x[0], x[1], x[2], x[3], x[4], x[5], x[6], x[7], _, _, _, _, _, _, _, _ = splitCubicCoords(x[0], x[1], x[2], x[3], x[4], x[5], x[6], x[7], t1)
+	}
+	if t0 > 0 {
+		_, _, _, _, _, _, _, _, x[0], x[1], x[2], x[3], x[4], x[5], x[6], x[7] = splitCubicCoords(x[0], x[1], x[2], x[3], x[4], x[5], x[6], x[7], t0/t1)
+	}
+	return [4]Point{P(x[0], x[1]), P(x[2], x[3]), P(x[4], x[5]), P(x[6], x[7])}
+}
+
+// cubicDirectionAt returns the unit direction of c at arc length s.
+func cubicDirectionAt(c [4]Point, s Number) Point {
+	t := cubicArcTime(c, s)
+	dx, dy := evalCubicDerivative(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, t)
+	if ux, uy, ok := unitVector(dx, dy); ok {
+		return P(ux, uy)
+	}
+	// Zero speed at an end: the direction is that of the next control point.
+	if t < 0.5 {
+		for _, q := range c[1:] {
+			if ux, uy, ok := unitVector(q.X-c[0].X, q.Y-c[0].Y); ok {
+				return P(ux, uy)
+			}
+		}
+	} else {
+		for i := 2; i >= 0; i-- {
+			if ux, uy, ok := unitVector(c[3].X-c[i].X, c[3].Y-c[i].Y); ok {
+				return P(ux, uy)
+			}
+		}
+	}
+	return P(0, 0)
+}
+
+// arcCubic returns a cubic from a leaving in direction da to b arriving in
+// direction db that approximates a circular arc: both handles have the
+// length 4/3·tan(θ/4)·R of a circular arc of turn θ and chord |ab|.
+func arcCubic(a, da, b, db Point) [4]Point {
+	chord := Distance(a, b)
+	theta := math.Acos(math.Max(-1, math.Min(1, da.Dot(db))))
+	h := chord / 3
+	if s := math.Sin(theta / 2); s > 1e-9 {
+		r := chord / (2 * s)
+		h = 4.0 / 3 * math.Tan(theta/4) * r
+	}
+	return [4]Point{a, a.Add(da.Mul(h)), b.Sub(db.Mul(h)), b}
+}
@@ -0,0 +1,96 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFilletCornersSquare(t *testing.T) {
+	sq := UnitSquare().Scaled(40)
+	sq.Style.StrokeWidth = 2
+	f := FilletCorners(sq, 5)
+	if f.Head.LType == KnotEndpoint {
+		t.Fatal("fillet of a cycle should be a cycle")
+	}
+	if n := f.PathLength(); n != 8 {
+		t.Fatalf("got %d segments, want 4 sides and 4 fillets", n)
+	}
+	if f.Style.StrokeWidth != 2 {
+		t.Error("style not copied")
+	}
+	x0, y0, x1, y1 := PathBBox(f)
+	if math.Abs(x0) > 1e-9 || math.Abs(y0) > 1e-9 || math.Abs(x1-40) > 1e-9 || math.Abs(y1-40) > 1e-9 {
+		t.Errorf("bbox %g %g %g %g, want 0 0 40 40", x0, y0, x1, y1)
+	}
+	centers := []Point{P(35, 5), P(35, 35), P(5, 35), P(5, 5)}
+	for i := 0; i < 8; i++ {
+		for j := 0; j <= 4; j++ {
+			x, y := f.PointOf(Number(i) + Number(j)/4)
+			// Every point is on a side or on a fillet around one of the centers.
+			onSide := math.Abs(x) < 1e-9 || math.Abs(x-40) < 1e-9 || math.Abs(y) < 1e-9 || math.Abs(y-40) < 1e-9
+			onArc := false
+			for _, c := range centers {
+				if math.Abs(math.Hypot(x-c.X, y-c.Y)-5) < 0.003 && math.Abs(x-c.X) <= 5 && math.Abs(y-c.Y) <= 5 {
+					onArc = true
+				}
+			}
+			if !onSide && !onArc {
+				t.Errorf("point (%g,%g) at time %g is neither on a side nor on a fillet", x, y, Number(i)+Number(j)/4)
+			}
+		}
+	}
+	// Tangent continuity at every knot.
+	for i := 0; i < 8; i++ {
+		ix, iy := incomingDirection(f, Number(i))
+		ox, oy := outgoingDirection(f, Number(i))
+		if math.Abs(ix-ox) > 1e-6 || math.Abs(iy-oy) > 1e-6 {
+			t.Errorf("knot %d has a corner: in (%g,%g) out (%g,%g)", i, ix, iy, ox, oy)
+		}
+	}
+}
+
+func TestFilletCornersOpenLimit(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(10, 0), P(10, 10)}, false)
+	f := FilletCorners(p, 20)
+	// The fillet takes both (end) segments completely.
+	if n := f.PathLength(); n != 1 {
+		t.Fatalf("got %d segments, want 1", n)
+	}
+	if f.Head.LType != KnotEndpoint {
+		t.Fatal("fillet of an open path should be open")
+	}
+	if x, y := f.PointOf(1); x != 10 || y != 10 {
+		t.Errorf("ends at (%g,%g), want (10,10)", x, y)
+	}
+	if x, y := f.PointOf(0.5); math.Abs(math.Hypot(x, y-10)-10) > 0.01 {
+		t.Errorf("midpoint (%g,%g) not on the circle of radius 10 around (0,10)", x, y)
+	}
+	// Ends and smooth knots are not corners.
+	smooth := FullCircle().Scaled(10)
+	if n := FilletCorners(smooth, 1).PathLength(); n != smooth.PathLength() {
+		t.Errorf("smooth cycle changed from %d to %d segments", smooth.PathLength(), n)
+	}
+}
+
+func TestChamferCorners(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(10, 0), P(10, 10)}, false)
+	c := ChamferCorners(p, 2)
+	want := []Point{P(0, 0), P(8, 0), P(10, 2), P(10, 10)}
+	if n := c.PathLength(); n != 3 {
+		t.Fatalf("got %d segments, want 3", n)
+	}
+	for i, w := range want {
+		if x, y := c.PointOf(Number(i)); math.Abs(x-w.X) > 1e-9 || math.Abs(y-w.Y) > 1e-9 {
+			t.Errorf("knot %d at (%g,%g), want (%g,%g)", i, x, y, w.X, w.Y)
+		}
+	}
+	sq := ChamferCorners(UnitSquare().Scaled(10), 3)
+	if n := sq.PathLength(); n != 8 {
+		t.Errorf("chamfered square has %d segments, want 8", n)
+	}
+	// Chamfers on a short side share it.
+	thin := ChamferCorners(straightPath([]Point{P(0, 0), P(4, 0), P(4, 2), P(0, 2)}, true), 3)
+	if x, y := thin.PointOf(1); math.Abs(x-3) > 1e-9 || math.Abs(y) > 1e-9 {
+		t.Errorf("first chamfer starts at (%g,%g), want (3,0)", x, y)
+	}
+}
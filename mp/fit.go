@@ -0,0 +1,208 @@
+package mp
+
+import "math"
+
+// Least-squares fitting of simple primitives to sampled points. These have
+// no MetaPost counterpart; they return ordinary paths that can be drawn,
+// intersected or fed into the equation solver like any other path.
+
+// FitLine returns the straight segment that best approximates pts in the
+// total least squares sense (orthogonal distances). The segment spans the
+// projections of all points onto the fitted line. Returns nil if fewer than
+// two distinct points are given.
+//
+// Example:
+//
+//	trend := mp.FitLine(samples)
+func FitLine(pts []Point) *Path {
+	if len(pts) < 2 {
+		return nil
+	}
+	cx, cy := centroid(pts)
+	var sxx, sxy, syy float64
+	for _, p := range pts {
+		dx, dy := p.X-cx, p.Y-cy
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx+syy == 0 {
+		return nil
+	}
+	// Principal axis of the covariance matrix
+	theta := 0.5 * math.Atan2(2*sxy, sxx-syy)
+	ux, uy := math.Cos(theta), math.Sin(theta)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range pts {
+		s := (p.X-cx)*ux + (p.Y-cy)*uy
+		lo = math.Min(lo, s)
+		hi = math.Max(hi, s)
+	}
+	return straightPath([]Point{
+		{X: cx + lo*ux, Y: cy + lo*uy},
+		{X: cx + hi*ux, Y: cy + hi*uy},
+	}, false)
+}
+
+// FitCircle returns the circle that best approximates pts using the
+// algebraic (Kåsa) least squares fit of x²+y²+Dx+Ey+F = 0. The result is a
+// fullcircle scaled to the fitted diameter and shifted to the fitted center.
+// Returns nil if fewer than three points are given or they are collinear.
+//
+// Example:
+//
+//	c := mp.FitCircle(measured)  // best-fit circle through measured points
+func FitCircle(pts []Point) *Path {
+	center, r, ok := fitCircleParams(pts)
+	if !ok {
+		return nil
+	}
+	return Scaled(2 * r).Then(Shifted(center.X, center.Y)).ApplyToPath(FullCircle())
+}
+
+// fitCircleParams returns center and radius of the Kåsa circle fit.
+func fitCircleParams(pts []Point) (center Point, r float64, ok bool) {
+	if len(pts) < 3 {
+		return Point{}, 0, false
+	}
+	// Work relative to the centroid for better conditioning.
+	cx, cy := centroid(pts)
+	var m [3][3]float64
+	var v [3]float64
+	for _, p := range pts {
+		x, y := p.X-cx, p.Y-cy
+		row := [3]float64{x, y, 1}
+		rhs := -(x*x + y*y)
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				m[i][j] += row[i] * row[j]
+			}
+			v[i] += row[i] * rhs
+		}
+	}
+	a := make([][]float64, 3)
+	for i := range a {
+		a[i] = m[i][:]
+	}
+	sol, ok := solveLinearSystem(a, v[:])
+	if !ok {
+		return Point{}, 0, false
+	}
+	d, e, f := sol[0], sol[1], sol[2]
+	x0, y0 := -d/2, -e/2
+	r2 := x0*x0 + y0*y0 - f
+	if r2 <= 0 {
+		return Point{}, 0, false
+	}
+	return Point{X: x0 + cx, Y: y0 + cy}, math.Sqrt(r2), true
+}
+
+// FitEllipse returns the ellipse that best approximates pts using an
+// algebraic least squares fit of the conic Ax²+Bxy+Cy²+Dx+Ey = 1 (in
+// coordinates relative to the centroid). The result is a fullcircle scaled,
+// rotated and shifted onto the fitted ellipse. Returns nil if fewer than
+// five points are given or the best-fit conic is not an ellipse.
+func FitEllipse(pts []Point) *Path {
+	if len(pts) < 5 {
+		return nil
+	}
+	cx, cy := centroid(pts)
+	// Normalize scale so the normal equations stay well conditioned.
+	var scale float64
+	for _, p := range pts {
+		scale += math.Hypot(p.X-cx, p.Y-cy)
+	}
+	scale /= float64(len(pts))
+	if scale == 0 {
+		return nil
+	}
+	var m [5][5]float64
+	var v [5]float64
+	for _, p := range pts {
+		x, y := (p.X-cx)/scale, (p.Y-cy)/scale
+		row := [5]float64{x * x, x * y, y * y, x, y}
+		for i := 0; i < 5; i++ {
+			for j := 0; j < 5; j++ {
+				m[i][j] += row[i] * row[j]
+			}
+			v[i] += row[i]
+		}
+	}
+	a := make([][]float64, 5)
+	for i := range a {
+		a[i] = m[i][:]
+	}
+	sol, ok := solveLinearSystem(a, v[:])
+	if !ok {
+		return nil
+	}
+	A, B, C, D, E := sol[0], sol[1], sol[2], sol[3], sol[4]
+	F := -1.0
+	if B*B-4*A*C >= 0 {
+		return nil // not an ellipse
+	}
+	// Center: gradient of the conic vanishes.
+	det := 4*A*C - B*B
+	x0 := (B*E - 2*C*D) / det
+	y0 := (B*D - 2*A*E) / det
+	f0 := A*x0*x0 + B*x0*y0 + C*y0*y0 + D*x0 + E*y0 + F
+	theta := 0.5 * math.Atan2(B, A-C)
+	cos, sin := math.Cos(theta), math.Sin(theta)
+	l1 := A*cos*cos + B*cos*sin + C*sin*sin
+	l2 := A + C - l1
+	if -f0/l1 <= 0 || -f0/l2 <= 0 {
+		return nil
+	}
+	ra := math.Sqrt(-f0/l1) * scale
+	rb := math.Sqrt(-f0/l2) * scale
+	return XScaled(2 * ra).Then(YScaled(2 * rb)).
+		Then(Rotated(theta * 180 / math.Pi)).
+		Then(Shifted(x0*scale+cx, y0*scale+cy)).
+		ApplyToPath(FullCircle())
+}
+
+// centroid returns the arithmetic mean of pts.
+func centroid(pts []Point) (x, y float64) {
+	for _, p := range pts {
+		x += p.X
+		y += p.Y
+	}
+	n := float64(len(pts))
+	return x / n, y / n
+}
+
+// solveLinearSystem solves a·x = b by Gaussian elimination with partial
+// pivoting. a and b are modified. ok is false for (nearly) singular systems.
+func solveLinearSystem(a [][]float64, b []float64) (x []float64, ok bool) {
+	n := len(b)
+	const eps = 1e-12
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < eps {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for row := col + 1; row < n; row++ {
+			f := a[row][col] / a[col][col]
+			for k := col; k < n; k++ {
+				a[row][k] -= f * a[col][k]
+			}
+			b[row] -= f * b[col]
+		}
+	}
+	x = make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := b[i]
+		for k := i + 1; k < n; k++ {
+			sum -= a[i][k] * x[k]
+		}
+		x[i] = sum / a[i][i]
+	}
+	return x, true
+}
@@ -0,0 +1,60 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFitLine(t *testing.T) {
+	pts := []Point{{0, 1}, {1, 3}, {2, 5}, {3, 7}}
+	line := FitLine(pts)
+	if line == nil {
+		t.Fatal("FitLine returned nil")
+	}
+	x0, y0 := line.PointOf(0)
+	x1, y1 := line.PointOf(1)
+	if !approxEqual(x0, 0, 1e-9) || !approxEqual(y0, 1, 1e-9) ||
+		!approxEqual(x1, 3, 1e-9) || !approxEqual(y1, 7, 1e-9) {
+		t.Errorf("FitLine = (%g,%g)--(%g,%g), want (0,1)--(3,7)", x0, y0, x1, y1)
+	}
+	if FitLine([]Point{{1, 1}, {1, 1}}) != nil {
+		t.Errorf("expected nil for coincident points")
+	}
+}
+
+func TestFitCircle(t *testing.T) {
+	var pts []Point
+	for i := 0; i < 12; i++ {
+		a := float64(i) * math.Pi / 6
+		pts = append(pts, Point{X: 10 + 5*math.Cos(a), Y: -3 + 5*math.Sin(a)})
+	}
+	c := FitCircle(pts)
+	if c == nil {
+		t.Fatal("FitCircle returned nil")
+	}
+	minX, minY, maxX, maxY := PathBBox(c)
+	if !approxEqual(minX, 5, 1e-3) || !approxEqual(maxX, 15, 1e-3) ||
+		!approxEqual(minY, -8, 1e-3) || !approxEqual(maxY, 2, 1e-3) {
+		t.Errorf("FitCircle bbox = %g %g %g %g", minX, minY, maxX, maxY)
+	}
+	if FitCircle([]Point{{0, 0}, {1, 1}, {2, 2}}) != nil {
+		t.Errorf("expected nil for collinear points")
+	}
+}
+
+func TestFitEllipse(t *testing.T) {
+	var pts []Point
+	for i := 0; i < 16; i++ {
+		a := float64(i) * math.Pi / 8
+		pts = append(pts, Point{X: 1 + 5*math.Cos(a), Y: 2 + 2*math.Sin(a)})
+	}
+	e := FitEllipse(pts)
+	if e == nil {
+		t.Fatal("FitEllipse returned nil")
+	}
+	minX, minY, maxX, maxY := PathBBox(e)
+	if !approxEqual(minX, -4, 1e-3) || !approxEqual(maxX, 6, 1e-3) ||
+		!approxEqual(minY, 0, 1e-3) || !approxEqual(maxY, 4, 1e-3) {
+		t.Errorf("FitEllipse bbox = %g %g %g %g", minX, minY, maxX, maxY)
+	}
+}
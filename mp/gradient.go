@@ -0,0 +1,235 @@
+package mp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Stroke gradients along a path. SVG (like PostScript and PDF) has no paint
+// that follows a curve, so renderers draw a path with a StrokeGradient as
+// short pieces of interpolated color (see ExpandStrokeGradient).
+
+const (
+	// gradientStep is the arc length of the pieces of a gradient stroke.
+	gradientStep = 2.0
+	// gradientMaxPieces limits the number of pieces per path.
+	gradientMaxPieces = 256
+)
+
+// GradientStop is a color at a position along a path.
+type GradientStop struct {
+	Offset Number // fraction of the arc length, 0 at the start, 1 at the end
+	Color  Color
+}
+
+// StrokeGradient makes the stroke color vary along the path's arc length
+// between two or more stops, e.g. to show the direction or intensity of a
+// flow. Colors between stops are interpolated in RGB (and opacity); this
+// works for colors made with ColorRGB, ColorRGBA, ColorGray, ColorCMYK and
+// hex ColorCSS values. Other colors are not interpolated: the nearer stop
+// wins. Envelopes of polygonal pens are filled in the plain stroke color.
+type StrokeGradient struct {
+	Stops []GradientStop
+}
+
+// NewStrokeGradient returns a gradient through the given colors, evenly
+// spaced from the start to the end of the path.
+//
+// Example:
+//
+//	g := mp.NewStrokeGradient(mp.ColorRGB(0, 0, 1), mp.ColorRGB(1, 0, 0))
+func NewStrokeGradient(colors ...Color) *StrokeGradient {
+	g := &StrokeGradient{}
+	for i, c := range colors {
+		offset := Number(0)
+		if len(colors) > 1 {
+			offset = Number(i) / Number(len(colors)-1)
+		}
+		g.Stops = append(g.Stops, GradientStop{Offset: offset, Color: c})
+	}
+	return g
+}
+
+// WithStrokeGradient sets a stroke gradient through the given colors (see
+// NewStrokeGradient).
+func WithStrokeGradient(colors ...Color) StyleOption {
+	return func(s *Style) { s.Gradient = NewStrokeGradient(colors...) }
+}
+
+// ColorAt returns the color of g at the fraction f of the arc length.
+func (g *StrokeGradient) ColorAt(f Number) Color {
+	if g == nil || len(g.Stops) == 0 {
+		return Color{}
+	}
+	stops := make([]GradientStop, len(g.Stops))
+	copy(stops, g.Stops)
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].Offset < stops[j].Offset })
+	if f <= stops[0].Offset {
+		return stops[0].Color
+	}
+	for i := 1; i < len(stops); i++ {
+		a, b := stops[i-1], stops[i]
+		if f > b.Offset {
+			continue
+		}
+		span := b.Offset - a.Offset
+		if span <= 0 {
+			return b.Color
+		}
+		return mixColors(a.Color, b.Color, (f-a.Offset)/span)
+	}
+	return stops[len(stops)-1].Color
+}
+
+// mixColors interpolates between a (t = 0) and b (t = 1).
+func mixColors(a, b Color, t Number) Color {
+	ar, ag, ab, ok1 := colorComponents(a)
+	br, bg, bb, ok2 := colorComponents(b)
+	if !ok1 || !ok2 {
+		if t < 0.5 {
+			return a
+		}
+		return b
+	}
+	lerp := func(x, y Number) Number { return x + (y-x)*t }
+	aop, aok := a.Opacity()
+	bop, bok := b.Opacity()
+	if !aok && !bok {
+		return ColorRGB(lerp(ar, br), lerp(ag, bg), lerp(ab, bb))
+	}
+	if !aok {
+		aop = 1
+	}
+	if !bok {
+		bop = 1
+	}
+	return ColorRGBA(lerp(ar, br), lerp(ag, bg), lerp(ab, bb), lerp(aop, bop))
+}
+
+// colorComponents returns the RGB components in [0,1] of an "rgb(r,g,b)"
+// or hex color.
+func colorComponents(c Color) (r, g, b Number, ok bool) {
+	css := strings.TrimSpace(c.CSS())
+	var ri, gi, bi int
+	switch {
+	case strings.HasPrefix(css, "rgb("):
+		if n, err := fmt.Sscanf(css, "rgb(%d,%d,%d)", &ri, &gi, &bi); err != nil || n != 3 {
+			return 0, 0, 0, false
+		}
+	case strings.HasPrefix(css, "#") && (len(css) == 7 || len(css) == 4):
+		hex := css[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		ri, gi, bi = int(v>>16), int(v>>8&0xff), int(v&0xff)
+	default:
+		return 0, 0, 0, false
+	}
+	return Number(ri) / 255, Number(gi) / 255, Number(bi) / 255, true
+}
+
+// ExpandStrokeGradient returns the plain paths that draw p with its
+// Gradient: p itself if it has none, otherwise consecutive pieces of about
+// gradientStep arc length (at most gradientMaxPieces), each stroked with
+// the gradient's color at its middle. Arrowheads are returned as separate
+// filled heads in the colors of the path's ends, and the pieces are cut
+// back for them as for a plain arrow. Dash patterns continue across the
+// pieces. A filled cycle also gets a fill-only copy first. Renderers call
+// it for every path they draw.
+func ExpandStrokeGradient(p *Path) []*Path {
+	if p == nil || p.Head == nil || p.Style.Gradient == nil || len(p.Style.Gradient.Stops) == 0 {
+		return []*Path{p}
+	}
+	g := p.Style.Gradient
+	line := p.Style
+	line.Gradient = nil
+	line.Arrow = ArrowStyle{}
+	line.Fill = Color{}
+
+	var out []*Path
+	if fill := p.Style.Fill.CSS(); fill != "" && fill != "none" && p.Head.LType != KnotEndpoint {
+		f := p.Copy()
+		f.Style.Gradient = nil
+		f.Style.Stroke = ColorCSS("none")
+		f.Style.Arrow = ArrowStyle{}
+		f.Envelope = nil
+		out = append(out, f)
+	}
+
+	total := p.ArcLength()
+	if total <= 0 {
+		q := p.Copy()
+		q.Style = line
+		q.Style.Stroke = g.ColorAt(0)
+		return append(out, q)
+	}
+
+	// Cut back the ends for the arrowheads.
+	ahLength, ahAngle := p.Style.Arrow.Length, p.Style.Arrow.Angle
+	if ahLength <= 0 {
+		ahLength = DefaultAHLength
+	}
+	if ahAngle <= 0 {
+		ahAngle = DefaultAHAngle
+	}
+	base := ArrowHeadDepth(p.Style.Arrow)
+	var start, end Number
+	if p.Style.Arrow.Start {
+		start = base
+	}
+	if p.Style.Arrow.End {
+		end = base
+	}
+	shaft := p
+	if start > 0 || end > 0 {
+		shaft = ShortenPathForArrow(p, start, end)
+	}
+
+	length := shaft.ArcLength()
+	n := int(math.Ceil(length / gradientStep))
+	if n < 1 {
+		n = 1
+	}
+	if n > gradientMaxPieces {
+		n = gradientMaxPieces
+	}
+	t0 := Number(0)
+	for i := 0; i < n; i++ {
+		s0, s1 := length*Number(i)/Number(n), length*Number(i+1)/Number(n)
+		t1 := Number(shaft.PathLength())
+		if i < n-1 {
+			t1 = shaft.ArcTime(s1)
+		}
+		piece := shaft.Subpath(t0, t1)
+		piece.Style = line
+		piece.Style.Stroke = g.ColorAt((start + (s0+s1)/2) / total)
+		if line.Dash != nil {
+			piece.Style.Dash = line.Dash.Shifted(s0)
+		}
+		out = append(out, piece)
+		t0 = t1
+	}
+
+	if p.Style.Arrow.End {
+		if head := ArrowHeadEnd(p, ahLength, ahAngle); head != nil {
+			head.Style.Fill = g.ColorAt(1)
+			head.Style.Stroke = ColorCSS("none")
+			out = append(out, head)
+		}
+	}
+	if p.Style.Arrow.Start {
+		if head := ArrowHeadStart(p, ahLength, ahAngle); head != nil {
+			head.Style.Fill = g.ColorAt(0)
+			head.Style.Stroke = ColorCSS("none")
+			out = append(out, head)
+		}
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStrokeGradientColorAt(t *testing.T) {
+	g := NewStrokeGradient(ColorRGB(0, 0, 1), ColorRGB(1, 0, 0))
+	cases := []struct {
+		f    Number
+		want string
+	}{
+		{-1, "rgb(0,0,255)"},
+		{0, "rgb(0,0,255)"},
+		{0.5, "rgb(128,0,128)"},
+		{1, "rgb(255,0,0)"},
+		{2, "rgb(255,0,0)"},
+	}
+	for _, c := range cases {
+		if got := g.ColorAt(c.f).CSS(); got != c.want {
+			t.Errorf("ColorAt(%g) = %s, want %s", c.f, got, c.want)
+		}
+	}
+	// Hex colors and opacity.
+	h := &StrokeGradient{Stops: []GradientStop{{1, ColorCSS("#ffffff")}, {0, ColorRGBA(0, 0, 0, 0)}}}
+	c := h.ColorAt(0.5)
+	if c.CSS() != "rgb(128,128,128)" {
+		t.Errorf("hex mix = %s", c.CSS())
+	}
+	if op, ok := c.Opacity(); !ok || math.Abs(op-0.5) > 1e-9 {
+		t.Errorf("opacity = %g, %v, want 0.5", op, ok)
+	}
+	// Named colors switch at the middle.
+	n := NewStrokeGradient(ColorCSS("red"), ColorCSS("blue"))
+	if n.ColorAt(0.4).CSS() != "red" || n.ColorAt(0.6).CSS() != "blue" {
+		t.Error("named colors should switch at the middle")
+	}
+}
+
+func TestExpandStrokeGradient(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(20, 0)}, false)
+	p.Style = NewStyle(WithStrokeWidth(1), WithStrokeGradient(ColorGray(0), ColorGray(1)), WithDash(DashEvenly()))
+	out := ExpandStrokeGradient(p)
+	if len(out) != 10 {
+		t.Fatalf("got %d pieces, want 10", len(out))
+	}
+	for i, q := range out {
+		if q.Style.Gradient != nil {
+			t.Fatalf("piece %d still has a gradient", i)
+		}
+		x0, _ := q.PointOf(0)
+		if math.Abs(x0-Number(2*i)) > 1e-6 {
+			t.Errorf("piece %d starts at %g, want %d", i, x0, 2*i)
+		}
+		if q.Style.Dash == nil || math.Abs(q.Style.Dash.Offset-Number(2*i)) > 1e-6 {
+			t.Errorf("piece %d dash offset not continued", i)
+		}
+	}
+	if out[0].Style.Stroke.CSS() != "rgb(13,13,13)" || out[9].Style.Stroke.CSS() != "rgb(242,242,242)" {
+		t.Errorf("end colors %s, %s", out[0].Style.Stroke.CSS(), out[9].Style.Stroke.CSS())
+	}
+
+	// With an arrow the shaft stops at the head's base; the head is last.
+	p.Style.Arrow = ArrowStyle{End: true}
+	out = ExpandStrokeGradient(p)
+	head := out[len(out)-1]
+	if head.Style.Fill.CSS() != "rgb(255,255,255)" {
+		t.Errorf("head fill = %s", head.Style.Fill.CSS())
+	}
+	shaft := out[len(out)-2]
+	x, _ := shaft.PointOf(Number(shaft.PathLength()))
+	if want := 20 - DefaultAHLength*math.Cos(DefaultAHAngle*math.Pi/360); math.Abs(x-want) > 1e-3 {
+		t.Errorf("shaft ends at %g, want %g", x, want)
+	}
+
+	// Without a gradient the path is returned as is.
+	p.Style.Gradient = nil
+	if out := ExpandStrokeGradient(p); len(out) != 1 || out[0] != p {
+		t.Error("path without gradient should be returned unchanged")
+	}
+}
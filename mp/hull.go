@@ -0,0 +1,177 @@
+package mp
+
+import "math"
+
+// Enclosing shapes for point sets and paths. The convex hull is the same
+// monotone chain computation that MakePen uses for polygonal pens.
+
+// hullSamples is the number of samples per segment used when paths are
+// reduced to point sets.
+const hullSamples = 16
+
+// ConvexHull returns the convex hull of pts as a cyclic path of straight
+// segments in counterclockwise order. Duplicate and collinear points are
+// dropped. Returns nil for an empty point set.
+//
+// Example:
+//
+//	outline := mp.ConvexHull(nodeCenters)
+func ConvexHull(pts []Point) *Path {
+	hull := convexHullPoints(pts)
+	if len(hull) == 0 {
+		return nil
+	}
+	return straightPath(hull, true)
+}
+
+// ConvexHullOfPaths returns the convex hull of all given paths (each
+// sampled along its curve, including extrema) as a cyclic path. Use it to
+// draw an enclosing outline around a group of drawn elements.
+func ConvexHullOfPaths(paths ...*Path) *Path {
+	return ConvexHull(pathSamples(paths...))
+}
+
+// BoundingCircle returns the smallest circle enclosing pts (Welzl's
+// algorithm in its iterative form) as a fullcircle scaled and shifted into
+// place. Returns nil for an empty point set.
+func BoundingCircle(pts []Point) *Path {
+	if len(pts) == 0 {
+		return nil
+	}
+	c, r := minEnclosingCircle(pts)
+	return Scaled(2 * r).Then(Shifted(c.X, c.Y)).ApplyToPath(FullCircle())
+}
+
+// OrientedBBox returns the minimum-area rectangle enclosing pts, which may be
+// rotated (rotating calipers over the convex hull edges). The result is a
+// cyclic path of four straight segments in counterclockwise order. Returns
+// nil for an empty point set.
+func OrientedBBox(pts []Point) *Path {
+	hull := convexHullPoints(pts)
+	if len(hull) == 0 {
+		return nil
+	}
+	if len(hull) < 3 {
+		// Degenerate: a point or a segment; the rectangle collapses.
+		a, b := hull[0], hull[len(hull)-1]
+		return straightPath([]Point{a, b, b, a}, true)
+	}
+	bestArea := math.Inf(1)
+	var best [4]Point
+	for i := range hull {
+		a, b := hull[i], hull[(i+1)%len(hull)]
+		ux, uy := b.X-a.X, b.Y-a.Y
+		l := math.Hypot(ux, uy)
+		if l == 0 {
+			continue
+		}
+		ux, uy = ux/l, uy/l
+		// Extent along the edge (u) and its normal (v)
+		minU, maxU := math.Inf(1), math.Inf(-1)
+		minV, maxV := math.Inf(1), math.Inf(-1)
+		for _, p := range hull {
+			du, dv := (p.X-a.X)*ux+(p.Y-a.Y)*uy, -(p.X-a.X)*uy+(p.Y-a.Y)*ux
+			minU, maxU = math.Min(minU, du), math.Max(maxU, du)
+			minV, maxV = math.Min(minV, dv), math.Max(maxV, dv)
+		}
+		if area := (maxU - minU) * (maxV - minV); area < bestArea {
+			bestArea = area
+			corner := func(u, v float64) Point {
+				return Point{X: a.X + u*ux - v*uy, Y: a.Y + u*uy + v*ux}
+			}
+			best = [4]Point{corner(minU, minV), corner(maxU, minV), corner(maxU, maxV), corner(minU, maxV)}
+		}
+	}
+	return straightPath(best[:], true)
+}
+
+// convexHullPoints returns the hull vertices of pts in counterclockwise order
+// without modifying pts.
+func convexHullPoints(pts []Point) []Point {
+	if len(pts) == 0 {
+		return nil
+	}
+	raw := make([][2]Number, 0, len(pts))
+	for _, p := range pts {
+		raw = append(raw, [2]Number{p.X, p.Y})
+	}
+	var hull [][2]Number
+	if len(raw) < 3 {
+		hull = raw
+	} else {
+		hull = convexHull(raw)
+	}
+	res := make([]Point, 0, len(hull))
+	for _, h := range hull {
+		pt := Point{X: h[0], Y: h[1]}
+		// Coincident input points can survive as duplicate vertices.
+		if len(res) > 0 && (res[len(res)-1] == pt || res[0] == pt) {
+			continue
+		}
+		res = append(res, pt)
+	}
+	return res
+}
+
+// pathSamples flattens the given paths into a point set.
+func pathSamples(paths ...*Path) []Point {
+	var pts []Point
+	for _, p := range paths {
+		flat, _ := flattenPath(p, hullSamples)
+		for _, f := range flat {
+			pts = append(pts, Point{X: f[0], Y: f[1]})
+		}
+	}
+	return pts
+}
+
+// minEnclosingCircle computes the smallest enclosing circle of pts.
+func minEnclosingCircle(pts []Point) (Point, float64) {
+	const eps = 1e-9
+	inside := func(c Point, r float64, p Point) bool {
+		return math.Hypot(p.X-c.X, p.Y-c.Y) <= r+eps
+	}
+	c, r := pts[0], 0.0
+	for i := 1; i < len(pts); i++ {
+		if inside(c, r, pts[i]) {
+			continue
+		}
+		c, r = pts[i], 0
+		for j := 0; j < i; j++ {
+			if inside(c, r, pts[j]) {
+				continue
+			}
+			c = MidPoint(pts[i], pts[j])
+			r = Distance(pts[i], pts[j]) / 2
+			for k := 0; k < j; k++ {
+				if inside(c, r, pts[k]) {
+					continue
+				}
+				if cc, ok := circumcenter(pts[i], pts[j], pts[k]); ok {
+					c, r = cc, Distance(cc, pts[i])
+					continue
+				}
+				// Collinear: the two points farthest apart form the diameter.
+				a, b := pts[i], pts[k]
+				if Distance(pts[j], pts[k]) > Distance(a, b) {
+					a = pts[j]
+				}
+				c, r = MidPoint(a, b), Distance(a, b)/2
+			}
+		}
+	}
+	return c, r
+}
+
+// circumcenter returns the center of the circle through a, b and c.
+func circumcenter(a, b, c Point) (Point, bool) {
+	d := 2 * (a.X*(b.Y-c.Y) + b.X*(c.Y-a.Y) + c.X*(a.Y-b.Y))
+	if d == 0 {
+		return Point{}, false
+	}
+	a2, b2, c2 := a.X*a.X+a.Y*a.Y, b.X*b.X+b.Y*b.Y, c.X*c.X+c.Y*c.Y
+	return Point{
+		X: (a2*(b.Y-c.Y) + b2*(c.Y-a.Y) + c2*(a.Y-b.Y)) / d,
+		Y: (a2*(c.X-b.X) + b2*(a.X-c.X) + c2*(b.X-a.X)) / d,
+	}, true
+}
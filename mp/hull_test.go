@@ -0,0 +1,65 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvexHull(t *testing.T) {
+	pts := []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {5, 5}, {2, 8}, {10, 5}}
+	hull := ConvexHull(pts)
+	if hull == nil {
+		t.Fatal("ConvexHull returned nil")
+	}
+	if n := hull.PathLength(); n != 4 {
+		t.Fatalf("expected 4 hull segments, got %d", n)
+	}
+	if hull.Head.LType == KnotEndpoint {
+		t.Errorf("hull should be a cycle")
+	}
+	if pts[4] != (Point{5, 5}) {
+		t.Errorf("input points were reordered")
+	}
+	if ConvexHull(nil) != nil {
+		t.Errorf("expected nil for empty input")
+	}
+	if h := ConvexHull([]Point{{1, 1}, {1, 1}, {1, 1}}); h == nil || h.PathLength() != 1 {
+		t.Errorf("expected single-knot hull for coincident points")
+	}
+}
+
+func TestBoundingCircle(t *testing.T) {
+	pts := []Point{{0, 0}, {4, 0}, {2, 1}, {2, -1}, {1, 0.5}}
+	c := BoundingCircle(pts)
+	minX, minY, maxX, maxY := PathBBox(c)
+	if !approxEqual(minX, 0, 1e-3) || !approxEqual(maxX, 4, 1e-3) ||
+		!approxEqual(minY, -2, 1e-3) || !approxEqual(maxY, 2, 1e-3) {
+		t.Errorf("BoundingCircle bbox = %g %g %g %g", minX, minY, maxX, maxY)
+	}
+	// Collinear points
+	c = BoundingCircle([]Point{{0, 0}, {1, 0}, {6, 0}})
+	minX, _, maxX, _ = PathBBox(c)
+	if !approxEqual(minX, 0, 1e-3) || !approxEqual(maxX, 6, 1e-3) {
+		t.Errorf("collinear BoundingCircle x range = %g..%g", minX, maxX)
+	}
+}
+
+func TestOrientedBBox(t *testing.T) {
+	// A 10×2 rectangle rotated by 30 degrees
+	rot := Rotated(30)
+	var pts []Point
+	for _, p := range []Point{{0, 0}, {10, 0}, {10, 2}, {0, 2}, {5, 1}} {
+		x, y := rot.ApplyToPoint(p.X, p.Y)
+		pts = append(pts, Point{X: x, Y: y})
+	}
+	box := OrientedBBox(pts)
+	if box == nil || box.PathLength() != 4 {
+		t.Fatalf("expected a 4-segment cycle")
+	}
+	a := box.Head
+	w := math.Hypot(a.Next.XCoord-a.XCoord, a.Next.YCoord-a.YCoord)
+	h := math.Hypot(a.Next.Next.XCoord-a.Next.XCoord, a.Next.Next.YCoord-a.Next.YCoord)
+	if !approxEqual(w*h, 20, 1e-6) {
+		t.Errorf("OrientedBBox area = %g, want 20", w*h)
+	}
+}
@@ -0,0 +1,113 @@
+package mp
+
+import "math"
+
+// Point-in-path tests. MetaPost has no such operator; the nonzero rule
+// matches how "fill" paints a cycle.
+
+// insideSamples is the number of samples per segment used to flatten a path
+// for the polygon tests of Outline.
+const insideSamples = 16
+
+// windingMaxDepth bounds the subdivision of a segment by WindingNumber;
+// at that depth the segment is shorter than any distance that matters.
+const windingMaxDepth = 40
+
+// Contains reports whether (x, y) lies inside the region bounded by p using
+// the nonzero winding rule, i.e. the area MetaPost's "fill p" would paint.
+// Open paths are treated as if closed by a straight line.
+//
+// Example:
+//
+//	region := mp.BuildCycle(a, b, c)
+//	if region.Contains(click.X, click.Y) { ... }
+func (p *Path) Contains(x, y Number) bool {
+	return p.WindingNumber(x, y) != 0
+}
+
+// WindingNumber returns how often the outline of p winds around (x, y),
+// counterclockwise positive. Open paths are treated as if closed by a
+// straight line. The curved segments are not flattened: each is halved
+// until its control polygon lies entirely to one side of the point or of
+// the horizontal through it, where its crossings equal those of its chord,
+// so points close to a curve are classified exactly. Points on the outline
+// count as inside for some parts of the outline and outside for others.
+func (p *Path) WindingNumber(x, y Number) int {
+	if p == nil || p.Head == nil {
+		return 0
+	}
+	pt := P(x, y)
+	wn := 0
+	k := p.Head
+	for {
+		next := k.Next
+		if next == nil || k.RType == KnotEndpoint {
+			break
+		}
+		wn += cubicWinding([4]Point{{k.XCoord, k.YCoord}, {k.RightX, k.RightY}, {next.LeftX, next.LeftY}, {next.XCoord, next.YCoord}}, pt, windingMaxDepth)
+		k = next
+		if k == p.Head {
+			return wn
+		}
+	}
+	// Close an open path with a straight line back to its head.
+	return wn + edgeWinding(P(k.XCoord, k.YCoord), P(p.Head.XCoord, p.Head.YCoord), pt)
+}
+
+// cubicWinding returns the signed crossings of the Bézier segment seg with
+// the ray from pt to the right, counted as edgeWinding counts them.
+func cubicWinding(seg [4]Point, pt Point, depth int) int {
+	minX, maxX := seg[0].X, seg[0].X
+	minY, maxY := seg[0].Y, seg[0].Y
+	for _, c := range seg[1:] {
+		minX, maxX = math.Min(minX, c.X), math.Max(maxX, c.X)
+		minY, maxY = math.Min(minY, c.Y), math.Max(maxY, c.Y)
+	}
+	switch {
+	case maxY < pt.Y || minY > pt.Y || maxX < pt.X:
+		// The ray misses the segment, and its chord as well.
+		return 0
+	case minX > pt.X || depth == 0 || maxX-minX < 1e-12 && maxY-minY < 1e-12:
+		// Right of pt the segment crosses the horizontal through pt as
+		// often as its chord, counted with signs.
+		return edgeWinding(seg[0], seg[3], pt)
+	}
+	a, b := halveCubic(seg)
+	return cubicWinding(a, pt, depth-1) + cubicWinding(b, pt, depth-1)
+}
+
+// halveCubic halves a Bézier segment with de Casteljau's algorithm.
+func halveCubic(seg [4]Point) (a, b [4]Point) {
+	mid := func(p, q Point) Point { return Point{(p.X + q.X) / 2, (p.Y + q.Y) / 2} }
+	p01, p12, p23 := mid(seg[0], seg[1]), mid(seg[1], seg[2]), mid(seg[2], seg[3])
+	p012, p123 := mid(p01, p12), mid(p12, p23)
+	m := mid(p012, p123)
+	return [4]Point{seg[0], p01, p012, m}, [4]Point{m, p123, p23, seg[3]}
+}
+
+// edgeWinding returns the contribution of the edge from a to b to the
+// winding number around pt: +1 for an upward crossing of the horizontal
+// through pt right of pt, -1 for a downward one.
+func edgeWinding(a, b, pt Point) int {
+	isLeft := (b.X-a.X)*(pt.Y-a.Y) - (pt.X-a.X)*(b.Y-a.Y)
+	if a.Y <= pt.Y {
+		if b.Y > pt.Y && isLeft > 0 {
+			return 1
+		}
+	} else if b.Y <= pt.Y && isLeft < 0 {
+		return -1
+	}
+	return 0
+}
+
+// polygonWinding computes the winding number of a closed polygon around pt
+// (Sunday's crossing algorithm).
+func polygonWinding(poly [][2]Number, pt Point) int {
+	wn := 0
+	n := len(poly)
+	for i := 0; i < n; i++ {
+		a, b := poly[i], poly[(i+1)%n]
+		wn += edgeWinding(P(a[0], a[1]), P(b[0], b[1]), pt)
+	}
+	return wn
+}
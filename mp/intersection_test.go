@@ -244,3 +244,44 @@ func TestIntersectionTimes_MultiSegmentCurves(t *testing.T) {
 		t.Logf("Intersection found at (%v, %v)", x, y)
 	}
 }
+
+func TestIntersectionTimesAfter_SkipsTouchAtStart(t *testing.T) {
+	// Circle through (0,0) and (60,0): q touches p at its start.
+	p := makeHorizontalLine(0, 100, 0)
+	q := Scaled(60).Then(Shifted(30, 0)).ApplyToPath(FullCircle())
+
+	t1, _ := p.IntersectionTimes(q)
+	if t1 < 0 || t1 > 0.01 {
+		t.Fatalf("IntersectionTimes t1 = %g, want ≈0", t1)
+	}
+	t1, _ = p.IntersectionTimesAfter(q, 0)
+	if math.Abs(t1-0.6) > 0.001 {
+		t.Errorf("IntersectionTimesAfter t1 = %g, want 0.6", t1)
+	}
+	if all := p.AllIntersectionTimes(q); len(all) != 2 {
+		t.Errorf("AllIntersectionTimes returned %d intersections, want 2: %v", len(all), all)
+	}
+
+	cut := p.CutAfterFrom(q, 0)
+	if x, _ := cut.PointOf(Number(cut.PathLength())); math.Abs(x-60) > 0.1 {
+		t.Errorf("CutAfterFrom ends at x=%g, want 60", x)
+	}
+	cut = p.CutBeforeFrom(q, 0)
+	if x, _ := cut.PointOf(0); math.Abs(x-60) > 0.1 {
+		t.Errorf("CutBeforeFrom starts at x=%g, want 60", x)
+	}
+}
+
+func TestCutNear(t *testing.T) {
+	p := makeHorizontalLine(0, 100, 0)
+	q := Scaled(60).Then(Shifted(30, 0)).ApplyToPath(FullCircle())
+
+	cut := p.CutAfterNear(q, Point{X: 55, Y: 5})
+	if x, _ := cut.PointOf(Number(cut.PathLength())); math.Abs(x-60) > 0.1 {
+		t.Errorf("CutAfterNear ends at x=%g, want 60", x)
+	}
+	cut = p.CutBeforeNear(q, Point{X: 1, Y: 0})
+	if x, _ := cut.PointOf(0); math.Abs(x) > 0.1 {
+		t.Errorf("CutBeforeNear starts at x=%g, want 0", x)
+	}
+}
@@ -1,16 +1,44 @@
 package mp
 
+import (
+	"fmt"
+	"math"
+)
+
+// KnotType says how the path continues on one side of a knot, mirroring
+// mp_left_type/mp_right_type (mplib.h). Before solving, a side is open,
+// curl or given (with the direction in the coordinates of that side);
+// after solving every side of a segment is explicit, with the control
+// point in LeftX/LeftY or RightX/RightY. KnotEndpoint marks the LType of
+// the first and the RType of the last knot of an open path; a path is a
+// cycle exactly when its head's LType is not KnotEndpoint.
 type KnotType uint16
 
 const (
-	KnotEndpoint KnotType = iota
-	KnotExplicit
-	KnotGiven
-	KnotCurl
-	KnotOpen
-	KnotEndCycle
+	KnotEndpoint KnotType = iota // end of an open path
+	KnotExplicit                 // explicit control point
+	KnotGiven                    // given direction, tension in the coordinates
+	KnotCurl                     // curl, tension in the coordinates
+	KnotOpen                     // direction chosen by the solver
+	KnotEndCycle                 // temporary marker used while solving cycles
 )
 
+// knotTypeNames are the names of the knot types, as in mplib.h.
+var knotTypeNames = [...]string{"endpoint", "explicit", "given", "curl", "open", "end_cycle"}
+
+// String returns the MetaPost name of the knot type, e.g. "explicit".
+func (t KnotType) String() string {
+	if t.Valid() {
+		return knotTypeNames[t]
+	}
+	return fmt.Sprintf("KnotType(%d)", uint16(t))
+}
+
+// Valid reports whether t is one of the KnotType constants.
+func (t KnotType) Valid() bool {
+	return t <= KnotEndCycle
+}
+
 type KnotOrigin uint8
 
 const (
@@ -32,6 +60,11 @@ type Knot struct {
 	LType  KnotType
 	RType  KnotType
 	Origin KnotOrigin
+	// Quadratic marks the segment leaving the knot as a quadratic Bézier
+	// curve (see SetQuadraticControl). The cubic control points always
+	// hold the exact degree-elevated curve, so code that does not know
+	// about quadratics still sees the right geometry.
+	Quadratic bool
 }
 
 func NewKnot() *Knot {
@@ -45,3 +78,129 @@ func CopyKnot(p *Knot) *Knot {
 	q := *p
 	return &q
 }
+
+// NewKnotAt returns an open knot at (x, y) with tension 1 on both sides,
+// the knot "z" in MetaPost's "..z..". Mark the ends of an open path by
+// setting the first knot's LType and the last knot's RType to
+// KnotEndpoint.
+func NewKnotAt(x, y Number) *Knot {
+	return &Knot{
+		XCoord: x, YCoord: y,
+		LeftY: unity, RightY: unity,
+		LType: KnotOpen, RType: KnotOpen,
+	}
+}
+
+// Boundary conditions of unsolved knots.
+//
+// Before solving, a knot side that is not explicit stores its boundary
+// condition in the control point fields, as MetaPost does: LeftX/RightX
+// hold a given direction (degrees times AngleMultiplier) or a curl, and
+// LeftY/RightY hold the tension, negative for "tension atleast". The
+// setters below hide this encoding. A side whose tension is unset (zero)
+// or that was explicit gets tension 1.
+
+// SetGivenDirection fixes the direction of the path through k to deg
+// degrees, like MetaPost's "{dir deg}" at a knot. Endpoint sides are left
+// alone, so at the ends of an open path only the inner side is set.
+func (k *Knot) SetGivenDirection(deg Number) *Knot {
+	if k.LType != KnotEndpoint {
+		k.SetDirectionIn(deg)
+	}
+	if k.RType != KnotEndpoint {
+		k.SetDirectionOut(deg)
+	}
+	return k
+}
+
+// SetDirectionIn fixes the direction in which the path arrives at k.
+func (k *Knot) SetDirectionIn(deg Number) *Knot {
+	knotEdits.Add(1)
+	k.initLeftTension()
+	k.LType = KnotGiven
+	k.LeftX = deg * angleMultiplier
+	return k
+}
+
+// SetDirectionOut fixes the direction in which the path leaves k.
+func (k *Knot) SetDirectionOut(deg Number) *Knot {
+	knotEdits.Add(1)
+	k.initRightTension()
+	k.RType = KnotGiven
+	k.RightX = deg * angleMultiplier
+	return k
+}
+
+// SetCurl sets the curl at k, like MetaPost's "{curl c}". Curl 1 is the
+// default at the ends of open paths. As with SetGivenDirection, endpoint
+// sides are left alone.
+func (k *Knot) SetCurl(c Number) *Knot {
+	if k.LType != KnotEndpoint {
+		k.SetCurlIn(c)
+	}
+	if k.RType != KnotEndpoint {
+		k.SetCurlOut(c)
+	}
+	return k
+}
+
+// SetCurlIn sets the curl of the segment arriving at k.
+func (k *Knot) SetCurlIn(c Number) *Knot {
+	knotEdits.Add(1)
+	k.initLeftTension()
+	k.LType = KnotCurl
+	k.LeftX = c
+	return k
+}
+
+// SetCurlOut sets the curl of the segment leaving k.
+func (k *Knot) SetCurlOut(c Number) *Knot {
+	knotEdits.Add(1)
+	k.initRightTension()
+	k.RType = KnotCurl
+	k.RightX = c
+	return k
+}
+
+// SetTension sets the tension of the segment arriving at k (in) and of
+// the segment leaving k (out), like "..tension in.. k ..tension out..".
+// MetaPost requires tensions of at least 3/4. Explicit sides become open.
+func (k *Knot) SetTension(in, out Number) *Knot {
+	knotEdits.Add(1)
+	k.openExplicit()
+	k.LeftY, k.RightY = math.Abs(in), math.Abs(out)
+	return k
+}
+
+// SetTensionAtLeast is SetTension for "tension atleast": the curve is
+// additionally kept inside the triangle formed by the chord and the knot
+// directions where possible.
+func (k *Knot) SetTensionAtLeast(in, out Number) *Knot {
+	knotEdits.Add(1)
+	k.openExplicit()
+	k.LeftY, k.RightY = -math.Abs(in), -math.Abs(out)
+	return k
+}
+
+// openExplicit makes explicit sides of k open, so that they carry a
+// tension instead of a control point.
+func (k *Knot) openExplicit() {
+	if k.LType == KnotExplicit {
+		k.LType = KnotOpen
+	}
+	if k.RType == KnotExplicit {
+		k.RType = KnotOpen
+	}
+}
+
+func (k *Knot) initLeftTension() {
+	if k.LType == KnotExplicit || k.LeftY == 0 {
+		k.LeftY = unity
+	}
+}
+
+func (k *Knot) initRightTension() {
+	if k.RType == KnotExplicit || k.RightY == 0 {
+		k.RightY = unity
+	}
+}
@@ -0,0 +1,55 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKnotSetters(t *testing.T) {
+	// (0,0){up} .. (50,20) .. {down}(100,0)
+	p := NewPath()
+	a, b, c := NewKnotAt(0, 0), NewKnotAt(50, 20), NewKnotAt(100, 0)
+	p.Append(a)
+	p.Append(b)
+	p.Append(c)
+	a.LType, c.RType = KnotEndpoint, KnotEndpoint
+	a.SetGivenDirection(90)
+	c.SetGivenDirection(-90)
+	b.SetTension(1.5, 1.5)
+
+	if a.LType != KnotEndpoint || c.RType != KnotEndpoint {
+		t.Fatal("SetGivenDirection must keep endpoint sides")
+	}
+	if a.RType != KnotGiven || a.RightX != 90*angleMultiplier || a.RightY != 1 {
+		t.Errorf("given direction encoding: type %d, x %g, tension %g", a.RType, a.RightX, a.RightY)
+	}
+	e := NewEngine()
+	e.AddPath(p)
+	if err := e.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	if dx, dy := a.RightX-a.XCoord, a.RightY-a.YCoord; math.Abs(dx) > 1e-9 || dy <= 0 {
+		t.Errorf("path should leave upwards, handle (%g,%g)", dx, dy)
+	}
+	if dx, dy := c.XCoord-c.LeftX, c.YCoord-c.LeftY; math.Abs(dx) > 1e-9 || dy >= 0 {
+		t.Errorf("path should arrive downwards, handle (%g,%g)", dx, dy)
+	}
+}
+
+func TestKnotSetCurlAndTension(t *testing.T) {
+	k := NewKnot()
+	k.LType, k.RType = KnotExplicit, KnotExplicit
+	k.LeftY, k.RightY = 7, 7
+	k.SetTensionAtLeast(1, 2)
+	if k.LType != KnotOpen || k.RType != KnotOpen || k.LeftY != -1 || k.RightY != -2 {
+		t.Errorf("SetTensionAtLeast: %+v", k)
+	}
+	k = NewKnotAt(0, 0).SetCurl(2)
+	if k.LType != KnotCurl || k.RType != KnotCurl || k.LeftX != 2 || k.RightX != 2 || k.LeftY != 1 || k.RightY != 1 {
+		t.Errorf("SetCurl: %+v", k)
+	}
+	k = NewKnot().SetCurlOut(0)
+	if k.RType != KnotCurl || k.RightY != 1 || k.LType != KnotEndpoint {
+		t.Errorf("SetCurlOut: %+v", k)
+	}
+}
@@ -1,6 +1,10 @@
 package mp
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
 
 // Anchor specifies the positioning of a label relative to its reference point.
 // These mirror MetaPost's label suffixes (.lft, .rt, .top, .bot, etc.).
@@ -41,6 +45,13 @@ type Label struct {
 	FontSize    float64 // Font size in points (default: 10)
 	FontFamily  string  // Font family (default: sans-serif for SVG)
 	LabelOffset float64 // Distance from reference point (default: 3bp)
+	// Angle rotates the label, including its offset, counterclockwise by
+	// this many degrees around Position (default 0).
+	Angle float64
+	// TeX marks Text as TeX source, like btex ... etex in MetaPost. Backends
+	// that typeset with TeX pass it through unchanged; SVG shows PlainText
+	// instead, and EPS, written for TeX workflows, refuses it.
+	TeX bool
 }
 
 // NewLabel creates a new label with default settings.
@@ -80,6 +91,22 @@ func (l *Label) WithOffset(offset float64) *Label {
 	return l
 }
 
+// WithAngle sets the rotation of the label in degrees.
+func (l *Label) WithAngle(deg float64) *Label {
+	l.Angle = deg
+	return l
+}
+
+// rotatePoint rotates (x, y) counterclockwise by l.Angle around Position.
+func (l *Label) rotatePoint(x, y float64) (float64, float64) {
+	if l.Angle == 0 {
+		return x, y
+	}
+	sin, cos := math.Sincos(l.Angle * math.Pi / 180)
+	dx, dy := x-l.Position.X, y-l.Position.Y
+	return l.Position.X + dx*cos - dy*sin, l.Position.Y + dx*sin + dy*cos
+}
+
 // LabelOffsetVector returns the offset direction vector for an anchor.
 // These values mirror MetaPost's laboff pairs from plain.mp.
 func LabelOffsetVector(anchor Anchor) (dx, dy float64) {
@@ -113,7 +140,7 @@ func LabelOffsetVector(anchor Anchor) (dx, dy float64) {
 //
 // Returns (xf, yf) where:
 //   - xf=0 means left edge of text, xf=1 means right edge, xf=0.5 means center
-//   - yf=0 means top edge of text, yf=1 means bottom edge, yf=0.5 means middle
+//   - yf=0 means bottom edge of text, yf=1 means top edge, yf=0.5 means middle
 func LabelAnchorFactors(anchor Anchor) (xf, yf float64) {
 	// Values from plain.mp:
 	// labxf=.5;  labyf=.5;     (center)
@@ -174,7 +201,7 @@ func (l *Label) ToPaths(f FontRenderer) ([]*Path, error) {
 	}
 
 	// Get text dimensions for anchor calculation
-	textWidth, textHeight := f.TextBounds(l.Text, fontSize)
+	textWidth, textHeight := f.TextBounds(l.PlainText(), fontSize)
 
 	// Calculate offset direction
 	dx, dy := LabelOffsetVector(l.Anchor)
@@ -197,12 +224,20 @@ func (l *Label) ToPaths(f FontRenderer) ([]*Path, error) {
 	}
 
 	// Convert text to paths
-	return f.TextToPaths(l.Text, TextToPathsOptions{
+	paths, err := f.TextToPaths(l.PlainText(), TextToPathsOptions{
 		FontSize: fontSize,
 		X:        textX,
 		Y:        textY,
 		Color:    color,
 	})
+	if err != nil || l.Angle == 0 {
+		return paths, err
+	}
+	rot := RotatedAround(l.Position.X, l.Position.Y, l.Angle)
+	for i, p := range paths {
+		paths[i] = rot.ApplyToPath(p)
+	}
+	return paths, nil
 }
 
 // EstimateBounds returns an estimated bounding box for the label.
@@ -222,7 +257,11 @@ func (l *Label) EstimateBounds() (minX, minY, maxX, maxY float64) {
 	}
 
 	// Estimate text dimensions
-	textWidth := fontSize * 0.6 * float64(len(l.Text))
+	chars := len(l.Text)
+	if l.TeX {
+		chars = utf8.RuneCountInString(l.PlainText())
+	}
+	textWidth := fontSize * 0.6 * float64(chars)
 	textHeight := fontSize
 
 	// Get offset direction and anchor factors
@@ -235,11 +274,21 @@ func (l *Label) EstimateBounds() (minX, minY, maxX, maxY float64) {
 
 	// Calculate text bounding box based on anchor factors
 	// xf=0: left edge at anchor, xf=1: right edge at anchor
-	// yf=0: top edge at anchor, yf=1: bottom edge at anchor
+	// yf=0: bottom edge at anchor, yf=1: top edge at anchor
 	minX = anchorX - xf*textWidth
 	maxX = anchorX + (1-xf)*textWidth
-	minY = anchorY - (1-yf)*textHeight // In MetaPost coords, y increases upward
-	maxY = anchorY + yf*textHeight
+	minY = anchorY - yf*textHeight // In MetaPost coords, y increases upward
+	maxY = anchorY + (1-yf)*textHeight
 
+	if l.Angle != 0 {
+		corners := [4][2]float64{{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}}
+		minX, minY = math.Inf(1), math.Inf(1)
+		maxX, maxY = math.Inf(-1), math.Inf(-1)
+		for _, c := range corners {
+			x, y := l.rotatePoint(c[0], c[1])
+			minX, minY = math.Min(minX, x), math.Min(minY, y)
+			maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+		}
+	}
 	return minX, minY, maxX, maxY
 }
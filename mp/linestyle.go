@@ -0,0 +1,213 @@
+package mp
+
+import "math"
+
+// LineStyle selects a cartographic line symbol drawn instead of a plain
+// stroke. The zero value draws the path as usual. All symbols are built
+// from the path's stroke color and scaled by its stroke width w (1 if
+// unset); ExpandLineStyle turns a path into the plain paths that make up
+// its symbol.
+type LineStyle int
+
+const (
+	// LineStyleSolid draws the path as an ordinary stroke.
+	LineStyleSolid LineStyle = iota
+	// LineStyleRailway draws two rails 3w apart with cross ties of length
+	// 5w every 4w.
+	LineStyleRailway
+	// LineStyleRoadCasing draws the two dashed casing lines of a road 3w
+	// apart (the usual symbol for tracks and unpaved roads).
+	LineStyleRoadCasing
+	// LineStyleCliff draws the path with hachures of length 3w every 2w on
+	// its right-hand side, pointing down the cliff.
+	LineStyleCliff
+	// LineStyleZigzag draws a zigzag of amplitude 1.5w and period 4w along
+	// the path, used for uncertain or disputed boundaries.
+	LineStyleZigzag
+)
+
+// parallelSteps is the number of cubic pieces per segment of a parallel.
+const parallelSteps = 4
+
+// WithLineStyle sets the cartographic line style.
+func WithLineStyle(ls LineStyle) StyleOption { return func(s *Style) { s.LineStyle = ls } }
+
+// Parallel returns the curve at distance d to the left of p (to the right
+// for negative d), as used for the two rails of a railway or the casing of
+// a road. Each segment of p is approximated by parallelSteps cubic pieces
+// fitted through the offset curve, and corners are mitered. Unlike the
+// envelope of a pen stroke the result is a single path of the same kind
+// as p (open or cycle); d should stay below the radius of curvature of p,
+// otherwise the parallel loops back on itself. The style is copied.
+//
+// Example:
+//
+//	left, right := mp.Parallel(road, 2), mp.Parallel(road, -2)
+func Parallel(p *Path, d Number) *Path {
+	if p == nil || p.Head == nil {
+		return nil
+	}
+	n := p.PathLength()
+	if n == 0 {
+		return p.Copy()
+	}
+	cycle := p.Head.LType != KnotEndpoint
+	steps := n * parallelSteps
+	times := make([]Number, steps+1)
+	for i := range times {
+		times[i] = Number(i) / parallelSteps
+	}
+	points := make([]Point, steps+1)
+	for i, t := range times {
+		points[i] = parallelPoint(p, t, d, cycle || (i > 0 && i < steps))
+	}
+	var pts []Point
+	if cycle {
+		pts = points[:steps]
+	} else {
+		pts = points
+	}
+	q := straightPath(pts, cycle)
+	q.Style = p.Style
+	k := q.Head
+	for i := 0; i < steps; i++ {
+		next := k.Next
+		t0, t1 := times[i], times[i+1]
+		d0x, d0y := outgoingDirection(p, t0)
+		d1x, d1y := incomingDirection(p, t1)
+		mx, my := p.PointOf((t0 + t1) / 2)
+		nx, ny := p.DirectionOf((t0 + t1) / 2)
+		if ux, uy, ok := unitVector(nx, ny); ok {
+			mx, my = mx-d*uy, my+d*ux
+		}
+		a, b := fitHandles(k.XCoord, k.YCoord, next.XCoord, next.YCoord, d0x, d0y, d1x, d1y, mx, my)
+		k.RightX, k.RightY = k.XCoord+a*d0x, k.YCoord+a*d0y
+		next.LeftX, next.LeftY = next.XCoord-b*d1x, next.YCoord-b*d1y
+		k = next
+	}
+	return q
+}
+
+// parallelPoint returns the point at distance d to the left of p at time
+// t. If corner is set the incoming and outgoing directions are both taken
+// into account and the point is placed on the miter.
+func parallelPoint(p *Path, t, d Number, corner bool) Point {
+	x, y := p.PointOf(t)
+	ox, oy := outgoingDirection(p, t)
+	if !corner {
+		if t > 0 {
+			ox, oy = incomingDirection(p, t)
+		}
+		return P(x-d*oy, y+d*ox)
+	}
+	ix, iy := incomingDirection(p, t)
+	// Bisector of the two left normals (-dy, dx).
+	bx, by, ok := unitVector(-iy-oy, ix+ox)
+	if !ok {
+		return P(x-d*oy, y+d*ox)
+	}
+	// Miter length d / cos(half the turn), limited to 4d.
+	cos := bx*(-oy) + by*ox
+	scale := d / math.Max(cos, 0.25)
+	return P(x+scale*bx, y+scale*by)
+}
+
+// ExpandLineStyle returns the plain paths that draw p with its LineStyle:
+// p itself for LineStyleSolid, otherwise the rails, ties, casings,
+// hachures or zigzag of the symbol, all with LineStyleSolid, without
+// arrows and unfilled. A filled cycle also gets a fill-only copy first.
+// Renderers call it for every path they draw.
+func ExpandLineStyle(p *Path) []*Path {
+	if p == nil || p.Head == nil || p.Style.LineStyle == LineStyleSolid {
+		return []*Path{p}
+	}
+	w := p.Style.StrokeWidth
+	if w <= 0 {
+		w = 1
+	}
+	line := p.Style
+	line.LineStyle = LineStyleSolid
+	line.Arrow = ArrowStyle{}
+	line.Fill = Color{}
+	line.Pen = nil
+	line.Dash = nil
+	thin := line
+	thin.StrokeWidth = w / 2
+
+	var out []*Path
+	if fill := p.Style.Fill.CSS(); fill != "" && fill != "none" && p.Head.LType != KnotEndpoint {
+		f := p.Copy()
+		f.Style.LineStyle = LineStyleSolid
+		f.Style.Stroke = ColorCSS("none")
+		f.Style.Arrow = ArrowStyle{}
+		f.Envelope = nil
+		out = append(out, f)
+	}
+	styled := func(q *Path, s Style) *Path {
+		q.Style = s
+		q.Envelope = nil
+		return q
+	}
+	switch p.Style.LineStyle {
+	case LineStyleRailway:
+		out = append(out, styled(Parallel(p, 1.5*w), thin), styled(Parallel(p, -1.5*w), thin))
+		tie := straightPath([]Point{P(0, -2.5*w), P(0, 2.5*w)}, false)
+		tie.Style = thin
+		out = append(out, PatternAlong(p, tie, 4*w, true)...)
+	case LineStyleRoadCasing:
+		casing := thin
+		casing.Dash = DashEvenly().Scaled(w)
+		out = append(out, styled(Parallel(p, 1.5*w), casing), styled(Parallel(p, -1.5*w), casing))
+	case LineStyleCliff:
+		out = append(out, styled(p.Copy(), line))
+		hachure := straightPath([]Point{P(0, 0), P(0, -3*w)}, false)
+		hachure.Style = thin
+		out = append(out, PatternAlong(p, hachure, 2*w, true)...)
+	case LineStyleZigzag:
+		zz := zigzag(p, 1.5*w, 2*w)
+		zz.Style = line
+		zz.Style.LineJoin = LineJoinMiter
+		out = append(out, zz)
+	default:
+		out = append(out, styled(p.Copy(), line))
+	}
+	return out
+}
+
+// zigzag returns a polyline that alternates between amplitude to the left
+// and to the right of p, one corner every halfPeriod of arc length, with
+// the first corner after the start on the left. Open paths start and end
+// on p; on cycles the number of corners is even.
+func zigzag(p *Path, amplitude, halfPeriod Number) *Path {
+	total := p.ArcLength()
+	cycle := p.Head.LType != KnotEndpoint
+	n := int(math.Round(total / halfPeriod))
+	if n < 2 {
+		n = 2
+	}
+	if cycle && n%2 == 1 {
+		n++
+	}
+	last := n
+	if cycle {
+		last = n - 1
+	}
+	pts := make([]Point, 0, last+1)
+	for i := 0; i <= last; i++ {
+		t := p.ArcTime(total * Number(i) / Number(n))
+		x, y := p.PointOf(t)
+		side := -amplitude
+		if i%2 == 1 {
+			side = amplitude
+		}
+		if !cycle && (i == 0 || i == n) {
+			side = 0
+		}
+		dx, dy := outgoingDirection(p, t)
+		if i == n {
+			dx, dy = incomingDirection(p, t)
+		}
+		pts = append(pts, P(x-side*dy, y+side*dx))
+	}
+	return straightPath(pts, cycle)
+}
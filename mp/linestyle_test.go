@@ -0,0 +1,107 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParallelLine(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(10, 0), P(10, 10)}, false)
+	q := Parallel(p, 1)
+	if q.Head.LType != KnotEndpoint {
+		t.Fatal("parallel of an open path should be open")
+	}
+	if x, y := q.Head.XCoord, q.Head.YCoord; math.Abs(x) > 1e-9 || math.Abs(y-1) > 1e-9 {
+		t.Errorf("start (%g,%g), want (0,1)", x, y)
+	}
+	if x, y := q.Head.Prev.XCoord, q.Head.Prev.YCoord; math.Abs(x-9) > 1e-9 || math.Abs(y-10) > 1e-9 {
+		t.Errorf("end (%g,%g), want (9,10)", x, y)
+	}
+	// The corner is mitered.
+	x, y := q.PointOf(Number(parallelSteps))
+	if math.Abs(x-9) > 1e-9 || math.Abs(y-1) > 1e-9 {
+		t.Errorf("corner (%g,%g), want (9,1)", x, y)
+	}
+}
+
+func TestParallelCircle(t *testing.T) {
+	circle := FullCircle().Scaled(20)
+	for _, d := range []Number{2, -2} {
+		q := Parallel(circle, d)
+		if q.Head.LType == KnotEndpoint {
+			t.Fatal("parallel of a cycle should be a cycle")
+		}
+		// Counterclockwise: left is inside.
+		want := 10 - d
+		for i := 0; i < 64; i++ {
+			x, y := q.PointOf(Number(q.PathLength()) * Number(i) / 64)
+			if r := math.Hypot(x, y); math.Abs(r-want) > 0.01 {
+				t.Fatalf("d=%g: radius %g at sample %d, want %g", d, r, i, want)
+			}
+		}
+	}
+}
+
+func TestExpandLineStyle(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(20, 0)}, false)
+	p.Style = NewStyle(WithStroke(ColorCSS("black")), WithStrokeWidth(1))
+	if got := ExpandLineStyle(p); len(got) != 1 || got[0] != p {
+		t.Fatal("solid line should expand to itself")
+	}
+
+	p.Style.LineStyle = LineStyleRailway
+	got := ExpandLineStyle(p)
+	// Two rails plus floor(20/4)+1 ties.
+	if len(got) != 8 {
+		t.Fatalf("railway: %d paths, want 8", len(got))
+	}
+	for _, q := range got {
+		if q.Style.LineStyle != LineStyleSolid || q.Style.StrokeWidth != 0.5 {
+			t.Errorf("railway part has style %+v", q.Style)
+		}
+	}
+
+	p.Style.LineStyle = LineStyleRoadCasing
+	got = ExpandLineStyle(p)
+	if len(got) != 2 || got[0].Style.Dash == nil {
+		t.Fatalf("road casing: want 2 dashed paths, got %d", len(got))
+	}
+
+	p.Style.LineStyle = LineStyleCliff
+	got = ExpandLineStyle(p)
+	if len(got) != 12 {
+		t.Fatalf("cliff: %d paths, want 12", len(got))
+	}
+	// Hachures hang to the right of the path (below for a path going right).
+	if tip := got[1].Head.Next; tip.YCoord >= 0 {
+		t.Errorf("hachure tip at y=%g, want below the path", tip.YCoord)
+	}
+
+	p.Style.LineStyle = LineStyleZigzag
+	got = ExpandLineStyle(p)
+	if len(got) != 1 {
+		t.Fatalf("zigzag: %d paths, want 1", len(got))
+	}
+	zz := got[0]
+	if zz.PathLength() != 10 {
+		t.Errorf("zigzag has %d segments, want 10", zz.PathLength())
+	}
+	if y := zz.Head.Next.YCoord; math.Abs(y-1.5) > 1e-9 {
+		t.Errorf("first zigzag corner at y=%g, want 1.5", y)
+	}
+}
+
+func TestExpandLineStyleFilledCycle(t *testing.T) {
+	sq := UnitSquare().Scaled(10)
+	sq.Style = NewStyle(WithFill(ColorCSS("green")), WithLineStyle(LineStyleZigzag))
+	got := ExpandLineStyle(sq)
+	if len(got) != 2 {
+		t.Fatalf("%d paths, want fill and zigzag", len(got))
+	}
+	if got[0].Style.Stroke.CSS() != "none" || got[1].Style.Fill.CSS() != "" {
+		t.Error("want a fill-only copy followed by an unfilled zigzag")
+	}
+	if got[1].Head.LType == KnotEndpoint || got[1].PathLength()%2 != 0 {
+		t.Error("zigzag of a cycle should be a cycle with an even number of corners")
+	}
+}
@@ -1,24 +1,29 @@
 package mp
 
-import "math"
+import (
+	"math"
 
-// Math helpers mirroring mpmathdouble.c (double backend).
+	"github.com/boxesandglue/mpgo/mp/num"
+)
+
+// Math helpers mirroring mpmathdouble.c (double backend). The primitives
+// live in package num, which exports them for code outside the solver.
 
 func makeScaled(p, q Number) Number {
-	return p / q
+	return num.MakeScaled(p, q)
 }
 
 func takeScaled(p, q Number) Number {
-	return p * q
+	return num.TakeScaled(p, q)
 }
 
 // Fractions follow mpmathdouble semantics: scale by fractionMultiplier (4096.0).
 func makeFraction(p, q Number) Number {
-	return (p / q) * fractionMultiplier
+	return num.MakeFraction(p, q)
 }
 
 func takeFraction(p, q Number) Number {
-	return (p * q) / fractionMultiplier
+	return num.TakeFraction(p, q)
 }
 
 func roundUnscaled(x Number) int {
@@ -41,17 +46,7 @@ func numberSqrt(x Number) Number {
 // input angle z is in degrees scaled by angleMultiplier; outputs are
 // cos/sin scaled by fractionMultiplier.
 func numberSinCos(z Number) (cos Number, sin Number) {
-	rad := (z / angleMultiplier) * math.Pi / 180.0
-	switch z / angleMultiplier {
-	case 90, -270:
-		return 0, fractionMultiplier
-	case -90, 270:
-		return 0, -fractionMultiplier
-	case 180, -180:
-		return -fractionMultiplier, 0
-	default:
-		return math.Cos(rad) * fractionMultiplier, math.Sin(rad) * fractionMultiplier
-	}
+	return num.SinCos(z)
 }
 
 func numberNegate(x Number) Number {
@@ -135,126 +130,40 @@ func numberGreater(a, b Number) bool {
 // reduceAngle mirrors mp_reduce_angle (mp.c:7969ff / mp.w:8556ff):
 // clamp to [-180,180] degrees scaled by angleMultiplier.
 func reduceAngle(a Number) Number {
-	oneEighty := 180 * angleMultiplier
-	threeSixty := 360 * angleMultiplier
-	if a > oneEighty {
-		a -= threeSixty
-	} else if a < -oneEighty {
-		a += threeSixty
-	}
-	return a
+	return num.ReduceAngle(a)
 }
 
 // ofTheWay mirrors mp_set_double_from_of_the_way (mpmathdouble.w:397):
 // A = B - take_fraction(B-C, t).
 func ofTheWay(b, c, t Number) Number {
-	return b - takeFraction(b-c, t)
+	return num.OfTheWay(b, c, t)
 }
 
 func pythAdd(a, b Number) Number {
-	return math.Hypot(a, b)
+	return num.PythAdd(a, b)
 }
 
 func pythSub(a, b Number) Number {
-	v := a*a - b*b
-	if v <= 0 {
-		return 0
-	}
-	return math.Sqrt(v)
+	return num.PythSub(a, b)
 }
 
 func nArg(x, y Number) Number {
-	// mp_double_n_arg (mpmathdouble.c:1041ff) — returns degrees*angleMultiplier.
-	return math.Atan2(y, x) * (180.0 / math.Pi) * angleMultiplier
+	return num.NArg(x, y)
 }
 
 // velocity mirrors mp_double_velocity (mpmathdouble.w:783-807).
 func velocity(st, ct, sf, cf, t Number) Number {
-	acc := takeFraction(st-(sf/16.0), sf-(st/16.0))
-	acc = takeFraction(acc, ct-cf)
-	num := fractionTwo + takeFraction(acc, math.Sqrt2*fractionOne)
-	denom := fractionThree +
-		takeFraction(ct, 3*fractionHalf*(math.Sqrt(5.0)-1.0)) +
-		takeFraction(cf, 3*fractionHalf*(3.0-math.Sqrt(5.0)))
-	if t != unity {
-		num = makeScaled(num, t)
-	}
-	if num/4 >= denom {
-		return fractionFour
-	}
-	return makeFraction(num, denom)
+	return num.Velocity(st, ct, sf, cf, t)
 }
 
 // abVsCd returns the sign of a*b - c*d (mpmathdouble.w:1479-1502).
 func abVsCd(a, b, c, d Number) Number {
-	ab := a * b
-	cd := c * d
-	switch {
-	case ab > cd:
-		return 1
-	case ab < cd:
-		return -1
-	default:
-		return 0
-	}
+	return num.AbVsCd(a, b, c, d)
 }
 
 // crossingPoint mirrors mp_double_crossing_point (mpmathdouble.w:931-993).
 func crossingPoint(a, b, c Number) Number {
-	if a < 0 {
-		return 0 // zero_crossing
-	}
-	if c >= 0 {
-		if b >= 0 {
-			if c > 0 {
-				return fractionOne + 1 // no_crossing
-			}
-			if a == 0 && b == 0 {
-				return fractionOne + 1 // no_crossing
-			}
-			return fractionOne // one_crossing
-		}
-		if a == 0 {
-			return 0 // zero_crossing
-		}
-	} else if a == 0 {
-		if b <= 0 {
-			return 0 // zero_crossing
-		}
-	}
-
-	d := epsilon
-	x0 := a
-	x1 := a - b
-	x2 := b - c
-	for {
-		x := (x1+x2)/2 + 1e-12
-		if x1-x0 > x0 {
-			x2 = x
-			x0 += x0
-			d += d
-		} else {
-			xx := x1 + x - x0
-			if xx > x0 {
-				x2 = x
-				x0 += x0
-				d += d
-			} else {
-				x0 = x0 - xx
-				if x <= x0 {
-					if x+x2 <= x0 {
-						return fractionOne + 1 // no_crossing
-					}
-				}
-				x1 = x
-				d = d + d + epsilon
-			}
-		}
-		if d >= fractionOne {
-			break
-		}
-	}
-	return d - fractionOne
+	return num.CrossingPoint(a, b, c)
 }
 
 // slowAdd mirrors mp_double_slow_add: here we simply add (overflow not handled).
@@ -0,0 +1,56 @@
+package mp
+
+import "sort"
+
+// SplitIntoMonotone returns a copy of p with knots inserted at the x and y
+// extrema and the inflections of every segment (see XExtrema, YExtrema and
+// Inflections), so that each segment of the result is monotone in both x
+// and y and bends to one side only. Such segments cross any horizontal or
+// vertical line at most once and lie within the box spanned by their end
+// knots, which makes them the input of choice for scanline filling,
+// point-in-path tests and boolean operations.
+//
+// The curve itself is unchanged: the new knots have explicit control
+// points from exact de Casteljau splits. The style is copied. It returns
+// nil for a nil path.
+func SplitIntoMonotone(p *Path) *Path {
+	if p == nil {
+		return nil
+	}
+	q := p.Copy()
+	segs := pathSegments(p)
+	if len(segs) == 0 {
+		return q
+	}
+	times := append(append(p.XExtrema(), p.YExtrema()...), p.Inflections()...)
+	sort.Float64s(times)
+	// Split times in (0,1) per segment, increasing and without duplicates.
+	const eps = 1e-9
+	split := make([][]Number, len(segs))
+	for _, t := range times {
+		i := int(t)
+		u := t - Number(i)
+		if u < eps || u > 1-eps || i >= len(segs) {
+			continue // at a knot
+		}
+		if s := split[i]; len(s) > 0 && u-s[len(s)-1] < eps {
+			continue
+		}
+		split[i] = append(split[i], u)
+	}
+	k := q.Head
+	for i := range segs {
+		next := k.Next
+		prev := Number(0)
+		for _, u := range split[i] {
+			// The remaining piece starts at prev of the original segment.
+			k = splitCubicAt(k, (u-prev)/(1-prev))
+			k.Quadratic = k.Prev.Quadratic
+			prev = u
+		}
+		k = next
+	}
+	// The knots no longer match the fullcircle the exact ellipse describes.
+	q.ellipse = nil
+	return q
+}
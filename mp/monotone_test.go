@@ -0,0 +1,96 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitIntoMonotone(t *testing.T) {
+	// monotone reports whether the coordinates of c change in one
+	// direction only, by sampling.
+	monotone := func(c [4]Point) bool {
+		px, py := c[0].X, c[0].Y
+		var sx, sy Number
+		for i := 1; i <= 64; i++ {
+			x, y := evalCubic(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, Number(i)/64)
+			for _, d := range [][3]*Number{{&sx, &x, &px}, {&sy, &y, &py}} {
+				delta := *d[1] - *d[2]
+				if math.Abs(delta) < 1e-9 {
+					continue
+				}
+				if *d[0]*delta < 0 {
+					return false
+				}
+				*d[0] = delta
+			}
+			px, py = x, y
+		}
+		return true
+	}
+
+	s := cubicPath(P(0, 0), [3]Point{P(10, 10), P(20, -10), P(30, 0)})
+	s.Style = NewStyle(WithStrokeWidth(2))
+	m := SplitIntoMonotone(s)
+	if n := m.PathLength(); n != 4 {
+		t.Fatalf("S curve split into %d segments, want 4 (two y extrema, one inflection)", n)
+	}
+	if m.Style.StrokeWidth != 2 {
+		t.Errorf("style not copied")
+	}
+	for i, c := range pathSegments(m) {
+		if !monotone(c) {
+			t.Errorf("segment %d is not monotone: %v", i, c)
+		}
+	}
+	if x, y := m.PointOf(2); math.Abs(x-15) > 1e-9 || math.Abs(y) > 1e-9 {
+		t.Errorf("inflection knot at (%g,%g), want (15,0)", x, y)
+	}
+	// The curve is unchanged.
+	for _, u := range []Number{0.1, 0.3, 0.7} {
+		x, y := s.PointOf(u)
+		var best Number = math.Inf(1)
+		for _, c := range pathSegments(m) {
+			for j := 0; j <= 1000; j++ {
+				qx, qy := evalCubic(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, Number(j)/1000)
+				best = math.Min(best, math.Hypot(qx-x, qy-y))
+			}
+		}
+		if best > 0.05 {
+			t.Errorf("point at %g is %g away from the split curve", u, best)
+		}
+	}
+
+	// A circle is already split at its extrema.
+	c := Scaled(10).ApplyToPath(FullCircle())
+	if n := SplitIntoMonotone(c).PathLength(); n != c.PathLength() {
+		t.Errorf("circle has %d segments after split, want %d", n, c.PathLength())
+	}
+	// A rotated circle gets four new knots, on the cycle's closing segment too.
+	r := Rotated(20).ApplyToPath(c)
+	mr := SplitIntoMonotone(r)
+	if n := mr.PathLength(); n != 12 || mr.Head.LType == KnotEndpoint {
+		t.Errorf("rotated circle: %d segments, cycle %v; want a cycle of 12", n, mr.Head.LType != KnotEndpoint)
+	}
+	for i, seg := range pathSegments(mr) {
+		if !monotone(seg) {
+			t.Errorf("rotated circle segment %d is not monotone", i)
+		}
+	}
+
+	// Quadratic segments stay quadratic.
+	q := NewPath()
+	q.Append(&Knot{LType: KnotEndpoint, RType: KnotExplicit})
+	q.AppendQuadratic(10, 20, 20, 0)
+	q.Head.Prev.RType = KnotEndpoint
+	mq := SplitIntoMonotone(q)
+	if mq.PathLength() != 2 || !mq.Head.Quadratic || !mq.Head.Next.Quadratic {
+		t.Errorf("quadratic arch: %d segments, flags %v %v", mq.PathLength(), mq.Head.Quadratic, mq.Head.Next.Quadratic)
+	}
+	if _, _, ok := mq.Head.Next.QuadraticControl(); !ok {
+		t.Errorf("second half is not quadratic")
+	}
+
+	if SplitIntoMonotone(nil) != nil {
+		t.Errorf("nil path")
+	}
+}
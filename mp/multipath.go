@@ -0,0 +1,91 @@
+package mp
+
+// MultiPath groups several disconnected subpaths into one drawable object
+// with a single style, e.g. glyph outlines with holes, dashed underlines or
+// hatch fills. MetaPost has no "&&" operator, but its pictures routinely
+// carry such geometry as several fill/stroke objects; backends render a
+// MultiPath as one element (one SVG <path> with several M commands), so
+// even-odd and nonzero filling sees all parts together.
+type MultiPath struct {
+	Parts []*Path
+	Style Style
+}
+
+// NewMultiPath creates a MultiPath from the given parts. Nil or empty parts
+// are skipped.
+func NewMultiPath(parts ...*Path) *MultiPath {
+	m := &MultiPath{}
+	for _, p := range parts {
+		m.Append(p)
+	}
+	return m
+}
+
+// Append adds a subpath (a "pen lift" followed by a new path) and returns the
+// MultiPath for chaining.
+func (m *MultiPath) Append(p *Path) *MultiPath {
+	if p != nil && p.Head != nil {
+		m.Parts = append(m.Parts, p)
+	}
+	return m
+}
+
+// Copy returns a deep copy of the MultiPath.
+func (m *MultiPath) Copy() *MultiPath {
+	if m == nil {
+		return nil
+	}
+	q := &MultiPath{Style: m.Style, Parts: make([]*Path, 0, len(m.Parts))}
+	for _, p := range m.Parts {
+		q.Parts = append(q.Parts, p.Copy())
+	}
+	return q
+}
+
+// AddMultiPath queues every part of m for solving. The parts take over the
+// style of m, so non-elliptical pens produce an envelope for each part.
+func (e *Engine) AddMultiPath(m *MultiPath) {
+	if m == nil {
+		return
+	}
+	for _, p := range m.Parts {
+		p.Style = m.Style
+		e.AddPath(p)
+	}
+}
+
+// ApplyToMultiPath applies the transformation to every part of m and returns
+// a new MultiPath (the original is not modified).
+func (t Transform) ApplyToMultiPath(m *MultiPath) *MultiPath {
+	if m == nil {
+		return nil
+	}
+	q := &MultiPath{Style: m.Style, Parts: make([]*Path, 0, len(m.Parts))}
+	for _, p := range m.Parts {
+		tp := t.ApplyToPath(p)
+		if p.Envelope != nil {
+			tp.Envelope = t.ApplyToPath(p.Envelope)
+		}
+		q.Parts = append(q.Parts, tp)
+	}
+	return q
+}
+
+// MultiPathBBox returns the bounding box of all parts of m (see PathBBox).
+func MultiPathBBox(m *MultiPath) (minX, minY, maxX, maxY Number) {
+	if m == nil {
+		return 0, 0, 0, 0
+	}
+	first := true
+	for _, p := range m.Parts {
+		x0, y0, x1, y1 := PathBBox(p)
+		if first {
+			minX, minY, maxX, maxY = x0, y0, x1, y1
+			first = false
+			continue
+		}
+		minX, minY = min(minX, x0), min(minY, y0)
+		maxX, maxY = max(maxX, x1), max(maxY, y1)
+	}
+	return minX, minY, maxX, maxY
+}
@@ -0,0 +1,209 @@
+// Package num provides the numeric primitives of MetaPost's double
+// backend (mpmathdouble.w) that the path solver and the envelope code in
+// package mp are built on. They are exported for tools that extend the
+// solver, e.g. custom stroking or research code, and behave exactly as the
+// versions used by mp.
+//
+// MetaPost represents some quantities in scaled units:
+//
+//   - fractions are multiplied by FractionOne (4096), so TakeFraction(x,
+//     FractionOne) is x and TakeFraction(x, FractionOne/2) is x/2;
+//   - angles are degrees multiplied by AngleMultiplier (16), as returned by
+//     NArg and consumed by SinCos.
+//
+// Plain ("scaled") numbers are ordinary float64 values.
+package num
+
+import "math"
+
+// Constants of the double backend (mpmathdouble.w:22-47).
+const (
+	// FractionOne is the fraction 1.0.
+	FractionOne = 4096.0
+	// AngleMultiplier scales degrees to MetaPost angles.
+	AngleMultiplier = 16.0
+	// Epsilon is the smallest increment the backend distinguishes (2^-52).
+	Epsilon = 2.220446049250313e-16
+	// NoCrossing is returned by CrossingPoint if there is no crossing in
+	// [0, 1].
+	NoCrossing = FractionOne + 1
+)
+
+// MakeScaled returns p/q (mp_double_make_scaled).
+func MakeScaled(p, q float64) float64 {
+	return p / q
+}
+
+// TakeScaled returns p*q (mp_double_take_scaled).
+func TakeScaled(p, q float64) float64 {
+	return p * q
+}
+
+// MakeFraction returns p/q as a fraction, i.e. scaled by FractionOne
+// (mp_double_make_fraction).
+func MakeFraction(p, q float64) float64 {
+	return (p / q) * FractionOne
+}
+
+// TakeFraction returns p times the fraction q, i.e. p*q/FractionOne
+// (mp_double_take_fraction).
+func TakeFraction(p, q float64) float64 {
+	return (p * q) / FractionOne
+}
+
+// OfTheWay returns b - TakeFraction(b-c, t), the point t of the way from b
+// to c for a fraction t (mp_set_double_from_of_the_way, mpmathdouble.w:397).
+func OfTheWay(b, c, t float64) float64 {
+	return b - TakeFraction(b-c, t)
+}
+
+// PythAdd returns sqrt(a²+b²) without intermediate overflow
+// (mp_double_pyth_add).
+func PythAdd(a, b float64) float64 {
+	return math.Hypot(a, b)
+}
+
+// PythSub returns sqrt(a²-b²), or 0 if |a| <= |b| (mp_double_pyth_sub).
+func PythSub(a, b float64) float64 {
+	v := a*a - b*b
+	if v <= 0 {
+		return 0
+	}
+	return math.Sqrt(v)
+}
+
+// NArg returns the angle of the vector (x, y) in degrees times
+// AngleMultiplier (mp_double_n_arg, mpmathdouble.c:1041ff).
+func NArg(x, y float64) float64 {
+	return math.Atan2(y, x) * (180.0 / math.Pi) * AngleMultiplier
+}
+
+// SinCos returns the cosine and sine of the MetaPost angle z (degrees times
+// AngleMultiplier) as fractions (mp_double_sin_cos, mpmathdouble.c:1066ff).
+// Multiples of 90 degrees are exact.
+func SinCos(z float64) (cos, sin float64) {
+	rad := (z / AngleMultiplier) * math.Pi / 180.0
+	switch z / AngleMultiplier {
+	case 90, -270:
+		return 0, FractionOne
+	case -90, 270:
+		return 0, -FractionOne
+	case 180, -180:
+		return -FractionOne, 0
+	default:
+		return math.Cos(rad) * FractionOne, math.Sin(rad) * FractionOne
+	}
+}
+
+// ReduceAngle brings the MetaPost angle a into [-180, 180] degrees by
+// adding or subtracting a full turn once (mp_reduce_angle, mp.w:8556ff).
+func ReduceAngle(a float64) float64 {
+	oneEighty := 180 * AngleMultiplier
+	threeSixty := 360 * AngleMultiplier
+	if a > oneEighty {
+		a -= threeSixty
+	} else if a < -oneEighty {
+		a += threeSixty
+	}
+	return a
+}
+
+// Velocity is Hobby's velocity function: the length of the control
+// handle, as a fraction of the chord, for a segment leaving at an angle
+// with sine st and cosine ct and arriving at an angle with sine sf and
+// cosine cf (all fractions) with tension t. The result is capped at
+// 4*FractionOne (mp_double_velocity, mpmathdouble.w:783-807).
+func Velocity(st, ct, sf, cf, t float64) float64 {
+	acc := TakeFraction(st-(sf/16.0), sf-(st/16.0))
+	acc = TakeFraction(acc, ct-cf)
+	num := 2*FractionOne + TakeFraction(acc, math.Sqrt2*FractionOne)
+	denom := 3*FractionOne +
+		TakeFraction(ct, 3*FractionOne/2*(math.Sqrt(5.0)-1.0)) +
+		TakeFraction(cf, 3*FractionOne/2*(3.0-math.Sqrt(5.0)))
+	if t != 1 {
+		num = MakeScaled(num, t)
+	}
+	if num/4 >= denom {
+		return 4 * FractionOne
+	}
+	return MakeFraction(num, denom)
+}
+
+// AbVsCd returns the sign of a*b - c*d as -1, 0 or 1
+// (mp_double_ab_vs_cd, mpmathdouble.w:1479-1502).
+func AbVsCd(a, b, c, d float64) float64 {
+	ab := a * b
+	cd := c * d
+	switch {
+	case ab > cd:
+		return 1
+	case ab < cd:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// CrossingPoint finds where the quadratic Bernstein polynomial
+// B(a,b,c;t) = a(1-t)² + 2bt(1-t) + ct² changes from positive to
+// negative for t in [0, 1] and returns t as a fraction. It returns 0 if
+// a < 0 and NoCrossing if the polynomial stays positive; a double root
+// where it only touches zero may be reported as a crossing
+// (mp_double_crossing_point, mpmathdouble.w:931-993). MetaPost uses it to
+// find where a curve's derivative changes sign.
+func CrossingPoint(a, b, c float64) float64 {
+	if a < 0 {
+		return 0 // zero_crossing
+	}
+	if c >= 0 {
+		if b >= 0 {
+			if c > 0 {
+				return NoCrossing
+			}
+			if a == 0 && b == 0 {
+				return NoCrossing
+			}
+			return FractionOne // one_crossing
+		}
+		if a == 0 {
+			return 0 // zero_crossing
+		}
+	} else if a == 0 {
+		if b <= 0 {
+			return 0 // zero_crossing
+		}
+	}
+
+	d := Epsilon
+	x0 := a
+	x1 := a - b
+	x2 := b - c
+	for {
+		x := (x1+x2)/2 + 1e-12
+		if x1-x0 > x0 {
+			x2 = x
+			x0 += x0
+			d += d
+		} else {
+			xx := x1 + x - x0
+			if xx > x0 {
+				x2 = x
+				x0 += x0
+				d += d
+			} else {
+				x0 = x0 - xx
+				if x <= x0 {
+					if x+x2 <= x0 {
+						return NoCrossing
+					}
+				}
+				x1 = x
+				d = d + d + Epsilon
+			}
+		}
+		if d >= FractionOne {
+			break
+		}
+	}
+	return d - FractionOne
+}
@@ -0,0 +1,89 @@
+package num
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFractions(t *testing.T) {
+	if got := MakeFraction(1, 2); got != FractionOne/2 {
+		t.Errorf("MakeFraction(1, 2) = %g, want %g", got, FractionOne/2)
+	}
+	if got := TakeFraction(10, FractionOne/4); got != 2.5 {
+		t.Errorf("TakeFraction(10, 1/4) = %g, want 2.5", got)
+	}
+	if got := TakeFraction(7, MakeFraction(3, 7)); math.Abs(got-3) > 1e-12 {
+		t.Errorf("TakeFraction(7, MakeFraction(3, 7)) = %g, want 3", got)
+	}
+	if got := OfTheWay(10, 20, FractionOne/4); got != 12.5 {
+		t.Errorf("OfTheWay(10, 20, 1/4) = %g, want 12.5", got)
+	}
+}
+
+func TestAngles(t *testing.T) {
+	if got := NArg(0, 1); got != 90*AngleMultiplier {
+		t.Errorf("NArg(0, 1) = %g, want %g", got, 90*AngleMultiplier)
+	}
+	for _, deg := range []float64{90, -90, 180, 270} {
+		c, s := SinCos(deg * AngleMultiplier)
+		wc := math.Round(math.Cos(deg*math.Pi/180)) * FractionOne
+		ws := math.Round(math.Sin(deg*math.Pi/180)) * FractionOne
+		if c != wc || s != ws {
+			t.Errorf("SinCos(%g°) = %g, %g, want exactly %g, %g", deg, c, s, wc, ws)
+		}
+	}
+	if got := ReduceAngle(270 * AngleMultiplier); got != -90*AngleMultiplier {
+		t.Errorf("ReduceAngle(270°) = %g°", got/AngleMultiplier)
+	}
+}
+
+func TestPyth(t *testing.T) {
+	if got := PythAdd(3, 4); got != 5 {
+		t.Errorf("PythAdd(3, 4) = %g", got)
+	}
+	if got := PythSub(5, 4); got != 3 {
+		t.Errorf("PythSub(5, 4) = %g", got)
+	}
+	if got := PythSub(4, 5); got != 0 {
+		t.Errorf("PythSub(4, 5) = %g, want 0", got)
+	}
+}
+
+func TestAbVsCd(t *testing.T) {
+	for _, tc := range []struct{ a, b, c, d, want float64 }{
+		{2, 3, 1, 5, 1},
+		{2, 3, 1, 6, 0},
+		{2, 3, 1, 7, -1},
+	} {
+		if got := AbVsCd(tc.a, tc.b, tc.c, tc.d); got != tc.want {
+			t.Errorf("AbVsCd(%g, %g, %g, %g) = %g, want %g", tc.a, tc.b, tc.c, tc.d, got, tc.want)
+		}
+	}
+}
+
+func TestCrossingPoint(t *testing.T) {
+	if got := CrossingPoint(1, 1, 1); got != NoCrossing {
+		t.Errorf("positive polynomial: got %g, want NoCrossing", got)
+	}
+	if got := CrossingPoint(-1, 0, 0); got != 0 {
+		t.Errorf("negative start: got %g, want 0", got)
+	}
+	// B(1,0,-1;t) = 1-2t: crosses at 1/2.
+	if got := CrossingPoint(1, 0, -1) / FractionOne; math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("linear crossing at %g, want 0.5", got)
+	}
+	// B(3,-1,-1;t) = 3 - 8t + 4t²: root (2-√1)/2 = 1/2.
+	if got := CrossingPoint(3, -1, -1) / FractionOne; math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("quadratic crossing at %g, want 0.5", got)
+	}
+}
+
+func TestVelocity(t *testing.T) {
+	// Straight segment (both angles 0): the handle is a third of the chord.
+	if got := Velocity(0, FractionOne, 0, FractionOne, 1) / FractionOne; math.Abs(got-1.0/3) > 1e-12 {
+		t.Errorf("Velocity for a straight line = %g, want 1/3", got)
+	}
+	if got := Velocity(0, FractionOne, 0, FractionOne, 0.01); got != 4*FractionOne {
+		t.Errorf("Velocity at tiny tension = %g, want capped at 4", got/FractionOne)
+	}
+}
@@ -1,6 +1,8 @@
 package mp
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -102,27 +104,11 @@ func htapYpoc(p *Knot) (q *Knot, pathTail *Knot) {
 // PenBBox returns the axis-aligned bounding box of a pen outline, equivalent to
 // mp_pen_bbox (mp.c:10670ff) but without transforming; used in offset prep.
 func PenBBox(pen *Pen) (minx, miny, maxx, maxy Number, ok bool) {
-	pts := penPoints(pen)
-	if len(pts) == 0 {
+	d := pen.data()
+	if d == nil {
 		return 0, 0, 0, 0, false
 	}
-	minx, miny = math.Inf(1), math.Inf(1)
-	maxx, maxy = math.Inf(-1), math.Inf(-1)
-	for _, pt := range pts {
-		if pt[0] < minx {
-			minx = pt[0]
-		}
-		if pt[0] > maxx {
-			maxx = pt[0]
-		}
-		if pt[1] < miny {
-			miny = pt[1]
-		}
-		if pt[1] > maxy {
-			maxy = pt[1]
-		}
-	}
-	return minx, miny, maxx, maxy, true
+	return d.minx, d.miny, d.maxx, d.maxy, true
 }
 
 // penOffsetPoints returns the pen points translated by (dx,dy).
@@ -375,27 +361,13 @@ func offsetPrep(path *Path, pen *Pen) int {
 		return 0
 	}
 
-	// Count pen vertices n (mp.c:13534 / mp.w:13534: section 550)
-	n := 0
-	pw := pen.Head
-	for {
-		n++
-		pw = pw.Next
-		if pw == pen.Head {
-			break
-		}
-	}
-
-	// Compute initial dxin, dyin from pen (mp.c:13546ff / mp.w:13546: section 551)
+	// Pen vertex count n (mp.c:13534 / mp.w:13534: section 550) and the
+	// initial dxin, dyin (mp.c:13546ff / mp.w:13546: section 551) come
+	// from the pen's cache.
+	pd := pen.data()
+	n := len(pd.points)
+	dxin, dyin := pd.dxin, pd.dyin
 	h := pen.Head
-	hn := h.Next
-	hp := h.Prev
-	dxin := hn.XCoord - hp.XCoord
-	dyin := hn.YCoord - hp.YCoord
-	if dxin == 0 && dyin == 0 {
-		dxin = hp.YCoord - h.YCoord
-		dyin = h.XCoord - hp.XCoord
-	}
 
 	w0 := pen.Head
 	c := path.Head
@@ -810,38 +782,127 @@ func absNumber(x Number) Number {
 	return x
 }
 
+// ErrEnvelopeNotConverged is returned (wrapped in an *EnvelopeError) when
+// the envelope main loop does not get back to the start of the path within
+// its iteration limit.
+var ErrEnvelopeNotConverged = errors.New("mp: envelope did not converge")
+
+// EnvelopeError describes an envelope computation that was aborted. Its
+// Unwrap method returns ErrEnvelopeNotConverged.
+type EnvelopeError struct {
+	Iterations int    // iterations of the main loop that were run
+	Knots      int    // knots of the prepared (doubled, for open paths) path
+	X, Y       Number // last knot reached
+}
+
+func (e *EnvelopeError) Error() string {
+	return fmt.Sprintf("%v after %d iterations (path has %d knots, stopped at (%g,%g))",
+		ErrEnvelopeNotConverged, e.Iterations, e.Knots, e.X, e.Y)
+}
+
+func (e *EnvelopeError) Unwrap() error { return ErrEnvelopeNotConverged }
+
 // MakeEnvelope creates an envelope outline by walking the pen around the path.
-// Mirrors mp_make_envelope (mp.c:13304ff / mp.w:14748ff).
+// Mirrors mp_make_envelope (mp.c:13304ff / mp.w:14748ff). It returns nil if
+// there is nothing to do or the envelope cannot be built; use
+// MakeEnvelopeLimit to get the reason.
+//
+// Degenerate pens are handled explicitly. A pen whose vertices all
+// coincide (a point pen) sweeps no area: the envelope is the path shifted
+// by the pen's position, traced forward and back, so filling it paints
+// nothing, as in MetaPost. A razor pen with two distinct vertices gives
+// the doubled offset outline, whose width drops to zero where the path
+// runs parallel to the razor and at the ends. Pens whose knots are not
+// linked into a loop are linked first.
 func MakeEnvelope(path *Path, pen *Pen) *Path {
-	if path == nil || path.Head == nil || pen == nil || pen.Head == nil {
-		return nil
-	}
-	if pen.Head.Next == nil || pen.Head.Prev == nil {
+	env, err := MakeEnvelopeLimit(path, pen, 0)
+	if err != nil {
 		return nil
 	}
+	return env
+}
 
-	debug := false // Set to true for debugging
+// MakeEnvelopeLimit is MakeEnvelope with a limit on the iterations of the
+// main loop, which visits each knot of the prepared path once. A limit of
+// 0 or less means one pass over the knots. If the loop does not return to
+// the start in time, no (partial) outline is returned but an
+// *EnvelopeError.
+func MakeEnvelopeLimit(path *Path, pen *Pen, maxIter int) (*Path, error) {
+	return MakeEnvelopeContext(context.Background(), path, pen, maxIter, nil)
+}
 
-	// Get join/cap settings from path style (mp.c:14827ff)
-	// Convert LineJoin from offset constants to MetaPost internal values.
-	// LineJoin constants are offset by 1 so 0 = "unset/default" → rounded (1).
-	// LineJoinMiter=1 → 0, LineJoinRound=2 → 1, LineJoinBevel=3 → 2.
-	ljoin := path.Style.LineJoin
-	if ljoin == 0 {
-		ljoin = 1 // Default to rounded (MetaPost default is linejoin=1)
-	} else {
-		ljoin-- // Convert from offset constant to MetaPost value
+// normalizePen returns pen as a closed loop of distinct vertices,
+// linking the knots of a pen built without Next/Prev loop. ok is false if
+// the pen is a single point, which is returned.
+func normalizePen(pen *Pen) (norm *Pen, point Point, ok bool) {
+	pts := penPoints(pen)
+	var distinct [][2]Number
+	for _, pt := range pts {
+		if n := len(distinct); n == 0 || pt != distinct[n-1] {
+			distinct = append(distinct, pt)
+		}
+	}
+	for len(distinct) > 1 && distinct[len(distinct)-1] == distinct[0] {
+		distinct = distinct[:len(distinct)-1]
+	}
+	if len(distinct) == 1 {
+		return nil, P(distinct[0][0], distinct[0][1]), false
 	}
+	if len(distinct) == len(pts) && pen.Head.Next != nil && pen.Head.Prev != nil {
+		return pen, Point{}, true
+	}
+	p := NewPath()
+	for _, pt := range distinct {
+		p.Append(&Knot{XCoord: pt[0], YCoord: pt[1]})
+	}
+	return NewPenFromPath(p), Point{}, true
+}
 
-	// Convert LineCap from offset constants to MetaPost internal values.
-	// LineCap constants are offset by 1 so 0 = "unset/default" → rounded (1).
-	// LineCapButt=1 → 0, LineCapRounded=2 → 1, LineCapSquared=3 → 2.
-	lcap := path.Style.LineCap
-	if lcap == 0 {
-		lcap = 1 // Default to rounded (MetaPost default)
-	} else {
-		lcap-- // Convert from offset constant to MetaPost value
+// pointPenEnvelope returns the envelope of path for a point pen at pt:
+// the path shifted by pt and traced forward and back as one cycle.
+func pointPenEnvelope(path *Path, pt Point) *Path {
+	segs := pathSegments(path)
+	if len(segs) == 0 {
+		// A single knot: a degenerate cycle at the knot.
+		x, y := path.Head.XCoord+pt.X, path.Head.YCoord+pt.Y
+		segs = [][4]Point{{P(x, y), P(x, y), P(x, y), P(x, y)}}
+		return segmentsPath(segs, true)
+	}
+	shift := func(q Point) Point { return q.Add(pt) }
+	doubled := make([][4]Point, 0, 2*len(segs))
+	for _, c := range segs {
+		doubled = append(doubled, [4]Point{shift(c[0]), shift(c[1]), shift(c[2]), shift(c[3])})
+	}
+	for i := len(segs) - 1; i >= 0; i-- {
+		c := segs[i]
+		doubled = append(doubled, [4]Point{shift(c[3]), shift(c[2]), shift(c[1]), shift(c[0])})
+	}
+	return segmentsPath(doubled, true)
+}
+
+// envelopeCheckInterval is the number of envelope main loop iterations
+// between cancellation checks and progress reports.
+const envelopeCheckInterval = 64
+
+// MakeEnvelopeContext is MakeEnvelopeLimit with cancellation and progress
+// reporting for very long paths. Every few iterations of the main loop ctx
+// is checked, returning ctx.Err() if it is done, and progress (if not nil)
+// is called with the iterations run and the expected total.
+func MakeEnvelopeContext(ctx context.Context, path *Path, pen *Pen, maxIter int, progress ProgressFunc) (*Path, error) {
+	if path == nil || path.Head == nil || pen == nil || pen.Head == nil {
+		return nil, nil
+	}
+	pen, point, ok := normalizePen(pen)
+	if !ok {
+		return pointPenEnvelope(path, point), nil
 	}
+
+	debug := false // Set to true for debugging
+
+	// Get join/cap settings from path style (mp.c:14827ff) as MetaPost's
+	// internal values: 0 = miter/butt, 1 = round, 2 = bevel/squared.
+	ljoin := path.Style.LineJoin.MetaPost()
+	lcap := path.Style.LineCap.MetaPost()
 	miterlim := Number(4.0) // default miter limit
 
 	// mp.c:14769-14770 - Copy path
@@ -999,9 +1060,22 @@ func MakeEnvelope(path *Path, pen *Pen) *Path {
 
 	// Main envelope loop (mp.c:14770-14798 / mp.w:14770ff)
 	// do { ... } while (q0 != c)
-	maxIter := 1000
+	knots := countKnots(c.Head)
+	if maxIter <= 0 {
+		maxIter = knots
+	}
+	converged := false
+	iter := 0
 	passedSpecP2 := false // Track when we've passed specP2 (entering inner contour)
-	for iter := 0; iter < maxIter; iter++ {
+	for ; iter < maxIter; iter++ {
+		if iter%envelopeCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if progress != nil {
+				progress(iter, knots)
+			}
+		}
 		q := p.Next
 		if q == nil {
 			break
@@ -1115,9 +1189,16 @@ func MakeEnvelope(path *Path, pen *Pen) *Path {
 				fmt.Printf("  Exit: q0 == c.Head, c.Head now at (%.1f,%.1f), w was (%.1f,%.1f)\n",
 					c.Head.XCoord, c.Head.YCoord, w.XCoord, w.YCoord)
 			}
+			converged = true
 			break
 		}
 	}
+	if !converged {
+		return nil, &EnvelopeError{Iterations: iter, Knots: knots, X: p.XCoord, Y: p.YCoord}
+	}
+	if progress != nil {
+		progress(knots, knots)
+	}
 
 	if debug {
 		fmt.Printf("Final: c.Head=(%.1f,%.1f)\n", c.Head.XCoord, c.Head.YCoord)
@@ -1129,7 +1210,22 @@ func MakeEnvelope(path *Path, pen *Pen) *Path {
 	c.Style.Stroke = ColorCSS("none")
 	c.Style.StrokeWidth = 0
 	c.Style.Pen = nil
-	return c
+	return c, nil
+}
+
+// countKnots returns the number of knots in the list starting at head,
+// following Next until it returns to head or ends. A list that loops
+// without returning to head is counted up to maxKnotCount.
+func countKnots(head *Knot) int {
+	const maxKnotCount = 1 << 20
+	n := 0
+	for k := head; k != nil && n < maxKnotCount; k = k.Next {
+		n++
+		if k.Next == head {
+			break
+		}
+	}
+	return n
 }
 
 // joinDirections holds the computed direction vectors for miter/squared joins.
@@ -1,6 +1,11 @@
 package mp
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
 
 func TestPenBBox(t *testing.T) {
 	p := PenSquare(2)
@@ -13,6 +18,63 @@ func TestPenBBox(t *testing.T) {
 	}
 }
 
+func TestPenCacheInvalidation(t *testing.T) {
+	p := PenSquare(2)
+	if _, _, maxx, _, _ := PenBBox(p); maxx != 1 {
+		t.Fatalf("maxx = %v, want 1", maxx)
+	}
+
+	// A transformed pen has its own data; the original keeps its cache.
+	q := Scaled(3).ApplyToPen(p)
+	if _, _, maxx, _, _ := PenBBox(q); maxx != 3 {
+		t.Errorf("transformed maxx = %v, want 3", maxx)
+	}
+	if _, _, maxx, _, _ := PenBBox(p); maxx != 1 {
+		t.Errorf("original maxx = %v after transform, want 1", maxx)
+	}
+
+	// Edits through ApplyToKnot are picked up without Invalidate.
+	k := p.Head
+	for {
+		Scaled(2).ApplyToKnot(k)
+		if k = k.Next; k == p.Head {
+			break
+		}
+	}
+	if _, _, maxx, _, _ := PenBBox(p); maxx != 2 {
+		t.Errorf("maxx = %v after ApplyToKnot, want 2", maxx)
+	}
+
+	// Direct field assignments are picked up after Invalidate.
+	k = p.Head
+	for {
+		k.XCoord *= 2
+		if k = k.Next; k == p.Head {
+			break
+		}
+	}
+	if _, _, maxx, _, _ := PenBBox(p); maxx != 2 {
+		t.Errorf("maxx = %v before Invalidate, want cached 2", maxx)
+	}
+	p.Invalidate()
+	if _, _, maxx, _, _ := PenBBox(p); maxx != 4 {
+		t.Errorf("maxx = %v after Invalidate, want 4", maxx)
+	}
+
+	// Replacing Head is detected without Invalidate.
+	p.Head = PenSquare(4).Head
+	if _, _, maxx, _, _ := PenBBox(p); maxx != 2 {
+		t.Errorf("maxx = %v after replacing Head, want 2", maxx)
+	}
+
+	// Knot setters count as edits too.
+	d := p.data()
+	p.Head.SetTension(1, 1)
+	if p.data() == d {
+		t.Error("pen data not recomputed after a knot setter")
+	}
+}
+
 func TestPathNormals(t *testing.T) {
 	path := NewPath()
 	k1 := NewKnot()
@@ -33,3 +95,137 @@ func TestPathNormals(t *testing.T) {
 		t.Fatalf("expected length 5, got %v", normals[0].Len)
 	}
 }
+
+func TestMakeEnvelopeLimit(t *testing.T) {
+	path := straightPath([]Point{{0, 0}, {50, 0}, {50, 50}}, false)
+	pen := PenSquare(4)
+
+	env, err := MakeEnvelopeLimit(path, pen, 0)
+	if err != nil || env == nil {
+		t.Fatalf("MakeEnvelopeLimit = %v, %v", env, err)
+	}
+
+	env, err = MakeEnvelopeLimit(path, pen, 2)
+	if env != nil {
+		t.Error("no partial envelope expected")
+	}
+	if !errors.Is(err, ErrEnvelopeNotConverged) {
+		t.Fatalf("err = %v, want ErrEnvelopeNotConverged", err)
+	}
+	var envErr *EnvelopeError
+	if !errors.As(err, &envErr) || envErr.Iterations != 2 || envErr.Knots <= 2 {
+		t.Errorf("unexpected diagnostics %+v", envErr)
+	}
+	if MakeEnvelope(path, pen) == nil {
+		t.Error("MakeEnvelope failed")
+	}
+}
+
+func TestEngineEnvelopeMaxIterations(t *testing.T) {
+	build := func() *Path {
+		p := straightPath([]Point{{0, 0}, {40, 0}, {40, 30}}, false)
+		p.Style.Pen = PenSquare(3)
+		return p
+	}
+	e := NewEngine().SetEnvelopeMaxIterations(1)
+	e.AddPath(build())
+	if err := e.Solve(); !errors.Is(err, ErrEnvelopeNotConverged) {
+		t.Errorf("Solve = %v, want ErrEnvelopeNotConverged", err)
+	}
+	p := build()
+	e = NewEngine()
+	e.AddPath(p)
+	if err := e.Solve(); err != nil || p.Envelope == nil {
+		t.Errorf("Solve = %v, envelope %v", err, p.Envelope)
+	}
+}
+
+func TestMakeEnvelopeContext(t *testing.T) {
+	path := straightPath([]Point{{0, 0}, {50, 0}, {50, 50}}, false)
+	pen := PenSquare(4)
+
+	var last [2]int
+	env, err := MakeEnvelopeContext(context.Background(), path, pen, 0, func(done, total int) {
+		last = [2]int{done, total}
+	})
+	if err != nil || env == nil {
+		t.Fatalf("MakeEnvelopeContext = %v, %v", env, err)
+	}
+	if last[0] != last[1] || last[1] == 0 {
+		t.Errorf("last progress report %v, want done == total", last)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if env, err := MakeEnvelopeContext(ctx, path, pen, 0, nil); env != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled MakeEnvelopeContext = %v, %v", env, err)
+	}
+}
+
+func TestEngineSolveContext(t *testing.T) {
+	e := NewEngine()
+	e.AddPath(straightPath([]Point{{0, 0}, {10, 0}}, false))
+	e.AddPath(straightPath([]Point{{0, 0}, {0, 10}}, false))
+	var reports [][2]int
+	e.SetProgress(func(done, total int) { reports = append(reports, [2]int{done, total}) })
+	if err := e.SolveContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || reports[0] != [2]int{1, 2} || reports[1] != [2]int{2, 2} {
+		t.Errorf("progress reports %v, want [[1 2] [2 2]]", reports)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := e.SolveContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("SolveContext = %v, want context.Canceled", err)
+	}
+}
+
+func TestMakeEnvelopeDegeneratePens(t *testing.T) {
+	path := straightPath([]Point{{0, 0}, {10, 0}, {10, 10}}, false)
+
+	// A point pen sweeps no area: the path there and back.
+	one := NewPath()
+	one.Append(&Knot{XCoord: 1, YCoord: 2})
+	for name, pen := range map[string]*Pen{
+		"single knot":   NewPenFromPath(one),
+		"unlinked knot": {Head: &Knot{XCoord: 1, YCoord: 2}},
+		"collapsed pen": Shifted(1, 2).ApplyToPen(PenSquare(0)),
+	} {
+		env, err := MakeEnvelopeLimit(path, pen, 0)
+		if err != nil || env == nil {
+			t.Fatalf("%s: MakeEnvelopeLimit = %v, %v", name, env, err)
+		}
+		if env.Head.LType == KnotEndpoint || env.PathLength() != 4 {
+			t.Errorf("%s: envelope %v, want a cycle of 4 segments", name, env)
+		}
+		minX, minY, maxX, maxY := PathBBox(env)
+		if minX != 1 || minY != 2 || maxX != 11 || maxY != 12 {
+			t.Errorf("%s: bbox (%g,%g)-(%g,%g), want (1,2)-(11,12)", name, minX, minY, maxX, maxY)
+		}
+		if poly, _ := flattenPath(env, 8); math.Abs(polygonArea(poly)) > 1e-9 {
+			t.Errorf("%s: envelope encloses area %g", name, polygonArea(poly))
+		}
+	}
+
+	// A razor gives the doubled offset outline: full width across the
+	// razor, none along it.
+	razor := PenRazor(2)
+	env := MakeEnvelope(straightPath([]Point{{0, 0}, {0, 10}}, false), razor)
+	if minX, minY, maxX, maxY := PathBBox(env); minX != -1 || maxX != 1 || minY != 0 || maxY != 10 {
+		t.Errorf("razor across: bbox (%g,%g)-(%g,%g)", minX, minY, maxX, maxY)
+	}
+	env = MakeEnvelope(straightPath([]Point{{0, 0}, {10, 0}}, false), razor)
+	if _, minY, _, maxY := PathBBox(env); minY != 0 || maxY != 0 {
+		t.Errorf("razor along: height %g, want 0", maxY-minY)
+	}
+	// Razor knots that are not linked into a loop work the same.
+	k1 := &Knot{XCoord: -1}
+	k1.Next = &Knot{XCoord: 1}
+	loose := MakeEnvelope(path, &Pen{Head: k1})
+	linked := MakeEnvelope(path, razor)
+	if loose == nil || loose.String() != linked.String() {
+		t.Errorf("unlinked razor envelope\n%v\nwant\n%v", loose, linked)
+	}
+}
@@ -0,0 +1,212 @@
+package mp
+
+import "math"
+
+// offsetTolerance is the largest distance, in the units of the path, by
+// which an offset curve from OffsetPath may deviate from the exact one
+// before a segment is subdivided.
+const offsetTolerance = 0.01
+
+// offsetMaxDepth limits the subdivision of a segment in OffsetPath to
+// 2^offsetMaxDepth pieces, for segments whose offset has cusps.
+const offsetMaxDepth = 6
+
+// offsetMiterLimit is the miter limit of OffsetPath's miter joins, the
+// same as MakeEnvelope's.
+const offsetMiterLimit = 4.0
+
+// OffsetPath returns the curve at distance d from p, to the left of its
+// direction for positive d and to the right for negative d: the edge of a
+// stroke of width 2|d|, or an outline grown or shrunk by |d| (a
+// counterclockwise cycle grows for negative d). Each segment is offset by
+// cubics that stay within 0.01 units of the exact offset, subdividing only
+// where the curvature demands it.
+//
+// Where the offsets of two segments separate at a corner they are joined
+// according to p.Style.LineJoin like MetaPost's linejoin: round joins
+// (the default) are true circular arcs around the knot of radius |d|, one
+// cubic per quarter turn, miter joins extend the offsets to their
+// intersection unless the miter is longer than four times |d|, and bevel
+// joins (and miters over the limit) connect them straight. On the inner
+// side of a corner the offsets are connected straight; the overlap forms
+// a small loop that does not change what a nonzero fill paints.
+//
+// The result is explicit and a cycle if p is. The style is copied. It
+// returns nil for paths without segments.
+//
+// Example:
+//
+//	border := mp.OffsetPath(shape, -2) // 2 units outside a counterclockwise shape
+func OffsetPath(p *Path, d Number) *Path {
+	segs := appendDrawnSegments(nil, p)
+	if len(segs) == 0 {
+		return nil
+	}
+	if d == 0 {
+		return p.Copy()
+	}
+	cycle := p.Head.LType != KnotEndpoint
+	q := segmentsPath(appendOffsetSegments(nil, segs, cycle, d, p.Style.LineJoin), cycle)
+	q.Style = p.Style
+	return q
+}
+
+// appendDrawnSegments appends the segments of p to segs, leaving out those
+// that are a single point, which have no offset.
+func appendDrawnSegments(segs [][4]Point, p *Path) [][4]Point {
+	if p == nil || p.Head == nil {
+		return segs
+	}
+	k := p.Head
+	for k.Next != nil && k.RType != KnotEndpoint {
+		n := k.Next
+		c := [4]Point{P(k.XCoord, k.YCoord), P(k.RightX, k.RightY), P(n.LeftX, n.LeftY), P(n.XCoord, n.YCoord)}
+		if _, ok := startDirection(c); ok {
+			segs = append(segs, c)
+		}
+		k = n
+		if k == p.Head {
+			break
+		}
+	}
+	return segs
+}
+
+// appendOffsetSegments appends to pieces the offset by d of the segments
+// segs, none of which may be a single point, with joins of type join; see
+// OffsetPath.
+func appendOffsetSegments(pieces, segs [][4]Point, cycle bool, d Number, join LineJoin) [][4]Point {
+	for i, c := range segs {
+		if i > 0 {
+			pieces = appendOffsetJoin(pieces, segs[i-1], c, d, join)
+		}
+		pieces = appendOffsetCubic(pieces, c, d, 0)
+	}
+	if cycle {
+		pieces = appendOffsetJoin(pieces, segs[len(segs)-1], segs[0], d, join)
+	}
+	return pieces
+}
+
+// startDirection and endDirection return the unit direction in which c
+// leaves its start and enters its end, looking past coincident control
+// points; ok is false for a segment that is a single point.
+func startDirection(c [4]Point) (Point, bool) {
+	for _, q := range c[1:] {
+		if ux, uy, ok := unitVector(q.X-c[0].X, q.Y-c[0].Y); ok {
+			return P(ux, uy), true
+		}
+	}
+	return Point{}, false
+}
+
+func endDirection(c [4]Point) (Point, bool) {
+	for i := 2; i >= 0; i-- {
+		if ux, uy, ok := unitVector(c[3].X-c[i].X, c[3].Y-c[i].Y); ok {
+			return P(ux, uy), true
+		}
+	}
+	return Point{}, false
+}
+
+// leftNormal returns u turned by 90° counterclockwise.
+func leftNormal(u Point) Point {
+	return P(-u.Y, u.X)
+}
+
+// appendOffsetCubic appends cubics approximating the offset of c by d,
+// subdividing c until they are within offsetTolerance.
+func appendOffsetCubic(pieces [][4]Point, c [4]Point, d Number, depth int) [][4]Point {
+	t0, _ := startDirection(c)
+	t1, _ := endDirection(c)
+	a := c[0].Add(leftNormal(t0).Mul(d))
+	b := c[3].Add(leftNormal(t1).Mul(d))
+	// The speed of the offset is (1 - dκ) times that of c.
+	h0 := Distance(c[0], c[1]) * math.Max(0, 1-d*cubicCurvature(c, 0))
+	h1 := Distance(c[2], c[3]) * math.Max(0, 1-d*cubicCurvature(c, 1))
+	cand := [4]Point{a, a.Add(t0.Mul(h0)), b.Sub(t1.Mul(h1)), b}
+	if depth >= offsetMaxDepth || offsetError(c, cand, d) <= offsetTolerance {
+		return append(pieces, cand)
+	}
+	l0, l1, l2, l3, r0, r1, r2, r3 := splitCubicPoints(c, 0.5)
+	pieces = appendOffsetCubic(pieces, [4]Point{l0, l1, l2, l3}, d, depth+1)
+	return appendOffsetCubic(pieces, [4]Point{r0, r1, r2, r3}, d, depth+1)
+}
+
+// splitCubicPoints splits c at time t into its two halves.
+func splitCubicPoints(c [4]Point, t Number) (l0, l1, l2, l3, r0, r1, r2, r3 Point) {
+	x0, y0, x1, y1, x2, y2, x3, y3, x4, y4, x5, y5, x6, y6, x7, y7 := splitCubicCoords(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, t)
+	return P(x0, y0), P(x1, y1), P(x2, y2), P(x3, y3), P(x4, y4), P(x5, y5), P(x6, y6), P(x7, y7)
+}
+
+// offsetError estimates how far the cubic cand deviates from the offset of
+// c by d: at a few times, the point of cand is projected onto c and its
+// distance compared with |d|.
+func offsetError(c, cand [4]Point, d Number) Number {
+	worst := Number(0)
+	for _, t := range []Number{0.25, 0.5, 0.75} {
+		qx, qy := evalCubic(cand[0].X, cand[0].Y, cand[1].X, cand[1].Y, cand[2].X, cand[2].Y, cand[3].X, cand[3].Y, t)
+		// Newton iteration for the nearest point of c, starting at t.
+		u := t
+		for range 4 {
+			x, y := evalCubic(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, u)
+			dx, dy := cubicDerivative(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, u)
+			ddx, ddy := cubicSecondDerivative(c, u)
+			ex, ey := x-qx, y-qy
+			den := dx*dx + dy*dy + ex*ddx + ey*ddy
+			if math.Abs(den) < 1e-12 {
+				break
+			}
+			u = math.Max(0, math.Min(1, u-(ex*dx+ey*dy)/den))
+		}
+		x, y := evalCubic(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, u)
+		worst = math.Max(worst, math.Abs(math.Hypot(qx-x, qy-y)-math.Abs(d)))
+	}
+	return worst
+}
+
+// appendOffsetJoin appends the join between the offsets by d of segment a
+// and the following segment b, which meet at a knot.
+func appendOffsetJoin(pieces [][4]Point, a, b [4]Point, d Number, join LineJoin) [][4]Point {
+	din, _ := endDirection(a)
+	dout, _ := startDirection(b)
+	from := pieces[len(pieces)-1][3]
+	to := b[0].Add(leftNormal(dout).Mul(d))
+	if Distance(from, to) < 1e-9 {
+		return pieces
+	}
+	// On the inner side of the turn the offsets overlap.
+	if d*din.Cross(dout) > 0 {
+		return append(pieces, lineSegment(from, to))
+	}
+	theta := math.Acos(math.Max(-1, math.Min(1, din.Dot(dout))))
+	switch join {
+	case LineJoinMiter:
+		if c := math.Cos(theta / 2); c > 1/offsetMiterLimit {
+			m := b[0].Add(leftNormal(din).Add(leftNormal(dout)).Normalized().Mul(d / c))
+			return append(pieces, lineSegment(from, m), lineSegment(m, to))
+		}
+		return append(pieces, lineSegment(from, to))
+	case LineJoinBevel:
+		return append(pieces, lineSegment(from, to))
+	}
+	// Round: circular arcs around the knot, at most a quarter turn each.
+	n := int(math.Ceil(theta / (math.Pi / 2)))
+	sign := Number(1)
+	if din.Cross(dout) < 0 {
+		sign = -1
+	}
+	start := math.Atan2(din.Y, din.X)
+	prev, prevDir := from, din
+	for i := 1; i <= n; i++ {
+		ang := start + sign*theta*Number(i)/Number(n)
+		dir := P(math.Cos(ang), math.Sin(ang))
+		pt := b[0].Add(leftNormal(dir).Mul(d))
+		if i == n {
+			pt = to
+		}
+		pieces = append(pieces, arcCubic(prev, prevDir, pt, dir))
+		prev, prevDir = pt, dir
+	}
+	return pieces
+}
@@ -0,0 +1,108 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+// sampleSegments returns points on every segment of p.
+func sampleSegments(p *Path, n int) []Point {
+	var pts []Point
+	for _, c := range pathSegments(p) {
+		for i := 0; i <= n; i++ {
+			x, y := evalCubic(c[0].X, c[0].Y, c[1].X, c[1].Y, c[2].X, c[2].Y, c[3].X, c[3].Y, Number(i)/Number(n))
+			pts = append(pts, P(x, y))
+		}
+	}
+	return pts
+}
+
+func TestOffsetPathCircle(t *testing.T) {
+	circle := Scaled(20).ApplyToPath(FullCircle()) // counterclockwise, radius 10
+	for _, d := range []Number{-2, 3} {
+		o := OffsetPath(circle, d)
+		if o.Head.LType == KnotEndpoint {
+			t.Fatalf("offset of a cycle is open")
+		}
+		if n := o.PathLength(); n > 16 {
+			t.Errorf("offset by %g has %d segments, want a compact result", d, n)
+		}
+		for _, q := range sampleSegments(o, 20) {
+			if r := math.Hypot(q.X, q.Y); math.Abs(r-(10-d)) > 0.02 {
+				t.Fatalf("offset by %g: point at radius %g, want %g", d, r, 10-d)
+			}
+		}
+	}
+}
+
+func TestOffsetPathJoins(t *testing.T) {
+	square := func(join LineJoin) *Path {
+		sq := Scaled(10).ApplyToPath(UnitSquare())
+		sq.Style.LineJoin = join
+		return sq
+	}
+	// squareDistance returns the distance of q from the outside of the
+	// square (0,0)--(10,10).
+	squareDistance := func(q Point) Number {
+		dx := math.Max(0, math.Max(-q.X, q.X-10))
+		dy := math.Max(0, math.Max(-q.Y, q.Y-10))
+		return math.Hypot(dx, dy)
+	}
+
+	round := OffsetPath(square(LineJoinDefault), -1)
+	if n := round.PathLength(); n != 8 {
+		t.Errorf("round-joined square has %d segments, want 4 sides and 4 arcs", n)
+	}
+	for _, q := range sampleSegments(round, 20) {
+		if d := squareDistance(q); math.Abs(d-1) > 0.001 {
+			t.Fatalf("round join point %v at distance %g, want 1", q, d)
+		}
+	}
+
+	miter := OffsetPath(square(LineJoinMiter), -1)
+	corners := 0
+	for _, q := range sampleSegments(miter, 1) {
+		if math.Abs(math.Abs(q.X-5)-6) < 1e-9 && math.Abs(math.Abs(q.Y-5)-6) < 1e-9 {
+			corners++
+		}
+	}
+	if corners == 0 || miter.PathLength() != 12 {
+		t.Errorf("miter join: %d segments, corner points found %d", miter.PathLength(), corners)
+	}
+
+	bevel := OffsetPath(square(LineJoinBevel), -1)
+	if n := bevel.PathLength(); n != 8 {
+		t.Errorf("bevel-joined square has %d segments, want 8", n)
+	}
+	if x, y := bevel.PointOf(2); x != 11 || y != 0 {
+		t.Errorf("bevel ends at (%g,%g), want (11,0)", x, y)
+	}
+
+	// Inside, the offsets are connected straight across the corners.
+	inner := OffsetPath(square(LineJoinDefault), 1)
+	if n := inner.PathLength(); n != 8 {
+		t.Errorf("inner offset has %d segments, want 8", n)
+	}
+	for _, q := range sampleSegments(inner, 4) {
+		if q.X < -1e-9 || q.X > 10+1e-9 || q.Y < -1e-9 || q.Y > 10+1e-9 {
+			t.Fatalf("inner offset leaves the square at %v", q)
+		}
+	}
+}
+
+func TestOffsetPathOpen(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(10, 0), P(10, 10)}, false)
+	o := OffsetPath(p, -2)
+	if o.Head.LType != KnotEndpoint {
+		t.Fatalf("offset of an open path is a cycle")
+	}
+	if x, y := o.PointOf(0); x != 0 || y != -2 {
+		t.Errorf("start at (%g,%g), want (0,-2)", x, y)
+	}
+	if x, y := o.PointOf(Number(o.PathLength())); x != 12 || y != 10 {
+		t.Errorf("end at (%g,%g), want (12,10)", x, y)
+	}
+	if OffsetPath(NewPath(), 1) != nil {
+		t.Errorf("offset of an empty path")
+	}
+}
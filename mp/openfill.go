@@ -0,0 +1,55 @@
+package mp
+
+import "errors"
+
+// ErrOpenFill is returned by the renderers for a filled path that is not a
+// cycle and has no Style.AutoCloseFill. MetaPost refuses such a fill too
+// ("Not a cycle"), while SVG viewers would close the path silently and
+// PostScript would not fill it at all.
+var ErrOpenFill = errors.New("mp: fill of an open path")
+
+// ExpandOpenFill returns the paths that draw p when it is an open path with
+// a fill: with Style.AutoCloseFill a fill-only copy closed by a straight
+// segment from its last knot back to its first, followed by p itself with
+// the fill removed for the stroke, which stays open. Without
+// AutoCloseFill it returns ErrOpenFill. Cycles and paths without a fill
+// are returned unchanged. Renderers call it for every path they draw, so
+// the region filled is part of the path data and the same in every
+// backend.
+//
+// Example:
+//
+//	p.Style.Fill = mp.ColorCSS("lightblue")
+//	p.Style.AutoCloseFill = true  // fill the area up to the chord
+func ExpandOpenFill(p *Path) ([]*Path, error) {
+	if p == nil || p.Head == nil || p.Head.LType != KnotEndpoint {
+		return []*Path{p}, nil
+	}
+	if fill := p.Style.Fill.CSS(); fill == "" || fill == "none" {
+		return []*Path{p}, nil
+	}
+	if !p.Style.AutoCloseFill {
+		return nil, ErrOpenFill
+	}
+	f := p.Copy()
+	first, last := f.Head, f.Head.Prev
+	last.RType, first.LType = KnotExplicit, KnotExplicit
+	last.RightX = last.XCoord + (first.XCoord-last.XCoord)/3
+	last.RightY = last.YCoord + (first.YCoord-last.YCoord)/3
+	first.LeftX = last.XCoord + 2*(first.XCoord-last.XCoord)/3
+	first.LeftY = last.YCoord + 2*(first.YCoord-last.YCoord)/3
+	f.Style.Stroke = ColorCSS("none")
+	f.Style.Dash = nil
+	f.Style.Arrow = ArrowStyle{}
+	f.Style.Closing = nil
+	f.Style.AutoCloseFill = false
+	f.Envelope = nil
+	out := []*Path{f}
+	if p.Style.Stroke.CSS() != "none" {
+		q := p.Copy()
+		q.Style.Fill = ColorCSS("none")
+		q.Style.AutoCloseFill = false
+		out = append(out, q)
+	}
+	return out, nil
+}
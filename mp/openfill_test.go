@@ -0,0 +1,50 @@
+package mp
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestExpandOpenFill(t *testing.T) {
+	p := makeMultiSegmentPath() // (0,0)--(100,0)--(100,100)
+	if got, err := ExpandOpenFill(p); err != nil || len(got) != 1 || got[0] != p {
+		t.Fatalf("unfilled path: got %d paths, %v; want p itself", len(got), err)
+	}
+
+	p.Style = Style{Stroke: ColorCSS("black"), Fill: ColorCSS("yellow"), Dash: DashEvenly()}
+	if _, err := ExpandOpenFill(p); !errors.Is(err, ErrOpenFill) {
+		t.Fatalf("filled open path without AutoCloseFill: err = %v, want ErrOpenFill", err)
+	}
+
+	p.Style.AutoCloseFill = true
+	got, err := ExpandOpenFill(p)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("got %d paths, %v; want fill and stroke", len(got), err)
+	}
+	fill, stroke := got[0], got[1]
+	if fill.Head.LType == KnotEndpoint || fill.PathLength() != 3 {
+		t.Fatalf("fill is not the path closed by one more segment: %s", fill)
+	}
+	if fill.Style.Fill.CSS() != "yellow" || fill.Style.Stroke.CSS() != "none" || fill.Style.Dash != nil {
+		t.Errorf("fill style = %+v", fill.Style)
+	}
+	// The closing segment is the straight chord from (100,100) to (0,0).
+	if x, y := fill.PointOf(2.5); math.Abs(x-50) > 1e-9 || math.Abs(y-50) > 1e-9 {
+		t.Errorf("closing segment passes (%g, %g), want (50, 50)", x, y)
+	}
+	if stroke.Head.LType != KnotEndpoint || stroke.Style.Fill.CSS() != "none" || stroke.Style.Dash == nil {
+		t.Errorf("stroke = %s with style %+v, want the open path without fill", stroke, stroke.Style)
+	}
+	if p.Head.LType != KnotEndpoint {
+		t.Error("p was closed")
+	}
+
+	p.Style.Stroke = ColorCSS("none")
+	if got, _ := ExpandOpenFill(p); len(got) != 1 {
+		t.Errorf("fill-only path: got %d paths, want the fill alone", len(got))
+	}
+	if got, _ := ExpandOpenFill(makeSquareCycle()); len(got) != 1 {
+		t.Errorf("cycle was expanded")
+	}
+}
@@ -0,0 +1,206 @@
+package mp
+
+import "math"
+
+// DefaultOutlineGrid is the number of grid cells along the longer side of
+// the area sampled by Outline.
+const DefaultOutlineGrid = 128
+
+// outlineSamples is the number of samples per segment used to flatten
+// paths for Outline.
+const outlineSamples = 16
+
+// outlineShape is a flattened path prepared for distance queries.
+type outlineShape struct {
+	pts    [][2]Number
+	closed bool
+	half   Number // half the stroke width, negative if not stroked
+	filled bool   // the closed polygon pts is filled
+	minX   Number // bounding box of pts
+	minY   Number
+	maxX   Number
+	maxY   Number
+}
+
+// Outline returns the outline of everything the paths paint, grown by
+// distance: the union of their strokes (half the stroke width or elliptical
+// pen around the path, or its envelope for polygonal pens) and fills, with
+// every point within distance of that area included. MetaPost has no such
+// operator; it is useful for sticker-style cut lines, glows and keep-out
+// regions.
+//
+// The union is found as a contour of the distance to the painted area,
+// sampled on a grid of DefaultOutlineGrid cells along the longer side, so
+// corners are rounded and details smaller than a grid cell are lost. The
+// result is a MultiPath of cycles oriented counterclockwise for outer
+// boundaries and clockwise for holes, so it can be filled with the nonzero
+// rule; it is nil if the paths paint nothing.
+//
+// Example:
+//
+//	cut := mp.Outline(paths, 3)
+//	cut.Style = mp.Style{Stroke: mp.ColorCSS("magenta"), StrokeWidth: 0.25}
+func Outline(paths []*Path, distance Number) *MultiPath {
+	return OutlineGrid(paths, distance, DefaultOutlineGrid)
+}
+
+// OutlineGrid is like Outline with an explicit number of grid cells along
+// the longer side of the sampled area.
+func OutlineGrid(paths []*Path, distance Number, cells int) *MultiPath {
+	var shapes []outlineShape
+	for _, p := range paths {
+		shapes = appendOutlineShapes(shapes, p)
+	}
+	if len(shapes) == 0 || cells < 1 {
+		return nil
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, s := range shapes {
+		grow := math.Max(s.half, 0)
+		minX, minY = math.Min(minX, s.minX-grow), math.Min(minY, s.minY-grow)
+		maxX, maxY = math.Max(maxX, s.maxX+grow), math.Max(maxY, s.maxY+grow)
+	}
+	// Pad so that the contour is closed inside the grid.
+	cell := math.Max(maxX-minX, maxY-minY) / Number(cells)
+	if cell <= 0 {
+		cell = math.Max(distance, 1) / Number(cells)
+	}
+	pad := math.Max(distance, 0) + 2*cell
+	minX, minY, maxX, maxY = minX-pad, minY-pad, maxX+pad, maxY+pad
+	nx := int(math.Ceil((maxX - minX) / cell))
+	ny := int(math.Ceil((maxY - minY) / cell))
+
+	dist := func(x, y Number) Number {
+		best := math.Inf(1)
+		for i := range shapes {
+			best = shapes[i].distance(x, y, best)
+		}
+		return best
+	}
+	var cycles []*Path
+	for _, c := range ContourLinesGrid(dist, minX, minY, maxX, maxY, []Number{distance}, nx, ny) {
+		if c.Path.Head.LType != KnotEndpoint {
+			cycles = append(cycles, c.Path)
+		}
+	}
+	if len(cycles) == 0 {
+		return nil
+	}
+	// Orient by nesting depth: even depth is an outer boundary.
+	m := NewMultiPath()
+	for i, c := range cycles {
+		depth := 0
+		pt := P(c.Head.XCoord, c.Head.YCoord)
+		for j, other := range cycles {
+			if i != j && other.Contains(pt.X, pt.Y) {
+				depth++
+			}
+		}
+		poly, _ := flattenPath(c, insideSamples)
+		if (polygonArea(poly) > 0) != (depth%2 == 0) {
+			c = c.Reversed()
+		}
+		m.Append(c)
+	}
+	return m
+}
+
+// appendOutlineShapes appends the shapes painted by p to shapes.
+func appendOutlineShapes(shapes []outlineShape, p *Path) []outlineShape {
+	if p == nil || p.Head == nil {
+		return shapes
+	}
+	fill := p.Style.Fill.CSS()
+	filled := fill != "" && fill != "none"
+	stroked := p.Style.Stroke.CSS() != "none"
+	if stroked && p.Envelope != nil {
+		shapes = appendOutlineShape(shapes, p.Envelope, -1, true)
+		stroked = false
+	}
+	half := Number(-1)
+	if stroked {
+		half = p.Style.StrokeWidth / 2
+		if pen := p.Style.Pen; pen != nil && pen.Elliptical {
+			half = GetPenScale(pen) / 2
+		}
+	}
+	if half < 0 && !filled {
+		return shapes
+	}
+	return appendOutlineShape(shapes, p, half, filled)
+}
+
+func appendOutlineShape(shapes []outlineShape, p *Path, half Number, filled bool) []outlineShape {
+	pts, closed := flattenPath(p, outlineSamples)
+	if len(pts) == 0 {
+		return shapes
+	}
+	s := outlineShape{pts: pts, closed: closed, half: half, filled: filled && closed && len(pts) > 2}
+	if s.half < 0 && !s.filled {
+		return shapes
+	}
+	s.minX, s.minY = math.Inf(1), math.Inf(1)
+	s.maxX, s.maxY = math.Inf(-1), math.Inf(-1)
+	for _, pt := range pts {
+		s.minX, s.maxX = math.Min(s.minX, pt[0]), math.Max(s.maxX, pt[0])
+		s.minY, s.maxY = math.Min(s.minY, pt[1]), math.Max(s.maxY, pt[1])
+	}
+	return append(shapes, s)
+}
+
+// distance returns the signed distance from (x, y) to the area painted by
+// s (negative inside fills), or best if s cannot come closer than that.
+func (s *outlineShape) distance(x, y, best Number) Number {
+	dx := math.Max(math.Max(s.minX-x, x-s.maxX), 0)
+	dy := math.Max(math.Max(s.minY-y, y-s.maxY), 0)
+	if math.Hypot(dx, dy)-math.Max(s.half, 0) >= best {
+		return best
+	}
+	d := math.Inf(1)
+	n := len(s.pts)
+	edges := n - 1
+	if s.closed || s.filled {
+		edges = n
+	}
+	if n == 1 {
+		d = math.Hypot(x-s.pts[0][0], y-s.pts[0][1])
+	}
+	for i := 0; i < edges; i++ {
+		a, b := s.pts[i], s.pts[(i+1)%n]
+		d = math.Min(d, segmentDistance(x, y, a[0], a[1], b[0], b[1]))
+	}
+	res := math.Inf(1)
+	if s.half >= 0 {
+		res = d - s.half
+	}
+	if s.filled {
+		if polygonWinding(s.pts, P(x, y)) != 0 {
+			d = -d
+		}
+		res = math.Min(res, d)
+	}
+	return math.Min(res, best)
+}
+
+// segmentDistance returns the distance from (x, y) to the segment from
+// (x0, y0) to (x1, y1).
+func segmentDistance(x, y, x0, y0, x1, y1 Number) Number {
+	dx, dy := x1-x0, y1-y0
+	l2 := dx*dx + dy*dy
+	t := Number(0)
+	if l2 > 0 {
+		t = math.Max(0, math.Min(1, ((x-x0)*dx+(y-y0)*dy)/l2))
+	}
+	return math.Hypot(x-(x0+t*dx), y-(y0+t*dy))
+}
+
+// polygonArea returns the signed area of poly (positive counterclockwise).
+func polygonArea(poly [][2]Number) Number {
+	a := Number(0)
+	for i := range poly {
+		p, q := poly[i], poly[(i+1)%len(poly)]
+		a += p[0]*q[1] - q[0]*p[1]
+	}
+	return a / 2
+}
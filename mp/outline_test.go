@@ -0,0 +1,60 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func circleAt(x, y, r Number, style Style) *Path {
+	c := Scaled(2 * r).Then(Shifted(x, y)).ApplyToPath(FullCircle())
+	c.Style = style
+	return c
+}
+
+func TestOutlineStrokedCircle(t *testing.T) {
+	ring := circleAt(0, 0, 20, Style{StrokeWidth: 2})
+	m := Outline([]*Path{ring}, 1)
+	if m == nil || len(m.Parts) != 2 {
+		t.Fatalf("expected outer and inner boundary, got %v", m)
+	}
+	for _, part := range m.Parts {
+		minX, _, maxX, _ := PathBBox(part)
+		r := (maxX - minX) / 2
+		poly, _ := flattenPath(part, insideSamples)
+		ccw := polygonArea(poly) > 0
+		switch {
+		case math.Abs(r-22) < 0.3:
+			if !ccw {
+				t.Error("outer boundary should be counterclockwise")
+			}
+		case math.Abs(r-18) < 0.3:
+			if ccw {
+				t.Error("hole should be clockwise")
+			}
+		default:
+			t.Errorf("unexpected boundary radius %g", r)
+		}
+	}
+}
+
+func TestOutlineUnion(t *testing.T) {
+	fill := Style{Stroke: ColorCSS("none"), Fill: ColorCSS("black")}
+	a := circleAt(0, 0, 10, fill)
+	b := circleAt(30, 0, 10, fill)
+	if m := Outline([]*Path{a, b}, 2); m == nil || len(m.Parts) != 2 {
+		t.Fatalf("distant circles: expected 2 parts, got %v", m)
+	}
+	m := Outline([]*Path{a, b}, 6)
+	if m == nil || len(m.Parts) != 1 {
+		t.Fatalf("merged circles: expected 1 part, got %v", m)
+	}
+	minX, minY, maxX, maxY := PathBBox(m.Parts[0])
+	if math.Abs(minX+16) > 0.3 || math.Abs(maxX-46) > 0.3 || math.Abs(maxY-16) > 0.3 || math.Abs(minY+16) > 0.3 {
+		t.Errorf("bbox = %g %g %g %g, want -16 -16 46 16", minX, minY, maxX, maxY)
+	}
+
+	unpainted := circleAt(0, 0, 10, Style{Stroke: ColorCSS("none")})
+	if m := Outline([]*Path{unpainted}, 2); m != nil {
+		t.Errorf("path painting nothing should have no outline, got %d parts", len(m.Parts))
+	}
+}
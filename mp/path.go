@@ -2,29 +2,113 @@ package mp
 
 import (
 	"fmt"
+	"math"
 	"strings"
 )
 
-// LineCap constants. Values are offset by 1 so that Go's zero value (0)
-// means "unset" and defaults to LineCapRounded (matching MetaPost's default).
-// Internal MetaPost values: butt=0, rounded=1, squared=2.
+// LineCap is the shape of the ends of open stroked paths, MetaPost's
+// linecap. The values are offset by 1 from MetaPost's so that the zero
+// value means "unset" and defaults to LineCapRounded, MetaPost's default;
+// use MetaPost and LineCapFromMetaPost to convert.
+type LineCap int
+
 const (
-	LineCapDefault = 0 // Unset - uses MetaPost default (rounded)
-	LineCapButt    = 1 // MetaPost linecap 0
-	LineCapRounded = 2 // MetaPost linecap 1 (MetaPost default)
-	LineCapSquared = 3 // MetaPost linecap 2
+	LineCapDefault LineCap = 0 // Unset - uses MetaPost default (rounded)
+	LineCapButt    LineCap = 1 // MetaPost linecap 0
+	LineCapRounded LineCap = 2 // MetaPost linecap 1 (MetaPost default)
+	LineCapSquared LineCap = 3 // MetaPost linecap 2
 )
 
-// LineJoin constants.
-// Note: MetaPost's default is linejoin=1 (rounded), not 0 (mitered).
-// Go's zero value means "unset", which defaults to rounded (MetaPost behavior).
+// String returns the name of the cap as in SVG, "butt", "round" or
+// "square", or "default" for LineCapDefault.
+func (c LineCap) String() string {
+	switch c {
+	case LineCapDefault:
+		return "default"
+	case LineCapButt:
+		return "butt"
+	case LineCapRounded:
+		return "round"
+	case LineCapSquared:
+		return "square"
+	}
+	return fmt.Sprintf("LineCap(%d)", int(c))
+}
+
+// Valid reports whether c is one of the LineCap constants.
+func (c LineCap) Valid() bool {
+	return c >= LineCapDefault && c <= LineCapSquared
+}
+
+// MetaPost returns the value of MetaPost's linecap for c: 0 for butt, 1
+// for rounded (also for LineCapDefault) and 2 for squared.
+func (c LineCap) MetaPost() int {
+	if c == LineCapDefault {
+		return 1
+	}
+	return int(c) - 1
+}
+
+// LineCapFromMetaPost returns the LineCap for a value of MetaPost's
+// linecap (0, 1 or 2); other values give LineCapDefault.
+func LineCapFromMetaPost(v int) LineCap {
+	if v < 0 || v > 2 {
+		return LineCapDefault
+	}
+	return LineCap(v + 1)
+}
+
+// LineJoin is the shape of the corners of stroked paths, MetaPost's
+// linejoin, offset by 1 like LineCap: the zero value means "unset" and
+// defaults to LineJoinRound, MetaPost's default (linejoin=1, not 0).
+type LineJoin int
+
 const (
-	LineJoinDefault = 0 // Unset - uses MetaPost default (rounded)
-	LineJoinMiter   = 1 // MetaPost linejoin 0
-	LineJoinRound   = 2 // MetaPost linejoin 1 (MetaPost default)
-	LineJoinBevel   = 3 // MetaPost linejoin 2
+	LineJoinDefault LineJoin = 0 // Unset - uses MetaPost default (rounded)
+	LineJoinMiter   LineJoin = 1 // MetaPost linejoin 0
+	LineJoinRound   LineJoin = 2 // MetaPost linejoin 1 (MetaPost default)
+	LineJoinBevel   LineJoin = 3 // MetaPost linejoin 2
 )
 
+// String returns the name of the join as in SVG, "miter", "round" or
+// "bevel", or "default" for LineJoinDefault.
+func (j LineJoin) String() string {
+	switch j {
+	case LineJoinDefault:
+		return "default"
+	case LineJoinMiter:
+		return "miter"
+	case LineJoinRound:
+		return "round"
+	case LineJoinBevel:
+		return "bevel"
+	}
+	return fmt.Sprintf("LineJoin(%d)", int(j))
+}
+
+// Valid reports whether j is one of the LineJoin constants.
+func (j LineJoin) Valid() bool {
+	return j >= LineJoinDefault && j <= LineJoinBevel
+}
+
+// MetaPost returns the value of MetaPost's linejoin for j: 0 for mitered,
+// 1 for rounded (also for LineJoinDefault) and 2 for beveled.
+func (j LineJoin) MetaPost() int {
+	if j == LineJoinDefault {
+		return 1
+	}
+	return int(j) - 1
+}
+
+// LineJoinFromMetaPost returns the LineJoin for a value of MetaPost's
+// linejoin (0, 1 or 2); other values give LineJoinDefault.
+func LineJoinFromMetaPost(v int) LineJoin {
+	if v < 0 || v > 2 {
+		return LineJoinDefault
+	}
+	return LineJoin(v + 1)
+}
+
 // Arrow constants (MetaPost defaults from plain.mp)
 const (
 	DefaultAHLength = 4.0  // default arrowhead length (4bp)
@@ -37,6 +121,15 @@ type ArrowStyle struct {
 	End    bool   // arrow at end of path (for drawarrow)
 	Length Number // ahlength - arrow head length
 	Angle  Number // ahangle - arrow head angle in degrees
+	// Joined draws the arrowheads of a path stroked with a polygonal pen
+	// and its envelope as one filled outline (see JoinedArrowOutline).
+	Joined bool
+	// Shape selects a built-in head shape, the triangle by default or a
+	// harpoon (see ArrowShape); Head overrides it.
+	Shape ArrowShape
+	// Head builds the arrowheads instead of the built-in triangle (see
+	// ArrowHeadFunc); nil draws the triangle.
+	Head ArrowHeadFunc
 }
 
 // DashPattern represents a dash pattern for stroked paths.
@@ -119,25 +212,82 @@ func (d *DashPattern) Shifted(offset float64) *DashPattern {
 	return result
 }
 
+// ForLineCap returns the dash pattern that a backend stroking only with
+// butt caps needs to render d as drawn with the given line cap and stroke
+// width. Round and square caps (and LineCapDefault, which is round) extend
+// every dash by half the width at both ends, so every "on" length grows by
+// the width, the following gap shrinks by the same amount (keeping the
+// period) and the phase is shifted by half the width. The "on 0" dashes of
+// MetaPost's dotted patterns (DashWithDots), which only show through these
+// caps, thus become dots as long as the stroke is wide. Butt caps need no
+// change and return d unchanged.
+func (d *DashPattern) ForLineCap(lineCap LineCap, width float64) *DashPattern {
+	if d == nil || lineCap == LineCapButt || len(d.Array) == 0 {
+		return d
+	}
+	if width <= 0 {
+		width = minDotLength
+	}
+	arr := d.Array
+	if len(arr)%2 == 1 {
+		// An odd array repeats with on/off roles swapped (as in SVG/PostScript).
+		arr = append(append([]float64{}, arr...), arr...)
+	}
+	result := &DashPattern{Array: make([]float64, len(arr)), Offset: d.Offset}
+	copy(result.Array, arr)
+	for i := 0; i < len(result.Array); i += 2 {
+		// A gap narrower than the caps closes.
+		grow := math.Min(width, result.Array[i+1])
+		result.Array[i] += grow
+		result.Array[i+1] -= grow
+	}
+	result.Offset += width / 2
+	return result
+}
+
+// minDotLength is the width used by ForLineCap when no stroke width is
+// known, so that dots stay visible.
+const minDotLength = 0.01
+
 // Style holds drawing attributes attached to a path.
 type Style struct {
 	Stroke      Color
 	StrokeWidth float64
 	Fill        Color
 	Pen         *Pen // mirrors pen_p in mp.c (mp.c:564)
-	// LineJoin/LineCap mirror MetaPost linejoin/linecap (mp.c:23894ff).
-	// LineCap uses offset constants (0=default/unset → rounded).
-	// LineJoin uses direct MetaPost values (0=miter, 1=round, 2=bevel).
-	LineJoin int
-	LineCap  int // Use LineCapButt, LineCapRounded, LineCapSquared constants
+	// LineJoin/LineCap mirror MetaPost linejoin/linecap (mp.c:23894ff),
+	// offset by 1 so that the zero value means MetaPost's default (rounded).
+	LineJoin LineJoin
+	LineCap  LineCap
 	Arrow    ArrowStyle
 	Dash     *DashPattern // dash pattern for stroked paths (mp.w:11362ff)
+	// LineStyle draws a cartographic symbol instead of a plain stroke
+	// (see ExpandLineStyle).
+	LineStyle LineStyle
+	// Gradient varies the stroke color along the path (see
+	// ExpandStrokeGradient); Stroke is then ignored.
+	Gradient *StrokeGradient
+	// Hairline draws the stroke as thin as the output device allows,
+	// whatever the scale, ignoring StrokeWidth and Pen.
+	Hairline bool
+	// Meta is free-form metadata for downstream tools; the SVG writer
+	// emits it as data- attributes (see WithMeta).
+	Meta map[string]string
+	// Closing is merged over the style for the closing segment of a
+	// cycle, e.g. to dash it or hide it (see ExpandClosingStyle); nil
+	// draws it like the rest of the path.
+	Closing *Style
+	// AutoCloseFill fills an open path up to the straight segment from its
+	// end back to its start (see ExpandOpenFill); the stroke stays open.
+	// Without it a filled open path is an error (ErrOpenFill).
+	AutoCloseFill bool
 }
 
 type Path struct {
 	Head     *Knot
 	Style    Style
-	Envelope *Path // optional precomputed offset/envelope (mp_apply_offset analogue)
+	Envelope *Path        // optional precomputed offset/envelope (mp_apply_offset analogue)
+	ellipse  *ellipseInfo // set for (transformed) fullcircles, see ellipse.go
 }
 
 func (p *Path) String() string {
@@ -197,80 +347,79 @@ func (p *Path) Copy() *Path {
 		}
 	}
 	q.Style = p.Style
+	q.ellipse = p.ellipse
 	if p.Envelope != nil {
 		q.Envelope = p.Envelope.Copy()
 	}
 	return q
 }
 
-// ShortenPathForArrow creates a copy of path p with endpoints moved inward
-// to make room for arrowheads. This mimics MetaPost's "cutafter" behavior.
-// shortenStart/shortenEnd specify how much to shorten at each end.
+// ShortenPathForArrow creates a copy of path p with shortenStart cut off
+// its start and shortenEnd cut off its end, both measured along the curve
+// (arc length), to make room for arrowheads. Like MetaPost's cutafter the
+// shortened path is a subpath of p, so on strongly curved ends it still
+// runs on the curve up to the base of the head instead of leaving along
+// the end tangent. If the cuts overlap, the result is the point where the
+// shortened start would be. The style is copied; a cycle becomes open.
 func ShortenPathForArrow(p *Path, shortenStart, shortenEnd Number) *Path {
 	if p == nil || p.Head == nil {
 		return nil
 	}
-	// Make a copy of the path
-	q := p.Copy()
-	if q.Head == nil {
-		return q
+	if shortenStart <= 0 && shortenEnd <= 0 {
+		return p.Copy()
+	}
+	n := Number(p.PathLength())
+	if n == 0 {
+		return p.Copy()
+	}
+	t0, t1 := Number(0), n
+	if shortenStart > 0 {
+		t0 = cutTime(p, shortenStart)
 	}
-
-	// Shorten at end
 	if shortenEnd > 0 {
-		// Find the last knot
-		last := q.Head
-		for last.Next != nil && last.Next != q.Head {
-			last = last.Next
-		}
-		// Get direction at the end
-		dx := last.XCoord - last.LeftX
-		dy := last.YCoord - last.LeftY
-		if dx == 0 && dy == 0 && last.Prev != nil {
-			dx = last.XCoord - last.Prev.XCoord
-			dy = last.YCoord - last.Prev.YCoord
-		}
-		length := sqrtNumber(dx*dx + dy*dy)
-		if length > 0.0001 {
-			dx /= length
-			dy /= length
-			// Move endpoint back
-			last.XCoord -= dx * shortenEnd
-			last.YCoord -= dy * shortenEnd
-			// Also adjust control point
-			last.LeftX -= dx * shortenEnd
-			last.LeftY -= dy * shortenEnd
+		// The length of the drawn curve, which cutTime measures, even on
+		// transformed fullcircles where ArcLength is the exact ellipse's.
+		total := p.Subpath(0, n).ArcLength()
+		t1 = 0
+		if total > shortenEnd {
+			t1 = cutTime(p, total-shortenEnd)
 		}
 	}
+	if t1 < t0 {
+		t1 = t0
+	}
+	q := p.Subpath(t0, t1)
+	q.Style = p.Style
+	return q
+}
 
-	// Shorten at start
-	if shortenStart > 0 {
-		start := q.Head
-		// Get direction at the start
-		dx := start.RightX - start.XCoord
-		dy := start.RightY - start.YCoord
-		if dx == 0 && dy == 0 && start.Next != nil {
-			dx = start.Next.XCoord - start.XCoord
-			dy = start.Next.YCoord - start.YCoord
+// cutTime returns the time at which the arc length of p from its start
+// reaches arcLen. ArcTime interpolates the speed linearly within a
+// segment, which misses by several units on segments of uneven speed (a
+// line whose controls sit on its endpoints), so its result is refined by
+// Newton steps on the arc length of the subpath up to t.
+func cutTime(p *Path, arcLen Number) Number {
+	n := Number(p.PathLength())
+	t := p.ArcTime(arcLen)
+	for i := 0; i < 8; i++ {
+		diff := p.Subpath(0, t).ArcLength() - arcLen
+		if math.Abs(diff) < 1e-6 {
+			break
 		}
-		length := sqrtNumber(dx*dx + dy*dy)
-		if length > 0.0001 {
-			dx /= length
-			dy /= length
-			// Move startpoint forward
-			start.XCoord += dx * shortenStart
-			start.YCoord += dy * shortenStart
-			// Also adjust control point
-			start.RightX += dx * shortenStart
-			start.RightY += dy * shortenStart
+		// The speed at t is three times postcontrol minus precontrol.
+		dx, dy := p.DirectionOf(t)
+		speed := 3 * math.Hypot(dx, dy)
+		if speed < 1e-9 {
+			break
 		}
+		t = math.Max(0, math.Min(n, t-diff/speed))
 	}
-
-	return q
+	return t
 }
 
 // ArrowHeadEnd creates an arrowhead path at the end of path p.
-// The arrowhead is a filled triangle with apex at the endpoint.
+// The arrowhead is a filled triangle with apex at the endpoint, or what
+// p.Style.Arrow.Head builds there.
 // Uses ahLength for the arrow length and ahAngle for the head angle (degrees).
 func ArrowHeadEnd(p *Path, ahLength, ahAngle Number) *Path {
 	if p == nil || p.Head == nil {
@@ -301,11 +450,12 @@ func ArrowHeadEnd(p *Path, ahLength, ahAngle Number) *Path {
 	dx /= length
 	dy /= length
 
-	return createArrowHead(last.XCoord, last.YCoord, dx, dy, ahLength, ahAngle)
+	return makeArrowHead(p.Style.Arrow, last.XCoord, last.YCoord, dx, dy, ahLength, ahAngle)
 }
 
 // ArrowHeadStart creates an arrowhead path at the start of path p.
-// The arrowhead is a filled triangle with apex at the start point.
+// The arrowhead is a filled triangle with apex at the start point, or what
+// p.Style.Arrow.Head builds there.
 func ArrowHeadStart(p *Path, ahLength, ahAngle Number) *Path {
 	if p == nil || p.Head == nil {
 		return nil
@@ -330,7 +480,113 @@ func ArrowHeadStart(p *Path, ahLength, ahAngle Number) *Path {
 	dx = -dx
 	dy = -dy
 
-	return createArrowHead(start.XCoord, start.YCoord, dx, dy, ahLength, ahAngle)
+	return makeArrowHead(p.Style.Arrow, start.XCoord, start.YCoord, dx, dy, ahLength, ahAngle)
+}
+
+// StraightPath returns the polyline through pts as MetaPost's "--" builds
+// it, closed if cycle is true. The control points are set directly, so no
+// solver runs, but like Solve it fails with a *KnotError if a point is NaN
+// or infinite. An empty point list gives a nil path and no error.
+//
+// Example:
+//
+//	tri, err := mp.StraightPath([]mp.Point{a, b, c}, true)  // a--b--c--cycle
+func StraightPath(pts []Point, cycle bool) (*Path, error) {
+	p := straightPath(pts, cycle)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// straightPath builds a polyline through pts with straight segments
+// (explicit control points at one and two thirds, as MetaPost's "--" with
+// curl 1 would produce). If cycle is true the last point connects back to
+// the first. Returns nil for an empty point list.
+func straightPath(pts []Point, cycle bool) *Path {
+	if len(pts) == 0 {
+		return nil
+	}
+	p := NewPath()
+	for _, pt := range pts {
+		p.Append(&Knot{XCoord: pt.X, YCoord: pt.Y, LType: KnotExplicit, RType: KnotExplicit})
+	}
+	k := p.Head
+	for {
+		next := k.Next
+		if next == p.Head && !cycle {
+			break
+		}
+		k.RightX = k.XCoord + (next.XCoord-k.XCoord)/3
+		k.RightY = k.YCoord + (next.YCoord-k.YCoord)/3
+		next.LeftX = k.XCoord + 2*(next.XCoord-k.XCoord)/3
+		next.LeftY = k.YCoord + 2*(next.YCoord-k.YCoord)/3
+		k = next
+		if k == p.Head {
+			break
+		}
+	}
+	if !cycle {
+		first, last := p.Head, p.Head.Prev
+		first.LType = KnotEndpoint
+		first.LeftX, first.LeftY = first.XCoord, first.YCoord
+		last.RType = KnotEndpoint
+		last.RightX, last.RightY = last.XCoord, last.YCoord
+	}
+	return p
+}
+
+// FlowArrowHeads returns n arrowheads evenly spaced along p by arc length,
+// each aligned with the path's direction at its position (flow indicators on
+// field lines or circuit wires). On open paths the heads sit at the centers
+// of n equal pieces; on cycles they start at the path's beginning. Each head
+// is centered on its position, i.e. its tip lies ahLength/2 further along.
+// Positions where the direction is undefined are skipped. The heads have
+// the shape of p.Style.Arrow.Head if it is set.
+//
+// Example:
+//
+//	for _, head := range mp.FlowArrowHeads(p, 3, mp.DefaultAHLength, mp.DefaultAHAngle) {
+//	    head.Style.Fill = p.Style.Stroke
+//	}
+func FlowArrowHeads(p *Path, n int, ahLength, ahAngle Number) []*Path {
+	return FlowArrowHeadsParam(NewArcLengthParam(p), n, ahLength, ahAngle)
+}
+
+// FlowArrowHeadsParam is FlowArrowHeads on the path of the arc-length
+// parameterization a (see ArcLengthParam).
+func FlowArrowHeadsParam(a *ArcLengthParam, n int, ahLength, ahAngle Number) []*Path {
+	p := a.Path()
+	if p == nil || p.Head == nil || n <= 0 {
+		return nil
+	}
+	total := a.Length()
+	if total <= 0 {
+		return nil
+	}
+	isCycle := p.Head.RType != KnotEndpoint && p.Head.Prev != nil && p.Head.Prev.RType != KnotEndpoint
+	heads := make([]*Path, 0, n)
+	for i := 0; i < n; i++ {
+		pos := total * (Number(i) + 0.5) / Number(n)
+		if isCycle {
+			pos = total * Number(i) / Number(n)
+		}
+		tip := pos + ahLength/2
+		if !isCycle && tip > total {
+			tip = total
+		}
+		t := a.Time(tip) // wraps around on cycles
+		x, y := p.PointOf(t)
+		dx, dy := p.DirectionOf(t)
+		length := sqrtNumber(dx*dx + dy*dy)
+		if length < 0.0001 {
+			continue
+		}
+		if h := makeArrowHead(p.Style.Arrow, x, y, dx/length, dy/length, ahLength, ahAngle); h != nil {
+			heads = append(heads, h)
+		}
+	}
+	return heads
 }
 
 // createArrowHead creates a triangular arrowhead path.
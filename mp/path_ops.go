@@ -316,7 +316,11 @@ func (p *Path) DirectionOf(t Number) (dx, dy Number) {
 // Subpath returns a new path representing the portion from t1 to t2.
 // Mirrors MetaPost's "subpath (t1,t2) of p" (mp.c:8869ff / mp.w:9543ff).
 //
-// If t1 > t2, the subpath runs backwards.
+// If t1 > t2, the subpath runs backwards. On open paths the times are
+// clamped to [0, length]; on cycles they wrap around the head, so
+// subpath (3.5,5.2) of a four-segment cycle runs from the middle of the
+// last segment through the head into the second, and an interval longer
+// than the cycle goes around more than once.
 // The returned path is always open (non-cyclic).
 func (p *Path) Subpath(t1, t2 Number) *Path {
 	if p == nil || p.Head == nil {
@@ -348,175 +352,59 @@ func (p *Path) Subpath(t1, t2 Number) *Path {
 	}
 
 	isCycle := p.Head.RType != KnotEndpoint && p.Head.Prev != nil && p.Head.Prev.RType != KnotEndpoint
+	fn := Number(n)
 
-	// Clamp for open paths
-	if !isCycle {
-		if t1 < 0 {
-			t1 = 0
-		}
-		if t2 > Number(n) {
-			t2 = Number(n)
-		}
-	}
-
-	result := NewPath()
-
-	// Get start and end segment indices
-	seg1 := int(math.Floor(float64(t1)))
-	frac1 := t1 - Number(seg1)
-	seg2 := int(math.Floor(float64(t2)))
-	frac2 := t2 - Number(seg2)
-
-	// Handle cycles
 	if isCycle {
-		seg1 = seg1 % n
-		if seg1 < 0 {
-			seg1 += n
-		}
-		seg2 = seg2 % n
-		if seg2 < 0 {
-			seg2 += n
-		}
+		// Like mp_chop_path, shift both times by whole turns so that
+		// 0 <= t1 < n; t2 may lie beyond n and then wraps past the head,
+		// as often as needed.
+		shift := math.Floor(t1/fn) * fn
+		t1 -= shift
+		t2 -= shift
 	} else {
-		// Clamp segment indices
-		if seg1 >= n {
-			seg1 = n - 1
-			frac1 = 1.0
-		}
-		if seg2 >= n {
-			seg2 = n - 1
-			frac2 = 1.0
-		}
+		// Clamp for open paths
+		t1 = math.Max(0, math.Min(t1, fn))
+		t2 = math.Max(0, math.Min(t2, fn))
 	}
 
-	if seg1 == seg2 && frac1 <= frac2 {
-		// Subpath within a single segment
-		return p.subpathSingleSegment(seg1, frac1, frac2)
+	// seg1 is the segment containing t1, seg2 the last segment the
+	// subpath reaches into (unwrapped indices).
+	seg1 := int(math.Floor(t1))
+	if seg1 >= n && !isCycle {
+		seg1 = n - 1
 	}
-
-	// Multi-segment subpath
-	// First partial segment: from frac1 to 1
-	knot1, _ := p.getSegment(seg1)
-	if knot1 == nil || knot1.Next == nil {
-		return result
+	seg2 := int(math.Ceil(t2)) - 1
+	if seg2 < seg1 {
+		seg2 = seg1
 	}
-
-	// Split at frac1 to get the second half
-	p0x, p0y := knot1.XCoord, knot1.YCoord
-	p1x, p1y := knot1.RightX, knot1.RightY
-	p2x, p2y := knot1.Next.LeftX, knot1.Next.LeftY
-	p3x, p3y := knot1.Next.XCoord, knot1.Next.YCoord
-
-	if frac1 > 0 {
-		// Split and take second half
-		_, _, _, _, _, _, _, _, q0x, q0y, q1x, q1y, q2x, q2y, q3x, q3y := splitCubicCoords(
-			p0x, p0y, p1x, p1y, p2x, p2y, p3x, p3y, frac1)
-		p0x, p0y = q0x, q0y
-		p1x, p1y = q1x, q1y
-		p2x, p2y = q2x, q2y
-		p3x, p3y = q3x, q3y
+	if seg1 == seg2 {
+		return p.subpathSingleSegment(seg1%n, t1-Number(seg1), t2-Number(seg1))
 	}
 
-	// Add first knot
-	k := NewKnot()
-	k.XCoord = p0x
-	k.YCoord = p0y
-	k.LeftX = p0x
-	k.LeftY = p0y
-	k.RightX = p1x
-	k.RightY = p1y
-	k.LType = KnotExplicit
-	k.RType = KnotExplicit
-	result.Append(k)
-
-	// Add endpoint of first partial segment
-	k = NewKnot()
-	k.XCoord = p3x
-	k.YCoord = p3y
-	k.LeftX = p2x
-	k.LeftY = p2y
-	k.RightX = p3x
-	k.RightY = p3y
-	k.LType = KnotExplicit
-	k.RType = KnotExplicit
-	result.Append(k)
-
-	// Add complete middle segments
-	for s := seg1 + 1; s < seg2; s++ {
-		actualSeg := s
-		if isCycle {
-			actualSeg = s % n
-			if actualSeg < 0 {
-				actualSeg += n
-			}
+	result := NewPath()
+	for s := seg1; s <= seg2; s++ {
+		from, to := Number(0), Number(1)
+		if s == seg1 {
+			from = t1 - Number(seg1)
 		}
-		knot, _ := p.getSegment(actualSeg)
-		if knot == nil || knot.Next == nil {
+		if s == seg2 {
+			to = t2 - Number(seg2)
+		}
+		piece := p.subpathSingleSegment(s%n, from, to)
+		if piece.Head == nil || piece.Head.Next == piece.Head {
 			continue
 		}
-
-		// Update control points of previous knot
-		lastKnot := result.Head.Prev
-		lastKnot.RightX = knot.RightX
-		lastKnot.RightY = knot.RightY
-
-		// Add new endpoint
-		k = NewKnot()
-		k.XCoord = knot.Next.XCoord
-		k.YCoord = knot.Next.YCoord
-		k.LeftX = knot.Next.LeftX
-		k.LeftY = knot.Next.LeftY
-		k.RightX = k.XCoord
-		k.RightY = k.YCoord
-		k.LType = KnotExplicit
-		k.RType = KnotExplicit
-		result.Append(k)
-	}
-
-	// Final partial segment: from 0 to frac2
-	if seg2 != seg1 || frac2 < frac1 {
-		knot2, _ := p.getSegment(seg2)
-		if knot2 != nil && knot2.Next != nil {
-			fp0x, fp0y := knot2.XCoord, knot2.YCoord
-			fp1x, fp1y := knot2.RightX, knot2.RightY
-			fp2x, fp2y := knot2.Next.LeftX, knot2.Next.LeftY
-			fp3x, fp3y := knot2.Next.XCoord, knot2.Next.YCoord
-
-			if frac2 < 1 {
-				// Split and take first half
-				r0x, r0y, r1x, r1y, r2x, r2y, r3x, r3y, _, _, _, _, _, _, _, _ := splitCubicCoords(
-					fp0x, fp0y, fp1x, fp1y, fp2x, fp2y, fp3x, fp3y, frac2)
-				fp0x, fp0y = r0x, r0y
-				fp1x, fp1y = r1x, r1y
-				fp2x, fp2y = r2x, r2y
-				fp3x, fp3y = r3x, r3y
-			}
-
-			// Update control points of previous knot
-			lastKnot := result.Head.Prev
-			lastKnot.RightX = fp1x
-			lastKnot.RightY = fp1y
-
-			// Add final endpoint
-			k = NewKnot()
-			k.XCoord = fp3x
-			k.YCoord = fp3y
-			k.LeftX = fp2x
-			k.LeftY = fp2y
-			k.RightX = k.XCoord
-			k.RightY = k.YCoord
-			k.LType = KnotExplicit
-			k.RType = KnotExplicit
-			result.Append(k)
+		start, end := piece.Head, piece.Head.Next
+		if result.Head == nil {
+			result.Append(CopyKnot(start))
+		} else {
+			last := result.Head.Prev
+			last.RightX, last.RightY = start.RightX, start.RightY
+			last.RType = KnotExplicit
+			last.Quadratic = start.Quadratic
 		}
+		result.Append(CopyKnot(end))
 	}
-
-	// Set endpoint types for open path
-	if result.Head != nil {
-		result.Head.LType = KnotEndpoint
-		result.Head.Prev.RType = KnotEndpoint
-	}
-
 	return result
 }
 
@@ -582,6 +470,7 @@ func (p *Path) subpathSingleSegment(seg int, frac1, frac2 Number) *Path {
 	k1.RightY = p1y
 	k1.LType = KnotEndpoint
 	k1.RType = KnotExplicit
+	k1.Quadratic = knot.Quadratic // pieces of a quadratic are quadratic
 	result.Append(k1)
 
 	k2 := NewKnot()
@@ -598,8 +487,12 @@ func (p *Path) subpathSingleSegment(seg int, frac1, frac2 Number) *Path {
 	return result
 }
 
-// Reversed returns a copy of the path with direction reversed.
-// Used for subpath when t1 > t2.
+// Reversed returns a copy of the path with direction reversed, like
+// MetaPost's "reverse p" (mp_htap_ypoc): time t on the result is time n-t
+// on p, where n is the length of p. An open path starts at the former
+// end; a cycle keeps its first knot, so the point at time 0 is unchanged
+// and only the direction of travel flips. Knots keep their Info and
+// Origin fields. Also used for subpath when t1 > t2.
 func (p *Path) Reversed() *Path {
 	if p == nil || p.Head == nil {
 		return NewPath()
@@ -617,22 +510,25 @@ func (p *Path) Reversed() *Path {
 			break
 		}
 	}
+	reversed := func(old *Knot) *Knot {
+		k := CopyKnot(old)
+		k.Next, k.Prev = nil, nil
+		// Swap left and right control points and types
+		k.LeftX, k.LeftY, k.RightX, k.RightY = old.RightX, old.RightY, old.LeftX, old.LeftY
+		k.LType, k.RType = old.RType, old.LType
+		// The segment leaving k is the one that arrived at old.
+		k.Quadratic = old.Prev != nil && old.Prev.Quadratic && old.LType != KnotEndpoint
+		return k
+	}
 
-	// Add knots in reverse order, swapping left/right control points
-	for i := len(knots) - 1; i >= 0; i-- {
-		old := knots[i]
-		k := NewKnot()
-		k.XCoord = old.XCoord
-		k.YCoord = old.YCoord
-		// Swap left and right control points
-		k.LeftX = old.RightX
-		k.LeftY = old.RightY
-		k.RightX = old.LeftX
-		k.RightY = old.LeftY
-		// Swap left and right types
-		k.LType = old.RType
-		k.RType = old.LType
-		result.Append(k)
+	// A cycle keeps its head: p0, p(n-1), ..., p1.
+	last := 0
+	if p.Head.LType != KnotEndpoint {
+		result.Append(reversed(knots[0]))
+		last = 1
+	}
+	for i := len(knots) - 1; i >= last; i-- {
+		result.Append(reversed(knots[i]))
 	}
 
 	result.Style = p.Style
@@ -692,14 +588,96 @@ func (p *Path) CutAfter(q *Path) *Path {
 	return p.Subpath(0, t1)
 }
 
+// CutBeforeFrom is like CutBefore but ignores intersections at or before time
+// t0 on p, so a q that touches p at its start (t0 = 0) is skipped. This
+// mirrors how plain.mp's cutarrows avoid trivial touches.
+//
+// If there is no such intersection, returns a copy of p.
+func (p *Path) CutBeforeFrom(q *Path, t0 Number) *Path {
+	if p == nil || p.Head == nil {
+		return NewPath()
+	}
+	t1, _ := p.IntersectionTimesAfter(q, t0)
+	if t1 < 0 {
+		return p.Copy()
+	}
+	return p.Subpath(t1, Number(p.PathLength()))
+}
+
+// CutAfterFrom is like CutAfter but ignores intersections at or before time
+// t0 on p.
+//
+// If there is no such intersection, returns a copy of p.
+func (p *Path) CutAfterFrom(q *Path, t0 Number) *Path {
+	if p == nil || p.Head == nil {
+		return NewPath()
+	}
+	t1, _ := p.IntersectionTimesAfter(q, t0)
+	if t1 < 0 {
+		return p.Copy()
+	}
+	return p.Subpath(0, t1)
+}
+
+// CutBeforeNear is like CutBefore but cuts at the intersection of p and q
+// that lies closest to hint.
+//
+// If there is no intersection, returns a copy of p.
+//
+// Example:
+//
+//	edge := p.CutBeforeNear(nodeOutline, nodeCenter)
+func (p *Path) CutBeforeNear(q *Path, hint Point) *Path {
+	if p == nil || p.Head == nil {
+		return NewPath()
+	}
+	t1, ok := p.intersectionTimeNear(q, hint)
+	if !ok {
+		return p.Copy()
+	}
+	return p.Subpath(t1, Number(p.PathLength()))
+}
+
+// CutAfterNear is like CutAfter but cuts at the intersection of p and q
+// that lies closest to hint.
+//
+// If there is no intersection, returns a copy of p.
+func (p *Path) CutAfterNear(q *Path, hint Point) *Path {
+	if p == nil || p.Head == nil {
+		return NewPath()
+	}
+	t1, ok := p.intersectionTimeNear(q, hint)
+	if !ok {
+		return p.Copy()
+	}
+	return p.Subpath(0, t1)
+}
+
+// intersectionTimeNear returns the time on p of the intersection with q
+// closest to hint.
+func (p *Path) intersectionTimeNear(q *Path, hint Point) (Number, bool) {
+	best, bestDist := Number(-1), math.Inf(1)
+	for _, tt := range p.AllIntersectionTimes(q) {
+		x, y := p.PointOf(tt[0])
+		if d := math.Hypot(x-hint.X, y-hint.Y); d < bestDist {
+			best, bestDist = tt[0], d
+		}
+	}
+	return best, best >= 0
+}
+
 // ArcLength returns the total arc length of the path.
 // Mirrors MetaPost's "arclength p" (mp.w:10197ff).
 //
 // Uses adaptive Simpson's rule to integrate |B'(t)| along each segment.
+// Transformed fullcircles use the exact ellipse instead (see ellipse.go).
 func (p *Path) ArcLength() Number {
 	if p == nil || p.Head == nil {
 		return 0
 	}
+	if e := p.ellipseShape(); e != nil {
+		return e.arcLength(0, 2*math.Pi)
+	}
 
 	n := p.PathLength()
 	if n == 0 {
@@ -770,10 +748,17 @@ const arcTolerance = 1.0 / 4096.0
 // For cyclic paths:
 //   - Negative arcLen traverses backwards
 //   - arcLen > total wraps around multiple times
+//
+// On transformed fullcircles arcLen is measured on the exact ellipse, like
+// ArcLength, so ArcTime(ArcLength()) is the path length; see ellipse.go for
+// how ellipse angles map to times.
 func (p *Path) ArcTime(arcLen Number) Number {
 	if p == nil || p.Head == nil {
 		return 0
 	}
+	if e := p.ellipseShape(); e != nil {
+		return e.arcTime(arcLen)
+	}
 
 	n := p.PathLength()
 	if n == 0 {
@@ -1180,15 +1165,55 @@ func splitCubicCoords(p0x, p0y, p1x, p1y, p2x, p2y, p3x, p3y, t Number) (
 //   - (-1, -1) if no intersection exists
 //
 // The algorithm iterates over all pairs of segments and uses recursive bisection
-// to find the intersection point.
+// to find the intersection point. Like MetaPost, it prefers the intersection
+// that comes first on p.
 func (p *Path) IntersectionTimes(q *Path) (t1, t2 Number) {
+	return p.intersectionTimesFrom(q, 0)
+}
+
+// intersectionTimeEps is the minimal distance in time between the lower
+// bound of IntersectionTimesAfter and an accepted intersection. It keeps
+// touching points at the lower bound (e.g. q starting on p) out of the result.
+const intersectionTimeEps = 1e-3
+
+// IntersectionTimesAfter is like IntersectionTimes but only considers
+// intersections with t1 > tMin on p. Use it to skip trivial intersections,
+// for example when q starts on p at time tMin.
+//
+// Returns (-1, -1) if there is no such intersection.
+func (p *Path) IntersectionTimesAfter(q *Path, tMin Number) (t1, t2 Number) {
+	if tMin < 0 {
+		return p.IntersectionTimes(q)
+	}
+	return p.intersectionTimesFrom(q, tMin+intersectionTimeEps)
+}
+
+// AllIntersectionTimes returns the intersection times of p and q ordered by
+// the time on p. At most one intersection is reported per time on p (within
+// a small tolerance).
+func (p *Path) AllIntersectionTimes(q *Path) [][2]Number {
+	var res [][2]Number
+	t1, t2 := p.IntersectionTimes(q)
+	for t1 >= 0 {
+		res = append(res, [2]Number{t1, t2})
+		t1, t2 = p.IntersectionTimesAfter(q, t1)
+	}
+	return res
+}
+
+// intersectionTimesFrom finds the first intersection of p and q with
+// t1 >= start by searching the segments of p from start onwards.
+func (p *Path) intersectionTimesFrom(q *Path, start Number) (t1, t2 Number) {
 	if p == nil || p.Head == nil || q == nil || q.Head == nil {
 		return -1, -1
 	}
+	if p.IsPoint() || q.IsPoint() {
+		return p.pointIntersectionTimes(q, start)
+	}
 
 	np := p.PathLength()
 	nq := q.PathLength()
-	if np == 0 || nq == 0 {
+	if np == 0 || nq == 0 || start >= Number(np) {
 		return -1, -1
 	}
 
@@ -1196,7 +1221,7 @@ func (p *Path) IntersectionTimes(q *Path) (t1, t2 Number) {
 	for tolStep := 0; tolStep <= 3; tolStep += 3 {
 		curP := p.Head
 		for i := 0; i < np; i++ {
-			if curP.Next == nil || curP.RType == KnotEndpoint {
+			if curP.Next == nil || curP.RType == KnotEndpoint || Number(i+1) <= start {
 				curP = curP.Next
 				if curP == p.Head {
 					break
@@ -1204,6 +1229,21 @@ func (p *Path) IntersectionTimes(q *Path) (t1, t2 Number) {
 				continue
 			}
 
+			p0x, p0y := curP.XCoord, curP.YCoord
+			p1x, p1y := curP.RightX, curP.RightY
+			p2x, p2y := curP.Next.LeftX, curP.Next.LeftY
+			p3x, p3y := curP.Next.XCoord, curP.Next.YCoord
+			// Only search the part of the first segment after start
+			lo := Number(0)
+			if start > Number(i) {
+				lo = start - Number(i)
+				_, _, _, _, _, _, _, _,
+					p0x, p0y, p1x, p1y, p2x, p2y, p3x, p3y = splitCubicCoords(
+					p0x, p0y, p1x, p1y, p2x, p2y, p3x, p3y, lo)
+			}
+
+			// Of all segments of q, take the intersection earliest on p
+			bestT1, bestT2 := Number(-1), Number(-1)
 			curQ := q.Head
 			for j := 0; j < nq; j++ {
 				if curQ.Next == nil || curQ.RType == KnotEndpoint {
@@ -1216,10 +1256,7 @@ func (p *Path) IntersectionTimes(q *Path) (t1, t2 Number) {
 
 				// Try to find intersection between segment i of p and segment j of q
 				t1Local, t2Local, found := cubicIntersection(
-					curP.XCoord, curP.YCoord,
-					curP.RightX, curP.RightY,
-					curP.Next.LeftX, curP.Next.LeftY,
-					curP.Next.XCoord, curP.Next.YCoord,
+					p0x, p0y, p1x, p1y, p2x, p2y, p3x, p3y,
 					curQ.XCoord, curQ.YCoord,
 					curQ.RightX, curQ.RightY,
 					curQ.Next.LeftX, curQ.Next.LeftY,
@@ -1227,9 +1264,8 @@ func (p *Path) IntersectionTimes(q *Path) (t1, t2 Number) {
 					tolStep,
 				)
 
-				if found {
-					// Add segment offsets
-					return Number(i) + t1Local, Number(j) + t2Local
+				if found && (bestT1 < 0 || t1Local < bestT1) {
+					bestT1, bestT2 = t1Local, Number(j)+t2Local
 				}
 
 				curQ = curQ.Next
@@ -1237,6 +1273,10 @@ func (p *Path) IntersectionTimes(q *Path) (t1, t2 Number) {
 					break
 				}
 			}
+			if bestT1 >= 0 {
+				// Add segment offsets
+				return Number(i) + lo + bestT1*(1-lo), bestT2
+			}
 
 			curP = curP.Next
 			if curP == p.Head {
@@ -1248,6 +1288,27 @@ func (p *Path) IntersectionTimes(q *Path) (t1, t2 Number) {
 	return -1, -1
 }
 
+// pointIntersectionTimes implements intersectionTimesFrom if p or q is a
+// point path: the time on the point path is 0, the time on the other path
+// is where it passes through the point.
+func (p *Path) pointIntersectionTimes(q *Path, start Number) (t1, t2 Number) {
+	if p.IsPoint() {
+		if start > 0 {
+			return -1, -1
+		}
+		x, y := p.PointOf(0)
+		if t := q.timeOfPoint(x, y, 0); t >= 0 {
+			return 0, t
+		}
+		return -1, -1
+	}
+	x, y := q.PointOf(0)
+	if t := p.timeOfPoint(x, y, start); t >= 0 {
+		return t, 0
+	}
+	return -1, -1
+}
+
 // IntersectionPoint returns the point where paths p and q intersect.
 // Mirrors MetaPost's "intersectionpoint (p, q)".
 //
@@ -1799,3 +1860,51 @@ func (p *Path) DirectionPointOf(dx, dy Number) (x, y Number, found bool) {
 	x, y = p.PointOf(t)
 	return x, y, true
 }
+
+// PathBBox returns the bounding box of the path including the extrema of
+// every cubic segment, i.e. MetaPost's llcorner/urcorner of a path
+// (mp_path_bbox in mp.w). An empty path yields all zeros.
+func PathBBox(p *Path) (minX, minY, maxX, maxY Number) {
+	if p == nil || p.Head == nil {
+		return 0, 0, 0, 0
+	}
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	expand := func(x, y Number) {
+		minX = math.Min(minX, x)
+		maxX = math.Max(maxX, x)
+		minY = math.Min(minY, y)
+		maxY = math.Max(maxY, y)
+	}
+	k := p.Head
+	for {
+		expand(k.XCoord, k.YCoord)
+		q := k.Next
+		if q == nil || k.RType == KnotEndpoint {
+			break
+		}
+		minX, maxX = bboxCubic1D(k.XCoord, k.RightX, q.LeftX, q.XCoord, minX, maxX)
+		minY, maxY = bboxCubic1D(k.YCoord, k.RightY, q.LeftY, q.YCoord, minY, maxY)
+		k = q
+		if k == p.Head {
+			break
+		}
+	}
+	return minX, minY, maxX, maxY
+}
+
+// bboxCubic1D merges the range of a one-dimensional cubic Bézier into
+// [curMin, curMax] by checking the roots of its derivative.
+func bboxCubic1D(p0, p1, p2, p3, curMin, curMax Number) (Number, Number) {
+	expand := func(v Number) {
+		curMin = math.Min(curMin, v)
+		curMax = math.Max(curMax, v)
+	}
+	expand(p0)
+	expand(p3)
+	for _, t := range cubicExtrema1D(p0, p1, p2, p3) {
+		mt := 1 - t
+		expand(mt*mt*mt*p0 + 3*mt*mt*t*p1 + 3*mt*t*t*p2 + t*t*t*p3)
+	}
+	return curMin, curMax
+}
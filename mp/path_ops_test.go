@@ -353,6 +353,57 @@ func TestSubpath_Reversed(t *testing.T) {
 	}
 }
 
+func TestSubpath_CycleWrap(t *testing.T) {
+	p := makeSquareCycle() // (0,0)--(100,0)--(100,100)--(0,100)--cycle
+
+	tests := []struct {
+		t1, t2 Number
+		length int
+		knots  [][2]Number // inner knots, which are knots of p
+	}{
+		// subpath (3.5,5.2): across the head into the second segment
+		{3.5, 5.2, 3, [][2]Number{{0, 0}, {100, 0}}},
+		// subpath (-0.5,0.5) is subpath (3.5,4.5)
+		{-0.5, 0.5, 2, [][2]Number{{0, 0}}},
+		// whole turns: twice around
+		{0, 8, 8, [][2]Number{{100, 0}, {100, 100}, {0, 100}, {0, 0}, {100, 0}, {100, 100}, {0, 100}}},
+		// integer ends add no degenerate segments
+		{1, 3, 2, [][2]Number{{100, 100}}},
+		{6, 7.5, 2, [][2]Number{{0, 100}}},
+	}
+	for _, tt := range tests {
+		sub := p.Subpath(tt.t1, tt.t2)
+		if got := sub.PathLength(); got != tt.length {
+			t.Errorf("subpath (%g,%g): length %d, want %d", tt.t1, tt.t2, got, tt.length)
+			continue
+		}
+		if sub.Head.LType != KnotEndpoint {
+			t.Errorf("subpath (%g,%g) is a cycle", tt.t1, tt.t2)
+		}
+		for _, end := range []struct{ st, pt Number }{{0, tt.t1}, {Number(tt.length), tt.t2}} {
+			x, y := sub.PointOf(end.st)
+			wx, wy := p.PointOf(end.pt)
+			if !approxEqual(x, wx, 1e-9) || !approxEqual(y, wy, 1e-9) {
+				t.Errorf("subpath (%g,%g) at %g = (%g, %g), want (%g, %g)", tt.t1, tt.t2, end.st, x, y, wx, wy)
+			}
+		}
+		for i, k := range tt.knots {
+			if x, y := sub.PointOf(Number(i + 1)); !approxEqual(x, k[0], 1e-9) || !approxEqual(y, k[1], 1e-9) {
+				t.Errorf("subpath (%g,%g) knot %d = (%g, %g), want %v", tt.t1, tt.t2, i+1, x, y, k)
+			}
+		}
+	}
+
+	// Backwards across the head: reverse of subpath (3.5,5.2).
+	back := p.Subpath(5.2, 3.5)
+	if x, y := back.PointOf(0); !approxEqual(x, 100, 1e-9) || y <= 0 || y >= 100 {
+		t.Errorf("subpath (5.2,3.5) starts at (%g, %g)", x, y)
+	}
+	if x, y := back.PointOf(3); !approxEqual(x, 0, 1e-9) || !approxEqual(y, 50, 1e-9) {
+		t.Errorf("subpath (5.2,3.5) ends at (%g, %g), want (0, 50)", x, y)
+	}
+}
+
 func TestSubpath_FullPath(t *testing.T) {
 	p := makeLinePath() // (0,0)--(100,0)
 
@@ -392,6 +443,40 @@ func TestReversed(t *testing.T) {
 	}
 }
 
+func TestReversedParameterization(t *testing.T) {
+	open := makeMultiSegmentPath()
+	open.Head.Next.Info, open.Head.Next.Origin = 7, OriginUser
+	cycle := UnitSquare()
+	cycle.Head.RightY, cycle.Head.Next.LeftX = -0.5, 1.5 // a curved first segment
+	cycle.Head.Info = 3
+	for _, tc := range []struct {
+		name string
+		p    *Path
+	}{{"open", open}, {"cycle", cycle}} {
+		n := Number(tc.p.PathLength())
+		rev := tc.p.Reversed()
+		if m := Number(rev.PathLength()); m != n {
+			t.Fatalf("%s: reversed length %g, want %g", tc.name, m, n)
+		}
+		for _, tt := range []Number{0, 0.25, 1, 1.5, 2, n - 0.1, n} {
+			x, y := tc.p.PointOf(n - tt)
+			rx, ry := rev.PointOf(tt)
+			if !approxEqual(x, rx, 1e-9) || !approxEqual(y, ry, 1e-9) {
+				t.Errorf("%s: reverse at %g = (%g, %g), want point at %g (%g, %g)", tc.name, tt, rx, ry, n-tt, x, y)
+			}
+		}
+	}
+	if rev := cycle.Reversed(); rev.Head.XCoord != cycle.Head.XCoord || rev.Head.YCoord != cycle.Head.YCoord || rev.Head.Info != 3 {
+		t.Errorf("reversed cycle does not keep its head knot")
+	}
+	if k := open.Reversed().Head.Next; k.Info != 7 || k.Origin != OriginUser {
+		t.Errorf("reversed knot Info/Origin = %d/%d, want 7/%d", k.Info, k.Origin, OriginUser)
+	}
+	if back := cycle.Reversed().Reversed(); back.String() != cycle.String() {
+		t.Errorf("reversing a cycle twice changed it:\n%s\nwant\n%s", back, cycle)
+	}
+}
+
 func TestEvalCubic(t *testing.T) {
 	// Test with a simple line: (0,0) to (100,0)
 	// Control points on the line: (33.33, 0) and (66.67, 0)
@@ -830,3 +915,44 @@ func TestSolveQuadratic(t *testing.T) {
 		})
 	}
 }
+
+func TestPathContains(t *testing.T) {
+	c := Scaled(10).ApplyToPath(FullCircle())
+	if !c.Contains(0, 0) || !c.Contains(4.9, 0) {
+		t.Errorf("expected points inside the circle")
+	}
+	if c.Contains(5.1, 0) || c.Contains(4, 4) {
+		t.Errorf("expected points outside the circle")
+	}
+}
+
+func TestPathWindingNumber(t *testing.T) {
+	c := Scaled(10).ApplyToPath(FullCircle())
+	if c.WindingNumber(0, 0) != 1 || c.Reversed().WindingNumber(0, 0) != -1 {
+		t.Errorf("winding numbers of the circle around its center: %d, reversed %d",
+			c.WindingNumber(0, 0), c.Reversed().WindingNumber(0, 0))
+	}
+	// Close to the curve between two knots, where a flattened outline
+	// would cut the corner
+	for _, r := range []Number{4.9999, 5.0001} {
+		x, y := Rotated(22.5).ApplyToPoint(r, 0)
+		if got, want := c.Contains(x, y), r < 5; got != want {
+			t.Errorf("point at radius %g: inside %v, want %v", r, got, want)
+		}
+	}
+	// The center of a pentagram is wound around twice, its points once
+	var star []Point
+	for i := 0; i < 5; i++ {
+		x, y := Rotated(Number(144*i)).ApplyToPoint(10, 0)
+		star = append(star, Point{x, y})
+	}
+	s := straightPath(star, true)
+	if s.WindingNumber(0, 0) != 2 || s.WindingNumber(8, 0) != 1 {
+		t.Errorf("pentagram winding numbers %d and %d, want 2 and 1", s.WindingNumber(0, 0), s.WindingNumber(8, 0))
+	}
+	// An open path is closed by a straight line
+	half := c.Subpath(0, 4)
+	if !half.Contains(0, 1) || half.Contains(0, -1) {
+		t.Error("open half circle should contain the points above its chord only")
+	}
+}
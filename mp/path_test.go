@@ -1,6 +1,7 @@
 package mp
 
 import (
+	"errors"
 	"math"
 	"testing"
 )
@@ -241,6 +242,24 @@ func TestShortenPathForArrow_DiagonalLine(t *testing.T) {
 	}
 }
 
+func TestShortenPathForArrow_CurvedEnd(t *testing.T) {
+	// A quarter circle of radius 20: the shortened end must stay on the
+	// circle, at the arc length before the original end.
+	p := FullCircle().Scaled(40).Subpath(0, 2)
+	total := p.ArcLength()
+	shortened := ShortenPathForArrow(p, 0, 10)
+	last := shortened.Head.Prev
+	if r := math.Hypot(last.XCoord, last.YCoord); math.Abs(r-20) > 0.01 {
+		t.Errorf("shortened end (%.4f, %.4f) is off the circle (r = %.4f)", last.XCoord, last.YCoord, r)
+	}
+	if got := shortened.ArcLength(); math.Abs(got-(total-10)) > 0.01 {
+		t.Errorf("shortened length = %.4f, want %.4f", got, total-10)
+	}
+	if shortened.Head.XCoord != 20 || shortened.Head.YCoord != 0 {
+		t.Errorf("start moved to (%.4f, %.4f)", shortened.Head.XCoord, shortened.Head.YCoord)
+	}
+}
+
 func TestShortenPathForArrow_NilPath(t *testing.T) {
 	result := ShortenPathForArrow(nil, 10, 10)
 	if result != nil {
@@ -418,3 +437,80 @@ func TestDashPatternNilShifted(t *testing.T) {
 		t.Error("shifting nil should return nil")
 	}
 }
+
+func TestDashPatternForLineCap(t *testing.T) {
+	dots := DashWithDots()
+	if got := dots.ForLineCap(LineCapButt, 1); got != dots || got.Offset != 2.5 {
+		t.Errorf("butt caps should keep the pattern and its offset unchanged")
+	}
+	for _, lineCap := range []LineCap{LineCapDefault, LineCapRounded, LineCapSquared} {
+		got := dots.ForLineCap(lineCap, 0.5)
+		if len(got.Array) != 2 || got.Array[0] != 0.5 || got.Array[1] != 4.5 {
+			t.Errorf("ForLineCap(%v) array = %v, want [0.5 4.5]", lineCap, got.Array)
+		}
+		if got.Offset != 2.75 {
+			t.Errorf("ForLineCap(%v) offset = %v, want 2.75", lineCap, got.Offset)
+		}
+	}
+	if dots.Array[0] != 0 {
+		t.Errorf("original pattern was modified: %v", dots.Array)
+	}
+	got := DashEvenly().ForLineCap(LineCapRounded, 4)
+	if len(got.Array) != 2 || got.Array[0] != 6 || got.Array[1] != 0 || got.Offset != 2 {
+		t.Errorf("ForLineCap(round) of evenly = %v offset %v, want [6 0] offset 2", got.Array, got.Offset)
+	}
+}
+
+func TestFlowArrowHeads(t *testing.T) {
+	p := makeHorizontalLine(0, 100, 0)
+	heads := FlowArrowHeads(p, 4, 4, 45)
+	if len(heads) != 4 {
+		t.Fatalf("expected 4 heads, got %d", len(heads))
+	}
+	for i, h := range heads {
+		// The tip is the second knot of the triangle.
+		tip := h.Head.Next
+		want := 12.5 + 25*float64(i) + 2
+		if math.Abs(tip.XCoord-want) > 0.01 || math.Abs(tip.YCoord) > 0.01 {
+			t.Errorf("head %d: tip at (%g, %g), want (%g, 0)", i, tip.XCoord, tip.YCoord, want)
+		}
+		// Base corners lie behind the tip for a rightward path.
+		if h.Head.XCoord >= tip.XCoord {
+			t.Errorf("head %d does not point along the path", i)
+		}
+	}
+	if FlowArrowHeads(p, 0, 4, 45) != nil {
+		t.Errorf("expected nil for n=0")
+	}
+}
+
+func TestStraightPath(t *testing.T) {
+	p, err := StraightPath([]Point{P(0, 0), P(30, 0), P(30, 30)}, true)
+	if err != nil {
+		t.Fatalf("StraightPath: %v", err)
+	}
+	if p.PathLength() != 3 || p.Head.LType == KnotEndpoint {
+		t.Errorf("expected a cycle of 3 segments, got %d (cycle %v)", p.PathLength(), p.Head.LType != KnotEndpoint)
+	}
+	if k := p.Head; k.RightX != 10 || k.Next.LeftX != 20 {
+		t.Errorf("controls at %g and %g, want 10 and 20", k.RightX, k.Next.LeftX)
+	}
+	p, err = StraightPath([]Point{P(0, 0), P(math.Inf(1), 0)}, false)
+	var ke *KnotError
+	if p != nil || !errors.As(err, &ke) {
+		t.Errorf("expected a KnotError, got %v, %v", p, err)
+	}
+	if p, err := StraightPath(nil, false); p != nil || err != nil {
+		t.Errorf("empty point list: %v, %v", p, err)
+	}
+}
+
+func TestShortenPathForArrow_Ellipse(t *testing.T) {
+	// The cut is measured on the drawn curve, so the shortened path is
+	// exactly 10 shorter than the Bézier curve.
+	p := FullCircle().XScaled(80).YScaled(30)
+	drawn := p.Subpath(0, 8).ArcLength()
+	if got := ShortenPathForArrow(p, 0, 10).ArcLength(); math.Abs(got-(drawn-10)) > 1e-4 {
+		t.Errorf("shortened length = %.6f, want %.6f", got, drawn-10)
+	}
+}
@@ -0,0 +1,61 @@
+package mp
+
+import "math"
+
+// PatternAlong places copies of motif along carrier, spaced evenly by arc
+// length, and returns them in order. The motif is drawn in its own
+// coordinates with the origin as the anchor placed on the carrier; with
+// align set, its x axis is turned into the carrier's direction at each
+// place (so a motif pointing right follows the path), otherwise it is only
+// shifted. The copies keep the motif's style.
+//
+// On a cycle the spacing is adjusted to the nearest value that divides the
+// arc length, so the pattern closes without a seam, and the first copy
+// sits at time 0. On an open path as many copies as fit at the given
+// spacing are centered on the path; when the arc length is a multiple of
+// spacing the first and last copy sit at the ends. Nothing is returned
+// for a spacing <= 0.
+//
+// Example:
+//
+//	tie := mp.UnitSquare().Shifted(-0.5, -0.5).YScaled(8)
+//	ties := mp.PatternAlong(track, tie, 5, true)  // railroad ties every 5 units
+func PatternAlong(carrier, motif *Path, spacing Number, align bool) []*Path {
+	return PatternAlongParam(NewArcLengthParam(carrier), motif, spacing, align)
+}
+
+// PatternAlongParam is PatternAlong on the path of the arc-length
+// parameterization a, for patterns that must line up with dashes, labels
+// or arrowheads placed with the same parameterization.
+func PatternAlongParam(a *ArcLengthParam, motif *Path, spacing Number, align bool) []*Path {
+	carrier := a.Path()
+	if carrier == nil || carrier.Head == nil || motif == nil || motif.Head == nil || spacing <= 0 {
+		return nil
+	}
+	total := a.Length()
+	cycle := carrier.Head.LType != KnotEndpoint
+	var n int
+	var start Number
+	if cycle {
+		n = int(math.Round(total / spacing))
+		if n < 1 {
+			n = 1
+		}
+		spacing = total / Number(n)
+	} else {
+		n = int(math.Floor(total/spacing+1e-9)) + 1
+		start = (total - Number(n-1)*spacing) / 2
+	}
+	copies := make([]*Path, 0, n)
+	for i := 0; i < n; i++ {
+		t := a.Time(start + Number(i)*spacing)
+		x, y := carrier.PointOf(t)
+		tr := Identity()
+		if align {
+			dx, dy := outgoingDirection(carrier, t)
+			tr = Rotated(math.Atan2(dy, dx) * 180 / math.Pi)
+		}
+		copies = append(copies, tr.Then(Shifted(x, y)).ApplyToPath(motif))
+	}
+	return copies
+}
@@ -0,0 +1,73 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPatternAlongOpen(t *testing.T) {
+	carrier := straightPath([]Point{P(0, 0), P(10, 0)}, false)
+	motif := straightPath([]Point{P(0, 0), P(1, 0)}, false)
+	copies := PatternAlong(carrier, motif, 3, true)
+	// floor(10/3)+1 = 4 copies, centered: 0.5, 3.5, 6.5, 9.5
+	if len(copies) != 4 {
+		t.Fatalf("got %d copies, want 4", len(copies))
+	}
+	for i, c := range copies {
+		want := 0.5 + 3*Number(i)
+		if math.Abs(c.Head.XCoord-want) > 1e-9 || math.Abs(c.Head.YCoord) > 1e-9 {
+			t.Errorf("copy %d at (%g,%g), want (%g,0)", i, c.Head.XCoord, c.Head.YCoord, want)
+		}
+	}
+	if PatternAlong(carrier, motif, 0, true) != nil {
+		t.Error("expected nil for spacing 0")
+	}
+}
+
+func TestPatternAlongAlign(t *testing.T) {
+	carrier := straightPath([]Point{P(0, 0), P(0, 4)}, false)
+	motif := straightPath([]Point{P(0, 0), P(1, 0)}, false)
+	copies := PatternAlong(carrier, motif, 2, true)
+	if len(copies) != 3 {
+		t.Fatalf("got %d copies, want 3", len(copies))
+	}
+	// The carrier goes up, so the motif's x axis is turned to (0, 1).
+	end := copies[1].Head.Next
+	if math.Abs(end.XCoord) > 1e-9 || math.Abs(end.YCoord-3) > 1e-9 {
+		t.Errorf("aligned motif ends at (%g,%g), want (0,3)", end.XCoord, end.YCoord)
+	}
+	end = PatternAlong(carrier, motif, 2, false)[1].Head.Next
+	if math.Abs(end.XCoord-1) > 1e-9 || math.Abs(end.YCoord-2) > 1e-9 {
+		t.Errorf("unaligned motif ends at (%g,%g), want (1,2)", end.XCoord, end.YCoord)
+	}
+}
+
+func TestPatternAlongCycle(t *testing.T) {
+	circle := FullCircle().Scaled(20)
+	e := NewEngine()
+	e.AddPath(circle)
+	if err := e.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	motif := straightPath([]Point{P(0, 0), P(0, 1)}, false)
+	// Circumference ~62.8: spacing 6 is adjusted to 62.8/10.
+	copies := PatternAlong(circle, motif, 6, true)
+	if len(copies) != 10 {
+		t.Fatalf("got %d copies, want 10", len(copies))
+	}
+	for i, c := range copies {
+		x, y := c.Head.XCoord, c.Head.YCoord
+		if r := math.Hypot(x, y); math.Abs(r-10) > 0.02 {
+			t.Errorf("copy %d at radius %g, want 10", i, r)
+		}
+		// The motif points along +y, i.e. to the left of the counterclockwise
+		// tangent: towards the center.
+		tip := c.Head.Next
+		if math.Hypot(tip.XCoord, tip.YCoord) >= math.Hypot(x, y) {
+			t.Errorf("copy %d does not point inwards", i)
+		}
+	}
+	if math.Abs(copies[0].Head.XCoord-10) > 1e-9 {
+		t.Errorf("first copy at x=%g, want 10", copies[0].Head.XCoord)
+	}
+}
@@ -1,13 +1,90 @@
 package mp
 
-import "sort"
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+)
 
 // Pen mirrors MetaPost's pen objects: a closed knot list describing the pen
 // shape. MetaPost stores this as pen_p on stroke/fill nodes (mp.c:564,1056ff).
 // Here we keep a minimal container with the pen's knot head.
+//
+// Data derived from the knots (vertex list, count, bounding box and the
+// initial edge direction used by offsetPrep) is computed on first use and
+// cached on the pen, so stroking many paths with one pen walks its knots
+// only once. The cache is recomputed after Transform.ApplyToPen,
+// Transform.ApplyToKnot or a Knot setter edited any knot; code that
+// assigns knot fields directly must call Invalidate. A Pen holds its cache
+// in an atomic value and must not be copied: pass and store it as *Pen.
 type Pen struct {
 	Head       *Knot
 	Elliptical bool // mirrors pen_is_elliptical macro (mp.c:444)
+
+	derived atomic.Pointer[penData]
+}
+
+// knotEdits counts the in-place edits of knots through Transform.ApplyToPen,
+// Transform.ApplyToKnot and the Knot setters. Knots do not know the pens
+// they belong to, so cached pen data is keyed on this count and recomputed
+// once it changes.
+var knotEdits atomic.Uint64
+
+// penData is the cached per-pen data, see Pen.data.
+type penData struct {
+	head       *Knot       // Head the data was computed for
+	edits      uint64      // knotEdits when the data was computed
+	points     [][2]Number // knot coordinates in loop order; read-only
+	dxin, dyin Number      // initial edge direction (mp.w:13546, section 551)
+	minx, miny Number      // bounding box of points
+	maxx, maxy Number
+}
+
+// Invalidate drops the cached data derived from the pen's knots. Call it
+// after assigning the knot fields of a pen that has already been used;
+// edits through Transform.ApplyToKnot and the Knot setters need no call.
+func (pen *Pen) Invalidate() {
+	if pen != nil {
+		pen.derived.Store(nil)
+	}
+}
+
+// data returns the cached derived data of pen, computing it if needed. A
+// replaced Head or an edited knot is detected and recomputed
+// automatically. It returns nil for a nil or empty pen.
+func (pen *Pen) data() *penData {
+	if pen == nil || pen.Head == nil {
+		return nil
+	}
+	edits := knotEdits.Load()
+	if d := pen.derived.Load(); d != nil && d.head == pen.Head && d.edits == edits {
+		return d
+	}
+	d := &penData{head: pen.Head, edits: edits}
+	cur := pen.Head
+	for {
+		d.points = append(d.points, [2]Number{cur.XCoord, cur.YCoord})
+		cur = cur.Next
+		if cur == pen.Head || cur == nil {
+			break
+		}
+	}
+	d.minx, d.miny = math.Inf(1), math.Inf(1)
+	d.maxx, d.maxy = math.Inf(-1), math.Inf(-1)
+	for _, pt := range d.points {
+		d.minx, d.maxx = math.Min(d.minx, pt[0]), math.Max(d.maxx, pt[0])
+		d.miny, d.maxy = math.Min(d.miny, pt[1]), math.Max(d.maxy, pt[1])
+	}
+	if h := pen.Head; h.Next != nil && h.Prev != nil {
+		d.dxin = h.Next.XCoord - h.Prev.XCoord
+		d.dyin = h.Next.YCoord - h.Prev.YCoord
+		if d.dxin == 0 && d.dyin == 0 {
+			d.dxin = h.Prev.YCoord - h.YCoord
+			d.dyin = h.XCoord - h.Prev.XCoord
+		}
+	}
+	pen.derived.Store(d)
+	return d
 }
 
 // NewPenFromPath builds a Pen from an existing path (the path should be a closed
@@ -218,20 +295,12 @@ func convexHull(pts [][2]Number) [][2]Number {
 }
 
 // penPoints returns the raw points of the pen knot loop (ignores controls).
+// The slice is shared with the pen's cache and must not be modified.
 func penPoints(pen *Pen) [][2]Number {
-	if pen == nil || pen.Head == nil {
-		return nil
-	}
-	var pts [][2]Number
-	cur := pen.Head
-	for {
-		pts = append(pts, [2]Number{cur.XCoord, cur.YCoord})
-		cur = cur.Next
-		if cur == pen.Head || cur == nil {
-			break
-		}
+	if d := pen.data(); d != nil {
+		return d.points
 	}
-	return pts
+	return nil
 }
 
 // PenEnvelopeHull builds a convex hull over the pen translated to each knot
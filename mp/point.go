@@ -0,0 +1,132 @@
+package mp
+
+import "math"
+
+// Single-point paths. A path whose knots and control points all coincide
+// (a single knot such as MetaPost's "draw z0", or segments of length zero)
+// is a point path. Queries on it return the point: PointOf, PrecontrolOf
+// and PostcontrolOf give the point for every time, DirectionOf is (0, 0),
+// ArcLength and ArcTime are 0 and PathBBox is the point. Intersections
+// with a point path find the time where the other path passes through the
+// point; the time on the point path itself is always 0. Drawn, a point
+// path is a dot in the shape of the pen (see PenDot).
+
+// pointTolerance is the distance within which a path counts as passing
+// through a point (same as the intersection tolerance).
+const pointTolerance = 0.0001
+
+// IsPoint reports whether p is a point path: it has at least one knot and
+// all knots and control points are at the same place.
+func (p *Path) IsPoint() bool {
+	if p == nil || p.Head == nil {
+		return false
+	}
+	x, y := p.Head.XCoord, p.Head.YCoord
+	k := p.Head
+	for {
+		if k.XCoord != x || k.YCoord != y {
+			return false
+		}
+		next := k.Next
+		if next == nil {
+			return true
+		}
+		if k.RType != KnotEndpoint && next != k && (k.RightX != x || k.RightY != y || next.LeftX != x || next.LeftY != y) {
+			return false
+		}
+		k = next
+		if k == p.Head {
+			return true
+		}
+	}
+}
+
+// PenDot returns the outline of pen centered at (x, y): the shape that
+// drawing the single point (x, y) with pen paints. Elliptical pens give a
+// transformed circle, polygonal pens their polygon. It returns nil for a
+// nil or empty pen.
+//
+// Example:
+//
+//	dot := mp.PenDot(mp.PenCircle(3), 10, 20)
+//	dot.Style.Fill = mp.ColorCSS("black")
+func PenDot(pen *Pen, x, y Number) *Path {
+	if pen == nil || pen.Head == nil {
+		return nil
+	}
+	h := pen.Head
+	if pen.Elliptical {
+		t := Transform{
+			Txx: h.LeftX - h.XCoord, Txy: h.RightX - h.XCoord, Tx: h.XCoord + x,
+			Tyx: h.LeftY - h.YCoord, Tyy: h.RightY - h.YCoord, Ty: h.YCoord + y,
+		}
+		return t.ApplyToPath(FullCircle())
+	}
+	d := pen.data()
+	pts := make([]Point, len(d.points))
+	for i, pt := range d.points {
+		pts[i] = P(pt[0]+x, pt[1]+y)
+	}
+	return straightPath(pts, true)
+}
+
+// timeOfPoint returns the first time t >= start at which p passes within
+// pointTolerance of (x, y), or -1.
+func (p *Path) timeOfPoint(x, y, start Number) Number {
+	if p.IsPoint() {
+		px, py := p.PointOf(0)
+		if start <= 0 && math.Hypot(px-x, py-y) <= pointTolerance {
+			return 0
+		}
+		return -1
+	}
+	n := p.PathLength()
+	k := p.Head
+	for i := 0; i < n; i++ {
+		next := k.Next
+		if Number(i+1) > start {
+			lo := math.Max(start-Number(i), 0)
+			if t := segmentTimeOfPoint(k.XCoord, k.YCoord, k.RightX, k.RightY,
+				next.LeftX, next.LeftY, next.XCoord, next.YCoord, x, y, lo, 0, 1, 30); t >= 0 {
+				return Number(i) + t
+			}
+		}
+		k = next
+	}
+	return -1
+}
+
+// segmentTimeOfPoint returns the first time in [max(lo, t0), t1] at which
+// the cubic passes within pointTolerance of (x, y), or -1, by bisecting
+// the curve down to pieces of size pointTolerance and projecting the point
+// onto the chord of the first piece that comes close enough.
+func segmentTimeOfPoint(p0x, p0y, p1x, p1y, p2x, p2y, p3x, p3y, x, y, lo, t0, t1 Number, depth int) Number {
+	if t1 < lo {
+		return -1
+	}
+	minX, maxX := minOf4(p0x, p1x, p2x, p3x), maxOf4(p0x, p1x, p2x, p3x)
+	minY, maxY := minOf4(p0y, p1y, p2y, p3y), maxOf4(p0y, p1y, p2y, p3y)
+	if x < minX-pointTolerance || x > maxX+pointTolerance || y < minY-pointTolerance || y > maxY+pointTolerance {
+		return -1
+	}
+	if (maxX-minX <= pointTolerance && maxY-minY <= pointTolerance) || depth == 0 {
+		// Project the point onto the chord of the piece. If it lies beyond
+		// the end, the next piece is closer.
+		f := Number(0.5)
+		if dx, dy := p3x-p0x, p3y-p0y; dx != 0 || dy != 0 {
+			f = ((x-p0x)*dx + (y-p0y)*dy) / (dx*dx + dy*dy)
+		}
+		if f > 1 && t1 < 1 {
+			return -1
+		}
+		f = math.Max(0, math.Min(1, f))
+		return math.Max(t0+f*(t1-t0), lo)
+	}
+	mid := (t0 + t1) / 2
+	a0x, a0y, a1x, a1y, a2x, a2y, a3x, a3y,
+		b0x, b0y, b1x, b1y, b2x, b2y, b3x, b3y := splitCubicCoords(p0x, p0y, p1x, p1y, p2x, p2y, p3x, p3y, 0.5)
+	if t := segmentTimeOfPoint(a0x, a0y, a1x, a1y, a2x, a2y, a3x, a3y, x, y, lo, t0, mid, depth-1); t >= 0 {
+		return t
+	}
+	return segmentTimeOfPoint(b0x, b0y, b1x, b1y, b2x, b2y, b3x, b3y, x, y, lo, mid, t1, depth-1)
+}
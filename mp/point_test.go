@@ -0,0 +1,174 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+// pointPath returns the single-knot path (x, y), as built by "draw z0".
+func pointPath(x, y Number) *Path {
+	p := NewPath()
+	p.Append(&Knot{XCoord: x, YCoord: y, LeftX: x, LeftY: y, RightX: x, RightY: y})
+	return p
+}
+
+func TestIsPoint(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Path
+		want bool
+	}{
+		{"nil", nil, false},
+		{"empty", NewPath(), false},
+		{"single knot", pointPath(3, 4), true},
+		{"zero-length segment", straightPath([]Point{P(3, 4), P(3, 4)}, false), true},
+		{"zero-length cycle", straightPath([]Point{P(3, 4), P(3, 4)}, true), true},
+		{"line", straightPath([]Point{P(3, 4), P(5, 4)}, false), false},
+		{"zero-length segment with handles", func() *Path {
+			p := straightPath([]Point{P(3, 4), P(3, 4)}, false)
+			p.Head.RightX = 5
+			return p
+		}(), false},
+	}
+	for _, tc := range tests {
+		if got := tc.p.IsPoint(); got != tc.want {
+			t.Errorf("%s: IsPoint() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPointPathQueries(t *testing.T) {
+	for _, p := range []*Path{pointPath(3, 4), straightPath([]Point{P(3, 4), P(3, 4)}, false)} {
+		for _, tm := range []Number{-1, 0, 0.5, 1, 2} {
+			if x, y := p.PointOf(tm); x != 3 || y != 4 {
+				t.Errorf("point %g of %v = (%g,%g)", tm, p, x, y)
+			}
+			if x, y := p.PrecontrolOf(tm); x != 3 || y != 4 {
+				t.Errorf("precontrol %g = (%g,%g)", tm, x, y)
+			}
+			if x, y := p.PostcontrolOf(tm); x != 3 || y != 4 {
+				t.Errorf("postcontrol %g = (%g,%g)", tm, x, y)
+			}
+			if dx, dy := p.DirectionOf(tm); dx != 0 || dy != 0 {
+				t.Errorf("direction %g = (%g,%g)", tm, dx, dy)
+			}
+		}
+		if l := p.ArcLength(); l != 0 {
+			t.Errorf("arclength = %g", l)
+		}
+		// Like any arc length beyond the end, the time is the path length.
+		if tm := p.ArcTime(1); tm != Number(p.PathLength()) {
+			t.Errorf("arctime = %g", tm)
+		}
+		if x0, y0, x1, y1 := PathBBox(p); x0 != 3 || y0 != 4 || x1 != 3 || y1 != 4 {
+			t.Errorf("bbox = %g %g %g %g", x0, y0, x1, y1)
+		}
+		if !p.Reversed().IsPoint() || !p.Subpath(0, Number(p.PathLength())).IsPoint() {
+			t.Error("reversed and subpath of a point should be points")
+		}
+	}
+}
+
+func TestZeroLengthSegmentQueries(t *testing.T) {
+	p := straightPath([]Point{P(0, 0), P(0, 0), P(10, 0)}, false)
+	if p.IsPoint() {
+		t.Fatal("path with a zero-length segment is not a point")
+	}
+	if l := p.ArcLength(); math.Abs(l-10) > 1e-9 {
+		t.Errorf("arclength = %g, want 10", l)
+	}
+	if x, _ := p.PointOf(p.ArcTime(5)); math.Abs(x-5) > 1e-6 {
+		t.Errorf("point at arc length 5 = %g", x)
+	}
+	if dx, dy := p.DirectionOf(0.5); dx != 0 || dy != 0 {
+		t.Errorf("direction inside the zero-length segment = (%g,%g)", dx, dy)
+	}
+	vertical := straightPath([]Point{P(5, -1), P(5, 1)}, false)
+	if t1, t2 := p.IntersectionTimes(vertical); math.Abs(t1-1.5) > 1e-3 || math.Abs(t2-0.5) > 1e-3 {
+		t.Errorf("intersection times = (%g,%g), want (1.5,0.5)", t1, t2)
+	}
+}
+
+func TestPointPathIntersections(t *testing.T) {
+	dot := pointPath(3, 4)
+	line := straightPath([]Point{P(0, 4), P(10, 4)}, false)
+	if t1, t2 := dot.IntersectionTimes(line); t1 != 0 || math.Abs(t2-0.3) > 1e-9 {
+		t.Errorf("dot/line = (%g,%g), want (0,0.3)", t1, t2)
+	}
+	if t1, t2 := line.IntersectionTimes(dot); math.Abs(t1-0.3) > 1e-9 || t2 != 0 {
+		t.Errorf("line/dot = (%g,%g), want (0.3,0)", t1, t2)
+	}
+	if t1, t2 := dot.IntersectionTimes(pointPath(3, 4)); t1 != 0 || t2 != 0 {
+		t.Errorf("dot/dot = (%g,%g), want (0,0)", t1, t2)
+	}
+	if t1, _ := dot.IntersectionTimes(pointPath(3, 5)); t1 != -1 {
+		t.Errorf("distinct dots intersect at %g", t1)
+	}
+	if t1, _ := dot.IntersectionTimes(straightPath([]Point{P(0, 5), P(10, 5)}, false)); t1 != -1 {
+		t.Errorf("dot off the line intersects at %g", t1)
+	}
+
+	// A curve passing through the dot twice.
+	circle := FullCircle().Scaled(10)
+	x, y := circle.PointOf(2.5)
+	all := circle.AllIntersectionTimes(pointPath(x, y))
+	if len(all) != 1 || math.Abs(all[0][0]-2.5) > 1e-3 {
+		t.Errorf("circle/dot = %v, want [[2.5 0]]", all)
+	}
+	zig := straightPath([]Point{P(0, 0), P(10, 0), P(10, 1), P(0, 1), P(0, 0)}, false)
+	all = zig.AllIntersectionTimes(pointPath(5, 0))
+	if len(all) != 1 || math.Abs(all[0][0]-0.5) > 1e-9 {
+		t.Errorf("zig/dot = %v, want [[0.5 0]]", all)
+	}
+	all = zig.AllIntersectionTimes(pointPath(0, 0))
+	if len(all) != 2 || all[0][0] != 0 || math.Abs(all[1][0]-4) > 1e-9 {
+		t.Errorf("closed polyline/start dot = %v, want times 0 and 4", all)
+	}
+
+	if got := line.CutBefore(dot); got.Head.XCoord != 3 {
+		t.Errorf("cut before dot starts at x=%g, want 3", got.Head.XCoord)
+	}
+	if got := line.CutAfter(dot); got.Head.Prev.XCoord != 3 {
+		t.Errorf("cut after dot ends at x=%g, want 3", got.Head.Prev.XCoord)
+	}
+}
+
+func TestPenDot(t *testing.T) {
+	if PenDot(nil, 0, 0) != nil {
+		t.Error("nil pen should give nil")
+	}
+	dot := PenDot(PenCircle(2), 3, 4)
+	for i := 0; i < 8; i++ {
+		x, y := dot.PointOf(Number(i) / 2)
+		if r := math.Hypot(x-3, y-4); math.Abs(r-1) > 1e-3 {
+			t.Errorf("pencircle dot radius %g at %d, want 1", r, i)
+		}
+	}
+	ellipse := PenDot(Scaled(2).Then(XScaled(2)).ApplyToPen(PenCircle(1)), 0, 0)
+	if x0, y0, x1, y1 := PathBBox(ellipse); math.Abs(x1-x0-4) > 1e-6 || math.Abs(y1-y0-2) > 1e-6 {
+		t.Errorf("transformed pen dot bbox %g×%g, want 4×2", x1-x0, y1-y0)
+	}
+	square := PenDot(PenSquare(2), 3, 4)
+	if x0, y0, x1, y1 := PathBBox(square); x0 != 2 || y0 != 3 || x1 != 4 || y1 != 5 {
+		t.Errorf("square dot bbox %g %g %g %g", x0, y0, x1, y1)
+	}
+	if square.Head.LType == KnotEndpoint {
+		t.Error("pen dot should be a cycle")
+	}
+}
+
+func TestPointPathEnvelope(t *testing.T) {
+	p := pointPath(3, 4)
+	p.Style.Pen = PenSquare(2)
+	e := NewEngine()
+	e.AddPath(p)
+	if err := e.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Envelope == nil {
+		t.Fatal("polygonal pen should give an envelope for a dot")
+	}
+	if x0, y0, x1, y1 := PathBBox(p.Envelope); x0 != 2 || y0 != 3 || x1 != 4 || y1 != 5 {
+		t.Errorf("envelope bbox %g %g %g %g, want the pen square around the dot", x0, y0, x1, y1)
+	}
+}
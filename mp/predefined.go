@@ -66,6 +66,7 @@ func FullCircle() *Path {
 	e.AddPath(p)
 	e.Solve()
 
+	p.ellipse = &ellipseInfo{ux: r, vy: r}
 	return p
 }
 
@@ -4,11 +4,15 @@ package mp
 // It fills deltaX/deltaY/delta and psi for a run from start up to (but not including) stop,
 // stopping early if end_cycle is hit. Returns the count n (number of edges processed).
 func (e *Engine) computePsiTheta(start, stop *Knot) int {
-	// Ensure we have room for at least n+1 slots (n edges plus closing slot).
-	e.ensurePathCapacity(e.pathSize + 1)
+	// mp.c starts with n = path_size as a "stop not reached yet" marker;
+	// here found says so and the buffers grow as needed. (Growing them up
+	// front on every call made an engine that solves many paths reallocate
+	// without bound.)
+	e.ensurePathCapacity(1)
 	k := 0
 	s := start
-	n := e.pathSize
+	n := 0
+	found := false
 	for {
 		t := s.Next
 		e.deltaX[k] = t.XCoord - s.XCoord
@@ -32,9 +36,10 @@ func (e *Engine) computePsiTheta(start, stop *Knot) int {
 		s = t
 		if s == stop {
 			n = k
+			found = true
 		}
 		// stop when k>=n && left_type(s)!=end_cycle
-		if k >= n && s.LType != KnotEndCycle {
+		if found && k >= n && s.LType != KnotEndCycle {
 			break
 		}
 		if k == len(e.deltaX) {
@@ -0,0 +1,62 @@
+package mp
+
+import "math"
+
+// Quadratic Bézier segments, as found in TrueType glyphs and SVG "Q"
+// commands, are stored as their degree-elevated cubics with the start
+// knot's Quadratic flag set. The elevation is exact, so every path
+// operation works on them unchanged, while renderers and exporters can
+// recover the single control point and write the shorter quadratic form.
+
+// quadraticTolerance is how far the two cubic control points may disagree
+// about the quadratic control point before a tagged segment is treated as
+// a plain cubic (e.g. after its control points were edited by hand).
+const quadraticTolerance = 1e-7
+
+// SetQuadraticControl makes the segment from k to k.Next the quadratic
+// Bézier curve with control point (x, y): the cubic control points are
+// set to the points 2/3 of the way from the ends to (x, y) and the segment
+// is marked Quadratic. It does nothing if k has no successor.
+func (k *Knot) SetQuadraticControl(x, y Number) {
+	next := k.Next
+	if next == nil {
+		return
+	}
+	knotEdits.Add(1)
+	k.RightX = k.XCoord + 2.0/3.0*(x-k.XCoord)
+	k.RightY = k.YCoord + 2.0/3.0*(y-k.YCoord)
+	next.LeftX = next.XCoord + 2.0/3.0*(x-next.XCoord)
+	next.LeftY = next.YCoord + 2.0/3.0*(y-next.YCoord)
+	k.RType, next.LType = KnotExplicit, KnotExplicit
+	k.Quadratic = true
+}
+
+// QuadraticControl returns the control point of the quadratic segment
+// leaving k. ok is false if the segment is not marked Quadratic or its
+// cubic control points no longer describe a quadratic curve.
+func (k *Knot) QuadraticControl() (x, y Number, ok bool) {
+	next := k.Next
+	if !k.Quadratic || next == nil || k.RType == KnotEndpoint {
+		return 0, 0, false
+	}
+	x, y = k.XCoord+1.5*(k.RightX-k.XCoord), k.YCoord+1.5*(k.RightY-k.YCoord)
+	x2, y2 := next.XCoord+1.5*(next.LeftX-next.XCoord), next.YCoord+1.5*(next.LeftY-next.YCoord)
+	scale := math.Max(1, math.Max(math.Abs(x), math.Abs(y)))
+	if math.Abs(x-x2) > quadraticTolerance*scale || math.Abs(y-y2) > quadraticTolerance*scale {
+		return 0, 0, false
+	}
+	return (x + x2) / 2, (y + y2) / 2, true
+}
+
+// AppendQuadratic appends a knot at (x, y) joined to the current last knot
+// by the quadratic Bézier curve with control point (cx, cy), like SVG's
+// "Q cx cy x y". The path must not be empty.
+func (p *Path) AppendQuadratic(cx, cy, x, y Number) {
+	if p.Head == nil {
+		return
+	}
+	last := p.Head.Prev
+	k := &Knot{XCoord: x, YCoord: y, RightX: x, RightY: y, LType: KnotExplicit, RType: KnotExplicit}
+	p.Append(k)
+	last.SetQuadraticControl(cx, cy)
+}
@@ -0,0 +1,67 @@
+package mp
+
+import "testing"
+
+// quadraticPath returns (0,0)--(10,0) followed by the quadratic curve to
+// (20,10) with control point (20,0).
+func quadraticPath() *Path {
+	p := NewPath()
+	p.Append(&Knot{LType: KnotEndpoint, RType: KnotExplicit, RightX: 10.0 / 3})
+	p.Append(&Knot{XCoord: 10, LeftX: 20.0 / 3, LType: KnotExplicit, RType: KnotExplicit})
+	p.AppendQuadratic(20, 0, 20, 10)
+	p.Head.Prev.RType = KnotEndpoint
+	return p
+}
+
+func TestQuadraticSegment(t *testing.T) {
+	p := quadraticPath()
+	k := p.Head.Next
+	if !k.Quadratic || p.Head.Quadratic {
+		t.Fatalf("quadratic flags = %v, %v; want only the second segment", p.Head.Quadratic, k.Quadratic)
+	}
+	// The stored cubic is the quadratic: B(t) = (1-t)²P0 + 2t(1-t)C + t²P2.
+	for _, tt := range []Number{0.25, 0.5, 0.8} {
+		x, y := p.PointOf(1 + tt)
+		wx := (1-tt)*(1-tt)*10 + 2*tt*(1-tt)*20 + tt*tt*20
+		wy := tt * tt * 10
+		if !approxEqual(x, wx, 1e-12) || !approxEqual(y, wy, 1e-12) {
+			t.Errorf("point %g = (%g, %g), want (%g, %g)", 1+tt, x, y, wx, wy)
+		}
+	}
+	if x, y, ok := k.QuadraticControl(); !ok || !approxEqual(x, 20, 1e-12) || !approxEqual(y, 0, 1e-12) {
+		t.Errorf("QuadraticControl = (%g, %g, %v), want (20, 0, true)", x, y, ok)
+	}
+	if _, _, ok := p.Head.QuadraticControl(); ok {
+		t.Errorf("cubic segment reports a quadratic control point")
+	}
+	k.RightY = 3 // no longer a quadratic
+	if _, _, ok := k.QuadraticControl(); ok {
+		t.Errorf("edited segment still reports a quadratic control point")
+	}
+}
+
+func TestQuadraticSegmentOperations(t *testing.T) {
+	p := quadraticPath()
+
+	rev := p.Reversed()
+	if !rev.Head.Quadratic || rev.Head.Next.Quadratic {
+		t.Errorf("reversed flags = %v, %v; want the first segment quadratic", rev.Head.Quadratic, rev.Head.Next.Quadratic)
+	}
+	if x, y, ok := rev.Head.QuadraticControl(); !ok || !approxEqual(x, 20, 1e-9) || !approxEqual(y, 0, 1e-9) {
+		t.Errorf("reversed control = (%g, %g, %v), want (20, 0, true)", x, y, ok)
+	}
+
+	sub := p.Subpath(0.5, 1.5)
+	if sub.Head.Quadratic || !sub.Head.Next.Quadratic {
+		t.Errorf("subpath flags = %v, %v; want the second segment quadratic", sub.Head.Quadratic, sub.Head.Next.Quadratic)
+	}
+	if _, _, ok := sub.Head.Next.QuadraticControl(); !ok {
+		t.Errorf("piece of a quadratic is not quadratic")
+	}
+
+	moved := Rotated(30).Then(Shifted(5, 5)).ApplyToPath(p)
+	cx, cy := Rotated(30).Then(Shifted(5, 5)).ApplyToPoint(20, 0)
+	if x, y, ok := moved.Head.Next.QuadraticControl(); !ok || !approxEqual(x, cx, 1e-9) || !approxEqual(y, cy, 1e-9) {
+		t.Errorf("transformed control = (%g, %g, %v), want (%g, %g, true)", x, y, ok, cx, cy)
+	}
+}
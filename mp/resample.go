@@ -0,0 +1,121 @@
+package mp
+
+import "math"
+
+// Resample returns a path with exactly n knots approximating p. The knots
+// are spaced evenly by arc length (for cycles, n knots around the loop
+// starting at knot 0; for open paths, n knots including both ends) and
+// each segment is re-fitted by a cubic that leaves and enters its knots in
+// the original directions and passes through the original curve at the
+// arc-length midpoint. The error shrinks quickly with n; corners of p that
+// do not fall on a new knot are rounded. Resample is useful to give two
+// paths the same number of knots for morphing, or to reduce sampled data.
+//
+// It returns nil if p is empty or n is less than 2. The style is copied.
+//
+// Example:
+//
+//	a := mp.Resample(circle, 16)
+//	b := mp.Resample(square, 16)  // now a and b can be interpolated knot by knot
+func Resample(p *Path, n int) *Path {
+	if p == nil || p.Head == nil || n < 2 || p.PathLength() == 0 {
+		return nil
+	}
+	cycle := p.Head.LType != KnotEndpoint
+	total := p.ArcLength()
+	segs := n - 1
+	if cycle {
+		segs = n
+	}
+	// Arc-length times of the new knots, plus the segment midpoints.
+	times := make([]Number, 2*segs+1)
+	for i := range times {
+		times[i] = p.ArcTime(total * Number(i) / Number(2*segs))
+	}
+	if !cycle {
+		times[len(times)-1] = Number(p.PathLength())
+	}
+
+	q := NewPath()
+	q.Style = p.Style
+	for i := 0; i < n; i++ {
+		x, y := p.PointOf(times[2*i])
+		k := NewKnot()
+		k.XCoord, k.YCoord = x, y
+		k.LType, k.RType = KnotExplicit, KnotExplicit
+		q.Append(k)
+	}
+	k := q.Head
+	for i := 0; i < segs; i++ {
+		next := k.Next
+		t0, tm, t1 := times[2*i], times[2*i+1], times[2*i+2]
+		if cycle && i == segs-1 {
+			t1 = Number(p.PathLength())
+		}
+		d0x, d0y := outgoingDirection(p, t0)
+		d1x, d1y := incomingDirection(p, t1)
+		mx, my := p.PointOf(tm)
+		a, b := fitHandles(k.XCoord, k.YCoord, next.XCoord, next.YCoord, d0x, d0y, d1x, d1y, mx, my)
+		k.RightX, k.RightY = k.XCoord+a*d0x, k.YCoord+a*d0y
+		next.LeftX, next.LeftY = next.XCoord-b*d1x, next.YCoord-b*d1y
+		k = next
+	}
+	if !cycle {
+		q.Head.LType = KnotEndpoint
+		q.Head.LeftX, q.Head.LeftY = q.Head.XCoord, q.Head.YCoord
+		last := q.Head.Prev
+		last.RType = KnotEndpoint
+		last.RightX, last.RightY = last.XCoord, last.YCoord
+	}
+	return q
+}
+
+// outgoingDirection returns the unit direction leaving time t of p.
+func outgoingDirection(p *Path, t Number) (Number, Number) {
+	x, y := p.PointOf(t)
+	cx, cy := p.PostcontrolOf(t)
+	if dx, dy, ok := unitVector(cx-x, cy-y); ok {
+		return dx, dy
+	}
+	dx, dy, _ := unitVector(p.DirectionOf(t))
+	return dx, dy
+}
+
+// incomingDirection returns the unit direction arriving at time t of p.
+func incomingDirection(p *Path, t Number) (Number, Number) {
+	x, y := p.PointOf(t)
+	cx, cy := p.PrecontrolOf(t)
+	if dx, dy, ok := unitVector(x-cx, y-cy); ok {
+		return dx, dy
+	}
+	dx, dy, _ := unitVector(p.DirectionOf(t))
+	return dx, dy
+}
+
+func unitVector(dx, dy Number) (Number, Number, bool) {
+	l := math.Hypot(dx, dy)
+	if l < 1e-12 {
+		return 0, 0, false
+	}
+	return dx / l, dy / l, true
+}
+
+// fitHandles returns the handle lengths a and b of the cubic from p0 in
+// direction d0 to p1 arriving in direction d1 whose midpoint is m. If that
+// has no sensible solution (parallel directions, negative lengths) both
+// handles are a third of the chord.
+func fitHandles(p0x, p0y, p1x, p1y, d0x, d0y, d1x, d1y, mx, my Number) (a, b Number) {
+	chord := math.Hypot(p1x-p0x, p1y-p0y)
+	// B(1/2) = (p0+p1)/2 + 3/8 (a d0 - b d1) = m
+	rx := (mx - (p0x+p1x)/2) * 8 / 3
+	ry := (my - (p0y+p1y)/2) * 8 / 3
+	det := d0x*(-d1y) - d0y*(-d1x)
+	if math.Abs(det) > 1e-6 {
+		a = (rx*(-d1y) - ry*(-d1x)) / det
+		b = (d0x*ry - d0y*rx) / det
+		if a > 0 && b > 0 && a < 2*chord && b < 2*chord {
+			return a, b
+		}
+	}
+	return chord / 3, chord / 3
+}
@@ -0,0 +1,56 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func knotCount(p *Path) int {
+	n := 0
+	for k := p.Head; ; k = k.Next {
+		n++
+		if k.Next == p.Head || k.RType == KnotEndpoint {
+			return n
+		}
+	}
+}
+
+func TestResampleCircle(t *testing.T) {
+	circle := Scaled(100).ApplyToPath(FullCircle())
+	for _, n := range []int{4, 7, 16} {
+		q := Resample(circle, n)
+		if got := knotCount(q); got != n {
+			t.Fatalf("n=%d: got %d knots", n, got)
+		}
+		if q.Head.LType == KnotEndpoint {
+			t.Fatalf("n=%d: resampled circle should be a cycle", n)
+		}
+		for i := 0; i < 200; i++ {
+			x, y := q.PointOf(Number(n) * Number(i) / 200)
+			if d := math.Hypot(x, y); math.Abs(d-50) > 0.05 {
+				t.Fatalf("n=%d: point at radius %g", n, d)
+			}
+		}
+	}
+}
+
+func TestResampleOpen(t *testing.T) {
+	p := straightPath([]Point{{0, 0}, {30, 0}, {100, 0}}, false)
+	q := Resample(p, 5)
+	if got := knotCount(q); got != 5 {
+		t.Fatalf("got %d knots", got)
+	}
+	k := q.Head
+	for i := 0; i < 5; i++ {
+		if math.Abs(k.XCoord-Number(25*i)) > 1e-3 || k.YCoord != 0 {
+			t.Errorf("knot %d at (%g,%g), want (%d,0)", i, k.XCoord, k.YCoord, 25*i)
+		}
+		if i < 4 && (k.RightY != 0 || k.Next.LeftY != 0) {
+			t.Errorf("segment %d is not straight", i)
+		}
+		k = k.Next
+	}
+	if Resample(p, 1) != nil {
+		t.Error("Resample with n < 2 should return nil")
+	}
+}
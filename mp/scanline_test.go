@@ -0,0 +1,85 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScanlinerSpans(t *testing.T) {
+	circle := Scaled(20).ApplyToPath(FullCircle())
+	s := NewScanliner(circle)
+	if lo, hi := s.Bounds(); math.Abs(lo+10) > 1e-9 || math.Abs(hi-10) > 1e-9 {
+		t.Errorf("bounds = [%g,%g], want [-10,10]", lo, hi)
+	}
+	for _, y := range []Number{0, 6, -8, 9.9} {
+		sp := s.Spans(y)
+		w := math.Sqrt(100 - y*y)
+		if len(sp) != 1 || math.Abs(sp[0].X0+w) > 0.01 || math.Abs(sp[0].X1-w) > 0.01 {
+			t.Errorf("spans at %g = %v, want [%g,%g]", y, sp, -w, w)
+		}
+	}
+	if sp := s.Spans(10); len(sp) != 0 {
+		t.Errorf("spans above circle = %v", sp)
+	}
+
+	// A hole wound the other way.
+	hole := Scaled(10).ApplyToPath(FullCircle()).Reversed()
+	if sp := NewScanliner(circle, hole).Spans(0); len(sp) != 2 || math.Abs(sp[0].X1+5) > 1e-9 || math.Abs(sp[1].X0-5) > 1e-9 {
+		t.Errorf("annulus spans = %v, want two runs", sp)
+	}
+
+	// Overlapping and touching squares merge under the nonzero rule.
+	a := Scaled(10).ApplyToPath(UnitSquare())
+	b := Shifted(5, 0).ApplyToPath(a)
+	c := Shifted(15, 0).ApplyToPath(a)
+	if sp := NewScanliner(a, b, c).Spans(5); len(sp) != 1 || sp[0] != (Span{0, 25}) {
+		t.Errorf("merged spans = %v, want [{0 25}]", sp)
+	}
+	// Bottom edge in, top edge out.
+	if sp := NewScanliner(a).Spans(0); len(sp) != 1 {
+		t.Errorf("spans on bottom edge = %v", sp)
+	}
+	if sp := NewScanliner(a).Spans(10); len(sp) != 0 {
+		t.Errorf("spans on top edge = %v", sp)
+	}
+
+	// Open paths are closed by a straight line.
+	open := straightPath([]Point{P(0, 0), P(10, 0), P(10, 10)}, false)
+	if sp := NewScanliner(open).Spans(5); len(sp) != 1 || math.Abs(sp[0].X0-5) > 1e-9 || sp[0].X1 != 10 {
+		t.Errorf("open path spans = %v, want [{5 10}]", sp)
+	}
+}
+
+func TestHatch(t *testing.T) {
+	sq := Scaled(10).ApplyToPath(UnitSquare())
+	h := Hatch(sq, 0, 2)
+	if len(h.Parts) != 5 {
+		t.Fatalf("got %d hatch lines, want 5", len(h.Parts))
+	}
+	for i, l := range h.Parts {
+		x0, y0 := l.PointOf(0)
+		x1, y1 := l.PointOf(1)
+		if x0 != 0 || x1 != 10 || y0 != Number(2*i) || y1 != y0 {
+			t.Errorf("line %d from (%g,%g) to (%g,%g)", i, x0, y0, x1, y1)
+		}
+	}
+
+	circle := Scaled(20).ApplyToPath(FullCircle())
+	h = Hatch(circle, 45, 1.5)
+	if len(h.Parts) != 13 {
+		t.Errorf("got %d hatch lines in circle, want 13", len(h.Parts))
+	}
+	for i, l := range h.Parts {
+		x0, y0 := l.PointOf(0)
+		x1, y1 := l.PointOf(1)
+		if math.Abs(math.Hypot(x0, y0)-10) > 0.01 || math.Abs(math.Hypot(x1, y1)-10) > 0.01 {
+			t.Errorf("line %d does not end on the circle", i)
+		}
+		if math.Abs((y1-y0)-(x1-x0)) > 1e-9 {
+			t.Errorf("line %d is not at 45°", i)
+		}
+	}
+	if len(Hatch(circle, 0, 0).Parts) != 0 {
+		t.Errorf("hatch with zero spacing")
+	}
+}
@@ -0,0 +1,40 @@
+package mp
+
+import "math"
+
+// ShadowTransform returns the projection used for fake cast shadows: points
+// on the ground line y = baseY stay in place and every point at height h
+// above it moves to the point at distance h*lengthFactor from its foot in
+// direction angleDeg. An angle of 90 and a factor of 1 is the identity;
+// small angles and factors lay the shadow flat on the ground.
+func ShadowTransform(baseY, angleDeg, lengthFactor Number) Transform {
+	rad := angleDeg * math.Pi / 180
+	sx := lengthFactor * math.Cos(rad)
+	sy := lengthFactor * math.Sin(rad)
+	return Transform{
+		Txx: 1, Txy: sx, Tx: -baseY * sx,
+		Tyx: 0, Tyy: sy, Ty: baseY - baseY*sy,
+	}
+}
+
+// ShadowOf returns the cast shadow of p standing on the lowest point of its
+// bounding box: the sheared and flattened copy of ShadowTransform, as used
+// in MetaPost figures for fake 3D shadows under shapes and text. The style
+// is copied from p; callers usually replace it by a gray fill.
+//
+// Example:
+//
+//	s := mp.ShadowOf(box, 30, 0.5)  // falls to the upper right, half as long
+//	s.Style = mp.Style{Fill: mp.ColorGray(0.7), Stroke: mp.ColorCSS("none")}
+func ShadowOf(p *Path, angleDeg, lengthFactor Number) *Path {
+	if p == nil || p.Head == nil {
+		return nil
+	}
+	_, minY, _, _ := PathBBox(p)
+	t := ShadowTransform(minY, angleDeg, lengthFactor)
+	q := t.ApplyToPath(p)
+	if p.Envelope != nil {
+		q.Envelope = t.ApplyToPath(p.Envelope)
+	}
+	return q
+}
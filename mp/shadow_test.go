@@ -0,0 +1,40 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShadowOf(t *testing.T) {
+	// A 10x20 box standing on y=5.
+	box := XScaled(10).Then(YScaled(20)).Then(Shifted(0, 5)).ApplyToPath(UnitSquare())
+	s := ShadowOf(box, 0, 0.5)
+	minX, minY, maxX, maxY := PathBBox(s)
+	// Flat on the ground, the top edge thrown 10 units to the right.
+	if math.Abs(minY-5) > 1e-9 || math.Abs(maxY-5) > 1e-9 {
+		t.Errorf("shadow y range = %g..%g, want 5..5", minY, maxY)
+	}
+	if math.Abs(minX) > 1e-9 || math.Abs(maxX-20) > 1e-9 {
+		t.Errorf("shadow x range = %g..%g, want 0..20", minX, maxX)
+	}
+
+	if s := ShadowOf(box, 90, 1); !samePoints(s, box) {
+		t.Error("angle 90 and factor 1 should leave the path unchanged")
+	}
+	if ShadowOf(nil, 30, 1) != nil {
+		t.Error("nil path should have no shadow")
+	}
+}
+
+func samePoints(a, b *Path) bool {
+	ka, kb := a.Head, b.Head
+	for {
+		if math.Abs(ka.XCoord-kb.XCoord) > 1e-9 || math.Abs(ka.YCoord-kb.YCoord) > 1e-9 {
+			return false
+		}
+		ka, kb = ka.Next, kb.Next
+		if ka == a.Head {
+			return kb == b.Head
+		}
+	}
+}
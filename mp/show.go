@@ -0,0 +1,142 @@
+package mp
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Show returns p in the format of MetaPost's "show" and of its tracing
+// output (mp_pr_path, mp.w:5445ff), so a path specification can be diffed
+// against the MetaPost program it was ported from. Unlike String, it
+// prints the solver input of unsolved knots as MetaPost reads it: given
+// directions as {x,y} unit vectors, curls as {curl c}, and tensions other
+// than 1 as "..tension a and b" (with "atleast" for negative tensions).
+// Solved segments print their control points. Inconsistent knots are
+// flagged as MetaPost does, e.g. "{open?}" or "..control?"; see Validate
+// for a check that gives errors instead.
+//
+// Example:
+//
+//	pb := draw.NewPath().MoveTo(mp.P(0, 0)).WithDirection(90).
+//		CurveTo(mp.P(10, 0)).WithTension(2).CurveTo(mp.P(20, 5))
+//	fmt.Println(pb.BuildPath().Show())
+//	// (0,0){0,1}
+//	//  ..(10,0)..tension 2
+//	//  ..{curl 1}(20,5)
+func (p *Path) Show() string {
+	if p == nil || p.Head == nil {
+		return ""
+	}
+	var b strings.Builder
+	h := p.Head
+	k := h
+	for {
+		q := k.Next
+		if q == nil {
+			b.WriteString("???") // a broken path
+			return b.String()
+		}
+		showPair(&b, k.XCoord, k.YCoord)
+		switch k.RType {
+		case KnotEndpoint:
+			if k.LType == KnotOpen {
+				b.WriteString("{open?}")
+			}
+			if q.LType != KnotEndpoint || q != h {
+				b.WriteString("???")
+			}
+			return b.String()
+		case KnotExplicit:
+			b.WriteString("..controls ")
+			showPair(&b, k.RightX, k.RightY)
+			b.WriteString(" and ")
+			if q.LType != KnotExplicit {
+				b.WriteString("??")
+			} else {
+				showPair(&b, q.LeftX, q.LeftY)
+			}
+		case KnotOpen:
+			if k.LType != KnotExplicit && k.LType != KnotOpen {
+				b.WriteString("{open?}")
+			}
+		case KnotCurl, KnotGiven:
+			if k.LType == KnotOpen {
+				b.WriteString("??")
+			}
+			showBoundary(&b, k.RType, k.RightX)
+		default:
+			b.WriteString("???")
+		}
+		if k.RType != KnotExplicit {
+			if q.LType <= KnotExplicit {
+				b.WriteString("..control?")
+			} else if rt, lt := k.RightY, q.LeftY; rt != unity || lt != unity {
+				b.WriteString("..tension ")
+				showTension(&b, rt)
+				if rt != lt {
+					b.WriteString(" and ")
+					showTension(&b, lt)
+				}
+			}
+		}
+		k = q
+		if k != h || h.LType != KnotEndpoint {
+			b.WriteString("\n ..")
+			if k.LType == KnotGiven || k.LType == KnotCurl {
+				showBoundary(&b, k.LType, k.LeftX)
+			}
+		}
+		if k == h {
+			break
+		}
+	}
+	if h.LType != KnotEndpoint {
+		b.WriteString("cycle")
+	}
+	return b.String()
+}
+
+// showPair writes (x,y) as MetaPost prints pairs.
+func showPair(b *strings.Builder, x, y Number) {
+	b.WriteByte('(')
+	b.WriteString(showNumber(x))
+	b.WriteByte(',')
+	b.WriteString(showNumber(y))
+	b.WriteByte(')')
+}
+
+// showBoundary writes the given direction (v in scaled degrees) or curl v
+// of a knot side of type t.
+func showBoundary(b *strings.Builder, t KnotType, v Number) {
+	if t == KnotCurl {
+		b.WriteString("{curl ")
+		b.WriteString(showNumber(v))
+		b.WriteByte('}')
+		return
+	}
+	sin, cos := math.Sincos(v / angleMultiplier * math.Pi / 180)
+	b.WriteByte('{')
+	b.WriteString(showNumber(cos))
+	b.WriteByte(',')
+	b.WriteString(showNumber(sin))
+	b.WriteByte('}')
+}
+
+// showTension writes a tension, negative ones as "atleast".
+func showTension(b *strings.Builder, t Number) {
+	if t < 0 {
+		b.WriteString("atleast")
+	}
+	b.WriteString(showNumber(math.Abs(t)))
+}
+
+// showNumber formats v like MetaPost's print_number: at most five
+// decimals, without trailing zeros.
+func showNumber(v Number) string {
+	v = math.Round(v*1e5) / 1e5
+	if v == 0 {
+		v = 0 // no "-0"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,37 @@
+package mp
+
+import "testing"
+
+func TestPathShow(t *testing.T) {
+	a, b, c := NewKnotAt(0, 0), NewKnotAt(10, 0), NewKnotAt(20, 5)
+	a.LType, c.RType = KnotEndpoint, KnotEndpoint
+	a.SetDirectionOut(90)
+	c.SetCurlIn(1)
+	b.RightY, c.LeftY = 2, 2 // ..tension 2..
+	p := NewPath()
+	p.Append(a)
+	p.Append(b)
+	p.Append(c)
+	want := "(0,0){0,1}\n ..(10,0)..tension 2\n ..{curl 1}(20,5)"
+	if got := p.Show(); got != want {
+		t.Errorf("unsolved:\n%s\nwant\n%s", got, want)
+	}
+
+	b.SetTensionAtLeast(1, 1.5)
+	want = "(0,0){0,1}..tension 1 and atleast1\n ..(10,0)..tension atleast1.5 and 2\n ..{curl 1}(20,5)"
+	if got := p.Show(); got != want {
+		t.Errorf("atleast:\n%s\nwant\n%s", got, want)
+	}
+
+	sq := straightPath([]Point{P(0, 0), P(10, 0), P(10, 10), P(0, 10)}, true)
+	want = "(0,0)..controls (3.33333,0) and (6.66667,0)\n ..(10,0)..controls (10,3.33333) and (10,6.66667)\n" +
+		" ..(10,10)..controls (6.66667,10) and (3.33333,10)\n ..(0,10)..controls (0,6.66667) and (0,3.33333)\n ..cycle"
+	if got := sq.Show(); got != want {
+		t.Errorf("solved:\n%s\nwant\n%s", got, want)
+	}
+
+	b.LType = KnotExplicit // an explicit side facing an unsolved one
+	if got := p.Show(); got != "(0,0){0,1}..control?\n ..(10,0)..tension atleast1.5 and 2\n ..{curl 1}(20,5)" {
+		t.Errorf("inconsistent knot: %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package mp
 
 import (
+	"context"
 	"errors"
 )
 
@@ -21,6 +22,13 @@ type Engine struct {
 	sf, cf Number
 	// epsilon-like small value; align with mpmathdouble's epsilon_t use.
 	epsilon Number
+	// envelopeMaxIter limits the envelope main loop (0: one pass over the
+	// knots), see MakeEnvelopeLimit.
+	envelopeMaxIter int
+	// progress is called after each solved path, see SetProgress.
+	progress ProgressFunc
+	// invalid is the first error reported by Validate in AddPath.
+	invalid error
 }
 
 func NewEngine() *Engine {
@@ -33,26 +41,88 @@ func NewEngine() *Engine {
 	return e
 }
 
-// AddPath appends a path to the engine queue.
+// SetEnvelopeMaxIterations limits the iterations of the envelope main loop
+// for paths drawn with polygonal pens (see MakeEnvelopeLimit). Solve
+// returns an *EnvelopeError if an envelope does not converge within the
+// limit. n <= 0 restores the default of one pass over the path's knots.
+func (e *Engine) SetEnvelopeMaxIterations(n int) *Engine {
+	e.envelopeMaxIter = n
+	return e
+}
+
+// SetProgress sets a function called by Solve after each path with the
+// number of paths done and the total, so front-ends can show progress for
+// pictures with many or expensive (envelope) paths. nil disables it.
+func (e *Engine) SetProgress(f ProgressFunc) *Engine {
+	e.progress = f
+	return e
+}
+
+// AddPath appends a path to the engine queue. The path is checked with
+// Path.Validate; if it has a NaN or infinite value, Solve returns the
+// *KnotError without solving any path.
 func (e *Engine) AddPath(p *Path) {
+	if err := p.Validate(); err != nil && e.invalid == nil {
+		var ke *KnotError
+		if errors.As(err, &ke) {
+			ke.Path = len(e.paths)
+		}
+		e.invalid = err
+	}
 	e.paths = append(e.paths, p)
 }
 
 // Solve runs the curve-solving and envelope pipeline on all paths.
 func (e *Engine) Solve() error {
+	return e.SolveContext(context.Background())
+}
+
+// SolveContext is Solve with cancellation: ctx is checked before each path
+// and during envelope construction, and ctx.Err() is returned once it is
+// done. Paths solved before the cancellation keep their results.
+//
+// If a path could not be solved because a computed control point is not
+// finite, the error is a *KnotError naming the path and knot.
+func (e *Engine) SolveContext(ctx context.Context) error {
 	if len(e.paths) == 0 {
 		return errors.New("no paths loaded")
 	}
-	for _, p := range e.paths {
-		if p == nil || p.Head == nil {
-			continue
+	if e.invalid != nil {
+		return e.invalid
+	}
+	for i, p := range e.paths {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		if err := e.solvePath(p); err != nil {
+		if err := e.solveQueued(ctx, p, i); err != nil {
 			return err
 		}
-		// After solving controls, compute a pen envelope for non-elliptical pens,
-		// mirroring the offset/envelope phase (mp_apply_offset/mp_offset_prep, mp.c:13364ff, 15800ff).
-		e.applyOffset(p)
+		if e.progress != nil {
+			e.progress(i+1, len(e.paths))
+		}
 	}
 	return nil
 }
+
+// solveQueued solves the path with index i of the queue: its control
+// points and, for polygonal pens, its envelope.
+func (e *Engine) solveQueued(ctx context.Context, p *Path, i int) error {
+	if p == nil || p.Head == nil {
+		return nil
+	}
+	if err := e.solvePath(p); err != nil {
+		var ke *KnotError
+		if errors.As(err, &ke) {
+			ke.Path = i
+		}
+		return err
+	}
+	// After solving controls, compute a pen envelope for non-elliptical pens,
+	// mirroring the offset/envelope phase (mp_apply_offset/mp_offset_prep, mp.c:13364ff, 15800ff).
+	return e.applyOffset(ctx, p)
+}
+
+// ProgressFunc receives progress reports of long operations: done of total
+// units of work (paths, loop iterations or elements, depending on the
+// caller) are finished. It is called from the goroutine doing the work.
+type ProgressFunc func(done, total int)
@@ -1,6 +1,7 @@
 package mp
 
 import (
+	"context"
 	"fmt"
 	"math"
 )
@@ -17,12 +18,13 @@ func (e *Engine) solvePath(p *Path) error {
 }
 
 // applyOffset mirrors the offset/envelope step for non-elliptical pens by
-// computing a swept outline (mp_offset_prep/mp_apply_offset, mp.c:13364ff, 15800ff).
-// For now this uses OffsetOutline (convex hull over translated pen) as an
-// approximation and stores it on the path for later backends.
-func (e *Engine) applyOffset(p *Path) {
+// computing a swept outline (mp_offset_prep/mp_apply_offset, mp.c:13364ff, 15800ff)
+// with MakeEnvelopeLimit and stores it on the path for later backends. An
+// envelope that does not converge is reported instead of storing a partial
+// outline.
+func (e *Engine) applyOffset(ctx context.Context, p *Path) error {
 	if p == nil || p.Style.Pen == nil {
-		return
+		return nil
 	}
 	pen := p.Style.Pen
 	// Defaults for linejoin/linecap as in mp_set_up_envelope (mp.c:29290ff).
@@ -31,9 +33,13 @@ func (e *Engine) applyOffset(p *Path) {
 	// Note: 0 is a valid value (miter/butt), so don't override it.
 	if pen.Elliptical {
 		// Elliptical pens are handled via stroke width in the backend.
-		return
+		return nil
+	}
+	env, err := MakeEnvelopeContext(ctx, p, pen, e.envelopeMaxIter, nil)
+	if err != nil {
+		return err
 	}
-	if env := OffsetOutline(p, pen); env != nil && env.Head != nil {
+	if env != nil && env.Head != nil {
 		// Fill the envelope with the stroke color; stroke is unused for the envelope.
 		env.Style = p.Style
 		env.Style.Fill = p.Style.Stroke
@@ -44,6 +50,7 @@ func (e *Engine) applyOffset(p *Path) {
 		env.Envelope = nil
 		p.Envelope = env
 	}
+	return nil
 }
 
 // makeChoices mirrors mp_make_choices (mp.c ~7321ff / mp.w ~7788ff).
@@ -142,6 +149,11 @@ func (e *Engine) makeChoices(p *Path) error {
 			}
 			n := e.computePsiTheta(cur, q)
 			e.solveChoices(cur, q, n) // mp.c:7495
+			// Values that pass Validate can still overflow (e.g. huge
+			// coordinates); report them instead of handing on NaN controls.
+			if k, field, v := checkControls(cur, n); k != nil {
+				return &KnotError{Path: -1, Knot: knotIndex(knots, k), Field: field, Value: v, Solved: true}
+			}
 		} else if cur.RType == KnotEndpoint {
 			// mp.c:7505-7509 — endpoint: control points equal to knot coords.
 			cur.RightX = cur.XCoord
@@ -501,7 +513,10 @@ func (e *Engine) setControls(p, q *Knot, k int) {
 	rr := velocity(e.st, e.ct, e.sf, e.cf, rt)
 	ss := velocity(e.sf, e.cf, e.st, e.ct, lt)
 
-	// Negative tension correction (mp.c:8066-8114 / mp.w ~8874ff).
+	// Negative tension ("tension atleast", the "..." operator) limits the
+	// velocities so that the curve stays inside the triangle formed by the
+	// chord and the two tangents, provided it has no inflection (st and sf
+	// of equal sign). mp.c:8066-8114 / mp.w ~8874ff.
 	if p.RightY < 0 || q.LeftY < 0 {
 		if (numberNonnegative(e.st) && numberNonnegative(e.sf)) ||
 			(numberNonpositive(e.st) && numberNonpositive(e.sf)) {
@@ -510,6 +525,9 @@ func (e *Engine) setControls(p, q *Knot, k int) {
 				takeFraction(numberAbsVal(e.sf), e.ct),
 			)
 			if numberPositive(sine) {
+				// Safety factor 1+2^-12 (mp.w: fraction_one+unity), keeps the
+				// control points strictly inside the bounding triangle.
+				sine = takeFraction(sine, fractionOne+unity)
 				if p.RightY < 0 {
 					ab := abVsCd(numberAbsVal(e.sf), fractionOne, rr, sine)
 					if ab < 0 {
@@ -0,0 +1,248 @@
+package mp
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// strokeBatchChunk is the number of consecutive paths a StrokeBatch
+// worker takes at a time.
+const strokeBatchChunk = 64
+
+// StrokeBatch strokes many paths with the same pen, for generative pieces
+// with tens of thousands of short strokes. Each path gives the filled
+// outline of what drawing it with the pen paints, styled like the
+// envelopes the engine computes for polygonal pens (filled with the stroke
+// color, not stroked).
+//
+// For elliptical pens (PenCircle and its transformations) the outline is
+// StrokeOutline's in the frame where the pen is round, mapped back, so
+// joins and caps follow each path's style. The working buffers are kept
+// per worker and reused for all paths, and the knots of each outline are
+// allocated in one block. Polygonal pens go through MakeEnvelope path by
+// path.
+//
+// Example:
+//
+//	b := mp.NewStrokeBatch(mp.XScaled(3).Then(mp.Rotated(30)).ApplyToPen(mp.PenCircle(0.4)))
+//	outlines := b.Stroke(hairs)
+//	for _, o := range outlines {
+//		pic.AddPath(o)
+//	}
+type StrokeBatch struct {
+	pen     *Pen
+	workers int
+}
+
+// NewStrokeBatch returns a batch stroker for pen that uses one worker
+// goroutine per CPU.
+func NewStrokeBatch(pen *Pen) *StrokeBatch {
+	return &StrokeBatch{pen: pen}
+}
+
+// SetWorkers sets the number of worker goroutines. With 1 the paths are
+// stroked on the calling goroutine; n <= 0 restores one per CPU.
+func (b *StrokeBatch) SetWorkers(n int) *StrokeBatch {
+	b.workers = n
+	return b
+}
+
+// Stroke returns the outlines of paths, in order. An outline is nil for a
+// nil or empty path, for a path that paints nothing (a butt-capped dot)
+// and for an envelope that could not be built; use StrokeContext to get
+// the reason.
+func (b *StrokeBatch) Stroke(paths []*Path) []*Path {
+	out := make([]*Path, len(paths))
+	b.strokeInto(context.Background(), out, paths)
+	return out
+}
+
+// StrokeContext is Stroke with cancellation and errors: ctx is checked
+// between chunks of paths, and the error of the lowest-indexed path whose
+// envelope failed is returned (an *EnvelopeError) together with the
+// outlines of all others.
+func (b *StrokeBatch) StrokeContext(ctx context.Context, paths []*Path) ([]*Path, error) {
+	out := make([]*Path, len(paths))
+	return out, b.strokeInto(ctx, out, paths)
+}
+
+// strokeInto strokes paths[i] into out[i] on the configured workers.
+func (b *StrokeBatch) strokeInto(ctx context.Context, out, paths []*Path) error {
+	workers := b.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if chunks := (len(paths) + strokeBatchChunk - 1) / strokeBatchChunk; workers > chunks {
+		workers = chunks
+	}
+	stroke := b.stroker()
+	errs := make([]error, len(paths))
+	var next atomic.Int64
+	work := func() {
+		st := stroke()
+		for {
+			lo := int(next.Add(strokeBatchChunk)) - strokeBatchChunk
+			if lo >= len(paths) {
+				return
+			}
+			if err := ctx.Err(); err != nil {
+				errs[lo] = err
+				return
+			}
+			hi := lo + strokeBatchChunk
+			if hi > len(paths) {
+				hi = len(paths)
+			}
+			for i := lo; i < hi; i++ {
+				out[i], errs[i] = st(ctx, paths[i])
+			}
+		}
+	}
+	if workers <= 1 {
+		work()
+	} else {
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				work()
+			}()
+		}
+		wg.Wait()
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stroker returns a function that makes a stroking function for one
+// worker, with its own buffers.
+func (b *StrokeBatch) stroker() func() func(context.Context, *Path) (*Path, error) {
+	pen := b.pen
+	if pen == nil || pen.Head == nil {
+		return func() func(context.Context, *Path) (*Path, error) {
+			return func(context.Context, *Path) (*Path, error) { return nil, nil }
+		}
+	}
+	if !pen.Elliptical {
+		return func() func(context.Context, *Path) (*Path, error) {
+			return func(ctx context.Context, p *Path) (*Path, error) {
+				if p == nil || p.Head == nil {
+					return nil, nil
+				}
+				env, err := MakeEnvelopeContext(ctx, p, pen, 0, nil)
+				if env == nil || err != nil {
+					return nil, err
+				}
+				return strokeBatchStyle(env, p), nil
+			}
+		}
+	}
+	// The pen is center + M·(disc of diameter 1), see PenCircle. With
+	// σ = sqrt|det M| it is the image of a disc of diameter σ under
+	// N = M/σ, which preserves areas and is a rotation for round pens, so
+	// StrokeOutline's tolerance holds in the stroke's own units.
+	k := pen.Head
+	m := Transform{
+		Txx: k.LeftX - k.XCoord, Txy: k.RightX - k.XCoord,
+		Tyx: k.LeftY - k.YCoord, Tyy: k.RightY - k.YCoord,
+	}
+	det := m.Determinant()
+	if det == 0 {
+		razor := flatPen(m, P(k.XCoord, k.YCoord))
+		return (&StrokeBatch{pen: razor}).stroker()
+	}
+	sigma := math.Sqrt(math.Abs(det))
+	n := Transform{Txx: m.Txx / sigma, Txy: m.Txy / sigma, Tyx: m.Tyx / sigma, Tyy: m.Tyy / sigma}
+	toPen := n.Inverse()
+	back := n.Then(Shifted(k.XCoord, k.YCoord))
+	return func() func(context.Context, *Path) (*Path, error) {
+		var s strokeScratch
+		return func(_ context.Context, p *Path) (*Path, error) {
+			if p == nil || p.Head == nil {
+				return nil, nil
+			}
+			s.segs = appendDrawnSegments(s.segs[:0], p)
+			for i, c := range s.segs {
+				for j, q := range c {
+					s.segs[i][j].X, s.segs[i][j].Y = toPen.ApplyToPoint(q.X, q.Y)
+				}
+			}
+			var o *Path
+			if s.outline(p.Head.LType != KnotEndpoint, sigma/2, p.Style.LineJoin, p.Style.LineCap) {
+				segs := s.out
+				if det < 0 {
+					// N reverses the orientation; keep outlines clockwise.
+					s.right = appendReversed(s.right[:0], s.out)
+					segs = s.right
+				}
+				o = transformedCycle(segs, back)
+			} else {
+				x, y := toPen.ApplyToPoint(p.Head.XCoord, p.Head.YCoord)
+				dot := &Path{Style: p.Style}
+				dot.Append(&Knot{XCoord: x, YCoord: y})
+				if o = strokeDot(dot, sigma/2); o == nil {
+					return nil, nil
+				}
+				if o = back.ApplyToPath(o); det < 0 {
+					o = o.Reversed()
+				}
+			}
+			return strokeBatchStyle(o, p), nil
+		}
+	}
+}
+
+// flatPen returns the razor pen that an elliptical pen center + M·disc
+// with a singular M degenerates to: the segment the disc is flattened
+// onto (a point if M is zero).
+func flatPen(m Transform, center Point) *Pen {
+	// M = v·wᵀ maps the disc of radius 1/2 onto ±|v||w|/2 along v, and
+	// |v||w| is the Frobenius norm of M.
+	v := P(m.Txx, m.Tyx)
+	if col2 := P(m.Txy, m.Tyy); col2.Length() > v.Length() {
+		v = col2
+	}
+	var e Point
+	if l := v.Length(); l > 0 {
+		e = v.Mul(math.Sqrt(m.Txx*m.Txx+m.Txy*m.Txy+m.Tyx*m.Tyx+m.Tyy*m.Tyy) / (2 * l))
+	}
+	p := NewPath()
+	p.Append(&Knot{XCoord: center.X - e.X, YCoord: center.Y - e.Y})
+	p.Append(&Knot{XCoord: center.X + e.X, YCoord: center.Y + e.Y})
+	return NewPenFromPath(p)
+}
+
+// transformedCycle returns the explicit cycle through the segments segs,
+// mapped by t, with all its knots allocated in one block.
+func transformedCycle(segs [][4]Point, t Transform) *Path {
+	ks := make([]Knot, len(segs))
+	for i, c := range segs {
+		k, next := &ks[i], &ks[(i+1)%len(ks)]
+		k.XCoord, k.YCoord = t.ApplyToPoint(c[0].X, c[0].Y)
+		k.RightX, k.RightY = t.ApplyToPoint(c[1].X, c[1].Y)
+		next.LeftX, next.LeftY = t.ApplyToPoint(c[2].X, c[2].Y)
+		k.LType, k.RType = KnotExplicit, KnotExplicit
+		k.Next, next.Prev = next, k
+	}
+	return &Path{Head: &ks[0]}
+}
+
+// strokeBatchStyle gives the outline o of p the style of an envelope, as
+// Engine.applyOffset does.
+func strokeBatchStyle(o, p *Path) *Path {
+	o.Style = p.Style
+	o.Style.Fill = p.Style.Stroke
+	o.Style.Stroke = ColorCSS("none")
+	o.Style.StrokeWidth = 0
+	o.Style.Pen = nil
+	o.Envelope = nil
+	return o
+}
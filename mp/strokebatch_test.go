@@ -0,0 +1,134 @@
+package mp
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// batchStrokes returns n short solved strokes scattered over a square.
+func batchStrokes(n int) []*Path {
+	r := rand.New(rand.NewSource(1))
+	paths := make([]*Path, n)
+	for i := range paths {
+		x, y := 1000*r.Float64(), 1000*r.Float64()
+		a := r.Float64() * 2 * math.Pi
+		p := NewPath()
+		for j := 0; j < 3; j++ {
+			p.Append(NewKnotAt(x+Number(j)*5*math.Cos(a+Number(j)*0.4), y+Number(j)*5*math.Sin(a+Number(j)*0.4)))
+		}
+		p.Head.LType, p.Head.Prev.RType = KnotEndpoint, KnotEndpoint
+		e := NewEngine()
+		e.AddPath(p)
+		if err := e.Solve(); err != nil {
+			panic(err)
+		}
+		paths[i] = p
+	}
+	return paths
+}
+
+func TestStrokeBatchRoundPen(t *testing.T) {
+	paths := batchStrokes(200)
+	paths[7] = nil
+	got := NewStrokeBatch(PenCircle(2)).Stroke(paths)
+	seq := NewStrokeBatch(PenCircle(2)).SetWorkers(1).Stroke(paths)
+	if got[7] != nil || seq[7] != nil {
+		t.Errorf("outline of a nil path")
+	}
+	for i, p := range paths {
+		if p == nil {
+			continue
+		}
+		want := StrokeOutline(p, 2)
+		for _, o := range []*Path{got[i], seq[i]} {
+			a, b := pathSegments(o), pathSegments(want)
+			if len(a) != len(b) {
+				t.Fatalf("path %d: %d outline segments, want %d", i, len(a), len(b))
+			}
+			for j := range a {
+				for k := range a[j] {
+					if Distance(a[j][k], b[j][k]) > 1e-9 {
+						t.Fatalf("path %d: outline point %v, want %v", i, a[j][k], b[j][k])
+					}
+				}
+			}
+		}
+		if got[i].Style.Fill != p.Style.Stroke || got[i].Style.Pen != nil {
+			t.Errorf("path %d: outline not styled as an envelope", i)
+		}
+	}
+}
+
+func TestStrokeBatchEllipticalPen(t *testing.T) {
+	line := straightPath([]Point{P(0, 0), P(10, 0)}, false)
+	// A pen 4 wide and 1 high: round caps reach 2 beyond the ends, the
+	// stroke is 1 high.
+	pen := XScaled(4).ApplyToPen(PenCircle(1))
+	o := NewStrokeBatch(pen).Stroke([]*Path{line})[0]
+	minX, minY, maxX, maxY := PathBBox(o)
+	if math.Abs(minX+2) > 1e-6 || math.Abs(maxX-12) > 1e-6 || math.Abs(minY+0.5) > 1e-6 || math.Abs(maxY-0.5) > 1e-6 {
+		t.Errorf("bbox (%g,%g)-(%g,%g), want (-2,-0.5)-(12,0.5)", minX, minY, maxX, maxY)
+	}
+	// A mirrored pen paints the same, and the outline still runs clockwise.
+	m := NewStrokeBatch(YScaled(-1).ApplyToPen(pen)).Stroke([]*Path{line})[0]
+	poly, _ := flattenPath(m, 32)
+	if a := polygonArea(poly); a >= 0 {
+		t.Errorf("mirrored pen: signed area %g, want negative", a)
+	}
+	// A dot.
+	dot := NewPath()
+	dot.Append(&Knot{XCoord: 3, YCoord: 4, LType: KnotEndpoint, RType: KnotEndpoint})
+	d := NewStrokeBatch(pen).Stroke([]*Path{dot})[0]
+	if minX, minY, maxX, maxY := PathBBox(d); math.Abs(minX-1) > 1e-6 || math.Abs(maxX-5) > 1e-6 || math.Abs(minY-3.5) > 1e-6 || math.Abs(maxY-4.5) > 1e-6 {
+		t.Errorf("dot bbox (%g,%g)-(%g,%g), want (1,3.5)-(5,4.5)", minX, minY, maxX, maxY)
+	}
+}
+
+func TestStrokeBatchPolygonalPen(t *testing.T) {
+	paths := batchStrokes(10)
+	got, err := NewStrokeBatch(PenSquare(2)).StrokeContext(context.Background(), paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range paths {
+		want := MakeEnvelope(p, PenSquare(2))
+		if len(pathSegments(got[i])) != len(pathSegments(want)) {
+			t.Errorf("path %d: envelope differs from MakeEnvelope", i)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewStrokeBatch(PenCircle(1)).StrokeContext(ctx, paths); err != context.Canceled {
+		t.Errorf("cancelled batch: err = %v", err)
+	}
+}
+
+func BenchmarkStrokeBatch(b *testing.B) {
+	paths := batchStrokes(100000)
+	sb := NewStrokeBatch(PenCircle(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sb.Stroke(paths)
+	}
+}
+
+func BenchmarkStrokeBatchSequential(b *testing.B) {
+	paths := batchStrokes(100000)
+	sb := NewStrokeBatch(PenCircle(1)).SetWorkers(1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sb.Stroke(paths)
+	}
+}
+
+func BenchmarkStrokeOutlinePerPath(b *testing.B) {
+	paths := batchStrokes(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range paths {
+			StrokeOutline(p, 1)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package mp
+
+// StrokeOutline returns the outline of the area that stroking p with a
+// round pen of the given diameter paints, as a single cycle to be filled
+// with the nonzero rule: the offsets of p by ±width/2 (see OffsetPath),
+// joined according to p.Style.LineJoin and, for open paths, closed by
+// caps according to p.Style.LineCap (round by default, as in MetaPost).
+// The outline of a cycle is the outer and the inner offset joined by a
+// straight bridge that is traversed both ways ("keyhole"), so the ring
+// stays one path; the interior winds clockwise in all cases, so outlines
+// of several strokes can be combined without cancelling.
+//
+// A path that is a single point gives the dot a round or squared cap
+// paints, and nothing for butt caps. The result has no style; it is nil
+// for an empty path or a width <= 0. Dashes and arrowheads are not
+// handled here; expand them first.
+//
+// Example:
+//
+//	outline := mp.StrokeOutline(p, 2)
+//	outline.Style = mp.NewStyle(mp.WithFill(p.Style.Stroke), mp.WithStroke(mp.ColorCSS("none")))
+func StrokeOutline(p *Path, width Number) *Path {
+	if p == nil || p.Head == nil || width <= 0 {
+		return nil
+	}
+	var s strokeScratch
+	s.segs = appendDrawnSegments(s.segs, p)
+	if !s.outline(p.Head.LType != KnotEndpoint, width/2, p.Style.LineJoin, p.Style.LineCap) {
+		return strokeDot(p, width/2)
+	}
+	return segmentsPath(s.out, true)
+}
+
+// strokeScratch holds the working buffers of a stroke outline, so that
+// StrokeBatch can reuse them for many paths.
+type strokeScratch struct {
+	segs        [][4]Point // the input: the drawn segments of the path
+	left, right [][4]Point // its offsets
+	out         [][4]Point // the outline, a cycle
+}
+
+// outline sets s.out to the outline of the stroke of s.segs with half
+// width h, see StrokeOutline. It returns false if there are no segments.
+func (s *strokeScratch) outline(cycle bool, h Number, join LineJoin, lineCap LineCap) bool {
+	if len(s.segs) == 0 {
+		return false
+	}
+	s.left = appendOffsetSegments(s.left[:0], s.segs, cycle, h, join)
+	s.right = appendOffsetSegments(s.right[:0], s.segs, cycle, -h, join)
+	ls, rs := s.left, s.right
+	out := append(s.out[:0], ls...)
+	if cycle {
+		out = append(out, lineSegment(ls[0][0], rs[0][0]))
+		out = appendReversed(out, rs)
+		out = append(out, lineSegment(rs[0][0], ls[0][0]))
+	} else {
+		endDir, _ := endDirection(s.segs[len(s.segs)-1])
+		startDir, _ := startDirection(s.segs[0])
+		out = appendCap(out, ls[len(ls)-1][3], rs[len(rs)-1][3], endDir, h, lineCap)
+		out = appendReversed(out, rs)
+		out = appendCap(out, rs[0][0], ls[0][0], startDir.Mul(-1), h, lineCap)
+	}
+	s.out = out
+	return true
+}
+
+// lineSegment returns the straight segment from a to b.
+func lineSegment(a, b Point) [4]Point {
+	return [4]Point{a, a.Add(b.Sub(a).Mul(1.0 / 3)), a.Add(b.Sub(a).Mul(2.0 / 3)), b}
+}
+
+// appendReversed appends segs traversed backwards to out.
+func appendReversed(out, segs [][4]Point) [][4]Point {
+	for i := len(segs) - 1; i >= 0; i-- {
+		c := segs[i]
+		out = append(out, [4]Point{c[3], c[2], c[1], c[0]})
+	}
+	return out
+}
+
+// appendCap appends the cap of a stroke of half width h from a to b, the
+// offset points on either side of an end of the path; dir points out of
+// the path there.
+func appendCap(segs [][4]Point, a, b, dir Point, h Number, lineCap LineCap) [][4]Point {
+	switch lineCap {
+	case LineCapButt:
+		return append(segs, lineSegment(a, b))
+	case LineCapSquared:
+		a1, b1 := a.Add(dir.Mul(h)), b.Add(dir.Mul(h))
+		return append(segs, lineSegment(a, a1), lineSegment(a1, b1), lineSegment(b1, b))
+	}
+	// Round: a half circle in two quarter arcs.
+	mid := a.Add(b).Mul(0.5).Add(dir.Mul(h))
+	across := b.Sub(a).Normalized()
+	return append(segs, arcCubic(a, dir, mid, across), arcCubic(mid, across, b, dir.Mul(-1)))
+}
+
+// strokeDot returns the dot that stroking the single point p with half
+// width h paints.
+func strokeDot(p *Path, h Number) *Path {
+	x, y := p.Head.XCoord, p.Head.YCoord
+	switch p.Style.LineCap {
+	case LineCapButt:
+		return nil
+	case LineCapSquared:
+		return straightPath([]Point{P(x-h, y-h), P(x-h, y+h), P(x+h, y+h), P(x+h, y-h)}, true)
+	}
+	return Scaled(2 * h).Then(Shifted(x, y)).ApplyToPath(FullCircle()).Reversed()
+}
@@ -0,0 +1,67 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStrokeOutlineCaps(t *testing.T) {
+	line := straightPath([]Point{P(0, 0), P(10, 0)}, false)
+	for _, tc := range []struct {
+		cap  LineCap
+		minX Number
+		area Number
+	}{
+		{LineCapButt, 0, 20},
+		{LineCapSquared, -1, 24},
+		{LineCapDefault, -1, 20 + math.Pi},
+	} {
+		line.Style.LineCap = tc.cap
+		o := StrokeOutline(line, 2)
+		minX, minY, maxX, maxY := PathBBox(o)
+		if math.Abs(minX-tc.minX) > 1e-9 || math.Abs(maxX-(10-tc.minX)) > 1e-9 || math.Abs(minY+1) > 1e-9 || math.Abs(maxY-1) > 1e-9 {
+			t.Errorf("cap %d: bbox (%g,%g)-(%g,%g)", tc.cap, minX, minY, maxX, maxY)
+		}
+		poly, _ := flattenPath(o, 32)
+		// The outline runs clockwise.
+		if a := polygonArea(poly); math.Abs(a+tc.area) > 0.01 {
+			t.Errorf("cap %d: signed area %g, want %g", tc.cap, a, -tc.area)
+		}
+	}
+}
+
+func TestStrokeOutlineCycle(t *testing.T) {
+	sq := Scaled(10).ApplyToPath(UnitSquare())
+	o := StrokeOutline(sq, 2)
+	if o.Head.LType == KnotEndpoint {
+		t.Fatal("outline is open")
+	}
+	// The ring is filled, the inside is not.
+	sp := NewScanliner(o).Spans(5)
+	if len(sp) != 2 || math.Abs(sp[0].X0+1) > 1e-9 || math.Abs(sp[0].X1-1) > 1e-9 || math.Abs(sp[1].X0-9) > 1e-9 || math.Abs(sp[1].X1-11) > 1e-9 {
+		t.Errorf("spans through the ring = %v, want [-1,1] and [9,11]", sp)
+	}
+	if minX, minY, maxX, maxY := PathBBox(o); minX != -1 || minY != -1 || maxX != 11 || maxY != 11 {
+		t.Errorf("bbox (%g,%g)-(%g,%g), want (-1,-1)-(11,11)", minX, minY, maxX, maxY)
+	}
+	// Clockwise rings work the same.
+	if sp := NewScanliner(StrokeOutline(sq.Reversed(), 2)).Spans(5); len(sp) != 2 {
+		t.Errorf("spans through reversed ring = %v", sp)
+	}
+}
+
+func TestStrokeOutlineDot(t *testing.T) {
+	dot := NewPath()
+	dot.Append(&Knot{XCoord: 3, YCoord: 4, LType: KnotEndpoint, RType: KnotEndpoint})
+	o := StrokeOutline(dot, 2)
+	if minX, minY, maxX, maxY := PathBBox(o); math.Abs(minX-2) > 1e-9 || math.Abs(minY-3) > 1e-9 || math.Abs(maxX-4) > 1e-9 || math.Abs(maxY-5) > 1e-9 {
+		t.Errorf("dot bbox (%g,%g)-(%g,%g)", minX, minY, maxX, maxY)
+	}
+	dot.Style.LineCap = LineCapButt
+	if StrokeOutline(dot, 2) != nil {
+		t.Errorf("butt-capped dot paints something")
+	}
+	if StrokeOutline(straightPath([]Point{P(0, 0), P(1, 0)}, false), 0) != nil {
+		t.Errorf("outline of zero width")
+	}
+}
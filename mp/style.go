@@ -0,0 +1,196 @@
+package mp
+
+// StyleOption sets one property of a Style, see NewStyle.
+type StyleOption func(*Style)
+
+// NewStyle returns a Style with the given options applied in order.
+//
+// Example:
+//
+//	s := mp.NewStyle(mp.WithStroke(mp.ColorCSS("red")), mp.WithStrokeWidth(1), mp.WithDash(mp.DashEvenly()))
+func NewStyle(opts ...StyleOption) Style {
+	var s Style
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// With returns a copy of s with the given options applied.
+func (s Style) With(opts ...StyleOption) Style {
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// WithStroke sets the stroke color (MetaPost: withcolor).
+func WithStroke(c Color) StyleOption { return func(s *Style) { s.Stroke = c } }
+
+// WithStrokeWidth sets the stroke width.
+func WithStrokeWidth(w float64) StyleOption { return func(s *Style) { s.StrokeWidth = w } }
+
+// WithFill sets the fill color.
+func WithFill(c Color) StyleOption { return func(s *Style) { s.Fill = c } }
+
+// WithPen sets the pen (MetaPost: withpen).
+func WithPen(p *Pen) StyleOption { return func(s *Style) { s.Pen = p } }
+
+// WithLineJoin sets the line join, one of the LineJoin constants.
+func WithLineJoin(join LineJoin) StyleOption { return func(s *Style) { s.LineJoin = join } }
+
+// WithLineCap sets the line cap, one of the LineCap constants.
+func WithLineCap(lineCap LineCap) StyleOption { return func(s *Style) { s.LineCap = lineCap } }
+
+// WithLineJoinInt sets the line join from an untyped value in the scheme
+// of the LineJoin constants (0 unset, 1 miter, 2 round, 3 bevel), as
+// WithLineJoin took it before LineJoin was a type.
+//
+// Deprecated: Use WithLineJoin with a LineJoin constant, or convert
+// MetaPost's linejoin with LineJoinFromMetaPost.
+func WithLineJoinInt(join int) StyleOption { return WithLineJoin(LineJoin(join)) }
+
+// WithLineCapInt sets the line cap from an untyped value in the scheme of
+// the LineCap constants (0 unset, 1 butt, 2 round, 3 square), as
+// WithLineCap took it before LineCap was a type.
+//
+// Deprecated: Use WithLineCap with a LineCap constant, or convert
+// MetaPost's linecap with LineCapFromMetaPost.
+func WithLineCapInt(lineCap int) StyleOption { return WithLineCap(LineCap(lineCap)) }
+
+// WithDash sets the dash pattern (MetaPost: dashed).
+func WithDash(d *DashPattern) StyleOption { return func(s *Style) { s.Dash = d } }
+
+// WithArrow adds arrowheads at the end and, if both is set, at the start
+// of the path, with MetaPost's default size (drawarrow / drawdblarrow).
+func WithArrow(both bool) StyleOption {
+	return func(s *Style) {
+		s.Arrow = ArrowStyle{Start: both, End: true, Length: DefaultAHLength, Angle: DefaultAHAngle}
+	}
+}
+
+// WithArrowHead draws the arrowheads with head instead of the built-in
+// triangle (see ArrowStyle.Head).
+func WithArrowHead(head ArrowHeadFunc) StyleOption { return func(s *Style) { s.Arrow.Head = head } }
+
+// WithArrowShape draws the arrowheads in one of the built-in shapes, e.g.
+// as harpoons (see ArrowShape).
+func WithArrowShape(shape ArrowShape) StyleOption { return func(s *Style) { s.Arrow.Shape = shape } }
+
+// WithJoinedArrows draws the arrowheads and the envelope of a path stroked
+// with a polygonal pen as one filled outline (ArrowStyle.Joined).
+func WithJoinedArrows() StyleOption { return func(s *Style) { s.Arrow.Joined = true } }
+
+// WithAutoCloseFill fills an open path up to its closing chord (see
+// Style.AutoCloseFill).
+func WithAutoCloseFill() StyleOption { return func(s *Style) { s.AutoCloseFill = true } }
+
+// WithHairline draws the stroke as a hairline (see Style.Hairline).
+func WithHairline() StyleOption { return func(s *Style) { s.Hairline = true } }
+
+// WithClosing sets the style merged over the path's style for the
+// closing segment of a cycle (see Style.Closing).
+//
+// Example:
+//
+//	hidden := mp.NewStyle(mp.WithClosing(mp.NewStyle(mp.WithStroke(mp.ColorCSS("none")))))
+func WithClosing(closing Style) StyleOption { return func(s *Style) { s.Closing = &closing } }
+
+// WithMeta sets the metadata entry key to value. The SVG writer emits
+// metadata as data- attributes; the key "id" sets the element id instead.
+// The map is copied, so styles sharing metadata stay independent.
+//
+// Example:
+//
+//	s := mp.NewStyle(mp.WithMeta("id", "flow-1"), mp.WithMeta("label", "inlet"))
+func WithMeta(key, value string) StyleOption {
+	return func(s *Style) { s.Meta = mergeMeta(s.Meta, map[string]string{key: value}) }
+}
+
+// mergeMeta returns a new map with the entries of a and b, b winning.
+func mergeMeta(a, b map[string]string) map[string]string {
+	m := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		m[k] = v
+	}
+	return m
+}
+
+// Merge returns s with every property that is set in overrides replaced.
+// A property counts as set if it differs from its zero value (an empty
+// color, width 0, nil pen, dash, gradient, arrowhead function or closing
+// style, LineJoinDefault, LineCapDefault, LineStyleSolid,
+// ArrowShapeTriangle); arrow and hairline
+// flags are set if true and arrow sizes if positive. Metadata maps are
+// combined, with the entries of overrides winning. Merge therefore cannot
+// clear a property; assign the field directly for that.
+//
+// Example:
+//
+//	highlight := base.Merge(mp.NewStyle(mp.WithStroke(mp.ColorCSS("red"))))
+func (s Style) Merge(overrides Style) Style {
+	if overrides.Stroke.CSS() != "" {
+		s.Stroke = overrides.Stroke
+	}
+	if overrides.StrokeWidth != 0 {
+		s.StrokeWidth = overrides.StrokeWidth
+	}
+	if overrides.Fill.CSS() != "" {
+		s.Fill = overrides.Fill
+	}
+	if overrides.Pen != nil {
+		s.Pen = overrides.Pen
+	}
+	if overrides.LineJoin != LineJoinDefault {
+		s.LineJoin = overrides.LineJoin
+	}
+	if overrides.LineCap != LineCapDefault {
+		s.LineCap = overrides.LineCap
+	}
+	if overrides.Arrow.Start {
+		s.Arrow.Start = true
+	}
+	if overrides.Arrow.End {
+		s.Arrow.End = true
+	}
+	if overrides.Arrow.Joined {
+		s.Arrow.Joined = true
+	}
+	if overrides.Arrow.Shape != ArrowShapeTriangle {
+		s.Arrow.Shape = overrides.Arrow.Shape
+	}
+	if overrides.Arrow.Head != nil {
+		s.Arrow.Head = overrides.Arrow.Head
+	}
+	if overrides.Hairline {
+		s.Hairline = true
+	}
+	if overrides.Arrow.Length > 0 {
+		s.Arrow.Length = overrides.Arrow.Length
+	}
+	if overrides.Arrow.Angle > 0 {
+		s.Arrow.Angle = overrides.Arrow.Angle
+	}
+	if overrides.Dash != nil {
+		s.Dash = overrides.Dash
+	}
+	if overrides.LineStyle != LineStyleSolid {
+		s.LineStyle = overrides.LineStyle
+	}
+	if overrides.Gradient != nil {
+		s.Gradient = overrides.Gradient
+	}
+	if len(overrides.Meta) > 0 {
+		s.Meta = mergeMeta(s.Meta, overrides.Meta)
+	}
+	if overrides.Closing != nil {
+		s.Closing = overrides.Closing
+	}
+	if overrides.AutoCloseFill {
+		s.AutoCloseFill = true
+	}
+	return s
+}
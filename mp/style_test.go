@@ -0,0 +1,78 @@
+package mp
+
+import "testing"
+
+func TestNewStyle(t *testing.T) {
+	dash := DashEvenly()
+	s := NewStyle(WithStroke(ColorCSS("red")), WithStrokeWidth(2), WithDash(dash), WithLineCap(LineCapButt), WithArrow(false))
+	if s.Stroke.CSS() != "red" || s.StrokeWidth != 2 || s.Dash != dash || s.LineCap != LineCapButt {
+		t.Errorf("unexpected style %+v", s)
+	}
+	if s.Arrow.Start || !s.Arrow.End || s.Arrow.Length != DefaultAHLength {
+		t.Errorf("unexpected arrow %+v", s.Arrow)
+	}
+	if s.Fill.CSS() != "" || s.Pen != nil {
+		t.Error("options not given should stay unset")
+	}
+	if w := s.With(WithStrokeWidth(3)); w.StrokeWidth != 3 || s.StrokeWidth != 2 {
+		t.Error("With should modify a copy")
+	}
+}
+
+func TestStyleMerge(t *testing.T) {
+	base := NewStyle(WithStroke(ColorCSS("black")), WithStrokeWidth(0.5), WithLineJoin(LineJoinRound))
+	m := base.Merge(NewStyle(WithStroke(ColorCSS("red")), WithFill(ColorCSS("yellow")), WithArrow(true)))
+	if m.Stroke.CSS() != "red" || m.Fill.CSS() != "yellow" {
+		t.Errorf("overrides not applied: %+v", m)
+	}
+	if m.StrokeWidth != 0.5 || m.LineJoin != LineJoinRound {
+		t.Errorf("unset overrides must keep base values: %+v", m)
+	}
+	if !m.Arrow.Start || !m.Arrow.End || m.Arrow.Angle != DefaultAHAngle {
+		t.Errorf("arrow not merged: %+v", m.Arrow)
+	}
+	if base.Stroke.CSS() != "black" {
+		t.Error("Merge must not modify the receiver")
+	}
+}
+
+func TestStyleMergeMeta(t *testing.T) {
+	base := NewStyle(WithMeta("id", "a"), WithMeta("kind", "flow"))
+	m := base.Merge(NewStyle(WithMeta("id", "b")))
+	if m.Meta["id"] != "b" || m.Meta["kind"] != "flow" {
+		t.Errorf("meta not merged: %v", m.Meta)
+	}
+	if base.Meta["id"] != "a" {
+		t.Error("Merge must not modify the receiver's metadata")
+	}
+}
+
+func TestLineCapJoinMetaPostValues(t *testing.T) {
+	for v := 0; v <= 2; v++ {
+		if got := LineCapFromMetaPost(v).MetaPost(); got != v {
+			t.Errorf("linecap %d round-trips to %d", v, got)
+		}
+		if got := LineJoinFromMetaPost(v).MetaPost(); got != v {
+			t.Errorf("linejoin %d round-trips to %d", v, got)
+		}
+	}
+	if LineCapDefault.MetaPost() != 1 || LineJoinDefault.MetaPost() != 1 {
+		t.Error("defaults must be MetaPost's rounded caps and joins")
+	}
+	if LineCapFromMetaPost(0) != LineCapButt || LineJoinFromMetaPost(0) != LineJoinMiter {
+		t.Error("MetaPost 0 must be butt caps and mitered joins")
+	}
+	if LineCapSquared.String() != "square" || LineJoinBevel.String() != "bevel" || LineJoin(7).String() != "LineJoin(7)" {
+		t.Errorf("unexpected names %v %v %v", LineCapSquared, LineJoinBevel, LineJoin(7))
+	}
+	if LineCap(-1).Valid() || !LineJoinMiter.Valid() {
+		t.Error("Valid is wrong")
+	}
+}
+
+func TestLineCapJoinIntShims(t *testing.T) {
+	s := NewStyle(WithLineCapInt(1), WithLineJoinInt(3))
+	if s.LineCap != LineCapButt || s.LineJoin != LineJoinBevel {
+		t.Errorf("got cap %v join %v, want butt and bevel", s.LineCap, s.LineJoin)
+	}
+}
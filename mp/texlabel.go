@@ -0,0 +1,182 @@
+package mp
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NewTeXLabel creates a label whose text is TeX source, as between btex
+// and etex in MetaPost, e.g. "$\alpha_1 + x^2$". See Label.TeX.
+func NewTeXLabel(tex string, pos Point, anchor Anchor) *Label {
+	return NewLabel(tex, pos, anchor).WithTeX()
+}
+
+// WithTeX marks the label text as TeX source (see Label.TeX).
+func (l *Label) WithTeX() *Label {
+	l.TeX = true
+	return l
+}
+
+// PlainText returns the text to show for the label in backends that do
+// not typeset TeX, such as SVG text elements and glyph outlines. For a
+// plain label it is the text itself. For a TeX label it is a best-effort
+// Unicode rendering of the source: math shifts and grouping braces are
+// dropped, Greek letters and common symbols become their characters,
+// digits and signs in sub- and superscripts become Unicode sub- and
+// superscripts, and the arguments of font commands such as \mathrm or
+// \textbf are kept without the command. "$\alpha_1 \leq x^2$" becomes
+// "α₁ ≤ x²".
+func (l *Label) PlainText() string {
+	if !l.TeX {
+		return l.Text
+	}
+	return texToPlain(l.Text)
+}
+
+// texSymbols maps TeX control words to the text that replaces them.
+var texSymbols = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε",
+	"varepsilon": "ε", "zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ",
+	"iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ",
+	"pi": "π", "varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ",
+	"varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "φ", "varphi": "φ",
+	"chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ",
+	"Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	"cdot": "·", "times": "×", "div": "÷", "pm": "±", "mp": "∓",
+	"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠",
+	"approx": "≈", "equiv": "≡", "sim": "∼", "propto": "∝",
+	"infty": "∞", "partial": "∂", "nabla": "∇", "sum": "∑", "prod": "∏",
+	"int": "∫", "sqrt": "√", "in": "∈", "notin": "∉", "subset": "⊂",
+	"subseteq": "⊆", "cup": "∪", "cap": "∩", "emptyset": "∅", "forall": "∀",
+	"exists": "∃", "neg": "¬", "wedge": "∧", "vee": "∨",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "Rightarrow": "⇒",
+	"Leftarrow": "⇐", "leftrightarrow": "↔", "mapsto": "↦",
+	"circ": "∘", "degree": "°", "prime": "′", "ldots": "…", "cdots": "⋯",
+	"dots": "…", "ell": "ℓ", "hbar": "ℏ", "angle": "∠", "perp": "⊥",
+	"parallel": "∥", "quad": " ", "qquad": "  ", "TeX": "TeX", "LaTeX": "LaTeX",
+}
+
+// texSup and texSub map characters to their Unicode superscript and
+// subscript forms.
+var (
+	texSup = strings.NewReplacer("0", "⁰", "1", "¹", "2", "²", "3", "³", "4", "⁴",
+		"5", "⁵", "6", "⁶", "7", "⁷", "8", "⁸", "9", "⁹", "+", "⁺", "-", "⁻",
+		"=", "⁼", "(", "⁽", ")", "⁾", "n", "ⁿ", "i", "ⁱ", "′", "′")
+	texSub = strings.NewReplacer("0", "₀", "1", "₁", "2", "₂", "3", "₃", "4", "₄",
+		"5", "₅", "6", "₆", "7", "₇", "8", "₈", "9", "₉", "+", "₊", "-", "₋",
+		"=", "₌", "(", "₍", ")", "₎")
+)
+
+// texToPlain renders the TeX source s as plain text (see Label.PlainText).
+func texToPlain(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		switch r := rs[i]; r {
+		case '$', '{', '}':
+		case '~':
+			b.WriteRune(' ')
+		case '^', '_':
+			arg, next := texArgument(rs, i+1)
+			i = next - 1
+			plain := texToPlain(arg)
+			repl := texSup
+			if r == '_' {
+				repl = texSub
+			}
+			if conv := repl.Replace(plain); texScripted(conv, plain) {
+				b.WriteString(conv)
+			} else {
+				b.WriteRune(r)
+				b.WriteString(plain)
+			}
+		case '\\':
+			j := i + 1
+			for j < len(rs) && unicode.IsLetter(rs[j]) {
+				j++
+			}
+			if j == i+1 {
+				// A control symbol: spacing commands give a space (\! none),
+				// escaped characters such as \{ \$ \% the character.
+				if j < len(rs) {
+					switch c := rs[j]; c {
+					case ',', ';', ':', ' ', '\\':
+						b.WriteRune(' ')
+					case '!':
+					default:
+						b.WriteRune(c)
+					}
+					j++
+				}
+				i = j - 1
+				continue
+			}
+			name := string(rs[i+1 : j])
+			if sym, ok := texSymbols[name]; ok {
+				b.WriteString(sym)
+			} else if j < len(rs) && rs[j] == ' ' {
+				// Unknown commands such as \mathrm or \textbf vanish with
+				// the space that ends them and leave their argument.
+				j++
+			}
+			i = j - 1
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// texArgument returns the argument of a sub- or superscript starting at
+// rs[i]: a braced group, a control word or a single character, and the
+// index after it.
+func texArgument(rs []rune, i int) (string, int) {
+	if i >= len(rs) {
+		return "", i
+	}
+	switch rs[i] {
+	case '{':
+		depth := 0
+		for j := i; j < len(rs); j++ {
+			switch rs[j] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					return string(rs[i+1 : j]), j + 1
+				}
+			}
+		}
+		return string(rs[i+1:]), len(rs)
+	case '\\':
+		j := i + 1
+		for j < len(rs) && unicode.IsLetter(rs[j]) {
+			j++
+		}
+		if j == i+1 && j < len(rs) {
+			j++
+		}
+		return string(rs[i:j]), j
+	}
+	return string(rs[i]), i + 1
+}
+
+// texScripted reports whether every character of plain has a Unicode
+// sub- or superscript form in conv.
+func texScripted(conv, plain string) bool {
+	if plain == "" {
+		return false
+	}
+	a, b := []rune(conv), []rune(plain)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] == b[i] && b[i] != '′' {
+			return false
+		}
+	}
+	return true
+}
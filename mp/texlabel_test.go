@@ -0,0 +1,25 @@
+package mp
+
+import "testing"
+
+func TestLabelPlainText(t *testing.T) {
+	tests := []struct {
+		tex, want string
+	}{
+		{`$\alpha_1 \leq x^2$`, "α₁ ≤ x²"},
+		{`$x^{n+1}$`, "xⁿ⁺¹"},
+		{`$a_{ij}$`, "a_ij"},
+		{`$\mathrm{d}x/\mathrm{d}t$`, "dx/dt"},
+		{`\textbf{Force} $F$\,[N]`, "Force F [N]"},
+		{`100\%`, "100%"},
+		{`$f'(x) \to \infty$`, "f'(x) → ∞"},
+	}
+	for _, tt := range tests {
+		if got := NewTeXLabel(tt.tex, P(0, 0), AnchorCenter).PlainText(); got != tt.want {
+			t.Errorf("PlainText(%q) = %q, want %q", tt.tex, got, tt.want)
+		}
+	}
+	if got := NewLabel(`$x^2$`, P(0, 0), AnchorCenter).PlainText(); got != `$x^2$` {
+		t.Errorf("plain label changed to %q", got)
+	}
+}
@@ -88,6 +88,53 @@ func Slanted(s Number) Transform {
 	}
 }
 
+// YSlanted returns a vertical shear transformation, the counterpart of
+// Slanted along the y axis: x' = x, y' = y + s*x. MetaPost has no such
+// operator; it is "rotated 90 slanted -s rotated -90" there.
+func YSlanted(s Number) Transform {
+	return Transform{
+		Txx: 1, Txy: 0, Tx: 0,
+		Tyx: s, Tyy: 1, Ty: 0,
+	}
+}
+
+// TransformFromMatrix returns the transformation with the matrix
+// [a b c d tx ty] in the order used by PDF's cm operator, the canvas
+// setTransform call, SVG's matrix() and font matrices:
+//
+//	x' = a*x + c*y + tx
+//	y' = b*x + d*y + ty
+//
+// It is the inverse of Matrix.
+func TransformFromMatrix(a, b, c, d, tx, ty Number) Transform {
+	return Transform{
+		Txx: a, Txy: c, Tx: tx,
+		Tyx: b, Tyy: d, Ty: ty,
+	}
+}
+
+// Matrix returns the components of t in the order of TransformFromMatrix:
+// [a b c d tx ty] as PDF, canvas and SVG expect them.
+//
+// Example:
+//
+//	a, b, c, d, e, f := t.Matrix()
+//	fmt.Fprintf(w, "%g %g %g %g %g %g cm\n", a, b, c, d, e, f)
+func (t Transform) Matrix() (a, b, c, d, tx, ty Number) {
+	return t.Txx, t.Tyx, t.Txy, t.Tyy, t.Tx, t.Ty
+}
+
+// Linear returns the linear part of t, without the shift, as the rows of
+// its 2x2 matrix: x' = xx*x + xy*y, y' = yx*x + yy*y.
+func (t Transform) Linear() (xx, xy, yx, yy Number) {
+	return t.Txx, t.Txy, t.Tyx, t.Tyy
+}
+
+// Translation returns the shift of t, the image of the origin.
+func (t Transform) Translation() (tx, ty Number) {
+	return t.Tx, t.Ty
+}
+
 // ZScaled returns a scaling+rotation transformation using a complex number.
 // Mirrors MetaPost's "zscaled (a, b)" which scales by sqrt(a²+b²) and
 // rotates by atan2(b, a).
@@ -171,10 +218,18 @@ func (t Transform) ApplyToPoint(x, y Number) (Number, Number) {
 }
 
 // ApplyToKnot applies the transformation to all coordinates of a knot.
+// Pens using the knot recompute their cached data (see Pen).
 func (t Transform) ApplyToKnot(k *Knot) {
 	if k == nil {
 		return
 	}
+	knotEdits.Add(1)
+	t.applyToKnot(k)
+}
+
+// applyToKnot is ApplyToKnot for knots of a fresh copy, which no pen
+// uses yet.
+func (t Transform) applyToKnot(k *Knot) {
 	k.XCoord, k.YCoord = t.ApplyToPoint(k.XCoord, k.YCoord)
 	k.LeftX, k.LeftY = t.ApplyToPoint(k.LeftX, k.LeftY)
 	k.RightX, k.RightY = t.ApplyToPoint(k.RightX, k.RightY)
@@ -192,15 +247,32 @@ func (t Transform) ApplyToPath(p *Path) *Path {
 	// Apply transform to each knot
 	cur := result.Head
 	for {
-		t.ApplyToKnot(cur)
+		t.applyToKnot(cur)
 		cur = cur.Next
 		if cur == nil || cur == result.Head {
 			break
 		}
 	}
+	if p.ellipse != nil {
+		result.ellipse = p.ellipse.transformed(t)
+	}
 	return result
 }
 
+// ApplyToPen returns a new pen whose knots are transformed by t, mirroring
+// MetaPost's "pen transformed t". The original pen and its cached data are
+// left untouched; the new pen computes its own on first use.
+func (t Transform) ApplyToPen(pen *Pen) *Pen {
+	if pen == nil || pen.Head == nil {
+		return nil
+	}
+	knotEdits.Add(1)
+	return &Pen{
+		Head:       t.ApplyToPath(&Path{Head: pen.Head}).Head,
+		Elliptical: pen.Elliptical,
+	}
+}
+
 // Inverse returns the inverse transformation, if it exists.
 // Returns Identity() if the transformation is singular (determinant = 0).
 func (t Transform) Inverse() Transform {
@@ -248,6 +320,11 @@ func (p *Path) Slanted(s Number) *Path {
 	return Slanted(s).ApplyToPath(p)
 }
 
+// YSlanted returns a new path with vertical shear applied.
+func (p *Path) YSlanted(s Number) *Path {
+	return YSlanted(s).ApplyToPath(p)
+}
+
 // XScaled returns a new path scaled horizontally.
 func (p *Path) XScaled(s Number) *Path {
 	return XScaled(s).ApplyToPath(p)
@@ -82,6 +82,41 @@ func TestSlanted(t *testing.T) {
 	}
 }
 
+func TestYSlanted(t *testing.T) {
+	tr := YSlanted(0.5)
+	x, y := tr.ApplyToPoint(10, 20)
+	// Vertical slant: x' = x = 10, y' = y + s*x = 20 + 0.5*10 = 25
+	if x != 10 || y != 25 {
+		t.Errorf("YSlanted(0.5) on (10,20): got (%f, %f), want (10, 25)", x, y)
+	}
+	// The same as MetaPost's rotated 90 slanted -s rotated -90.
+	rot := Rotated(90).Then(Slanted(-0.5)).Then(Rotated(-90))
+	if mx, my := rot.ApplyToPoint(10, 20); math.Abs(mx-x) > 1e-9 || math.Abs(my-y) > 1e-9 {
+		t.Errorf("rotated 90 slanted -0.5 rotated -90 on (10,20): got (%f, %f), want (%f, %f)", mx, my, x, y)
+	}
+}
+
+func TestTransformFromMatrix(t *testing.T) {
+	// PDF's "2 1 0 3 5 7 cm": x' = 2x + 0y + 5, y' = 1x + 3y + 7
+	tr := TransformFromMatrix(2, 1, 0, 3, 5, 7)
+	x, y := tr.ApplyToPoint(10, 20)
+	if x != 25 || y != 77 {
+		t.Errorf("TransformFromMatrix(2,1,0,3,5,7) on (10,20): got (%f, %f), want (25, 77)", x, y)
+	}
+	if a, b, c, d, tx, ty := tr.Matrix(); a != 2 || b != 1 || c != 0 || d != 3 || tx != 5 || ty != 7 {
+		t.Errorf("Matrix() = %v %v %v %v %v %v, want 2 1 0 3 5 7", a, b, c, d, tx, ty)
+	}
+	if a, b, c, d, tx, ty := Slanted(0.5).Matrix(); a != 1 || b != 0 || c != 0.5 || d != 1 || tx != 0 || ty != 0 {
+		t.Errorf("Slanted(0.5).Matrix() = %v %v %v %v %v %v, want 1 0 0.5 1 0 0", a, b, c, d, tx, ty)
+	}
+	if xx, xy, yx, yy := tr.Linear(); xx != 2 || xy != 0 || yx != 1 || yy != 3 {
+		t.Errorf("Linear() = %v %v %v %v, want 2 0 1 3", xx, xy, yx, yy)
+	}
+	if tx, ty := tr.Translation(); tx != 5 || ty != 7 {
+		t.Errorf("Translation() = %v %v, want 5 7", tx, ty)
+	}
+}
+
 func TestZScaled(t *testing.T) {
 	// ZScaled(0, 1) should rotate by 90 degrees
 	tr := ZScaled(0, 1)
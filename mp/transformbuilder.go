@@ -0,0 +1,117 @@
+package mp
+
+import "math"
+
+// TransformBuilder composes a Transform step by step, each step applied
+// after the previous ones as with Then. Rotations, scalings, slants and
+// reflections are made about the pivot set with About (the origin until
+// then), so the usual "rotated around this point, then scaled around that
+// one" needs no hand-written shifts.
+//
+// Example:
+//
+//	t := mp.NewTransformBuilder().About(mp.P(50, 50)).Rotate(30).Scale(2).
+//		Then(mp.Shifted(10, 0)).Transform()
+//	star = star.Transformed(t)
+type TransformBuilder struct {
+	t     Transform
+	pivot Point
+}
+
+// NewTransformBuilder returns a builder for the identity transformation
+// with the pivot at the origin.
+func NewTransformBuilder() *TransformBuilder {
+	return &TransformBuilder{t: Identity()}
+}
+
+// About sets the pivot of the following steps to p.
+func (b *TransformBuilder) About(p Point) *TransformBuilder {
+	b.pivot = p
+	return b
+}
+
+// Then appends the transformation t. It is not affected by the pivot.
+func (b *TransformBuilder) Then(t Transform) *TransformBuilder {
+	b.t = b.t.Then(t)
+	return b
+}
+
+// pivoted appends t made about the pivot.
+func (b *TransformBuilder) pivoted(t Transform) *TransformBuilder {
+	c := b.pivot
+	if c.X == 0 && c.Y == 0 {
+		return b.Then(t)
+	}
+	return b.Then(Shifted(-c.X, -c.Y).Then(t).Then(Shifted(c.X, c.Y)))
+}
+
+// Shift appends a shift by (dx, dy).
+func (b *TransformBuilder) Shift(dx, dy Number) *TransformBuilder {
+	return b.Then(Shifted(dx, dy))
+}
+
+// Rotate appends a rotation by angleDeg degrees counter-clockwise about
+// the pivot.
+func (b *TransformBuilder) Rotate(angleDeg Number) *TransformBuilder {
+	return b.pivoted(Rotated(angleDeg))
+}
+
+// Scale appends a uniform scaling by s about the pivot.
+func (b *TransformBuilder) Scale(s Number) *TransformBuilder {
+	return b.pivoted(Scaled(s))
+}
+
+// XScale appends a horizontal scaling by s about the pivot.
+func (b *TransformBuilder) XScale(s Number) *TransformBuilder {
+	return b.pivoted(XScaled(s))
+}
+
+// YScale appends a vertical scaling by s about the pivot.
+func (b *TransformBuilder) YScale(s Number) *TransformBuilder {
+	return b.pivoted(YScaled(s))
+}
+
+// ZScale appends MetaPost's "zscaled (a, b)" about the pivot.
+func (b *TransformBuilder) ZScale(a, c Number) *TransformBuilder {
+	return b.pivoted(ZScaled(a, c))
+}
+
+// Slant appends a horizontal shear by s about the pivot, which stays on
+// its line.
+func (b *TransformBuilder) Slant(s Number) *TransformBuilder {
+	return b.pivoted(Slanted(s))
+}
+
+// Reflect appends a reflection about the line through the pivot in
+// direction angleDeg.
+func (b *TransformBuilder) Reflect(angleDeg Number) *TransformBuilder {
+	sin, cos := math.Sincos(angleDeg * math.Pi / 180)
+	return b.pivoted(ReflectedAbout(0, 0, cos, sin))
+}
+
+// Transform returns the composed transformation.
+func (b *TransformBuilder) Transform() Transform {
+	return b.t
+}
+
+// AlignSegments returns the transformation that maps the segment from a1
+// to a2 onto the segment from b1 to b2: a1 goes to b1 and a2 to b2, by a
+// rotation, a uniform scaling and a shift, so shapes keep their
+// proportions. If a1 and a2 coincide it is the shift from a1 to b1.
+//
+// Example:
+//
+//	// Put an arrowhead drawn from (0,0) to (1,0) onto the edge from p to q.
+//	head = head.Transformed(mp.AlignSegments(mp.P(0, 0), mp.P(1, 0), p, q))
+func AlignSegments(a1, a2, b1, b2 Point) Transform {
+	ax, ay := a2.X-a1.X, a2.Y-a1.Y
+	d := ax*ax + ay*ay
+	if d == 0 {
+		return Shifted(b1.X-a1.X, b1.Y-a1.Y)
+	}
+	bx, by := b2.X-b1.X, b2.Y-b1.Y
+	// The complex quotient (b2-b1)/(a2-a1).
+	re := (bx*ax + by*ay) / d
+	im := (by*ax - bx*ay) / d
+	return Shifted(-a1.X, -a1.Y).Then(ZScaled(re, im)).Then(Shifted(b1.X, b1.Y))
+}
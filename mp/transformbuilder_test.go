@@ -0,0 +1,50 @@
+package mp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTransformBuilderAbout(t *testing.T) {
+	c := P(50, 50)
+	got := NewTransformBuilder().About(c).Rotate(30).Scale(2).Then(Shifted(10, 0)).Transform()
+	want := RotatedAround(50, 50, 30).Then(ScaledAround(50, 50, 2)).Then(Shifted(10, 0))
+	for _, pt := range []Point{P(0, 0), P(50, 50), P(80, 20)} {
+		gx, gy := got.ApplyToPoint(pt.X, pt.Y)
+		wx, wy := want.ApplyToPoint(pt.X, pt.Y)
+		if math.Abs(gx-wx) > 1e-9 || math.Abs(gy-wy) > 1e-9 {
+			t.Errorf("%v -> (%g,%g), want (%g,%g)", pt, gx, gy, wx, wy)
+		}
+	}
+	// The pivot stays fixed under everything but Then and Shift.
+	tr := NewTransformBuilder().About(c).Rotate(77).XScale(3).Slant(0.5).Reflect(20).Transform()
+	if x, y := tr.ApplyToPoint(50, 50); math.Abs(x-50) > 1e-9 || math.Abs(y-50) > 1e-9 {
+		t.Errorf("pivot moved to (%g,%g)", x, y)
+	}
+}
+
+func TestTransformBuilderReflect(t *testing.T) {
+	tr := NewTransformBuilder().About(P(0, 10)).Reflect(0).Transform()
+	if x, y := tr.ApplyToPoint(3, 14); math.Abs(x-3) > 1e-9 || math.Abs(y-6) > 1e-9 {
+		t.Errorf("reflected (3,14) to (%g,%g), want (3,6)", x, y)
+	}
+}
+
+func TestAlignSegments(t *testing.T) {
+	a1, a2 := P(0, 0), P(1, 0)
+	b1, b2 := P(10, 10), P(10, 13)
+	tr := AlignSegments(a1, a2, b1, b2)
+	for _, m := range [][2]Point{{a1, b1}, {a2, b2}, {P(0, 1), P(7, 10)}} {
+		x, y := tr.ApplyToPoint(m[0].X, m[0].Y)
+		if math.Abs(x-m[1].X) > 1e-9 || math.Abs(y-m[1].Y) > 1e-9 {
+			t.Errorf("%v -> (%g,%g), want %v", m[0], x, y, m[1])
+		}
+	}
+	if d := tr.Determinant(); math.Abs(d-9) > 1e-9 {
+		t.Errorf("determinant %g, want 9 (uniform scaling by 3)", d)
+	}
+	shift := AlignSegments(P(1, 1), P(1, 1), P(4, 5), P(0, 0))
+	if x, y := shift.ApplyToPoint(2, 2); x != 5 || y != 6 {
+		t.Errorf("degenerate segment: (2,2) -> (%g,%g), want (5,6)", x, y)
+	}
+}
@@ -0,0 +1,128 @@
+package mp
+
+import "math"
+
+// turningMaxDepth bounds the subdivision of a segment when its tangent
+// turns are summed.
+const turningMaxDepth = 12
+
+// TurningNumber returns how often the tangent of the cycle p turns around
+// a full circle while p is traversed once, counterclockwise positive.
+// Mirrors MetaPost's "turningnumber p": the turns inside the segments and
+// at the corners between them are added up, so a simple cycle gives 1 or
+// -1 and a figure eight 0. The turn at a cusp, where the direction
+// reverses, counts as +180 degrees. Open paths, single knots and cycles
+// whose segments all have length zero give 0.
+//
+// Example:
+//
+//	mp.FullCircle().TurningNumber()            // 1
+//	mp.FullCircle().Reversed().TurningNumber() // -1
+func (p *Path) TurningNumber() int {
+	if p == nil || p.Head == nil || p.Head.LType == KnotEndpoint {
+		return 0
+	}
+	var total Number
+	var first, prev Point
+	started := false
+	k := p.Head
+	for {
+		next := k.Next
+		if next == nil {
+			return 0
+		}
+		seg := [4]Point{{k.XCoord, k.YCoord}, {k.RightX, k.RightY}, {next.LeftX, next.LeftY}, {next.XCoord, next.YCoord}}
+		d0, d1 := cubicTangent(seg, 0), cubicTangent(seg, 1)
+		if d0 != (Point{}) {
+			if started {
+				total += tangentTurn(prev, d0)
+			} else {
+				first, started = d0, true
+			}
+			total += segmentTurn(seg, 0, 1, d0, d1, turningMaxDepth)
+			prev = d1
+		}
+		k = next
+		if k == p.Head {
+			break
+		}
+	}
+	if !started {
+		return 0
+	}
+	total += tangentTurn(prev, first)
+	return int(math.Round(total / (2 * math.Pi)))
+}
+
+// IsClockwise reports whether the cycle p runs clockwise, that is, whether
+// its turning number is negative.
+func (p *Path) IsClockwise() bool {
+	return p.TurningNumber() < 0
+}
+
+// EnsureCounterClockwise returns p if its turning number is positive and
+// the reversed path otherwise, like plain.mp's "counterclockwise p". Use it
+// before combining cycles whose orientation matters, e.g. outer outlines
+// and holes filled with the nonzero rule, or envelopes that must run
+// counterclockwise.
+func (p *Path) EnsureCounterClockwise() *Path {
+	if p == nil || p.TurningNumber() > 0 {
+		return p
+	}
+	return p.Reversed()
+}
+
+// cubicTangent returns the direction of the Bézier segment seg at time t.
+// Where the derivative vanishes at an end, because a control point
+// coincides with the knot, the direction towards the next distinct control
+// point is used, as MetaPost does for "direction t of p".
+func cubicTangent(seg [4]Point, t Number) Point {
+	mt := 1 - t
+	d := Point{
+		X: 3 * (mt*mt*(seg[1].X-seg[0].X) + 2*mt*t*(seg[2].X-seg[1].X) + t*t*(seg[3].X-seg[2].X)),
+		Y: 3 * (mt*mt*(seg[1].Y-seg[0].Y) + 2*mt*t*(seg[2].Y-seg[1].Y) + t*t*(seg[3].Y-seg[2].Y)),
+	}
+	if math.Hypot(d.X, d.Y) > 1e-12 {
+		return d
+	}
+	var candidates [][2]int
+	switch t {
+	case 0:
+		candidates = [][2]int{{2, 0}, {3, 0}}
+	case 1:
+		candidates = [][2]int{{3, 1}, {3, 0}}
+	}
+	for _, c := range candidates {
+		d = Point{seg[c[0]].X - seg[c[1]].X, seg[c[0]].Y - seg[c[1]].Y}
+		if math.Hypot(d.X, d.Y) > 1e-12 {
+			return d
+		}
+	}
+	return Point{}
+}
+
+// segmentTurn returns the angle the tangent of seg turns through between
+// times a and b, where it points along da and db, halving the interval
+// until each step turns by less than 45 degrees.
+func segmentTurn(seg [4]Point, a, b Number, da, db Point, depth int) Number {
+	m := (a + b) / 2
+	dm := cubicTangent(seg, m)
+	t1, t2 := tangentTurn(da, dm), tangentTurn(dm, db)
+	if depth == 0 || math.Abs(t1) < math.Pi/4 && math.Abs(t2) < math.Pi/4 {
+		return t1 + t2
+	}
+	return segmentTurn(seg, a, m, da, dm, depth-1) + segmentTurn(seg, m, b, dm, db, depth-1)
+}
+
+// tangentTurn returns the angle in (-pi, pi] from direction a to b, 0 if
+// either is zero.
+func tangentTurn(a, b Point) Number {
+	if a == (Point{}) || b == (Point{}) {
+		return 0
+	}
+	angle := math.Atan2(a.X*b.Y-a.Y*b.X, a.X*b.X+a.Y*b.Y)
+	if angle == -math.Pi {
+		angle = math.Pi
+	}
+	return angle
+}
@@ -0,0 +1,42 @@
+package mp
+
+import "testing"
+
+func TestTurningNumber(t *testing.T) {
+	square := straightPath([]Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, true)
+	// A figure eight: the two loops turn in opposite directions
+	eight := straightPath([]Point{{0, 0}, {10, 10}, {10, 0}, {0, 10}}, true)
+	// A pentagram runs around its center twice
+	var star []Point
+	for i := 0; i < 5; i++ {
+		x, y := Rotated(Number(144*i)).ApplyToPoint(10, 0)
+		star = append(star, Point{x, y})
+	}
+	tests := []struct {
+		name string
+		p    *Path
+		want int
+	}{
+		{"circle", FullCircle(), 1},
+		{"reversed circle", FullCircle().Reversed(), -1},
+		{"square", square, 1},
+		{"clockwise square", square.Reversed(), -1},
+		{"figure eight", eight, 0},
+		{"pentagram", straightPath(star, true), 2},
+		{"open path", straightPath([]Point{{0, 0}, {10, 0}, {10, 10}}, false), 0},
+	}
+	for _, tt := range tests {
+		if got := tt.p.TurningNumber(); got != tt.want {
+			t.Errorf("%s: turning number %d, want %d", tt.name, got, tt.want)
+		}
+	}
+	if square.IsClockwise() || !square.Reversed().IsClockwise() {
+		t.Error("IsClockwise disagrees with the orientation of the square")
+	}
+	if q := square.EnsureCounterClockwise(); q != square {
+		t.Error("a counterclockwise path should be returned unchanged")
+	}
+	if q := square.Reversed().EnsureCounterClockwise(); q.TurningNumber() != 1 {
+		t.Errorf("EnsureCounterClockwise gave turning number %d", q.TurningNumber())
+	}
+}
@@ -0,0 +1,145 @@
+package mp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidKnot is returned (wrapped in a *KnotError) when a path has a
+// knot with a NaN or infinite coordinate, control point, direction, curl or
+// tension, or a side whose KnotType is not one of the constants, or when
+// solving a path produces such a control point.
+var ErrInvalidKnot = errors.New("mp: invalid knot")
+
+// KnotError identifies the knot that makes a path unusable. Its Unwrap
+// method returns ErrInvalidKnot.
+type KnotError struct {
+	Path   int    // index of the path in the engine (order of AddPath), -1 if unknown
+	Knot   int    // index of the knot, counting from the path's Head
+	Field  string // the offending value, e.g. "x" or "right tension"
+	Value  Number
+	Solved bool // the value was computed by the solver, not given
+}
+
+func (e *KnotError) Error() string {
+	path := ""
+	if e.Path >= 0 {
+		path = fmt.Sprintf("path %d, ", e.Path)
+	}
+	if e.Solved {
+		return fmt.Sprintf("%v: %sknot %d: solver computed %s = %g", ErrInvalidKnot, path, e.Knot, e.Field, e.Value)
+	}
+	return fmt.Sprintf("%v: %sknot %d: %s is %g", ErrInvalidKnot, path, e.Knot, e.Field, e.Value)
+}
+
+func (e *KnotError) Unwrap() error { return ErrInvalidKnot }
+
+// finite reports whether v is neither NaN nor infinite.
+func finite(v Number) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// Validate checks that every value of p the solver reads is usable: the
+// type of each side is a KnotType constant, and the coordinates of each
+// knot and, depending on the type of each side, its explicit control
+// point, given direction, curl and tension are finite. It returns a
+// *KnotError for the first offending knot, or nil. Engine.AddPath runs it
+// for every path, so Solve fails instead of producing NaN control points.
+func (p *Path) Validate() error {
+	if p == nil || p.Head == nil {
+		return nil
+	}
+	i := 0
+	for k := p.Head; ; i++ {
+		if err := validateKnot(k, i); err != nil {
+			return err
+		}
+		k = k.Next
+		if k == nil || k == p.Head {
+			return nil
+		}
+	}
+}
+
+func validateKnot(k *Knot, i int) error {
+	bad := func(field string, v Number) error {
+		return &KnotError{Path: -1, Knot: i, Field: field, Value: v}
+	}
+	if !finite(k.XCoord) {
+		return bad("x", k.XCoord)
+	}
+	if !finite(k.YCoord) {
+		return bad("y", k.YCoord)
+	}
+	sides := []struct {
+		name string
+		typ  KnotType
+		x, y Number
+	}{
+		{"left", k.LType, k.LeftX, k.LeftY},
+		{"right", k.RType, k.RightX, k.RightY},
+	}
+	for _, s := range sides {
+		if !s.typ.Valid() {
+			return bad(s.name+" type", Number(s.typ))
+		}
+		switch s.typ {
+		case KnotExplicit:
+			if !finite(s.x) {
+				return bad(s.name+" control x", s.x)
+			}
+			if !finite(s.y) {
+				return bad(s.name+" control y", s.y)
+			}
+		case KnotGiven:
+			if !finite(s.x) {
+				return bad(s.name+" direction", s.x)
+			}
+		case KnotCurl:
+			if !finite(s.x) {
+				return bad(s.name+" curl", s.x)
+			}
+		}
+		if s.typ >= KnotGiven && !finite(s.y) {
+			return bad(s.name+" tension", s.y)
+		}
+	}
+	return nil
+}
+
+// checkControls returns the knot and field of the first non-finite control
+// point on the n segments starting at p, as left by solveChoices, or nil.
+func checkControls(p *Knot, n int) (*Knot, string, Number) {
+	k := p
+	for i := 0; i < n; i++ {
+		next := k.Next
+		switch {
+		case !finite(k.RightX):
+			return k, "right control x", k.RightX
+		case !finite(k.RightY):
+			return k, "right control y", k.RightY
+		case !finite(next.LeftX):
+			return next, "left control x", next.LeftX
+		case !finite(next.LeftY):
+			return next, "left control y", next.LeftY
+		}
+		k = next
+	}
+	return nil, "", 0
+}
+
+// knotIndex returns the index of k counting from head, or -1.
+func knotIndex(head, k *Knot) int {
+	i := 0
+	for cur := head; cur != nil; i++ {
+		if cur == k {
+			return i
+		}
+		cur = cur.Next
+		if cur == head {
+			break
+		}
+	}
+	return -1
+}
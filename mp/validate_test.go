@@ -0,0 +1,117 @@
+package mp
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// openPath returns an open path through pts with open knots of tension 1,
+// ready for the solver.
+func openPath(pts ...Point) *Path {
+	p := NewPath()
+	for _, pt := range pts {
+		p.Append(NewKnotAt(pt.X, pt.Y))
+	}
+	p.Head.LType = KnotEndpoint
+	p.Head.Prev.RType = KnotEndpoint
+	return p
+}
+
+func TestValidate(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	tests := []struct {
+		name  string
+		set   func(p *Path)
+		knot  int
+		field string
+	}{
+		{"x", func(p *Path) { p.Head.Next.XCoord = nan }, 1, "x"},
+		{"y", func(p *Path) { p.Head.Prev.YCoord = inf }, 2, "y"},
+		{"tension", func(p *Path) { p.Head.Next.SetTension(1, inf) }, 1, "right tension"},
+		{"curl", func(p *Path) { p.Head.SetCurlOut(nan) }, 0, "right curl"},
+		{"direction", func(p *Path) { p.Head.Next.SetDirectionIn(inf) }, 1, "left direction"},
+		{"explicit control", func(p *Path) {
+			k := p.Head.Next
+			k.LType, k.LeftX, k.LeftY = KnotExplicit, 1, nan
+		}, 1, "left control y"},
+	}
+	for _, tc := range tests {
+		p := openPath(P(0, 0), P(10, 10), P(20, 0))
+		if err := p.Validate(); err != nil {
+			t.Fatalf("%s: valid path: %v", tc.name, err)
+		}
+		tc.set(p)
+		err := p.Validate()
+		var ke *KnotError
+		if !errors.As(err, &ke) || !errors.Is(err, ErrInvalidKnot) {
+			t.Fatalf("%s: want *KnotError, got %v", tc.name, err)
+		}
+		if ke.Knot != tc.knot || ke.Field != tc.field || ke.Solved {
+			t.Errorf("%s: got knot %d field %q solved %v, want knot %d field %q", tc.name, ke.Knot, ke.Field, ke.Solved, tc.knot, tc.field)
+		}
+	}
+
+	// Unused values of a side are not checked.
+	p := openPath(P(0, 0), P(10, 10))
+	p.Head.LeftX, p.Head.Next.RightY = nan, inf // endpoint sides
+	if err := p.Validate(); err != nil {
+		t.Errorf("endpoint sides should be ignored: %v", err)
+	}
+}
+
+func TestEngineRejectsInvalidPath(t *testing.T) {
+	good := openPath(P(0, 0), P(10, 10))
+	bad := openPath(P(0, 0), P(math.NaN(), 10), P(20, 0))
+	e := NewEngine()
+	e.AddPath(good)
+	e.AddPath(bad)
+	err := e.Solve()
+	var ke *KnotError
+	if !errors.As(err, &ke) {
+		t.Fatalf("want *KnotError, got %v", err)
+	}
+	if ke.Path != 1 || ke.Knot != 1 || ke.Field != "x" {
+		t.Errorf("got path %d knot %d field %q, want path 1 knot 1 field x", ke.Path, ke.Knot, ke.Field)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "path 1, knot 1: x is NaN") {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if good.Head.RType != KnotOpen {
+		t.Error("no path should be solved when one is invalid")
+	}
+}
+
+func TestSolverReportsOverflow(t *testing.T) {
+	// Finite input whose chords overflow to infinity.
+	p := openPath(P(-1e308, 0), P(1e308, 0), P(1e308, 1e308))
+	e := NewEngine()
+	e.AddPath(p)
+	err := e.Solve()
+	var ke *KnotError
+	if !errors.As(err, &ke) {
+		t.Fatalf("want *KnotError, got %v", err)
+	}
+	if !ke.Solved || ke.Path != 0 || ke.Knot < 0 {
+		t.Errorf("got %+v, want a solved value on path 0", ke)
+	}
+	if !strings.Contains(err.Error(), "solver computed") {
+		t.Errorf("unexpected message %q", err)
+	}
+}
+
+func TestValidateKnotType(t *testing.T) {
+	p := openPath(P(0, 0), P(10, 0))
+	p.Head.Next.LType = KnotType(42)
+	var ke *KnotError
+	if err := p.Validate(); !errors.As(err, &ke) || ke.Knot != 1 || ke.Field != "left type" {
+		t.Fatalf("got %v, want an invalid left type at knot 1", err)
+	}
+	if got := KnotType(42).String(); got != "KnotType(42)" {
+		t.Errorf("String = %q", got)
+	}
+	if got := KnotExplicit.String(); got != "explicit" {
+		t.Errorf("String = %q", got)
+	}
+}
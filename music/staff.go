@@ -0,0 +1,335 @@
+// Package music draws simple music notation: staves, note heads, stems,
+// flags, beams and slurs.
+//
+// Positions on a staff are given in staff steps: step 0 is the bottom line,
+// step 1 the space above it, step 8 the top line. Steps outside 0..8 get
+// ledger lines. Slurs and ties are Hobby curves, so their shape is
+// controlled by tension just like a MetaPost "a..tension t..b".
+//
+// Example:
+//
+//	s := music.NewStaff(0, 0, 200)
+//	a := s.Note(20, 2, music.Eighth)
+//	b := s.Note(40, 4, music.Eighth)
+//	s.Beam(a, b)
+//	c := s.Note(70, 6, music.Half)
+//	s.Slur(a, c, 1.2)
+//	pic := s.Picture()
+package music
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Duration is a note value.
+type Duration int
+
+const (
+	Whole Duration = iota
+	Half
+	Quarter
+	Eighth
+	Sixteenth
+)
+
+// DefaultStaffSpace is the distance between two staff lines in bp.
+const DefaultStaffSpace = 6.0
+
+// Proportions relative to the staff space, following common engraving
+// practice.
+const (
+	headWidth      = 1.3  // note head width
+	headHeight     = 0.9  // note head height
+	headAngle      = 20.0 // tilt of filled and half note heads in degrees
+	stemLength     = 3.5  // natural stem length
+	minBeamedStem  = 2.5  // shortest stem under a beam
+	beamThickness  = 0.5  // thickness of a beam
+	beamGap        = 0.75 // distance between successive beams
+	maxBeamSlope   = 0.15 // steepest beam slope
+	ledgerOverhang = 0.4  // ledger line extent beyond the note head
+	lineWidth      = 0.1  // staff and ledger lines
+	stemWidth      = 0.12 // stems
+	hollowWidth    = 0.15 // outline of half and whole note heads
+	slurClearance  = 0.8  // distance of slur ends from the note center
+)
+
+// Staff is a five-line staff with notes, beams and slurs. Its bottom line
+// starts at (X, Y).
+type Staff struct {
+	X, Y  float64
+	Width float64
+	Space float64  // distance between staff lines (default DefaultStaffSpace)
+	Color mp.Color // drawing color (default black)
+
+	notes []*Note
+	beams [][]*Note
+	slurs []slur
+}
+
+// Note is a note head with stem and flags, placed at a horizontal position
+// and a staff step.
+type Note struct {
+	X        float64
+	Step     int
+	Duration Duration
+	// StemUp is the stem direction; it defaults to up below the middle
+	// line and is set per group by Beam.
+	StemUp bool
+
+	beam  []*Note // beam group, if any
+	stemY float64 // y of the stem end, set while drawing
+}
+
+type slur struct {
+	a, b    *Note
+	tension float64
+}
+
+// NewStaff creates a staff whose bottom line starts at (x, y).
+func NewStaff(x, y, width float64) *Staff {
+	return &Staff{X: x, Y: y, Width: width, Space: DefaultStaffSpace, Color: mp.ColorCSS("black")}
+}
+
+// StepY returns the y coordinate of a staff step.
+func (s *Staff) StepY(step int) float64 {
+	return s.Y + float64(step)*s.Space/2
+}
+
+// Note adds a note at horizontal position x (relative to the staff start)
+// and the given staff step.
+func (s *Staff) Note(x float64, step int, d Duration) *Note {
+	n := &Note{X: s.X + x, Step: step, Duration: d, StemUp: step < 4}
+	s.notes = append(s.notes, n)
+	return n
+}
+
+// Beam joins the stems of consecutive eighth or shorter notes with beams
+// instead of flags. All notes of the group get the stem direction that suits
+// their average position.
+func (s *Staff) Beam(notes ...*Note) {
+	if len(notes) < 2 {
+		return
+	}
+	sum := 0
+	for _, n := range notes {
+		sum += n.Step
+	}
+	up := float64(sum)/float64(len(notes)) < 4
+	group := append([]*Note(nil), notes...)
+	for _, n := range group {
+		n.StemUp = up
+		n.beam = group
+	}
+	s.beams = append(s.beams, group)
+}
+
+// Slur adds a slur (or tie) from note a to note b. It bows away from the
+// stems; tension controls how full the curve is (values below 1 give a
+// rounder, larger ones a flatter slur; 0 means 1).
+func (s *Staff) Slur(a, b *Note, tension float64) {
+	if tension == 0 {
+		tension = 1
+	}
+	s.slurs = append(s.slurs, slur{a: a, b: b, tension: tension})
+}
+
+// Picture returns the staff with all notes, beams and slurs.
+func (s *Staff) Picture() *draw.Picture {
+	pic := draw.NewPicture()
+	line := mp.Style{Stroke: s.color(), StrokeWidth: lineWidth * s.Space}
+	for i := 0; i < 5; i++ {
+		y := s.StepY(2 * i)
+		pic.AddPath(s.line(line, mp.P(s.X, y), mp.P(s.X+s.Width, y)))
+	}
+	for _, g := range s.beams {
+		s.placeBeam(g)
+	}
+	for _, n := range s.notes {
+		s.drawNote(pic, n)
+	}
+	for _, g := range s.beams {
+		s.drawBeams(pic, g)
+	}
+	for _, sl := range s.slurs {
+		pic.AddPath(s.slurPath(sl))
+	}
+	return pic
+}
+
+func (s *Staff) color() mp.Color {
+	if s.Color.CSS() == "" {
+		return mp.ColorCSS("black")
+	}
+	return s.Color
+}
+
+// line returns a straight path from p to q, or nil if a point is not
+// finite (Picture.AddPath skips nil paths).
+func (s *Staff) line(style mp.Style, p, q mp.Point) *mp.Path {
+	path, err := mp.StraightPath([]mp.Point{p, q}, false)
+	if err != nil {
+		return nil
+	}
+	path.Style = style
+	return path
+}
+
+// stemX returns the x coordinate of the note's stem.
+func (s *Staff) stemX(n *Note) float64 {
+	dx := (headWidth*s.Space - stemWidth*s.Space) / 2
+	if n.StemUp {
+		return n.X + dx
+	}
+	return n.X - dx
+}
+
+// drawNote adds ledger lines, head, stem and flags of n.
+func (s *Staff) drawNote(pic *draw.Picture, n *Note) {
+	y := s.StepY(n.Step)
+	sp := s.Space
+	ledger := mp.Style{Stroke: s.color(), StrokeWidth: lineWidth * sp}
+	hw := (headWidth/2 + ledgerOverhang) * sp
+	for step := -2; step >= n.Step; step -= 2 {
+		pic.AddPath(s.line(ledger, mp.P(n.X-hw, s.StepY(step)), mp.P(n.X+hw, s.StepY(step))))
+	}
+	for step := 10; step <= n.Step; step += 2 {
+		pic.AddPath(s.line(ledger, mp.P(n.X-hw, s.StepY(step)), mp.P(n.X+hw, s.StepY(step))))
+	}
+
+	// Head: a tilted ellipse, filled for quarters and shorter.
+	w, h, angle := headWidth*sp, headHeight*sp, headAngle
+	if n.Duration == Whole {
+		w, angle = 1.6*sp, 0
+	}
+	head := mp.XScaled(w).Then(mp.YScaled(h)).Then(mp.Rotated(angle)).
+		Then(mp.Shifted(n.X, y)).ApplyToPath(mp.FullCircle())
+	if n.Duration >= Quarter {
+		head.Style = mp.Style{Fill: s.color(), Stroke: mp.ColorCSS("none")}
+	} else {
+		head.Style = mp.Style{Stroke: s.color(), StrokeWidth: hollowWidth * sp}
+	}
+	pic.AddPath(head)
+	if n.Duration == Whole {
+		return
+	}
+
+	dir := 1.0
+	if !n.StemUp {
+		dir = -1
+	}
+	if n.beam == nil {
+		n.stemY = y + dir*stemLength*sp
+	}
+	stem := mp.Style{Stroke: s.color(), StrokeWidth: stemWidth * sp}
+	x := s.stemX(n)
+	pic.AddPath(s.line(stem, mp.P(x, y), mp.P(x, n.stemY)))
+
+	if n.beam != nil {
+		return
+	}
+	// Flags hang from the stem end towards the note head.
+	flag := mp.Style{Stroke: s.color(), StrokeWidth: 1.5 * stemWidth * sp}
+	for i := 0; i < int(n.Duration-Quarter); i++ {
+		top := n.stemY - dir*float64(i)*beamGap*sp
+		path, _ := draw.NewPath().
+			MoveTo(mp.P(x, top)).
+			WithDirection(-90 * dir).
+			CurveTo(mp.P(x+0.9*sp, top-dir*1.6*sp)).
+			WithIncomingDirection(-90 * dir).
+			CurveTo(mp.P(x+0.7*sp, top-dir*2.8*sp)).
+			Solve()
+		path.Style = flag
+		pic.AddPath(path)
+	}
+}
+
+// placeBeam sets the stem ends of a beam group: a straight line through the
+// natural stem ends with limited slope, moved away from the heads until
+// every stem is at least minBeamedStem long.
+func (s *Staff) placeBeam(g []*Note) {
+	sp := s.Space
+	dir := 1.0
+	if !g[0].StemUp {
+		dir = -1
+	}
+	first, last := g[0], g[len(g)-1]
+	x0, x1 := s.stemX(first), s.stemX(last)
+	y0 := s.StepY(first.Step) + dir*stemLength*sp
+	y1 := s.StepY(last.Step) + dir*stemLength*sp
+	slope := 0.0
+	if x1 != x0 {
+		slope = math.Max(-maxBeamSlope, math.Min(maxBeamSlope, (y1-y0)/(x1-x0)))
+	}
+	shift := 0.0
+	for _, n := range g {
+		beamY := y0 + slope*(s.stemX(n)-x0)
+		need := s.StepY(n.Step) + dir*minBeamedStem*sp
+		if d := (need - beamY) * dir; d > shift {
+			shift = d
+		}
+	}
+	for _, n := range g {
+		n.stemY = y0 + dir*shift + slope*(s.stemX(n)-x0)
+	}
+}
+
+// drawBeams adds the beams of a group: one for eighths, a second one for
+// sixteenths.
+func (s *Staff) drawBeams(pic *draw.Picture, g []*Note) {
+	sp := s.Space
+	dir := 1.0
+	if !g[0].StemUp {
+		dir = -1
+	}
+	levels := 0
+	for _, n := range g {
+		levels = max(levels, int(n.Duration-Quarter))
+	}
+	first, last := g[0], g[len(g)-1]
+	x0, x1 := s.stemX(first)-stemWidth*sp/2, s.stemX(last)+stemWidth*sp/2
+	slope := 0.0
+	if sx0, sx1 := s.stemX(first), s.stemX(last); sx1 != sx0 {
+		slope = (last.stemY - first.stemY) / (sx1 - sx0)
+	}
+	yAt := func(x float64) float64 { return first.stemY + slope*(x-s.stemX(first)) }
+	for lvl := 0; lvl < levels; lvl++ {
+		off := -dir * float64(lvl) * beamGap * sp
+		t := -dir * beamThickness * sp
+		path, _ := draw.NewPath().
+			MoveTo(mp.P(x0, yAt(x0)+off)).
+			LineTo(mp.P(x1, yAt(x1)+off)).
+			LineTo(mp.P(x1, yAt(x1)+off+t)).
+			LineTo(mp.P(x0, yAt(x0)+off+t)).
+			Close().
+			Solve()
+		path.Style = mp.Style{Fill: s.color(), Stroke: mp.ColorCSS("none")}
+		pic.AddPath(path)
+	}
+}
+
+// slurPath returns the slur as a Hobby curve through its two ends and a
+// raised middle point.
+func (s *Staff) slurPath(sl slur) *mp.Path {
+	sp := s.Space
+	// Slurs go on the head side when both stems point up, else above.
+	dir := 1.0
+	if sl.a.StemUp && sl.b.StemUp {
+		dir = -1
+	}
+	p := mp.P(sl.a.X, s.StepY(sl.a.Step)+dir*slurClearance*sp)
+	q := mp.P(sl.b.X, s.StepY(sl.b.Step)+dir*slurClearance*sp)
+	height := math.Max(sp, math.Min(3*sp, 0.15*math.Abs(q.X-p.X)))
+	mid := mp.P((p.X+q.X)/2, (p.Y+q.Y)/2+dir*height)
+	path, _ := draw.NewPath().
+		MoveTo(p).
+		WithTension(sl.tension).
+		CurveTo(mid).
+		WithTension(sl.tension).
+		CurveTo(q).
+		Solve()
+	path.Style = mp.Style{Stroke: s.color(), StrokeWidth: 1.5 * stemWidth * sp}
+	return path
+}
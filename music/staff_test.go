@@ -0,0 +1,70 @@
+package music
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStaffLinesAndLedgers(t *testing.T) {
+	s := NewStaff(0, 0, 100)
+	s.Note(20, -4, Whole) // two ledger lines below
+	s.Note(50, 4, Whole)  // middle line, none
+	pic := s.Picture()
+	// 5 staff lines, 2 ledger lines, 2 heads.
+	if got := len(pic.Paths()); got != 9 {
+		t.Errorf("expected 9 paths, got %d", got)
+	}
+	if y := s.StepY(8); y != 4*DefaultStaffSpace {
+		t.Errorf("top line at %g", y)
+	}
+}
+
+func TestStaffNotFinite(t *testing.T) {
+	if got := len(NewStaff(0, 0, math.Inf(1)).Picture().Paths()); got != 0 {
+		t.Errorf("expected no staff lines of infinite width, got %d paths", got)
+	}
+}
+
+func TestBeamStemsReachBeam(t *testing.T) {
+	s := NewStaff(0, 0, 100)
+	a := s.Note(10, 0, Eighth)
+	b := s.Note(25, 5, Sixteenth)
+	c := s.Note(40, 2, Eighth)
+	s.Beam(a, b, c)
+	// Filled heads and beams have no outline.
+	for _, p := range s.Picture().Paths() {
+		if fill := p.Style.Fill.CSS(); fill != "" && fill != "none" && p.Style.Stroke.CSS() != "none" {
+			t.Errorf("filled path stroked with %q", p.Style.Stroke.CSS())
+		}
+	}
+	if !a.StemUp || !b.StemUp || !c.StemUp {
+		t.Fatal("low group should have stems up")
+	}
+	for _, n := range []*Note{a, b, c} {
+		if l := (n.stemY - s.StepY(n.Step)) / s.Space; l < minBeamedStem-1e-9 {
+			t.Errorf("stem of step %d only %g spaces long", n.Step, l)
+		}
+	}
+	slope := (c.stemY - a.stemY) / (s.stemX(c) - s.stemX(a))
+	if math.Abs(slope) > maxBeamSlope+1e-9 {
+		t.Errorf("beam slope %g too steep", slope)
+	}
+}
+
+func TestSlurBowsAwayFromStems(t *testing.T) {
+	s := NewStaff(0, 0, 100)
+	a := s.Note(10, 6, Quarter) // stem down
+	b := s.Note(60, 7, Quarter)
+	s.Slur(a, b, 1)
+	path := s.slurPath(s.slurs[0])
+	_, midY := path.PointOf(1)
+	if midY <= s.StepY(7) {
+		t.Errorf("slur over stem-down notes should bow upwards, apex at %g", midY)
+	}
+	flat := s.slurPath(slur{a: a, b: b, tension: 3})
+	_, cy := flat.PostcontrolOf(0)
+	_, ry := path.PostcontrolOf(0)
+	if cy >= ry {
+		t.Errorf("higher tension should flatten the slur: %g >= %g", cy, ry)
+	}
+}
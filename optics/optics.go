@@ -0,0 +1,278 @@
+// Package optics draws ray diagrams: rays traced through mirrors, refracting
+// interfaces and ideal thin lenses.
+//
+// Surfaces are ordinary paths, usually straight segments or circular arcs
+// built with [Segment] and [Arc]. Rays are traced with the path intersection
+// API; the surface normal at a hit comes from the path direction there.
+//
+// Example:
+//
+//	s := optics.NewScene()
+//	s.Add(optics.Mirror(optics.Arc(mp.P(100, 0), 80, 150, 210)))
+//	s.Add(optics.ThinLens(mp.P(0, -30), mp.P(0, 30), 50))
+//	ray := s.Trace(mp.P(-50, 10), 0)
+package optics
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Kind selects how a surface acts on rays.
+type Kind int
+
+const (
+	Reflect Kind = iota // mirror
+	Refract             // interface between two media (Snell's law)
+	Lens                // ideal thin lens along a straight segment
+	Absorb              // screen or stop; rays end here
+)
+
+// Surface is an optical surface along a path.
+type Surface struct {
+	Path *mp.Path
+	Kind Kind
+	// N1 and N2 are the refractive indices left and right of the path's
+	// direction of travel (Refract only).
+	N1, N2 float64
+	// Focal is the focal length of a thin lens; negative for diverging
+	// lenses (Lens only).
+	Focal float64
+}
+
+// Mirror returns a reflecting surface along path.
+func Mirror(path *mp.Path) *Surface {
+	return &Surface{Path: path, Kind: Reflect}
+}
+
+// Interface returns a refracting surface along path with index n1 on its
+// left and n2 on its right, seen in the path's direction of travel.
+func Interface(path *mp.Path, n1, n2 float64) *Surface {
+	return &Surface{Path: path, Kind: Refract, N1: n1, N2: n2}
+}
+
+// ThinLens returns an ideal thin lens from a to b with the given focal
+// length: a ray hitting it at distance h from the lens center leaves with
+// its slope against the lens axis changed by -h/f.
+func ThinLens(a, b mp.Point, focal float64) *Surface {
+	return &Surface{Path: Segment(a, b), Kind: Lens, Focal: focal}
+}
+
+// Screen returns an absorbing surface along path.
+func Screen(path *mp.Path) *Surface {
+	return &Surface{Path: path, Kind: Absorb}
+}
+
+// Segment returns the straight path from a to b, or nil if a point is not
+// finite. Surfaces without a path are never hit.
+func Segment(a, b mp.Point) *mp.Path {
+	path, err := mp.StraightPath([]mp.Point{a, b}, false)
+	if err != nil {
+		return nil
+	}
+	return path
+}
+
+// Arc returns the circular arc around center with the given radius from
+// angle from to angle to (degrees, counterclockwise), built from MetaPost's
+// fullcircle.
+func Arc(center mp.Point, radius, from, to float64) *mp.Path {
+	sweep := math.Mod(to-from, 360)
+	if sweep <= 0 {
+		sweep += 360
+	}
+	arc := mp.FullCircle().Subpath(0, sweep/45)
+	t := mp.Scaled(2 * radius).Then(mp.Rotated(from)).Then(mp.Shifted(center.X, center.Y))
+	return t.ApplyToPath(arc)
+}
+
+// PrincipalAxis returns the dash-dotted optical axis from a to b.
+func PrincipalAxis(a, b mp.Point) *mp.Path {
+	axis := Segment(a, b)
+	axis.Style = mp.Style{
+		Stroke:      mp.ColorCSS("black"),
+		StrokeWidth: 0.3,
+		Dash:        mp.NewDashPattern(6, 2, 1, 2),
+	}
+	return axis
+}
+
+// Scene is a set of surfaces rays are traced through.
+type Scene struct {
+	Surfaces []*Surface
+	// Length is how far a ray continues after its last interaction
+	// (default 200). MaxBounces limits the number of interactions
+	// (default 20).
+	Length     float64
+	MaxBounces int
+	Style      mp.Style // ray style; an end arrow is added
+}
+
+// NewScene creates an empty scene.
+func NewScene() *Scene {
+	return &Scene{
+		Length:     200,
+		MaxBounces: 20,
+		Style:      mp.Style{Stroke: mp.ColorCSS("black"), StrokeWidth: 0.5},
+	}
+}
+
+// Add adds a surface to the scene.
+func (s *Scene) Add(surface *Surface) *Surface {
+	s.Surfaces = append(s.Surfaces, surface)
+	return surface
+}
+
+// Trace follows a ray from origin in direction dir (degrees) and returns its
+// polyline with an arrowhead at the end. Total internal reflection is
+// handled at refracting surfaces. A NaN or infinite origin or direction
+// gives nil.
+func (s *Scene) Trace(origin mp.Point, dir float64) *mp.Path {
+	rad := dir * math.Pi / 180
+	dx, dy := math.Cos(rad), math.Sin(rad)
+	pts := []mp.Point{origin}
+	p := origin
+	length := s.Length
+	if length <= 0 {
+		length = 200
+	}
+	maxBounces := s.MaxBounces
+	if maxBounces <= 0 {
+		maxBounces = 20
+	}
+	for bounce := 0; bounce <= maxBounces; bounce++ {
+		ray := Segment(p, mp.P(p.X+dx*length, p.Y+dy*length))
+		if ray == nil {
+			break
+		}
+		skip := mp.Number(-1)
+		if bounce > 0 {
+			// The ray starts on the surface it just left.
+			skip = 0
+		}
+		hit, surface, t2, ok := s.firstHit(ray, skip)
+		if !ok {
+			pts = append(pts, mp.P(p.X+dx*length, p.Y+dy*length))
+			break
+		}
+		pts = append(pts, hit)
+		p = hit
+		var alive bool
+		dx, dy, alive = surface.redirect(dx, dy, t2, hit)
+		if !alive {
+			break
+		}
+		if bounce == maxBounces {
+			pts = append(pts, mp.P(p.X+dx*length, p.Y+dy*length))
+		}
+	}
+	path, err := mp.StraightPath(pts, false)
+	if err != nil || path.PathLength() == 0 {
+		return nil
+	}
+	path.Style = s.Style
+	path.Style.Arrow = mp.ArrowStyle{End: true, Length: mp.DefaultAHLength, Angle: mp.DefaultAHAngle}
+	return path
+}
+
+// firstHit returns the nearest intersection of ray with any surface after
+// time tMin on the ray.
+func (s *Scene) firstHit(ray *mp.Path, tMin mp.Number) (hit mp.Point, surface *Surface, t2 mp.Number, ok bool) {
+	best := mp.Number(math.Inf(1))
+	for _, sf := range s.Surfaces {
+		if sf == nil || sf.Path == nil {
+			continue
+		}
+		t1, tq := ray.IntersectionTimesAfter(sf.Path, tMin)
+		if t1 >= 0 && t1 < best {
+			best, surface, t2 = t1, sf, tq
+		}
+	}
+	if surface == nil {
+		return mp.Point{}, nil, 0, false
+	}
+	t2 = refineHit(ray, surface.Path, t2)
+	x, y := surface.Path.PointOf(t2)
+	return mp.P(x, y), surface, t2, true
+}
+
+// refineHit improves the intersection time t on surface with Newton steps
+// on the signed distance of the surface point from the ray's line. The
+// bisection in IntersectionTimes is only accurate to about 1e-3 in time,
+// which is visible on long surfaces.
+func refineHit(ray, surface *mp.Path, t mp.Number) mp.Number {
+	px, py := ray.PointOf(0)
+	qx, qy := ray.PointOf(1)
+	dx, dy := qx-px, qy-py
+	dist := func(t mp.Number) float64 {
+		x, y := surface.PointOf(t)
+		return (x-px)*dy - (y-py)*dx
+	}
+	lo := math.Floor(t)
+	hi := math.Min(lo+1, mp.Number(surface.PathLength()))
+	const h = 1e-6
+	for i := 0; i < 8; i++ {
+		f := dist(t)
+		df := (dist(t+h) - dist(t-h)) / (2 * h)
+		if df == 0 {
+			break
+		}
+		next := t - f/df
+		if next < lo || next > hi {
+			break
+		}
+		t = next
+	}
+	return t
+}
+
+// redirect returns the ray direction after interacting with the surface at
+// time t on its path, and false if the ray ends there.
+func (sf *Surface) redirect(dx, dy float64, t mp.Number, hit mp.Point) (float64, float64, bool) {
+	tx, ty := sf.Path.DirectionOf(t)
+	tl := math.Hypot(tx, ty)
+	if tl == 0 {
+		return dx, dy, false
+	}
+	tx, ty = tx/tl, ty/tl
+	// Left normal of the surface.
+	nx, ny := -ty, tx
+	dn := dx*nx + dy*ny
+	switch sf.Kind {
+	case Reflect:
+		return dx - 2*dn*nx, dy - 2*dn*ny, true
+	case Refract:
+		// Travelling against the left normal means coming from the left.
+		n1, n2 := sf.N1, sf.N2
+		if dn > 0 {
+			n1, n2 = n2, n1
+			nx, ny, dn = -nx, -ny, -dn
+		}
+		eta := n1 / n2
+		cosI := -dn
+		k := 1 - eta*eta*(1-cosI*cosI)
+		if k < 0 {
+			// Total internal reflection.
+			return dx - 2*dn*nx, dy - 2*dn*ny, true
+		}
+		c := eta*cosI - math.Sqrt(k)
+		return eta*dx + c*nx, eta*dy + c*ny, true
+	case Lens:
+		if dn == 0 || sf.Focal == 0 {
+			return dx, dy, true
+		}
+		sx, sy := sf.Path.PointOf(0)
+		ex, ey := sf.Path.PointOf(mp.Number(sf.Path.PathLength()))
+		h := (hit.X-(sx+ex)/2)*tx + (hit.Y-(sy+ey)/2)*ty
+		// Work against the lens axis oriented along the ray.
+		if dn < 0 {
+			nx, ny, dn = -nx, -ny, -dn
+		}
+		slope := (dx*tx+dy*ty)/dn - h/sf.Focal
+		ox, oy := nx+slope*tx, ny+slope*ty
+		l := math.Hypot(ox, oy)
+		return ox / l, oy / l, true
+	}
+	return dx, dy, false
+}
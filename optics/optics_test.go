@@ -0,0 +1,85 @@
+package optics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// exitDirection returns the direction of the last segment of a traced ray.
+func exitDirection(ray *mp.Path) (float64, float64) {
+	n := float64(ray.PathLength())
+	x0, y0 := ray.PointOf(n - 1)
+	x1, y1 := ray.PointOf(n)
+	l := math.Hypot(x1-x0, y1-y0)
+	return (x1 - x0) / l, (y1 - y0) / l
+}
+
+func TestMirrorReflection(t *testing.T) {
+	s := NewScene()
+	s.Add(Mirror(Segment(mp.P(50, -50), mp.P(50, 50))))
+	ray := s.Trace(mp.P(0, 0), 30)
+	if ray.PathLength() != 2 {
+		t.Fatalf("expected one bounce, got %d segments", ray.PathLength())
+	}
+	dx, dy := exitDirection(ray)
+	want := 150 * math.Pi / 180
+	if math.Abs(dx-math.Cos(want)) > 1e-3 || math.Abs(dy-math.Sin(want)) > 1e-3 {
+		t.Errorf("reflected direction (%g,%g), want 150°", dx, dy)
+	}
+}
+
+func TestRefractionSnell(t *testing.T) {
+	s := NewScene()
+	// Upwards path: left side (x < 50) is air, right side glass.
+	s.Add(Interface(Segment(mp.P(50, -100), mp.P(50, 100)), 1, 1.5))
+	ray := s.Trace(mp.P(0, 0), 30)
+	_, dy := exitDirection(ray)
+	if got, want := dy, math.Sin(30*math.Pi/180)/1.5; math.Abs(got-want) > 1e-3 {
+		t.Errorf("sin of refraction angle %g, want %g", got, want)
+	}
+
+	// Leaving the glass at a steep angle is totally reflected.
+	tir := s.Trace(mp.P(100, 0), 135)
+	if dx, _ := exitDirection(tir); dx <= 0 {
+		t.Errorf("expected total internal reflection, exit dx = %g", dx)
+	}
+}
+
+func TestThinLensFocus(t *testing.T) {
+	s := NewScene()
+	s.Add(ThinLens(mp.P(0, -40), mp.P(0, 40), 50))
+	s.Add(Screen(Segment(mp.P(50, -40), mp.P(50, 40))))
+	for _, h := range []float64{-20, 10, 30} {
+		ray := s.Trace(mp.P(-30, h), 0)
+		_, y := ray.PointOf(float64(ray.PathLength()))
+		if math.Abs(y) > 0.05 {
+			t.Errorf("parallel ray at height %g meets the focal plane at y=%g", h, y)
+		}
+	}
+}
+
+func TestArc(t *testing.T) {
+	arc := Arc(mp.P(10, 0), 20, 90, 180)
+	x0, y0 := arc.PointOf(0)
+	x1, y1 := arc.PointOf(float64(arc.PathLength()))
+	if math.Abs(x0-10) > 1e-6 || math.Abs(y0-20) > 1e-6 || math.Abs(x1+10) > 1e-6 || math.Abs(y1) > 1e-6 {
+		t.Errorf("arc from (%g,%g) to (%g,%g)", x0, y0, x1, y1)
+	}
+}
+
+func TestNotFinite(t *testing.T) {
+	if seg := Segment(mp.P(0, 0), mp.P(math.NaN(), 0)); seg != nil {
+		t.Errorf("expected no segment to a NaN point, got %v", seg)
+	}
+	s := NewScene()
+	s.Add(ThinLens(mp.P(50, math.Inf(-1)), mp.P(50, 50), 20))
+	s.Add(Mirror(Segment(mp.P(100, -50), mp.P(100, 50))))
+	if ray := s.Trace(mp.P(0, 0), 0); ray == nil || ray.PathLength() != 2 {
+		t.Errorf("expected the ray to pass the invalid lens and bounce off the mirror, got %v", ray)
+	}
+	if ray := s.Trace(mp.P(math.NaN(), 0), 0); ray != nil {
+		t.Errorf("expected no ray from a NaN origin, got %v", ray)
+	}
+}
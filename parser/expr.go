@@ -0,0 +1,585 @@
+package parser
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// reserved are the tags that are not variable names: commands, operators
+// and the words of path and draw syntax.
+var reserved = map[string]bool{}
+
+// prefixOps are the operators that start a primary.
+var prefixOps = map[string]bool{}
+
+func init() {
+	for _, s := range []string{
+		"sqrt", "sind", "cosd", "abs", "length", "xpart", "ypart", "dir", "angle",
+		"unitvector", "round", "floor", "ceiling", "reverse", "arclength",
+		"llcorner", "lrcorner", "ulcorner", "urcorner", "center", "point",
+		"direction", "precontrol", "postcontrol", "subpath", "directiontime",
+		"arctime", "decimal", "dashpattern",
+	} {
+		prefixOps[s] = true
+		reserved[s] = true
+	}
+	for _, s := range []string{
+		"beginfig", "endfig", "end", "bye", "draw", "fill", "filldraw", "unfill",
+		"undraw", "drawarrow", "drawdblarrow", "drawdot", "pickup", "label",
+		"dotlabel", "numeric", "pair", "path", "pen", "color", "rgbcolor",
+		"string", "picture", "transform", "boolean", "save", "interim", "input",
+		"def", "vardef", "primarydef", "secondarydef", "tertiarydef", "for",
+		"forever", "if", "begingroup", "let", "newinternal", "withcolor",
+		"withpen", "dashed", "shifted", "scaled", "xscaled", "yscaled",
+		"slanted", "zscaled", "rotated", "rotatedaround", "rotatedabout",
+		"reflectedabout", "transformed", "cutbefore", "cutafter",
+		"intersectionpoint", "intersectiontimes", "dotprod", "cycle", "tension",
+		"controls", "and", "atleast", "curl", "of", "on", "off", "whatever",
+	} {
+		reserved[s] = true
+	}
+}
+
+// isTag reports whether s is a tag: a symbolic token of letters.
+func isTag(s string) bool {
+	return s != "" && charClass(s[0]) == 1
+}
+
+// variableName reads a variable name at the current token: a tag that is
+// neither reserved nor a constant, followed by numeric subscripts (z1 or
+// z[1], both named "z[1]") and suffix tags written right after a
+// subscript (z1a, named "z[1]a").
+func (p *parser) variableName() (string, bool) {
+	t := p.tok()
+	if t.kind != tokSymbol || !isTag(t.text) || reserved[t.text] {
+		return "", false
+	}
+	if _, ok := constantValue(t.text); ok {
+		return "", false
+	}
+	p.next()
+	name := t.text
+	afterSubscript := false
+	for {
+		c := p.tok()
+		switch {
+		case c.kind == tokNumber && c.glued:
+			p.next()
+			name += "[" + formatNumber(c.num) + "]"
+			afterSubscript = true
+			continue
+		case c.kind == tokSymbol && c.text == "[":
+			save := p.pos
+			p.next()
+			v := p.expression()
+			if v.kind == kindNumeric && p.accept("]") {
+				name += "[" + formatNumber(v.n) + "]"
+				afterSubscript = true
+				continue
+			}
+			p.pos = save // mediation such as t[z1,z2]
+		case afterSubscript && c.kind == tokSymbol && c.glued && isTag(c.text) && !reserved[c.text]:
+			p.next()
+			name += c.text
+			afterSubscript = false
+			continue
+		}
+		return name, true
+	}
+}
+
+// formatNumber formats a subscript or decimal like MetaPost.
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e5)/1e5, 'f', -1, 64)
+}
+
+// lookup returns the value of the variable name; z<s> is the pair of x<s>
+// and y<s>.
+func (p *parser) lookup(name string) (value, bool) {
+	if v, ok := p.vars[name]; ok {
+		return v, true
+	}
+	if len(name) > 1 && name[0] == 'z' && !isTagByte(name, 1) {
+		x, xok := p.vars["x"+name[1:]]
+		y, yok := p.vars["y"+name[1:]]
+		if xok && yok && x.kind == kindNumeric && y.kind == kindNumeric {
+			return pair(x.n, y.n), true
+		}
+	}
+	return value{}, false
+}
+
+// expression reads an expression: a tertiary, or a path built from
+// tertiaries with path joins.
+func (p *parser) expression() value {
+	v := p.tertiary()
+	if p.atPathJoin() {
+		return p.pathExpression(v)
+	}
+	return v
+}
+
+// numericExpr reads an expression that must be numeric.
+func (p *parser) numericExpr() float64 {
+	return p.need(p.expression(), kindNumeric).n
+}
+
+// need fails unless v has kind k.
+func (p *parser) need(v value, k valueKind) value {
+	if v.kind != k {
+		p.fail("a %v was expected, not a %v", k, v.kind)
+	}
+	return v
+}
+
+// pathOf fails unless v is a path or a pair, which is a path of one knot.
+func (p *parser) pathOf(v value) *mp.Path {
+	path, ok := v.asPath()
+	if !ok {
+		p.fail("a path was expected, not a %v", v.kind)
+	}
+	return path
+}
+
+// tertiary reads secondaries joined by +, -, ++, +-+, cutbefore and
+// cutafter.
+func (p *parser) tertiary() value {
+	v := p.secondary()
+	for {
+		at := p.tok()
+		switch {
+		case p.accept("+"):
+			v = p.add(v, p.secondary(), 1, at)
+		case p.accept("-"):
+			v = p.add(v, p.secondary(), -1, at)
+		case p.accept("++"):
+			a, b := p.need(v, kindNumeric).n, p.need(p.secondary(), kindNumeric).n
+			v = numeric(math.Hypot(a, b))
+		case p.accept("+-+"):
+			a, b := p.need(v, kindNumeric).n, p.need(p.secondary(), kindNumeric).n
+			if b > a {
+				p.failAt(at, "pythagorean subtraction %g+-+%g has been replaced by 0", a, b)
+			}
+			v = numeric(math.Sqrt(a*a - b*b))
+		case p.accept("cutbefore"):
+			v = pathValue(p.pathOf(v).CutBefore(p.pathOf(p.secondary())))
+		case p.accept("cutafter"):
+			v = pathValue(p.pathOf(v).CutAfter(p.pathOf(p.secondary())))
+		default:
+			return v
+		}
+	}
+}
+
+// secondary reads primaries joined by *, / and the binary operators of
+// MetaPost's secondary level, and applies transformers.
+func (p *parser) secondary() value {
+	v := p.primary()
+	for {
+		at := p.tok()
+		switch {
+		case p.accept("*"):
+			v = p.mul(v, p.primary(), at)
+		case p.accept("/"):
+			v = p.div(v, p.primary(), at)
+		case p.accept("dotprod"):
+			a, b := p.need(v, kindPair), p.need(p.primary(), kindPair)
+			v = numeric(a.x*b.x + a.y*b.y)
+		case p.accept("intersectionpoint"):
+			x, y, ok := p.pathOf(v).IntersectionPoint(p.pathOf(p.primary()))
+			if !ok {
+				p.failAt(at, "the paths don't intersect")
+			}
+			v = pair(x, y)
+		case p.accept("intersectiontimes"):
+			t1, t2 := p.pathOf(v).IntersectionTimes(p.pathOf(p.primary()))
+			v = pair(t1, t2)
+		case v.kind == kindDash && p.accept("scaled"):
+			v.dash = v.dash.Scaled(p.need(p.primary(), kindNumeric).n)
+		default:
+			t, ok := p.transformer()
+			if !ok {
+				return v
+			}
+			v = p.transform(v, t, at)
+		}
+	}
+}
+
+// transformer reads a transformer such as "shifted (1,2)" if one is at the
+// current token.
+func (p *parser) transformer() (mp.Transform, bool) {
+	t := p.tok()
+	if t.kind != tokSymbol {
+		return mp.Transform{}, false
+	}
+	switch t.text {
+	case "shifted":
+		p.next()
+		z := p.need(p.primary(), kindPair)
+		return mp.Shifted(z.x, z.y), true
+	case "scaled", "xscaled", "yscaled", "slanted", "rotated":
+		p.next()
+		s := p.need(p.primary(), kindNumeric).n
+		switch t.text {
+		case "scaled":
+			return mp.Scaled(s), true
+		case "xscaled":
+			return mp.XScaled(s), true
+		case "yscaled":
+			return mp.YScaled(s), true
+		case "slanted":
+			return mp.Slanted(s), true
+		}
+		return mp.Rotated(s), true
+	case "zscaled":
+		p.next()
+		z := p.need(p.primary(), kindPair)
+		return mp.ZScaled(z.x, z.y), true
+	case "rotatedaround", "rotatedabout":
+		p.next()
+		p.expect("(")
+		z := p.need(p.expression(), kindPair)
+		p.expect(",")
+		d := p.numericExpr()
+		p.expect(")")
+		return mp.RotatedAround(z.x, z.y, d), true
+	case "reflectedabout":
+		p.next()
+		p.expect("(")
+		a := p.need(p.expression(), kindPair)
+		p.expect(",")
+		b := p.need(p.expression(), kindPair)
+		p.expect(")")
+		return mp.ReflectedAbout(a.x, a.y, b.x, b.y), true
+	}
+	return mp.Transform{}, false
+}
+
+// transform applies t to a pair, path or pen.
+func (p *parser) transform(v value, t mp.Transform, at token) value {
+	switch v.kind {
+	case kindPair:
+		x, y := t.ApplyToPoint(v.x, v.y)
+		return pair(x, y)
+	case kindPath:
+		return pathValue(t.ApplyToPath(v.path))
+	case kindPen:
+		return penValue(t.ApplyToPen(v.pen))
+	}
+	p.failAt(at, "a %v cannot be transformed", v.kind)
+	return v
+}
+
+// add returns a + sign*b for numerics, pairs and colors.
+func (p *parser) add(a, b value, sign float64, at token) value {
+	if a.kind != b.kind {
+		p.failAt(at, "cannot add a %v and a %v", a.kind, b.kind)
+	}
+	switch a.kind {
+	case kindNumeric:
+		return numeric(a.n + sign*b.n)
+	case kindPair:
+		return pair(a.x+sign*b.x, a.y+sign*b.y)
+	case kindColor:
+		return color(a.c[0]+sign*b.c[0], a.c[1]+sign*b.c[1], a.c[2]+sign*b.c[2])
+	}
+	p.failAt(at, "cannot add %vs", a.kind)
+	return a
+}
+
+// scale returns s times the numeric, pair or color v.
+func (p *parser) scale(v value, s float64, at token) value {
+	switch v.kind {
+	case kindNumeric:
+		return numeric(s * v.n)
+	case kindPair:
+		return pair(s*v.x, s*v.y)
+	case kindColor:
+		return color(s*v.c[0], s*v.c[1], s*v.c[2])
+	}
+	p.failAt(at, "cannot multiply a %v", v.kind)
+	return v
+}
+
+// mul returns a*b where one of them is numeric.
+func (p *parser) mul(a, b value, at token) value {
+	switch {
+	case a.kind == kindNumeric:
+		return p.scale(b, a.n, at)
+	case b.kind == kindNumeric:
+		return p.scale(a, b.n, at)
+	}
+	p.failAt(at, "cannot multiply a %v by a %v", a.kind, b.kind)
+	return a
+}
+
+// div returns a/b for a numeric b.
+func (p *parser) div(a, b value, at token) value {
+	if b.kind != kindNumeric {
+		p.failAt(at, "cannot divide by a %v", b.kind)
+	}
+	if b.n == 0 {
+		p.failAt(at, "division by zero")
+	}
+	return p.scale(a, 1/b.n, at)
+}
+
+// startsPrimary reports whether the current token can start a primary
+// that follows a number without an operator, as in 2cm, 3z1 or .5(1,2).
+func (p *parser) startsPrimary() bool {
+	t := p.tok()
+	if t.kind != tokSymbol {
+		return false
+	}
+	if t.text == "(" || prefixOps[t.text] {
+		return true
+	}
+	if !isTag(t.text) || reserved[t.text] {
+		return false
+	}
+	return true
+}
+
+// primary reads a primary: a number, string, pair, color, variable,
+// constant, parenthesized expression or prefix operation, followed by any
+// mediations t[a,b].
+func (p *parser) primary() value {
+	t := p.tok()
+	var v value
+	switch t.kind {
+	case tokNumber:
+		p.next()
+		n := t.num
+		if p.is("/") && p.peek().kind == tokNumber {
+			p.next()
+			d := p.next().num
+			if d == 0 {
+				p.failAt(t, "division by zero")
+			}
+			n /= d
+		}
+		v = numeric(n)
+		if p.startsPrimary() {
+			v = p.mul(v, p.primary(), t)
+		}
+	case tokString:
+		p.next()
+		v = value{kind: kindString, s: t.text}
+	case tokTeX:
+		p.next()
+		v = value{kind: kindString, s: t.text, tex: true}
+	case tokEOF:
+		p.fail("missing expression")
+	default:
+		v = p.symbolPrimary()
+	}
+	for v.kind == kindNumeric && p.accept("[") {
+		at := p.tok()
+		a := p.expression()
+		p.expect(",")
+		b := p.expression()
+		p.expect("]")
+		v = p.add(a, p.scale(p.add(b, a, -1, at), v.n, at), 1, at)
+	}
+	return v
+}
+
+// symbolPrimary reads a primary that starts with a symbolic token.
+func (p *parser) symbolPrimary() value {
+	t := p.tok()
+	switch t.text {
+	case "(":
+		p.next()
+		a := p.expression()
+		if !p.accept(",") {
+			p.expect(")")
+			return a
+		}
+		b := p.expression()
+		if !p.accept(",") {
+			p.expect(")")
+			return pair(p.need(a, kindNumeric).n, p.need(b, kindNumeric).n)
+		}
+		c := p.expression()
+		p.expect(")")
+		return color(p.need(a, kindNumeric).n, p.need(b, kindNumeric).n, p.need(c, kindNumeric).n)
+	case "-":
+		p.next()
+		return p.scale(p.primary(), -1, t)
+	case "+":
+		p.next()
+		return p.primary()
+	case "whatever":
+		p.fail("whatever is not supported: equations are limited to one unknown variable")
+	}
+	if prefixOps[t.text] {
+		p.next()
+		return p.prefixOperation(t)
+	}
+	if v, ok := constantValue(t.text); ok {
+		p.next()
+		return v
+	}
+	if name, ok := p.variableName(); ok {
+		v, known := p.lookup(name)
+		if !known {
+			p.failAt(t, "%s is unknown", name)
+		}
+		return v
+	}
+	p.fail("unexpected %s", describe(t))
+	return value{}
+}
+
+// prefixOperation evaluates the prefix operator t, which has been read.
+func (p *parser) prefixOperation(t token) value {
+	switch t.text {
+	case "sqrt":
+		x := p.need(p.primary(), kindNumeric).n
+		if x < 0 {
+			p.failAt(t, "square root of %g has been replaced by 0", x)
+		}
+		return numeric(math.Sqrt(x))
+	case "sind", "cosd":
+		s, c := math.Sincos(p.need(p.primary(), kindNumeric).n * math.Pi / 180)
+		if t.text == "sind" {
+			return numeric(s)
+		}
+		return numeric(c)
+	case "abs", "length":
+		v := p.primary()
+		switch v.kind {
+		case kindNumeric:
+			return numeric(math.Abs(v.n))
+		case kindPair:
+			return numeric(math.Hypot(v.x, v.y))
+		case kindPath:
+			if t.text == "length" {
+				return numeric(float64(v.path.PathLength()))
+			}
+		case kindString:
+			if t.text == "length" {
+				return numeric(float64(len(v.s)))
+			}
+		}
+		p.failAt(t, "%s of a %v", t.text, v.kind)
+	case "xpart", "ypart":
+		z := p.need(p.primary(), kindPair)
+		if t.text == "xpart" {
+			return numeric(z.x)
+		}
+		return numeric(z.y)
+	case "dir":
+		s, c := math.Sincos(p.need(p.primary(), kindNumeric).n * math.Pi / 180)
+		return pair(c, s)
+	case "angle", "unitvector":
+		z := p.need(p.primary(), kindPair)
+		if z.x == 0 && z.y == 0 {
+			p.failAt(t, "%s(0,0) is taken as zero", t.text)
+		}
+		if t.text == "angle" {
+			return numeric(math.Atan2(z.y, z.x) * 180 / math.Pi)
+		}
+		l := math.Hypot(z.x, z.y)
+		return pair(z.x/l, z.y/l)
+	case "round", "floor", "ceiling":
+		x := p.need(p.primary(), kindNumeric).n
+		switch t.text {
+		case "round":
+			return numeric(math.Floor(x + 0.5))
+		case "floor":
+			return numeric(math.Floor(x))
+		}
+		return numeric(math.Ceil(x))
+	case "reverse":
+		return pathValue(p.pathOf(p.primary()).Reversed())
+	case "arclength":
+		return numeric(p.pathOf(p.primary()).ArcLength())
+	case "llcorner", "lrcorner", "ulcorner", "urcorner", "center":
+		x0, y0, x1, y1 := mp.PathBBox(p.pathOf(p.primary()))
+		switch t.text {
+		case "llcorner":
+			return pair(x0, y0)
+		case "lrcorner":
+			return pair(x1, y0)
+		case "ulcorner":
+			return pair(x0, y1)
+		case "urcorner":
+			return pair(x1, y1)
+		}
+		return pair((x0+x1)/2, (y0+y1)/2)
+	case "point", "direction", "precontrol", "postcontrol", "arctime":
+		s := p.numericExpr()
+		p.expect("of")
+		path := p.pathOf(p.primary())
+		switch t.text {
+		case "point":
+			return pair(path.PointOf(s))
+		case "direction":
+			return pair(path.DirectionOf(s))
+		case "precontrol":
+			return pair(path.PrecontrolOf(s))
+		case "postcontrol":
+			return pair(path.PostcontrolOf(s))
+		}
+		return numeric(path.ArcTime(s))
+	case "directiontime":
+		d := p.need(p.expression(), kindPair)
+		p.expect("of")
+		return numeric(p.pathOf(p.primary()).DirectionTimeOf(d.x, d.y))
+	case "subpath":
+		ts := p.need(p.expression(), kindPair)
+		p.expect("of")
+		return pathValue(p.pathOf(p.primary()).Subpath(ts.x, ts.y))
+	case "decimal":
+		return value{kind: kindString, s: formatNumber(p.need(p.primary(), kindNumeric).n)}
+	case "dashpattern":
+		return p.dashPattern()
+	}
+	p.failAt(t, "%q is not supported", t.text)
+	return value{}
+}
+
+// dashPattern reads the argument of dashpattern(on a off b ...). A
+// pattern that starts with "off" is rotated to start with a dash and
+// given the matching offset, as mp.DashWithDots is.
+func (p *parser) dashPattern() value {
+	p.expect("(")
+	var arr []float64
+	lead := 0.0 // length of a leading "off"
+	for !p.accept(")") {
+		on := p.accept("on")
+		if !on && !p.accept("off") {
+			p.fail("missing \"on\" or \"off\" in dashpattern, found %s", describe(p.tok()))
+		}
+		d := p.need(p.tertiary(), kindNumeric).n
+		lastOn := len(arr)%2 == 1
+		switch {
+		case len(arr) == 0 && !on:
+			lead += d
+		case on == lastOn:
+			arr[len(arr)-1] += d // on after on, or off after off
+		default:
+			arr = append(arr, d)
+		}
+	}
+	if len(arr) == 0 {
+		return value{kind: kindDash}
+	}
+	if len(arr)%2 == 1 {
+		arr = append(arr, 0)
+	}
+	arr[len(arr)-1] += lead
+	d := mp.NewDashPattern(arr...)
+	if lead > 0 {
+		total := 0.0
+		for _, v := range arr {
+			total += v
+		}
+		d.Offset = total - lead
+	}
+	return value{kind: kindDash, dash: d}
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// tokenKind classifies tokens.
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokNumber           // numeric token such as 3, .5 or 2.25
+	tokSymbol           // symbolic token: a tag such as z or draw, or operators such as .. and :=
+	tokString           // string token "..."
+	tokTeX              // the text between btex and etex
+)
+
+// token is a MetaPost token with its position in the source.
+type token struct {
+	kind      tokenKind
+	text      string
+	num       float64
+	line, col int
+	glued     bool // no space between this token and the previous one
+}
+
+// charClass returns MetaPost's character class of c (mp.w, char_class):
+// consecutive characters of the same class form one symbolic token, so
+// that "--" and ":=" are single tokens while "(" and ")" stand alone.
+// Letters, digits, spaces, quotes and percent signs are handled by the
+// lexer; 0 marks characters that are always a token of their own.
+func charClass(c byte) int {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		return 1
+	case c == '<' || c == '=' || c == '>' || c == ':' || c == '|':
+		return 2
+	case c == '`' || c == '\'':
+		return 3
+	case c == '+' || c == '-':
+		return 4
+	case c == '/' || c == '*' || c == '\\':
+		return 5
+	case c == '!' || c == '?':
+		return 6
+	case c == '#' || c == '&' || c == '@' || c == '$':
+		return 7
+	case c == '^' || c == '~':
+		return 8
+	case c == '.':
+		return 9
+	}
+	return 0
+}
+
+// lex splits src into tokens. Comments run from % to the end of the line;
+// the text between btex (or verbatimtex) and etex is one token.
+func lex(src string) ([]token, error) {
+	var toks []token
+	line, lineStart := 1, 0
+	glued := false
+	for i := 0; i < len(src); {
+		c := src[i]
+		col := i - lineStart + 1
+		switch {
+		case c == '\n':
+			line, lineStart = line+1, i+1
+			i++
+			glued = false
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+			glued = false
+			continue
+		case c == '%':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			glued = false
+			continue
+		}
+		t := token{line: line, col: col, glued: glued}
+		switch {
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i
+			for j < len(src) && src[j] >= '0' && src[j] <= '9' {
+				j++
+			}
+			if j+1 < len(src) && src[j] == '.' && src[j+1] >= '0' && src[j+1] <= '9' {
+				j++
+				for j < len(src) && src[j] >= '0' && src[j] <= '9' {
+					j++
+				}
+			}
+			t.kind, t.text = tokNumber, src[i:j]
+			t.num, _ = strconv.ParseFloat(t.text, 64)
+			i = j
+		case c == '"':
+			j := strings.IndexAny(src[i+1:], "\"\n")
+			if j < 0 || src[i+1+j] != '"' {
+				return nil, &Error{Line: line, Col: col, Msg: "incomplete string token"}
+			}
+			t.kind, t.text = tokString, src[i+1:i+1+j]
+			i += j + 2
+		default:
+			j := i + 1
+			if cl := charClass(c); cl != 0 {
+				for j < len(src) && charClass(src[j]) == cl {
+					j++
+				}
+			}
+			t.kind, t.text = tokSymbol, src[i:j]
+			i = j
+			if t.text == "btex" || t.text == "verbatimtex" {
+				end := strings.Index(src[i:], "etex")
+				if end < 0 {
+					return nil, &Error{Line: line, Col: col, Msg: t.text + " without etex"}
+				}
+				body := src[i : i+end]
+				line += strings.Count(body, "\n")
+				if k := strings.LastIndexByte(body, '\n'); k >= 0 {
+					lineStart = i + k + 1
+				}
+				i += end + len("etex")
+				if t.text == "verbatimtex" {
+					glued = false
+					continue // TeX preamble material, not drawn
+				}
+				t.kind, t.text = tokTeX, strings.TrimSpace(body)
+			}
+		}
+		toks = append(toks, t)
+		glued = true
+	}
+	toks = append(toks, token{kind: tokEOF, line: line, col: len(src) - lineStart + 1})
+	return toks, nil
+}
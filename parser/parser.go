@@ -0,0 +1,627 @@
+// Package parser reads MetaPost source and turns it into mpgo objects, so
+// that existing .mp files can be ported without translating every figure
+// by hand.
+//
+// It understands the subset of MetaPost that most figures are written in:
+//
+//   - path expressions with .., ..., --, ---, &, "tension a and b",
+//     "controls a and b", direction specifiers {dir d}, {curl c}, {z} and
+//     {x, y}, and cycle; paths are solved like MetaPost does when the
+//     expression is complete
+//   - numeric, pair and color arithmetic with units (2cm), implicit
+//     multiplication (3z1), fractions and mediation (1/3[z1,z2]), the
+//     transformers shifted, scaled, rotated, xscaled, yscaled, slanted,
+//     zscaled, rotatedaround and reflectedabout, and common operators such
+//     as sqrt, sind, cosd, abs, xpart, ypart, dir, angle, point ... of,
+//     direction ... of, subpath ... of, reverse, cutbefore, cutafter and
+//     intersectionpoint
+//   - variables with suffixes (z1, x2a, p[3]), declarations, assignments
+//     (:=) and equations (=) in which one side is known; z<suffix> is the
+//     pair (x<suffix>, y<suffix>) as in plain.mp
+//   - draw, fill, filldraw, unfill, undraw, drawarrow, drawdblarrow and
+//     drawdot with withcolor, withpen and dashed; pickup; label and
+//     dotlabel with their suffixes (label.top ...), btex ... etex labels;
+//     the internals linecap, linejoin, ahlength and ahangle
+//   - beginfig(n) ... endfig, end and bye
+//
+// Macros (def, vardef), loops and conditionals are not supported and
+// give an *Error, as does everything else outside the subset.
+//
+// Example:
+//
+//	figs, err := parser.Parse(`
+//	  beginfig(1);
+//	    u := 1cm;
+//	    z0 = (0,0); z1 = (2u,u);
+//	    draw z0..{up}z1 withpen pencircle scaled 1 withcolor red;
+//	    label.top(btex $z_1$ etex, z1);
+//	  endfig;
+//	  end`)
+//	if err != nil {
+//		return err
+//	}
+//	svg.NewBuilder().AddPicture(figs[0].Picture).WriteTo(w)
+package parser
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Figure is a figure of a MetaPost program: what was drawn between
+// beginfig(Number) and endfig. Content drawn outside of any figure becomes
+// a last figure with number -1.
+type Figure struct {
+	Number  int
+	Picture *draw.Picture
+}
+
+// Error is a syntax or evaluation error with its position in the source.
+type Error struct {
+	Line, Col int
+	Msg       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("parser: line %d, column %d: %s", e.Line, e.Col, e.Msg)
+}
+
+// Parse reads a MetaPost program and returns its figures in order.
+func Parse(src string) ([]*Figure, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+	return p.program()
+}
+
+// ParseReader is Parse for a MetaPost program read from r.
+func ParseReader(r io.Reader) ([]*Figure, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(src))
+}
+
+// ParsePath evaluates a single path expression such as
+// "(0,0)..(10,10){right}..tension 1.5..(20,0)--cycle" and returns the
+// solved path. A pair expression gives a path of one knot.
+func ParsePath(src string) (path *mp.Path, err error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+	defer p.recover(&err)
+	v := p.expression()
+	if p.tok().kind != tokEOF {
+		p.fail("extra tokens after the path expression: %q", p.tok().text)
+	}
+	path, ok := v.asPath()
+	if !ok {
+		p.fail("a %v is not a path", v.kind)
+	}
+	return path, nil
+}
+
+// parser evaluates a token stream statement by statement, in the manner
+// of MetaPost's own interpreter: expressions are evaluated as they are
+// read, there is no syntax tree.
+type parser struct {
+	toks    []token
+	pos     int
+	vars    map[string]value // known variables by name, e.g. "x1", "u", "p[3]"
+	pen     *mp.Pen          // the current pen (pickup)
+	pic     *draw.Picture    // currentpicture
+	figure  int              // number of the open figure
+	inFig   bool
+	figures []*Figure
+}
+
+// syntaxError is the panic value that carries an *Error out of the
+// recursive descent; recover turns it back into an error.
+type syntaxError struct{ err *Error }
+
+func newParser(src string) (*parser, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{
+		toks: toks,
+		vars: map[string]value{
+			"linecap": numeric(1), "linejoin": numeric(1),
+			"ahlength": numeric(mp.DefaultAHLength), "ahangle": numeric(mp.DefaultAHAngle),
+			"labeloffset": numeric(mp.DefaultLabelOffset),
+		},
+		pen: mp.PenCircle(0.5),
+		pic: draw.NewPicture(),
+	}, nil
+}
+
+func (p *parser) tok() token  { return p.toks[p.pos] }
+func (p *parser) peek() token { return p.toks[min(p.pos+1, len(p.toks)-1)] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is reports whether the current token is the symbol s.
+func (p *parser) is(s string) bool {
+	t := p.tok()
+	return t.kind == tokSymbol && t.text == s
+}
+
+// accept consumes the symbol s if it is the current token.
+func (p *parser) accept(s string) bool {
+	if p.is(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect consumes the symbol s or fails.
+func (p *parser) expect(s string) {
+	if !p.accept(s) {
+		p.fail("missing %q, found %s", s, describe(p.tok()))
+	}
+}
+
+// fail aborts parsing with an error at the current token.
+func (p *parser) fail(format string, args ...any) {
+	t := p.tok()
+	panic(syntaxError{&Error{Line: t.line, Col: t.col, Msg: fmt.Sprintf(format, args...)}})
+}
+
+// recover stores the error of a failed parse in *err.
+func (p *parser) recover(err *error) {
+	if r := recover(); r != nil {
+		se, ok := r.(syntaxError)
+		if !ok {
+			panic(r)
+		}
+		*err = se.err
+	}
+}
+
+// describe names a token for error messages.
+func describe(t token) string {
+	switch t.kind {
+	case tokEOF:
+		return "end of input"
+	case tokString:
+		return strconv.Quote(t.text)
+	case tokTeX:
+		return "btex ... etex"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// program reads statements until end, bye or the end of the input.
+func (p *parser) program() (figs []*Figure, err error) {
+	defer p.recover(&err)
+	for p.tok().kind != tokEOF && !p.is("end") && !p.is("bye") {
+		p.statement()
+	}
+	if p.inFig {
+		p.fail("beginfig(%d) without endfig", p.figure)
+	}
+	if len(p.pic.Paths()) > 0 || len(p.pic.Labels()) > 0 {
+		p.figures = append(p.figures, &Figure{Number: -1, Picture: p.pic})
+	}
+	return p.figures, nil
+}
+
+// statement reads one statement including its semicolon.
+func (p *parser) statement() {
+	t := p.tok()
+	handled := t.kind == tokSymbol
+	if handled {
+		switch t.text {
+		case ";":
+		case "beginfig":
+			p.next()
+			p.expect("(")
+			n := p.numericExpr()
+			p.expect(")")
+			if p.inFig {
+				p.fail("beginfig inside beginfig(%d)", p.figure)
+			}
+			p.figure, p.inFig = int(math.Round(n)), true
+			p.pic = draw.NewPicture()
+			p.pen = mp.PenCircle(0.5)
+			p.forget("x[]") // plain.mp's clearxy
+			p.forget("y[]")
+		case "endfig":
+			p.next()
+			if !p.inFig {
+				p.fail("endfig without beginfig")
+			}
+			p.figures = append(p.figures, &Figure{Number: p.figure, Picture: p.pic})
+			p.inFig = false
+			p.pic = draw.NewPicture()
+		case "draw", "fill", "filldraw", "unfill", "undraw", "drawarrow", "drawdblarrow", "drawdot":
+			p.next()
+			p.drawCommand(t.text)
+		case "pickup":
+			p.next()
+			at, v := p.tok(), p.expression()
+			if v.kind != kindPen {
+				p.failAt(at, "pickup needs a pen, not a %v", v.kind)
+			}
+			p.pen = v.pen
+		case "label", "dotlabel":
+			p.next()
+			p.labelCommand(t.text == "dotlabel")
+		case "numeric", "pair", "path", "pen", "color", "rgbcolor", "string", "picture", "transform", "boolean", "save":
+			p.next()
+			p.declaration(t.text)
+		case "interim":
+			p.next()
+			p.equation()
+		case "input", "def", "vardef", "primarydef", "secondarydef", "tertiarydef", "for", "forever", "if", "begingroup", "let", "newinternal":
+			p.fail("%q is not supported", t.text)
+		default:
+			handled = false
+		}
+	}
+	if !handled {
+		p.equation()
+	}
+	if p.tok().kind != tokEOF && !p.is("end") && !p.is("bye") {
+		p.expect(";")
+	}
+}
+
+// declaration reads the variable names of a type declaration (or of save)
+// and forgets their values, as MetaPost does.
+func (p *parser) declaration(kind string) {
+	for {
+		name, ok := p.declaredName()
+		if !ok {
+			p.fail("missing variable name after %q", kind)
+		}
+		p.forget(name)
+		if !p.accept(",") {
+			return
+		}
+	}
+}
+
+// declaredName reads a variable name in a declaration, where "[]" stands
+// for any subscript.
+func (p *parser) declaredName() (string, bool) {
+	t := p.tok()
+	if t.kind != tokSymbol || !isTag(t.text) || reserved[t.text] {
+		return "", false
+	}
+	p.next()
+	name := t.text
+	for {
+		switch {
+		case p.is("[") && p.peek().kind == tokSymbol && p.peek().text == "]":
+			p.pos += 2
+			name += "[]"
+		case p.tok().kind == tokNumber && p.tok().glued:
+			name += p.next().text
+		default:
+			return name, true
+		}
+	}
+}
+
+// forget drops the value of name; "[]" in name matches any subscript, and
+// forgetting z<s> forgets x<s> and y<s>.
+func (p *parser) forget(name string) {
+	names := []string{name}
+	if strings.HasPrefix(name, "z") && !isTagByte(name, 1) {
+		names = append(names, "x"+name[1:], "y"+name[1:])
+	}
+	for _, n := range names {
+		if !strings.Contains(n, "[]") {
+			delete(p.vars, n)
+			continue
+		}
+		prefix := n[:strings.Index(n, "[]")]
+		for k := range p.vars {
+			if strings.HasPrefix(k, prefix) && !isTagByte(k, len(prefix)) {
+				delete(p.vars, k)
+			}
+		}
+	}
+}
+
+// isTagByte reports whether s[i] exists and continues a tag.
+func isTagByte(s string, i int) bool {
+	return i < len(s) && charClass(s[i]) == 1
+}
+
+// equation reads an assignment "v := e" or an equation "a = b = ..." in
+// which the unknown operands are single variables.
+func (p *parser) equation() {
+	start := p.tok()
+	if name, ok := p.variableTarget(":="); ok {
+		p.expect(":=")
+		v := p.expression()
+		p.forget(name)
+		p.assign(name, v, start)
+		return
+	}
+	type operand struct {
+		name  string
+		known bool
+		v     value
+	}
+	var ops []operand
+	for {
+		if name, ok := p.variableTarget("=", ";"); ok {
+			if v, known := p.lookup(name); known {
+				ops = append(ops, operand{name: name, known: true, v: v})
+			} else {
+				ops = append(ops, operand{name: name})
+			}
+		} else {
+			ops = append(ops, operand{known: true, v: p.expression()})
+		}
+		if !p.accept("=") {
+			break
+		}
+	}
+	if len(ops) < 2 {
+		p.fail("isolated expression; missing \"=\" or \":=\"")
+	}
+	k := -1
+	for i := range ops {
+		if ops[i].known {
+			k = i
+			break
+		}
+	}
+	if k < 0 {
+		p.fail("equation has no known side; equations between unknowns are not supported")
+	}
+	known := ops[k].v
+	for i, op := range ops {
+		switch {
+		case i == k:
+		case op.name != "":
+			p.assign(op.name, known, start)
+		case !sameValue(op.v, known):
+			p.failAt(start, "inconsistent equation")
+		}
+	}
+}
+
+// variableTarget reports whether the current tokens are a variable name
+// followed by one of the symbols follow, and if so reads the name.
+// Otherwise nothing is consumed.
+func (p *parser) variableTarget(follow ...string) (string, bool) {
+	save := p.pos
+	if name, ok := p.variableName(); ok {
+		for _, s := range follow {
+			if p.is(s) {
+				return name, true
+			}
+		}
+	}
+	p.pos = save
+	return "", false
+}
+
+// assign sets name to v, or checks v against the parts already known. A
+// pair assigned to z<s> sets x<s> and y<s>.
+func (p *parser) assign(name string, v value, at token) {
+	if strings.HasPrefix(name, "z") && !isTagByte(name, 1) {
+		if v.kind != kindPair {
+			p.failAt(at, "%s needs a pair, not a %v", name, v.kind)
+		}
+		p.assign("x"+name[1:], numeric(v.x), at)
+		p.assign("y"+name[1:], numeric(v.y), at)
+		return
+	}
+	if old, ok := p.vars[name]; ok {
+		if !sameValue(old, v) {
+			p.failAt(at, "inconsistent equation for %s", name)
+		}
+		return
+	}
+	p.vars[name] = v
+}
+
+// failAt is fail at the position of token t.
+func (p *parser) failAt(t token, format string, args ...any) {
+	panic(syntaxError{&Error{Line: t.line, Col: t.col, Msg: fmt.Sprintf(format, args...)}})
+}
+
+// sameValue reports whether a and b are equal: numerics, pairs and colors
+// up to MetaPost's precision, strings by content and other values only if
+// they are the same object.
+func sameValue(a, b value) bool {
+	const eps = 1e-4
+	if a.kind != b.kind {
+		return false
+	}
+	switch a.kind {
+	case kindNumeric:
+		return math.Abs(a.n-b.n) < eps
+	case kindPair:
+		return math.Abs(a.x-b.x) < eps && math.Abs(a.y-b.y) < eps
+	case kindColor:
+		for i := range a.c {
+			if math.Abs(a.c[i]-b.c[i]) >= eps {
+				return false
+			}
+		}
+		return true
+	case kindString:
+		return a.s == b.s && a.tex == b.tex
+	case kindPath:
+		return a.path == b.path
+	case kindPen:
+		return a.pen == b.pen
+	case kindDash:
+		return a.dash == b.dash
+	}
+	return false
+}
+
+// drawCommand reads the argument and options of a drawing command and
+// adds the result to the current picture.
+func (p *parser) drawCommand(cmd string) {
+	at := p.tok()
+	v := p.expression()
+	path, ok := v.asPath()
+	if !ok {
+		p.failAt(at, "%s needs a path, not a %v", cmd, v.kind)
+	}
+	path = path.Copy()
+	col := color(0, 0, 0)
+	pen := p.pen
+	var dash *mp.DashPattern
+	for {
+		switch {
+		case p.accept("withcolor"):
+			opt, c := p.tok(), p.expression()
+			switch c.kind {
+			case kindColor:
+				col = c
+			case kindNumeric:
+				col = color(c.n, c.n, c.n)
+			default:
+				p.failAt(opt, "withcolor needs a color, not a %v", c.kind)
+			}
+		case p.accept("withpen"):
+			opt, pv := p.tok(), p.expression()
+			if pv.kind != kindPen {
+				p.failAt(opt, "withpen needs a pen, not a %v", pv.kind)
+			}
+			pen = pv.pen
+		case p.accept("dashed"):
+			opt, d := p.tok(), p.expression()
+			if d.kind != kindDash {
+				p.failAt(opt, "dashed needs a dash pattern, not a %v", d.kind)
+			}
+			dash = d.dash
+		default:
+			p.addDrawn(cmd, path, col, pen, dash, at)
+			return
+		}
+	}
+}
+
+// addDrawn adds path drawn by cmd to the current picture.
+func (p *parser) addDrawn(cmd string, path *mp.Path, col value, pen *mp.Pen, dash *mp.DashPattern, at token) {
+	cycle := path.Head.LType != mp.KnotEndpoint
+	style := mp.Style{
+		Stroke:      col.mpColor(),
+		StrokeWidth: mp.GetPenScale(pen),
+		Fill:        mp.ColorCSS("none"),
+		Pen:         pen,
+		Dash:        dash,
+		LineCap:     mp.LineCap(p.internal("linecap") + 1),
+		LineJoin:    mp.LineJoin(p.internal("linejoin") + 1),
+	}
+	switch cmd {
+	case "fill", "unfill":
+		if !cycle {
+			p.failAt(at, "%s needs a cycle", cmd)
+		}
+		if cmd == "unfill" {
+			col = color(1, 1, 1)
+		}
+		style.Fill, style.Stroke, style.Pen, style.Dash = col.mpColor(), mp.ColorCSS("none"), nil, nil
+		style.StrokeWidth = 0
+	case "filldraw":
+		if !cycle {
+			p.failAt(at, "filldraw needs a cycle")
+		}
+		style.Fill = style.Stroke
+	case "undraw":
+		style.Stroke = mp.ColorRGB(1, 1, 1)
+	case "drawarrow", "drawdblarrow":
+		style.Arrow = mp.ArrowStyle{
+			End: true, Start: cmd == "drawdblarrow",
+			Length: p.internal("ahlength"), Angle: p.internal("ahangle"),
+		}
+	}
+	path.Style = style
+	if style.Pen != nil && !style.Pen.Elliptical {
+		// The envelope of a polygonal pen is computed by the engine.
+		e := mp.NewEngine()
+		e.AddPath(path)
+		if err := e.Solve(); err != nil {
+			p.failAt(at, "%v", err)
+		}
+	}
+	p.pic.AddPath(path)
+}
+
+// internal returns the numeric internal quantity name.
+func (p *parser) internal(name string) float64 {
+	v, ok := p.vars[name]
+	if !ok || v.kind != kindNumeric {
+		p.fail("internal quantity %s is not numeric", name)
+	}
+	return v.n
+}
+
+// labelAnchors maps label suffixes to anchors.
+var labelAnchors = map[string]mp.Anchor{
+	"lft": mp.AnchorLeft, "rt": mp.AnchorRight, "top": mp.AnchorTop, "bot": mp.AnchorBottom,
+	"ulft": mp.AnchorUpperLeft, "urt": mp.AnchorUpperRight,
+	"llft": mp.AnchorLowerLeft, "lrt": mp.AnchorLowerRight,
+}
+
+// labelCommand reads label@#(s, z) or dotlabel@#(s, z) with an optional
+// withcolor.
+func (p *parser) labelCommand(dot bool) {
+	anchor := mp.AnchorCenter
+	if p.accept(".") {
+		t := p.next()
+		a, ok := labelAnchors[t.text]
+		if !ok {
+			p.failAt(t, "unknown label suffix %q", t.text)
+		}
+		anchor = a
+	}
+	p.expect("(")
+	s := p.expression()
+	if s.kind != kindString {
+		p.fail("label text must be a string, not a %v", s.kind)
+	}
+	p.expect(",")
+	z := p.expression()
+	if z.kind != kindPair {
+		p.fail("label position must be a pair, not a %v", z.kind)
+	}
+	p.expect(")")
+	col := color(0, 0, 0)
+	if p.accept("withcolor") {
+		if col = p.expression(); col.kind != kindColor {
+			p.fail("withcolor needs a color, not a %v", col.kind)
+		}
+	}
+	pos := mp.P(z.x, z.y)
+	if dot {
+		p.pic.DotLabel(s.s, pos, anchor, col.mpColor())
+		labels := p.pic.Labels()
+		l := labels[len(labels)-1]
+		l.TeX = s.tex
+		l.WithColor(col.mpColor()).WithOffset(p.internal("labeloffset"))
+		return
+	}
+	l := mp.NewLabel(s.s, pos, anchor).WithColor(col.mpColor()).WithOffset(p.internal("labeloffset"))
+	l.TeX = s.tex
+	p.pic.AddLabel(l)
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// sameKnots reports the first knot of got that differs from want.
+func sameKnots(t *testing.T, src string, got, want *mp.Path) {
+	t.Helper()
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-3 }
+	g, w := got.Head, want.Head
+	for i := 0; ; i++ {
+		// The controls outside an open path's ends are not used.
+		if !near(g.XCoord, w.XCoord) || !near(g.YCoord, w.YCoord) ||
+			g.LType != mp.KnotEndpoint && (!near(g.LeftX, w.LeftX) || !near(g.LeftY, w.LeftY)) ||
+			g.RType != mp.KnotEndpoint && (!near(g.RightX, w.RightX) || !near(g.RightY, w.RightY)) {
+			t.Fatalf("%s: knot %d differs\ngot  %s\nwant %s", src, i, got.Show(), want.Show())
+		}
+		g, w = g.Next, w.Next
+		if (g == nil || g == got.Head) != (w == nil || w == want.Head) {
+			t.Fatalf("%s: knot count differs\ngot  %s\nwant %s", src, got.Show(), want.Show())
+		}
+		if g == nil || g == got.Head {
+			return
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	solve := func(b *draw.PathBuilder) *mp.Path {
+		p, err := b.Solve()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	tests := []struct {
+		src  string
+		want *mp.Path
+	}{
+		{"(0,0)..(10,10)..(20,0)",
+			solve(draw.NewPath().MoveTo(mp.P(0, 0)).CurveTo(mp.P(10, 10)).CurveTo(mp.P(20, 0)))},
+		{"(0,0){up}..tension 2..{down}(10,0)",
+			solve(draw.NewPath().MoveTo(mp.P(0, 0)).WithDirection(90).WithIncomingDirection(-90).WithTension(2).CurveTo(mp.P(10, 0)))},
+		{"(0,0){curl 0}..(10,10)..(20,0)",
+			solve(draw.NewPath().MoveTo(mp.P(0, 0)).WithOutgoingCurl(0).CurveTo(mp.P(10, 10)).CurveTo(mp.P(20, 0)))},
+		{"(0,0)..controls (0,5) and (10,5)..(10,0)",
+			solve(draw.NewPath().MoveTo(mp.P(0, 0)).CurveToWithControls(mp.P(10, 0), mp.P(0, 5), mp.P(10, 5)))},
+		{"fullcircle scaled 10 shifted (5,5)",
+			mp.Shifted(5, 5).ApplyToPath(mp.Scaled(10).ApplyToPath(mp.FullCircle()))},
+	}
+	for _, tt := range tests {
+		got, err := ParsePath(tt.src)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.src, err)
+		}
+		sameKnots(t, tt.src, got, tt.want)
+	}
+}
+
+func TestParsePathStraightAndCycle(t *testing.T) {
+	p, err := ParsePath("(0,0)--(30,0)--(30,30)--cycle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Head.LType == mp.KnotEndpoint {
+		t.Fatal("--cycle gave an open path")
+	}
+	// Straight segments have their controls at a third and two thirds.
+	k := p.Head
+	if k.RightX != 10 || k.RightY != 0 || k.Next.LeftX != 20 || k.Next.LeftY != 0 {
+		t.Errorf("first segment is not straight: %s", p.Show())
+	}
+	if got := p.Head.Prev.RightX; math.Abs(got-20) > 1e-9 {
+		t.Errorf("closing segment control x = %g, want 20", got)
+	}
+	// Joining paths with & and reading a path back from a variable.
+	p, err = ParsePath("((0,0)..(10,10)) & ((10,10)--(20,0))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := p.PathLength(); n != 2 {
+		t.Errorf("length of concatenated path = %d, want 2", n)
+	}
+}
+
+func TestParseProgram(t *testing.T) {
+	figs, err := Parse(`
+u := 1cm;
+beginfig(3);
+  z0 = (0,0); z1 = (2u,u); x2 = 3u; y2 = y0;
+  pair a[]; a[1] := 1/3[z0,z2];
+  path p; p = z0..{up}z1..z2;
+  draw p withpen pencircle scaled 1 withcolor red;
+  fill fullcircle scaled 5 shifted z1 withcolor .5white;
+  pickup pensquare scaled 2;
+  draw z0--a1 dashed evenly scaled 2;
+  drawarrow z0..z2 shifted (0,-u) withcolor (0,0,1);
+  label.top(btex $z_1$ etex, z1);
+  interim linecap := butt;
+  draw point 1 of p -- point 2 of p dashed dashpattern(on 2 off 1);
+endfig;
+end`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(figs) != 1 || figs[0].Number != 3 {
+		t.Fatalf("got %d figures, want figure 3", len(figs))
+	}
+	paths := figs[0].Picture.Paths()
+	if len(paths) != 5 {
+		t.Fatalf("got %d paths, want 5", len(paths))
+	}
+	curve, fill, dashed, arrow, last := paths[0], paths[1], paths[2], paths[3], paths[4]
+	if curve.Style.Stroke.CSS() != "rgb(255,0,0)" || curve.Style.StrokeWidth != 1 {
+		t.Errorf("draw: stroke %s width %g", curve.Style.Stroke.CSS(), curve.Style.StrokeWidth)
+	}
+	if x, y := curve.PointOf(1); math.Abs(x-2*28.34645) > 1e-3 || math.Abs(y-28.34645) > 1e-3 {
+		t.Errorf("z1 = (%g,%g)", x, y)
+	}
+	if dx, dy := curve.DirectionOf(1); math.Abs(dx) > 1e-9 || dy <= 0 {
+		t.Errorf("direction at z1 = (%g,%g), want up", dx, dy)
+	}
+	if fill.Style.Fill.CSS() != "rgb(128,128,128)" || fill.Style.Stroke.CSS() != "none" {
+		t.Errorf("fill: fill %s stroke %s", fill.Style.Fill.CSS(), fill.Style.Stroke.CSS())
+	}
+	if dashed.Style.Dash == nil || len(dashed.Style.Dash.Array) != 2 || dashed.Style.Dash.Array[0] != 6 {
+		t.Errorf("dashed evenly scaled 2: %v", dashed.Style.Dash)
+	}
+	if dashed.Envelope == nil {
+		t.Error("pensquare stroke has no envelope")
+	}
+	if x, _ := dashed.PointOf(1); math.Abs(x-28.34645) > 1e-3 {
+		t.Errorf("x of a1 = %g, want a third of x2", x)
+	}
+	if !arrow.Style.Arrow.End || arrow.Style.Arrow.Start || arrow.Style.Stroke.CSS() != "rgb(0,0,255)" {
+		t.Errorf("drawarrow: %+v", arrow.Style.Arrow)
+	}
+	if last.Style.LineCap != mp.LineCapButt || last.Style.Dash == nil || last.Style.Dash.Array[1] != 1 {
+		t.Errorf("interim linecap or dashpattern lost: cap %v dash %v", last.Style.LineCap, last.Style.Dash)
+	}
+	labels := figs[0].Picture.Labels()
+	if len(labels) != 1 || !labels[0].TeX || labels[0].Text != "$z_1$" || labels[0].Anchor != mp.AnchorTop {
+		t.Errorf("label: %+v", labels)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src       string
+		line, col int
+	}{
+		{"draw (0,0)--z9;", 1, 13},
+		{"x = 1;\nfor i = 1 upto 3: draw (i,0); endfor", 2, 1},
+		{"x1 = 1; x1 = 2;", 1, 9},
+		{"fill (0,0)--(1,1);", 1, 6},
+		{"draw (0,0) withpen 3;", 1, 20},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		var perr *Error
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: error %v is not an *Error", tt.src, err)
+			continue
+		}
+		if perr.Line != tt.line || perr.Col != tt.col {
+			t.Errorf("%q: error at %d:%d, want %d:%d (%v)", tt.src, perr.Line, perr.Col, tt.line, tt.col, err)
+		}
+	}
+}
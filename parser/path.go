@@ -0,0 +1,231 @@
+package parser
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// plainInfinity is the tension of "---" (plain.mp: tension infinity).
+const plainInfinity = 4095.99998
+
+// boundary is a direction specifier {…} before or after a path join: a
+// given direction in degrees or a curl.
+type boundary struct {
+	typ mp.KnotType // KnotGiven or KnotCurl; KnotOpen if there is none
+	val float64
+}
+
+// atPathJoin reports whether the current token continues a path
+// expression.
+func (p *parser) atPathJoin() bool {
+	switch {
+	case p.is(".."), p.is("..."), p.is("--"), p.is("---"), p.is("&"), p.is("{"):
+		return true
+	}
+	return false
+}
+
+// pathExpression reads the path joins that follow first and returns the
+// solved path, following MetaPost's scanning of path expressions (mp.w,
+// "Scan a path construction operation"): the knots of the operands are
+// linked with the direction specifiers, tensions or controls of the joins,
+// openings next to a given direction or curl are plugged, and the path is
+// solved once the expression is complete.
+func (p *parser) pathExpression(first value) value {
+	at := p.tok()
+	knots := p.knotsOf(first)
+	cycle := false
+	for !cycle {
+		pre := p.direction()
+		if !p.atPathJoin() {
+			if pre.typ != mp.KnotOpen {
+				// z1..z2{dir}: a direction after the last knot.
+				last := knots[len(knots)-1]
+				last.RType, last.RightX = pre.typ, pre.val
+			}
+			break
+		}
+		join := p.next()
+		t1, t2 := 1.0, 1.0
+		var controls *[2]value
+		switch join.text {
+		case "..":
+			switch {
+			case p.accept("tension"):
+				t1 = p.tension()
+				t2 = t1
+				if p.accept("and") {
+					t2 = p.tension()
+				}
+				p.expect("..")
+			case p.accept("controls"):
+				c1 := p.need(p.primary(), kindPair)
+				c2 := c1
+				if p.accept("and") {
+					c2 = p.need(p.primary(), kindPair)
+				}
+				p.expect("..")
+				controls = &[2]value{c1, c2}
+			}
+		case "...":
+			t1, t2 = -1, -1 // tension atleast 1
+		case "---":
+			t1, t2 = plainInfinity, plainInfinity
+		case "--":
+			if pre.typ == mp.KnotOpen {
+				pre = boundary{mp.KnotCurl, 1}
+			}
+		case "&":
+			if pre.typ != mp.KnotOpen {
+				p.failAt(join, "a direction cannot be given at &")
+			}
+		default:
+			p.failAt(join, "missing path join, found %s", describe(join))
+		}
+		post := p.direction()
+		if join.text == "--" && post.typ == mp.KnotOpen {
+			post = boundary{mp.KnotCurl, 1}
+		}
+		var next []*mp.Knot
+		if p.accept("cycle") {
+			cycle = true
+		} else {
+			next = p.knotsOf(p.tertiary())
+		}
+		last := knots[len(knots)-1]
+		target := knots[0]
+		if !cycle {
+			target = next[0]
+		}
+		if join.text == "&" {
+			if post.typ != mp.KnotOpen {
+				p.failAt(join, "a direction cannot be given at &")
+			}
+			if math.Abs(last.XCoord-target.XCoord) > 1e-4 || math.Abs(last.YCoord-target.YCoord) > 1e-4 {
+				p.failAt(join, "paths don't touch; & cannot be done")
+			}
+			if cycle {
+				// The last knot is dropped, the first takes its left side.
+				target.LType, target.LeftX, target.LeftY = last.LType, last.LeftX, last.LeftY
+				knots = knots[:len(knots)-1]
+			} else {
+				last.RType, last.RightX, last.RightY = target.RType, target.RightX, target.RightY
+				knots = append(knots, next[1:]...)
+			}
+			continue
+		}
+		if controls != nil {
+			last.RType, last.RightX, last.RightY = mp.KnotExplicit, controls[0].x, controls[0].y
+			target.LType, target.LeftX, target.LeftY = mp.KnotExplicit, controls[1].x, controls[1].y
+		} else {
+			last.RType, last.RightX, last.RightY = pre.typ, pre.val, t1
+			target.LType, target.LeftX, target.LeftY = post.typ, post.val, t2
+		}
+		knots = append(knots, next...)
+	}
+	if p.atPathJoin() {
+		p.fail("a cycle must end the path expression")
+	}
+	path := mp.NewPath()
+	for _, k := range knots {
+		plugKnot(k)
+		path.Append(k)
+	}
+	if !cycle {
+		// The ends of an open path get curl 1 unless given otherwise.
+		first, last := knots[0], knots[len(knots)-1]
+		first.LType, last.RType = mp.KnotEndpoint, mp.KnotEndpoint
+		if first.RType == mp.KnotOpen {
+			first.RType, first.RightX = mp.KnotCurl, 1
+		}
+		if last.LType == mp.KnotOpen {
+			last.LType, last.LeftX = mp.KnotCurl, 1
+		}
+	}
+	e := mp.NewEngine()
+	e.AddPath(path)
+	if err := e.Solve(); err != nil {
+		p.failAt(at, "%v", err)
+	}
+	return pathValue(path)
+}
+
+// knotsOf returns copies of the knots of the pair or open path v, unlinked.
+func (p *parser) knotsOf(v value) []*mp.Knot {
+	path := p.pathOf(v)
+	if path.Head == nil {
+		p.fail("an empty path cannot be joined")
+	}
+	if path.Head.LType != mp.KnotEndpoint {
+		p.fail("a cycle cannot be joined")
+	}
+	var knots []*mp.Knot
+	k := path.Head
+	for {
+		c := mp.CopyKnot(k)
+		c.Next, c.Prev = nil, nil
+		knots = append(knots, c)
+		if k.RType == mp.KnotEndpoint || k.Next == nil || k.Next == path.Head {
+			return knots
+		}
+		k = k.Next
+	}
+}
+
+// tension reads a tension value, negative for "atleast". MetaPost
+// requires at least 3/4.
+func (p *parser) tension() float64 {
+	atLeast := p.accept("atleast")
+	at := p.tok()
+	t := p.need(p.primary(), kindNumeric).n
+	if t < 0.75 {
+		p.failAt(at, "improper tension %g has been replaced by 1", t)
+	}
+	if atLeast {
+		return -t
+	}
+	return t
+}
+
+// direction reads an optional direction specifier: {curl c}, {z} with a
+// pair z, or {x, y}. A zero direction gives no specifier, as in MetaPost.
+func (p *parser) direction() boundary {
+	if !p.accept("{") {
+		return boundary{typ: mp.KnotOpen}
+	}
+	if p.accept("curl") {
+		at := p.tok()
+		c := p.numericExpr()
+		if c < 0 {
+			p.failAt(at, "improper curl %g has been replaced by 0", c)
+		}
+		p.expect("}")
+		return boundary{mp.KnotCurl, c}
+	}
+	v := p.expression()
+	var x, y float64
+	if p.accept(",") {
+		x, y = p.need(v, kindNumeric).n, p.numericExpr()
+	} else {
+		z := p.need(v, kindPair)
+		x, y = z.x, z.y
+	}
+	p.expect("}")
+	if x == 0 && y == 0 {
+		return boundary{typ: mp.KnotOpen}
+	}
+	return boundary{mp.KnotGiven, math.Atan2(y, x) * 180 / math.Pi * mp.AngleMultiplier()}
+}
+
+// plugKnot copies a given direction or curl on one side of k to the other
+// side if that is open, so that the path passes through k in the given
+// direction (mp.w, "Plug an opening in right_type(pp), if possible").
+func plugKnot(k *mp.Knot) {
+	switch {
+	case k.LType == mp.KnotOpen && (k.RType == mp.KnotGiven || k.RType == mp.KnotCurl):
+		k.LType, k.LeftX = k.RType, k.RightX
+	case k.RType == mp.KnotOpen && (k.LType == mp.KnotGiven || k.LType == mp.KnotCurl):
+		k.RType, k.RightX = k.LType, k.LeftX
+	}
+}
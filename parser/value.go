@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// valueKind is the type of a MetaPost value.
+type valueKind int
+
+const (
+	kindNumeric valueKind = iota
+	kindPair
+	kindColor
+	kindPath
+	kindPen
+	kindString
+	kindDash
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindNumeric:
+		return "numeric"
+	case kindPair:
+		return "pair"
+	case kindColor:
+		return "color"
+	case kindPath:
+		return "path"
+	case kindPen:
+		return "pen"
+	case kindString:
+		return "string"
+	case kindDash:
+		return "dash pattern"
+	}
+	return "unknown"
+}
+
+// value is a known MetaPost value. Numerics use n, pairs x and y, colors
+// c; paths are always solved.
+type value struct {
+	kind valueKind
+	n    float64
+	x, y float64
+	c    [3]float64
+	path *mp.Path
+	pen  *mp.Pen
+	s    string
+	tex  bool // a string from btex ... etex
+	dash *mp.DashPattern
+}
+
+func numeric(n float64) value     { return value{kind: kindNumeric, n: n} }
+func pair(x, y float64) value     { return value{kind: kindPair, x: x, y: y} }
+func color(r, g, b float64) value { return value{kind: kindColor, c: [3]float64{r, g, b}} }
+func pathValue(p *mp.Path) value  { return value{kind: kindPath, path: p} }
+func penValue(p *mp.Pen) value    { return value{kind: kindPen, pen: p} }
+
+// asPath returns v as a path: pairs become a path of one knot.
+func (v value) asPath() (*mp.Path, bool) {
+	switch v.kind {
+	case kindPath:
+		return v.path, true
+	case kindPair:
+		p := mp.NewPath()
+		p.Append(&mp.Knot{XCoord: v.x, YCoord: v.y, LeftX: v.x, LeftY: v.y, RightX: v.x, RightY: v.y})
+		return p, true
+	}
+	return nil, false
+}
+
+// mpColor returns the color value v as an mp.Color.
+func (v value) mpColor() mp.Color {
+	return mp.ColorRGB(v.c[0], v.c[1], v.c[2])
+}
+
+// plain.mp's units and constants.
+var constants = map[string]value{
+	"bp": numeric(1), "pt": numeric(0.99626), "mm": numeric(2.83464),
+	"cm": numeric(28.34645), "in": numeric(72), "pc": numeric(11.95517),
+	"cc": numeric(12.79213), "dd": numeric(1.06601),
+	"infinity": numeric(4095.99998), "epsilon": numeric(1.0 / 65536), "eps": numeric(0.00049),
+	"right": pair(1, 0), "left": pair(-1, 0), "up": pair(0, 1), "down": pair(0, -1),
+	"origin": pair(0, 0),
+	"black":  color(0, 0, 0), "white": color(1, 1, 1), "background": color(1, 1, 1),
+	"red": color(1, 0, 0), "green": color(0, 1, 0), "blue": color(0, 0, 1),
+	"butt": numeric(0), "rounded": numeric(1), "squared": numeric(2),
+	"mitered": numeric(0), "beveled": numeric(2),
+	"true": numeric(1), "false": numeric(0),
+}
+
+// constantValue returns the value of the constant or predefined path,
+// pen or dash pattern name.
+func constantValue(name string) (value, bool) {
+	switch name {
+	case "fullcircle":
+		return pathValue(mp.FullCircle()), true
+	case "halfcircle":
+		return pathValue(mp.HalfCircle()), true
+	case "quartercircle":
+		return pathValue(mp.QuarterCircle()), true
+	case "unitsquare":
+		return pathValue(mp.UnitSquare()), true
+	case "pencircle":
+		return penValue(mp.PenCircle(1)), true
+	case "pensquare":
+		return penValue(mp.PenSquare(1)), true
+	case "penrazor":
+		return penValue(mp.PenRazor(1)), true
+	case "evenly":
+		return value{kind: kindDash, dash: mp.DashEvenly()}, true
+	case "withdots":
+		return value{kind: kindDash, dash: mp.DashWithDots()}, true
+	}
+	v, ok := constants[name]
+	return v, ok
+}
@@ -0,0 +1,186 @@
+package svg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+)
+
+// Patch describes how the children of the root element changed between
+// two renders of a Differ. A client applies it to the DOM in order:
+// remove the Removed elements, replace the Updated ones, then insert the
+// Added ones, each after the element with key After (at the start if
+// After is empty), and finally replace the root start tag if Root is set.
+type Patch struct {
+	Root    string         `json:"root,omitempty"`    // new start tag of the root element, if it changed
+	Removed []string       `json:"removed,omitempty"` // keys of the removed elements
+	Updated []PatchElement `json:"updated,omitempty"` // elements whose markup changed
+	Added   []PatchElement `json:"added,omitempty"`   // new elements in document order
+}
+
+// PatchElement is an element of a Patch.
+type PatchElement struct {
+	Key    string `json:"key"`             // the element's id or data-key attribute
+	After  string `json:"after,omitempty"` // key of the preceding element (Added only)
+	Markup string `json:"markup"`          // the element's SVG markup
+}
+
+// Empty reports whether the patch changes nothing.
+func (p *Patch) Empty() bool {
+	return p.Root == "" && len(p.Removed) == 0 && len(p.Updated) == 0 && len(p.Added) == 0
+}
+
+// Differ renders successive states of a drawing and reports only what
+// changed, for live-preview editors and animations that update the DOM
+// instead of replacing the whole document. Elements are keyed by their id,
+// which paths get from the "id" metadata entry (see mp.WithMeta), so an
+// edited path with an id is reported as updated. Elements without id are
+// keyed by their content and get a data-key attribute; a change to them is
+// reported as a removal and an addition.
+//
+// Give the builders a fixed viewBox (SetViewBox): an automatic one follows
+// the content and moves every element when the extent of the drawing
+// changes.
+//
+// Example:
+//
+//	d := svg.NewDiffer()
+//	for frame := range frames {
+//		patch, err := d.Diff(svg.NewBuilder().SetViewBox(0, 0, 200, 100).AddPicture(frame))
+//		...
+//		json.NewEncoder(conn).Encode(patch)
+//	}
+type Differ struct {
+	root   string
+	keys   []string
+	markup map[string]string
+}
+
+// NewDiffer returns a Differ without a previous render; its first Diff
+// reports every element as added.
+func NewDiffer() *Differ {
+	return &Differ{markup: map[string]string{}}
+}
+
+// Diff renders b, returns the patch from the previous render to this one
+// and remembers this render for the next call.
+func (d *Differ) Diff(b *Builder) (*Patch, error) {
+	var buf bytes.Buffer
+	if err := b.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	root, keys, markup, err := splitRootChildren(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	patch := &Patch{}
+	if root != d.root {
+		patch.Root = root
+	}
+	moved := map[string]bool{}
+	// Elements kept from the previous render must stay in their order;
+	// those that are out of order are moved by removing and adding them.
+	var oldKept []string
+	for _, k := range d.keys {
+		if _, ok := markup[k]; ok {
+			oldKept = append(oldKept, k)
+		}
+	}
+	i := 0
+	for _, k := range keys {
+		if _, ok := d.markup[k]; !ok {
+			continue
+		}
+		if i < len(oldKept) && oldKept[i] == k {
+			i++
+			continue
+		}
+		moved[k] = true
+	}
+	for _, k := range d.keys {
+		if _, ok := markup[k]; !ok || moved[k] {
+			patch.Removed = append(patch.Removed, k)
+		}
+	}
+	after := ""
+	for _, k := range keys {
+		old, ok := d.markup[k]
+		switch {
+		case !ok || moved[k]:
+			patch.Added = append(patch.Added, PatchElement{Key: k, After: after, Markup: markup[k]})
+		case old != markup[k]:
+			patch.Updated = append(patch.Updated, PatchElement{Key: k, Markup: markup[k]})
+		}
+		after = k
+	}
+
+	d.root, d.keys, d.markup = root, keys, markup
+	return patch, nil
+}
+
+// splitRootChildren returns the start tag of the root element and the keys
+// and markup of its children. Children without id get a data-key
+// attribute derived from their content.
+func splitRootChildren(doc []byte) (root string, keys []string, markup map[string]string, err error) {
+	markup = map[string]string{}
+	seen := map[string]int{}
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	depth := 0
+	var start int64
+	var id string
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("svg: split document: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch depth {
+			case 1:
+				root = string(doc[offset:dec.InputOffset()])
+			case 2:
+				start, id = offset, ""
+				for _, a := range t.Attr {
+					if a.Name.Space == "" && a.Name.Local == "id" {
+						id = a.Value
+					}
+				}
+			}
+		case xml.EndElement:
+			if depth == 2 {
+				m := string(doc[start:dec.InputOffset()])
+				key := id
+				if key == "" {
+					h := fnv.New64a()
+					h.Write([]byte(m))
+					base := fmt.Sprintf("k%x", h.Sum64())
+					seen[base]++
+					key = fmt.Sprintf("%s-%d", base, seen[base])
+					m = withDataKey(m, key)
+				}
+				keys = append(keys, key)
+				markup[key] = m
+			}
+			depth--
+		}
+	}
+	return root, keys, markup, nil
+}
+
+// withDataKey inserts a data-key attribute after the element name.
+func withDataKey(m, key string) string {
+	end := strings.IndexAny(m, " />")
+	if end < 0 {
+		return m
+	}
+	return m[:end] + fmt.Sprintf(` data-key="%s"`, key) + m[end:]
+}
@@ -0,0 +1,136 @@
+package svg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// IndexEntry describes one element with metadata in the index written by
+// WriteIndex.
+type IndexEntry struct {
+	Meta map[string]string `json:"meta"`
+	// BBox is the bounding box (minX, minY, maxX, maxY) of the element's
+	// geometry in viewBox coordinates, without the stroke width.
+	BBox [4]float64 `json:"bbox"`
+}
+
+// WriteIndex writes a JSON object to w that maps the id of every element
+// with metadata (mp.Style.Meta) to its metadata and bounding box, e.g. for
+// image maps, test assertions or links from documentation. The ids are
+// the ones WriteTo gives the elements: the "id" entry of the metadata, or
+// "mpgo-1", "mpgo-2", … in drawing order. A path drawn as several elements
+// (gradient pieces, line styles) gets one entry per element, with -2, -3,
+// … appended to repeated ids.
+//
+// Example:
+//
+//	b := svg.NewBuilder().AddPicture(pic)
+//	_ = b.WriteTo(svgFile)
+//	_ = b.WriteIndex(indexFile)
+func (s *Builder) WriteIndex(w io.Writer) error {
+	progress := s.progress
+	s.progress = nil
+	err := s.WriteTo(io.Discard)
+	s.progress = progress
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.index)
+}
+
+// resetIndex clears the element ids and index before writing a document.
+func (s *Builder) resetIndex() {
+	s.index = map[string]IndexEntry{}
+	s.metaIDs = map[string]int{}
+	s.metaCount = 0
+}
+
+// metaAttrs returns the id and data- attributes for an element drawn with
+// style, and records the element with the bounding box of parts in the
+// index. It returns "" if style has no metadata.
+func (s *Builder) metaAttrs(style mp.Style, parts ...*mp.Path) string {
+	if len(style.Meta) == 0 {
+		return ""
+	}
+	if s.index == nil {
+		s.resetIndex()
+	}
+	base := style.Meta["id"]
+	if base == "" {
+		s.metaCount++
+		base = fmt.Sprintf("mpgo-%d", s.metaCount)
+	}
+	id := base
+	if n := s.metaIDs[base]; n > 0 {
+		id = fmt.Sprintf("%s-%d", base, n+1)
+	}
+	s.metaIDs[base]++
+
+	meta := make(map[string]string, len(style.Meta))
+	keys := make([]string, 0, len(style.Meta))
+	for k, v := range style.Meta {
+		meta[k] = v
+		if k != "id" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	s.index[id] = IndexEntry{Meta: meta, BBox: s.outputBBox(parts)}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, ` id="%s"`, escapeXML(id))
+	for _, k := range keys {
+		fmt.Fprintf(&b, ` data-%s="%s"`, dataAttrName(k), escapeXML(style.Meta[k]))
+	}
+	return b.String()
+}
+
+// outputBBox returns the bounding box of paths in viewBox coordinates.
+func (s *Builder) outputBBox(paths []*mp.Path) [4]float64 {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range paths {
+		if p == nil || p.Head == nil {
+			continue
+		}
+		x0, y0, x1, y1 := mp.PathBBox(p)
+		minX, minY = math.Min(minX, x0), math.Min(minY, y0)
+		maxX, maxY = math.Max(maxX, x1), math.Max(maxY, y1)
+	}
+	if math.IsInf(minX, 1) {
+		return [4]float64{}
+	}
+	switch {
+	case s.metaPostCompat:
+		return [4]float64{minX - s.mpOffsetX, s.mpMaxY - maxY + s.mpOffsetY, maxX - s.mpOffsetX, s.mpMaxY - minY + s.mpOffsetY}
+	case s.flipY:
+		var vx, vy, vw, vh float64
+		_, _ = fmt.Sscanf(s.viewBox, "%f %f %f %f", &vx, &vy, &vw, &vh)
+		ty := 2*vy + vh
+		return [4]float64{minX, ty - maxY, maxX, ty - minY}
+	}
+	return [4]float64{minX, minY, maxX, maxY}
+}
+
+// dataAttrName turns a metadata key into the name of a data- attribute:
+// lowercase, with characters other than letters, digits, '-', '_' and '.'
+// replaced by '-'.
+func dataAttrName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, key)
+}
@@ -0,0 +1,121 @@
+package svg
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Prolog lines written before the root element.
+const (
+	xmlDeclaration = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n"
+	svgDocType     = `<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd">` + "\n"
+)
+
+// unitsPerBP converts model units (PostScript points, MetaPost's bp) to the
+// units accepted by Units.
+var unitsPerBP = map[string]float64{
+	"px": 96.0 / 72,
+	"pt": 1,
+	"pc": 1.0 / 12,
+	"in": 1.0 / 72,
+	"cm": 2.54 / 72,
+	"mm": 25.4 / 72,
+}
+
+// rootAttr is an extra attribute of the root element.
+type rootAttr struct {
+	name, value string
+}
+
+// XMLDeclaration writes an XML declaration before the root element, as
+// some print pipelines and older tools require. It is off by default.
+func (s *Builder) XMLDeclaration(on bool) *Builder {
+	s.xmlDecl = on
+	return s
+}
+
+// DocType writes the SVG 1.1 DOCTYPE before the root element. It is off by
+// default, which is what browsers and dvisvgm-based LaTeX workflows
+// expect; turn it on for validators that need it.
+func (s *Builder) DocType(on bool) *Builder {
+	s.docType = on
+	return s
+}
+
+// PreserveAspectRatio sets the preserveAspectRatio attribute of the root
+// element, e.g. "xMidYMid meet" or "none". An empty value omits it.
+func (s *Builder) PreserveAspectRatio(value string) *Builder {
+	s.aspectRatio = value
+	return s
+}
+
+// Units writes the width and height of the root element in a physical
+// unit, one of "px", "pt", "pc", "in", "cm" and "mm", converted from model
+// units (PostScript points, 1/72 in), e.g. width="35.28mm" for 100 units.
+// Automatically sized documents then get width and height attributes too.
+// An empty unit restores plain numbers. Unknown units make WriteTo fail.
+//
+// Example:
+//
+//	b := svg.NewBuilder().Units("mm")  // printed at the size of the model
+func (s *Builder) Units(unit string) *Builder {
+	s.unit = unit
+	return s
+}
+
+// RootAttr adds an attribute to the root element, e.g. a class, a role or
+// a version. Attributes are written in the order they were added; adding a
+// name again replaces its value.
+//
+// Example:
+//
+//	b := svg.NewBuilder().RootAttr("class", "figure").RootAttr("role", "img")
+func (s *Builder) RootAttr(name, value string) *Builder {
+	for i, a := range s.rootAttrs {
+		if a.name == name {
+			s.rootAttrs[i].value = value
+			return s
+		}
+	}
+	s.rootAttrs = append(s.rootAttrs, rootAttr{name, value})
+	return s
+}
+
+// writeRoot writes the prolog and the start tag of the root element with
+// the given viewBox.
+func (s *Builder) writeRoot(w io.Writer, viewBox string) error {
+	var b strings.Builder
+	if s.xmlDecl {
+		b.WriteString(xmlDeclaration)
+	}
+	if s.docType {
+		b.WriteString(svgDocType)
+	}
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg"`)
+	switch {
+	case s.unit != "":
+		factor, ok := unitsPerBP[s.unit]
+		if !ok {
+			return fmt.Errorf("svg: unknown unit %q", s.unit)
+		}
+		fmt.Fprintf(&b, ` width="%g%s" height="%g%s"`, roundUnit(s.width*factor), s.unit, roundUnit(s.height*factor), s.unit)
+	case !s.autoSize:
+		fmt.Fprintf(&b, ` width="%g" height="%g"`, s.width, s.height)
+	}
+	fmt.Fprintf(&b, ` viewBox="%s"`, viewBox)
+	if s.aspectRatio != "" {
+		fmt.Fprintf(&b, ` preserveAspectRatio="%s"`, escapeXML(s.aspectRatio))
+	}
+	for _, a := range s.rootAttrs {
+		fmt.Fprintf(&b, ` %s="%s"`, a.name, escapeXML(a.value))
+	}
+	b.WriteString(">")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// roundUnit rounds a physical size to 1/1000 of its unit.
+func roundUnit(v float64) float64 {
+	return float64(int64(v*1000+0.5)) / 1000
+}
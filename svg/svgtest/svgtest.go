@@ -0,0 +1,292 @@
+// Package svgtest compares SVG documents for golden-file tests.
+//
+// Documents are parsed and compared structurally: element names, attribute
+// sets (in any order) and attribute values and text split into tokens,
+// where numbers are compared with a tolerance and separators (spaces and
+// commas) are ignored. Changes in number precision, attribute order or
+// path data spacing therefore do not break a test.
+//
+// Example:
+//
+//	var sb strings.Builder
+//	b.WriteTo(&sb)
+//	if err := svgtest.Golden(sb.String(), "testdata/figure.svg", 1e-3); err != nil {
+//		t.Error(err)
+//	}
+package svgtest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxReported is the number of differences listed by Compare.
+const maxReported = 10
+
+// node is a parsed SVG element.
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []*node
+	text     string
+}
+
+// prefixes maps the namespaces found in SVG files back to their usual
+// prefixes; the SVG namespace itself is dropped.
+var prefixes = map[string]string{
+	"http://www.w3.org/2000/svg":                  "",
+	"http://www.w3.org/1999/xlink":                "xlink",
+	"http://www.w3.org/XML/1998/namespace":        "xml",
+	"http://www.w3.org/2000/xmlns/":               "xmlns",
+	"http://www.w3.org/1999/02/22-rdf-syntax-ns#": "rdf",
+}
+
+func qualified(n xml.Name) string {
+	space := n.Space
+	if prefix, ok := prefixes[space]; ok {
+		space = prefix
+	}
+	if space == "" {
+		return n.Local
+	}
+	return space + ":" + n.Local
+}
+
+// parse reads an SVG document into its root element. Comments, processing
+// instructions and whitespace between elements are dropped.
+func parse(doc string) (*node, error) {
+	dec := xml.NewDecoder(strings.NewReader(doc))
+	root := &node{}
+	stack := []*node{root}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("svgtest: %w", err)
+		}
+		cur := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: qualified(t.Name), attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[qualified(a.Name)] = a.Value
+			}
+			cur.children = append(cur.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			cur.text += string(t)
+		}
+	}
+	if len(root.children) != 1 {
+		return nil, fmt.Errorf("svgtest: expected one root element, found %d", len(root.children))
+	}
+	return root.children[0], nil
+}
+
+var numberRe = regexp.MustCompile(`[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`)
+
+// token is a number or a run of other characters.
+type token struct {
+	text  string
+	num   float64
+	isNum bool
+}
+
+// tokenize splits s into numbers and the text between them, with spaces and
+// commas removed from the text.
+func tokenize(s string) []token {
+	var toks []token
+	addText := func(t string) {
+		for _, f := range strings.FieldsFunc(t, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		}) {
+			toks = append(toks, token{text: f})
+		}
+	}
+	last := 0
+	for _, m := range numberRe.FindAllStringIndex(s, -1) {
+		addText(s[last:m[0]])
+		v, err := strconv.ParseFloat(s[m[0]:m[1]], 64)
+		if err != nil {
+			addText(s[m[0]:m[1]])
+		} else {
+			toks = append(toks, token{text: s[m[0]:m[1]], num: v, isNum: true})
+		}
+		last = m[1]
+	}
+	addText(s[last:])
+	return toks
+}
+
+// Normalize returns doc in a canonical form: one element per line, sorted
+// attributes, and every number rounded to the given number of decimals and
+// printed without trailing zeros. Two documents that differ only in
+// formatting normalize to the same string, which makes readable diffs for
+// failed golden tests.
+func Normalize(doc string, decimals int) (string, error) {
+	root, err := parse(doc)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	writeNode(&sb, root, 0, decimals)
+	return sb.String(), nil
+}
+
+func normalizeValue(s string, decimals int) string {
+	toks := tokenize(s)
+	parts := make([]string, len(toks))
+	scale := math.Pow(10, float64(decimals))
+	for i, t := range toks {
+		if t.isNum {
+			v := math.Round(t.num*scale) / scale
+			if v == 0 {
+				v = 0 // avoid "-0"
+			}
+			parts[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		} else {
+			parts[i] = t.text
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func writeNode(sb *strings.Builder, n *node, depth, decimals int) {
+	indent := strings.Repeat("  ", depth)
+	sb.WriteString(indent + "<" + n.name)
+	names := make([]string, 0, len(n.attrs))
+	for name := range n.attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(sb, " %s=%q", name, normalizeValue(n.attrs[name], decimals))
+	}
+	text := normalizeValue(n.text, decimals)
+	if len(n.children) == 0 && text == "" {
+		sb.WriteString("/>\n")
+		return
+	}
+	sb.WriteString(">\n")
+	if text != "" {
+		var escaped strings.Builder
+		xml.EscapeText(&escaped, []byte(text))
+		sb.WriteString(indent + "  " + escaped.String() + "\n")
+	}
+	for _, c := range n.children {
+		writeNode(sb, c, depth+1, decimals)
+	}
+	sb.WriteString(indent + "</" + n.name + ">\n")
+}
+
+// Compare parses got and want and returns an error listing where they
+// differ, or nil if they are structurally equal with numbers agreeing
+// within tol.
+func Compare(got, want string, tol float64) error {
+	g, err := parse(got)
+	if err != nil {
+		return fmt.Errorf("got: %w", err)
+	}
+	w, err := parse(want)
+	if err != nil {
+		return fmt.Errorf("want: %w", err)
+	}
+	var diffs []string
+	compareNodes(g, w, "/"+w.name, tol, &diffs)
+	if len(diffs) == 0 {
+		return nil
+	}
+	if len(diffs) > maxReported {
+		diffs = append(diffs[:maxReported], fmt.Sprintf("... and %d more", len(diffs)-maxReported))
+	}
+	return fmt.Errorf("svgtest: documents differ:\n%s", strings.Join(diffs, "\n"))
+}
+
+// Golden compares got with the contents of the golden file at path, see
+// Compare.
+func Golden(got, path string, tol float64) error {
+	want, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("svgtest: %w", err)
+	}
+	if err := Compare(got, string(want), tol); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func compareNodes(g, w *node, where string, tol float64, diffs *[]string) {
+	if g.name != w.name {
+		*diffs = append(*diffs, fmt.Sprintf("%s: element <%s>, want <%s>", where, g.name, w.name))
+		return
+	}
+	names := make(map[string]bool)
+	for name := range g.attrs {
+		names[name] = true
+	}
+	for name := range w.attrs {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		gv, gok := g.attrs[name]
+		wv, wok := w.attrs[name]
+		switch {
+		case !gok:
+			*diffs = append(*diffs, fmt.Sprintf("%s: missing attribute %s=%q", where, name, wv))
+		case !wok:
+			*diffs = append(*diffs, fmt.Sprintf("%s: unexpected attribute %s=%q", where, name, gv))
+		default:
+			if msg := compareValues(gv, wv, tol); msg != "" {
+				*diffs = append(*diffs, fmt.Sprintf("%s@%s: %s", where, name, msg))
+			}
+		}
+	}
+	if msg := compareValues(g.text, w.text, tol); msg != "" {
+		*diffs = append(*diffs, fmt.Sprintf("%s text: %s", where, msg))
+	}
+	if len(g.children) != len(w.children) {
+		*diffs = append(*diffs, fmt.Sprintf("%s: %d child elements, want %d", where, len(g.children), len(w.children)))
+	}
+	count := make(map[string]int)
+	for i := 0; i < len(g.children) && i < len(w.children); i++ {
+		name := w.children[i].name
+		compareNodes(g.children[i], w.children[i], fmt.Sprintf("%s/%s[%d]", where, name, count[name]), tol, diffs)
+		count[name]++
+	}
+}
+
+// compareValues returns a description of the first difference between two
+// attribute values or texts, or "" if they agree.
+func compareValues(got, want string, tol float64) string {
+	g, w := tokenize(got), tokenize(want)
+	for i := 0; i < len(g) && i < len(w); i++ {
+		switch {
+		case g[i].isNum && w[i].isNum:
+			if math.Abs(g[i].num-w[i].num) > tol {
+				return fmt.Sprintf("token %d is %s, want %s (in %q)", i, g[i].text, w[i].text, want)
+			}
+		case g[i].isNum != w[i].isNum || g[i].text != w[i].text:
+			return fmt.Sprintf("token %d is %q, want %q (in %q)", i, g[i].text, w[i].text, want)
+		}
+	}
+	if len(g) != len(w) {
+		return fmt.Sprintf("%d tokens, want %d (%q, want %q)", len(g), len(w), got, want)
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package svgtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+	"github.com/boxesandglue/mpgo/svg"
+)
+
+const reference = `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">
+  <path d="M0,0 L10.000,5.5" stroke="black" stroke-width="0.5"/>
+  <text x="1" y="2">A</text>
+</svg>`
+
+func TestCompareIgnoresFormatting(t *testing.T) {
+	got := `<?xml version="1.0"?><svg height="10.0" width="10" xmlns="http://www.w3.org/2000/svg">` +
+		`<path stroke-width=".5" stroke="black" d="M 0 0 L 10 5.50001"/><text y="2" x="1">A</text></svg>`
+	if err := Compare(got, reference, 1e-4); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCompareReportsDifferences(t *testing.T) {
+	got := `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">
+  <path d="M0,0 L10,6" stroke="red"/>
+  <text x="1" y="2">B</text>
+</svg>`
+	err := Compare(got, reference, 1e-4)
+	if err == nil {
+		t.Fatal("expected differences")
+	}
+	for _, want := range []string{
+		`/svg/path[0]@d: token 5 is 6, want 5.5`,
+		`/svg/path[0]@stroke: token 0 is "red", want "black"`,
+		`/svg/path[0]: missing attribute stroke-width="0.5"`,
+		`/svg/text[0] text: token 0 is "B", want "A"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error lacks %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	a, err := Normalize(`<svg b="1.00004" a="x"><g><path d="M0,0L-0.00001,3"/></g></svg>`, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<svg a=\"x\" b=\"1\">\n  <g>\n    <path d=\"M 0 0 L 0 3\"/>\n  </g>\n</svg>\n"
+	if a != want {
+		t.Errorf("Normalize =\n%s\nwant\n%s", a, want)
+	}
+}
+
+func TestGoldenBuilderOutput(t *testing.T) {
+	pic := draw.NewPicture()
+	path, _ := draw.NewPath().MoveTo(mp.P(0, 0)).CurveTo(mp.P(30, 20)).CurveTo(mp.P(60, 0)).Solve()
+	path.Style.Stroke = mp.ColorCSS("black")
+	path.Style.StrokeWidth = 1
+	pic.AddPath(path)
+	var sb strings.Builder
+	b := svg.NewBuilder()
+	b.AddPicture(pic)
+	if err := b.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if err := Golden(sb.String(), "testdata/curve.svg", 1e-3); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,95 @@
+package svg
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Theme maps CSS custom property names, without the leading "--", to
+// colors, e.g. {"stroke-primary": mp.ColorCSS("black")}.
+type Theme map[string]mp.Color
+
+// ThemeColors writes the colors of the document as CSS custom properties,
+// so one exported figure can follow the light or dark theme of the page or
+// viewer without being rendered again. Every fill, stroke and label color
+// that equals a color of light (compared by its CSS value) is written as
+// var(--name, color), and a style block at the start of the document
+// declares the properties with the colors of light, and with those of dark
+// inside @media (prefers-color-scheme: dark). Pages embedding the SVG can
+// override the properties with their own rules on svg elements. Names must
+// be valid CSS identifiers; colors of neither theme are written as before.
+// A nil light theme turns theming off.
+//
+// Example:
+//
+//	b := svg.NewBuilder().ThemeColors(
+//		svg.Theme{"ink": mp.ColorCSS("black"), "accent": mp.ColorCSS("#1f77b4")},
+//		svg.Theme{"ink": mp.ColorCSS("#eeeeee"), "accent": mp.ColorCSS("#6cb4ee")},
+//	)
+func (s *Builder) ThemeColors(light, dark Theme) *Builder {
+	s.lightTheme, s.darkTheme = light, dark
+	s.themeVars = nil
+	if light == nil {
+		return s
+	}
+	s.themeVars = make(map[string]string, len(light))
+	for _, name := range sortedThemeNames(light) {
+		css := light[name].CSS()
+		if _, ok := s.themeVars[css]; !ok && css != "" && css != "none" {
+			s.themeVars[css] = name
+		}
+	}
+	return s
+}
+
+// paint returns the value of a fill or stroke attribute for c: a reference
+// to its custom property if c is a theme color, else its CSS value.
+func (s *Builder) paint(c mp.Color) string {
+	css := c.CSS()
+	if name, ok := s.themeVars[css]; ok {
+		return fmt.Sprintf("var(--%s, %s)", name, css)
+	}
+	return css
+}
+
+// writeThemeStyle writes the style block declaring the theme properties.
+func (s *Builder) writeThemeStyle(w io.Writer) error {
+	if s.lightTheme == nil {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("<style>")
+	writeThemeRule(&b, s.lightTheme)
+	if len(s.darkTheme) > 0 {
+		b.WriteString("@media (prefers-color-scheme: dark){")
+		writeThemeRule(&b, s.darkTheme)
+		b.WriteString("}")
+	}
+	b.WriteString("</style>")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeThemeRule writes a rule declaring the properties of t on svg
+// elements.
+func writeThemeRule(b *strings.Builder, t Theme) {
+	b.WriteString("svg{")
+	for _, name := range sortedThemeNames(t) {
+		fmt.Fprintf(b, "--%s:%s;", name, escapeXML(t[name].CSS()))
+	}
+	b.WriteString("}")
+}
+
+// sortedThemeNames returns the property names of t in a stable order.
+func sortedThemeNames(t Theme) []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
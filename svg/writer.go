@@ -1,6 +1,7 @@
 package svg
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"math"
@@ -11,7 +12,7 @@ import (
 
 // formatLineCap returns the SVG stroke-linecap value for a given LineCap constant.
 // Defaults to "round" (MetaPost default) if unset or unknown.
-func formatLineCap(cap int) string {
+func formatLineCap(cap mp.LineCap) string {
 	switch cap {
 	case mp.LineCapButt:
 		return "butt"
@@ -24,7 +25,7 @@ func formatLineCap(cap int) string {
 
 // formatLineJoin returns the SVG stroke-linejoin value for a given LineJoin constant.
 // Defaults to "round" (MetaPost default) if unset or unknown.
-func formatLineJoin(join int) string {
+func formatLineJoin(join mp.LineJoin) string {
 	switch join {
 	case mp.LineJoinMiter:
 		return "miter"
@@ -59,6 +60,7 @@ func FormatDashAttrs(dash *mp.DashPattern) string {
 
 // PathToSVG converts a solved mp.Path into an SVG path string.
 // It expects explicit control points to be present (after Solve).
+// Segments marked as quadratic are written as "Q" commands.
 func PathToSVG(path *mp.Path) string {
 	if path == nil || path.Head == nil {
 		return ""
@@ -87,8 +89,11 @@ func PathToSVG(path *mp.Path) string {
 				isLine = true
 			}
 		}
+		qx, qy, isQuad := p.QuadraticControl()
 		if isLine {
 			fmt.Fprintf(&b, " L %.3f %.3f", q.XCoord, q.YCoord)
+		} else if isQuad {
+			fmt.Fprintf(&b, " Q %.3f %.3f %.3f %.3f", qx, qy, q.XCoord, q.YCoord)
 		} else {
 			fmt.Fprintf(&b, " C %.3f %.3f %.3f %.3f %.3f %.3f",
 				p.RightX, p.RightY,
@@ -110,6 +115,21 @@ func PathToSVG(path *mp.Path) string {
 	return b.String()
 }
 
+// MultiPathToSVG converts all parts of a MultiPath into one SVG path string
+// with a separate M command per part.
+func MultiPathToSVG(m *mp.MultiPath) string {
+	if m == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(m.Parts))
+	for _, p := range m.Parts {
+		if d := PathToSVG(p); d != "" {
+			parts = append(parts, d)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
 // PathToSVGFlipped converts a solved mp.Path into an SVG path string with Y-coordinates
 // flipped around the given height. This produces MetaPost-compatible output where
 // y_svg = height - y_math. Use height = maxY + minY for proper alignment.
@@ -145,8 +165,11 @@ func PathToSVGTransformed(path *mp.Path, offsetX, offsetY, flipHeight float64) s
 				isLine = true
 			}
 		}
+		qx, qy, isQuad := p.QuadraticControl()
 		if isLine {
 			fmt.Fprintf(&b, "L %.6f %.6f", transformX(q.XCoord), transformY(q.YCoord))
+		} else if isQuad {
+			fmt.Fprintf(&b, "Q %.6f %.6f,%.6f %.6f", transformX(qx), transformY(qy), transformX(q.XCoord), transformY(q.YCoord))
 		} else {
 			fmt.Fprintf(&b, "C %.6f %.6f,%.6f %.6f,%.6f %.6f",
 				transformX(p.RightX), transformY(p.RightY),
@@ -168,94 +191,17 @@ func PathToSVGTransformed(path *mp.Path, offsetX, offsetY, flipHeight float64) s
 	return b.String()
 }
 
-// bbox1D computes min/max for a cubic Bezier in one dimension and merges it
-// into the provided running min/max. Mirrors mp_bound_cubic ideas (mp.c:9434ff).
-func bbox1D(p0, p1, p2, p3 float64, curMin, curMax float64) (float64, float64) {
-	expand := func(v float64) {
-		if v < curMin {
-			curMin = v
-		}
-		if v > curMax {
-			curMax = v
-		}
-	}
-	expand(p0)
-	expand(p3)
-
-	// Derivative coefficients: 3(-p0+3p1-3p2+p3) t^2 + 2(2p0-4p1+2p2) t + (p1-p0)
-	a := -p0 + 3*p1 - 3*p2 + p3
-	b := 2 * (p0 - 2*p1 + p2)
-	c := p1 - p0
-
-	if a == 0 {
-		if b != 0 {
-			t := -c / b
-			if t > 0 && t < 1 {
-				expand(cubicAt(p0, p1, p2, p3, t))
-			}
-		}
-		return curMin, curMax
-	}
-
-	discriminant := b*b - 4*a*c
-	if discriminant < 0 {
-		return curMin, curMax
-	}
-	sqrtDisc := math.Sqrt(discriminant)
-	for _, t := range []float64{(-b + sqrtDisc) / (2 * a), (-b - sqrtDisc) / (2 * a)} {
-		if t > 0 && t < 1 {
-			expand(cubicAt(p0, p1, p2, p3, t))
-		}
-	}
-	return curMin, curMax
-}
-
-func cubicAt(p0, p1, p2, p3, t float64) float64 {
-	mt := 1 - t
-	return mt*mt*mt*p0 + 3*mt*mt*t*p1 + 3*mt*t*t*p2 + t*t*t*p3
-}
-
 // PathBBox computes the bounding box (minX, minY, maxX, maxY) for a path,
-// including cubic extrema.
+// including cubic extrema. It is a thin wrapper around mp.PathBBox.
 func PathBBox(p *mp.Path) (minX, minY, maxX, maxY float64) {
-	if p == nil || p.Head == nil {
-		return 0, 0, 0, 0
-	}
-	minX, minY = math.Inf(1), math.Inf(1)
-	maxX, maxY = math.Inf(-1), math.Inf(-1)
-	expand := func(x, y float64) {
-		if x < minX {
-			minX = x
-		}
-		if x > maxX {
-			maxX = x
-		}
-		if y < minY {
-			minY = y
-		}
-		if y > maxY {
-			maxY = y
-		}
-	}
-	k := p.Head
-	for {
-		q := k.Next
-		expand(k.XCoord, k.YCoord)
-		minX, maxX = bbox1D(k.XCoord, k.RightX, q.LeftX, q.XCoord, minX, maxX)
-		minY, maxY = bbox1D(k.YCoord, k.RightY, q.LeftY, q.YCoord, minY, maxY)
-		k = q
-		if k == p.Head || k == nil || k.RType == mp.KnotEndpoint {
-			expand(k.XCoord, k.YCoord)
-			break
-		}
-	}
-	return minX, minY, maxX, maxY
+	return mp.PathBBox(p)
 }
 
 // Builder is a tiny SVG writer for demo purposes.
 type Builder struct {
 	width, height  float64
 	paths          []string
+	pathSources    []*mp.Path  // Path drawn by each entry of paths, nil for AddPath
 	labels         []*mp.Label // Labels to render as SVG text elements
 	bg             string
 	viewBox        string
@@ -263,25 +209,53 @@ type Builder struct {
 	stroke         mp.Color
 	fill           mp.Color
 	strokeWidth    float64
-	lineCap        int // Default line cap (uses mp.LineCap* constants)
-	lineJoin       int // Default line join (uses mp.LineJoin* constants)
+	lineCap        mp.LineCap  // Default line cap
+	lineJoin       mp.LineJoin // Default line join
 	flipY          bool
 	autoSize       bool
 	padding        float64
-	metaPostCompat bool           // Output in MetaPost-compatible format (Y-down in path data, no transform)
-	mpPaths        []*mp.Path     // Store paths for MetaPost-compatible rendering
-	mpOrigPaths    []*mp.Path     // Store original paths (before envelope substitution) for auto viewBox
-	mpMinY, mpMaxY float64        // Bounding box for Y-flip calculation
-	mpOffsetX      float64        // X offset for coordinate transformation (minX - halfStroke)
-	mpOffsetY      float64        // Y offset for coordinate transformation (minY - halfStroke)
-	clipPaths      []*mp.Path     // Clip paths (each gets an ID)
-	clippedGroups  []clippedGroup // Groups of paths with their clip path index
+	metaPostCompat bool                   // Output in MetaPost-compatible format (Y-down in path data, no transform)
+	mpPaths        []*mp.Path             // Store paths for MetaPost-compatible rendering
+	mpOrigPaths    []*mp.Path             // Store original paths (before envelope substitution) for auto viewBox
+	mpMinY, mpMaxY float64                // Bounding box for Y-flip calculation
+	mpOffsetX      float64                // X offset for coordinate transformation (minX - halfStroke)
+	mpOffsetY      float64                // Y offset for coordinate transformation (minY - halfStroke)
+	clipPaths      []*mp.Path             // Clip paths (each gets an ID)
+	clippedGroups  []clippedGroup         // Groups of paths with their clip path index
+	multiPaths     []*mp.MultiPath        // Multi-part paths, each rendered as one element
+	multiAt        []int                  // Number of paths added before each multi-part path
+	snapDPI        float64                // Snap axis-aligned lines to this pixel grid (0 = off)
+	margin         [4]float64             // Extra space around the content: top, right, bottom, left (model units)
+	labelMargin    [4]float64             // Extra space around each label's extent: top, right, bottom, left
+	labelOvershoot float64                // Growth of estimated label extents per side, times the font size
+	labelMetrics   mp.FontRenderer        // Font measuring label extents exactly, nil to estimate them
+	modelBox       [4]float64             // Final model-space bbox (minX, minY, maxX, maxY) including margins
+	modelBoxSet    bool                   // True once modelBox has been computed by a viewBox fit
+	modelBG        mp.Color               // Background filling modelBox (drawn behind all content)
+	frame          *mp.Style              // Frame drawn along the inside of modelBox, nil for none
+	progress       mp.ProgressFunc        // Called by WriteTo after each element, nil for none
+	extents        []mp.ExtentContributor // Extra content for the automatic viewBox
+	minStrokeWidth float64                // Thinnest stroke written (0 = no limit)
+	xmlDecl        bool                   // Write an XML declaration
+	docType        bool                   // Write the SVG 1.1 DOCTYPE
+	aspectRatio    string                 // preserveAspectRatio of the root element
+	unit           string                 // Unit of the root width and height ("" = none)
+	rootAttrs      []rootAttr             // Extra attributes of the root element
+	index          map[string]IndexEntry  // Elements with metadata written by the last WriteTo
+	metaIDs        map[string]int         // Number of elements written per metadata id
+	metaCount      int                    // Number of generated metadata ids
+	lightTheme     Theme                  // Colors written as CSS custom properties, nil for none
+	darkTheme      Theme                  // Values of the properties in dark mode
+	themeVars      map[string]string      // Property name per CSS value of a light theme color
+	err            error                  // First error of the content added, returned by WriteTo
 }
 
 // clippedGroup represents a set of paths that share a clip path.
 type clippedGroup struct {
-	clipIndex int        // Index into clipPaths (-1 means no clip)
-	paths     []*mp.Path // Paths in this group
+	clipIndex  int             // Index into clipPaths (-1 means no clip)
+	paths      []*mp.Path      // Paths in this group
+	multiPaths []*mp.MultiPath // Multi-part paths in this group
+	multiAt    []int           // Number of paths drawn before each multi-part path
 }
 
 // NewBuilder constructs a Builder. When called without dimensions, it enables
@@ -313,6 +287,165 @@ func (s *Builder) Padding(p float64) *Builder {
 	return s
 }
 
+// MinStrokeWidth makes every stroke at least w wide (in model units), so
+// thin lines of drawings exported at a small scale do not vanish. Hairlines
+// (mp.Style.Hairline) are not affected. 0 disables the limit.
+//
+// Example:
+//
+//	b := svg.NewBuilder().MinStrokeWidth(0.25)
+func (s *Builder) MinStrokeWidth(w float64) *Builder {
+	s.minStrokeWidth = w
+	return s
+}
+
+// Margin sets the same margin on all four sides. See Margins.
+func (s *Builder) Margin(m float64) *Builder {
+	return s.Margins(m, m, m, m)
+}
+
+// Margins adds extra space (in model units) around the content when the
+// viewBox is fitted, in CSS order: top, right, bottom, left. Unlike Padding,
+// margins also apply in MetaPost-compatible mode, and they are part of the
+// area covered by SetModelBackground and Frame.
+func (s *Builder) Margins(top, right, bottom, left float64) *Builder {
+	s.margin = [4]float64{top, right, bottom, left}
+	return s
+}
+
+// LabelMargins adds extra space (in model units) around the extent of
+// every label when the viewBox is fitted, in CSS order: top, right,
+// bottom, left. Unlike Margins it only moves the edges that labels
+// determine, so a label hanging below the drawing can be given room for
+// its descenders without growing the other sides.
+//
+// Example:
+//
+//	b := svg.NewBuilder().LabelMargins(0, 1, 2.5, 0) // descenders, italics
+func (s *Builder) LabelMargins(top, right, bottom, left float64) *Builder {
+	s.labelMargin = [4]float64{top, right, bottom, left}
+	return s
+}
+
+// LabelOvershoot grows the estimated extent of every label (see
+// mp.Label.EstimateBounds) by factor times its font size on each side when
+// the viewBox is fitted. The estimate ignores descenders and slanted
+// glyphs, so labels anchored at the edge of a drawing can be cut; a
+// factor of about 0.25 covers the descenders of common fonts. It is not
+// applied to labels measured with StrictLabelBounds.
+func (s *Builder) LabelOvershoot(factor float64) *Builder {
+	s.labelOvershoot = factor
+	return s
+}
+
+// StrictLabelBounds measures labels with the glyph outlines of f (see
+// mp.Label.ToPaths) instead of estimating them when the viewBox is fitted,
+// so their extents include descenders, accents and italic overhang. Labels
+// that f cannot render are estimated. A nil f restores the estimate.
+//
+// Example:
+//
+//	face, _ := font.Load(r)
+//	b := svg.NewBuilder().StrictLabelBounds(face)
+func (s *Builder) StrictLabelBounds(f mp.FontRenderer) *Builder {
+	s.labelMetrics = f
+	return s
+}
+
+// labelExtent is the extent of a label when fitting the viewBox, with the
+// builder's label margins, overshoot and metrics applied.
+type labelExtent struct {
+	s     *Builder
+	label *mp.Label
+}
+
+// Extent implements mp.ExtentContributor.
+func (e labelExtent) Extent() (minX, minY, maxX, maxY float64, ok bool) {
+	s, l := e.s, e.label
+	measured := false
+	if s.labelMetrics != nil {
+		if glyphs, err := l.ToPaths(s.labelMetrics); err == nil && len(glyphs) > 0 {
+			minX, minY = math.Inf(1), math.Inf(1)
+			maxX, maxY = math.Inf(-1), math.Inf(-1)
+			for _, g := range glyphs {
+				if x0, y0, x1, y1, gok := g.Extent(); gok {
+					minX, minY = math.Min(minX, x0), math.Min(minY, y0)
+					maxX, maxY = math.Max(maxX, x1), math.Max(maxY, y1)
+					measured = true
+				}
+			}
+		}
+	}
+	if !measured {
+		if minX, minY, maxX, maxY, ok = l.Extent(); !ok {
+			return 0, 0, 0, 0, false
+		}
+		size := l.FontSize
+		if size == 0 {
+			size = mp.DefaultFontSize
+		}
+		o := s.labelOvershoot * size
+		minX, minY, maxX, maxY = minX-o, minY-o, maxX+o, maxY+o
+	}
+	m := s.labelMargin
+	return minX - m[3], minY - m[2], maxX + m[1], maxY + m[0], true
+}
+
+// SetModelBackground fills the final bounding box of the drawing (content plus
+// margins) with color, drawn behind all paths. Unlike SetBackground, the
+// rectangle lives in model coordinates and is only known once the viewBox
+// has been fitted (explicitly or automatically by WriteTo).
+func (s *Builder) SetModelBackground(color mp.Color) *Builder {
+	s.modelBG = color
+	return s
+}
+
+// Frame draws a rectangular border around the final bounding box of the
+// drawing (content plus margins) using the given style. The rectangle is
+// inset by half the stroke width, so the whole frame stays inside the
+// viewBox. A nil style removes the frame.
+//
+// Example:
+//
+//	b.Margin(5).Frame(&mp.Style{Stroke: mp.ColorCSS("gray"), StrokeWidth: 1})
+func (s *Builder) Frame(style *mp.Style) *Builder {
+	s.frame = style
+	return s
+}
+
+// setModelBox records the final model-space bounding box used by
+// SetModelBackground and Frame.
+func (s *Builder) setModelBox(minX, minY, maxX, maxY float64) {
+	s.modelBox = [4]float64{minX, minY, maxX, maxY}
+	s.modelBoxSet = true
+}
+
+// modelRect returns a closed rectangle path in model coordinates, inset by
+// inset on every side.
+func (s *Builder) modelRect(inset float64) *mp.Path {
+	b := s.modelBox
+	w := b[2] - b[0] - 2*inset
+	h := b[3] - b[1] - 2*inset
+	return mp.XScaled(w).Then(mp.YScaled(h)).Then(mp.Shifted(b[0]+inset, b[1]+inset)).ApplyToPath(mp.UnitSquare())
+}
+
+// ModelTransform returns the transform from model coordinates to the SVG user
+// coordinates of the output (the coordinate system of the viewBox). It is
+// only meaningful once the viewBox has been fitted, i.e. after
+// FitViewBoxToPaths/FitViewBoxToPictures or WriteTo.
+func (s *Builder) ModelTransform() mp.Transform {
+	if s.metaPostCompat {
+		// x_svg = x - offsetX, y_svg = maxY - y + offsetY (see PathToSVGTransformed)
+		return mp.Transform{Txx: 1, Tx: -s.mpOffsetX, Tyy: -1, Ty: s.mpMaxY + s.mpOffsetY}
+	}
+	if s.flipY {
+		var vx, vy, vw, vh float64
+		_, _ = fmt.Sscanf(s.viewBox, "%f %f %f %f", &vx, &vy, &vw, &vh)
+		return mp.Transform{Txx: 1, Tyy: -1, Ty: 2*vy + vh}
+	}
+	return mp.Identity()
+}
+
 // SetViewBox sets an explicit viewBox. The auto-fit is disabled.
 func (s *Builder) SetViewBox(minX, minY, width, height float64) *Builder {
 	s.viewBox = fmt.Sprintf("%g %g %g %g", minX, minY, width, height)
@@ -331,8 +464,9 @@ func (s *Builder) AutoViewBox(pad float64) *Builder {
 	return s
 }
 
-// FitViewBoxToPaths computes a tight bounding box over the given paths (including
-// cubic extrema) and applies padding plus half the max stroke width (builder
+// FitViewBoxToPaths computes a tight bounding box over the extents of the
+// given paths (see mp.Path.Extent: cubic extrema, envelopes, arrowheads and
+// square caps) and applies padding plus half the max stroke width (builder
 // default or per-path Style). Useful to ensure the full drawing is visible
 // regardless of content size.
 //
@@ -341,93 +475,13 @@ func (s *Builder) AutoViewBox(pad float64) *Builder {
 func (s *Builder) FitViewBoxToPaths(paths ...*mp.Path) *Builder {
 	s.viewBoxSet = true
 	pad := s.padding
-	minx, miny := math.Inf(1), math.Inf(1)
-	maxx, maxy := math.Inf(-1), math.Inf(-1)
-	maxStroke := s.strokeWidth
-	hasEnvelope := false // Track if any path has an envelope (for stroke padding)
-	expand := func(x, y float64) {
-		if x < minx {
-			minx = x
-		}
-		if x > maxx {
-			maxx = x
-		}
-		if y < miny {
-			miny = y
-		}
-		if y > maxy {
-			maxy = y
-		}
-	}
-	// Helper to expand bounds from a path
-	expandPath := func(p *mp.Path) {
-		if p == nil || p.Head == nil {
-			return
-		}
-		k := p.Head
-		for {
-			q := k.Next
-			// Include endpoints
-			expand(k.XCoord, k.YCoord)
-			// Include cubic extrema
-			minx, maxx = bbox1D(k.XCoord, k.RightX, q.LeftX, q.XCoord, minx, maxx)
-			miny, maxy = bbox1D(k.YCoord, k.RightY, q.LeftY, q.YCoord, miny, maxy)
-			k = q
-			if k == p.Head || k == nil || k.RType == mp.KnotEndpoint {
-				expand(k.XCoord, k.YCoord)
-				break
-			}
-		}
-	}
+	items := make([]mp.ExtentContributor, 0, len(paths))
 	for _, p := range paths {
-		if p == nil || p.Head == nil {
-			continue
-		}
-		if p.Style.StrokeWidth > 0 && p.Style.StrokeWidth > maxStroke {
-			maxStroke = p.Style.StrokeWidth
-		}
-		// For elliptical pens, use GetPenScale to determine stroke width (mp.w:11529-11547)
-		if pen := p.Style.Pen; pen != nil && pen.Elliptical {
-			if scale := mp.GetPenScale(pen); scale > maxStroke {
-				maxStroke = scale
-			}
-		}
-		// If there's an envelope (from non-elliptical pen), use it for bounds;
-		// otherwise use the main path
-		if p.Envelope != nil {
-			hasEnvelope = true
-			expandPath(p.Envelope)
-		} else {
-			expandPath(p)
-		}
-		// Also include arrow heads in bounds calculation
-		if p.Style.Arrow.End {
-			ahLen := p.Style.Arrow.Length
-			ahAng := p.Style.Arrow.Angle
-			if ahLen <= 0 {
-				ahLen = mp.DefaultAHLength
-			}
-			if ahAng <= 0 {
-				ahAng = mp.DefaultAHAngle
-			}
-			if arrow := mp.ArrowHeadEnd(p, ahLen, ahAng); arrow != nil {
-				expandPath(arrow)
-			}
-		}
-		if p.Style.Arrow.Start {
-			ahLen := p.Style.Arrow.Length
-			ahAng := p.Style.Arrow.Angle
-			if ahLen <= 0 {
-				ahLen = mp.DefaultAHLength
-			}
-			if ahAng <= 0 {
-				ahAng = mp.DefaultAHAngle
-			}
-			if arrow := mp.ArrowHeadStart(p, ahLen, ahAng); arrow != nil {
-				expandPath(arrow)
-			}
+		if p != nil {
+			items = append(items, p)
 		}
 	}
+	minx, miny, maxx, maxy, maxStroke, hasEnvelope := s.contentExtent(items)
 	if math.IsInf(minx, 1) {
 		// fallback: keep existing viewBox if nothing found
 		return s
@@ -447,12 +501,13 @@ func (s *Builder) FitViewBoxToPaths(paths ...*mp.Path) *Builder {
 		s.mpOffsetX = 0
 		s.mpOffsetY = 0
 		// viewBox dimensions: account for negative coordinates
-		viewBoxMinX := minx - halfStroke
-		viewBoxMaxX := maxx + halfStroke
-		viewBoxMinY := miny - halfStroke
-		viewBoxMaxY := maxy + halfStroke
+		viewBoxMinX := minx - halfStroke - s.margin[3]
+		viewBoxMaxX := maxx + halfStroke + s.margin[1]
+		viewBoxMinY := miny - halfStroke - s.margin[2]
+		viewBoxMaxY := maxy + halfStroke + s.margin[0]
 		viewBoxW := viewBoxMaxX - viewBoxMinX
 		viewBoxH := viewBoxMaxY - viewBoxMinY
+		s.setModelBox(viewBoxMinX, viewBoxMinY, viewBoxMaxX, viewBoxMaxY)
 		s.mpMaxY = viewBoxMaxY // Y-flip reference point
 		s.mpOffsetX = viewBoxMinX
 		s.viewBox = fmt.Sprintf("0 0 %g %g", viewBoxW, viewBoxH)
@@ -464,10 +519,12 @@ func (s *Builder) FitViewBoxToPaths(paths ...*mp.Path) *Builder {
 	}
 	halfStroke := maxStroke / 2
 	totalPad := pad + halfStroke
-	s.viewBox = fmt.Sprintf("%g %g %g %g", minx-totalPad, miny-totalPad, w+2*totalPad, h+2*totalPad)
+	mt, mr, mb, ml := s.margin[0], s.margin[1], s.margin[2], s.margin[3]
+	s.viewBox = fmt.Sprintf("%g %g %g %g", minx-totalPad-ml, miny-totalPad-mb, w+2*totalPad+ml+mr, h+2*totalPad+mt+mb)
+	s.setModelBox(minx-totalPad-ml, miny-totalPad-mb, maxx+totalPad+mr, maxy+totalPad+mt)
 	if s.autoSize {
-		s.width = w + 2*totalPad
-		s.height = h + 2*totalPad
+		s.width = w + 2*totalPad + ml + mr
+		s.height = h + 2*totalPad + mt + mb
 	}
 	return s
 }
@@ -479,6 +536,43 @@ type Picture interface {
 	ClipPath() *mp.Path
 }
 
+// MultiPathPicture is implemented by pictures that also carry multi-part
+// paths (see mp.MultiPath). AddPicture renders them after the plain paths
+// unless the picture is also a StackedPicture.
+type MultiPathPicture interface {
+	MultiPaths() []*mp.MultiPath
+}
+
+// StackedPicture is implemented by pictures whose multi-paths are drawn
+// between their paths: MultiPathPositions()[i] is the number of paths
+// drawn before multi-path i. draw.Picture implements it.
+type StackedPicture interface {
+	MultiPathPositions() []int
+}
+
+// multiPathPositions returns the multi-paths of pic and the number of paths
+// drawn before each. Multi-paths without a position follow all paths.
+func multiPathPositions(pic Picture) ([]*mp.MultiPath, []int) {
+	mpic, ok := pic.(MultiPathPicture)
+	if !ok {
+		return nil, nil
+	}
+	multis := mpic.MultiPaths()
+	var at []int
+	if spic, ok := pic.(StackedPicture); ok {
+		at = spic.MultiPathPositions()
+	}
+	positions := make([]int, len(multis))
+	for i := range multis {
+		if i < len(at) {
+			positions[i] = at[i]
+		} else {
+			positions[i] = len(pic.Paths())
+		}
+	}
+	return multis, positions
+}
+
 // FitViewBoxToPictures computes a viewBox over all paths in the provided pictures.
 // If a picture has a clip path, the clip path's bounding box is used instead of
 // the content paths (matching MetaPost's behavior where viewBox reflects visible content).
@@ -493,6 +587,11 @@ func (s *Builder) FitViewBoxToPictures(pics ...Picture) *Builder {
 			paths = append(paths, clip)
 		} else {
 			paths = append(paths, pic.Paths()...)
+			if mpic, ok := pic.(MultiPathPicture); ok {
+				for _, m := range mpic.MultiPaths() {
+					paths = append(paths, multiPathParts(m)...)
+				}
+			}
 		}
 	}
 	return s.FitViewBoxToPaths(paths...)
@@ -509,6 +608,14 @@ func (s *Builder) SetStroke(color string, width float64) *Builder {
 	return s
 }
 
+// SetProgress sets a function called by WriteTo after each path, multi-path
+// and label written, with the number of elements done and the total. nil
+// disables progress reports.
+func (s *Builder) SetProgress(f mp.ProgressFunc) *Builder {
+	s.progress = f
+	return s
+}
+
 // WithColor sets the stroke color using a Color helper (e.g., ColorRGB/ColorCSS).
 func (s *Builder) WithColor(c mp.Color) *Builder {
 	s.stroke = c
@@ -559,8 +666,8 @@ func (s *Builder) AddPath(pathData string, stroke string) *Builder {
 	}
 	linecap := formatLineCap(s.lineCap)
 	linejoin := formatLineJoin(s.lineJoin)
-	attrs := fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="%.2f" stroke-linecap="%s" stroke-linejoin="%s"`,
-		s.fill.CSS(), color.CSS(), s.strokeWidth, linecap, linejoin)
+	attrs := fmt.Sprintf(`fill="%s" stroke="%s" %s stroke-linecap="%s" stroke-linejoin="%s"`,
+		s.paint(s.fill), s.paint(color), s.strokeWidthAttr(mp.Style{}, s.strokeWidth), linecap, linejoin)
 	if op, ok := color.Opacity(); ok {
 		attrs += fmt.Sprintf(` stroke-opacity="%.3f"`, op)
 	}
@@ -570,6 +677,7 @@ func (s *Builder) AddPath(pathData string, stroke string) *Builder {
 	s.paths = append(s.paths, fmt.Sprintf(
 		`<path d="%s" %s/>`,
 		pathData, attrs))
+	s.pathSources = append(s.pathSources, nil)
 	return s
 }
 
@@ -579,17 +687,85 @@ func (s *Builder) AddPathFromPath(p *mp.Path) *Builder {
 	if p == nil {
 		return s
 	}
-	// If an envelope was precomputed, render that instead
-	if p.Envelope != nil {
+	// An open path with a fill is closed for the fill or refused
+	parts, err := mp.ExpandOpenFill(p)
+	if err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+		return s
+	}
+	if parts[0] != p {
+		for _, q := range parts {
+			s.AddPathFromPath(q)
+		}
+		return s
+	}
+	// A closing segment with a style of its own is drawn separately
+	if p.Style.Closing != nil && p.Head != nil && p.Head.LType != mp.KnotEndpoint {
+		for _, q := range mp.ExpandClosingStyle(p) {
+			s.AddPathFromPath(q)
+		}
+		return s
+	}
+	// Cartographic line styles are drawn as the plain paths of the symbol
+	if p.Style.LineStyle != mp.LineStyleSolid {
+		for _, q := range mp.ExpandLineStyle(p) {
+			s.AddPathFromPath(q)
+		}
+		return s
+	}
+	// Gradient strokes are drawn as pieces of interpolated color
+	if p.Style.Gradient != nil && p.Envelope == nil {
+		for _, q := range mp.ExpandStrokeGradient(p) {
+			s.AddPathFromPath(q)
+		}
+		return s
+	}
+	// A point path is drawn as a dot in the shape of the pen
+	if p.Envelope == nil && p.IsPoint() && p.Style.Stroke.CSS() != "none" {
+		pen := p.Style.Pen
+		if pen == nil {
+			width := p.Style.StrokeWidth
+			if width <= 0 {
+				width = s.strokeWidth
+			}
+			pen = mp.PenCircle(width)
+		}
+		x, y := p.PointOf(0)
+		if dot := mp.PenDot(pen, x, y); dot != nil {
+			dot.Style.Fill = p.Style.Stroke
+			if dot.Style.Fill.CSS() == "" {
+				dot.Style.Fill = s.stroke
+			}
+			dot.Style.Stroke = mp.ColorCSS("none")
+			return s.AddPathFromPath(dot)
+		}
+	}
+	// If an envelope was precomputed, render that instead (hairlines ignore
+	// the pen)
+	if p.Envelope != nil && !p.Style.Hairline {
 		// Store original path for auto viewBox calculation (includes envelope info)
 		s.mpOrigPaths = append(s.mpOrigPaths, p)
 		// Envelope is a filled shape representing the stroked path.
 		// MetaPost renders envelopes with fill only, no stroke (stroke: none).
+		// Arrowheads are taken from the path itself and sized for the pen.
+		if p.Style.Arrow.Joined {
+			if m := mp.JoinedArrowOutline(p); m != nil {
+				s.addMulti(m)
+				return s
+			}
+		}
 		envelope := p.Envelope
-		envelope.Style.Arrow = p.Style.Arrow
+		envelope.Style.Arrow = mp.ArrowStyle{}
 		envelope.Style.Fill = p.Style.Stroke        // Fill with the stroke color
 		envelope.Style.Stroke = mp.ColorCSS("none") // No SVG stroke on envelope
-		return s.AddPathFromPath(envelope)
+		envelope.Style.Meta = p.Style.Meta
+		s.AddPathFromPath(envelope)
+		for _, head := range mp.EnvelopeArrowHeads(p) {
+			s.AddPathFromPath(head)
+		}
+		return s
 	}
 	// For MetaPost-compatible mode, store paths and defer rendering to WriteTo
 	if s.metaPostCompat {
@@ -609,15 +785,13 @@ func (s *Builder) AddPathFromPath(p *mp.Path) *Builder {
 		ahAngStart := ahAngEnd
 
 		// The arrow base is at distance ahlength * cos(ahangle/2) from the apex
-		// (not the full ahlength, which is the distance to the base corners)
-		halfAngleRad := ahAngEnd * 3.14159265358979323846 / 360.0
-		cosHalfAngle := math.Cos(halfAngleRad)
-
+		// (not the full ahlength, which is the distance to the base corners);
+		// harpoons are not cut back
 		if p.Style.Arrow.End {
-			shortenEnd = ahLenEnd * mp.Number(cosHalfAngle)
+			shortenEnd = mp.ArrowHeadDepth(p.Style.Arrow)
 		}
 		if p.Style.Arrow.Start {
-			shortenStart = ahLenStart * mp.Number(cosHalfAngle)
+			shortenStart = mp.ArrowHeadDepth(p.Style.Arrow)
 		}
 
 		// If arrows are present, shorten the path (like MetaPost's cutafter)
@@ -683,8 +857,8 @@ func (s *Builder) AddPathFromPath(p *mp.Path) *Builder {
 		if scale > 0 {
 			width = scale
 		}
-		attrs := fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="%.2f" stroke-linecap="%s" stroke-linejoin="%s"`,
-			fill.CSS(), color.CSS(), width, linecap, linejoin)
+		attrs := fmt.Sprintf(`fill="%s" stroke="%s" %s stroke-linecap="%s" stroke-linejoin="%s"`,
+			s.paint(fill), s.paint(color), s.strokeWidthAttr(p.Style, width), linecap, linejoin)
 		if op, ok := color.Opacity(); ok {
 			attrs += fmt.Sprintf(` stroke-opacity="%.3f"`, op)
 		}
@@ -695,6 +869,7 @@ func (s *Builder) AddPathFromPath(p *mp.Path) *Builder {
 		s.paths = append(s.paths, fmt.Sprintf(
 			`<path d="%s" %s/>`,
 			pathData, attrs))
+		s.pathSources = append(s.pathSources, p)
 		return s
 	}
 
@@ -716,8 +891,8 @@ func (s *Builder) AddPathFromPath(p *mp.Path) *Builder {
 		}
 	}
 
-	attrs := fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="%.2f" stroke-linecap="%s" stroke-linejoin="%s"`,
-		fill.CSS(), color.CSS(), width, linecap, linejoin)
+	attrs := fmt.Sprintf(`fill="%s" stroke="%s" %s stroke-linecap="%s" stroke-linejoin="%s"`,
+		s.paint(fill), s.paint(color), s.strokeWidthAttr(p.Style, width), linecap, linejoin)
 	if op, ok := color.Opacity(); ok {
 		attrs += fmt.Sprintf(` stroke-opacity="%.3f"`, op)
 	}
@@ -728,9 +903,98 @@ func (s *Builder) AddPathFromPath(p *mp.Path) *Builder {
 	s.paths = append(s.paths, fmt.Sprintf(
 		`<path d="%s" %s/>`,
 		pathData, attrs))
+	s.pathSources = append(s.pathSources, p)
+	return s
+}
+
+// AddMultiPath renders all parts of m as a single SVG path element using the
+// style of m. Parts with an envelope (non-elliptical pens) contribute the
+// envelope outline instead, filled with the stroke color. Arrows are not
+// drawn for multi-part paths.
+func (s *Builder) AddMultiPath(m *mp.MultiPath) *Builder {
+	if m == nil || len(m.Parts) == 0 {
+		return s
+	}
+	if s.metaPostCompat {
+		s.mpOrigPaths = append(s.mpOrigPaths, multiPathParts(m)...)
+	}
+	s.addMulti(m)
 	return s
 }
 
+// addMulti queues m behind the paths added so far.
+func (s *Builder) addMulti(m *mp.MultiPath) {
+	at := len(s.paths)
+	if s.metaPostCompat {
+		at = len(s.mpPaths)
+	}
+	s.multiPaths = append(s.multiPaths, m)
+	s.multiAt = append(s.multiAt, at)
+}
+
+// multiPathParts returns the parts of m carrying the style of m, so that
+// bounding box computations see the right stroke widths.
+func multiPathParts(m *mp.MultiPath) []*mp.Path {
+	if m == nil {
+		return nil
+	}
+	parts := make([]*mp.Path, 0, len(m.Parts))
+	for _, p := range m.Parts {
+		if p == nil || p.Head == nil {
+			continue
+		}
+		q := *p
+		q.Style = m.Style
+		q.Style.Arrow = mp.ArrowStyle{}
+		parts = append(parts, &q)
+	}
+	return parts
+}
+
+// writeMultiPathElement writes all parts of m as one path element. Parts
+// with a pen envelope are filled with the stroke color instead of being
+// stroked, so a multi-path mixing both kinds is written as one element per
+// run of parts of the same kind.
+func (s *Builder) writeMultiPathElement(w io.Writer, m *mp.MultiPath) error {
+	envStyle := m.Style
+	envStyle.Fill = m.Style.Stroke
+	envStyle.Stroke = mp.ColorCSS("none")
+
+	var data []string
+	var parts []*mp.Path
+	envelope := false
+	flush := func() error {
+		if len(data) == 0 {
+			return nil
+		}
+		style := m.Style
+		if envelope {
+			style = envStyle
+		}
+		err := s.writeStyledPath(w, strings.Join(data, " "), style, s.metaAttrs(style, parts...))
+		data, parts = nil, nil
+		return err
+	}
+	for _, p := range m.Parts {
+		if p == nil || p.Head == nil {
+			continue
+		}
+		if (p.Envelope != nil) != envelope {
+			if err := flush(); err != nil {
+				return err
+			}
+			envelope = p.Envelope != nil
+		}
+		style := m.Style
+		if envelope {
+			p, style = p.Envelope, envStyle
+		}
+		data = append(data, s.pathData(p, style))
+		parts = append(parts, p)
+	}
+	return flush()
+}
+
 // AddPicture renders every path stored in the picture using their Style (if set),
 // mirroring how MetaPost pictures collect edges before backend output.
 // If the picture has a clip path set, all paths will be clipped to that boundary.
@@ -744,19 +1008,29 @@ func (s *Builder) AddPicture(pic Picture) *Builder {
 		// Add clip path to the list and create a clipped group
 		clipIndex := len(s.clipPaths)
 		s.clipPaths = append(s.clipPaths, clip)
-		s.clippedGroups = append(s.clippedGroups, clippedGroup{
+		group := clippedGroup{
 			clipIndex: clipIndex,
 			paths:     pic.Paths(),
-		})
+		}
+		group.multiPaths, group.multiAt = multiPathPositions(pic)
+		s.clippedGroups = append(s.clippedGroups, group)
 		// Add labels (not clipped for now, matching MetaPost behavior)
 		s.labels = append(s.labels, pic.Labels()...)
 		return s
 	}
 
-	// No clipping - add paths directly
-	for _, p := range pic.Paths() {
+	// No clipping - add paths directly, keeping multi-paths in between
+	multis, at := multiPathPositions(pic)
+	next := 0
+	for i, p := range pic.Paths() {
+		for ; next < len(multis) && at[next] <= i; next++ {
+			s.AddMultiPath(multis[next])
+		}
 		s.AddPathFromPath(p)
 	}
+	for ; next < len(multis); next++ {
+		s.AddMultiPath(multis[next])
+	}
 	// Add labels
 	s.labels = append(s.labels, pic.Labels()...)
 	return s
@@ -766,103 +1040,27 @@ func (s *Builder) AddPicture(pic Picture) *Builder {
 func (s *Builder) fitViewBoxToContent() {
 	s.viewBoxSet = true
 	pad := s.padding
-	minx, miny := math.Inf(1), math.Inf(1)
-	maxx, maxy := math.Inf(-1), math.Inf(-1)
-	maxStroke := s.strokeWidth
-	hasEnvelope := false
-
-	expand := func(x, y float64) {
-		if x < minx {
-			minx = x
-		}
-		if x > maxx {
-			maxx = x
-		}
-		if y < miny {
-			miny = y
-		}
-		if y > maxy {
-			maxy = y
-		}
-	}
-
-	// Include paths (same logic as FitViewBoxToPaths)
+	items := make([]mp.ExtentContributor, 0, len(s.mpOrigPaths)+len(s.labels)+len(s.extents))
 	for _, p := range s.mpOrigPaths {
-		if p == nil || p.Head == nil {
-			continue
-		}
-		if p.Style.StrokeWidth > 0 && p.Style.StrokeWidth > maxStroke {
-			maxStroke = p.Style.StrokeWidth
-		}
-		if pen := p.Style.Pen; pen != nil && pen.Elliptical {
-			if scale := mp.GetPenScale(pen); scale > maxStroke {
-				maxStroke = scale
-			}
-		}
-		if p.Envelope != nil {
-			hasEnvelope = true
-			lminX, lminY, lmaxX, lmaxY := PathBBox(p.Envelope)
-			expand(lminX, lminY)
-			expand(lmaxX, lmaxY)
-		} else {
-			lminX, lminY, lmaxX, lmaxY := PathBBox(p)
-			expand(lminX, lminY)
-			expand(lmaxX, lmaxY)
-		}
-		// Include arrow heads
-		if p.Style.Arrow.End {
-			ahLen := p.Style.Arrow.Length
-			if ahLen <= 0 {
-				ahLen = mp.DefaultAHLength
-			}
-			ahAng := p.Style.Arrow.Angle
-			if ahAng <= 0 {
-				ahAng = mp.DefaultAHAngle
-			}
-			if arrow := mp.ArrowHeadEnd(p, ahLen, ahAng); arrow != nil {
-				lminX, lminY, lmaxX, lmaxY := PathBBox(arrow)
-				expand(lminX, lminY)
-				expand(lmaxX, lmaxY)
-			}
-		}
-		if p.Style.Arrow.Start {
-			ahLen := p.Style.Arrow.Length
-			if ahLen <= 0 {
-				ahLen = mp.DefaultAHLength
-			}
-			ahAng := p.Style.Arrow.Angle
-			if ahAng <= 0 {
-				ahAng = mp.DefaultAHAngle
-			}
-			if arrow := mp.ArrowHeadStart(p, ahLen, ahAng); arrow != nil {
-				lminX, lminY, lmaxX, lmaxY := PathBBox(arrow)
-				expand(lminX, lminY)
-				expand(lmaxX, lmaxY)
-			}
+		if p != nil {
+			items = append(items, p)
 		}
 	}
-
-	// Include clipped groups (use clip path bounds, like MetaPost)
+	// Clipped groups count with their clip path, like in MetaPost.
 	for _, cg := range s.clippedGroups {
 		if cg.clipIndex >= 0 && cg.clipIndex < len(s.clipPaths) {
-			clip := s.clipPaths[cg.clipIndex]
-			if clip != nil && clip.Head != nil {
-				lminX, lminY, lmaxX, lmaxY := PathBBox(clip)
-				expand(lminX, lminY)
-				expand(lmaxX, lmaxY)
+			if clip := s.clipPaths[cg.clipIndex]; clip != nil {
+				items = append(items, clip)
 			}
 		}
 	}
-
-	// Include labels
 	for _, label := range s.labels {
-		if label == nil {
-			continue
+		if label != nil {
+			items = append(items, labelExtent{s, label})
 		}
-		lminX, lminY, lmaxX, lmaxY := label.EstimateBounds()
-		expand(lminX, lminY)
-		expand(lmaxX, lmaxY)
 	}
+	items = append(items, s.extents...)
+	minx, miny, maxx, maxy, maxStroke, hasEnvelope := s.contentExtent(items)
 
 	if math.IsInf(minx, 1) {
 		return // No content
@@ -875,13 +1073,14 @@ func (s *Builder) fitViewBoxToContent() {
 	}
 	halfStroke += pad
 
-	viewBoxMinX := minx - halfStroke
-	viewBoxMaxX := maxx + halfStroke
-	viewBoxMinY := miny - halfStroke
-	viewBoxMaxY := maxy + halfStroke
+	viewBoxMinX := minx - halfStroke - s.margin[3]
+	viewBoxMaxX := maxx + halfStroke + s.margin[1]
+	viewBoxMinY := miny - halfStroke - s.margin[2]
+	viewBoxMaxY := maxy + halfStroke + s.margin[0]
 	viewBoxW := viewBoxMaxX - viewBoxMinX
 	viewBoxH := viewBoxMaxY - viewBoxMinY
 
+	s.setModelBox(viewBoxMinX, viewBoxMinY, viewBoxMaxX, viewBoxMaxY)
 	s.mpMaxY = viewBoxMaxY
 	s.mpOffsetX = viewBoxMinX
 	s.viewBox = fmt.Sprintf("0 0 %g %g", viewBoxW, viewBoxH)
@@ -891,7 +1090,68 @@ func (s *Builder) fitViewBoxToContent() {
 	}
 }
 
+// AddExtent makes the automatic viewBox include the extent of c, for
+// content the builder cannot measure itself, such as elements added with
+// AddPath or drawn by callers around the SVG output.
+func (s *Builder) AddExtent(c mp.ExtentContributor) *Builder {
+	if c != nil {
+		s.extents = append(s.extents, c)
+	}
+	return s
+}
+
+// contentExtent returns the union of the extents of items (infinite if
+// there are none), the largest stroke width among the paths and whether
+// any path is drawn as an envelope, which already includes its pen.
+func (s *Builder) contentExtent(items []mp.ExtentContributor) (minx, miny, maxx, maxy, maxStroke float64, hasEnvelope bool) {
+	minx, miny = math.Inf(1), math.Inf(1)
+	maxx, maxy = math.Inf(-1), math.Inf(-1)
+	maxStroke = s.strokeWidth
+	stroke := func(p *mp.Path) {
+		if p.Envelope != nil {
+			hasEnvelope = true
+		}
+		maxStroke = math.Max(maxStroke, p.Style.StrokeWidth)
+		// For elliptical pens, use GetPenScale to determine stroke width (mp.w:11529-11547)
+		if pen := p.Style.Pen; pen != nil && pen.Elliptical {
+			maxStroke = math.Max(maxStroke, mp.GetPenScale(pen))
+		}
+	}
+	for _, item := range items {
+		x0, y0, x1, y1, ok := item.Extent()
+		if !ok {
+			continue
+		}
+		minx, miny = math.Min(minx, x0), math.Min(miny, y0)
+		maxx, maxy = math.Max(maxx, x1), math.Max(maxy, y1)
+		if p, isPath := item.(*mp.Path); isPath {
+			for _, q := range mp.ExpandLineStyle(p) {
+				stroke(q)
+			}
+		}
+	}
+	return minx, miny, maxx, maxy, maxStroke, hasEnvelope
+}
+
+// WriteTo writes the SVG document to w. It fails with mp.ErrOpenFill,
+// writing nothing, if an open path with a fill but without
+// Style.AutoCloseFill was added.
 func (s *Builder) WriteTo(w io.Writer) error {
+	return s.WriteToContext(context.Background(), w)
+}
+
+// WriteToContext is WriteTo with cancellation: ctx is checked after each
+// path, multi-path and label, and ctx.Err() is returned once it is done,
+// leaving an incomplete document in w. Use it to abort server-side
+// rendering of large pictures when the client goes away.
+func (s *Builder) WriteToContext(ctx context.Context, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.err != nil {
+		return s.err
+	}
+	s.resetIndex()
 	// Auto-fit viewBox if not explicitly set and we have content
 	if !s.viewBoxSet && (len(s.mpOrigPaths) > 0 || len(s.labels) > 0 || len(s.clippedGroups) > 0) {
 		s.fitViewBoxToContent()
@@ -900,11 +1160,10 @@ func (s *Builder) WriteTo(w io.Writer) error {
 	if vb == "" {
 		vb = fmt.Sprintf("0 0 %g %g", s.width, s.height)
 	}
-	if s.autoSize {
-		if _, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="%s">`, vb); err != nil {
-			return err
-		}
-	} else if _, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="%s">`, s.width, s.height, vb); err != nil {
+	if err := s.writeRoot(w, vb); err != nil {
+		return err
+	}
+	if err := s.writeThemeStyle(w); err != nil {
 		return err
 	}
 
@@ -942,28 +1201,101 @@ func (s *Builder) WriteTo(w io.Writer) error {
 		}
 	}
 
+	// Model-space background behind all content
+	if s.modelBoxSet && s.modelBG.CSS() != "" {
+		bg := s.modelRect(0)
+		bg.Style.Fill = s.modelBG
+		bg.Style.Stroke = mp.ColorCSS("none")
+		if err := s.writePathElement(w, bg); err != nil {
+			return err
+		}
+	}
+
+	// Progress reporting over all content elements
+	total := len(s.paths) + len(s.multiPaths) + len(s.labels)
+	for _, group := range s.clippedGroups {
+		total += len(group.paths) + len(group.multiPaths)
+	}
+	if s.metaPostCompat {
+		total += len(s.mpPaths)
+	}
+	done := 0
+	tick := func() error {
+		done++
+		if s.progress != nil {
+			s.progress(done, total)
+		}
+		return ctx.Err()
+	}
+
 	// Render clipped groups
 	for _, group := range s.clippedGroups {
 		if _, err := fmt.Fprintf(w, `<g clip-path="url(#clip%d)">`, group.clipIndex); err != nil {
 			return err
 		}
-		for _, p := range group.paths {
-			if err := s.writePathElement(w, p); err != nil {
+		next := 0
+		writeGroupMultis := func(upTo int) error {
+			for ; next < len(group.multiPaths) && (upTo < 0 || group.multiAt[next] <= upTo); next++ {
+				if err := s.writeMultiPathElement(w, group.multiPaths[next]); err != nil {
+					return err
+				}
+				if err := tick(); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for i, p := range group.paths {
+			if err := writeGroupMultis(i); err != nil {
+				return err
+			}
+			parts, err := mp.ExpandOpenFill(p)
+			if err != nil {
+				return err
+			}
+			for _, q := range parts {
+				if err := s.writePathElement(w, q); err != nil {
+					return err
+				}
+			}
+			if err := tick(); err != nil {
 				return err
 			}
 		}
+		if err := writeGroupMultis(-1); err != nil {
+			return err
+		}
 		if _, err := io.WriteString(w, "</g>"); err != nil {
 			return err
 		}
 	}
 
+	// Multi-part paths are written between the paths they were added
+	// between: before path number upTo of the mode they were added in, or
+	// all remaining ones for a negative upTo.
+	nextMulti := 0
+	writeMultis := func(upTo int) error {
+		for ; nextMulti < len(s.multiPaths) && (upTo < 0 || s.multiAt[nextMulti] <= upTo); nextMulti++ {
+			if err := s.writeMultiPathElement(w, s.multiPaths[nextMulti]); err != nil {
+				return err
+			}
+			if err := tick(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
 	// MetaPost-compatible mode: render paths with transformed coordinates
 	if s.metaPostCompat && len(s.mpPaths) > 0 {
 		// MetaPost uses y_svg = maxY - y_orig and shifts X so viewBox starts at (0,0).
 		// We apply the same transformation using stored offsets.
 		flipHeight := s.mpMaxY
-		for _, p := range s.mpPaths {
-			pathData := PathToSVGTransformed(p, s.mpOffsetX, s.mpOffsetY, flipHeight)
+		for i, p := range s.mpPaths {
+			if err := writeMultis(i); err != nil {
+				return err
+			}
+			pathData := PathToSVGTransformed(s.snapPath(p, p.Style), s.mpOffsetX, s.mpOffsetY, flipHeight)
 			fill := s.fill
 			color := s.stroke
 			if p.Style.Fill.CSS() != "" {
@@ -972,8 +1304,9 @@ func (s *Builder) WriteTo(w io.Writer) error {
 			if p.Style.Stroke.CSS() != "" {
 				color = p.Style.Stroke
 			}
+			meta := s.metaAttrs(p.Style, p)
 			if color.CSS() == "none" {
-				if _, err := fmt.Fprintf(w, `<path d="%s" fill="%s" stroke="none"/>`, pathData, fill.CSS()); err != nil {
+				if _, err := fmt.Fprintf(w, `<path d="%s" fill="%s" stroke="none"%s/>`, pathData, s.paint(fill), meta); err != nil {
 					return err
 				}
 			} else {
@@ -990,23 +1323,61 @@ func (s *Builder) WriteTo(w io.Writer) error {
 				dashAttrs := FormatDashAttrs(p.Style.Dash)
 				linecap := formatLineCap(p.Style.LineCap)
 				linejoin := formatLineJoin(p.Style.LineJoin)
-				if _, err := fmt.Fprintf(w, `<path d="%s" fill="%s" stroke="%s" stroke-width="%.2f" stroke-linecap="%s" stroke-linejoin="%s"%s/>`,
-					pathData, fill.CSS(), color.CSS(), width, linecap, linejoin, dashAttrs); err != nil {
+				if _, err := fmt.Fprintf(w, `<path d="%s" fill="%s" stroke="%s" %s stroke-linecap="%s" stroke-linejoin="%s"%s%s/>`,
+					pathData, s.paint(fill), s.paint(color), s.strokeWidthAttr(p.Style, width), linecap, linejoin, dashAttrs, meta); err != nil {
 					return err
 				}
 			}
+			if err := tick(); err != nil {
+				return err
+			}
 		}
 	}
-	for _, p := range s.paths {
+	for i, p := range s.paths {
+		if !s.metaPostCompat {
+			if err := writeMultis(i); err != nil {
+				return err
+			}
+		}
+		if src := s.pathSources[i]; src != nil {
+			if meta := s.metaAttrs(src.Style, src); meta != "" {
+				p = strings.TrimSuffix(p, "/>") + meta + "/>"
+			}
+		}
 		if _, err := io.WriteString(w, p); err != nil {
 			return err
 		}
+		if err := tick(); err != nil {
+			return err
+		}
+	}
+	if err := writeMultis(-1); err != nil {
+		return err
 	}
 	// Render labels
 	for _, label := range s.labels {
 		if err := s.writeLabelElement(w, label); err != nil {
 			return err
 		}
+		if err := tick(); err != nil {
+			return err
+		}
+	}
+	// Frame around the final bounding box, on top of the content
+	if s.modelBoxSet && s.frame != nil {
+		width := s.frame.StrokeWidth
+		if width <= 0 {
+			width = s.strokeWidth
+		}
+		frame := s.modelRect(width / 2)
+		frame.Style = *s.frame
+		frame.Style.StrokeWidth = width
+		if frame.Style.Fill.CSS() == "" {
+			frame.Style.Fill = mp.ColorCSS("none")
+		}
+		if err := s.writePathElement(w, frame); err != nil {
+			return err
+		}
 	}
 	if s.flipY {
 		if _, err := io.WriteString(w, "</g>"); err != nil {
@@ -1019,38 +1390,68 @@ func (s *Builder) WriteTo(w io.Writer) error {
 
 // writePathElement writes a single path element to the SVG output.
 func (s *Builder) writePathElement(w io.Writer, p *mp.Path) error {
-	pathData := PathToSVG(p)
+	return s.writeStyledPath(w, s.pathData(p, p.Style), p.Style, s.metaAttrs(p.Style, p))
+}
+
+// pathData returns the SVG path data for p in the builder's output
+// coordinates. The style is used for pixel snapping (see SnapToPixels).
+func (s *Builder) pathData(p *mp.Path, style mp.Style) string {
 	if s.metaPostCompat {
-		pathData = PathToSVGTransformed(p, s.mpOffsetX, s.mpOffsetY, s.mpMaxY)
+		return PathToSVGTransformed(s.snapPath(p, style), s.mpOffsetX, s.mpOffsetY, s.mpMaxY)
 	}
+	return PathToSVG(p)
+}
+
+// writeStyledPath writes a path element with the given data and style,
+// falling back to the builder defaults. extra is appended to the
+// attributes (see metaAttrs).
+func (s *Builder) writeStyledPath(w io.Writer, pathData string, style mp.Style, extra string) error {
 	fill := s.fill
 	color := s.stroke
-	if p.Style.Fill.CSS() != "" {
-		fill = p.Style.Fill
+	if style.Fill.CSS() != "" {
+		fill = style.Fill
 	}
-	if p.Style.Stroke.CSS() != "" {
-		color = p.Style.Stroke
+	if style.Stroke.CSS() != "" {
+		color = style.Stroke
 	}
 	if color.CSS() == "none" {
-		_, err := fmt.Fprintf(w, `<path d="%s" fill="%s" stroke="none"/>`, pathData, fill.CSS())
+		_, err := fmt.Fprintf(w, `<path d="%s" fill="%s" stroke="none"%s/>`, pathData, s.paint(fill), extra)
 		return err
 	}
+	width := s.styleStrokeWidth(style)
+	dashAttrs := FormatDashAttrs(style.Dash)
+	linecap := formatLineCap(style.LineCap)
+	linejoin := formatLineJoin(style.LineJoin)
+	_, err := fmt.Fprintf(w, `<path d="%s" fill="%s" stroke="%s" %s stroke-linecap="%s" stroke-linejoin="%s"%s%s/>`,
+		pathData, s.paint(fill), s.paint(color), s.strokeWidthAttr(style, width), linecap, linejoin, dashAttrs, extra)
+	return err
+}
+
+// styleStrokeWidth returns the stroke width used for style: the style's
+// width, the builder default, or the scale of an elliptical pen.
+func (s *Builder) styleStrokeWidth(style mp.Style) float64 {
 	width := s.strokeWidth
-	if p.Style.StrokeWidth > 0 {
-		width = p.Style.StrokeWidth
+	if style.StrokeWidth > 0 {
+		width = style.StrokeWidth
 	}
 	// For elliptical pens, use the pen's scale as stroke width
-	if pen := p.Style.Pen; pen != nil && pen.Elliptical {
+	if pen := style.Pen; pen != nil && pen.Elliptical {
 		if scale := mp.GetPenScale(pen); scale > 0 {
 			width = scale
 		}
 	}
-	dashAttrs := FormatDashAttrs(p.Style.Dash)
-	linecap := formatLineCap(p.Style.LineCap)
-	linejoin := formatLineJoin(p.Style.LineJoin)
-	_, err := fmt.Fprintf(w, `<path d="%s" fill="%s" stroke="%s" stroke-width="%.2f" stroke-linecap="%s" stroke-linejoin="%s"%s/>`,
-		pathData, fill.CSS(), color.CSS(), width, linecap, linejoin, dashAttrs)
-	return err
+	return width
+}
+
+// strokeWidthAttr returns the stroke-width attribute for a stroke of the
+// given width drawn with style: hairlines are one device pixel wide at any
+// scale (vector-effect="non-scaling-stroke"), other strokes are at least
+// MinStrokeWidth wide.
+func (s *Builder) strokeWidthAttr(style mp.Style, width float64) string {
+	if style.Hairline {
+		return `stroke-width="1" vector-effect="non-scaling-stroke"`
+	}
+	return fmt.Sprintf(`stroke-width="%.2f"`, math.Max(width, s.minStrokeWidth))
 }
 
 // AddLabel adds a label to the SVG output.
@@ -1105,13 +1506,23 @@ func (s *Builder) writeLabelElement(w io.Writer, label *mp.Label) error {
 	if offset == 0 {
 		offset = mp.DefaultLabelOffset
 	}
+	if label.Angle != 0 {
+		sin, cos := math.Sincos(label.Angle * math.Pi / 180)
+		dx, dy = dx*cos-dy*sin, dx*sin+dy*cos
+	}
 	x := label.Position.X + dx*offset
 	y := label.Position.Y + dy*offset
 
 	// Transform coordinates for MetaPost-compatible mode
+	angle := label.Angle
 	if s.metaPostCompat {
 		x = x - s.mpOffsetX
 		y = s.mpMaxY - y + s.mpOffsetY
+		angle = -angle // SVG rotates clockwise in y-down coordinates
+	}
+	rotate := ""
+	if angle != 0 {
+		rotate = fmt.Sprintf(` transform="rotate(%.3f %.3f %.3f)"`, angle, x, y)
 	}
 
 	// Get SVG text attributes
@@ -1135,8 +1546,8 @@ func (s *Builder) writeLabelElement(w io.Writer, label *mp.Label) error {
 	}
 
 	// Write the text element
-	_, err := fmt.Fprintf(w, `<text x="%.3f" y="%.3f" font-family="%s" font-size="%.2f" fill="%s" text-anchor="%s" dominant-baseline="%s">%s</text>`,
-		x, y, fontFamily, fontSize, color.CSS(), textAnchor, dominantBaseline, escapeXML(label.Text))
+	_, err := fmt.Fprintf(w, `<text x="%.3f" y="%.3f" font-family="%s" font-size="%.2f" fill="%s" text-anchor="%s" dominant-baseline="%s"%s>%s</text>`,
+		x, y, fontFamily, fontSize, s.paint(color), textAnchor, dominantBaseline, rotate, escapeXML(label.PlainText()))
 	return err
 }
 
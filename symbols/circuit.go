@@ -0,0 +1,282 @@
+// Package symbols provides parameterized electrical circuit symbols and
+// orthogonal wiring between their connection anchors.
+//
+// Two-terminal elements are placed between two points: the symbol body is
+// centered between them, rotated along the connection, and joined to both
+// points by straight leads. Every symbol exposes its terminals as named
+// anchors ("a" and "b", or "a" alone for ground), which wires snap to.
+//
+// Example:
+//
+//	c := symbols.NewCircuit()
+//	v := c.Add(symbols.VoltageSource(mp.P(0, 0), mp.P(0, 60)))
+//	r := c.Add(symbols.Resistor(mp.P(30, 60), mp.P(90, 60), symbols.Zigzag))
+//	c.Wire(v.Anchor("b"), r.Anchor("a"))
+//	c.Add(symbols.Ground(mp.P(0, -10)))
+//	pic := c.Picture()
+package symbols
+
+import (
+	"math"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// ResistorStyle selects the resistor drawing convention.
+type ResistorStyle int
+
+const (
+	Zigzag ResistorStyle = iota // ANSI zigzag
+	IEC                         // IEC rectangle
+)
+
+// Symbol dimensions in bp.
+const (
+	bodyLength     = 24.0 // length of resistor and inductor bodies
+	zigzagAmp      = 4.0  // zigzag half height
+	iecHeight      = 8.0  // IEC resistor box height
+	plateGap       = 4.0  // capacitor plate distance
+	plateLength    = 12.0 // capacitor plate length
+	sourceDiameter = 16.0 // voltage/current source circle
+	groundLead     = 6.0  // ground lead length
+	groundWidth    = 12.0 // widest ground bar
+	lineWidth      = 0.6
+	junctionSize   = 2.5 // diameter of wire junction dots
+)
+
+// Symbol is a placed circuit element: its drawing and its named connection
+// anchors in model coordinates.
+type Symbol struct {
+	Picture *draw.Picture
+	Anchors map[string]mp.Point
+}
+
+// Anchor returns the named connection point. Unknown names return the
+// origin.
+func (s *Symbol) Anchor(name string) mp.Point {
+	return s.Anchors[name]
+}
+
+var symbolStyle = mp.Style{Stroke: mp.ColorCSS("black"), StrokeWidth: lineWidth}
+
+// straight returns the polyline through pts in the symbol style, or nil if
+// a point is not finite (Picture.AddPath skips nil paths).
+func straight(pts ...mp.Point) *mp.Path {
+	path, err := mp.StraightPath(pts, false)
+	if err != nil {
+		return nil
+	}
+	path.Style = symbolStyle
+	return path
+}
+
+// twoTerminal places a body drawn along the x axis, centered on the origin
+// and length units long, between a and b, and adds the leads.
+func twoTerminal(a, b mp.Point, length float64, body *draw.Picture) *Symbol {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	d := math.Hypot(dx, dy)
+	angle := math.Atan2(dy, dx) * 180 / math.Pi
+	mid := mp.P((a.X+b.X)/2, (a.Y+b.Y)/2)
+	body.Transform(mp.Rotated(angle).Then(mp.Shifted(mid.X, mid.Y)))
+	if d > length {
+		ux, uy := dx/d, dy/d
+		body.AddPath(straight(a, mp.P(mid.X-ux*length/2, mid.Y-uy*length/2)))
+		body.AddPath(straight(mp.P(mid.X+ux*length/2, mid.Y+uy*length/2), b))
+	}
+	return &Symbol{Picture: body, Anchors: map[string]mp.Point{"a": a, "b": b}}
+}
+
+// Resistor returns a resistor between a and b.
+func Resistor(a, b mp.Point, style ResistorStyle) *Symbol {
+	body := draw.NewPicture()
+	h := bodyLength / 2
+	if style == IEC {
+		box := mp.XScaled(bodyLength).Then(mp.YScaled(iecHeight)).
+			Then(mp.Shifted(-h, -iecHeight/2)).ApplyToPath(mp.UnitSquare())
+		box.Style = symbolStyle
+		body.AddPath(box)
+		return twoTerminal(a, b, bodyLength, body)
+	}
+	pts := []mp.Point{mp.P(-h, 0)}
+	const peaks = 6
+	for k := 1; k <= peaks; k++ {
+		y := zigzagAmp
+		if k%2 == 0 {
+			y = -zigzagAmp
+		}
+		pts = append(pts, mp.P(-h+(float64(k)-0.5)*bodyLength/peaks, y))
+	}
+	pts = append(pts, mp.P(h, 0))
+	body.AddPath(straight(pts...))
+	return twoTerminal(a, b, bodyLength, body)
+}
+
+// Capacitor returns a capacitor between a and b. Polarized capacitors get a
+// curved negative plate on the b side.
+func Capacitor(a, b mp.Point, polarized bool) *Symbol {
+	body := draw.NewPicture()
+	g, l := plateGap/2, plateLength/2
+	body.AddPath(straight(mp.P(-g, -l), mp.P(-g, l)))
+	if polarized {
+		plate, _ := draw.NewPath().MoveTo(mp.P(g+2, l)).CurveTo(mp.P(g, 0)).CurveTo(mp.P(g+2, -l)).Solve()
+		plate.Style = symbolStyle
+		body.AddPath(plate)
+		body.Label("+", mp.P(-g, l), mp.AnchorUpperLeft)
+	} else {
+		body.AddPath(straight(mp.P(g, -l), mp.P(g, l)))
+	}
+	return twoTerminal(a, b, plateGap, body)
+}
+
+// Inductor returns a coil with the given number of turns (default 4)
+// between a and b.
+func Inductor(a, b mp.Point, turns int) *Symbol {
+	if turns <= 0 {
+		turns = 4
+	}
+	body := draw.NewPicture()
+	d := bodyLength / float64(turns)
+	x := -bodyLength / 2
+	pb := draw.NewPath().MoveTo(mp.P(x, 0))
+	for i := 0; i < turns; i++ {
+		pb.CurveToDir(mp.P(x+d/2, d/2), 90, 0).CurveToDir(mp.P(x+d, 0), 0, -90)
+		x += d
+	}
+	coil, _ := pb.Solve()
+	coil.Style = symbolStyle
+	body.AddPath(coil)
+	return twoTerminal(a, b, bodyLength, body)
+}
+
+// VoltageSource returns a DC voltage source between a and b with the
+// positive terminal at b.
+func VoltageSource(a, b mp.Point) *Symbol {
+	body := sourceCircle()
+	r := sourceDiameter / 2
+	// Marks are placed in local coordinates, where b lies on the +x side.
+	body.AddPath(straight(mp.P(r/2-2, 0), mp.P(r/2+2, 0)))
+	body.AddPath(straight(mp.P(r/2, -2), mp.P(r/2, 2)))
+	body.AddPath(straight(mp.P(-r/2-2, 0), mp.P(-r/2+2, 0)))
+	return twoTerminal(a, b, sourceDiameter, body)
+}
+
+// CurrentSource returns a current source between a and b whose arrow points
+// from a to b.
+func CurrentSource(a, b mp.Point) *Symbol {
+	body := sourceCircle()
+	r := sourceDiameter / 2
+	arrow := straight(mp.P(-r/2, 0), mp.P(r/2, 0))
+	arrow.Style.Arrow = mp.ArrowStyle{End: true, Length: 3, Angle: mp.DefaultAHAngle}
+	body.AddPath(arrow)
+	return twoTerminal(a, b, sourceDiameter, body)
+}
+
+func sourceCircle() *draw.Picture {
+	body := draw.NewPicture()
+	circle := mp.Scaled(sourceDiameter).ApplyToPath(mp.FullCircle())
+	circle.Style = symbolStyle
+	body.AddPath(circle)
+	return body
+}
+
+// Ground returns a ground symbol hanging below its anchor "a" at p.
+func Ground(p mp.Point) *Symbol {
+	pic := draw.NewPicture()
+	y := p.Y - groundLead
+	pic.AddPath(straight(p, mp.P(p.X, y)))
+	for i, w := range []float64{groundWidth, groundWidth * 0.6, groundWidth * 0.2} {
+		yy := y - float64(i)*2
+		pic.AddPath(straight(mp.P(p.X-w/2, yy), mp.P(p.X+w/2, yy)))
+	}
+	return &Symbol{Picture: pic, Anchors: map[string]mp.Point{"a": p}}
+}
+
+// Circuit collects symbols and the wires between them.
+type Circuit struct {
+	// SnapDistance is how close a wire end must be to an anchor to be
+	// moved onto it (default 3).
+	SnapDistance float64
+
+	symbols   []*Symbol
+	wires     []*mp.Path
+	junctions []mp.Point
+}
+
+// NewCircuit creates an empty circuit.
+func NewCircuit() *Circuit {
+	return &Circuit{SnapDistance: 3}
+}
+
+// Add adds a symbol to the circuit and returns it.
+func (c *Circuit) Add(s *Symbol) *Symbol {
+	c.symbols = append(c.symbols, s)
+	return s
+}
+
+// snap returns the anchor nearest to p if it is within SnapDistance, else p.
+func (c *Circuit) snap(p mp.Point) mp.Point {
+	best, bestDist := p, c.SnapDistance
+	for _, s := range c.symbols {
+		for _, a := range s.Anchors {
+			if d := math.Hypot(a.X-p.X, a.Y-p.Y); d <= bestDist {
+				best, bestDist = a, d
+			}
+		}
+	}
+	return best
+}
+
+// Wire adds an orthogonal wire through the given points and returns it. The
+// first and last points snap to nearby anchors; between consecutive points
+// the wire runs horizontally first, then vertically. Wires through NaN or
+// infinite points are not added and give nil.
+func (c *Circuit) Wire(pts ...mp.Point) *mp.Path {
+	if len(pts) < 2 {
+		return nil
+	}
+	pts = append([]mp.Point(nil), pts...)
+	pts[0] = c.snap(pts[0])
+	pts[len(pts)-1] = c.snap(pts[len(pts)-1])
+	route := []mp.Point{pts[0]}
+	for _, q := range pts[1:] {
+		p := route[len(route)-1]
+		if p.X != q.X && p.Y != q.Y {
+			route = append(route, mp.P(q.X, p.Y))
+		}
+		if p != q {
+			route = append(route, q)
+		}
+	}
+	if len(route) < 2 {
+		return nil
+	}
+	wire := straight(route...)
+	if wire == nil {
+		return nil
+	}
+	c.wires = append(c.wires, wire)
+	return wire
+}
+
+// Junction marks a connection of crossing wires with a dot.
+func (c *Circuit) Junction(p mp.Point) {
+	c.junctions = append(c.junctions, c.snap(p))
+}
+
+// Picture returns the circuit drawing.
+func (c *Circuit) Picture() *draw.Picture {
+	pic := draw.NewPicture()
+	for _, s := range c.symbols {
+		pic.AddPicture(s.Picture)
+	}
+	for _, w := range c.wires {
+		pic.AddPath(w)
+	}
+	for _, j := range c.junctions {
+		dot := mp.Scaled(junctionSize).Then(mp.Shifted(j.X, j.Y)).ApplyToPath(mp.FullCircle())
+		dot.Style = mp.Style{Fill: symbolStyle.Stroke, Stroke: mp.ColorCSS("none")}
+		pic.AddPath(dot)
+	}
+	return pic
+}
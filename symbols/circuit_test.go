@@ -0,0 +1,66 @@
+package symbols
+
+import (
+	"math"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func TestTwoTerminalPlacement(t *testing.T) {
+	r := Resistor(mp.P(0, 0), mp.P(0, 60), Zigzag)
+	if a := r.Anchor("b"); a != mp.P(0, 60) {
+		t.Errorf("anchor b = %v", a)
+	}
+	// Body plus two leads.
+	paths := r.Picture.Paths()
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 paths, got %d", len(paths))
+	}
+	// The vertical resistor's zigzag spans y = 18..42 and x = ±zigzagAmp.
+	minX, minY, maxX, maxY := mp.PathBBox(paths[0])
+	if math.Abs(minY-18) > 1e-9 || math.Abs(maxY-42) > 1e-9 || math.Abs(maxX-zigzagAmp) > 1e-9 || math.Abs(minX+zigzagAmp) > 1e-9 {
+		t.Errorf("zigzag bbox (%g,%g)-(%g,%g)", minX, minY, maxX, maxY)
+	}
+}
+
+func TestWireSnapsAndRoutesOrthogonally(t *testing.T) {
+	c := NewCircuit()
+	v := c.Add(VoltageSource(mp.P(0, 0), mp.P(0, 40)))
+	r := c.Add(Resistor(mp.P(40, 60), mp.P(100, 60), IEC))
+	w := c.Wire(mp.P(1, 41), mp.P(39, 59))
+	if sx, sy := w.PointOf(0); sx != v.Anchor("b").X || sy != v.Anchor("b").Y {
+		t.Errorf("wire start (%g,%g) not snapped", sx, sy)
+	}
+	if w.PathLength() != 2 {
+		t.Fatalf("expected an L-shaped wire, got %d segments", w.PathLength())
+	}
+	if cx, cy := w.PointOf(1); cx != 40 || cy != 40 {
+		t.Errorf("corner at (%g,%g), want (40,40)", cx, cy)
+	}
+	if ex, ey := w.PointOf(2); ex != r.Anchor("a").X || ey != r.Anchor("a").Y {
+		t.Errorf("wire end (%g,%g) not snapped", ex, ey)
+	}
+	c.Add(Capacitor(mp.P(100, 60), mp.P(100, 0), true))
+	c.Add(Inductor(mp.P(0, 0), mp.P(100, 0), 0))
+	c.Add(CurrentSource(mp.P(120, 0), mp.P(120, 60)))
+	c.Add(Ground(mp.P(0, 0)))
+	c.Junction(mp.P(0, 0))
+	pic := c.Picture()
+	if len(pic.Labels()) != 1 {
+		t.Errorf("expected the capacitor's + label, got %d labels", len(pic.Labels()))
+	}
+	if dot := pic.Paths()[len(pic.Paths())-1]; dot.Style.Stroke.CSS() != "none" {
+		t.Errorf("junction dot stroked with %q", dot.Style.Stroke.CSS())
+	}
+}
+
+func TestWireNotFinite(t *testing.T) {
+	c := NewCircuit()
+	if w := c.Wire(mp.P(0, 0), mp.P(math.NaN(), 10)); w != nil {
+		t.Errorf("expected no wire through a NaN point, got %v", w)
+	}
+	if got := len(c.Picture().Paths()); got != 0 {
+		t.Errorf("expected an empty circuit, got %d paths", got)
+	}
+}
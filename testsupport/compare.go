@@ -0,0 +1,356 @@
+// Package testsupport provides helpers for regression tests of drawings.
+//
+// [ComparePictures] compares two pictures by geometry and style rather than
+// by their SVG output, so tests do not break on formatting changes and
+// failures say which element changed and how.
+//
+// Example:
+//
+//	if d := testsupport.ComparePictures(got, want, 1e-6); len(d) > 0 {
+//		t.Error(d)
+//	}
+package testsupport
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+// Kind classifies a difference between two pictures.
+type Kind int
+
+const (
+	Missing  Kind = iota // element of a has no counterpart in b
+	Extra                // element of b has no counterpart in a
+	Modified             // elements were paired but differ
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Missing:
+		return "missing"
+	case Extra:
+		return "extra"
+	}
+	return "modified"
+}
+
+// Difference is one element that differs between two pictures. IndexA and
+// IndexB are the element's positions among the paths, multi-paths or labels
+// of a and b; -1 where it is absent.
+type Difference struct {
+	Kind    Kind
+	Element string // "path", "multipath" or "label"
+	IndexA  int
+	IndexB  int
+	Details []string
+}
+
+func (d Difference) String() string {
+	var head string
+	switch d.Kind {
+	case Missing:
+		head = fmt.Sprintf("%s a[%d] missing in b", d.Element, d.IndexA)
+	case Extra:
+		head = fmt.Sprintf("%s b[%d] not in a", d.Element, d.IndexB)
+	default:
+		head = fmt.Sprintf("%s a[%d] / b[%d] modified", d.Element, d.IndexA, d.IndexB)
+	}
+	if len(d.Details) == 0 {
+		return head
+	}
+	return head + ":\n\t" + strings.Join(d.Details, "\n\t")
+}
+
+// Diff is the list of differences found by ComparePictures.
+type Diff []Difference
+
+func (d Diff) String() string {
+	lines := make([]string, len(d))
+	for i, diff := range d {
+		lines[i] = diff.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ComparePictures compares the paths, multi-paths and labels of a and b
+// independently of their order and returns the differences, or nil if the
+// pictures agree. Coordinates, control points and numeric style values may
+// differ by up to tol.
+//
+// Elements are first matched exactly (same knots within tol and the same
+// style); the remaining elements are paired by proximity and reported as
+// modified, listing the style fields that differ and the largest knot
+// displacement. Whatever is left is reported as missing or extra.
+func ComparePictures(a, b *draw.Picture, tol float64) Diff {
+	var diff Diff
+	diff = append(diff, compareElements("path", pathElements(a.Paths()), pathElements(b.Paths()), tol)...)
+	diff = append(diff, compareElements("multipath", multiPathElements(a.MultiPaths()), multiPathElements(b.MultiPaths()), tol)...)
+	diff = append(diff, compareElements("label", labelElements(a.Labels()), labelElements(b.Labels()), tol)...)
+	return diff
+}
+
+// element is the comparable form of a path, multi-path or label: a list of
+// coordinates split into parts (knots with their controls, or a label
+// position), and named properties.
+type element struct {
+	parts   [][]mp.Point
+	cyclic  []bool
+	props   []property
+	summary string
+}
+
+type property struct {
+	name  string
+	text  string  // compared exactly
+	num   float64 // compared within tol if isNum is set
+	isNum bool
+}
+
+func text(name, v string) property { return property{name: name, text: v} }
+
+func num(name string, v float64) property { return property{name: name, num: v, isNum: true} }
+
+func pathElements(paths []*mp.Path) []element {
+	els := make([]element, len(paths))
+	for i, p := range paths {
+		pts, cyclic := pathPoints(p)
+		els[i] = element{
+			parts:   [][]mp.Point{pts},
+			cyclic:  []bool{cyclic},
+			props:   styleProps(p.Style),
+			summary: summarize(pts, cyclic),
+		}
+	}
+	return els
+}
+
+func multiPathElements(mps []*mp.MultiPath) []element {
+	els := make([]element, len(mps))
+	for i, m := range mps {
+		var e element
+		var sums []string
+		for _, p := range m.Parts {
+			pts, cyclic := pathPoints(p)
+			e.parts = append(e.parts, pts)
+			e.cyclic = append(e.cyclic, cyclic)
+			sums = append(sums, summarize(pts, cyclic))
+		}
+		e.props = styleProps(m.Style)
+		e.summary = strings.Join(sums, " & ")
+		els[i] = e
+	}
+	return els
+}
+
+func labelElements(labels []*mp.Label) []element {
+	els := make([]element, len(labels))
+	for i, l := range labels {
+		els[i] = element{
+			parts:  [][]mp.Point{{l.Position}},
+			cyclic: []bool{false},
+			props: []property{
+				text("text", l.Text),
+				num("anchor", float64(l.Anchor)),
+				text("color", l.Color.CSS()),
+				num("font size", l.FontSize),
+				text("font family", l.FontFamily),
+				num("label offset", l.LabelOffset),
+			},
+			summary: fmt.Sprintf("%q at (%g,%g)", l.Text, l.Position.X, l.Position.Y),
+		}
+	}
+	return els
+}
+
+// pathPoints returns each knot followed by its outgoing and the next knot's
+// incoming control point, and whether the path is cyclic.
+func pathPoints(p *mp.Path) ([]mp.Point, bool) {
+	if p == nil || p.Head == nil {
+		return nil, false
+	}
+	var pts []mp.Point
+	k := p.Head
+	for {
+		pts = append(pts, mp.P(k.XCoord, k.YCoord))
+		next := k.Next
+		if next == nil || k.RType == mp.KnotEndpoint {
+			return pts, false
+		}
+		pts = append(pts, mp.P(k.RightX, k.RightY), mp.P(next.LeftX, next.LeftY))
+		if next == p.Head {
+			return pts, true
+		}
+		k = next
+	}
+}
+
+func styleProps(s mp.Style) []property {
+	dash := "none"
+	if s.Dash != nil {
+		dash = fmt.Sprintf("%g offset %g", s.Dash.Array, s.Dash.Offset)
+	}
+	pen := "none"
+	if s.Pen != nil {
+		pen = "set"
+	}
+	return []property{
+		text("stroke", s.Stroke.CSS()),
+		text("fill", s.Fill.CSS()),
+		num("stroke width", s.StrokeWidth),
+		text("pen", pen),
+		num("line join", float64(s.LineJoin)),
+		num("line cap", float64(s.LineCap)),
+		text("arrows", fmt.Sprintf("start=%t end=%t", s.Arrow.Start, s.Arrow.End)),
+		num("arrow length", s.Arrow.Length),
+		num("arrow angle", s.Arrow.Angle),
+		text("dash", dash),
+	}
+}
+
+func summarize(pts []mp.Point, cyclic bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(pts); i += 3 {
+		if i > 0 {
+			sb.WriteString("..")
+		}
+		fmt.Fprintf(&sb, "(%g,%g)", pts[i].X, pts[i].Y)
+	}
+	if cyclic {
+		sb.WriteString("..cycle")
+	}
+	return sb.String()
+}
+
+// sameShape reports whether a and b have the same structure and returns the
+// largest point displacement and where it occurs.
+func sameShape(a, b element) (ok bool, maxDev float64, part, index int) {
+	if len(a.parts) != len(b.parts) {
+		return false, 0, 0, 0
+	}
+	for i := range a.parts {
+		if len(a.parts[i]) != len(b.parts[i]) || a.cyclic[i] != b.cyclic[i] {
+			return false, 0, 0, 0
+		}
+		for j, p := range a.parts[i] {
+			q := b.parts[i][j]
+			if d := math.Hypot(p.X-q.X, p.Y-q.Y); d > maxDev {
+				maxDev, part, index = d, i, j
+			}
+		}
+	}
+	return true, maxDev, part, index
+}
+
+func propDiffs(a, b element, tol float64) []string {
+	var diffs []string
+	for i, p := range a.props {
+		q := b.props[i]
+		if p.isNum {
+			if math.Abs(p.num-q.num) > tol {
+				diffs = append(diffs, fmt.Sprintf("%s %g → %g", p.name, p.num, q.num))
+			}
+		} else if p.text != q.text {
+			diffs = append(diffs, fmt.Sprintf("%s %q → %q", p.name, p.text, q.text))
+		}
+	}
+	return diffs
+}
+
+// center returns the mean of all coordinates of e.
+func center(e element) mp.Point {
+	var x, y float64
+	n := 0
+	for _, part := range e.parts {
+		for _, p := range part {
+			x, y, n = x+p.X, y+p.Y, n+1
+		}
+	}
+	if n == 0 {
+		return mp.Point{}
+	}
+	return mp.P(x/float64(n), y/float64(n))
+}
+
+func compareElements(name string, as, bs []element, tol float64) Diff {
+	matchedA := make([]bool, len(as))
+	matchedB := make([]bool, len(bs))
+
+	// Exact matches, preferring the closest candidate.
+	for i, a := range as {
+		best, bestDev := -1, math.Inf(1)
+		for j, b := range bs {
+			if matchedB[j] {
+				continue
+			}
+			ok, dev, _, _ := sameShape(a, b)
+			if ok && dev <= tol && dev < bestDev && len(propDiffs(a, b, tol)) == 0 {
+				best, bestDev = j, dev
+			}
+		}
+		if best >= 0 {
+			matchedA[i], matchedB[best] = true, true
+		}
+	}
+
+	// Pair the rest by proximity, preferring elements of the same shape.
+	var diff Diff
+	for i, a := range as {
+		if matchedA[i] {
+			continue
+		}
+		ca := center(a)
+		best, bestDist := -1, math.Inf(1)
+		for j, b := range bs {
+			if matchedB[j] {
+				continue
+			}
+			cb := center(b)
+			d := math.Hypot(ca.X-cb.X, ca.Y-cb.Y)
+			if ok, _, _, _ := sameShape(a, b); !ok {
+				d += 1e6
+			}
+			if name == "label" && a.props[0].text == b.props[0].text {
+				d -= 1e6
+			}
+			if d < bestDist {
+				best, bestDist = j, d
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		matchedA[i], matchedB[best] = true, true
+		b := bs[best]
+		details := propDiffs(a, b, tol)
+		if ok, dev, part, index := sameShape(a, b); !ok {
+			details = append(details, fmt.Sprintf("shape %s → %s", a.summary, b.summary))
+		} else if dev > tol {
+			p, q := a.parts[part][index], b.parts[part][index]
+			what := "point"
+			if name != "label" {
+				what = "knot"
+				if index%3 != 0 {
+					what = "control point"
+				}
+			}
+			details = append(details, fmt.Sprintf("%s (%g,%g) moved by %g to (%g,%g)", what, p.X, p.Y, dev, q.X, q.Y))
+		}
+		diff = append(diff, Difference{Kind: Modified, Element: name, IndexA: i, IndexB: best, Details: details})
+	}
+	for i, a := range as {
+		if !matchedA[i] {
+			diff = append(diff, Difference{Kind: Missing, Element: name, IndexA: i, IndexB: -1, Details: []string{a.summary}})
+		}
+	}
+	for j, b := range bs {
+		if !matchedB[j] {
+			diff = append(diff, Difference{Kind: Extra, Element: name, IndexA: -1, IndexB: j, Details: []string{b.summary}})
+		}
+	}
+	return diff
+}
@@ -0,0 +1,65 @@
+package testsupport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/boxesandglue/mpgo/draw"
+	"github.com/boxesandglue/mpgo/mp"
+)
+
+func line(a, b mp.Point, color string) *mp.Path {
+	path, _ := draw.NewPath().MoveTo(a).LineTo(b).Solve()
+	path.Style.Stroke = mp.ColorCSS(color)
+	return path
+}
+
+func TestComparePicturesEqualIgnoresOrder(t *testing.T) {
+	a, b := draw.NewPicture(), draw.NewPicture()
+	a.AddPath(line(mp.P(0, 0), mp.P(10, 0), "black")).AddPath(line(mp.P(0, 5), mp.P(10, 5), "red"))
+	a.Label("x", mp.P(1, 2), mp.AnchorTop)
+	b.AddPath(line(mp.P(0, 5), mp.P(10, 5+1e-9), "red")).AddPath(line(mp.P(0, 0), mp.P(10, 0), "black"))
+	b.Label("x", mp.P(1, 2), mp.AnchorTop)
+	if d := ComparePictures(a, b, 1e-6); len(d) > 0 {
+		t.Errorf("unexpected differences:\n%s", d)
+	}
+}
+
+func TestComparePicturesReportsChanges(t *testing.T) {
+	a, b := draw.NewPicture(), draw.NewPicture()
+	a.AddPath(line(mp.P(0, 0), mp.P(10, 0), "black"))
+	a.AddPath(line(mp.P(0, 5), mp.P(10, 5), "black"))
+	a.Label("x", mp.P(1, 2), mp.AnchorTop)
+	b.AddPath(line(mp.P(0, 0), mp.P(10, 0), "blue"))
+	b.Label("x", mp.P(1, 3), mp.AnchorTop)
+	b.Label("y", mp.P(5, 5), mp.AnchorTop)
+
+	d := ComparePictures(a, b, 1e-6)
+	var kinds []string
+	for _, diff := range d {
+		kinds = append(kinds, diff.Element+" "+diff.Kind.String())
+	}
+	want := "path modified,path missing,label modified,label extra"
+	if got := strings.Join(kinds, ","); got != want {
+		t.Fatalf("differences = %s, want %s\n%s", got, want, d)
+	}
+	if s := d[0].String(); !strings.Contains(s, `stroke "black" → "blue"`) {
+		t.Errorf("path diff should name the stroke change:\n%s", s)
+	}
+	if s := d[2].String(); !strings.Contains(s, "moved by 1") {
+		t.Errorf("label diff should name the displacement:\n%s", s)
+	}
+}
+
+func TestComparePicturesKnotMoved(t *testing.T) {
+	a, b := draw.NewPicture(), draw.NewPicture()
+	a.AddPath(line(mp.P(0, 0), mp.P(10, 0), "black"))
+	b.AddPath(line(mp.P(0, 0), mp.P(10, 0.5), "black"))
+	d := ComparePictures(a, b, 0.01)
+	if len(d) != 1 || d[0].Kind != Modified {
+		t.Fatalf("expected one modification, got\n%s", d)
+	}
+	if !strings.Contains(d[0].String(), "knot (10,0) moved by 0.5") {
+		t.Errorf("unexpected description:\n%s", d[0])
+	}
+}